@@ -23,11 +23,41 @@ import (
 	"github.com/spf13/viper"
 )
 
+type AccessPolicyConfig struct {
+	AppendOnly []string `yaml:"append-only"`
+
+	DenyDelete []string `yaml:"deny-delete"`
+
+	DetectRetention bool `yaml:"detect-retention"`
+
+	Hidden []string `yaml:"hidden"`
+
+	ReadOnly []string `yaml:"read-only"`
+
+	WriteOnce bool `yaml:"write-once"`
+
+	WriteOncePaths []string `yaml:"write-once-paths"`
+}
+
+type AuditConfig struct {
+	FilePath ResolvedPath `yaml:"file-path"`
+
+	GcsRequestHeaders bool `yaml:"gcs-request-headers"`
+
+	Syslog bool `yaml:"syslog"`
+}
+
 type Config struct {
+	AccessPolicy AccessPolicyConfig `yaml:"access-policy"`
+
 	AppName string `yaml:"app-name"`
 
+	Audit AuditConfig `yaml:"audit"`
+
 	CacheDir ResolvedPath `yaml:"cache-dir"`
 
+	ControlSocket ResolvedPath `yaml:"control-socket"`
+
 	Debug DebugConfig `yaml:"debug"`
 
 	EnableAtomicRenameObject bool `yaml:"enable-atomic-rename-object"`
@@ -46,8 +76,12 @@ type Config struct {
 
 	GcsRetries GcsRetriesConfig `yaml:"gcs-retries"`
 
+	Health HealthConfig `yaml:"health"`
+
 	ImplicitDirs bool `yaml:"implicit-dirs"`
 
+	Integrity IntegrityConfig `yaml:"integrity"`
+
 	List ListConfig `yaml:"list"`
 
 	Logging LoggingConfig `yaml:"logging"`
@@ -71,9 +105,17 @@ type DebugConfig struct {
 	Gcs bool `yaml:"gcs"`
 
 	LogMutex bool `yaml:"log-mutex"`
+
+	SlowOpDumpInterval time.Duration `yaml:"slow-op-dump-interval"`
+
+	SlowOpThreshold time.Duration `yaml:"slow-op-threshold"`
+
+	TimelineMaxEvents int64 `yaml:"timeline-max-events"`
 }
 
 type FileCacheConfig struct {
+	AccessManifestFile ResolvedPath `yaml:"access-manifest-file"`
+
 	CacheFileForRangeRead bool `yaml:"cache-file-for-range-read"`
 
 	DownloadChunkSizeMb int64 `yaml:"download-chunk-size-mb"`
@@ -90,14 +132,22 @@ type FileCacheConfig struct {
 
 	ParallelDownloadsPerFile int64 `yaml:"parallel-downloads-per-file"`
 
+	PrefetchConcurrency int64 `yaml:"prefetch-concurrency"`
+
 	WriteBufferSize int64 `yaml:"write-buffer-size"`
 }
 
 type FileSystemConfig struct {
+	CheckPermissions bool `yaml:"check-permissions"`
+
 	DirMode Octal `yaml:"dir-mode"`
 
 	DisableParallelDirops bool `yaml:"disable-parallel-dirops"`
 
+	EnableTrash bool `yaml:"enable-trash"`
+
+	EncryptLocalData bool `yaml:"encrypt-local-data"`
+
 	FileMode Octal `yaml:"file-mode"`
 
 	FuseOptions []string `yaml:"fuse-options"`
@@ -116,14 +166,26 @@ type FileSystemConfig struct {
 
 	TempDir ResolvedPath `yaml:"temp-dir"`
 
+	TrashRetentionSecs int64 `yaml:"trash-retention-secs"`
+
 	Uid int64 `yaml:"uid"`
 }
 
 type GcsAuthConfig struct {
 	AnonymousAccess bool `yaml:"anonymous-access"`
 
+	CredentialProcess string `yaml:"credential-process"`
+
+	ImpersonateDelegates []string `yaml:"impersonate-delegates"`
+
+	ImpersonateServiceAccount string `yaml:"impersonate-service-account"`
+
 	KeyFile ResolvedPath `yaml:"key-file"`
 
+	PerUserKeyFileDir ResolvedPath `yaml:"per-user-key-file-dir"`
+
+	PerUserTokenUrl string `yaml:"per-user-token-url"`
+
 	ReuseTokenFromUrl bool `yaml:"reuse-token-from-url"`
 
 	TokenUrl string `yaml:"token-url"`
@@ -165,6 +227,30 @@ type GcsRetriesConfig struct {
 	ReadStall ReadStallGcsRetriesConfig `yaml:"read-stall"`
 }
 
+type HealthConfig struct {
+	Address string `yaml:"address"`
+
+	BucketProbeInterval time.Duration `yaml:"bucket-probe-interval"`
+
+	MaxErrorRate float64 `yaml:"max-error-rate"`
+
+	MaxStallRate float64 `yaml:"max-stall-rate"`
+
+	Port int64 `yaml:"port"`
+
+	ProbeTimeout time.Duration `yaml:"probe-timeout"`
+
+	StallThreshold time.Duration `yaml:"stall-threshold"`
+}
+
+type IntegrityConfig struct {
+	Manifest ResolvedPath `yaml:"manifest"`
+
+	PublicKey ResolvedPath `yaml:"public-key"`
+
+	Signature ResolvedPath `yaml:"signature"`
+}
+
 type ListConfig struct {
 	EnableEmptyManagedFolders bool `yaml:"enable-empty-managed-folders"`
 }
@@ -178,12 +264,18 @@ type LogRotateLoggingConfig struct {
 }
 
 type LoggingConfig struct {
+	ComponentSeverity []string `yaml:"component-severity"`
+
 	FilePath ResolvedPath `yaml:"file-path"`
 
 	Format string `yaml:"format"`
 
 	LogRotate LogRotateLoggingConfig `yaml:"log-rotate"`
 
+	Output string `yaml:"output"`
+
+	Sampling SamplingLoggingConfig `yaml:"sampling"`
+
 	Severity LogSeverity `yaml:"severity"`
 }
 
@@ -208,8 +300,14 @@ type MetadataCacheConfig struct {
 }
 
 type MetricsConfig struct {
+	AttributionMaxKeys int64 `yaml:"attribution-max-keys"`
+
+	AttributionPathDepth int64 `yaml:"attribution-path-depth"`
+
 	CloudMetricsExportIntervalSecs int64 `yaml:"cloud-metrics-export-interval-secs"`
 
+	EnableAttribution bool `yaml:"enable-attribution"`
+
 	EnableOtel bool `yaml:"enable-otel"`
 
 	PrometheusPort int64 `yaml:"prometheus-port"`
@@ -220,6 +318,10 @@ type MetricsConfig struct {
 type MonitoringConfig struct {
 	ExperimentalOpentelemetryCollectorAddress string `yaml:"experimental-opentelemetry-collector-address"`
 
+	ExperimentalTracingEndpoint string `yaml:"experimental-tracing-endpoint"`
+
+	ExperimentalTracingFile ResolvedPath `yaml:"experimental-tracing-file"`
+
 	ExperimentalTracingMode string `yaml:"experimental-tracing-mode"`
 
 	ExperimentalTracingSamplingRatio float64 `yaml:"experimental-tracing-sampling-ratio"`
@@ -239,6 +341,10 @@ type ReadStallGcsRetriesConfig struct {
 	ReqTargetPercentile float64 `yaml:"req-target-percentile"`
 }
 
+type SamplingLoggingConfig struct {
+	MaxPerSecond int64 `yaml:"max-per-second"`
+}
+
 type WriteConfig struct {
 	BlockSizeMb int64 `yaml:"block-size-mb"`
 
@@ -257,10 +363,20 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.StringP("app-name", "", "", "The application name of this mount.")
 
+	flagSet.StringSliceP("append-only-paths", "", []string{}, "Glob patterns of object names, relative to the mount root, that are append-only: new files can be created and files opened with O_APPEND, but existing files can't be overwritten, truncated, renamed or deleted. \"*\" matches within a path component and \"**\" matches any number of them. A pattern matching a directory applies to everything under it.")
+
+	flagSet.BoolP("audit-gcs-request-headers", "", false, "Send the PID and UID of the process that caused each GCS request as x-goog-custom-audit-gcsfuse-pid and x-goog-custom-audit-gcsfuse-uid headers, so that Cloud Storage data access logs can be correlated with local processes.")
+
+	flagSet.StringP("audit-log-file", "", "", "Path to a file that a JSON record of every file system mutation (create, mkdir, symlink, write commit, setattr, rename, unlink and rmdir) is appended to. Each record carries the PID, UID and GID of the calling process, the object name, the resulting generation, the outcome and the latency.")
+
+	flagSet.BoolP("audit-syslog", "", false, "Write the audit records described for audit-log-file to syslog, with the authpriv facility and the tag gcsfuse-audit.")
+
 	flagSet.StringP("billing-project", "", "", "Project to use for billing when accessing a bucket enabled with \"Requester Pays\". (The default is none)")
 
 	flagSet.StringP("cache-dir", "", "", "Enables file-caching. Specifies the directory to use for file-cache.")
 
+	flagSet.BoolP("check-permissions", "", false, "Checks the permissions granted on the bucket when it is mounted. Ops that the credentials aren't permitted to perform then fail with EACCES up front, and a bucket on which only storage.objects.get and storage.objects.list are granted is served read-only. Not done with per-user credentials.")
+
 	flagSet.IntP("chunk-transfer-timeout-secs", "", 10, "We send larger file uploads in 16 MiB chunks. This flag controls the duration  that the HTTP client will wait for a response after making a request to upload a chunk.  The default value of 10s indicates that the client will wait 10 seconds for upload completion;  otherwise, it cancels the request and retries for that chunk till chunkRetryDeadline(32s). 0 means no timeout.")
 
 	if err := flagSet.MarkHidden("chunk-transfer-timeout-secs"); err != nil {
@@ -271,10 +387,20 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.IntP("cloud-metrics-export-interval-secs", "", 0, "Specifies the interval at which the metrics are uploaded to cloud monitoring")
 
+	flagSet.StringP("control-socket", "", "", "Path to a Unix socket to serve the admin API of the mount on, for use with \"gcsfuse ctl\". It can show the effective config and internal counts, invalidate cache entries, change the log severity, capture profiles and drain and unmount the file system. It's only accessible to the user running gcsfuse. The admin API is disabled if unset.")
+
 	flagSet.BoolP("create-empty-file", "", false, "For a new file, it creates an empty file in Cloud Storage bucket as a hold.")
 
+	flagSet.StringP("credential-process", "", "", "A command, run with /bin/sh, that prints a JSON access token, e.g. {\"access_token\": \"...\", \"expiry\": \"2025-01-02T03:04:05Z\"} or with \"expires_in\" in seconds. Used when key-file and token-url are absent. The token is cached and the command is run again shortly before it expires.")
+
 	flagSet.StringP("custom-endpoint", "", "", "Specifies an alternative custom endpoint for fetching data. Should only be used for testing.  The custom endpoint must support the equivalent resources and operations as the GCS  JSON endpoint, https://storage.googleapis.com/storage/v1. If a custom endpoint is not specified,  GCSFuse uses the global GCS JSON API endpoint, https://storage.googleapis.com/storage/v1.")
 
+	flagSet.DurationP("debug-slow-op-dump-interval", "", 60000000000*time.Nanosecond, "The minimum time between two diagnostic dumps of slow file system ops. Ops that become slow in between are reported by the next dump.")
+
+	flagSet.DurationP("debug-slow-op-threshold", "", 0*time.Nanosecond, "Log a diagnostic dump when a file system op takes longer than this, with the op, its path, the GCS requests in progress for it, the file system's locks held and the stacks of the goroutines running gcsfuse code. The default value 0 disables the dumps.")
+
+	flagSet.IntP("debug-timeline-max-events", "", 0, "Record when file system ops, GCS requests and file cache downloads begin and end, keeping this many of the most recent ones in memory, to be written as a Chrome trace by \"gcsfuse ctl timeline\". The default value 0 disables the recording.")
+
 	flagSet.BoolP("debug_fs", "", false, "This flag is unused.")
 
 	if err := flagSet.MarkDeprecated("debug_fs", "This flag is currently unused."); err != nil {
@@ -309,6 +435,10 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.BoolP("debug_mutex", "", false, "Print debug messages when a mutex is held too long.")
 
+	flagSet.StringSliceP("deny-delete-paths", "", []string{}, "Glob patterns of object names, relative to the mount root, that can't be deleted or renamed, e.g. to guard a shared dataset against an accidental rm -rf. See append-only-paths for the pattern syntax.")
+
+	flagSet.BoolP("detect-object-retention", "", false, "Check whether objects are under an event-based or temporary hold or a retention policy, as of their cached metadata, and report those that are as read-only, failing writes and truncation with EPERM rather than at sync time.")
+
 	flagSet.StringP("dir-mode", "", "0755", "Permissions bits for directories, in octal.")
 
 	flagSet.BoolP("disable-parallel-dirops", "", false, "Specifies whether to allow parallel dir operations (lookups and readers)")
@@ -355,6 +485,10 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	flagSet.BoolP("enable-trash", "", false, "Instead of deleting the object behind a removed file, move it to .trash/<timestamp>/<original path> in the bucket. Trashed files can be listed under the .trash directory at the root of the mount and restored by renaming them back. Removing a file under .trash deletes it for good. Directories can only be removed once empty, and are deleted directly.")
+
+	flagSet.BoolP("encrypt-local-data", "", false, "Encrypt the object data staged on the local disk, in the file cache and in temp files, with a key that is generated at mount and held only in memory. Encrypted file cache files are removed at unmount, and O_DIRECT isn't used to write them.")
+
 	flagSet.BoolP("experimental-enable-json-read", "", false, "By default, GCSFuse uses the GCS XML API to get and read objects. When this flag is specified, GCSFuse uses the GCS JSON API instead.\"")
 
 	if err := flagSet.MarkDeprecated("experimental-enable-json-read", "Experimental flag: could be dropped even in a minor release."); err != nil {
@@ -379,7 +513,19 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
-	flagSet.StringP("experimental-tracing-mode", "", "", "Experimental: specify tracing mode")
+	flagSet.StringP("experimental-tracing-endpoint", "", "", "Experimental: host:port of the OTLP/HTTP collector that spans are sent to in the otlp tracing mode, e.g. a local collector or Jaeger. Defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable or localhost:4318.")
+
+	if err := flagSet.MarkHidden("experimental-tracing-endpoint"); err != nil {
+		return err
+	}
+
+	flagSet.StringP("experimental-tracing-file", "", "", "Experimental: file that spans are written to as JSON lines in the file tracing mode.")
+
+	if err := flagSet.MarkHidden("experimental-tracing-file"); err != nil {
+		return err
+	}
+
+	flagSet.StringP("experimental-tracing-mode", "", "", "Experimental: specify tracing mode. One of stdout, file, otlp or gcptrace.")
 
 	if err := flagSet.MarkHidden("experimental-tracing-mode"); err != nil {
 		return err
@@ -391,6 +537,8 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	flagSet.StringP("file-cache-access-manifest-file", "", "", "Path to a local file that the reads of objects are recorded to, compacted per object in the order they were first read. At the next mount, the file cache is warmed from it in that order, so that a workload that reads the same objects across runs, like training epochs, doesn't start cold. Requires the file cache.")
+
 	flagSet.BoolP("file-cache-cache-file-for-range-read", "", false, "Whether to cache file for range reads.")
 
 	flagSet.IntP("file-cache-download-chunk-size-mb", "", 50, "Size of chunks in MiB that each concurrent request downloads.")
@@ -415,6 +563,8 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.IntP("file-cache-parallel-downloads-per-file", "", 16, "Number of concurrent download requests per file.")
 
+	flagSet.IntP("file-cache-prefetch-concurrency", "", 4, "Number of objects from file-cache.access-manifest-file that are downloaded into the file cache at once at mount. 0 only records reads, without warming the cache.")
+
 	flagSet.IntP("file-cache-write-buffer-size", "", 4194304, "Size of in-memory buffer that is used per goroutine in parallel downloads while writing to file-cache.")
 
 	if err := flagSet.MarkHidden("file-cache-write-buffer-size"); err != nil {
@@ -433,12 +583,38 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	flagSet.StringP("health-address", "", "127.0.0.1", "The address to serve the liveness and readiness of the mount on, with health-port. Use an empty address to serve them on all interfaces.")
+
+	flagSet.DurationP("health-bucket-probe-interval", "", 30000000000*time.Nanosecond, "How long a successful probe of a mounted bucket keeps the mount ready. A readiness check older than this lists the bucket again.")
+
+	flagSet.Float64P("health-max-error-rate", "", 0.1, "The fraction of the file system ops in the last minute that may fail with I/O errors before the mount is no longer ready.")
+
+	flagSet.Float64P("health-max-stall-rate", "", 0.1, "The fraction of the file system ops in the last minute that may take longer than health-stall-threshold before the mount is no longer ready.")
+
+	flagSet.IntP("health-port", "", 0, "Serve the liveness and readiness of the mount at /livez and /readyz on this port, e.g. for the probes of a sidecar container. 0 disables it.")
+
+	flagSet.DurationP("health-probe-timeout", "", 5000000000*time.Nanosecond, "How long the liveness check waits for a statfs of the mount point, and the readiness check for the listing of a bucket.")
+
+	flagSet.DurationP("health-stall-threshold", "", 10000000000*time.Nanosecond, "File system ops that take longer than this count as stalled for the readiness check.")
+
+	flagSet.StringSliceP("hidden-paths", "", []string{}, "Glob patterns of object names, relative to the mount root, that are hidden from listings and lookups and can't be created, e.g. \"**/.secret*\". See append-only-paths for the pattern syntax.")
+
 	flagSet.DurationP("http-client-timeout", "", 0*time.Nanosecond, "The time duration that http client will wait to get response from the server. The default value 0 indicates no timeout.")
 
 	flagSet.BoolP("ignore-interrupts", "", true, "Instructs gcsfuse to ignore system interrupt signals (like SIGINT, triggered by Ctrl+C). This prevents those signals from immediately terminating gcsfuse inflight operations. (default: true)")
 
+	flagSet.StringSliceP("impersonate-delegates", "", []string{}, "Comma separated delegation chain of service accounts to use when impersonating impersonate-service-account. Each service account must be granted roles/iam.serviceAccountTokenCreator on the next one in the chain.")
+
+	flagSet.StringP("impersonate-service-account", "", "", "Email of a service account to impersonate. The credentials from key-file, token-url or the application default credentials are exchanged for a short-lived token of this service account.")
+
 	flagSet.BoolP("implicit-dirs", "", false, "Implicitly define directories based on content. See files and directories in docs/semantics for more information")
 
+	flagSet.StringP("integrity-manifest", "", "", "Path to a JSON manifest mapping object names to the SHA-256 and/or CRC32C of their contents, e.g. {\"objects\": {\"model/weights.bin\": {\"sha256\": \"<hex>\", \"crc32c\": 1234}}}. The manifest is verified against integrity-manifest-signature at mount. Objects listed in it are read in full and checked before any of their data is served, and reads of objects that don't match fail with EIO. Objects not listed are served as usual.")
+
+	flagSet.StringP("integrity-manifest-signature", "", "", "Path to the detached Ed25519 signature of integrity-manifest, either raw or base64-encoded.")
+
+	flagSet.StringP("integrity-public-key", "", "", "Path to the PEM-encoded Ed25519 public key that integrity-manifest is signed with.")
+
 	flagSet.IntP("kernel-list-cache-ttl-secs", "", 0, "How long the directory listing (output of ls <dir>) should be cached in the kernel page cache. If a particular directory cache entry is kept by kernel for longer than TTL, then it will be sent for invalidation by gcsfuse on next opendir (comes in the start, as part of next listing) call. 0 means no caching. Use -1 to cache for lifetime (no ttl). Negative value other than -1 will throw error.")
 
 	flagSet.StringP("key-file", "", "", "Absolute path to JSON key file for use with GCS. (The default is none, Google application default credentials used)")
@@ -447,16 +623,22 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.Float64P("limit-ops-per-sec", "", -1, "Operations per second limit, measured over a 30-second window (use -1 for no limit)")
 
+	flagSet.StringSliceP("log-component-severity", "", []string{}, "Severities of the messages of single components, as component=severity pairs that override log-severity for that component, e.g. file-cache=trace,fuse=warning. The components are fuse, gcs, file-cache, metadata-cache, writes and auth.")
+
 	flagSet.StringP("log-file", "", "", "The file for storing logs that can be parsed by fluentd. When not provided, plain text logs are printed to stdout when Cloud Storage FUSE is run  in the foreground, or to syslog when Cloud Storage FUSE is run in the  background.")
 
 	flagSet.StringP("log-format", "", "json", "The format of the log file: 'text' or 'json'.")
 
+	flagSet.StringP("log-output", "", "auto", "Where logs are written: 'auto' writes them to log-file if set, otherwise to stdout in the foreground or syslog in the background; 'syslog' writes them to syslog with the priority of each message's severity; 'journald' writes them to the systemd journal, with the fields of each message as journal fields.")
+
 	flagSet.IntP("log-rotate-backup-file-count", "", 10, "The maximum number of backup log files to retain after they have been rotated. The default value is 10. When value is set to 0, all backup files are retained.")
 
 	flagSet.BoolP("log-rotate-compress", "", true, "Controls whether the rotated log files should be compressed using gzip.")
 
 	flagSet.IntP("log-rotate-max-file-size-mb", "", 512, "The maximum size in megabytes that a log file can reach before it is rotated.")
 
+	flagSet.IntP("log-sampling-max-per-second", "", 0, "The number of trace and debug messages per second that each component, and the rest of Cloud Storage FUSE, logs at most. Further messages in the same second are dropped, and counted in the \"dropped\" field of the next message logged. 0 logs all messages.")
+
 	flagSet.StringP("log-severity", "", "info", "Specifies the logging severity expressed as one of [trace, debug, info, warning, error, off]")
 
 	flagSet.IntP("max-conns-per-host", "", 0, "The max number of TCP connections allowed per server. This is effective when client-protocol is set to 'http1'. The default value 0 indicates no limit on TCP connections (limited by the machine specifications).")
@@ -481,10 +663,20 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.IntP("metadata-cache-ttl-secs", "", 60, "The ttl value in seconds to be used for expiring items in metadata-cache. It can be set to -1 for no-ttl, 0 for no cache and > 0 for ttl-controlled metadata-cache. Any value set below -1 will throw an error.")
 
+	flagSet.IntP("metrics-attribution-max-keys", "", 100, "The maximum number of caller and path prefix pairs that usage is attributed to. The usage of the pairs beyond it is attributed to a single \"other\" pair.")
+
+	flagSet.IntP("metrics-attribution-path-depth", "", 1, "The number of leading directories of the paths of file system ops that usage is attributed to. 0 attributes all the usage of a caller to the root.")
+
+	flagSet.BoolP("metrics-enable-attribution", "", false, "Attributes file system ops, the bytes they read and write and the GCS requests they make to the cgroup or command of the calling process and to the prefix of their path. The usage is exported as metrics and reported by \"gcsfuse ctl top\".")
+
 	flagSet.StringSliceP("o", "", []string{}, "Additional system-specific mount options. Multiple options can be passed as comma separated. For readonly, use --o ro")
 
 	flagSet.StringP("only-dir", "", "", "Mount only a specific directory within the bucket. See docs/mounting for more information")
 
+	flagSet.StringP("per-user-key-file-dir", "", "", "Enables per-user credentials: requests are made with the JSON key file <uid>.json in this directory, where uid is the UID of the calling process. Requests from root use the mount's own credentials. Useful with allow_other on shared machines.")
+
+	flagSet.StringP("per-user-token-url", "", "", "Enables per-user credentials: requests are made with access tokens from this url, with {uid} replaced by the UID of the calling process. Requests from root use the mount's own credentials.")
+
 	flagSet.BoolP("precondition-errors", "", true, "Throw Stale NFS file handle error in case the object being synced or read  from is modified by some other concurrent process. This helps prevent  silent data loss or data corruption.")
 
 	if err := flagSet.MarkHidden("precondition-errors"); err != nil {
@@ -497,6 +689,8 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	flagSet.StringSliceP("read-only-paths", "", []string{}, "Glob patterns of object names, relative to the mount root, that are read-only: ops that would modify them fail with EROFS. See append-only-paths for the pattern syntax.")
+
 	flagSet.DurationP("read-stall-initial-req-timeout", "", 20000000000*time.Nanosecond, "Initial value of the read-request dynamic timeout.")
 
 	if err := flagSet.MarkHidden("read-stall-initial-req-timeout"); err != nil {
@@ -559,6 +753,8 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 
 	flagSet.StringP("token-url", "", "", "A url for getting an access token when the key-file is absent.")
 
+	flagSet.IntP("trash-retention-secs", "", 604800, "How long files moved to the trash with --enable-trash are kept before being garbage collected. Use -1 to keep them until removed by hand. Negative value other than -1 will throw error.")
+
 	flagSet.IntP("type-cache-max-size-mb", "", 4, "Max size of type-cache maps which are maintained at a per-directory level.")
 
 	flagSet.DurationP("type-cache-ttl", "", 60000000000*time.Nanosecond, "Usage: How long to cache StatObject results and inode attributes. This flag has been deprecated (starting v2.0) in favor of metadata-cache-ttl-secs. For now, the minimum of stat-cache-ttl and type-cache-ttl values, rounded up to the next higher multiple of a second is used as ttl for both stat-cache and type-cache, when metadata-cache-ttl-secs is not set.")
@@ -587,6 +783,10 @@ func BuildFlagSet(flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	flagSet.BoolP("write-once", "", false, "Make the whole mount write-once, as for write-once-paths.")
+
+	flagSet.StringSliceP("write-once-paths", "", []string{}, "Glob patterns of object names, relative to the mount root, that are write-once: new files are only ever created with an IfGenerationMatch=0 precondition, and once a file has been synced to GCS it can't be written, truncated, renamed or deleted, failing with EPERM. See append-only-paths for the pattern syntax.")
+
 	return nil
 }
 
@@ -600,6 +800,22 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("access-policy.append-only", flagSet.Lookup("append-only-paths")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("audit.gcs-request-headers", flagSet.Lookup("audit-gcs-request-headers")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("audit.file-path", flagSet.Lookup("audit-log-file")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("audit.syslog", flagSet.Lookup("audit-syslog")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-connection.billing-project", flagSet.Lookup("billing-project")); err != nil {
 		return err
 	}
@@ -608,6 +824,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("file-system.check-permissions", flagSet.Lookup("check-permissions")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-retries.chunk-transfer-timeout-secs", flagSet.Lookup("chunk-transfer-timeout-secs")); err != nil {
 		return err
 	}
@@ -620,14 +840,34 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("control-socket", flagSet.Lookup("control-socket")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("write.create-empty-file", flagSet.Lookup("create-empty-file")); err != nil {
 		return err
 	}
 
+	if err := v.BindPFlag("gcs-auth.credential-process", flagSet.Lookup("credential-process")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-connection.custom-endpoint", flagSet.Lookup("custom-endpoint")); err != nil {
 		return err
 	}
 
+	if err := v.BindPFlag("debug.slow-op-dump-interval", flagSet.Lookup("debug-slow-op-dump-interval")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("debug.slow-op-threshold", flagSet.Lookup("debug-slow-op-threshold")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("debug.timeline-max-events", flagSet.Lookup("debug-timeline-max-events")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("debug.fuse", flagSet.Lookup("debug_fuse")); err != nil {
 		return err
 	}
@@ -644,6 +884,14 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("access-policy.deny-delete", flagSet.Lookup("deny-delete-paths")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("access-policy.detect-retention", flagSet.Lookup("detect-object-retention")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-system.dir-mode", flagSet.Lookup("dir-mode")); err != nil {
 		return err
 	}
@@ -680,6 +928,14 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("file-system.enable-trash", flagSet.Lookup("enable-trash")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("file-system.encrypt-local-data", flagSet.Lookup("encrypt-local-data")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-connection.experimental-enable-json-read", flagSet.Lookup("experimental-enable-json-read")); err != nil {
 		return err
 	}
@@ -696,6 +952,14 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("monitoring.experimental-tracing-endpoint", flagSet.Lookup("experimental-tracing-endpoint")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("monitoring.experimental-tracing-file", flagSet.Lookup("experimental-tracing-file")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("monitoring.experimental-tracing-mode", flagSet.Lookup("experimental-tracing-mode")); err != nil {
 		return err
 	}
@@ -704,6 +968,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("file-cache.access-manifest-file", flagSet.Lookup("file-cache-access-manifest-file")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-cache.cache-file-for-range-read", flagSet.Lookup("file-cache-cache-file-for-range-read")); err != nil {
 		return err
 	}
@@ -736,6 +1004,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("file-cache.prefetch-concurrency", flagSet.Lookup("file-cache-prefetch-concurrency")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-cache.write-buffer-size", flagSet.Lookup("file-cache-write-buffer-size")); err != nil {
 		return err
 	}
@@ -756,6 +1028,38 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("health.address", flagSet.Lookup("health-address")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.bucket-probe-interval", flagSet.Lookup("health-bucket-probe-interval")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.max-error-rate", flagSet.Lookup("health-max-error-rate")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.max-stall-rate", flagSet.Lookup("health-max-stall-rate")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.port", flagSet.Lookup("health-port")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.probe-timeout", flagSet.Lookup("health-probe-timeout")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("health.stall-threshold", flagSet.Lookup("health-stall-threshold")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("access-policy.hidden", flagSet.Lookup("hidden-paths")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-connection.http-client-timeout", flagSet.Lookup("http-client-timeout")); err != nil {
 		return err
 	}
@@ -764,10 +1068,30 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("gcs-auth.impersonate-delegates", flagSet.Lookup("impersonate-delegates")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("gcs-auth.impersonate-service-account", flagSet.Lookup("impersonate-service-account")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("implicit-dirs", flagSet.Lookup("implicit-dirs")); err != nil {
 		return err
 	}
 
+	if err := v.BindPFlag("integrity.manifest", flagSet.Lookup("integrity-manifest")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("integrity.signature", flagSet.Lookup("integrity-manifest-signature")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("integrity.public-key", flagSet.Lookup("integrity-public-key")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-system.kernel-list-cache-ttl-secs", flagSet.Lookup("kernel-list-cache-ttl-secs")); err != nil {
 		return err
 	}
@@ -784,6 +1108,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("logging.component-severity", flagSet.Lookup("log-component-severity")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("logging.file-path", flagSet.Lookup("log-file")); err != nil {
 		return err
 	}
@@ -792,6 +1120,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("logging.output", flagSet.Lookup("log-output")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("logging.log-rotate.backup-file-count", flagSet.Lookup("log-rotate-backup-file-count")); err != nil {
 		return err
 	}
@@ -804,6 +1136,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("logging.sampling.max-per-second", flagSet.Lookup("log-sampling-max-per-second")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("logging.severity", flagSet.Lookup("log-severity")); err != nil {
 		return err
 	}
@@ -832,6 +1168,18 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("metrics.attribution-max-keys", flagSet.Lookup("metrics-attribution-max-keys")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("metrics.attribution-path-depth", flagSet.Lookup("metrics-attribution-path-depth")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("metrics.enable-attribution", flagSet.Lookup("metrics-enable-attribution")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-system.fuse-options", flagSet.Lookup("o")); err != nil {
 		return err
 	}
@@ -840,6 +1188,14 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("gcs-auth.per-user-key-file-dir", flagSet.Lookup("per-user-key-file-dir")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("gcs-auth.per-user-token-url", flagSet.Lookup("per-user-token-url")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("file-system.precondition-errors", flagSet.Lookup("precondition-errors")); err != nil {
 		return err
 	}
@@ -848,6 +1204,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("access-policy.read-only", flagSet.Lookup("read-only-paths")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("gcs-retries.read-stall.initial-req-timeout", flagSet.Lookup("read-stall-initial-req-timeout")); err != nil {
 		return err
 	}
@@ -908,6 +1268,10 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("file-system.trash-retention-secs", flagSet.Lookup("trash-retention-secs")); err != nil {
+		return err
+	}
+
 	if err := v.BindPFlag("metadata-cache.type-cache-max-size-mb", flagSet.Lookup("type-cache-max-size-mb")); err != nil {
 		return err
 	}
@@ -932,5 +1296,13 @@ func BindFlags(v *viper.Viper, flagSet *pflag.FlagSet) error {
 		return err
 	}
 
+	if err := v.BindPFlag("access-policy.write-once", flagSet.Lookup("write-once")); err != nil {
+		return err
+	}
+
+	if err := v.BindPFlag("access-policy.write-once-paths", flagSet.Lookup("write-once-paths")); err != nil {
+		return err
+	}
+
 	return nil
 }
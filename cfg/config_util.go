@@ -16,7 +16,10 @@ package cfg
 
 import (
 	"fmt"
+	"reflect"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -24,6 +27,27 @@ func DefaultMaxParallelDownloads() int {
 	return max(16, 2*runtime.NumCPU())
 }
 
+// ParseComponentSeverities parses the component=severity pairs of
+// logging.component-severity into a map from component to severity.
+func ParseComponentSeverities(pairs []string) (map[string]LogSeverity, error) {
+	severities := make(map[string]LogSeverity, len(pairs))
+	for _, pair := range pairs {
+		component, severity, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q isn't of the form component=severity", pair)
+		}
+		if !slices.Contains(LogComponents, component) {
+			return nil, fmt.Errorf("unknown log component %q; supported components: %v", component, LogComponents)
+		}
+		var level LogSeverity
+		if err := level.UnmarshalText([]byte(severity)); err != nil {
+			return nil, err
+		}
+		severities[component] = level
+	}
+	return severities, nil
+}
+
 func IsFileCacheEnabled(mountConfig *Config) bool {
 	return mountConfig.FileCache.MaxSizeMb != 0 && string(mountConfig.CacheDir) != ""
 }
@@ -37,6 +61,29 @@ func IsTracingEnabled(mountConfig *Config) bool {
 	return mountConfig.Monitoring.ExperimentalTracingMode != ""
 }
 
+// IsPerUserCredentialsEnabled returns true if requests are made with the
+// credentials of the calling user.
+func IsPerUserCredentialsEnabled(mountConfig *Config) bool {
+	return mountConfig.GcsAuth.PerUserKeyFileDir != "" || mountConfig.GcsAuth.PerUserTokenUrl != ""
+}
+
+// IsReadOnly returns true if the mount is read-only, i.e. "ro" is the last of
+// the "ro" and "rw" options passed via -o.
+func IsReadOnly(mountConfig *Config) bool {
+	readOnly := false
+	for _, opts := range mountConfig.FileSystem.FuseOptions {
+		for _, o := range strings.Split(opts, ",") {
+			switch strings.TrimSpace(o) {
+			case "ro":
+				readOnly = true
+			case "rw":
+				readOnly = false
+			}
+		}
+	}
+	return readOnly
+}
+
 // ListCacheTTLSecsToDuration converts TTL in seconds to time.Duration.
 func ListCacheTTLSecsToDuration(secs int64) time.Duration {
 	err := isTTLInSecsValid(secs)
@@ -55,3 +102,23 @@ func ListCacheTTLSecsToDuration(secs int64) time.Duration {
 func IsMetricsEnabled(c *MetricsConfig) bool {
 	return c.CloudMetricsExportIntervalSecs > 0 || c.PrometheusPort > 0
 }
+
+// Diff returns the config paths, e.g. "metadata-cache.ttl-secs", of the
+// fields that differ between the given configs, in the order they're declared.
+func Diff(a, b *Config) []string {
+	return diffStructs(reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem(), "")
+}
+
+func diffStructs(a, b reflect.Value, prefix string) (paths []string) {
+	for i := 0; i < a.NumField(); i++ {
+		path := prefix + a.Type().Field(i).Tag.Get("yaml")
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			paths = append(paths, diffStructs(fa, fb, path+".")...)
+		} else if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			paths = append(paths, path)
+		}
+	}
+
+	return
+}
@@ -25,6 +25,41 @@ func Test_DefaultMaxParallelDownloads(t *testing.T) {
 	assert.GreaterOrEqual(t, DefaultMaxParallelDownloads(), 16)
 }
 
+func TestParseComponentSeverities(t *testing.T) {
+	severities, err := ParseComponentSeverities([]string{"file-cache=trace", "fuse=Warning"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]LogSeverity{"file-cache": "TRACE", "fuse": "WARNING"}, severities)
+}
+
+func TestParseComponentSeverities_Errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		pairs []string
+	}{
+		{
+			name:  "no_severity",
+			pairs: []string{"gcs"},
+		},
+		{
+			name:  "unknown_component",
+			pairs: []string{"kernel=trace"},
+		},
+		{
+			name:  "unknown_severity",
+			pairs: []string{"gcs=verbose"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseComponentSeverities(tc.pairs)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestIsFileCacheEnabled(t *testing.T) {
 	testCases := []struct {
 		name                       string
@@ -203,3 +238,47 @@ func TestIsMetricsEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestIsReadOnly(t *testing.T) {
+	t.Parallel()
+	var testCases = []struct {
+		testName    string
+		fuseOptions []string
+		expected    bool
+	}{
+		{"none", nil, false},
+		{"ro", []string{"ro"}, true},
+		{"comma_separated", []string{"allow_other, ro"}, true},
+		{"rw_after_ro", []string{"ro", "rw"}, false},
+		{"ro_after_rw", []string{"rw,ro"}, true},
+		{"prefix_only", []string{"root"}, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.testName, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, IsReadOnly(&Config{FileSystem: FileSystemConfig{
+				FuseOptions: tc.fuseOptions,
+			}}))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := &Config{
+		AppName:       "app",
+		MetadataCache: MetadataCacheConfig{TtlSecs: 60},
+		FileSystem:    FileSystemConfig{FuseOptions: []string{"ro"}},
+	}
+	b := &Config{
+		AppName:       "app",
+		MetadataCache: MetadataCacheConfig{TtlSecs: 30},
+		FileSystem:    FileSystemConfig{FuseOptions: []string{"rw"}},
+		OnlyDir:       "dir",
+	}
+
+	assert.Equal(t, []string{"file-system.fuse-options", "metadata-cache.ttl-secs", "only-dir"}, Diff(a, b))
+	assert.Empty(t, Diff(a, a))
+}
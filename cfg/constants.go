@@ -32,6 +32,18 @@ const (
 	OFF     string = "OFF"
 )
 
+const (
+	// Logging-output constants
+
+	LogOutputAuto     string = "auto"
+	LogOutputSyslog   string = "syslog"
+	LogOutputJournald string = "journald"
+)
+
+// LogComponents are the components whose severity can be set with
+// logging.component-severity.
+var LogComponents = []string{"fuse", "gcs", "file-cache", "metadata-cache", "writes", "auth"}
+
 const (
 	// ExperimentalMetadataPrefetchOnMountDisabled is the mode without metadata-prefetch.
 	ExperimentalMetadataPrefetchOnMountDisabled = "disabled"
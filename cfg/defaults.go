@@ -21,6 +21,7 @@ func DefaultLoggingConfig() LoggingConfig {
 	return LoggingConfig{
 		Severity: "INFO",
 		Format:   "json",
+		Output:   LogOutputAuto,
 		LogRotate: LogRotateLoggingConfig{
 			BackupFileCount: 10,
 			Compress:        true,
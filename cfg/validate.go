@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"path"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 )
@@ -40,6 +41,25 @@ func isValidLogRotateConfig(config *LogRotateLoggingConfig) error {
 	return nil
 }
 
+func isValidLoggingConfig(config *LoggingConfig) error {
+	if _, err := ParseComponentSeverities(config.ComponentSeverity); err != nil {
+		return fmt.Errorf("component-severity: %w", err)
+	}
+	switch config.Output {
+	case "", LogOutputAuto:
+	case LogOutputSyslog, LogOutputJournald:
+		if config.FilePath != "" {
+			return fmt.Errorf("file-path can't be set with output %q", config.Output)
+		}
+	default:
+		return fmt.Errorf("unsupported output %q; supported values: %s, %s, %s", config.Output, LogOutputAuto, LogOutputSyslog, LogOutputJournald)
+	}
+	if config.Sampling.MaxPerSecond < 0 {
+		return fmt.Errorf("sampling max-per-second can't be negative")
+	}
+	return nil
+}
+
 func isValidURL(u string) error {
 	_, err := decodeURL(u)
 	return err
@@ -81,6 +101,16 @@ func isValidFileCacheConfig(config *FileCacheConfig) error {
 	return nil
 }
 
+func isValidAccessManifestConfig(config *Config) error {
+	if config.FileCache.PrefetchConcurrency < 0 {
+		return errors.New("the value of prefetch-concurrency for file-cache can't be less than 0")
+	}
+	if config.FileCache.AccessManifestFile != "" && !IsFileCacheEnabled(config) {
+		return errors.New("file cache should be enabled for access-manifest-file")
+	}
+	return nil
+}
+
 func IsValidExperimentalMetadataPrefetchOnMount(mode string) error {
 	switch mode {
 	case ExperimentalMetadataPrefetchOnMountDisabled,
@@ -92,6 +122,61 @@ func IsValidExperimentalMetadataPrefetchOnMount(mode string) error {
 	}
 }
 
+func isValidImpersonationConfig(c *GcsAuthConfig) error {
+	if c.ImpersonateServiceAccount == "" {
+		if len(c.ImpersonateDelegates) > 0 {
+			return fmt.Errorf("impersonate-delegates requires impersonate-service-account")
+		}
+		return nil
+	}
+	if c.AnonymousAccess {
+		return fmt.Errorf("impersonate-service-account can't be used with anonymous-access")
+	}
+	return nil
+}
+
+func isValidPerUserCredentialsConfig(c *Config) error {
+	if !IsPerUserCredentialsEnabled(c) {
+		return nil
+	}
+	if c.GcsAuth.PerUserKeyFileDir != "" && c.GcsAuth.PerUserTokenUrl != "" {
+		return fmt.Errorf("only one of per-user-key-file-dir and per-user-token-url can be specified")
+	}
+	if c.GcsAuth.AnonymousAccess {
+		return fmt.Errorf("per-user credentials can't be used with anonymous-access")
+	}
+	// Both of these read or write object contents outside the op of the calling
+	// user, so would bypass the user's permissions.
+	if IsFileCacheEnabled(c) {
+		return fmt.Errorf("per-user credentials can't be used with the file cache, which is shared by all users")
+	}
+	if c.Write.EnableStreamingWrites {
+		return fmt.Errorf("per-user credentials can't be used with streaming writes")
+	}
+	return nil
+}
+
+func isValidAccessPolicyConfig(c *AccessPolicyConfig) error {
+	for _, patterns := range [][]string{c.AppendOnly, c.DenyDelete, c.Hidden, c.ReadOnly, c.WriteOncePaths} {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isValidIntegrityConfig(c *IntegrityConfig) error {
+	if c.Manifest == "" && c.Signature == "" && c.PublicKey == "" {
+		return nil
+	}
+	if c.Manifest == "" || c.Signature == "" || c.PublicKey == "" {
+		return fmt.Errorf("integrity-manifest, integrity-manifest-signature and integrity-public-key must be set together")
+	}
+	return nil
+}
+
 func isValidSequentialReadSizeMB(size int64) error {
 	if size < 1 || size > maxSequentialReadSizeMB {
 		return fmt.Errorf("sequential-read-size-mb should be between 1 and %d", maxSequentialReadSizeMB)
@@ -201,6 +286,56 @@ func isValidMetricsConfig(m *MetricsConfig) error {
 	if m.PrometheusPort > maxPortNumber {
 		return fmt.Errorf("prometheus-port must not be higher than the maximum allowed port number: %d but received: %d instead", maxPortNumber, m.PrometheusPort)
 	}
+	if m.EnableAttribution {
+		if m.AttributionPathDepth < 0 {
+			return fmt.Errorf("metrics-attribution-path-depth can't be negative")
+		}
+		if m.AttributionMaxKeys < 1 {
+			return fmt.Errorf("metrics-attribution-max-keys must be at least 1")
+		}
+	}
+	return nil
+}
+
+func isValidTracingConfig(m *MonitoringConfig) error {
+	switch m.ExperimentalTracingMode {
+	case "", "stdout", "gcptrace", "otlp":
+	case "file":
+		if m.ExperimentalTracingFile == "" {
+			return fmt.Errorf("experimental-tracing-file must be specified for the file tracing mode")
+		}
+	default:
+		return fmt.Errorf("unsupported experimental-tracing-mode: %q; supported values: stdout, file, otlp, gcptrace", m.ExperimentalTracingMode)
+	}
+	return nil
+}
+
+func isValidHealthConfig(h *HealthConfig) error {
+	if h.Port < 0 || h.Port > math.MaxUint16 {
+		return fmt.Errorf("health-port must be between 0 and %d but received: %d instead", math.MaxUint16, h.Port)
+	}
+	if h.Port == 0 {
+		return nil
+	}
+	if h.ProbeTimeout <= 0 {
+		return fmt.Errorf("health-probe-timeout must be positive")
+	}
+	if h.BucketProbeInterval < 0 || h.StallThreshold <= 0 {
+		return fmt.Errorf("health-bucket-probe-interval can't be negative and health-stall-threshold must be positive")
+	}
+	if h.MaxErrorRate < 0 || h.MaxErrorRate > 1 || h.MaxStallRate < 0 || h.MaxStallRate > 1 {
+		return fmt.Errorf("health-max-error-rate and health-max-stall-rate must be between 0 and 1")
+	}
+	return nil
+}
+
+func isValidSlowOpConfig(c *DebugConfig) error {
+	if c.SlowOpThreshold < 0 {
+		return fmt.Errorf("debug-slow-op-threshold can't be negative")
+	}
+	if c.SlowOpDumpInterval < 0 {
+		return fmt.Errorf("debug-slow-op-dump-interval can't be negative")
+	}
 	return nil
 }
 
@@ -219,6 +354,10 @@ func ValidateConfig(v isSet, config *Config) error {
 		return fmt.Errorf("error parsing log-rotate config: %w", err)
 	}
 
+	if err = isValidLoggingConfig(&config.Logging); err != nil {
+		return fmt.Errorf("error parsing logging config: %w", err)
+	}
+
 	if err = isValidURL(config.GcsConnection.CustomEndpoint); err != nil {
 		return fmt.Errorf("error parsing custom-endpoint config: %w", err)
 	}
@@ -235,6 +374,14 @@ func ValidateConfig(v isSet, config *Config) error {
 		return fmt.Errorf("error parsing token-url config: %w", err)
 	}
 
+	if err = isValidImpersonationConfig(&config.GcsAuth); err != nil {
+		return fmt.Errorf("error parsing gcs-auth config: %w", err)
+	}
+
+	if err = isValidPerUserCredentialsConfig(config); err != nil {
+		return fmt.Errorf("error parsing gcs-auth config: %w", err)
+	}
+
 	if err = isValidSequentialReadSizeMB(config.GcsConnection.SequentialReadSizeMb); err != nil {
 		return fmt.Errorf("error parsing gcs-connection config: %w", err)
 	}
@@ -243,6 +390,10 @@ func ValidateConfig(v isSet, config *Config) error {
 		return fmt.Errorf("error parsing kernel-list-cache-ttl-secs config: %w", err)
 	}
 
+	if err = isTTLInSecsValid(config.FileSystem.TrashRetentionSecs); err != nil {
+		return fmt.Errorf("error parsing trash-retention-secs config: %w", err)
+	}
+
 	if err = isValidMetadataCache(v, &config.MetadataCache); err != nil {
 		return fmt.Errorf("error parsing metadata-cache config: %w", err)
 	}
@@ -263,9 +414,37 @@ func ValidateConfig(v isSet, config *Config) error {
 		return fmt.Errorf("error parsing metrics config: %w", err)
 	}
 
+	if err = isValidTracingConfig(&config.Monitoring); err != nil {
+		return fmt.Errorf("error parsing monitoring config: %w", err)
+	}
+
+	if err = isValidSlowOpConfig(&config.Debug); err != nil {
+		return fmt.Errorf("error parsing debug config: %w", err)
+	}
+
+	if config.Debug.TimelineMaxEvents < 0 {
+		return fmt.Errorf("error parsing debug config: debug-timeline-max-events can't be negative")
+	}
+
+	if err = isValidHealthConfig(&config.Health); err != nil {
+		return fmt.Errorf("error parsing health config: %w", err)
+	}
+
 	if err = isValidParallelDownloadConfig(config); err != nil {
 		return fmt.Errorf("error parsing parallel download config: %w", err)
 	}
 
+	if err = isValidAccessManifestConfig(config); err != nil {
+		return fmt.Errorf("error parsing access manifest config: %w", err)
+	}
+
+	if err = isValidAccessPolicyConfig(&config.AccessPolicy); err != nil {
+		return fmt.Errorf("error parsing access-policy config: %w", err)
+	}
+
+	if err = isValidIntegrityConfig(&config.Integrity); err != nil {
+		return fmt.Errorf("error parsing integrity config: %w", err)
+	}
+
 	return nil
 }
@@ -64,6 +64,60 @@ func TestValidateConfigSuccessful(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Valid Config with per-user credentials.",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					PerUserTokenUrl: "http://broker/token?uid={uid}",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "disabled",
+				},
+			},
+		},
+		{
+			name: "Valid Config with access policy.",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				AccessPolicy: AccessPolicyConfig{
+					Hidden:         []string{"**/.secret*"},
+					ReadOnly:       []string{"datasets/**"},
+					AppendOnly:     []string{"logs"},
+					DenyDelete:     []string{"shared/*"},
+					WriteOncePaths: []string{"archive/**"},
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "disabled",
+				},
+			},
+		},
+		{
+			name: "Valid Config with integrity manifest.",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				Integrity: IntegrityConfig{
+					Manifest:  "/etc/manifest.json",
+					Signature: "/etc/manifest.json.sig",
+					PublicKey: "/etc/release.pem",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "disabled",
+				},
+			},
+		},
 		{
 			name: "Valid Config where input and expected custom endpoint differ.",
 			config: &Config{
@@ -252,6 +306,145 @@ func TestValidateConfig_ErrorScenarios(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Impersonate delegates without service account",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					ImpersonateDelegates: []string{"delegate@project.iam.gserviceaccount.com"},
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Impersonate service account with anonymous access",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					AnonymousAccess:           true,
+					ImpersonateServiceAccount: "target@project.iam.gserviceaccount.com",
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Both per-user key file dir and token url",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					PerUserKeyFileDir: "/keys",
+					PerUserTokenUrl:   "http://broker/{uid}",
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Per-user credentials with anonymous access",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					AnonymousAccess:   true,
+					PerUserKeyFileDir: "/keys",
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Invalid access policy pattern",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				AccessPolicy: AccessPolicyConfig{
+					ReadOnly: []string{"datasets/[a-"},
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Integrity manifest without public key",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				Integrity: IntegrityConfig{
+					Manifest:  "/etc/manifest.json",
+					Signature: "/etc/manifest.json.sig",
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Per-user credentials with file cache",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					PerUserKeyFileDir: "/keys",
+				},
+				CacheDir: "/cache",
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
+		{
+			name: "Per-user credentials with streaming writes",
+			config: &Config{
+				Logging:   LoggingConfig{LogRotate: validLogRotateConfig()},
+				FileCache: validFileCacheConfig(t),
+				GcsAuth: GcsAuthConfig{
+					PerUserKeyFileDir: "/keys",
+				},
+				Write: WriteConfig{
+					EnableStreamingWrites: true,
+					BlockSizeMb:           1,
+					GlobalMaxBlocks:       -1,
+					MaxBlocksPerFile:      -1,
+				},
+				MetadataCache: MetadataCacheConfig{
+					ExperimentalMetadataPrefetchOnMount: "sync",
+				},
+				GcsConnection: GcsConnectionConfig{
+					SequentialReadSizeMb: 200,
+				},
+			},
+		},
 		{
 			name: "Sequential read size MB more than 1024 (max permissible value)",
 			config: &Config{
@@ -631,6 +824,32 @@ func TestValidateMetrics(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid_attribution",
+			metricsConfig: MetricsConfig{
+				EnableAttribution:    true,
+				AttributionPathDepth: 2,
+				AttributionMaxKeys:   100,
+			},
+			wantErr: false,
+		},
+		{
+			name: "neg_attribution_path_depth",
+			metricsConfig: MetricsConfig{
+				EnableAttribution:    true,
+				AttributionPathDepth: -1,
+				AttributionMaxKeys:   100,
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero_attribution_max_keys",
+			metricsConfig: MetricsConfig{
+				EnableAttribution:    true,
+				AttributionPathDepth: 1,
+			},
+			wantErr: true,
+		},
 		{
 			name: "prom_disabled_0",
 			metricsConfig: MetricsConfig{
@@ -663,3 +882,315 @@ func TestValidateMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTracing(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name             string
+		monitoringConfig MonitoringConfig
+		wantErr          bool
+	}{
+		{
+			name:             "disabled",
+			monitoringConfig: MonitoringConfig{},
+			wantErr:          false,
+		},
+		{
+			name:             "otlp",
+			monitoringConfig: MonitoringConfig{ExperimentalTracingMode: "otlp"},
+			wantErr:          false,
+		},
+		{
+			name: "file",
+			monitoringConfig: MonitoringConfig{
+				ExperimentalTracingMode: "file",
+				ExperimentalTracingFile: "/tmp/spans.json",
+			},
+			wantErr: false,
+		},
+		{
+			name:             "file_without_path",
+			monitoringConfig: MonitoringConfig{ExperimentalTracingMode: "file"},
+			wantErr:          true,
+		},
+		{
+			name:             "unsupported_mode",
+			monitoringConfig: MonitoringConfig{ExperimentalTracingMode: "jaeger"},
+			wantErr:          true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := validConfig(t)
+			c.Monitoring = tc.monitoringConfig
+
+			err := ValidateConfig(&mockIsSet{}, &c)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateHealth(t *testing.T) {
+	t.Parallel()
+	enabled := HealthConfig{
+		Port:                8081,
+		ProbeTimeout:        5 * time.Second,
+		BucketProbeInterval: 30 * time.Second,
+		StallThreshold:      10 * time.Second,
+		MaxErrorRate:        0.1,
+		MaxStallRate:        0.1,
+	}
+	testCases := []struct {
+		name         string
+		healthConfig func(h *HealthConfig)
+		wantErr      bool
+	}{
+		{
+			name:         "disabled",
+			healthConfig: func(h *HealthConfig) { *h = HealthConfig{} },
+			wantErr:      false,
+		},
+		{
+			name:         "enabled",
+			healthConfig: func(h *HealthConfig) {},
+			wantErr:      false,
+		},
+		{
+			name:         "too_high_port",
+			healthConfig: func(h *HealthConfig) { h.Port = 100000 },
+			wantErr:      true,
+		},
+		{
+			name:         "zero_probe_timeout",
+			healthConfig: func(h *HealthConfig) { h.ProbeTimeout = 0 },
+			wantErr:      true,
+		},
+		{
+			name:         "zero_stall_threshold",
+			healthConfig: func(h *HealthConfig) { h.StallThreshold = 0 },
+			wantErr:      true,
+		},
+		{
+			name:         "error_rate_above_one",
+			healthConfig: func(h *HealthConfig) { h.MaxErrorRate = 1.5 },
+			wantErr:      true,
+		},
+		{
+			name:         "negative_stall_rate",
+			healthConfig: func(h *HealthConfig) { h.MaxStallRate = -0.1 },
+			wantErr:      true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := validConfig(t)
+			c.Health = enabled
+			tc.healthConfig(&c.Health)
+
+			err := ValidateConfig(&mockIsSet{}, &c)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateLogging(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name          string
+		loggingConfig func(l *LoggingConfig)
+		wantErr       bool
+	}{
+		{
+			name:          "default",
+			loggingConfig: func(l *LoggingConfig) {},
+			wantErr:       false,
+		},
+		{
+			name: "component_severity",
+			loggingConfig: func(l *LoggingConfig) {
+				l.ComponentSeverity = []string{"file-cache=trace", "gcs=off"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown_component",
+			loggingConfig: func(l *LoggingConfig) {
+				l.ComponentSeverity = []string{"kernel=trace"}
+			},
+			wantErr: true,
+		},
+		{
+			name:          "journald",
+			loggingConfig: func(l *LoggingConfig) { l.Output = "journald" },
+			wantErr:       false,
+		},
+		{
+			name: "syslog_with_file",
+			loggingConfig: func(l *LoggingConfig) {
+				l.Output = "syslog"
+				l.FilePath = "/tmp/gcsfuse.log"
+			},
+			wantErr: true,
+		},
+		{
+			name:          "unknown_output",
+			loggingConfig: func(l *LoggingConfig) { l.Output = "stderr" },
+			wantErr:       true,
+		},
+		{
+			name:          "negative_sampling",
+			loggingConfig: func(l *LoggingConfig) { l.Sampling.MaxPerSecond = -1 },
+			wantErr:       true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := validConfig(t)
+			c.Logging.Output = "auto"
+			tc.loggingConfig(&c.Logging)
+
+			err := ValidateConfig(&mockIsSet{}, &c)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateAccessManifest(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name      string
+		setConfig func(c *Config)
+		wantErr   bool
+	}{
+		{
+			name:      "disabled",
+			setConfig: func(c *Config) {},
+			wantErr:   false,
+		},
+		{
+			name: "enabled",
+			setConfig: func(c *Config) {
+				c.CacheDir = "/tmp/cache"
+				c.FileCache.AccessManifestFile = "/tmp/manifest.jsonl"
+				c.FileCache.PrefetchConcurrency = 4
+			},
+			wantErr: false,
+		},
+		{
+			name: "record_only",
+			setConfig: func(c *Config) {
+				c.CacheDir = "/tmp/cache"
+				c.FileCache.AccessManifestFile = "/tmp/manifest.jsonl"
+				c.FileCache.PrefetchConcurrency = 0
+			},
+			wantErr: false,
+		},
+		{
+			name: "file_cache_disabled",
+			setConfig: func(c *Config) {
+				c.FileCache.AccessManifestFile = "/tmp/manifest.jsonl"
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative_prefetch_concurrency",
+			setConfig: func(c *Config) {
+				c.FileCache.PrefetchConcurrency = -1
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := validConfig(t)
+			tc.setConfig(&c)
+
+			err := ValidateConfig(&mockIsSet{}, &c)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSlowOp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name        string
+		debugConfig DebugConfig
+		wantErr     bool
+	}{
+		{
+			name:        "disabled",
+			debugConfig: DebugConfig{},
+			wantErr:     false,
+		},
+		{
+			name:        "enabled",
+			debugConfig: DebugConfig{SlowOpThreshold: 5 * time.Second, SlowOpDumpInterval: time.Minute},
+			wantErr:     false,
+		},
+		{
+			name:        "negative_threshold",
+			debugConfig: DebugConfig{SlowOpThreshold: -time.Second},
+			wantErr:     true,
+		},
+		{
+			name:        "negative_dump_interval",
+			debugConfig: DebugConfig{SlowOpDumpInterval: -time.Second},
+			wantErr:     true,
+		},
+		{
+			name:        "timeline",
+			debugConfig: DebugConfig{TimelineMaxEvents: 100000},
+			wantErr:     false,
+		},
+		{
+			name:        "negative_timeline_max_events",
+			debugConfig: DebugConfig{TimelineMaxEvents: -1},
+			wantErr:     true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			c := validConfig(t)
+			c.Debug = tc.debugConfig
+
+			err := ValidateConfig(&mockIsSet{}, &c)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
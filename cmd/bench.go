@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/filesystem"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/benchmark"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/jacobsa/fuse"
+	"github.com/spf13/cobra"
+)
+
+// The name under which the simulated bucket is mounted when no bucket is
+// given to the bench command.
+const simulatedBenchBucketName = "gcsfuse-bench-simulated"
+
+// benchOptions are the parsed flags of the bench command.
+type benchOptions struct {
+	// Empty to benchmark a simulated bucket.
+	Bucket string
+
+	// Empty to mount in a fresh temporary directory.
+	MountPoint string
+
+	Profiles []string
+	Options  benchmark.Options
+
+	// Used only for the simulated bucket.
+	Simulated benchmark.LatencyModel
+
+	// Where to write the JSON report; "-" means stdout.
+	Output string
+}
+
+type benchFn func(c *cfg.Config, o *benchOptions) error
+
+// newBenchCmd returns the bench subcommand. It takes the parsed gcsfuse
+// configuration through getConfig, since the gcsfuse flags are declared on
+// the root command.
+func newBenchCmd(getConfig func() (*cfg.Config, error), b benchFn) *cobra.Command {
+	var (
+		o                 benchOptions
+		fileSizeMB        int64
+		smallFileSizeKB   int64
+		ioSizeKB          int
+		readBandwidthMBs  float64
+		writeBandwidthMBs float64
+	)
+
+	defaults := benchmark.DefaultOptions()
+	benchCmd := &cobra.Command{
+		Use:   "bench [flags]",
+		Short: "Benchmark gcsfuse with synthetic workloads",
+		Long: `Mount a bucket, run synthetic workload profiles against the mount and
+print their throughput and latency as JSON, so that runs with different
+gcsfuse flags can be compared. Without --bucket, a simulated in-memory bucket
+is mounted whose latency and bandwidth are set by the --sim-* flags.
+
+Available profiles:
+` + describeProfiles() + `
+A bucket named "bench" is mounted by passing it after "--", as in
+"gcsfuse -- bench mount_point", and all the buckets are mounted at a mount
+point named "bench" with "gcsfuse -- bench".`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := getConfig()
+			if err != nil {
+				return fmt.Errorf("error while parsing config: %w", err)
+			}
+
+			o.Options.FileSize = fileSizeMB << 20
+			o.Options.SmallFileSize = smallFileSizeKB << 10
+			o.Options.IOSize = ioSizeKB << 10
+			o.Simulated.ReadBandwidth = readBandwidthMBs * (1 << 20)
+			o.Simulated.WriteBandwidth = writeBandwidthMBs * (1 << 20)
+			if len(o.Profiles) == 0 {
+				o.Profiles = benchmark.ProfileNames()
+			}
+
+			return b(c, &o)
+		},
+	}
+
+	flags := benchCmd.Flags()
+	flags.StringVar(&o.Bucket, "bucket", "", "The bucket to benchmark. If empty, a simulated bucket is used.")
+	flags.StringVar(&o.MountPoint, "mount-point", "", "Where to mount the bucket. If empty, a temporary directory is used.")
+	flags.StringSliceVar(&o.Profiles, "profiles", nil, "Comma-separated workload profiles to run. If empty, all profiles are run.")
+	flags.DurationVar(&o.Options.Duration, "duration", defaults.Duration, "How long to run each profile for.")
+	flags.Int64Var(&o.Options.MaxOps, "max-ops", 0, "If non-zero, stop each profile after this many ops.")
+	flags.IntVar(&o.Options.Concurrency, "concurrency", defaults.Concurrency, "Number of workers issuing ops concurrently.")
+	flags.Int64Var(&fileSizeMB, "file-size-mb", defaults.FileSize>>20, "Size in MiB of the large files read and written by the profiles.")
+	flags.Int64Var(&smallFileSizeKB, "small-file-size-kb", defaults.SmallFileSize>>10, "Size in KiB of the small files created and read by the profiles.")
+	flags.IntVar(&ioSizeKB, "io-size-kb", defaults.IOSize>>10, "Size in KiB of each read and write call.")
+	flags.IntVar(&o.Options.NumFiles, "num-files", defaults.NumFiles, "Number of files used by the listing and dataloader-shuffle profiles.")
+	flags.BoolVar(&o.Options.KeepFiles, "keep-files", false, "Don't remove the files created by each profile.")
+	flags.StringVar(&o.Output, "output", "-", `Where to write the JSON report; "-" means stdout.`)
+	flags.DurationVar(&o.Simulated.RequestLatency, "sim-request-latency", 20*time.Millisecond, "Latency added to every request to the simulated bucket.")
+	flags.DurationVar(&o.Simulated.FirstByteLatency, "sim-first-byte-latency", 30*time.Millisecond, "Latency added before the first byte of each object read or write in the simulated bucket.")
+	flags.Float64Var(&o.Simulated.Jitter, "sim-jitter", 0.1, "Fraction by which simulated latencies vary at random.")
+	flags.Float64Var(&readBandwidthMBs, "sim-read-bandwidth-mbps", 0, "Aggregate read bandwidth of the simulated bucket in MiB/s; 0 means unlimited.")
+	flags.Float64Var(&writeBandwidthMBs, "sim-write-bandwidth-mbps", 0, "Aggregate write bandwidth of the simulated bucket in MiB/s; 0 means unlimited.")
+
+	return benchCmd
+}
+
+func describeProfiles() string {
+	var sb strings.Builder
+	for _, name := range benchmark.ProfileNames() {
+		fmt.Fprintf(&sb, "  %-20s %s\n", name, benchmark.ProfileDescription(name))
+	}
+
+	return sb.String()
+}
+
+// runBench mounts the bucket in the foreground, runs the profiles against it
+// and writes the report.
+func runBench(newConfig *cfg.Config, o *benchOptions) (err error) {
+	logger.SetLogFormat(newConfig.Logging.Format)
+	if err = logger.InitLogFile(newConfig.Logging); err != nil {
+		return fmt.Errorf("init log file: %w", err)
+	}
+
+	out := io.Writer(os.Stdout)
+	if o.Output != "-" {
+		var f *os.File
+		if f, err = os.Create(o.Output); err != nil {
+			return
+		}
+		defer func() {
+			err = errors.Join(err, f.Close())
+		}()
+		out = f
+	}
+
+	mountPoint := o.MountPoint
+	if mountPoint == "" {
+		if mountPoint, err = os.MkdirTemp("", "gcsfuse-bench-"); err != nil {
+			return
+		}
+		defer os.Remove(mountPoint)
+	}
+
+	report := &benchmark.Report{
+		Version: common.GetVersion(),
+		Started: time.Now(),
+		Bucket:  o.Bucket,
+		Args:    os.Args,
+		Options: o.Options,
+	}
+
+	opts := filesystem.Options{
+		Config:       newConfig,
+		BucketName:   o.Bucket,
+		MetricHandle: common.NewNoopMetrics(),
+	}
+	if o.Bucket == "" {
+		model := o.Simulated
+		report.Bucket = simulatedBenchBucketName
+		report.Simulated = &model
+		fake := gcsx.BackingBucket(filesystem.NewFakeBucket(simulatedBenchBucketName, newConfig.EnableHns))
+		opts.Bucket = gcsx.NewServedBucket(benchmark.NewLatencyBucket(model, fake))
+	} else {
+		opts.UserAgent = getUserAgent(newConfig.AppName, getConfigForUserAgent(newConfig))
+	}
+
+	ctx := context.Background()
+	mfs, _, err := mountFileSystem(ctx, mountPoint, newConfig, opts)
+	if err != nil {
+		return fmt.Errorf("mountFileSystem: %w", err)
+	}
+
+	logger.Infof("Benchmarking %q mounted at %q", report.Bucket, mountPoint)
+	report.Results, err = benchmark.Run(ctx, mountPoint, o.Profiles, o.Options)
+
+	if unmountErr := fuse.Unmount(mountPoint); unmountErr != nil {
+		err = errors.Join(err, fmt.Errorf("unmount: %w", unmountErr))
+	} else if joinErr := mfs.Join(ctx); joinErr != nil {
+		err = errors.Join(err, fmt.Errorf("MountedFileSystem.Join: %w", joinErr))
+	}
+
+	if err != nil {
+		return
+	}
+
+	return report.WriteJSON(out)
+}
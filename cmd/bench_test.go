@@ -0,0 +1,252 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/benchmark"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name:     "Mount",
+			args:     []string{"gcsfuse", "abc", "pqr"},
+			expected: []string{"gcsfuse", "abc", "pqr"},
+		},
+		{
+			name:     "Bench",
+			args:     []string{"gcsfuse", "bench", "--duration", "1s"},
+			expected: []string{"bench", "--duration", "1s"},
+		},
+		{
+			name:     "Bucket named bench",
+			args:     []string{"gcsfuse", "--", "bench", "pqr"},
+			expected: []string{"gcsfuse", "--", "bench", "pqr"},
+		},
+		{
+			name:     "Flag before subcommand name",
+			args:     []string{"gcsfuse", "--implicit-dirs", "bench", "pqr"},
+			expected: []string{"gcsfuse", "--implicit-dirs", "bench", "pqr"},
+		},
+		{
+			name:     "Bucket named bench without --",
+			args:     []string{"gcsfuse", "bench", "/mnt"},
+			expected: []string{"bench", "/mnt"},
+		},
+		{
+			name:     "Bench flag taking a value",
+			args:     []string{"gcsfuse", "bench", "--output", "/tmp/out.json", "--implicit-dirs"},
+			expected: []string{"bench", "--output", "/tmp/out.json", "--implicit-dirs"},
+		},
+		{
+			name:     "Ctl",
+			args:     []string{"gcsfuse", "ctl", "invalidate", "dir/", "--control-socket", "/run/ctl.sock"},
+			expected: []string{"ctl", "invalidate", "dir/", "--control-socket", "/run/ctl.sock"},
+		},
+		{
+			name:     "Bucket named ctl",
+			args:     []string{"gcsfuse", "ctl", "/mnt"},
+			expected: []string{"ctl", "/mnt"},
+		},
+		{
+			name:     "Bucket named ctl with a mount point named like a command taking an arg",
+			args:     []string{"gcsfuse", "ctl", "invalidate"},
+			expected: []string{"ctl", "invalidate"},
+		},
+		{
+			name:     "Program name only",
+			args:     []string{"gcsfuse"},
+			expected: []string{"gcsfuse"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := newRootCmd(func(*cfg.Config, string, string) error { return nil })
+			require.Nil(t, err)
+
+			assert.Equal(t, tc.expected, commandArgs(tc.args, cmd))
+		})
+	}
+}
+
+func TestArgsParsing_BenchFlags(t *testing.T) {
+	defaultOptions := benchmark.DefaultOptions()
+	tests := []struct {
+		name             string
+		args             []string
+		expected         *benchOptions
+		expectedImplicit bool
+	}{
+		{
+			name: "Defaults",
+			args: []string{"gcsfuse", "bench"},
+			expected: &benchOptions{
+				Profiles: benchmark.ProfileNames(),
+				Options:  defaultOptions,
+				Simulated: benchmark.LatencyModel{
+					RequestLatency:   20 * time.Millisecond,
+					FirstByteLatency: 30 * time.Millisecond,
+					Jitter:           0.1,
+				},
+				Output: "-",
+			},
+		},
+		{
+			name: "Real bucket with gcsfuse flags",
+			args: []string{"gcsfuse", "bench", "--bucket=abc", "--mount-point=/mnt/abc", "-implicit-dirs", "--profiles=seq-read,listing", "--max-ops=100", "--duration=0s", "--output=/tmp/out.json", "--keep-files"},
+			expected: &benchOptions{
+				Bucket:     "abc",
+				MountPoint: "/mnt/abc",
+				Profiles:   []string{"seq-read", "listing"},
+				Options: benchmark.Options{
+					MaxOps:        100,
+					Concurrency:   defaultOptions.Concurrency,
+					FileSize:      defaultOptions.FileSize,
+					SmallFileSize: defaultOptions.SmallFileSize,
+					IOSize:        defaultOptions.IOSize,
+					NumFiles:      defaultOptions.NumFiles,
+					KeepFiles:     true,
+				},
+				Simulated: benchmark.LatencyModel{
+					RequestLatency:   20 * time.Millisecond,
+					FirstByteLatency: 30 * time.Millisecond,
+					Jitter:           0.1,
+				},
+				Output: "/tmp/out.json",
+			},
+			expectedImplicit: true,
+		},
+		{
+			name: "Sizes and simulated model",
+			args: []string{"gcsfuse", "bench", "-concurrency=8", "--file-size-mb=2", "--small-file-size-kb=16", "--io-size-kb=128", "--num-files=10", "--sim-request-latency=1ms", "--sim-first-byte-latency=2ms", "--sim-jitter=0", "--sim-read-bandwidth-mbps=100", "--sim-write-bandwidth-mbps=0.5"},
+			expected: &benchOptions{
+				Profiles: benchmark.ProfileNames(),
+				Options: benchmark.Options{
+					Duration:      defaultOptions.Duration,
+					Concurrency:   8,
+					FileSize:      2 << 20,
+					SmallFileSize: 16 << 10,
+					IOSize:        128 << 10,
+					NumFiles:      10,
+				},
+				Simulated: benchmark.LatencyModel{
+					RequestLatency:   time.Millisecond,
+					FirstByteLatency: 2 * time.Millisecond,
+					ReadBandwidth:    100 << 20,
+					WriteBandwidth:   1 << 19,
+				},
+				Output: "-",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotConfig *cfg.Config
+			var gotOptions *benchOptions
+			cmd, err := newRootCmdWithBench(
+				func(*cfg.Config, string, string) error {
+					return assert.AnError
+				},
+				func(c *cfg.Config, o *benchOptions) error {
+					gotConfig = c
+					gotOptions = o
+					return nil
+				})
+			require.Nil(t, err)
+			cmd.SetArgs(commandArgs(convertToPosixArgs(tc.args, cmd), cmd))
+
+			err = cmd.Execute()
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, gotOptions)
+				assert.Equal(t, tc.expectedImplicit, gotConfig.ImplicitDirs)
+			}
+		})
+	}
+}
+
+func TestArgsParsing_BucketsNamedLikeSubcommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		bucket string
+	}{
+		{"bench", []string{"gcsfuse", "--", "bench", "/mnt"}, "bench"},
+		{"bench with flags", []string{"gcsfuse", "--implicit-dirs", "--", "bench", "/mnt"}, "bench"},
+		{"ctl", []string{"gcsfuse", "--", "ctl", "/mnt"}, "ctl"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var bucketName, mountPoint string
+			cmd, err := newRootCmdWithBench(
+				func(_ *cfg.Config, b, m string) error {
+					bucketName, mountPoint = b, m
+					return nil
+				},
+				func(*cfg.Config, *benchOptions) error { return assert.AnError })
+			require.Nil(t, err)
+			cmd.SetArgs(commandArgs(convertToPosixArgs(tc.args, cmd), cmd))
+
+			err = cmd.Execute()
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.bucket, bucketName)
+				assert.Equal(t, "/mnt", mountPoint)
+			}
+		})
+	}
+}
+
+func TestArgsParsing_BucketsNamedLikeSubcommandsWithoutDashes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		sub  string
+	}{
+		{"bench", []string{"gcsfuse", "bench", "/mnt"}, "bench"},
+		{"bench with flags", []string{"gcsfuse", "bench", "--implicit-dirs", "/mnt"}, "bench"},
+		{"ctl", []string{"gcsfuse", "ctl", "/mnt"}, "ctl"},
+		{"ctl with a mount point named like a command taking an arg", []string{"gcsfuse", "ctl", "invalidate"}, "ctl"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := newRootCmdWithBench(
+				func(*cfg.Config, string, string) error { return assert.AnError },
+				func(*cfg.Config, *benchOptions) error { return assert.AnError })
+			require.Nil(t, err)
+			cmd.SetArgs(commandArgs(convertToPosixArgs(tc.args, cmd), cmd))
+
+			err = cmd.Execute()
+
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, assert.AnError)
+			assert.Contains(t, err.Error(), "to mount a bucket named \""+tc.sub+"\", pass it after \"--\"")
+		})
+	}
+}
@@ -64,6 +64,7 @@ func defaultFileCacheConfig(t *testing.T) cfg.FileCacheConfig {
 		ParallelDownloadsPerFile: 16,
 		WriteBufferSize:          4 * 1024 * 1024,
 		EnableODirect:            false,
+		PrefetchConcurrency:      4,
 	}
 }
 
@@ -362,6 +363,7 @@ func TestValidateConfigFile_FileCacheConfigSuccessful(t *testing.T) {
 					ParallelDownloadsPerFile: 10,
 					WriteBufferSize:          8192,
 					EnableODirect:            true,
+					PrefetchConcurrency:      8,
 				},
 			},
 		},
@@ -391,10 +393,11 @@ func TestValidateConfigFile_GCSAuthConfigSuccessful(t *testing.T) {
 			configFile: "testdata/empty_file.yaml",
 			expectedConfig: &cfg.Config{
 				GcsAuth: cfg.GcsAuthConfig{
-					AnonymousAccess:   false,
-					KeyFile:           "",
-					ReuseTokenFromUrl: true,
-					TokenUrl:          "",
+					AnonymousAccess:      false,
+					ImpersonateDelegates: []string{},
+					KeyFile:              "",
+					ReuseTokenFromUrl:    true,
+					TokenUrl:             "",
 				},
 			},
 		},
@@ -403,10 +406,22 @@ func TestValidateConfigFile_GCSAuthConfigSuccessful(t *testing.T) {
 			configFile: "testdata/valid_config.yaml",
 			expectedConfig: &cfg.Config{
 				GcsAuth: cfg.GcsAuthConfig{
-					AnonymousAccess:   true,
-					KeyFile:           cfg.ResolvedPath(path.Join(hd, "key.file")),
-					ReuseTokenFromUrl: false,
-					TokenUrl:          "www.abc.com",
+					AnonymousAccess:      true,
+					ImpersonateDelegates: []string{},
+					KeyFile:              cfg.ResolvedPath(path.Join(hd, "key.file")),
+					ReuseTokenFromUrl:    false,
+					TokenUrl:             "www.abc.com",
+				},
+			},
+		},
+		{
+			name:       "Valid config file with impersonation",
+			configFile: "testdata/gcs_auth/impersonate_service_account.yaml",
+			expectedConfig: &cfg.Config{
+				GcsAuth: cfg.GcsAuthConfig{
+					ImpersonateDelegates:      []string{"a@project.iam.gserviceaccount.com", "b@project.iam.gserviceaccount.com"},
+					ImpersonateServiceAccount: "target@project.iam.gserviceaccount.com",
+					ReuseTokenFromUrl:         true,
 				},
 			},
 		},
@@ -415,10 +430,11 @@ func TestValidateConfigFile_GCSAuthConfigSuccessful(t *testing.T) {
 			configFile: "testdata/gcs_auth/unset_anonymous_access.yaml",
 			expectedConfig: &cfg.Config{
 				GcsAuth: cfg.GcsAuthConfig{
-					AnonymousAccess:   false,
-					KeyFile:           "",
-					ReuseTokenFromUrl: true,
-					TokenUrl:          "",
+					AnonymousAccess:      false,
+					ImpersonateDelegates: []string{},
+					KeyFile:              "",
+					ReuseTokenFromUrl:    true,
+					TokenUrl:             "",
 				},
 			},
 		},
@@ -515,6 +531,7 @@ func TestValidateConfigFile_FileSystemConfigSuccessful(t *testing.T) {
 					KernelListCacheTtlSecs: 0,
 					RenameDirLimit:         0,
 					TempDir:                "",
+					TrashRetentionSecs:     604800,
 					PreconditionErrors:     true,
 					Uid:                    -1,
 					HandleSigterm:          true,
@@ -535,6 +552,7 @@ func TestValidateConfigFile_FileSystemConfigSuccessful(t *testing.T) {
 					KernelListCacheTtlSecs: 0,
 					RenameDirLimit:         0,
 					TempDir:                "",
+					TrashRetentionSecs:     604800,
 					PreconditionErrors:     true,
 					Uid:                    -1,
 					HandleSigterm:          true,
@@ -546,8 +564,11 @@ func TestValidateConfigFile_FileSystemConfigSuccessful(t *testing.T) {
 			configFile: "testdata/valid_config.yaml",
 			expectedConfig: &cfg.Config{
 				FileSystem: cfg.FileSystemConfig{
+					CheckPermissions:       true,
 					DirMode:                0777,
 					DisableParallelDirops:  true,
+					EnableTrash:            true,
+					EncryptLocalData:       true,
 					FileMode:               0666,
 					FuseOptions:            []string{"ro"},
 					Gid:                    7,
@@ -555,6 +576,7 @@ func TestValidateConfigFile_FileSystemConfigSuccessful(t *testing.T) {
 					KernelListCacheTtlSecs: 300,
 					RenameDirLimit:         10,
 					TempDir:                cfg.ResolvedPath(path.Join(hd, "temp")),
+					TrashRetentionSecs:     86400,
 					PreconditionErrors:     false,
 					Uid:                    8,
 					HandleSigterm:          true,
@@ -804,6 +826,8 @@ func TestValidateConfigFile_MetricsConfigSuccessful(t *testing.T) {
 			name:       "empty_config_file",
 			configFile: "testdata/empty_file.yaml",
 			expectedConfig: &cfg.MetricsConfig{
+				AttributionMaxKeys:             100,
+				AttributionPathDepth:           1,
 				StackdriverExportInterval:      0,
 				CloudMetricsExportIntervalSecs: 0,
 				PrometheusPort:                 0,
@@ -813,6 +837,8 @@ func TestValidateConfigFile_MetricsConfigSuccessful(t *testing.T) {
 			name:       "valid_config_file",
 			configFile: "testdata/valid_config.yaml",
 			expectedConfig: &cfg.MetricsConfig{
+				AttributionMaxKeys:             100,
+				AttributionPathDepth:           1,
 				CloudMetricsExportIntervalSecs: 10,
 			},
 		},
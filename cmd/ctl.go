@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/control"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/spf13/cobra"
+)
+
+// newCtlCmd returns the ctl subcommand, which administers a running mount
+// through the control socket given by the parsed gcsfuse flags.
+func newCtlCmd(getConfig func() (*cfg.Config, error)) *cobra.Command {
+	newClient := func() (*control.Client, error) {
+		c, err := getConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing config: %w", err)
+		}
+		if c.ControlSocket == "" {
+			return nil, errors.New("--control-socket must say which mount to administer")
+		}
+
+		return control.NewClient(string(c.ControlSocket)), nil
+	}
+
+	ctlCmd := &cobra.Command{
+		Use:   "ctl command [flags]",
+		Short: "Administer a running gcsfuse mount",
+		Long: `Administer a gcsfuse mount that was started with --control-socket, by
+calling the admin API served on that socket. Pass the same --control-socket
+to this command.
+
+A bucket named "ctl" is mounted by passing it after "--", as in
+"gcsfuse -- ctl mount_point".`,
+		// Runnable, so that args that aren't commands are rejected rather than
+		// printing the help.
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Help()
+		},
+	}
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "config",
+		Short: "Print the effective config of the mount as YAML",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			out, err := client.Config(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write(out)
+			return err
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "reload",
+		Short: "Apply the changes to the config file that don't need a remount",
+		Long: `Make the mount load its config file again, as on SIGHUP, and apply the
+changes to the TTLs, rate limits, log severity and file cache size. Print the
+changed fields that were applied, and those that were rejected because they
+need a remount, as JSON.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			r, err := client.Reload(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Print the inode and handle counts and file cache usage as JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			stats, err := client.Stats(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		},
+	})
+
+	var (
+		topN  int
+		topBy string
+	)
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Print the callers and path prefixes with the most usage as JSON",
+		Long: `Print the callers and path prefixes of a mount started with
+--metrics-enable-attribution that issued the most file system ops, read or
+wrote the most bytes or made the most GCS requests since it was mounted, as
+JSON. Callers are the cgroups of the calling processes, or their commands when
+they are in the root cgroup.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			by, err := attribution.ParseMeasure(topBy)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			r, err := client.Top(cmd.Context(), topN, by)
+			if err != nil {
+				return err
+			}
+
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		},
+	}
+	topCmd.Flags().IntVar(&topN, "count", 10, "The number of callers and path prefixes to print; 0 prints all of them.")
+	topCmd.Flags().StringVar(&topBy, "by", string(attribution.ByOps), fmt.Sprintf("What to rank by, one of %v.", attribution.Measures))
+	ctlCmd.AddCommand(topCmd)
+
+	var invalidateReq control.InvalidateRequest
+	invalidateCmd := &cobra.Command{
+		Use:   "invalidate path",
+		Short: "Drop the cache entries for a path relative to the mount point",
+		Long: `Drop the cache entries for a path relative to the mount point, so that
+the next access fetches it from GCS again. With --prefix, drop those for
+every path that starts with it, e.g. "dir/" for everything in dir.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			invalidateReq.Path = args[0]
+			return client.Invalidate(cmd.Context(), invalidateReq)
+		},
+	}
+	invalidateCmd.Flags().StringVar((*string)(&invalidateReq.Cache), "cache", "", fmt.Sprintf("The cache to drop entries from: %q, %q or %q. If empty, all the enabled caches.", fs.StatCache, fs.TypeCache, fs.FileCache))
+	invalidateCmd.Flags().BoolVar(&invalidateReq.Prefix, "prefix", false, "Drop the entries for every path starting with the given one.")
+	ctlCmd.AddCommand(invalidateCmd)
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "log-severity severity",
+		Short: "Change the log severity of the mount",
+		Long:  `Change the log severity of the mount to one of TRACE, DEBUG, INFO, WARNING, ERROR or OFF, until it's unmounted.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			return client.SetLogSeverity(cmd.Context(), args[0])
+		},
+	})
+
+	var (
+		profileDuration time.Duration
+		profileOutput   string
+	)
+	pprofCmd := &cobra.Command{
+		Use:       "pprof cpu|heap",
+		Short:     "Capture a CPU or heap profile of the mount",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"cpu", "heap"},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if profileOutput != "-" {
+				var f *os.File
+				if f, err = os.Create(profileOutput); err != nil {
+					return err
+				}
+				defer func() {
+					err = errors.Join(err, f.Close())
+				}()
+				out = f
+			}
+
+			return client.Profile(cmd.Context(), args[0], profileDuration, out)
+		},
+	}
+	pprofCmd.Flags().DurationVar(&profileDuration, "duration", 30*time.Second, "How long to profile the CPU for.")
+	pprofCmd.Flags().StringVar(&profileOutput, "output", "-", `Where to write the profile; "-" means stdout.`)
+	ctlCmd.AddCommand(pprofCmd)
+
+	var timelineOutput string
+	timelineCmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Write the timeline of the mount as a Chrome trace",
+		Long: `Write the file system ops, GCS requests and file cache downloads that a
+mount started with --debug-timeline-max-events recorded last, as a Chrome trace
+in JSON. Open it in https://ui.perfetto.dev or chrome://tracing to see which of
+them overlapped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) (err error) {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if timelineOutput != "-" {
+				var f *os.File
+				if f, err = os.Create(timelineOutput); err != nil {
+					return err
+				}
+				defer func() {
+					err = errors.Join(err, f.Close())
+				}()
+				out = f
+			}
+
+			return client.Timeline(cmd.Context(), out)
+		},
+	}
+	timelineCmd.Flags().StringVar(&timelineOutput, "output", "-", `Where to write the trace; "-" means stdout.`)
+	ctlCmd.AddCommand(timelineCmd)
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "unmount",
+		Short: "Upload unsynced writes and unmount",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			client, err := newClient()
+			if err != nil {
+				return err
+			}
+
+			return client.Unmount(cmd.Context())
+		},
+	})
+
+	// Errors from the mount aren't usage errors.
+	for _, sub := range ctlCmd.Commands() {
+		sub.SilenceUsage = true
+	}
+
+	return ctlCmd
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/control"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAdmin struct {
+	invalidated []string
+}
+
+func (a *fakeAdmin) Stats() fs.Stats {
+	return fs.Stats{Inodes: 7, Handles: 2}
+}
+
+func (a *fakeAdmin) InvalidateCache(cache fs.Cache, path string, prefix bool) error {
+	if prefix {
+		path += "*"
+	}
+	a.invalidated = append(a.invalidated, string(cache)+":"+path)
+	return nil
+}
+
+func (a *fakeAdmin) Drain(context.Context) error {
+	return nil
+}
+
+func (a *fakeAdmin) Config() *cfg.Config {
+	return &cfg.Config{}
+}
+
+func (a *fakeAdmin) Reconfigure(*cfg.Config) (fs.Reconfiguration, error) {
+	return fs.Reconfiguration{Applied: []string{"logging.severity"}}, nil
+}
+
+func (a *fakeAdmin) TopConsumers(n int, by attribution.Measure) (attribution.Report, error) {
+	return attribution.Report{By: by, Keys: n, Top: []attribution.Consumer{{Key: attribution.Key{Caller: "cp", PathPrefix: "/data"}}}}, nil
+}
+
+// Run "gcsfuse ctl" with the given args against the admin API of a fake
+// mount, returning its output.
+func runCtl(t *testing.T, admin fs.Admin, args ...string) (string, error) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "ctl.sock")
+	s, err := control.Serve(socket, control.Mount{Admin: admin, Reload: func() (fs.Reconfiguration, error) {
+		return admin.Reconfigure(&cfg.Config{})
+	}})
+	require.NoError(t, err)
+	defer s.Close()
+	cmd, err := newRootCmd(func(*cfg.Config, string, string) error {
+		return assert.AnError
+	})
+	require.NoError(t, err)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	args = append([]string{"gcsfuse", "ctl"}, args...)
+	cmd.SetArgs(commandArgs(convertToPosixArgs(append(args, "--control-socket="+socket), cmd), cmd))
+
+	err = cmd.Execute()
+
+	return out.String(), err
+}
+
+func TestCtl_Stats(t *testing.T) {
+	out, err := runCtl(t, &fakeAdmin{}, "stats")
+
+	require.NoError(t, err)
+	var stats fs.Stats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	assert.Equal(t, fs.Stats{Inodes: 7, Handles: 2}, stats)
+}
+
+func TestCtl_Top(t *testing.T) {
+	out, err := runCtl(t, &fakeAdmin{}, "top", "--count=3", "--by=gcs-class-a")
+
+	require.NoError(t, err)
+	var r attribution.Report
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	assert.Equal(t, attribution.ByGCSClassA, r.By)
+	assert.Equal(t, 3, r.Keys)
+	require.Len(t, r.Top, 1)
+	assert.Equal(t, "cp", r.Top[0].Caller)
+}
+
+func TestCtl_TopInvalidMeasure(t *testing.T) {
+	_, err := runCtl(t, &fakeAdmin{}, "top", "--by=cost")
+
+	assert.ErrorContains(t, err, "unknown measure")
+}
+
+func TestCtl_Invalidate(t *testing.T) {
+	admin := &fakeAdmin{}
+
+	_, err := runCtl(t, admin, "invalidate", "--cache=type", "--prefix", "dir/")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"type:dir/*"}, admin.invalidated)
+}
+
+func TestCtl_Reload(t *testing.T) {
+	out, err := runCtl(t, &fakeAdmin{}, "reload")
+
+	require.NoError(t, err)
+	var r fs.Reconfiguration
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	assert.Equal(t, fs.Reconfiguration{Applied: []string{"logging.severity"}}, r)
+}
+
+func TestCtl_Timeline(t *testing.T) {
+	timeline.Enable(10)
+	t.Cleanup(timeline.Disable)
+	timeline.Begin(timeline.FUSE, "ReadFile").End(nil)
+	path := filepath.Join(t.TempDir(), "trace.json")
+
+	_, err := runCtl(t, &fakeAdmin{}, "timeline", "--output="+path)
+
+	require.NoError(t, err)
+	trace, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, json.Valid(trace))
+	assert.Contains(t, string(trace), `"name":"ReadFile"`)
+}
+
+func TestCtl_RequiresControlSocket(t *testing.T) {
+	cmd, err := newRootCmd(func(*cfg.Config, string, string) error {
+		return assert.AnError
+	})
+	require.NoError(t, err)
+	cmd.SetArgs(commandArgs(convertToPosixArgs([]string{"gcsfuse", "ctl", "stats"}, cmd), cmd))
+
+	err = cmd.Execute()
+
+	assert.ErrorContains(t, err, "--control-socket")
+}
@@ -32,13 +32,16 @@ import (
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/filesystem"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/canned"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/control"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/monitor"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/mount"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 	"github.com/jacobsa/daemonize"
 	"github.com/jacobsa/fuse"
@@ -87,6 +90,32 @@ func registerTerminatingSignalHandler(mountPoint string, c *cfg.Config) {
 	}()
 }
 
+func registerReloadSignalHandler(reload func() (filesystem.Reconfiguration, error)) {
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, unix.SIGHUP)
+
+	// Start a goroutine that reloads the config whenever the signal is received,
+	// for as long as the process runs.
+	go func() {
+		for range signalChan {
+			logger.Infof("Received SIGHUP, reloading the config...")
+			if _, err := reload(); err != nil {
+				logger.Errorf("Failed to reload the config: %v", err)
+			}
+		}
+	}()
+}
+
+// Log which config changes were applied, and which need a remount.
+func logReconfiguration(r filesystem.Reconfiguration) {
+	if len(r.Applied) > 0 {
+		logger.Infof("Applied the config changes to: %s", strings.Join(r.Applied, ", "))
+	}
+	if len(r.Rejected) > 0 {
+		logger.Warnf("Ignored the config changes that require a remount to: %s", strings.Join(r.Rejected, ", "))
+	}
+}
+
 func getUserAgent(appName string, config string) string {
 	gcsfuseMetadataImageType := os.Getenv("GCSFUSE_METADATA_IMAGE_TYPE")
 	if len(gcsfuseMetadataImageType) > 0 {
@@ -116,25 +145,7 @@ func getConfigForUserAgent(mountConfig *cfg.Config) string {
 	return fmt.Sprintf("%s:%s:%s", isFileCacheEnabled, isFileCacheForRangeReadEnabled, isParallelDownloadsEnabled)
 }
 func createStorageHandle(newConfig *cfg.Config, userAgent string) (storageHandle storage.StorageHandle, err error) {
-	storageClientConfig := storageutil.StorageClientConfig{
-		ClientProtocol:             newConfig.GcsConnection.ClientProtocol,
-		MaxConnsPerHost:            int(newConfig.GcsConnection.MaxConnsPerHost),
-		MaxIdleConnsPerHost:        int(newConfig.GcsConnection.MaxIdleConnsPerHost),
-		HttpClientTimeout:          newConfig.GcsConnection.HttpClientTimeout,
-		MaxRetrySleep:              newConfig.GcsRetries.MaxRetrySleep,
-		MaxRetryAttempts:           int(newConfig.GcsRetries.MaxRetryAttempts),
-		RetryMultiplier:            newConfig.GcsRetries.Multiplier,
-		UserAgent:                  userAgent,
-		CustomEndpoint:             newConfig.GcsConnection.CustomEndpoint,
-		KeyFile:                    string(newConfig.GcsAuth.KeyFile),
-		AnonymousAccess:            newConfig.GcsAuth.AnonymousAccess,
-		TokenUrl:                   newConfig.GcsAuth.TokenUrl,
-		ReuseTokenFromUrl:          newConfig.GcsAuth.ReuseTokenFromUrl,
-		ExperimentalEnableJsonRead: newConfig.GcsConnection.ExperimentalEnableJsonRead,
-		GrpcConnPoolSize:           int(newConfig.GcsConnection.GrpcConnPoolSize),
-		EnableHNS:                  newConfig.EnableHns,
-		ReadStallRetryConfig:       newConfig.GcsRetries.ReadStall,
-	}
+	storageClientConfig := storageutil.NewStorageClientConfig(newConfig, userAgent)
 	logger.Infof("UserAgent = %s\n", storageClientConfig.UserAgent)
 	storageHandle, err = storage.NewStorageHandle(context.Background(), storageClientConfig)
 	return
@@ -145,7 +156,7 @@ func createStorageHandle(newConfig *cfg.Config, userAgent string) (storageHandle
 ////////////////////////////////////////////////////////////////////////
 
 // Mount the file system according to arguments in the supplied context.
-func mountWithArgs(bucketName string, mountPoint string, newConfig *cfg.Config, metricHandle common.MetricHandle) (mfs *fuse.MountedFileSystem, err error) {
+func mountWithArgs(bucketName string, mountPoint string, newConfig *cfg.Config, metricHandle common.MetricHandle, healthMonitor *filesystem.HealthMonitor) (mfs *fuse.MountedFileSystem, admin filesystem.Admin, err error) {
 	// Enable invariant checking if requested.
 	if newConfig.Debug.ExitOnInvariantViolation {
 		locker.EnableInvariantsCheck()
@@ -153,14 +164,17 @@ func mountWithArgs(bucketName string, mountPoint string, newConfig *cfg.Config,
 	if newConfig.Debug.LogMutex {
 		locker.EnableDebugMessages()
 	}
+	if n := newConfig.Debug.TimelineMaxEvents; n > 0 {
+		timeline.Enable(int(n))
+	}
 
 	// Grab the connection.
 	//
 	// Special case: if we're mounting the fake bucket, we don't need an actual
 	// connection.
 	var storageHandle storage.StorageHandle
+	userAgent := getUserAgent(newConfig.AppName, getConfigForUserAgent(newConfig))
 	if bucketName != canned.FakeBucketName {
-		userAgent := getUserAgent(newConfig.AppName, getConfigForUserAgent(newConfig))
 		logger.Info("Creating Storage handle...")
 		storageHandle, err = createStorageHandle(newConfig, userAgent)
 		if err != nil {
@@ -171,13 +185,15 @@ func mountWithArgs(bucketName string, mountPoint string, newConfig *cfg.Config,
 
 	// Mount the file system.
 	logger.Infof("Creating a mount at %q\n", mountPoint)
-	mfs, err = mountWithStorageHandle(
+	mfs, admin, err = mountWithStorageHandle(
 		context.Background(),
 		bucketName,
 		mountPoint,
 		newConfig,
 		storageHandle,
-		metricHandle)
+		userAgent,
+		metricHandle,
+		healthMonitor)
 
 	if err != nil {
 		err = fmt.Errorf("mountWithStorageHandle: %w", err)
@@ -248,7 +264,7 @@ func isDynamicMount(bucketName string) bool {
 	return bucketName == "" || bucketName == "_"
 }
 
-func Mount(newConfig *cfg.Config, bucketName, mountPoint string) (err error) {
+func Mount(newConfig *cfg.Config, reloadConfig configLoader, bucketName, mountPoint string) (err error) {
 	// Ideally this call to SetLogFormat (which internally creates a new defaultLogger)
 	// should be set as an else to the 'if flags.Foreground' check below, but currently
 	// that means the logs generated by resolveConfigFilePaths below don't honour
@@ -389,13 +405,32 @@ func Mount(newConfig *cfg.Config, bucketName, mountPoint string) (err error) {
 		}
 	}
 	shutdownTracingFn := monitor.SetupTracing(ctx, newConfig)
-	shutdownFn := common.JoinShutdownFunc(metricExporterShutdownFn, shutdownTracingFn)
+	// Serve the health checks while mounting, so that the mount is live but
+	// not ready until it's done.
+	var healthMonitor *filesystem.HealthMonitor
+	var shutdownHealthFn common.ShutdownFn
+	if newConfig.Health.Port > 0 {
+		healthMonitor = filesystem.NewHealthMonitor(newConfig)
+		shutdownHealthFn = monitor.ServeHealth(newConfig.Health.Address, newConfig.Health.Port, healthMonitor.Handler())
+	}
+	shutdownFn := common.JoinShutdownFunc(metricExporterShutdownFn, shutdownTracingFn, shutdownHealthFn)
 
 	// Mount, writing information about our progress to the writer that package
 	// daemonize gives us and telling it about the outcome.
 	var mfs *fuse.MountedFileSystem
+	var admin filesystem.Admin
+	// Load the config again and apply what can change to the file system.
+	reload := func() (r filesystem.Reconfiguration, err error) {
+		c, err := reloadConfig()
+		if err != nil {
+			return r, fmt.Errorf("error while parsing config: %w", err)
+		}
+		r, err = admin.Reconfigure(c)
+		logReconfiguration(r)
+		return
+	}
 	{
-		mfs, err = mountWithArgs(bucketName, mountPoint, newConfig, metricHandle)
+		mfs, admin, err = mountWithArgs(bucketName, mountPoint, newConfig, metricHandle, healthMonitor)
 
 		// This utility is to absorb the error
 		// returned by daemonize.SignalOutcome calls by simply
@@ -425,6 +460,27 @@ func Mount(newConfig *cfg.Config, bucketName, mountPoint string) (err error) {
 			markMountFailure(err)
 			return err
 		}
+		if newConfig.ControlSocket != "" {
+			var ctl *control.Server
+			ctl, err = control.Serve(string(newConfig.ControlSocket), control.Mount{
+				Admin:   admin,
+				Reload:  reload,
+				Unmount: func() error { return fuse.Unmount(mfs.Dir()) },
+			})
+			if err != nil {
+				err = fmt.Errorf("control socket: %w", err)
+				markMountFailure(err)
+				if unmountErr := fuse.Unmount(mfs.Dir()); unmountErr != nil {
+					logger.Errorf("Failed to unmount: %v", unmountErr)
+				}
+				return err
+			}
+			defer func() {
+				if err := ctl.Close(); err != nil {
+					logger.Errorf("Error while closing the control socket: %v", err)
+				}
+			}()
+		}
 		if !isDynamicMount(bucketName) {
 			switch newConfig.MetadataCache.ExperimentalMetadataPrefetchOnMount {
 			case cfg.ExperimentalMetadataPrefetchOnMountSynchronous:
@@ -441,11 +497,17 @@ func Mount(newConfig *cfg.Config, bucketName, mountPoint string) (err error) {
 			}
 		}
 		markSuccessfulMount()
+		if healthMonitor != nil {
+			healthMonitor.SetMounted()
+		}
 	}
 
 	// Let the user unmount with Ctrl-C (SIGINT).
 	registerTerminatingSignalHandler(mfs.Dir(), newConfig)
 
+	// Let the user reload the config with SIGHUP.
+	registerReloadSignalHandler(reload)
+
 	// Wait for the file system to be unmounted.
 	if err = mfs.Join(ctx); err != nil {
 		err = fmt.Errorf("MountedFileSystem.Join: %w", err)
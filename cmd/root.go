@@ -19,6 +19,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
@@ -31,13 +32,37 @@ import (
 
 type mountFn func(c *cfg.Config, bucketName, mountPoint string) error
 
+// configLoader loads the config again from the config file and flags that the
+// command was run with, e.g. after the config file was edited.
+type configLoader func() (*cfg.Config, error)
+
+// reloadableMountFn is like mountFn, and is also passed a configLoader for
+// the config.
+type reloadableMountFn func(c *cfg.Config, reload configLoader, bucketName, mountPoint string) error
+
 // newRootCmd accepts the mountFn that it executes with the parsed configuration
 func newRootCmd(m mountFn) (*cobra.Command, error) {
+	return newRootCmdWithBench(m, runBench)
+}
+
+// newRootCmdWithBench is like newRootCmd, and also accepts the benchFn that
+// the bench subcommand executes.
+func newRootCmdWithBench(m mountFn, b benchFn) (*cobra.Command, error) {
+	return newReloadableRootCmd(func(c *cfg.Config, _ configLoader, bucketName, mountPoint string) error {
+		return m(c, bucketName, mountPoint)
+	}, b)
+}
+
+// newReloadableRootCmd is like newRootCmdWithBench, for a reloadableMountFn.
+func newReloadableRootCmd(m reloadableMountFn, b benchFn) (*cobra.Command, error) {
 	var (
 		configObj cfg.Config
 		cfgFile   string
 		cfgErr    error
 		v         = viper.New()
+		// Serializes the uses of v by reload.
+		vMu    sync.Mutex
+		reload configLoader
 	)
 	rootCmd := &cobra.Command{
 		Use:   "gcsfuse [flags] bucket mount_point",
@@ -56,41 +81,55 @@ of Cloud Storage FUSE, see https://cloud.google.com/storage/docs/gcs-fuse.`,
 			if err != nil {
 				return fmt.Errorf("error occurred while extracting the bucket and mountPoint: %w", err)
 			}
-			return m(&configObj, bucket, mountPoint)
+			return m(&configObj, reload, bucket, mountPoint)
 		},
 	}
-	initConfig := func() {
+	loadConfig := func(c *cfg.Config) error {
 		if cfgFile != "" {
 			cfgFile, err := util.GetResolvedPath(cfgFile)
 			if err != nil {
-				cfgErr = fmt.Errorf("error while resolving config-file path[%s]: %w", cfgFile, err)
-				return
+				return fmt.Errorf("error while resolving config-file path[%s]: %w", cfgFile, err)
 			}
 			v.SetConfigFile(cfgFile)
 			v.SetConfigType("yaml")
 			if err := v.ReadInConfig(); err != nil {
-				cfgErr = fmt.Errorf("error while reading the config: %w", err)
-				return
+				return fmt.Errorf("error while reading the config: %w", err)
 			}
 		}
 
-		if cfgErr = v.Unmarshal(&configObj, viper.DecodeHook(cfg.DecodeHook()), func(decoderConfig *mapstructure.DecoderConfig) {
+		if err := v.Unmarshal(c, viper.DecodeHook(cfg.DecodeHook()), func(decoderConfig *mapstructure.DecoderConfig) {
 			// By default, viper supports mapstructure tags for unmarshalling. Override that to support yaml tag.
 			decoderConfig.TagName = "yaml"
 			// Reject the config file if any of the fields in the YAML don't map to the struct.
 			decoderConfig.ErrorUnused = true
 		},
-		); cfgErr != nil {
-			return
+		); err != nil {
+			return err
 		}
-		if cfgErr = cfg.ValidateConfig(v, &configObj); cfgErr != nil {
-			return
+		if err := cfg.ValidateConfig(v, c); err != nil {
+			return err
 		}
-		if cfgErr = cfg.Rationalize(v, &configObj); cfgErr != nil {
-			return
+		return cfg.Rationalize(v, c)
+	}
+	initConfig := func() {
+		cfgErr = loadConfig(&configObj)
+	}
+	reload = func() (*cfg.Config, error) {
+		vMu.Lock()
+		defer vMu.Unlock()
+
+		var c cfg.Config
+		if err := loadConfig(&c); err != nil {
+			return nil, err
 		}
+		return &c, nil
+	}
+	// A hook of rootCmd, inherited by its subcommands, rather than
+	// cobra.OnInitialize, which would also load the config of every other root
+	// command created in the process, e.g. by tests, each time one is executed.
+	rootCmd.PersistentPreRun = func(*cobra.Command, []string) {
+		initConfig()
 	}
-	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, cfg.ConfigFileFlagName, "", "The path to the config file where all gcsfuse related config needs to be specified. "+
 		"Refer to 'https://cloud.google.com/storage/docs/gcsfuse-cli#config-file' for possible configurations.")
 
@@ -101,6 +140,15 @@ of Cloud Storage FUSE, see https://cloud.google.com/storage/docs/gcs-fuse.`,
 	if err := cfg.BindFlags(v, rootCmd.PersistentFlags()); err != nil {
 		return nil, fmt.Errorf("error while binding flags: %w", err)
 	}
+
+	getConfig := func() (*cfg.Config, error) {
+		return &configObj, cfgErr
+	}
+	rootCmd.AddCommand(newBenchCmd(getConfig, b))
+	rootCmd.AddCommand(newCtlCmd(getConfig))
+	for _, sub := range rootCmd.Commands() {
+		rejectBucketArgs(sub)
+	}
 	return rootCmd, nil
 }
 
@@ -114,6 +162,11 @@ func convertToPosixArgs(args []string, c *cobra.Command) []string {
 	c.PersistentFlags().VisitAll(func(f *pflag.Flag) {
 		flagSet[f.Name] = true
 	})
+	for _, sub := range c.Commands() {
+		sub.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			flagSet[f.Name] = true
+		})
+	}
 	// Treat help and version like flags
 	flagSet["version"] = true
 	flagSet["help"] = true
@@ -146,12 +199,53 @@ func convertToPosixArgs(args []string, c *cobra.Command) []string {
 	return pArgs
 }
 
+// commandArgs returns the args to execute c with. The root command expects the
+// program name as its first arg, which is dropped when the next arg names a
+// subcommand, so that cobra dispatches to it. A bucket named like a subcommand
+// must therefore be passed after "--", e.g. "gcsfuse -- bench /mnt", which
+// rejectBucketArgs tells the user when they don't.
+func commandArgs(args []string, c *cobra.Command) []string {
+	if len(args) < 2 {
+		return args
+	}
+
+	for _, sub := range c.Commands() {
+		if sub.Name() == args[1] || sub.HasAlias(args[1]) {
+			return args[1:]
+		}
+	}
+
+	return args
+}
+
+// rejectBucketArgs makes the runnable commands under the subcommand sub fail
+// on args they don't take, with an error saying how to mount a bucket named
+// like sub, as such args are likely the mount point.
+func rejectBucketArgs(sub *cobra.Command) {
+	name := sub.Name()
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		if validate := c.Args; validate != nil {
+			c.Args = func(cmd *cobra.Command, args []string) error {
+				if err := validate(cmd, args); err != nil {
+					return fmt.Errorf("%w (to mount a bucket named %q, pass it after \"--\": gcsfuse [flags] -- %s mount_point)", err, name, name)
+				}
+				return nil
+			}
+		}
+		for _, child := range c.Commands() {
+			visit(child)
+		}
+	}
+	visit(sub)
+}
+
 var ExecuteMountCmd = func() {
-	rootCmd, err := newRootCmd(Mount)
+	rootCmd, err := newReloadableRootCmd(Mount, runBench)
 	if err != nil {
 		log.Fatalf("Error occurred while creating the root command: %v", err)
 	}
-	rootCmd.SetArgs(convertToPosixArgs(os.Args, rootCmd))
+	rootCmd.SetArgs(commandArgs(convertToPosixArgs(os.Args, rootCmd), rootCmd))
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatalf("Error occurred during command execution: %v", err)
 	}
@@ -307,7 +307,7 @@ func TestArgsParsing_FileCacheFlags(t *testing.T) {
 	}{
 		{
 			name: "Test file cache flags.",
-			args: []string{"gcsfuse", "--file-cache-cache-file-for-range-read", "--file-cache-download-chunk-size-mb=20", "--file-cache-enable-crc", "--cache-dir=/some/valid/dir", "--file-cache-enable-parallel-downloads", "--file-cache-max-parallel-downloads=40", "--file-cache-max-size-mb=100", "--file-cache-parallel-downloads-per-file=2", "--file-cache-enable-o-direct=false", "abc", "pqr"},
+			args: []string{"gcsfuse", "--file-cache-cache-file-for-range-read", "--file-cache-download-chunk-size-mb=20", "--file-cache-enable-crc", "--cache-dir=/some/valid/dir", "--file-cache-enable-parallel-downloads", "--file-cache-max-parallel-downloads=40", "--file-cache-max-size-mb=100", "--file-cache-parallel-downloads-per-file=2", "--file-cache-enable-o-direct=false", "--file-cache-access-manifest-file=/some/manifest.jsonl", "--file-cache-prefetch-concurrency=8", "abc", "pqr"},
 			expectedConfig: &cfg.Config{
 				CacheDir: "/some/valid/dir",
 				FileCache: cfg.FileCacheConfig{
@@ -320,6 +320,8 @@ func TestArgsParsing_FileCacheFlags(t *testing.T) {
 					ParallelDownloadsPerFile: 2,
 					WriteBufferSize:          4 * 1024 * 1024,
 					EnableODirect:            false,
+					AccessManifestFile:       "/some/manifest.jsonl",
+					PrefetchConcurrency:      8,
 				},
 			},
 		},
@@ -337,6 +339,7 @@ func TestArgsParsing_FileCacheFlags(t *testing.T) {
 					ParallelDownloadsPerFile: 16,
 					WriteBufferSize:          4 * 1024 * 1024,
 					EnableODirect:            false,
+					PrefetchConcurrency:      4,
 				},
 			},
 		},
@@ -453,13 +456,37 @@ func TestArgsParsing_GCSAuthFlags(t *testing.T) {
 	}{
 		{
 			name: "Test gcs auth flags.",
-			args: []string{"gcsfuse", "--anonymous-access", "--key-file=key.file", "--reuse-token-from-url", "--token-url=www.abc.com", "abc", "pqr"},
+			args: []string{"gcsfuse", "--anonymous-access", "--credential-process=get-token --json", "--key-file=key.file", "--reuse-token-from-url", "--token-url=www.abc.com", "abc", "pqr"},
 			expectedConfig: &cfg.Config{
 				GcsAuth: cfg.GcsAuthConfig{
-					AnonymousAccess:   true,
-					KeyFile:           cfg.ResolvedPath(path.Join(wd, "key.file")),
-					ReuseTokenFromUrl: true,
-					TokenUrl:          "www.abc.com",
+					AnonymousAccess:      true,
+					CredentialProcess:    "get-token --json",
+					ImpersonateDelegates: []string{},
+					KeyFile:              cfg.ResolvedPath(path.Join(wd, "key.file")),
+					ReuseTokenFromUrl:    true,
+					TokenUrl:             "www.abc.com",
+				},
+			},
+		},
+		{
+			name: "Test impersonation flags.",
+			args: []string{"gcsfuse", "--impersonate-service-account=target@project.iam.gserviceaccount.com", "--impersonate-delegates=a@project.iam.gserviceaccount.com,b@project.iam.gserviceaccount.com", "abc", "pqr"},
+			expectedConfig: &cfg.Config{
+				GcsAuth: cfg.GcsAuthConfig{
+					ImpersonateDelegates:      []string{"a@project.iam.gserviceaccount.com", "b@project.iam.gserviceaccount.com"},
+					ImpersonateServiceAccount: "target@project.iam.gserviceaccount.com",
+					ReuseTokenFromUrl:         true,
+				},
+			},
+		},
+		{
+			name: "Test per-user credential flags.",
+			args: []string{"gcsfuse", "--per-user-key-file-dir=keys", "abc", "pqr"},
+			expectedConfig: &cfg.Config{
+				GcsAuth: cfg.GcsAuthConfig{
+					ImpersonateDelegates: []string{},
+					PerUserKeyFileDir:    cfg.ResolvedPath(path.Join(wd, "keys")),
+					ReuseTokenFromUrl:    true,
 				},
 			},
 		},
@@ -468,10 +495,11 @@ func TestArgsParsing_GCSAuthFlags(t *testing.T) {
 			args: []string{"gcsfuse", "abc", "pqr"},
 			expectedConfig: &cfg.Config{
 				GcsAuth: cfg.GcsAuthConfig{
-					AnonymousAccess:   false,
-					KeyFile:           "",
-					ReuseTokenFromUrl: true,
-					TokenUrl:          "",
+					AnonymousAccess:      false,
+					ImpersonateDelegates: []string{},
+					KeyFile:              "",
+					ReuseTokenFromUrl:    true,
+					TokenUrl:             "",
 				},
 			},
 		},
@@ -643,11 +671,14 @@ func TestArgsParsing_FileSystemFlags(t *testing.T) {
 	}{
 		{
 			name: "normal",
-			args: []string{"gcsfuse", "--dir-mode=0777", "--disable-parallel-dirops", "--file-mode=0666", "--o", "ro", "--gid=7", "--ignore-interrupts=false", "--kernel-list-cache-ttl-secs=300", "--rename-dir-limit=10", "--temp-dir=~/temp", "--uid=8", "--precondition-errors=false", "abc", "pqr"},
+			args: []string{"gcsfuse", "--dir-mode=0777", "--disable-parallel-dirops", "--file-mode=0666", "--o", "ro", "--gid=7", "--ignore-interrupts=false", "--check-permissions", "--enable-trash", "--encrypt-local-data", "--kernel-list-cache-ttl-secs=300", "--rename-dir-limit=10", "--temp-dir=~/temp", "--trash-retention-secs=3600", "--uid=8", "--precondition-errors=false", "abc", "pqr"},
 			expectedConfig: &cfg.Config{
 				FileSystem: cfg.FileSystemConfig{
+					CheckPermissions:       true,
 					DirMode:                0777,
 					DisableParallelDirops:  true,
+					EnableTrash:            true,
+					EncryptLocalData:       true,
 					FileMode:               0666,
 					FuseOptions:            []string{"ro"},
 					Gid:                    7,
@@ -655,6 +686,7 @@ func TestArgsParsing_FileSystemFlags(t *testing.T) {
 					KernelListCacheTtlSecs: 300,
 					RenameDirLimit:         10,
 					TempDir:                cfg.ResolvedPath(path.Join(hd, "temp")),
+					TrashRetentionSecs:     3600,
 					PreconditionErrors:     false,
 					Uid:                    8,
 					HandleSigterm:          true,
@@ -675,6 +707,7 @@ func TestArgsParsing_FileSystemFlags(t *testing.T) {
 					KernelListCacheTtlSecs: 0,
 					RenameDirLimit:         0,
 					TempDir:                "",
+					TrashRetentionSecs:     604800,
 					PreconditionErrors:     true,
 					Uid:                    -1,
 					HandleSigterm:          true,
@@ -695,6 +728,7 @@ func TestArgsParsing_FileSystemFlags(t *testing.T) {
 					KernelListCacheTtlSecs: 0,
 					RenameDirLimit:         0,
 					TempDir:                "",
+					TrashRetentionSecs:     604800,
 					PreconditionErrors:     true,
 					Uid:                    -1,
 					HandleSigterm:          true,
@@ -882,39 +916,52 @@ func TestArgsParsing_MetricsFlags(t *testing.T) {
 			name: "default",
 			args: []string{"gcsfuse", "abc", "pqr"},
 			expected: &cfg.MetricsConfig{
-				EnableOtel: false,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           false,
 			},
 		},
 		{
 			name: "enable_otel_normal",
 			args: []string{"gcsfuse", "--enable-otel", "abc", "pqr"},
 			expected: &cfg.MetricsConfig{
-				EnableOtel: true,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           true,
 			},
 		},
 		{
 			name: "enable_otel_false",
 			args: []string{"gcsfuse", "--enable-otel=false", "abc", "pqr"},
 			expected: &cfg.MetricsConfig{
-				EnableOtel: false,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           false,
 			},
 		},
 		{
 			name: "enable_otel_false",
 			args: []string{"gcsfuse", "--enable-otel=true", "abc", "pqr"},
 			expected: &cfg.MetricsConfig{
-				EnableOtel: true,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           true,
 			},
 		},
 		{
 			name:     "cloud-metrics-export-interval-secs-positive",
 			args:     []string{"gcsfuse", "--cloud-metrics-export-interval-secs=10", "abc", "pqr"},
-			expected: &cfg.MetricsConfig{CloudMetricsExportIntervalSecs: 10},
+			expected: &cfg.MetricsConfig{AttributionMaxKeys: 100, AttributionPathDepth: 1, CloudMetricsExportIntervalSecs: 10},
 		},
 		{
 			name:     "stackdriver-export-interval-positive",
 			args:     []string{"gcsfuse", "--stackdriver-export-interval=10h", "abc", "pqr"},
-			expected: &cfg.MetricsConfig{CloudMetricsExportIntervalSecs: 10 * 3600, StackdriverExportInterval: time.Duration(10) * time.Hour},
+			expected: &cfg.MetricsConfig{AttributionMaxKeys: 100, AttributionPathDepth: 1, CloudMetricsExportIntervalSecs: 10 * 3600, StackdriverExportInterval: time.Duration(10) * time.Hour},
+		},
+		{
+			name:     "attribution",
+			args:     []string{"gcsfuse", "--metrics-enable-attribution", "--metrics-attribution-path-depth=2", "--metrics-attribution-max-keys=20", "abc", "pqr"},
+			expected: &cfg.MetricsConfig{EnableAttribution: true, AttributionMaxKeys: 20, AttributionPathDepth: 2},
 		},
 	}
 	for _, tc := range tests {
@@ -946,32 +993,38 @@ func TestArgsParsing_MetricsViewConfig(t *testing.T) {
 			name:    "default",
 			cfgFile: "empty.yml",
 			expected: &cfg.MetricsConfig{
-				EnableOtel: false,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           false,
 			},
 		},
 		{
 			name:    "enable_otel_true",
 			cfgFile: "enable_otel_true.yml",
 			expected: &cfg.MetricsConfig{
-				EnableOtel: true,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           true,
 			},
 		},
 		{
 			name:    "enable_otel_false",
 			cfgFile: "enable_otel_false.yml",
 			expected: &cfg.MetricsConfig{
-				EnableOtel: false,
+				AttributionMaxKeys:   100,
+				AttributionPathDepth: 1,
+				EnableOtel:           false,
 			},
 		},
 		{
 			name:     "cloud-metrics-export-interval-secs-positive",
 			cfgFile:  "metrics_export_interval_positive.yml",
-			expected: &cfg.MetricsConfig{CloudMetricsExportIntervalSecs: 100},
+			expected: &cfg.MetricsConfig{AttributionMaxKeys: 100, AttributionPathDepth: 1, CloudMetricsExportIntervalSecs: 100},
 		},
 		{
 			name:     "stackdriver-export-interval-positive",
 			cfgFile:  "stackdriver_export_interval_positive.yml",
-			expected: &cfg.MetricsConfig{CloudMetricsExportIntervalSecs: 12 * 3600, StackdriverExportInterval: 12 * time.Hour},
+			expected: &cfg.MetricsConfig{AttributionMaxKeys: 100, AttributionPathDepth: 1, CloudMetricsExportIntervalSecs: 12 * 3600, StackdriverExportInterval: 12 * time.Hour},
 		},
 	}
 	for _, tc := range tests {
@@ -1099,3 +1152,42 @@ func TestArgParsing_GCSRetries(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadConfig(t *testing.T) {
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("metadata-cache:\n  ttl-secs: 60\n"), 0600))
+	var reload configLoader
+	cmd, err := newReloadableRootCmd(func(_ *cfg.Config, r configLoader, _, _ string) error {
+		reload = r
+		return nil
+	}, runBench)
+	require.NoError(t, err)
+	cmd.SetArgs(convertToPosixArgs([]string{"gcsfuse", "--config-file=" + configFile, "--kernel-list-cache-ttl-secs=5", "abc", "pqr"}, cmd))
+	require.NoError(t, cmd.Execute())
+	require.NoError(t, os.WriteFile(configFile, []byte("metadata-cache:\n  ttl-secs: 30\nfile-system:\n  kernel-list-cache-ttl-secs: 10\n"), 0600))
+
+	c, err := reload()
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), c.MetadataCache.TtlSecs)
+	// Flags still take precedence over the config file.
+	assert.Equal(t, int64(5), c.FileSystem.KernelListCacheTtlSecs)
+}
+
+func TestReloadConfig_Invalid(t *testing.T) {
+	configFile := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("metadata-cache:\n  ttl-secs: 60\n"), 0600))
+	var reload configLoader
+	cmd, err := newReloadableRootCmd(func(_ *cfg.Config, r configLoader, _, _ string) error {
+		reload = r
+		return nil
+	}, runBench)
+	require.NoError(t, err)
+	cmd.SetArgs(convertToPosixArgs([]string{"gcsfuse", "--config-file=" + configFile, "abc", "pqr"}, cmd))
+	require.NoError(t, cmd.Execute())
+	require.NoError(t, os.WriteFile(configFile, []byte("metadata-cache:\n  ttl-secs: -5\n"), 0600))
+
+	_, err = reload()
+
+	assert.Error(t, err)
+}
@@ -37,3 +37,22 @@ func (*noopMetrics) OpsErrorCount(_ context.Context, _ int64, _ []MetricAttr)
 func (*noopMetrics) FileCacheReadCount(_ context.Context, _ int64, _ []MetricAttr)         {}
 func (*noopMetrics) FileCacheReadBytesCount(_ context.Context, _ int64, _ []MetricAttr)    {}
 func (*noopMetrics) FileCacheReadLatency(_ context.Context, value float64, _ []MetricAttr) {}
+
+func (*noopMetrics) InodeCount(_ context.Context, _ int64, _ []MetricAttr)                   {}
+func (*noopMetrics) HandleCount(_ context.Context, _ int64, _ []MetricAttr)                  {}
+func (*noopMetrics) AllocatedBlockCount(_ context.Context, _ int64, _ []MetricAttr)          {}
+func (*noopMetrics) FreeBlockCount(_ context.Context, _ int64, _ []MetricAttr)               {}
+func (*noopMetrics) BlockWaitLatency(_ context.Context, _ float64, _ []MetricAttr)           {}
+func (*noopMetrics) PendingUploadBytes(_ context.Context, _ int64, _ []MetricAttr)           {}
+func (*noopMetrics) DownloadJobCount(_ context.Context, _ int64, _ []MetricAttr)             {}
+func (*noopMetrics) DownloadJobFailureCount(_ context.Context, _ int64, _ []MetricAttr)      {}
+func (*noopMetrics) AuditWriteFailureCount(_ context.Context, _ int64, _ []MetricAttr)       {}
+func (*noopMetrics) MultiRangeDownloaderRefCount(_ context.Context, _ int64, _ []MetricAttr) {}
+
+func (*noopMetrics) CacheEntryCount(_ context.Context, _ int64, _ []MetricAttr)    {}
+func (*noopMetrics) CacheSizeBytes(_ context.Context, _ int64, _ []MetricAttr)     {}
+func (*noopMetrics) CacheEvictionCount(_ context.Context, _ int64, _ []MetricAttr) {}
+
+func (*noopMetrics) AttributedOpsCount(_ context.Context, _ int64, _ []MetricAttr)        {}
+func (*noopMetrics) AttributedBytesCount(_ context.Context, _ int64, _ []MetricAttr)      {}
+func (*noopMetrics) AttributedGCSRequestCount(_ context.Context, _ int64, _ []MetricAttr) {}
@@ -45,6 +45,53 @@ const (
 
 	// CacheHit annotates the read operation from file cache with true or false.
 	CacheHit = "cache_hit"
+
+	// InodeType annotates the inode count with the type - File/Dir/Symlink.
+	InodeType = "inode_type"
+
+	// HandleType annotates the handle count with the type - File/Dir.
+	HandleType = "handle_type"
+
+	// CacheType annotates the cache metrics with the cache - Stat/Type/File.
+	CacheType = "cache_type"
+
+	// Caller annotates the attributed metrics with the cgroup or command of
+	// the process that issued the op.
+	Caller = "caller"
+
+	// PathPrefix annotates the attributed metrics with the leading directories
+	// of the path the op is for.
+	PathPrefix = "path_prefix"
+
+	// IODirection annotates the attributed bytes with the direction -
+	// Read/Write/GCSRead.
+	IODirection = "io_direction"
+
+	// GCSClass annotates the attributed GCS requests with the operation class
+	// they're billed as - A/B/Free.
+	GCSClass = "gcs_class"
+)
+
+// Values of the InodeType, HandleType and CacheType attributes.
+const (
+	FileType    = "File"
+	DirType     = "Dir"
+	SymlinkType = "Symlink"
+
+	StatCache = "Stat"
+	TypeCache = "Type"
+	FileCache = "File"
+)
+
+// Values of the IODirection and GCSClass attributes.
+const (
+	ReadDirection    = "Read"
+	WriteDirection   = "Write"
+	GCSReadDirection = "GCSRead"
+
+	GCSClassA    = "A"
+	GCSClassB    = "B"
+	GCSClassFree = "Free"
 )
 
 type ocMetrics struct {
@@ -65,6 +112,28 @@ type ocMetrics struct {
 	fileCacheReadCount      *stats.Int64Measure
 	fileCacheReadBytesCount *stats.Int64Measure
 	fileCacheReadLatency    *stats.Float64Measure
+
+	// State measures
+	inodeCount                   *stats.Int64Measure
+	handleCount                  *stats.Int64Measure
+	allocatedBlockCount          *stats.Int64Measure
+	freeBlockCount               *stats.Int64Measure
+	blockWaitLatency             *stats.Float64Measure
+	pendingUploadBytes           *stats.Int64Measure
+	downloadJobCount             *stats.Int64Measure
+	downloadJobFailureCount      *stats.Int64Measure
+	auditWriteFailureCount       *stats.Int64Measure
+	multiRangeDownloaderRefCount *stats.Int64Measure
+
+	// Cache measures
+	cacheEntryCount    *stats.Int64Measure
+	cacheSizeBytes     *stats.Int64Measure
+	cacheEvictionCount *stats.Int64Measure
+
+	// Attribution measures
+	attributedOpsCount        *stats.Int64Measure
+	attributedBytesCount      *stats.Int64Measure
+	attributedGCSRequestCount *stats.Int64Measure
 }
 
 func attrsToTags(attrs []MetricAttr) []tag.Mutator {
@@ -133,6 +202,57 @@ func (o *ocMetrics) FileCacheReadLatency(ctx context.Context, value float64, att
 	recordOCLatencyMetric(ctx, o.fileCacheReadLatency, value, attrs, "file cache read latency")
 }
 
+func (o *ocMetrics) InodeCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.inodeCount, inc, attrs, "inode count")
+}
+func (o *ocMetrics) HandleCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.handleCount, inc, attrs, "handle count")
+}
+func (o *ocMetrics) AllocatedBlockCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.allocatedBlockCount, inc, attrs, "allocated block count")
+}
+func (o *ocMetrics) FreeBlockCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.freeBlockCount, inc, attrs, "free block count")
+}
+func (o *ocMetrics) BlockWaitLatency(ctx context.Context, value float64, attrs []MetricAttr) {
+	recordOCLatencyMetric(ctx, o.blockWaitLatency, value, attrs, "block wait latency")
+}
+func (o *ocMetrics) PendingUploadBytes(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.pendingUploadBytes, inc, attrs, "pending upload bytes")
+}
+func (o *ocMetrics) DownloadJobCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.downloadJobCount, inc, attrs, "download job count")
+}
+func (o *ocMetrics) DownloadJobFailureCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.downloadJobFailureCount, inc, attrs, "download job failure count")
+}
+func (o *ocMetrics) AuditWriteFailureCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.auditWriteFailureCount, inc, attrs, "audit write failure count")
+}
+func (o *ocMetrics) MultiRangeDownloaderRefCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.multiRangeDownloaderRefCount, inc, attrs, "multi range downloader ref count")
+}
+
+func (o *ocMetrics) CacheEntryCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.cacheEntryCount, inc, attrs, "cache entry count")
+}
+func (o *ocMetrics) CacheSizeBytes(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.cacheSizeBytes, inc, attrs, "cache size bytes")
+}
+func (o *ocMetrics) CacheEvictionCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.cacheEvictionCount, inc, attrs, "cache eviction count")
+}
+
+func (o *ocMetrics) AttributedOpsCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.attributedOpsCount, inc, attrs, "attributed ops count")
+}
+func (o *ocMetrics) AttributedBytesCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.attributedBytesCount, inc, attrs, "attributed bytes count")
+}
+func (o *ocMetrics) AttributedGCSRequestCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	recordOCMetric(ctx, o.attributedGCSRequestCount, inc, attrs, "attributed GCS request count")
+}
+
 func recordOCMetric(ctx context.Context, m *stats.Int64Measure, inc int64, attrs []MetricAttr, metricStr string) {
 	if err := stats.RecordWithTags(
 		ctx,
@@ -168,7 +288,28 @@ func NewOCMetrics() (MetricHandle, error) {
 	fileCacheReadCount := stats.Int64("file_cache/read_count", "Specifies the number of read requests made via file cache along with type - Sequential/Random and cache hit - true/false", stats.UnitDimensionless)
 	fileCacheReadBytesCount := stats.Int64("file_cache/read_bytes_count", "The cumulative number of bytes read from file cache along with read type - Sequential/Random", stats.UnitBytes)
 	fileCacheReadLatency := stats.Float64("file_cache/read_latency", "Latency of read from file cache along with cache hit - true/false", "us")
-	// OpenCensus views (aggregated measures)
+
+	inodeCount := stats.Int64("fs/inode_count", "The change in the number of live inodes along with inode type - File/Dir/Symlink", stats.UnitDimensionless)
+	handleCount := stats.Int64("fs/handle_count", "The change in the number of open handles along with handle type - File/Dir", stats.UnitDimensionless)
+	allocatedBlockCount := stats.Int64("buffered_write/allocated_block_count", "The change in the number of blocks allocated by the buffered write block pools.", stats.UnitDimensionless)
+	freeBlockCount := stats.Int64("buffered_write/free_block_count", "The change in the number of allocated blocks that are free for reuse.", stats.UnitDimensionless)
+	blockWaitLatency := stats.Float64("buffered_write/block_wait_latency", "The time spent waiting for a block when the global block limit is reached.", "us")
+	pendingUploadBytes := stats.Int64("buffered_write/pending_upload_bytes", "The change in the number of buffered bytes waiting to be uploaded to GCS.", stats.UnitBytes)
+	downloadJobCount := stats.Int64("file_cache/download_job_count", "The change in the number of file cache download jobs in flight.", stats.UnitDimensionless)
+	downloadJobFailureCount := stats.Int64("file_cache/download_job_failure_count", "The number of file cache download jobs that failed.", stats.UnitDimensionless)
+	auditWriteFailureCount := stats.Int64("audit/write_failure_count", "The number of audit records that couldn't be written.", stats.UnitDimensionless)
+	multiRangeDownloaderRefCount := stats.Int64("gcs/multi_range_downloader_ref_count", "The change in the number of references held on multi range downloaders.", stats.UnitDimensionless)
+
+	cacheEntryCount := stats.Int64("cache/entry_count", "The change in the number of entries in a cache along with cache type - Stat/Type/File", stats.UnitDimensionless)
+	cacheSizeBytes := stats.Int64("cache/size_bytes", "The change in the size of the entries in a cache along with cache type - Stat/Type/File", stats.UnitBytes)
+	cacheEvictionCount := stats.Int64("cache/eviction_count", "The number of entries evicted from a cache along with cache type - Stat/Type/File", stats.UnitDimensionless)
+
+	attributedOpsCount := stats.Int64("attribution/ops_count", "The number of file system ops along with caller and path prefix", stats.UnitDimensionless)
+	attributedBytesCount := stats.Int64("attribution/bytes_count", "The number of bytes read, written or read from GCS along with caller, path prefix and direction - Read/Write/GCSRead", stats.UnitBytes)
+	attributedGCSRequestCount := stats.Int64("attribution/gcs_request_count", "The number of GCS requests triggered by file system ops along with caller, path prefix and class - A/B/Free", stats.UnitDimensionless)
+
+	// OpenCensus views (aggregated measures). The sum of the changes recorded
+	// for a gauge is its current value.
 	if err := view.Register(
 		&view.View{
 			Name:        "gcs/read_bytes_count",
@@ -253,6 +394,113 @@ func NewOCMetrics() (MetricHandle, error) {
 			Description: "The cumulative distribution of the file cache read latencies along with cache hit - true/false",
 			Aggregation: ochttp.DefaultLatencyDistribution,
 			TagKeys:     []tag.Key{tag.MustNewKey(CacheHit)},
+		},
+		// State related metrics
+		&view.View{
+			Name:        "fs/inode_count",
+			Measure:     inodeCount,
+			Description: "The number of live inodes along with inode type - File/Dir/Symlink",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(InodeType)},
+		},
+		&view.View{
+			Name:        "fs/handle_count",
+			Measure:     handleCount,
+			Description: "The number of open handles along with handle type - File/Dir",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(HandleType)},
+		},
+		&view.View{
+			Name:        "buffered_write/allocated_block_count",
+			Measure:     allocatedBlockCount,
+			Description: "The number of blocks allocated by the buffered write block pools.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "buffered_write/free_block_count",
+			Measure:     freeBlockCount,
+			Description: "The number of allocated blocks that are free for reuse.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "buffered_write/block_wait_latencies",
+			Measure:     blockWaitLatency,
+			Description: "The cumulative distribution of the time spent waiting for a block when the global block limit is reached.",
+			Aggregation: ochttp.DefaultLatencyDistribution,
+		},
+		&view.View{
+			Name:        "buffered_write/pending_upload_bytes",
+			Measure:     pendingUploadBytes,
+			Description: "The number of buffered bytes waiting to be uploaded to GCS.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "file_cache/download_job_count",
+			Measure:     downloadJobCount,
+			Description: "The number of file cache download jobs in flight.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "file_cache/download_job_failure_count",
+			Measure:     downloadJobFailureCount,
+			Description: "The cumulative number of file cache download jobs that failed.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "audit/write_failure_count",
+			Measure:     auditWriteFailureCount,
+			Description: "The cumulative number of audit records that couldn't be written.",
+			Aggregation: view.Sum(),
+		},
+		&view.View{
+			Name:        "gcs/multi_range_downloader_ref_count",
+			Measure:     multiRangeDownloaderRefCount,
+			Description: "The total number of references held on multi range downloaders.",
+			Aggregation: view.Sum(),
+		},
+		// Cache related metrics
+		&view.View{
+			Name:        "cache/entry_count",
+			Measure:     cacheEntryCount,
+			Description: "The number of entries in a cache along with cache type - Stat/Type/File",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(CacheType)},
+		},
+		&view.View{
+			Name:        "cache/size_bytes",
+			Measure:     cacheSizeBytes,
+			Description: "The size of the entries in a cache along with cache type - Stat/Type/File",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(CacheType)},
+		},
+		&view.View{
+			Name:        "cache/eviction_count",
+			Measure:     cacheEvictionCount,
+			Description: "The cumulative number of entries evicted from a cache along with cache type - Stat/Type/File",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(CacheType)},
+		},
+		// Attribution related metrics
+		&view.View{
+			Name:        "attribution/ops_count",
+			Measure:     attributedOpsCount,
+			Description: "The cumulative number of file system ops along with caller and path prefix",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(Caller), tag.MustNewKey(PathPrefix)},
+		},
+		&view.View{
+			Name:        "attribution/bytes_count",
+			Measure:     attributedBytesCount,
+			Description: "The cumulative number of bytes read, written or read from GCS along with caller, path prefix and direction - Read/Write/GCSRead",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(Caller), tag.MustNewKey(PathPrefix), tag.MustNewKey(IODirection)},
+		},
+		&view.View{
+			Name:        "attribution/gcs_request_count",
+			Measure:     attributedGCSRequestCount,
+			Description: "The cumulative number of GCS requests triggered by file system ops along with caller, path prefix and class - A/B/Free",
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{tag.MustNewKey(Caller), tag.MustNewKey(PathPrefix), tag.MustNewKey(GCSClass)},
 		}); err != nil {
 		return nil, fmt.Errorf("failed to register OpenCensus metrics for GCS client library: %w", err)
 	}
@@ -271,5 +519,24 @@ func NewOCMetrics() (MetricHandle, error) {
 		fileCacheReadCount:      fileCacheReadCount,
 		fileCacheReadBytesCount: fileCacheReadBytesCount,
 		fileCacheReadLatency:    fileCacheReadLatency,
+
+		inodeCount:                   inodeCount,
+		handleCount:                  handleCount,
+		allocatedBlockCount:          allocatedBlockCount,
+		freeBlockCount:               freeBlockCount,
+		blockWaitLatency:             blockWaitLatency,
+		pendingUploadBytes:           pendingUploadBytes,
+		downloadJobCount:             downloadJobCount,
+		downloadJobFailureCount:      downloadJobFailureCount,
+		auditWriteFailureCount:       auditWriteFailureCount,
+		multiRangeDownloaderRefCount: multiRangeDownloaderRefCount,
+
+		cacheEntryCount:    cacheEntryCount,
+		cacheSizeBytes:     cacheSizeBytes,
+		cacheEvictionCount: cacheEvictionCount,
+
+		attributedOpsCount:        attributedOpsCount,
+		attributedBytesCount:      attributedBytesCount,
+		attributedGCSRequestCount: attributedGCSRequestCount,
 	}, nil
 }
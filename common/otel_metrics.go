@@ -26,6 +26,9 @@ var (
 	fsOpsMeter     = otel.Meter("fs_op")
 	gcsMeter       = otel.Meter("gcs")
 	fileCacheMeter = otel.Meter("file_cache")
+	stateMeter     = otel.Meter("state")
+	cacheMeter     = otel.Meter("cache")
+	attrMeter      = otel.Meter("attribution")
 )
 
 // otelMetrics maintains the list of all metrics computed in GCSFuse.
@@ -44,6 +47,25 @@ type otelMetrics struct {
 	fileCacheReadCount      metric.Int64Counter
 	fileCacheReadBytesCount metric.Int64Counter
 	fileCacheReadLatency    metric.Float64Histogram
+
+	inodeCount                   metric.Int64UpDownCounter
+	handleCount                  metric.Int64UpDownCounter
+	allocatedBlockCount          metric.Int64UpDownCounter
+	freeBlockCount               metric.Int64UpDownCounter
+	blockWaitLatency             metric.Float64Histogram
+	pendingUploadBytes           metric.Int64UpDownCounter
+	downloadJobCount             metric.Int64UpDownCounter
+	downloadJobFailureCount      metric.Int64Counter
+	auditWriteFailureCount       metric.Int64Counter
+	multiRangeDownloaderRefCount metric.Int64UpDownCounter
+
+	cacheEntryCount    metric.Int64UpDownCounter
+	cacheSizeBytes     metric.Int64UpDownCounter
+	cacheEvictionCount metric.Int64Counter
+
+	attributedOpsCount        metric.Int64Counter
+	attributedBytesCount      metric.Int64Counter
+	attributedGCSRequestCount metric.Int64Counter
 }
 
 func (o *otelMetrics) GCSReadBytesCount(ctx context.Context, inc int64, attrs []MetricAttr) {
@@ -94,6 +116,70 @@ func (o *otelMetrics) FileCacheReadLatency(ctx context.Context, value float64, a
 	o.fileCacheReadLatency.Record(ctx, value, attrsToRecordOption(attrs)...)
 }
 
+func (o *otelMetrics) InodeCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.inodeCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) HandleCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.handleCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) AllocatedBlockCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.allocatedBlockCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) FreeBlockCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.freeBlockCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) BlockWaitLatency(ctx context.Context, value float64, attrs []MetricAttr) {
+	o.blockWaitLatency.Record(ctx, value, attrsToRecordOption(attrs)...)
+}
+
+func (o *otelMetrics) PendingUploadBytes(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.pendingUploadBytes.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) DownloadJobCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.downloadJobCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) DownloadJobFailureCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.downloadJobFailureCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) AuditWriteFailureCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.auditWriteFailureCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) MultiRangeDownloaderRefCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.multiRangeDownloaderRefCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) CacheEntryCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.cacheEntryCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) CacheSizeBytes(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.cacheSizeBytes.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) CacheEvictionCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.cacheEvictionCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) AttributedOpsCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.attributedOpsCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) AttributedBytesCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.attributedBytesCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
+func (o *otelMetrics) AttributedGCSRequestCount(ctx context.Context, inc int64, attrs []MetricAttr) {
+	o.attributedGCSRequestCount.Add(ctx, inc, attrsToAddOption(attrs)...)
+}
+
 func NewOTelMetrics() (MetricHandle, error) {
 	fsOpsCount, err1 := fsOpsMeter.Int64Counter("fs/ops_count", metric.WithDescription("The cumulative number of ops processed by the file system."))
 	fsOpsLatency, err2 := fsOpsMeter.Float64Histogram("fs/ops_latency", metric.WithDescription("The cumulative distribution of file system operation latencies"), metric.WithUnit("us"),
@@ -119,7 +205,32 @@ func NewOTelMetrics() (MetricHandle, error) {
 		metric.WithUnit("us"),
 		defaultLatencyDistribution)
 
-	if err := errors.Join(err1, err2, err3, err4, err5, err6, err7, err8, err9, err10, err11, err12); err != nil {
+	inodeCount, err13 := stateMeter.Int64UpDownCounter("fs/inode_count", metric.WithDescription("The number of live inodes along with inode type - File/Dir/Symlink"))
+	handleCount, err14 := stateMeter.Int64UpDownCounter("fs/handle_count", metric.WithDescription("The number of open handles along with handle type - File/Dir"))
+	allocatedBlockCount, err15 := stateMeter.Int64UpDownCounter("buffered_write/allocated_block_count", metric.WithDescription("The number of blocks allocated by the buffered write block pools."))
+	freeBlockCount, err16 := stateMeter.Int64UpDownCounter("buffered_write/free_block_count", metric.WithDescription("The number of allocated blocks that are free for reuse."))
+	blockWaitLatency, err17 := stateMeter.Float64Histogram("buffered_write/block_wait_latencies",
+		metric.WithDescription("The cumulative distribution of the time spent waiting for a block when the global block limit is reached."),
+		metric.WithUnit("us"),
+		defaultLatencyDistribution)
+	pendingUploadBytes, err18 := stateMeter.Int64UpDownCounter("buffered_write/pending_upload_bytes", metric.WithDescription("The number of buffered bytes waiting to be uploaded to GCS."), metric.WithUnit("By"))
+	downloadJobCount, err19 := stateMeter.Int64UpDownCounter("file_cache/download_job_count", metric.WithDescription("The number of file cache download jobs in flight."))
+	downloadJobFailureCount, err20 := stateMeter.Int64Counter("file_cache/download_job_failure_count", metric.WithDescription("The cumulative number of file cache download jobs that failed."))
+	multiRangeDownloaderRefCount, err21 := stateMeter.Int64UpDownCounter("gcs/multi_range_downloader_ref_count", metric.WithDescription("The total number of references held on multi range downloaders."))
+
+	cacheEntryCount, err22 := cacheMeter.Int64UpDownCounter("cache/entry_count", metric.WithDescription("The number of entries in a cache along with cache type - Stat/Type/File"))
+	cacheSizeBytes, err23 := cacheMeter.Int64UpDownCounter("cache/size_bytes", metric.WithDescription("The size of the entries in a cache along with cache type - Stat/Type/File"), metric.WithUnit("By"))
+	cacheEvictionCount, err24 := cacheMeter.Int64Counter("cache/eviction_count", metric.WithDescription("The cumulative number of entries evicted from a cache along with cache type - Stat/Type/File"))
+
+	attributedOpsCount, err25 := attrMeter.Int64Counter("attribution/ops_count", metric.WithDescription("The cumulative number of file system ops along with caller and path prefix"))
+	attributedBytesCount, err26 := attrMeter.Int64Counter("attribution/bytes_count", metric.WithDescription("The cumulative number of bytes read, written or read from GCS along with caller, path prefix and direction - Read/Write/GCSRead"), metric.WithUnit("By"))
+	attributedGCSRequestCount, err27 := attrMeter.Int64Counter("attribution/gcs_request_count", metric.WithDescription("The cumulative number of GCS requests triggered by file system ops along with caller, path prefix and class - A/B/Free"))
+
+	auditWriteFailureCount, err28 := stateMeter.Int64Counter("audit/write_failure_count", metric.WithDescription("The cumulative number of audit records that couldn't be written."))
+
+	if err := errors.Join(err1, err2, err3, err4, err5, err6, err7, err8, err9, err10, err11, err12,
+		err13, err14, err15, err16, err17, err18, err19, err20, err21, err22, err23, err24,
+		err25, err26, err27, err28); err != nil {
 		return nil, err
 	}
 	return &otelMetrics{
@@ -135,5 +246,24 @@ func NewOTelMetrics() (MetricHandle, error) {
 		fileCacheReadCount:      fileCacheReadCount,
 		fileCacheReadBytesCount: fileCacheReadBytesCount,
 		fileCacheReadLatency:    fileCacheReadLatency,
+
+		inodeCount:                   inodeCount,
+		handleCount:                  handleCount,
+		allocatedBlockCount:          allocatedBlockCount,
+		freeBlockCount:               freeBlockCount,
+		blockWaitLatency:             blockWaitLatency,
+		pendingUploadBytes:           pendingUploadBytes,
+		downloadJobCount:             downloadJobCount,
+		downloadJobFailureCount:      downloadJobFailureCount,
+		auditWriteFailureCount:       auditWriteFailureCount,
+		multiRangeDownloaderRefCount: multiRangeDownloaderRefCount,
+
+		cacheEntryCount:    cacheEntryCount,
+		cacheSizeBytes:     cacheSizeBytes,
+		cacheEvictionCount: cacheEvictionCount,
+
+		attributedOpsCount:        attributedOpsCount,
+		attributedBytesCount:      attributedBytesCount,
+		attributedGCSRequestCount: attributedGCSRequestCount,
 	}, nil
 }
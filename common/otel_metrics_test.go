@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOTelMetrics_StateGauges(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	m, err := NewOTelMetrics()
+	require.NoError(t, err)
+	ctx := context.Background()
+	dirAttrs := []MetricAttr{{Key: InodeType, Value: DirType}}
+	fileAttrs := []MetricAttr{{Key: InodeType, Value: FileType}}
+	statAttrs := []MetricAttr{{Key: CacheType, Value: StatCache}}
+
+	m.InodeCount(ctx, 3, dirAttrs)
+	m.InodeCount(ctx, -1, dirAttrs)
+	m.InodeCount(ctx, 1, fileAttrs)
+	m.CacheSizeBytes(ctx, 100, statAttrs)
+	m.CacheSizeBytes(ctx, -40, statAttrs)
+	m.BlockWaitLatency(ctx, 5, nil)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	inodes, ok := metrics["fs/inode_count"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	assert.False(t, inodes.IsMonotonic)
+	values := make(map[string]int64)
+	for _, dp := range inodes.DataPoints {
+		v, _ := dp.Attributes.Value(attribute.Key(InodeType))
+		values[v.AsString()] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{DirType: 2, FileType: 1}, values)
+	size, ok := metrics["cache/size_bytes"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, size.DataPoints, 1)
+	assert.Equal(t, int64(60), size.DataPoints[0].Value)
+	wait, ok := metrics["buffered_write/block_wait_latencies"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, wait.DataPoints, 1)
+	assert.Equal(t, uint64(1), wait.DataPoints[0].Count)
+}
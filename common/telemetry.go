@@ -71,10 +71,49 @@ type FileCacheMetricHandle interface {
 	FileCacheReadBytesCount(ctx context.Context, inc int64, attrs []MetricAttr)
 	FileCacheReadLatency(ctx context.Context, value float64, attrs []MetricAttr)
 }
+
+// StateMetricHandle records the internal state of a mount. Apart from the
+// latency and failure count, the metrics are gauges that are recorded as
+// positive or negative increments to the current value.
+type StateMetricHandle interface {
+	InodeCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	HandleCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	AllocatedBlockCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	FreeBlockCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	BlockWaitLatency(ctx context.Context, value float64, attrs []MetricAttr)
+	PendingUploadBytes(ctx context.Context, inc int64, attrs []MetricAttr)
+	DownloadJobCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	DownloadJobFailureCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	AuditWriteFailureCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	MultiRangeDownloaderRefCount(ctx context.Context, inc int64, attrs []MetricAttr)
+}
+
+// CacheMetricHandle records the state of the in-memory LRU caches, i.e. the
+// stat, type and file caches, distinguished by the CacheType attribute. The
+// entry count and size are gauges recorded as increments.
+type CacheMetricHandle interface {
+	CacheEntryCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	CacheSizeBytes(ctx context.Context, inc int64, attrs []MetricAttr)
+	CacheEvictionCount(ctx context.Context, inc int64, attrs []MetricAttr)
+}
+
+// AttributionMetricHandle records the file system ops, bytes and GCS requests
+// attributed to a caller and path prefix, i.e. with the Caller and PathPrefix
+// attributes. The cardinality of the attributes is bounded by the attribution
+// tracker, which folds the keys above its limit into a single one.
+type AttributionMetricHandle interface {
+	AttributedOpsCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	AttributedBytesCount(ctx context.Context, inc int64, attrs []MetricAttr)
+	AttributedGCSRequestCount(ctx context.Context, inc int64, attrs []MetricAttr)
+}
+
 type MetricHandle interface {
 	GCSMetricHandle
 	OpsMetricHandle
 	FileCacheMetricHandle
+	StateMetricHandle
+	CacheMetricHandle
+	AttributionMetricHandle
 }
 
 func CaptureGCSReadMetrics(ctx context.Context, metricHandle MetricHandle, readType string, requestedDataSize int64) {
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var _ afero.File = &File{}
+
+// Afero returns fsys as an afero.Fs. Unlike FS, it accepts rooted and
+// unclean paths, e.g. "/a/../b", which are taken relative to the root of the
+// file system.
+func (fsys *FS) Afero() afero.Fs {
+	return aferoFS{fsys: fsys}
+}
+
+type aferoFS struct {
+	fsys *FS
+}
+
+// Convert an afero path to one accepted by FS.
+func aferoPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
+
+func (a aferoFS) Name() string {
+	return "gcsfuse"
+}
+
+func (a aferoFS) Create(name string) (afero.File, error) {
+	f, err := a.fsys.Create(aferoPath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (a aferoFS) Open(name string) (afero.File, error) {
+	return a.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (a aferoFS) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	f, err := a.fsys.OpenFile(aferoPath(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (a aferoFS) Mkdir(name string, perm os.FileMode) error {
+	return a.fsys.Mkdir(aferoPath(name), perm)
+}
+
+func (a aferoFS) MkdirAll(name string, perm os.FileMode) error {
+	return a.fsys.MkdirAll(aferoPath(name), perm)
+}
+
+func (a aferoFS) Remove(name string) error {
+	return a.fsys.Remove(aferoPath(name))
+}
+
+func (a aferoFS) RemoveAll(name string) error {
+	return a.fsys.RemoveAll(aferoPath(name))
+}
+
+func (a aferoFS) Rename(oldname, newname string) error {
+	return a.fsys.Rename(aferoPath(oldname), aferoPath(newname))
+}
+
+func (a aferoFS) Stat(name string) (os.FileInfo, error) {
+	return a.fsys.Stat(aferoPath(name))
+}
+
+func (a aferoFS) Chmod(name string, mode os.FileMode) error {
+	return a.fsys.Chmod(aferoPath(name), mode)
+}
+
+func (a aferoFS) Chown(name string, uid, gid int) error {
+	return a.fsys.Chown(aferoPath(name), uid, gid)
+}
+
+func (a aferoFS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return a.fsys.Chtimes(aferoPath(name), atime, mtime)
+}
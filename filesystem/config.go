@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/health"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/mount"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/jacobsa/fuse"
+	"github.com/jacobsa/timeutil"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Bucket is a bucket that the file system can serve in place of a GCS bucket,
+// as returned by NewFakeBucket.
+type Bucket = gcsx.ServedBucket
+
+// StorageHandle is a connection to GCS.
+type StorageHandle = storage.StorageHandle
+
+// Admin administers a file system while it's served.
+type Admin = fs.Admin
+
+// Reconfiguration reports what Admin.Reconfigure made of a new config.
+type Reconfiguration = fs.Reconfiguration
+
+// ConsumerReport ranks the callers and path prefixes by their usage, as
+// returned by Admin.TopConsumers.
+type ConsumerReport = attribution.Report
+
+// ConsumerMeasure is what Admin.TopConsumers ranks by, e.g. ByOps.
+type ConsumerMeasure = attribution.Measure
+
+// The measures Admin.TopConsumers can rank by.
+const (
+	ByOps          = attribution.ByOps
+	ByBytesRead    = attribution.ByBytesRead
+	ByBytesWritten = attribution.ByBytesWritten
+	ByGCSBytesRead = attribution.ByGCSBytesRead
+	ByGCSRequests  = attribution.ByGCSRequests
+	ByGCSClassA    = attribution.ByGCSClassA
+	ByGCSClassB    = attribution.ByGCSClassB
+)
+
+// HealthMonitor checks the liveness and readiness of a file system.
+type HealthMonitor = health.Monitor
+
+// NewHealthMonitor returns a health monitor with the thresholds of the given
+// config.
+func NewHealthMonitor(c *cfg.Config) *HealthMonitor {
+	return health.NewMonitor(health.Config{
+		ProbeTimeout:        c.Health.ProbeTimeout,
+		BucketProbeInterval: c.Health.BucketProbeInterval,
+		StallThreshold:      c.Health.StallThreshold,
+		MaxErrorRate:        c.Health.MaxErrorRate,
+		MaxStallRate:        c.Health.MaxStallRate,
+	})
+}
+
+// MountPointProbe returns a liveness probe for a HealthMonitor that calls
+// statfs on the given mount point, which fails to finish while the mount is
+// hung.
+func MountPointProbe(mountPoint string) func(ctx context.Context) error {
+	return health.MountPointProbe(mountPoint)
+}
+
+// NewFakeBucket returns an empty in-memory bucket, for testing code that uses
+// the file system without GCS.
+func NewFakeBucket(name string, hierarchical bool) *Bucket {
+	return gcsx.NewServedBucket(fake.NewFakeBucket(timeutil.RealClock(), name, gcs.BucketType{Hierarchical: hierarchical}))
+}
+
+// DefaultConfig returns the configuration of the gcsfuse binary when no flags
+// or config file are given.
+func DefaultConfig() (*cfg.Config, error) {
+	flagSet := pflag.NewFlagSet("gcsfuse", pflag.ContinueOnError)
+	if err := cfg.BuildFlagSet(flagSet); err != nil {
+		return nil, fmt.Errorf("error while declaring flags: %w", err)
+	}
+
+	v := viper.New()
+	if err := cfg.BindFlags(v, flagSet); err != nil {
+		return nil, fmt.Errorf("error while binding flags: %w", err)
+	}
+
+	var c cfg.Config
+	if err := v.Unmarshal(&c, viper.DecodeHook(cfg.DecodeHook()), func(decoderConfig *mapstructure.DecoderConfig) {
+		// By default, viper supports mapstructure tags for unmarshalling. Override that to support yaml tag.
+		decoderConfig.TagName = "yaml"
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.ValidateConfig(v, &c); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Rationalize(v, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func fuseMountConfig(fsName string, newConfig *cfg.Config) *fuse.MountConfig {
+	// Handle the repeated "-o" flag.
+	parsedOptions := make(map[string]string)
+	for _, o := range newConfig.FileSystem.FuseOptions {
+		mount.ParseOptions(parsedOptions, o)
+	}
+
+	mountCfg := &fuse.MountConfig{
+		FSName:     fsName,
+		Subtype:    "gcsfuse",
+		VolumeName: "gcsfuse",
+		Options:    parsedOptions,
+		// Allows parallel LookUpInode & ReadDir calls from Kernel's FUSE driver.
+		// GCSFuse takes exclusive lock on directory inodes during ReadDir call,
+		// hence there is no effect of parallelization of incoming ReadDir calls
+		// from FUSE driver for user of GCSFuse. However, in case of LookUpInode
+		// calls, GCSFuse takes read only lock during LookUpInode call which helps
+		// users experience the performance gains. E.g. if a user workload tries to
+		// access two files under same directory parallely, then the lookups also
+		// happen parallely.
+		EnableParallelDirOps: !(newConfig.FileSystem.DisableParallelDirops),
+		// We disable write-back cache when streaming writes are enabled.
+		DisableWritebackCaching: newConfig.Write.EnableStreamingWrites,
+	}
+
+	mountCfg.ErrorLogger = logger.FUSE.NewLegacyLogger(logger.LevelError, "fuse: ")
+	mountCfg.DebugLogger = logger.FUSE.NewLegacyLogger(logger.LevelTrace, "fuse_debug: ")
+	return mountCfg
+}
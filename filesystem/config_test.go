@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package cmd
+package filesystem
 
 import (
 	"testing"
@@ -21,7 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestGetFuseMountConfig_MountOptionsFormattedCorrectly(t *testing.T) {
+func TestFuseMountConfig_MountOptionsFormattedCorrectly(t *testing.T) {
 	testCases := []struct {
 		name                string
 		inputFuseOptions    []string
@@ -57,7 +57,7 @@ func TestGetFuseMountConfig_MountOptionsFormattedCorrectly(t *testing.T) {
 			},
 		}
 
-		fuseMountCfg := getFuseMountConfig(fsName, newConfig)
+		fuseMountCfg := fuseMountConfig(fsName, newConfig)
 
 		assert.Equal(t, fsName, fuseMountCfg.FSName)
 		assert.Equal(t, "gcsfuse", fuseMountCfg.Subtype)
@@ -0,0 +1,427 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"syscall"
+
+	gcsfs "github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+// File is a file or directory opened through FS. Like an *os.File, a File
+// keeps the file open until Close is called.
+type File struct {
+	fsys *FS
+	name string
+	f    *gcsfs.InProcessFile
+	dir  bool
+	flag int
+
+	mu sync.Mutex
+
+	// The offset used by Read, Write and Seek.
+	//
+	// GUARDED_BY(mu)
+	offset int64
+
+	// The entries of a directory not yet returned by ReadDir, read on the
+	// first call.
+	//
+	// GUARDED_BY(mu)
+	entries     []fs.DirEntry
+	entriesRead bool
+
+	// GUARDED_BY(mu)
+	closed bool
+}
+
+var (
+	_ fs.ReadDirFile = &File{}
+	_ io.ReaderAt    = &File{}
+	_ io.WriterAt    = &File{}
+	_ io.Seeker      = &File{}
+)
+
+// Name returns the name the file was opened with.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Stat returns information about the file.
+func (f *File) Stat() (fs.FileInfo, error) {
+	attrs, err := f.f.Stat(f.fsys.ctx)
+	if err != nil {
+		return nil, pathError("stat", f.name, err)
+	}
+
+	return &fileInfo{name: path.Base(f.name), attrs: attrs}, nil
+}
+
+// Read reads from the file at the current offset.
+func (f *File) Read(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err = f.checkReadable("read"); err != nil {
+		return
+	}
+
+	n, err = f.readAt(p, f.offset)
+	f.offset += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+
+	return
+}
+
+// ReadAt reads len(p) bytes from the file at off, returning io.EOF if there
+// are fewer.
+func (f *File) ReadAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err = f.checkReadable("read"); err != nil {
+		return
+	}
+
+	if off < 0 {
+		return 0, pathError("readat", f.name, errors.New("negative offset"))
+	}
+
+	return f.readAt(p, off)
+}
+
+// Write writes to the file at the current offset, or at its end if it was
+// opened with os.O_APPEND.
+func (f *File) Write(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err = f.checkWritable("write"); err != nil {
+		return
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		var size int64
+		if size, err = f.size(); err != nil {
+			return 0, pathError("write", f.name, err)
+		}
+		f.offset = size
+	}
+
+	n, err = f.writeAt(p, f.offset)
+	f.offset += int64(n)
+	return
+}
+
+// WriteString is like Write, but writes the contents of s.
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// WriteAt writes p to the file at off.
+func (f *File) WriteAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err = f.checkWritable("write"); err != nil {
+		return
+	}
+
+	if f.flag&os.O_APPEND != 0 {
+		return 0, errors.New("filesystem: invalid use of WriteAt on file opened with O_APPEND")
+	}
+
+	if off < 0 {
+		return 0, pathError("writeat", f.name, errors.New("negative offset"))
+	}
+
+	return f.writeAt(p, off)
+}
+
+// Seek sets the offset for the next Read or Write, like os.File.Seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, pathError("seek", f.name, fs.ErrClosed)
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, err := f.size()
+		if err != nil {
+			return 0, pathError("seek", f.name, err)
+		}
+		offset += size
+	default:
+		return 0, pathError("seek", f.name, fs.ErrInvalid)
+	}
+
+	if offset < 0 {
+		return 0, pathError("seek", f.name, fs.ErrInvalid)
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+// Sync writes the file's contents to the bucket.
+func (f *File) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkWritable("sync"); err != nil {
+		return err
+	}
+
+	if err := f.f.Sync(f.fsys.ctx); err != nil {
+		return pathError("sync", f.name, err)
+	}
+
+	return nil
+}
+
+// Truncate changes the size of the file.
+func (f *File) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkWritable("truncate"); err != nil {
+		return err
+	}
+
+	if size < 0 {
+		return pathError("truncate", f.name, fs.ErrInvalid)
+	}
+
+	if err := f.truncate(size); err != nil {
+		return pathError("truncate", f.name, err)
+	}
+
+	return nil
+}
+
+// ReadDir returns the next n entries of the directory, sorted by name, like
+// fs.ReadDirFile.
+func (f *File) ReadDir(n int) (entries []fs.DirEntry, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, pathError("readdir", f.name, fs.ErrClosed)
+	}
+
+	if !f.dir {
+		return nil, pathError("readdir", f.name, syscall.ENOTDIR)
+	}
+
+	if !f.entriesRead {
+		if f.entries, err = f.readDir(); err != nil {
+			return nil, pathError("readdir", f.name, err)
+		}
+		f.entriesRead = true
+	}
+
+	if n <= 0 {
+		entries, f.entries = f.entries, nil
+		return
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(f.entries))
+	entries, f.entries = f.entries[:n], f.entries[n:]
+	return
+}
+
+// Readdir is like ReadDir, but returns the entries' FileInfo, like
+// os.File.Readdir.
+func (f *File) Readdir(count int) (infos []os.FileInfo, err error) {
+	entries, err := f.ReadDir(count)
+	for _, e := range entries {
+		var fi fs.FileInfo
+		if fi, err = e.Info(); err != nil {
+			return
+		}
+		infos = append(infos, fi)
+	}
+
+	return
+}
+
+// Readdirnames is like ReadDir, but returns the entries' names.
+func (f *File) Readdirnames(n int) (names []string, err error) {
+	entries, err := f.ReadDir(n)
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return
+}
+
+// Close flushes the file's contents to the bucket, if it was written, and
+// closes it.
+func (f *File) Close() (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return pathError("close", f.name, fs.ErrClosed)
+	}
+	f.closed = true
+
+	if !f.dir {
+		err = f.f.Flush(f.fsys.ctx)
+	}
+
+	f.f.Close()
+
+	if err != nil {
+		err = pathError("close", f.name, err)
+	}
+
+	return
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+// LOCKS_REQUIRED(f.mu)
+func (f *File) checkReadable(op string) error {
+	switch {
+	case f.closed:
+		return pathError(op, f.name, fs.ErrClosed)
+	case f.dir:
+		return pathError(op, f.name, syscall.EISDIR)
+	case f.flag&os.O_WRONLY != 0:
+		return pathError(op, f.name, syscall.EBADF)
+	}
+
+	return nil
+}
+
+// LOCKS_REQUIRED(f.mu)
+func (f *File) checkWritable(op string) error {
+	switch {
+	case f.closed:
+		return pathError(op, f.name, fs.ErrClosed)
+	case f.dir:
+		return pathError(op, f.name, syscall.EISDIR)
+	case f.flag&(os.O_WRONLY|os.O_RDWR) == 0:
+		return pathError(op, f.name, syscall.EBADF)
+	}
+
+	return nil
+}
+
+func (f *File) size() (int64, error) {
+	attrs, err := f.f.Stat(f.fsys.ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(attrs.Size), nil
+}
+
+func (f *File) truncate(size int64) error {
+	return f.f.Truncate(f.fsys.ctx, size)
+}
+
+// Read until p is full or the end of the file is reached, in which case
+// io.EOF is returned.
+func (f *File) readAt(p []byte, off int64) (n int, err error) {
+	for n < len(p) {
+		var m int
+		m, err = f.f.ReadAt(f.fsys.ctx, p[n:], off+int64(n))
+		n += m
+		switch {
+		case errors.Is(err, io.EOF), err == nil && m == 0:
+			return n, io.EOF
+		case err != nil:
+			return n, pathError("read", f.name, err)
+		}
+	}
+
+	return
+}
+
+func (f *File) writeAt(p []byte, off int64) (int, error) {
+	if err := f.f.WriteAt(f.fsys.ctx, p, off); err != nil {
+		return 0, pathError("write", f.name, err)
+	}
+
+	return len(p), nil
+}
+
+// Read all of the directory's entries, sorted by name.
+func (f *File) readDir() (entries []fs.DirEntry, err error) {
+	dirents, err := f.f.ReadDir(f.fsys.ctx)
+	if err != nil {
+		return
+	}
+
+	for _, d := range dirents {
+		entries = append(entries, &dirEntry{
+			fsys: f.fsys,
+			path: path.Join(f.name, d.Name),
+			typ:  direntTypeToMode(d.Type),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return
+}
+
+func direntTypeToMode(t fuseutil.DirentType) fs.FileMode {
+	switch t {
+	case fuseutil.DT_Directory:
+		return fs.ModeDir
+	case fuseutil.DT_Link:
+		return fs.ModeSymlink
+	default:
+		return 0
+	}
+}
+
+// dirEntry implements fs.DirEntry, stating the entry when its info is first
+// needed.
+type dirEntry struct {
+	fsys *FS
+	path string
+	typ  fs.FileMode
+}
+
+func (e *dirEntry) Name() string               { return path.Base(e.path) }
+func (e *dirEntry) IsDir() bool                { return e.typ.IsDir() }
+func (e *dirEntry) Type() fs.FileMode          { return e.typ }
+func (e *dirEntry) Info() (fs.FileInfo, error) { return e.fsys.Stat(e.path) }
+func (e *dirEntry) String() string             { return fs.FormatDirEntry(e) }
@@ -0,0 +1,346 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filesystem runs the gcsfuse file system in-process. It builds the
+// same file system as the gcsfuse binary from a cfg.Config, and either mounts
+// it, hands it to the caller to serve with their own fuse server, or exposes
+// it as an io/fs.FS that needs no mount at all.
+//
+// A typical use:
+//
+//	c, err := filesystem.DefaultConfig()
+//	...
+//	c.ImplicitDirs = true
+//	fsys, err := filesystem.New(ctx, filesystem.Options{Config: c, BucketName: "my-bucket"})
+//	...
+//	mfs, err := fsys.Mount("/mnt/my-bucket")
+//	...
+//	err = mfs.Join(ctx)
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/canned"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/perms"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/fuse"
+	"github.com/jacobsa/fuse/fsutil"
+	"github.com/jacobsa/fuse/fuseutil"
+	"github.com/jacobsa/timeutil"
+)
+
+// The user agent used for GCS requests when Options.UserAgent is empty.
+const defaultUserAgent = "gcsfuse-embedded"
+
+// Options say what file system New builds.
+type Options struct {
+	// The gcsfuse configuration, as set by the gcsfuse flags and config file.
+	// If nil, DefaultConfig is used.
+	Config *cfg.Config
+
+	// The name of the bucket to serve. If it's empty or "_" and Bucket is nil,
+	// all accessible buckets are served as subdirectories of the root. If it's
+	// empty and Bucket is set, the name of Bucket is used.
+	BucketName string
+
+	// If set, this bucket is served instead of connecting to GCS, e.g. one
+	// from NewFakeBucket in tests.
+	Bucket *Bucket
+
+	// The connection to GCS, used when Bucket is nil. If nil, one is created
+	// from Config.
+	StorageHandle StorageHandle
+
+	// Sent with GCS requests when New creates the connection.
+	UserAgent string
+
+	// Receives the file system's metrics. If nil, they are dropped.
+	MetricHandle common.MetricHandle
+
+	// If set, checks the liveness and readiness of the file system, e.g. one
+	// from NewHealthMonitor. The caller serves its handler and calls
+	// SetMounted once the file system is mounted. Mount sets its liveness
+	// probe; callers that mount the file system themselves set it with
+	// SetLivenessProbe and MountPointProbe.
+	Health *HealthMonitor
+}
+
+// FileSystem is a gcsfuse file system that has not been served yet. Serve it
+// exactly once, with one of Mount, Server, FuseFileSystem or FS.
+type FileSystem struct {
+	fs        fuseutil.FileSystem
+	admin     Admin
+	inProcess fs.InProcess
+	fsName    string
+	config    *cfg.Config
+	health    *HealthMonitor
+}
+
+// New builds the file system described by opts. This is what the gcsfuse
+// binary does before mounting.
+func New(ctx context.Context, opts Options) (f *FileSystem, err error) {
+	newConfig := opts.Config
+	if newConfig == nil {
+		if newConfig, err = DefaultConfig(); err != nil {
+			return
+		}
+	}
+
+	// The slow op dumps report the holders of the locks.
+	if newConfig.Debug.SlowOpThreshold > 0 {
+		locker.EnableHolderTracking()
+	}
+
+	bucketName := opts.BucketName
+	if opts.Bucket != nil {
+		if bucketName == "" {
+			bucketName = opts.Bucket.Name()
+		} else if isDynamicMount(bucketName) {
+			err = errors.New("a caller-supplied bucket can't be served as all buckets")
+			return
+		}
+	}
+
+	bm, err := newBucketManager(ctx, newConfig, opts)
+	if err != nil {
+		return
+	}
+
+	serverCfg, err := newServerConfig(newConfig, bucketName, bm, opts.MetricHandle)
+	if err != nil {
+		return
+	}
+	serverCfg.Health = opts.Health
+
+	logger.Infof("Creating a new server...\n")
+	inner, err := fs.NewFileSystem(ctx, serverCfg)
+	if err != nil {
+		err = fmt.Errorf("fs.NewFileSystem: %w", err)
+		return
+	}
+
+	fsName := bucketName
+	if isDynamicMount(bucketName) {
+		// mounting all the buckets at once
+		fsName = "gcsfuse"
+	}
+
+	f = &FileSystem{
+		fs:        fs.WrapFileSystem(inner, serverCfg),
+		admin:     inner.(fs.Admin),
+		inProcess: fs.NewInProcess(inner),
+		fsName:    fsName,
+		config:    newConfig,
+		health:    opts.Health,
+	}
+
+	return
+}
+
+// FuseFileSystem returns the file system, for callers running their own fuse
+// server. Its Destroy method must be called once it is no longer served.
+func (f *FileSystem) FuseFileSystem() fuseutil.FileSystem {
+	return f.fs
+}
+
+// Admin returns the means to administer the file system while it's served,
+// e.g. to invalidate its caches.
+func (f *FileSystem) Admin() Admin {
+	return f.admin
+}
+
+// Server returns a fuse server for the file system, for callers that mount it
+// themselves, e.g. with options beyond MountConfig.
+func (f *FileSystem) Server() fuse.Server {
+	return fuseutil.NewFileSystemServer(f.fs)
+}
+
+// MountConfig returns the fuse mount config the gcsfuse binary would use for
+// the file system.
+func (f *FileSystem) MountConfig() *fuse.MountConfig {
+	return fuseMountConfig(f.fsName, f.config)
+}
+
+// Mount mounts the file system at mountPoint. Join the returned file system to
+// wait for it to be unmounted.
+func (f *FileSystem) Mount(mountPoint string) (mfs *fuse.MountedFileSystem, err error) {
+	logger.Infof("Mounting file system %q...", f.fsName)
+
+	mfs, err = fuse.Mount(mountPoint, f.Server(), f.MountConfig())
+	if err != nil {
+		err = fmt.Errorf("mount: %w", err)
+		return
+	}
+
+	if f.health != nil {
+		f.health.SetLivenessProbe(MountPointProbe(mfs.Dir()))
+	}
+	return
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+func isDynamicMount(bucketName string) bool {
+	return bucketName == "" || bucketName == "_"
+}
+
+func newBucketManager(ctx context.Context, newConfig *cfg.Config, opts Options) (gcsx.BucketManager, error) {
+	bucketCfg := bucketConfig(newConfig)
+	bucketCfg.MetricHandle = opts.MetricHandle
+	bucketCfg.Health = opts.Health
+	if opts.Bucket != nil {
+		bucketCfg.NewBackingBucket = func(context.Context, string) (gcs.Bucket, error) {
+			return gcsx.BackingBucket(opts.Bucket), nil
+		}
+		return gcsx.NewBucketManager(bucketCfg, nil), nil
+	}
+
+	// Special case: if we're mounting the fake bucket, we don't need an actual
+	// connection.
+	if opts.BucketName == canned.FakeBucketName {
+		return gcsx.NewBucketManager(bucketCfg, opts.StorageHandle), nil
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	clientConfig := storageutil.NewStorageClientConfig(newConfig, userAgent)
+
+	storageHandle := opts.StorageHandle
+	if storageHandle == nil {
+		var err error
+		storageHandle, err = storage.NewStorageHandle(ctx, clientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create storage handle: %w", err)
+		}
+	}
+
+	// With per-user credentials, each user gets their own storage handle.
+	if cfg.IsPerUserCredentialsEnabled(newConfig) {
+		bucketCfg.NewUserBackingBucket = func(ctx context.Context, name string, uid uint32) (gcs.Bucket, error) {
+			sh, err := storage.NewStorageHandle(ctx, clientConfig.ForUser(uid))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create storage handle: %w", err)
+			}
+			return sh.BucketHandle(ctx, name, bucketCfg.BillingProject)
+		}
+	}
+
+	return gcsx.NewBucketManager(bucketCfg, storageHandle), nil
+}
+
+func bucketConfig(newConfig *cfg.Config) gcsx.BucketConfig {
+	return gcsx.BucketConfig{
+		BillingProject:                     newConfig.GcsConnection.BillingProject,
+		OnlyDir:                            newConfig.OnlyDir,
+		EgressBandwidthLimitBytesPerSecond: newConfig.GcsConnection.LimitBytesPerSec,
+		OpRateLimitHz:                      newConfig.GcsConnection.LimitOpsPerSec,
+		StatCacheMaxSizeMB:                 uint64(newConfig.MetadataCache.StatCacheMaxSizeMb),
+		StatCacheTTL:                       time.Duration(newConfig.MetadataCache.TtlSecs) * time.Second,
+		NegativeStatCacheTTL:               time.Duration(newConfig.MetadataCache.NegativeTtlSecs) * time.Second,
+		EnableMonitoring:                   cfg.IsMetricsEnabled(&newConfig.Metrics),
+		EnableTracing:                      cfg.IsTracingEnabled(newConfig),
+		AppendThreshold:                    1 << 21, // 2 MiB, a total guess.
+		ChunkTransferTimeoutSecs:           newConfig.GcsRetries.ChunkTransferTimeoutSecs,
+		TmpObjectPrefix:                    ".gcsfuse_tmp/",
+		CheckPermissions:                   newConfig.FileSystem.CheckPermissions,
+		EnableTrash:                        newConfig.FileSystem.EnableTrash,
+		TrashRetention:                     time.Duration(newConfig.FileSystem.TrashRetentionSecs) * time.Second,
+	}
+}
+
+func newServerConfig(
+	newConfig *cfg.Config,
+	bucketName string,
+	bm gcsx.BucketManager,
+	metricHandle common.MetricHandle) (serverCfg *fs.ServerConfig, err error) {
+	// Sanity check: make sure the temporary directory exists and is writable
+	// currently. This gives a better user experience than harder to debug EIO
+	// errors when reading files in the future.
+	if newConfig.FileSystem.TempDir != "" {
+		logger.Infof("Creating a temporary directory at %q\n", newConfig.FileSystem.TempDir)
+		var f *os.File
+		f, err = fsutil.AnonymousFile(string(newConfig.FileSystem.TempDir))
+		f.Close()
+
+		if err != nil {
+			err = fmt.Errorf(
+				"error writing to temporary directory (%q); are you sure it exists "+
+					"with the correct permissions",
+				err.Error())
+			return
+		}
+	}
+
+	// Choose UID and GID, defaulting to those of the current process.
+	uid, gid, err := perms.MyUserAndGroup()
+	if err != nil {
+		err = fmt.Errorf("MyUserAndGroup: %w", err)
+		return
+	}
+
+	if newConfig.FileSystem.Uid >= 0 {
+		uid = uint32(newConfig.FileSystem.Uid)
+	}
+
+	if newConfig.FileSystem.Gid >= 0 {
+		gid = uint32(newConfig.FileSystem.Gid)
+	}
+
+	if metricHandle == nil {
+		metricHandle = common.NewNoopMetrics()
+	}
+
+	serverCfg = &fs.ServerConfig{
+		CacheClock:                 timeutil.RealClock(),
+		BucketManager:              bm,
+		BucketName:                 bucketName,
+		LocalFileCache:             false,
+		TempDir:                    string(newConfig.FileSystem.TempDir),
+		ImplicitDirectories:        newConfig.ImplicitDirs,
+		InodeAttributeCacheTTL:     time.Duration(newConfig.MetadataCache.TtlSecs) * time.Second,
+		DirTypeCacheTTL:            time.Duration(newConfig.MetadataCache.TtlSecs) * time.Second,
+		Uid:                        uid,
+		Gid:                        gid,
+		FilePerms:                  os.FileMode(newConfig.FileSystem.FileMode),
+		DirPerms:                   os.FileMode(newConfig.FileSystem.DirMode),
+		RenameDirLimit:             newConfig.FileSystem.RenameDirLimit,
+		SequentialReadSizeMb:       int32(newConfig.GcsConnection.SequentialReadSizeMb),
+		EnableNonexistentTypeCache: newConfig.MetadataCache.EnableNonexistentTypeCache,
+		NewConfig:                  newConfig,
+		MetricHandle:               metricHandle,
+	}
+	if m := newConfig.Metrics; m.EnableAttribution {
+		serverCfg.Attribution = attribution.NewTracker(int(m.AttributionPathDepth), int(m.AttributionMaxKeys), metricHandle)
+	}
+
+	return
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const bucketName = "some_bucket"
+
+type FileSystemTest struct {
+	suite.Suite
+	ctx    context.Context
+	bucket gcs.Bucket
+	config *cfg.Config
+}
+
+func TestFileSystem(t *testing.T) {
+	suite.Run(t, new(FileSystemTest))
+}
+
+func (t *FileSystemTest) SetupTest() {
+	var err error
+	t.ctx = context.Background()
+	t.bucket = gcsx.BackingBucket(NewFakeBucket(bucketName, false))
+	t.config, err = DefaultConfig()
+	require.NoError(t.T(), err)
+}
+
+func (t *FileSystemTest) newFileSystem(opts Options) *FileSystem {
+	opts.Config = t.config
+	opts.Bucket = gcsx.NewServedBucket(t.bucket)
+	f, err := New(t.ctx, opts)
+	require.NoError(t.T(), err)
+	t.T().Cleanup(f.FuseFileSystem().Destroy)
+	return f
+}
+
+func (t *FileSystemTest) TestDefaultConfig() {
+	assert.Equal(t.T(), cfg.Octal(0644), t.config.FileSystem.FileMode)
+	assert.Equal(t.T(), cfg.Octal(0755), t.config.FileSystem.DirMode)
+	assert.Equal(t.T(), int64(-1), t.config.FileSystem.Uid)
+	assert.False(t.T(), t.config.ImplicitDirs)
+}
+
+func (t *FileSystemTest) TestBucketNameDefaultsToBucket() {
+	f := t.newFileSystem(Options{})
+
+	assert.Equal(t.T(), bucketName, f.MountConfig().FSName)
+}
+
+func (t *FileSystemTest) TestCallerBucketCannotBeDynamicMount() {
+	_, err := New(t.ctx, Options{Config: t.config, Bucket: gcsx.NewServedBucket(t.bucket), BucketName: "_"})
+
+	assert.Error(t.T(), err)
+}
+
+func (t *FileSystemTest) TestReadsCallerBucket() {
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, "foo", []byte("taco"))
+	require.NoError(t.T(), err)
+	fs := t.newFileSystem(Options{}).FuseFileSystem()
+
+	lookUp := &fuseops.LookUpInodeOp{Parent: fuseops.RootInodeID, Name: "foo"}
+	require.NoError(t.T(), fs.LookUpInode(t.ctx, lookUp))
+	open := &fuseops.OpenFileOp{Inode: lookUp.Entry.Child, OpenFlags: syscall.O_RDONLY}
+	require.NoError(t.T(), fs.OpenFile(t.ctx, open))
+	read := &fuseops.ReadFileOp{Inode: lookUp.Entry.Child, Handle: open.Handle, Dst: make([]byte, 16)}
+	require.NoError(t.T(), fs.ReadFile(t.ctx, read))
+
+	assert.Equal(t.T(), uint64(4), lookUp.Entry.Attributes.Size)
+	assert.Equal(t.T(), "taco", string(read.Dst[:read.BytesRead]))
+}
+
+func (t *FileSystemTest) TestWritesCallerBucket() {
+	fs := t.newFileSystem(Options{}).FuseFileSystem()
+
+	create := &fuseops.CreateFileOp{Parent: fuseops.RootInodeID, Name: "foo", Mode: 0644}
+	require.NoError(t.T(), fs.CreateFile(t.ctx, create))
+	require.NoError(t.T(), fs.WriteFile(t.ctx, &fuseops.WriteFileOp{Inode: create.Entry.Child, Handle: create.Handle, Data: []byte("burrito")}))
+	require.NoError(t.T(), fs.FlushFile(t.ctx, &fuseops.FlushFileOp{Inode: create.Entry.Child, Handle: create.Handle}))
+
+	contents, err := storageutil.ReadObject(t.ctx, t.bucket, "foo")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", string(contents))
+}
+
+func (t *FileSystemTest) TestConfigIsApplied() {
+	t.config.ImplicitDirs = true
+	t.config.FileSystem.Uid = 123
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, "dir/foo", []byte("taco"))
+	require.NoError(t.T(), err)
+	fs := t.newFileSystem(Options{}).FuseFileSystem()
+
+	lookUp := &fuseops.LookUpInodeOp{Parent: fuseops.RootInodeID, Name: "dir"}
+	require.NoError(t.T(), fs.LookUpInode(t.ctx, lookUp))
+
+	assert.True(t.T(), lookUp.Entry.Attributes.Mode.IsDir())
+	assert.Equal(t.T(), uint32(123), lookUp.Entry.Attributes.Uid)
+}
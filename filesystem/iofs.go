@@ -0,0 +1,306 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	gcsfs "github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/jacobsa/fuse/fuseops"
+)
+
+// FS is the file system as an io/fs.FS, with os-like write operations on top.
+// It drives the file system's inodes and handles in-process, with the same
+// caches as a mount, but without /dev/fuse or a kernel mount.
+//
+// Names are slash-separated paths relative to the root, as accepted by
+// fs.ValidPath. Errors are *fs.PathError wrapping the syscall.Errno the
+// mounted file system would return. FS is safe for concurrent use.
+type FS struct {
+	ctx context.Context
+	fs  gcsfs.InProcess
+}
+
+var (
+	_ fs.FS         = &FS{}
+	_ fs.StatFS     = &FS{}
+	_ fs.ReadDirFS  = &FS{}
+	_ fs.ReadFileFS = &FS{}
+)
+
+// FS returns the file system as an FS. Call its Close method once it is no
+// longer used.
+func (f *FileSystem) FS() *FS {
+	return &FS{
+		ctx: context.Background(),
+		fs:  f.inProcess,
+	}
+}
+
+// Close shuts the file system down. Files must not be used afterwards.
+func (fsys *FS) Close() error {
+	fsys.fs.Destroy()
+	return nil
+}
+
+// Open opens the named file or directory for reading.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Stat returns information about the named file or directory.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, pathError("stat", name, fs.ErrInvalid)
+	}
+
+	attrs, err := fsys.fs.Stat(fsys.ctx, name)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+
+	return &fileInfo{name: path.Base(name), attrs: attrs}, nil
+}
+
+// ReadDir returns the entries of the named directory, sorted by name.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return f.ReadDir(-1)
+}
+
+// ReadFile returns the contents of the named file.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// Create creates or truncates the named file and opens it for reading and
+// writing, like os.Create.
+func (fsys *FS) Create(name string) (*File, error) {
+	return fsys.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile opens the named file with the given os.O_* flags, creating it with
+// perm if os.O_CREATE is set and it doesn't exist, like os.OpenFile.
+func (fsys *FS) OpenFile(name string, flag int, perm fs.FileMode) (*File, error) {
+	if !fs.ValidPath(name) {
+		return nil, pathError("open", name, fs.ErrInvalid)
+	}
+
+	f, err := fsys.fs.Open(fsys.ctx, name, flag, perm)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+
+	return &File{
+		fsys: fsys,
+		name: name,
+		f:    f,
+		dir:  f.IsDir(),
+		flag: flag,
+	}, nil
+}
+
+// Mkdir creates the named directory.
+func (fsys *FS) Mkdir(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return pathError("mkdir", name, fs.ErrInvalid)
+	}
+
+	if err := fsys.fs.Mkdir(fsys.ctx, name); err != nil {
+		return pathError("mkdir", name, err)
+	}
+
+	return nil
+}
+
+// MkdirAll creates the named directory and any missing parents, like
+// os.MkdirAll.
+func (fsys *FS) MkdirAll(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return pathError("mkdir", name, fs.ErrInvalid)
+	}
+
+	if name == "." {
+		return nil
+	}
+
+	var dir string
+	for _, component := range strings.Split(name, "/") {
+		dir = path.Join(dir, component)
+
+		attrs, err := fsys.fs.Stat(fsys.ctx, dir)
+		switch {
+		case err == nil:
+			if !attrs.Mode.IsDir() {
+				err = syscall.ENOTDIR
+			}
+
+		case errors.Is(err, syscall.ENOENT):
+			err = fsys.fs.Mkdir(fsys.ctx, dir)
+		}
+
+		if err != nil {
+			return pathError("mkdir", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (fsys *FS) Remove(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return pathError("remove", name, fs.ErrInvalid)
+	}
+
+	if err := fsys.fs.Remove(fsys.ctx, name); err != nil {
+		return pathError("remove", name, err)
+	}
+
+	return nil
+}
+
+// RemoveAll removes the named file or directory and everything it contains,
+// like os.RemoveAll. It returns nil if name doesn't exist.
+func (fsys *FS) RemoveAll(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return pathError("removeall", name, fs.ErrInvalid)
+	}
+
+	fi, err := fsys.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if fi.IsDir() {
+		entries, err := fsys.ReadDir(name)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if err = fsys.RemoveAll(path.Join(name, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	err = fsys.Remove(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// Rename renames oldname to newname, replacing newname if it exists and
+// isn't a non-empty directory.
+func (fsys *FS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(oldname) || !fs.ValidPath(newname) || oldname == "." || newname == "." {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrInvalid}
+	}
+
+	if err := fsys.fs.Rename(fsys.ctx, oldname, newname); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	return nil
+}
+
+// Chtimes sets the modification time of the named file. Like a mount, the
+// file system doesn't store access times, so atime is ignored.
+func (fsys *FS) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	if !fs.ValidPath(name) {
+		return pathError("chtimes", name, fs.ErrInvalid)
+	}
+
+	if err := fsys.fs.SetMtime(fsys.ctx, name, mtime); err != nil {
+		return pathError("chtimes", name, err)
+	}
+
+	return nil
+}
+
+// Chmod has the effect chmod has on a mount, which is none: file modes are
+// set for the whole file system by its config.
+func (fsys *FS) Chmod(name string, mode fs.FileMode) error {
+	return fsys.checkExists("chmod", name)
+}
+
+// Chown has the effect chown has on a mount, which is none: owners are set
+// for the whole file system by its config.
+func (fsys *FS) Chown(name string, uid, gid int) error {
+	return fsys.checkExists("chown", name)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+func pathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Return an error for the op on the named file if it doesn't exist.
+func (fsys *FS) checkExists(op, name string) error {
+	if !fs.ValidPath(name) {
+		return pathError(op, name, fs.ErrInvalid)
+	}
+
+	if _, err := fsys.fs.Stat(fsys.ctx, name); err != nil {
+		return pathError(op, name, err)
+	}
+
+	return nil
+}
+
+// fileInfo implements fs.FileInfo for an inode's attributes.
+type fileInfo struct {
+	name  string
+	attrs fuseops.InodeAttributes
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(fi.attrs.Size) }
+func (fi *fileInfo) Mode() fs.FileMode  { return fi.attrs.Mode }
+func (fi *fileInfo) ModTime() time.Time { return fi.attrs.Mtime }
+func (fi *fileInfo) IsDir() bool        { return fi.attrs.Mode.IsDir() }
+func (fi *fileInfo) Sys() any           { return &fi.attrs }
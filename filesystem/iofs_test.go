@@ -0,0 +1,240 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type IOFSTest struct {
+	suite.Suite
+	ctx    context.Context
+	bucket gcs.Bucket
+	fsys   *FS
+}
+
+func TestIOFS(t *testing.T) {
+	suite.Run(t, new(IOFSTest))
+}
+
+func (t *IOFSTest) SetupTest() {
+	t.ctx = context.Background()
+	t.bucket = gcsx.BackingBucket(NewFakeBucket(bucketName, false))
+	config, err := DefaultConfig()
+	require.NoError(t.T(), err)
+	config.ImplicitDirs = true
+
+	f, err := New(t.ctx, Options{Config: config, Bucket: gcsx.NewServedBucket(t.bucket)})
+	require.NoError(t.T(), err)
+	t.fsys = f.FS()
+}
+
+func (t *IOFSTest) TearDownTest() {
+	assert.NoError(t.T(), t.fsys.Close())
+}
+
+func (t *IOFSTest) createObjects(objects map[string]string) {
+	for name, contents := range objects {
+		_, err := storageutil.CreateObject(t.ctx, t.bucket, name, []byte(contents))
+		require.NoError(t.T(), err)
+	}
+}
+
+func (t *IOFSTest) readObject(name string) string {
+	contents, err := storageutil.ReadObject(t.ctx, t.bucket, name)
+	require.NoError(t.T(), err)
+	return string(contents)
+}
+
+func (t *IOFSTest) TestFSTest() {
+	// Directory inodes are recreated with a fresh mtime once forgotten, which
+	// fstest.TestFS reports as inconsistent, so only check files here.
+	t.createObjects(map[string]string{
+		"foo": "taco",
+		"bar": "burrito",
+		"baz": "",
+	})
+
+	assert.NoError(t.T(), fstest.TestFS(t.fsys, "foo", "bar", "baz"))
+}
+
+func (t *IOFSTest) TestReadFileAndStat() {
+	t.createObjects(map[string]string{"dir/foo": "taco"})
+
+	contents, err := t.fsys.ReadFile("dir/foo")
+	require.NoError(t.T(), err)
+	fi, err := t.fsys.Stat("dir/foo")
+	require.NoError(t.T(), err)
+
+	assert.Equal(t.T(), "taco", string(contents))
+	assert.Equal(t.T(), "foo", fi.Name())
+	assert.Equal(t.T(), int64(4), fi.Size())
+	assert.False(t.T(), fi.IsDir())
+}
+
+func (t *IOFSTest) TestErrors() {
+	t.createObjects(map[string]string{"foo": "taco"})
+
+	_, err := t.fsys.Open("missing")
+	assert.ErrorIs(t.T(), err, fs.ErrNotExist)
+	_, err = t.fsys.Open("/foo")
+	assert.ErrorIs(t.T(), err, fs.ErrInvalid)
+	_, err = t.fsys.Stat("foo/bar")
+	assert.ErrorIs(t.T(), err, syscall.ENOTDIR)
+	_, err = t.fsys.OpenFile("foo", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	assert.ErrorIs(t.T(), err, fs.ErrExist)
+	var pathErr *fs.PathError
+	require.ErrorAs(t.T(), t.fsys.Remove("missing"), &pathErr)
+	assert.Equal(t.T(), "missing", pathErr.Path)
+}
+
+func (t *IOFSTest) TestCreateWriteAndClose() {
+	f, err := t.fsys.Create("foo")
+	require.NoError(t.T(), err)
+	_, err = f.WriteString("taco")
+	require.NoError(t.T(), err)
+	_, err = f.Write([]byte(" burrito"))
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Close())
+
+	assert.Equal(t.T(), "taco burrito", t.readObject("foo"))
+	assert.ErrorIs(t.T(), f.Close(), fs.ErrClosed)
+}
+
+func (t *IOFSTest) TestOpenFileFlags() {
+	t.createObjects(map[string]string{"foo": "taco"})
+
+	// Append.
+	f, err := t.fsys.OpenFile("foo", os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t.T(), err)
+	_, err = f.WriteString("s")
+	require.NoError(t.T(), err)
+	_, err = f.Read(make([]byte, 1))
+	assert.ErrorIs(t.T(), err, syscall.EBADF)
+	require.NoError(t.T(), f.Close())
+	assert.Equal(t.T(), "tacos", t.readObject("foo"))
+
+	// Truncate.
+	f, err = t.fsys.OpenFile("foo", os.O_RDWR|os.O_TRUNC, 0)
+	require.NoError(t.T(), err)
+	_, err = f.WriteString("burrito")
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Close())
+	assert.Equal(t.T(), "burrito", t.readObject("foo"))
+
+	// Read only.
+	f, err = t.fsys.OpenFile("foo", os.O_RDONLY, 0)
+	require.NoError(t.T(), err)
+	_, err = f.WriteString("enchilada")
+	assert.ErrorIs(t.T(), err, syscall.EBADF)
+	require.NoError(t.T(), f.Close())
+}
+
+func (t *IOFSTest) TestSeekReadAtWriteAtAndTruncate() {
+	f, err := t.fsys.Create("foo")
+	require.NoError(t.T(), err)
+	defer f.Close()
+
+	_, err = f.WriteString("0123456789")
+	require.NoError(t.T(), err)
+	_, err = f.WriteAt([]byte("ab"), 2)
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Truncate(8))
+	off, err := f.Seek(-3, io.SeekEnd)
+	require.NoError(t.T(), err)
+	buf := make([]byte, 8)
+	n, err := f.Read(buf)
+	require.NoError(t.T(), err)
+	_, err = f.ReadAt(make([]byte, 8), 6)
+	assert.ErrorIs(t.T(), err, io.EOF)
+	require.NoError(t.T(), f.Sync())
+
+	assert.Equal(t.T(), int64(5), off)
+	assert.Equal(t.T(), "567", string(buf[:n]))
+	assert.Equal(t.T(), "01ab4567", t.readObject("foo"))
+}
+
+func (t *IOFSTest) TestMkdirAllRenameAndRemove() {
+	require.NoError(t.T(), t.fsys.MkdirAll("a/b/c", 0755))
+	require.NoError(t.T(), t.fsys.MkdirAll("a/b", 0755))
+	f, err := t.fsys.Create("a/b/c/foo")
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Close())
+	require.NoError(t.T(), t.fsys.Rename("a/b/c/foo", "a/bar"))
+	require.NoError(t.T(), t.fsys.Mkdir("a/d", 0755))
+
+	entries, err := t.fsys.ReadDir("a")
+	require.NoError(t.T(), err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t.T(), []string{"b", "bar", "d"}, names)
+	assert.ErrorIs(t.T(), t.fsys.MkdirAll("a/bar/baz", 0755), syscall.ENOTDIR)
+	assert.ErrorIs(t.T(), t.fsys.Remove("a/b"), syscall.ENOTEMPTY)
+
+	require.NoError(t.T(), t.fsys.Remove("a/bar"))
+	require.NoError(t.T(), t.fsys.RemoveAll("a"))
+	require.NoError(t.T(), t.fsys.RemoveAll("a"))
+	_, err = t.fsys.Stat("a")
+	assert.ErrorIs(t.T(), err, fs.ErrNotExist)
+	listing, err := t.bucket.ListObjects(t.ctx, &gcs.ListObjectsRequest{})
+	require.NoError(t.T(), err)
+	assert.Empty(t.T(), listing.MinObjects)
+}
+
+func (t *IOFSTest) TestChtimes() {
+	t.createObjects(map[string]string{"foo": "taco"})
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t.T(), t.fsys.Chtimes("foo", mtime, mtime))
+
+	fi, err := t.fsys.Stat("foo")
+	require.NoError(t.T(), err)
+	assert.True(t.T(), mtime.Equal(fi.ModTime()))
+}
+
+func (t *IOFSTest) TestAfero() {
+	a := t.fsys.Afero()
+
+	require.NoError(t.T(), a.MkdirAll("/a/b", 0755))
+	f, err := a.Create("/a/b/../foo")
+	require.NoError(t.T(), err)
+	_, err = f.WriteString("taco")
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Close())
+	f, err = a.Open("/a")
+	require.NoError(t.T(), err)
+	names, err := f.Readdirnames(-1)
+	require.NoError(t.T(), err)
+	require.NoError(t.T(), f.Close())
+
+	assert.Equal(t.T(), []string{"b", "foo"}, names)
+	assert.Equal(t.T(), "taco", t.readObject("a/foo"))
+}
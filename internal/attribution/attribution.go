@@ -0,0 +1,329 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package attribution attributes file system ops, the bytes they read and
+// write and the GCS requests they trigger to the process that issued them and
+// to the leading directories of the path they're for.
+//
+// The process is identified by its cgroup, so that the processes of a
+// container or systemd service are attributed together, or by its command
+// when it's in the root cgroup. The number of distinct callers and path
+// prefixes tracked is bounded, and the usage above the bound is attributed to
+// a single overflow key, which keeps the cardinality of the metrics bounded.
+package attribution
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+)
+
+const (
+	// UnknownCaller is the caller of ops without a PID, e.g. the write back of
+	// dirty pages, and of those whose process has already exited.
+	UnknownCaller = "unknown"
+
+	// Other is the caller and path prefix of the usage above the bound on the
+	// number of keys.
+	Other = "other"
+
+	// The number of recent PIDs reported for each key.
+	maxPIDs = 8
+)
+
+// Key is what usage is attributed to.
+type Key struct {
+	Caller     string `json:"caller"`
+	PathPrefix string `json:"pathPrefix"`
+}
+
+// Usage is the usage attributed to a key.
+type Usage struct {
+	Ops          int64 `json:"ops"`
+	BytesRead    int64 `json:"bytesRead"`
+	BytesWritten int64 `json:"bytesWritten"`
+	GCSBytesRead int64 `json:"gcsBytesRead"`
+	GCSClassA    int64 `json:"gcsClassA"`
+	GCSClassB    int64 `json:"gcsClassB"`
+	GCSFree      int64 `json:"gcsFree"`
+}
+
+// Measure is what Top ranks keys by.
+type Measure string
+
+const (
+	ByOps          Measure = "ops"
+	ByBytesRead    Measure = "bytes-read"
+	ByBytesWritten Measure = "bytes-written"
+	ByGCSBytesRead Measure = "gcs-bytes-read"
+	ByGCSRequests  Measure = "gcs-requests"
+	ByGCSClassA    Measure = "gcs-class-a"
+	ByGCSClassB    Measure = "gcs-class-b"
+)
+
+// Measures are the valid measures, in the order they're documented.
+var Measures = []Measure{ByOps, ByBytesRead, ByBytesWritten, ByGCSBytesRead, ByGCSRequests, ByGCSClassA, ByGCSClassB}
+
+// ParseMeasure returns the measure with the given name, or ByOps if it's
+// empty.
+func ParseMeasure(s string) (Measure, error) {
+	if s == "" {
+		return ByOps, nil
+	}
+	if m := Measure(s); slices.Contains(Measures, m) {
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown measure %q, must be one of %v", s, Measures)
+}
+
+func (u *Usage) value(by Measure) int64 {
+	switch by {
+	case ByBytesRead:
+		return u.BytesRead
+	case ByBytesWritten:
+		return u.BytesWritten
+	case ByGCSBytesRead:
+		return u.GCSBytesRead
+	case ByGCSRequests:
+		return u.GCSClassA + u.GCSClassB + u.GCSFree
+	case ByGCSClassA:
+		return u.GCSClassA
+	case ByGCSClassB:
+		return u.GCSClassB
+	default:
+		return u.Ops
+	}
+}
+
+// Consumer is the usage attributed to a key, with the PIDs of the processes
+// that most recently issued ops for it.
+type Consumer struct {
+	Key
+	Usage
+	PIDs []uint32 `json:"pids,omitempty"`
+}
+
+// Report ranks the keys by a measure of their usage since tracking started.
+type Report struct {
+	Since time.Time `json:"since"`
+	By    Measure   `json:"by"`
+	// The number of keys tracked, including the overflow key if it's in use.
+	Keys int        `json:"keys"`
+	Top  []Consumer `json:"top"`
+}
+
+// The usage of a key. The counters are updated without holding the tracker's
+// lock.
+type entry struct {
+	key          Key
+	attrs        []common.MetricAttr
+	metricHandle common.MetricHandle
+
+	ops, bytesRead, bytesWritten, gcsBytesRead atomic.Int64
+	gcsClassA, gcsClassB, gcsFree              atomic.Int64
+
+	// GUARDED_BY(Tracker.mu)
+	pids []uint32
+}
+
+func (e *entry) usage() Usage {
+	return Usage{
+		Ops:          e.ops.Load(),
+		BytesRead:    e.bytesRead.Load(),
+		BytesWritten: e.bytesWritten.Load(),
+		GCSBytesRead: e.gcsBytesRead.Load(),
+		GCSClassA:    e.gcsClassA.Load(),
+		GCSClassB:    e.gcsClassB.Load(),
+		GCSFree:      e.gcsFree.Load(),
+	}
+}
+
+// Tracker accumulates the usage of each key and records it as metrics with
+// the Caller and PathPrefix attributes.
+type Tracker struct {
+	depth        int
+	maxKeys      int
+	metricHandle common.MetricHandle
+	callers      *callerCache
+	since        time.Time
+
+	mu sync.Mutex
+	// At most maxKeys entries, plus the overflow entry.
+	//
+	// GUARDED_BY(mu)
+	entries map[Key]*entry
+}
+
+// NewTracker returns a tracker that attributes usage to path prefixes of the
+// given depth, i.e. the number of leading directories kept, and tracks at
+// most maxKeys keys besides the overflow key.
+func NewTracker(depth, maxKeys int, metricHandle common.MetricHandle) *Tracker {
+	if metricHandle == nil {
+		metricHandle = common.NewNoopMetrics()
+	}
+	return &Tracker{
+		depth:        depth,
+		maxKeys:      maxKeys,
+		metricHandle: metricHandle,
+		callers:      newCallerCache(procRoot),
+		since:        time.Now(),
+		entries:      make(map[Key]*entry),
+	}
+}
+
+type contextKey struct{}
+
+// Begin attributes an op issued by the given PID for the given path, relative
+// to the root of the mount, and returns a context in which the bytes and GCS
+// requests recorded are attributed to the same key.
+//
+// LOCKS_EXCLUDED(t.mu)
+func (t *Tracker) Begin(ctx context.Context, pid uint32, path string) context.Context {
+	key := Key{Caller: t.callers.lookUp(pid), PathPrefix: PathPrefix(path, t.depth)}
+	e := t.entry(key, pid)
+	e.ops.Add(1)
+	t.metricHandle.AttributedOpsCount(ctx, 1, e.attrs)
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// Return the entry for the given key, or the overflow entry if there are too
+// many keys, noting the PID as its most recent.
+//
+// LOCKS_EXCLUDED(t.mu)
+func (t *Tracker) entry(key Key, pid uint32) *entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.entries[key]
+	if !ok {
+		if t.tracked() >= t.maxKeys {
+			key = Key{Caller: Other, PathPrefix: Other}
+			e, ok = t.entries[key]
+		}
+		if !ok {
+			e = &entry{
+				key:          key,
+				metricHandle: t.metricHandle,
+				attrs: []common.MetricAttr{
+					{Key: common.Caller, Value: key.Caller},
+					{Key: common.PathPrefix, Value: key.PathPrefix},
+				},
+			}
+			t.entries[key] = e
+		}
+	}
+
+	if pid != 0 && (len(e.pids) == 0 || e.pids[len(e.pids)-1] != pid) {
+		e.pids = slices.DeleteFunc(e.pids, func(p uint32) bool { return p == pid })
+		if len(e.pids) == maxPIDs {
+			e.pids = e.pids[1:]
+		}
+		e.pids = append(e.pids, pid)
+	}
+
+	return e
+}
+
+// The number of keys tracked, apart from the overflow key.
+//
+// LOCKS_REQUIRED(t.mu)
+func (t *Tracker) tracked() int {
+	n := len(t.entries)
+	if _, ok := t.entries[Key{Caller: Other, PathPrefix: Other}]; ok {
+		n--
+	}
+	return n
+}
+
+// Top returns the n keys with the most usage by the given measure, or all of
+// them if n isn't positive.
+//
+// LOCKS_EXCLUDED(t.mu)
+func (t *Tracker) Top(n int, by Measure) Report {
+	t.mu.Lock()
+	consumers := make([]Consumer, 0, len(t.entries))
+	for _, e := range t.entries {
+		consumers = append(consumers, Consumer{Key: e.key, Usage: e.usage(), PIDs: slices.Clone(e.pids)})
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(consumers, func(a, b Consumer) int {
+		if c := cmp.Compare(b.value(by), a.value(by)); c != 0 {
+			return c
+		}
+		if a.Caller != b.Caller {
+			return strings.Compare(a.Caller, b.Caller)
+		}
+		return strings.Compare(a.PathPrefix, b.PathPrefix)
+	})
+
+	r := Report{Since: t.since, By: by, Keys: len(consumers), Top: consumers}
+	if n > 0 && len(r.Top) > n {
+		r.Top = r.Top[:n]
+	}
+	return r
+}
+
+// RecordBytes attributes n bytes in the given direction, one of
+// common.ReadDirection, WriteDirection and GCSReadDirection, to the key of
+// the op the context is for. It does nothing if the context isn't for an op
+// attributed by Begin.
+func RecordBytes(ctx context.Context, direction string, n int64) {
+	e, ok := ctx.Value(contextKey{}).(*entry)
+	if !ok || n <= 0 {
+		return
+	}
+
+	switch direction {
+	case common.ReadDirection:
+		e.bytesRead.Add(n)
+	case common.WriteDirection:
+		e.bytesWritten.Add(n)
+	case common.GCSReadDirection:
+		e.gcsBytesRead.Add(n)
+	default:
+		return
+	}
+	attrs := append(slices.Clip(e.attrs), common.MetricAttr{Key: common.IODirection, Value: direction})
+	e.metricHandle.AttributedBytesCount(ctx, n, attrs)
+}
+
+// RecordGCSRequest attributes a request made with the given method of
+// gcs.Bucket to the key of the op the context is for. It does nothing if the
+// context isn't for an op attributed by Begin.
+func RecordGCSRequest(ctx context.Context, method string) {
+	e, ok := ctx.Value(contextKey{}).(*entry)
+	if !ok {
+		return
+	}
+
+	class := GCSClass(method)
+	switch class {
+	case common.GCSClassA:
+		e.gcsClassA.Add(1)
+	case common.GCSClassB:
+		e.gcsClassB.Add(1)
+	default:
+		e.gcsFree.Add(1)
+	}
+	attrs := append(slices.Clip(e.attrs), common.MetricAttr{Key: common.GCSClass, Value: class})
+	e.metricHandle.AttributedGCSRequestCount(ctx, 1, attrs)
+}
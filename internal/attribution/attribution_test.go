@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attribution
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Write a fake /proc/<pid> directory with the given cgroup and comm files,
+// skipping the empty ones.
+func writeProc(t *testing.T, root string, pid, cgroup, comm string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	if cgroup != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0644))
+	}
+	if comm != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "comm"), []byte(comm), 0644))
+	}
+}
+
+func newTestTracker(t *testing.T, depth, maxKeys int) *Tracker {
+	t.Helper()
+	root := t.TempDir()
+	writeProc(t, root, "10", "0::/system.slice/trainer.service\n", "python3\n")
+	writeProc(t, root, "11", "0::/system.slice/trainer.service\n", "python3\n")
+	writeProc(t, root, "20", "0::/\n", "cp\n")
+	tr := NewTracker(depth, maxKeys, nil)
+	tr.callers = newCallerCache(root)
+	return tr
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		path  string
+		depth int
+		want  string
+	}{
+		{"a/b/c/file", 2, "/a/b"},
+		{"a/b/c/", 2, "/a/b"},
+		{"a/file", 2, "/a"},
+		{"file", 1, "/"},
+		{"", 1, "/"},
+		{"a/b/", 0, "/"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, PathPrefix(tc.path, tc.depth), "%q at depth %d", tc.path, tc.depth)
+	}
+}
+
+func TestGCSClass(t *testing.T) {
+	assert.Equal(t, common.GCSClassA, GCSClass("ListObjects"))
+	assert.Equal(t, common.GCSClassB, GCSClass("StatObject"))
+	assert.Equal(t, common.GCSClassFree, GCSClass("DeleteObject"))
+	assert.Equal(t, common.GCSClassA, GCSClass("SomeNewMethod"))
+}
+
+func TestReadCgroup(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, "1", "12:memory:/docker/abc\n1:name=systemd:/user.slice\n", "")
+	writeProc(t, root, "2", "12:memory:/docker/abc\n0::/kubepods/pod1\n", "")
+	writeProc(t, root, "3", "12:memory:/docker/abc\n", "")
+
+	assert.Equal(t, "/user.slice", readCgroup(filepath.Join(root, "1", "cgroup")))
+	assert.Equal(t, "/kubepods/pod1", readCgroup(filepath.Join(root, "2", "cgroup")))
+	assert.Equal(t, "/docker/abc", readCgroup(filepath.Join(root, "3", "cgroup")))
+	assert.Equal(t, "", readCgroup(filepath.Join(root, "4", "cgroup")))
+}
+
+func TestCallerCache(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, "10", "0::/system.slice/trainer.service\n", "python3\n")
+	writeProc(t, root, "20", "0::/\n", "cp\n")
+	c := newCallerCache(root)
+
+	assert.Equal(t, "/system.slice/trainer.service", c.lookUp(10))
+	assert.Equal(t, "cp", c.lookUp(20))
+	assert.Equal(t, UnknownCaller, c.lookUp(30))
+	assert.Equal(t, UnknownCaller, c.lookUp(0))
+
+	// Cached until the TTL expires.
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "10")))
+	assert.Equal(t, "/system.slice/trainer.service", c.lookUp(10))
+}
+
+func TestTracker(t *testing.T) {
+	tr := newTestTracker(t, 1, 10)
+
+	ctx := tr.Begin(context.Background(), 10, "data/shard-0/file")
+	RecordBytes(ctx, common.ReadDirection, 100)
+	RecordBytes(ctx, common.GCSReadDirection, 150)
+	RecordGCSRequest(ctx, "NewReader")
+	ctx = tr.Begin(context.Background(), 11, "data/")
+	RecordGCSRequest(ctx, "ListObjects")
+	ctx = tr.Begin(context.Background(), 20, "out/file")
+	RecordBytes(ctx, common.WriteDirection, 500)
+	// Bytes and requests without an op aren't attributed.
+	RecordBytes(context.Background(), common.ReadDirection, 1000)
+
+	r := tr.Top(0, ByOps)
+
+	assert.Equal(t, ByOps, r.By)
+	assert.Equal(t, 2, r.Keys)
+	require.Len(t, r.Top, 2)
+	assert.Equal(t, Consumer{
+		Key:   Key{Caller: "/system.slice/trainer.service", PathPrefix: "/data"},
+		Usage: Usage{Ops: 2, BytesRead: 100, GCSBytesRead: 150, GCSClassA: 1, GCSClassB: 1},
+		PIDs:  []uint32{10, 11},
+	}, r.Top[0])
+	assert.Equal(t, Consumer{
+		Key:   Key{Caller: "cp", PathPrefix: "/out"},
+		Usage: Usage{Ops: 1, BytesWritten: 500},
+		PIDs:  []uint32{20},
+	}, r.Top[1])
+}
+
+func TestTracker_TopByMeasure(t *testing.T) {
+	tr := newTestTracker(t, 1, 10)
+	ctx := tr.Begin(context.Background(), 10, "a/file")
+	tr.Begin(context.Background(), 10, "a/file")
+	RecordBytes(ctx, common.ReadDirection, 10)
+	ctx = tr.Begin(context.Background(), 10, "b/file")
+	RecordBytes(ctx, common.ReadDirection, 20)
+
+	r := tr.Top(1, ByBytesRead)
+
+	assert.Equal(t, 2, r.Keys)
+	require.Len(t, r.Top, 1)
+	assert.Equal(t, "/b", r.Top[0].PathPrefix)
+}
+
+func TestTracker_Overflow(t *testing.T) {
+	tr := newTestTracker(t, 1, 2)
+
+	tr.Begin(context.Background(), 10, "a/file")
+	tr.Begin(context.Background(), 10, "b/file")
+	tr.Begin(context.Background(), 10, "c/file")
+	tr.Begin(context.Background(), 20, "d/file")
+	tr.Begin(context.Background(), 10, "a/file")
+
+	r := tr.Top(0, ByOps)
+	assert.Equal(t, 3, r.Keys)
+	require.Len(t, r.Top, 3)
+	assert.Equal(t, "/a", r.Top[0].PathPrefix)
+	assert.Equal(t, int64(2), r.Top[0].Ops)
+	assert.Equal(t, Key{Caller: Other, PathPrefix: Other}, r.Top[1].Key)
+	assert.Equal(t, int64(2), r.Top[1].Ops)
+	assert.Equal(t, []uint32{10, 20}, r.Top[1].PIDs)
+}
+
+func TestParseMeasure(t *testing.T) {
+	m, err := ParseMeasure("")
+	require.NoError(t, err)
+	assert.Equal(t, ByOps, m)
+
+	m, err = ParseMeasure("gcs-class-a")
+	require.NoError(t, err)
+	assert.Equal(t, ByGCSClassA, m)
+
+	_, err = ParseMeasure("cost")
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attribution
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Where the proc file system is mounted.
+	procRoot = "/proc"
+
+	// How long the caller of a PID is cached. PIDs are reused, and processes
+	// may move between cgroups.
+	callerTTL = time.Minute
+
+	// The number of PIDs whose callers are cached. The cache is cleared when
+	// it's full.
+	maxCachedCallers = 4096
+)
+
+type cachedCaller struct {
+	caller string
+	expiry time.Time
+}
+
+// callerCache looks up the callers of PIDs in the proc file system.
+type callerCache struct {
+	procRoot string
+
+	mu sync.Mutex
+	// GUARDED_BY(mu)
+	callers map[uint32]cachedCaller
+}
+
+func newCallerCache(procRoot string) *callerCache {
+	return &callerCache{
+		procRoot: procRoot,
+		callers:  make(map[uint32]cachedCaller),
+	}
+}
+
+// lookUp returns the cgroup of the given PID, or its command if it's in the
+// root cgroup, or UnknownCaller if neither can be read.
+//
+// LOCKS_EXCLUDED(c.mu)
+func (c *callerCache) lookUp(pid uint32) string {
+	if pid == 0 {
+		return UnknownCaller
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.callers[pid]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiry) {
+		return cached.caller
+	}
+
+	caller := c.read(pid)
+
+	c.mu.Lock()
+	if len(c.callers) >= maxCachedCallers {
+		clear(c.callers)
+	}
+	c.callers[pid] = cachedCaller{caller: caller, expiry: now.Add(callerTTL)}
+	c.mu.Unlock()
+
+	return caller
+}
+
+func (c *callerCache) read(pid uint32) string {
+	dir := filepath.Join(c.procRoot, strconv.FormatUint(uint64(pid), 10))
+	if cgroup := readCgroup(filepath.Join(dir, "cgroup")); cgroup != "" && cgroup != "/" {
+		return cgroup
+	}
+
+	if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		if comm := strings.TrimSpace(string(comm)); comm != "" {
+			return comm
+		}
+	}
+
+	return UnknownCaller
+}
+
+// readCgroup returns the cgroup path from a /proc/<pid>/cgroup file: the one
+// of the unified (v2) hierarchy if there is one, else the one of the systemd
+// hierarchy, else the first.
+func readCgroup(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var systemd, first string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Each line is hierarchy-ID:controller-list:cgroup-path.
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		switch {
+		case fields[0] == "0" && fields[1] == "":
+			return fields[2]
+		case fields[1] == "name=systemd" && systemd == "":
+			systemd = fields[2]
+		case first == "":
+			first = fields[2]
+		}
+	}
+
+	if systemd != "" {
+		return systemd
+	}
+	return first
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package attribution
+
+import (
+	"strings"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+)
+
+// PathPrefix returns the first depth directories of the given path, relative
+// to the root of the mount, as an absolute path. Paths ending in a slash are
+// directories; for the others, the directory they're in is used, so that the
+// files directly in a directory share its prefix.
+func PathPrefix(p string, depth int) string {
+	dir := p[:strings.LastIndex(p, "/")+1]
+
+	var parts []string
+	for _, part := range strings.Split(dir, "/") {
+		if len(parts) == depth {
+			break
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
+
+// The operation classes that the methods of gcs.Bucket and of multi range
+// downloaders are billed as. Deletes are free, as are the ranges read from an
+// open multi range downloader.
+var gcsClasses = map[string]string{
+	"CreateObject":              common.GCSClassA,
+	"CreateObjectChunkWriter":   common.GCSClassA,
+	"CopyObject":                common.GCSClassA,
+	"ComposeObjects":            common.GCSClassA,
+	"ListObjects":               common.GCSClassA,
+	"UpdateObject":              common.GCSClassA,
+	"MoveObject":                common.GCSClassA,
+	"CreateFolder":              common.GCSClassA,
+	"RenameFolder":              common.GCSClassA,
+	"StatObject":                common.GCSClassB,
+	"NewReader":                 common.GCSClassB,
+	"GetFolder":                 common.GCSClassB,
+	"NewMultiRangeDownloader":   common.GCSClassB,
+	"FinalizeUpload":            common.GCSClassFree,
+	"DeleteObject":              common.GCSClassFree,
+	"DeleteFolder":              common.GCSClassFree,
+	"MultiRangeDownloader::Add": common.GCSClassFree,
+}
+
+// GCSClass returns the operation class a request made with the given method
+// is billed as. Unknown methods are taken to be class A, the costlier one.
+func GCSClass(method string) string {
+	if c, ok := gcsClasses[method]; ok {
+		return c
+	}
+	return common.GCSClassA
+}
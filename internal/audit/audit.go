@@ -0,0 +1,280 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit writes a structured record of every file system mutation, as
+// one JSON object per line, to a file and/or syslog.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/jacobsa/timeutil"
+)
+
+// Caller identifies the process on whose behalf an op was made.
+type Caller struct {
+	Pid uint32
+	Uid uint32
+}
+
+// Known returns false if the caller isn't known, as for ops that the kernel
+// doesn't attribute to a process.
+func (c Caller) Known() bool {
+	return c.Pid != 0
+}
+
+// Record is the audit record of a single op.
+type Record struct {
+	Time time.Time `json:"time"`
+	Op   string    `json:"op"`
+
+	// The caller, omitted if unknown.
+	Pid *uint32 `json:"pid,omitempty"`
+	Uid *uint32 `json:"uid,omitempty"`
+	Gid *uint32 `json:"gid,omitempty"`
+
+	Bucket string `json:"bucket,omitempty"`
+	Object string `json:"object,omitempty"`
+
+	// The new name, for renames.
+	NewObject string `json:"new_object,omitempty"`
+
+	// The generation of the object as a result of the op, if any.
+	Generation int64 `json:"generation,omitempty"`
+
+	// "ok" or "error".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+
+	LatencyMicros int64 `json:"latency_us"`
+}
+
+// Logger writes audit records. A nil *Logger discards them.
+//
+// Safe for concurrent access.
+type Logger struct {
+	clock timeutil.Clock
+
+	// Reads the GID of the process with the given PID.
+	gidOf func(pid uint32) (uint32, error)
+
+	// Counts the records that couldn't be written.
+	metricHandle common.MetricHandle
+
+	mu      sync.Mutex
+	w       io.Writer // GUARDED_BY(mu)
+	closers []io.Closer
+
+	// Set while writes are failing, so that a failure is logged once rather
+	// than for every record.
+	failing bool // GUARDED_BY(mu)
+}
+
+// New returns a logger writing to the file at filePath, if not empty, and to
+// syslog, if useSyslog is set. It returns nil if neither is asked for. The
+// metric handle may be nil.
+func New(filePath string, useSyslog bool, metricHandle common.MetricHandle) (*Logger, error) {
+	var writers []io.Writer
+	var closers []io.Closer
+
+	if filePath != "" {
+		f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening audit log file: %w", err)
+		}
+		writers = append(writers, f)
+		closers = append(closers, f)
+	}
+
+	if useSyslog {
+		w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, "gcsfuse-audit")
+		if err != nil {
+			for _, c := range closers {
+				c.Close()
+			}
+			return nil, fmt.Errorf("connecting to syslog: %w", err)
+		}
+		writers = append(writers, w)
+		closers = append(closers, w)
+	}
+
+	if len(writers) == 0 {
+		return nil, nil
+	}
+
+	l := newLogger(io.MultiWriter(writers...), timeutil.RealClock())
+	l.closers = closers
+	if metricHandle != nil {
+		l.metricHandle = metricHandle
+	}
+	return l, nil
+}
+
+func newLogger(w io.Writer, clock timeutil.Clock) *Logger {
+	return &Logger{
+		clock:        clock,
+		gidOf:        processGID,
+		metricHandle: common.NewNoopMetrics(),
+		w:            w,
+	}
+}
+
+// Close closes the file and syslog connection written to.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	for _, c := range l.closers {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// Start starts the record of an op made by the given caller. The caller's GID
+// is looked up from its PID. The record is written by Entry.Finish.
+func (l *Logger) Start(op string, caller Caller) *Entry {
+	if l == nil {
+		return nil
+	}
+
+	e := &Entry{l: l}
+	e.rec.Time = l.clock.Now()
+	e.rec.Op = op
+	if caller.Known() {
+		e.rec.Pid = &caller.Pid
+		e.rec.Uid = &caller.Uid
+		if gid, err := l.gidOf(caller.Pid); err == nil {
+			e.rec.Gid = &gid
+		}
+	}
+
+	return e
+}
+
+// Write the record. Failures don't fail the op being audited, but are counted
+// and logged.
+func (l *Logger) write(rec *Record) {
+	b, err := json.Marshal(rec)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil {
+		_, err = l.w.Write(append(b, '\n'))
+	}
+
+	if err != nil {
+		l.metricHandle.AuditWriteFailureCount(context.Background(), 1, nil)
+		if !l.failing {
+			logger.Errorf("Writing audit record of %s %q: %v", rec.Op, rec.Object, err)
+		}
+		l.failing = true
+		return
+	}
+
+	if l.failing {
+		logger.Infof("Writing audit records succeeds again")
+		l.failing = false
+	}
+}
+
+// Entry is an audit record in progress. The methods of a nil *Entry do
+// nothing.
+type Entry struct {
+	l   *Logger
+	rec Record
+}
+
+// Object sets the object operated on.
+func (e *Entry) Object(bucket, name string) {
+	if e == nil {
+		return
+	}
+	e.rec.Bucket = bucket
+	e.rec.Object = name
+}
+
+// NewObject sets the name the object was renamed to.
+func (e *Entry) NewObject(name string) {
+	if e == nil {
+		return
+	}
+	e.rec.NewObject = name
+}
+
+// Generation sets the generation of the object as a result of the op.
+func (e *Entry) Generation(generation int64) {
+	if e == nil {
+		return
+	}
+	e.rec.Generation = generation
+}
+
+// Finish writes the record with the outcome of the op.
+func (e *Entry) Finish(err error) {
+	if e == nil {
+		return
+	}
+
+	e.rec.LatencyMicros = e.l.clock.Now().Sub(e.rec.Time).Microseconds()
+	e.rec.Outcome = "ok"
+	if err != nil {
+		e.rec.Outcome = "error"
+		e.rec.Error = err.Error()
+	}
+
+	e.l.write(&e.rec)
+}
+
+// Returns the real GID of the process with the given PID, from procfs.
+func processGID(pid uint32) (uint32, error) {
+	f, err := os.Open("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/status")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		rest, ok := strings.CutPrefix(scanner.Text(), "Gid:")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			break
+		}
+		gid, err := strconv.ParseUint(fields[0], 10, 32)
+		return uint32(gid), err
+	}
+
+	return 0, fmt.Errorf("no Gid in status of process %d", pid)
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger() (*Logger, *bytes.Buffer, *timeutil.SimulatedClock) {
+	var buf bytes.Buffer
+	clock := &timeutil.SimulatedClock{}
+	clock.SetTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	l := newLogger(&buf, clock)
+	l.gidOf = func(pid uint32) (uint32, error) {
+		return pid + 1, nil
+	}
+	return l, &buf, clock
+}
+
+func readRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec), line)
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestEntry_Finish(t *testing.T) {
+	l, buf, clock := newTestLogger()
+
+	e := l.Start("rename", Caller{Pid: 42, Uid: 1000})
+	e.Object("bucket", "a/foo")
+	e.NewObject("a/bar")
+	e.Generation(17)
+	clock.AdvanceTime(1500 * time.Microsecond)
+	e.Finish(nil)
+
+	assert.Equal(t, []map[string]any{{
+		"time":       "2025-01-02T03:04:05Z",
+		"op":         "rename",
+		"pid":        float64(42),
+		"uid":        float64(1000),
+		"gid":        float64(43),
+		"bucket":     "bucket",
+		"object":     "a/foo",
+		"new_object": "a/bar",
+		"generation": float64(17),
+		"outcome":    "ok",
+		"latency_us": float64(1500),
+	}}, readRecords(t, buf))
+}
+
+func TestEntry_FinishWithError(t *testing.T) {
+	l, buf, _ := newTestLogger()
+
+	e := l.Start("unlink", Caller{Pid: 42, Uid: 0})
+	e.Object("bucket", "foo")
+	e.Finish(errors.New("taco"))
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "error", records[0]["outcome"])
+	assert.Equal(t, "taco", records[0]["error"])
+	// Root's UID is recorded.
+	assert.Equal(t, float64(0), records[0]["uid"])
+}
+
+func TestEntry_UnknownCaller(t *testing.T) {
+	l, buf, _ := newTestLogger()
+
+	l.Start("setattr", Caller{}).Finish(nil)
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0], "pid")
+	assert.NotContains(t, records[0], "uid")
+	assert.NotContains(t, records[0], "gid")
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+
+	e := l.Start("create", Caller{Pid: 1})
+	e.Object("bucket", "foo")
+	e.Finish(nil)
+
+	assert.Nil(t, e)
+	assert.NoError(t, l.Close())
+}
+
+func TestNew(t *testing.T) {
+	l, err := New("", false, nil)
+	require.NoError(t, err)
+	assert.Nil(t, l)
+
+	filePath := path.Join(t.TempDir(), "audit.log")
+	l, err = New(filePath, false, nil)
+	require.NoError(t, err)
+	l.Start("create", Caller{Pid: uint32(os.Getpid()), Uid: uint32(os.Getuid())}).Finish(nil)
+	require.NoError(t, l.Close())
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	var rec Record
+	require.NoError(t, json.Unmarshal(contents, &rec))
+	assert.Equal(t, "create", rec.Op)
+	// The GID is read from procfs.
+	require.NotNil(t, rec.Gid)
+	assert.Equal(t, uint32(os.Getgid()), *rec.Gid)
+}
+
+// A writer that fails while err is set.
+type flakyWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+type writeFailureCounter struct {
+	common.MetricHandle
+	count int64
+}
+
+func (c *writeFailureCounter) AuditWriteFailureCount(_ context.Context, inc int64, _ []common.MetricAttr) {
+	c.count += inc
+}
+
+func TestWriteFailuresAreCounted(t *testing.T) {
+	w := &flakyWriter{err: errors.New("disk full")}
+	l := newLogger(w, &timeutil.SimulatedClock{})
+	counter := &writeFailureCounter{MetricHandle: common.NewNoopMetrics()}
+	l.metricHandle = counter
+
+	l.Start("create", Caller{}).Finish(nil)
+	l.Start("unlink", Caller{}).Finish(nil)
+	w.err = nil
+	l.Start("rename", Caller{}).Finish(nil)
+
+	assert.Equal(t, int64(2), counter.count)
+	assert.False(t, l.failing)
+	records := readRecords(t, &w.Buffer)
+	require.Len(t, records, 1)
+	assert.Equal(t, "rename", records[0]["op"])
+}
@@ -81,8 +81,17 @@ func newTokenSourceFromPath(ctx context.Context, path string, scope string) (oau
 	return ts, err
 }
 
-// GetTokenSource generates the token-source for GCS endpoint by following oauth2.0 authentication
-// for key-file and default-credential flow.
+// StorageScope returns the narrowest GCS scope sufficient for a mount, i.e.
+// read_only for read-only mounts and read_write otherwise.
+func StorageScope(readOnly bool) string {
+	if readOnly {
+		return storagev1.DevstorageReadOnlyScope
+	}
+	return storagev1.DevstorageReadWriteScope
+}
+
+// GetTokenSource generates the token-source with the given scope by following oauth2.0 authentication
+// for key-file and default-credential flow, or from the token-url or credential process.
 // It also supports generating the self-signed JWT tokenSource for key-file authentication which can be
 // used by custom-endpoint(e.g. TPC).
 func GetTokenSource(
@@ -90,9 +99,10 @@ func GetTokenSource(
 	keyFile string,
 	tokenUrl string,
 	reuseTokenFromUrl bool,
+	credentialProcess string,
+	scope string,
 ) (tokenSrc oauth2.TokenSource, err error) {
 	// Create the oauth2 token source.
-	const scope = storagev1.DevstorageFullControlScope
 	var method string
 
 	if keyFile != "" {
@@ -101,6 +111,9 @@ func GetTokenSource(
 	} else if tokenUrl != "" {
 		tokenSrc, err = newProxyTokenSource(ctx, tokenUrl, reuseTokenFromUrl)
 		method = "newProxyTokenSource"
+	} else if credentialProcess != "" {
+		tokenSrc = newCredentialProcessTokenSource(ctx, credentialProcess)
+		method = "newCredentialProcessTokenSource"
 	} else {
 		tokenSrc, err = google.DefaultTokenSource(ctx, scope)
 		method = "DefaultTokenSource"
@@ -0,0 +1,50 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+type callerUIDKey struct{}
+
+// WithCallerUID returns a copy of ctx carrying the UID of the process on whose
+// behalf a request is made. On mounts with per-user credentials, it selects
+// the credentials and cache partition used for the request.
+func WithCallerUID(ctx context.Context, uid uint32) context.Context {
+	return context.WithValue(ctx, callerUIDKey{}, uid)
+}
+
+// CallerUID returns the UID stored in ctx by WithCallerUID, if any.
+func CallerUID(ctx context.Context) (uid uint32, ok bool) {
+	uid, ok = ctx.Value(callerUIDKey{}).(uint32)
+	return
+}
+
+// UserCredentialsError is returned when the credentials of the user with the
+// given UID can't be set up on a mount with per-user credentials.
+type UserCredentialsError struct {
+	UID uint32
+	Err error
+}
+
+func (e *UserCredentialsError) Error() string {
+	return fmt.Sprintf("credentials for UID %d: %v", e.UID, e.Err)
+}
+
+func (e *UserCredentialsError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// The maximum time the credential process may run for.
+	credentialProcessTimeout = time.Minute
+
+	// The backoff after the first consecutive failure of the credential
+	// process, doubled after each further failure up to the maximum.
+	credentialProcessInitialBackoff = time.Second
+	credentialProcessMaxBackoff     = time.Minute
+)
+
+// CredentialProcessError is returned when the credential process can't be run
+// or its output can't be understood.
+type CredentialProcessError struct {
+	Command string
+	Err     error
+}
+
+func (e *CredentialProcessError) Error() string {
+	return fmt.Sprintf("credential process %q: %v", e.Command, e.Err)
+}
+
+func (e *CredentialProcessError) Unwrap() error {
+	return e.Err
+}
+
+// The output of the credential process. The expiry may be given either as an
+// RFC 3339 timestamp or as a lifetime in seconds. A token without either never
+// expires.
+type credentialProcessOutput struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+	ExpiresIn   int64     `json:"expires_in"`
+}
+
+// newCredentialProcessTokenSource returns a TokenSource that runs command with
+// the shell and reads an access token from the JSON it prints to stdout, e.g.
+//
+//	{"access_token": "ya29...", "expiry": "2025-01-02T03:04:05Z"}
+//
+// The token is cached and refreshed in the background somewhat before it
+// expires, with jitter so that many mounts started together don't run the
+// process in lockstep. Only callers without a valid cached token wait for the
+// process. Failures are retried with exponential backoff, serving the cached
+// token in the meantime if it is still valid.
+func newCredentialProcessTokenSource(ctx context.Context, command string) oauth2.TokenSource {
+	return &credentialProcessTokenSource{
+		ctx:     ctx,
+		command: command,
+		run:     runCredentialProcess,
+		now:     time.Now,
+		jitter:  rand.Float64,
+	}
+}
+
+type credentialProcessTokenSource struct {
+	ctx     context.Context
+	command string
+
+	// Dependencies, replaced in tests.
+	run    func(ctx context.Context, command string) ([]byte, error)
+	now    func() time.Time
+	jitter func() float64
+
+	mu sync.Mutex
+
+	// The cached token and when to refresh it.
+	//
+	// GUARDED_BY(mu)
+	token     *oauth2.Token
+	refreshAt time.Time
+
+	// The number of consecutive failures, the last of them, and the time before
+	// which the process isn't run again.
+	//
+	// GUARDED_BY(mu)
+	failures int
+	lastErr  error
+	retryAt  time.Time
+
+	// Closed when the run of the process in progress completes, or nil if the
+	// process isn't running.
+	//
+	// GUARDED_BY(mu)
+	refreshing chan struct{}
+}
+
+func runCredentialProcess(ctx context.Context, command string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialProcessTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func parseCredentialProcessOutput(out []byte, now time.Time) (*oauth2.Token, error) {
+	var o credentialProcessOutput
+	if err := json.Unmarshal(out, &o); err != nil {
+		return nil, fmt.Errorf("cannot decode output: %w", err)
+	}
+	if o.AccessToken == "" {
+		return nil, errors.New("output has no access_token")
+	}
+
+	token := &oauth2.Token{
+		AccessToken: o.AccessToken,
+		TokenType:   o.TokenType,
+		Expiry:      o.Expiry,
+	}
+	if token.Expiry.IsZero() && o.ExpiresIn > 0 {
+		token.Expiry = now.Add(time.Duration(o.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Is the cached token usable at the given time? Unlike token.Valid(), this
+// uses the injected clock.
+//
+// LOCKS_REQUIRED(ts.mu)
+func (ts *credentialProcessTokenSource) usable(now time.Time) bool {
+	return ts.token != nil && (ts.token.Expiry.IsZero() || now.Before(ts.token.Expiry))
+}
+
+// Choose when to refresh token, between 10% and 20% of its lifetime before it
+// expires.
+//
+// LOCKS_REQUIRED(ts.mu)
+func (ts *credentialProcessTokenSource) scheduleRefresh(now time.Time) {
+	if ts.token.Expiry.IsZero() {
+		ts.refreshAt = time.Time{}
+		return
+	}
+
+	lifetime := ts.token.Expiry.Sub(now)
+	early := time.Duration(float64(lifetime) * (0.1 + 0.1*ts.jitter()))
+	ts.refreshAt = ts.token.Expiry.Add(-early)
+}
+
+// LOCKS_REQUIRED(ts.mu)
+func (ts *credentialProcessTokenSource) recordFailure(now time.Time, err error) {
+	ts.failures++
+	backoff := credentialProcessInitialBackoff << min(ts.failures-1, 16)
+	ts.retryAt = now.Add(min(backoff, credentialProcessMaxBackoff))
+	ts.lastErr = &CredentialProcessError{Command: ts.command, Err: err}
+}
+
+func (ts *credentialProcessTokenSource) Token() (*oauth2.Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for {
+		now := ts.now()
+		if ts.usable(now) && (ts.refreshAt.IsZero() || now.Before(ts.refreshAt)) {
+			return ts.token, nil
+		}
+
+		if ts.refreshing == nil && !now.Before(ts.retryAt) {
+			ts.refreshing = make(chan struct{})
+			go ts.refresh(ts.refreshing)
+		}
+
+		// The cached token is served while it is refreshed, or while the
+		// process is backing off after failing to.
+		if ts.usable(now) {
+			return ts.token, nil
+		}
+
+		if ts.refreshing == nil {
+			return nil, ts.lastErr
+		}
+
+		// Wait for the process, which may take a while, without holding up
+		// the callers that find a token when it completes.
+		refreshing := ts.refreshing
+		ts.mu.Unlock()
+		<-refreshing
+		ts.mu.Lock()
+
+		if !ts.usable(ts.now()) && ts.lastErr != nil {
+			return nil, ts.lastErr
+		}
+	}
+}
+
+// Run the credential process, record the token it prints or its failure, and
+// close done.
+//
+// LOCKS_EXCLUDED(ts.mu)
+func (ts *credentialProcessTokenSource) refresh(done chan struct{}) {
+	defer close(done)
+
+	now := ts.now()
+	out, err := ts.run(ts.ctx, ts.command)
+	var token *oauth2.Token
+	if err == nil {
+		token, err = parseCredentialProcessOutput(out, now)
+	}
+	// Treated as a failure, so that the process isn't run again right away.
+	if err == nil && !token.Expiry.IsZero() && !ts.now().Before(token.Expiry) {
+		err = errors.New("credential process returned an expired token")
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.refreshing = nil
+	if err != nil {
+		// Back off from when the process completed, which may be a while.
+		now = ts.now()
+		ts.recordFailure(now, err)
+		if ts.usable(now) {
+			logger.Auth.Warnf("Serving cached token after failure %d: %v", ts.failures, ts.lastErr)
+		}
+		return
+	}
+
+	ts.token = token
+	ts.failures = 0
+	ts.lastErr = nil
+	ts.retryAt = time.Time{}
+	ts.scheduleRefresh(now)
+}
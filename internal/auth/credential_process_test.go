@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	storagev1 "google.golang.org/api/storage/v1"
+)
+
+type CredentialProcessTest struct {
+	suite.Suite
+	clock time.Time
+
+	// The outputs returned by successive runs of the fake process.
+	outputs []string
+	errs    []error
+	runs    int
+
+	ts *credentialProcessTokenSource
+}
+
+func TestCredentialProcessSuite(t *testing.T) {
+	suite.Run(t, new(CredentialProcessTest))
+}
+
+func (t *CredentialProcessTest) SetupTest() {
+	t.clock = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	t.outputs = nil
+	t.errs = nil
+	t.runs = 0
+
+	t.ts = newCredentialProcessTokenSource(context.Background(), "get-token").(*credentialProcessTokenSource)
+	t.ts.now = func() time.Time { return t.clock }
+	t.ts.jitter = func() float64 { return 0.5 }
+	t.ts.run = func(ctx context.Context, command string) ([]byte, error) {
+		i := t.runs
+		t.runs++
+		if i < len(t.errs) && t.errs[i] != nil {
+			return nil, t.errs[i]
+		}
+		return []byte(t.outputs[i]), nil
+	}
+}
+
+// Wait for the background run of the process, if any, to complete.
+func (t *CredentialProcessTest) waitForRefresh() {
+	t.ts.mu.Lock()
+	refreshing := t.ts.refreshing
+	t.ts.mu.Unlock()
+	if refreshing != nil {
+		<-refreshing
+	}
+}
+
+func tokenOutput(accessToken string, expiresIn time.Duration) string {
+	return fmt.Sprintf(`{"access_token": %q, "expires_in": %d}`, accessToken, int64(expiresIn.Seconds()))
+}
+
+func (t *CredentialProcessTest) TestRunsScript() {
+	ts, err := GetTokenSource(
+		context.Background(),
+		"",
+		"",
+		false,
+		`echo '{"access_token": "taco", "token_type": "Bearer", "expiry": "2100-01-02T03:04:05Z"}'`,
+		storagev1.DevstorageReadWriteScope)
+	require.NoError(t.T(), err)
+
+	token, err := ts.Token()
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+	assert.Equal(t.T(), "Bearer", token.TokenType)
+	assert.Equal(t.T(), time.Date(2100, 1, 2, 3, 4, 5, 0, time.UTC), token.Expiry.UTC())
+}
+
+func (t *CredentialProcessTest) TestScriptFailureIsCredentialProcessError() {
+	ts := newCredentialProcessTokenSource(context.Background(), "echo denied >&2; exit 1")
+
+	_, err := ts.Token()
+
+	var credentialProcessErr *CredentialProcessError
+	require.ErrorAs(t.T(), err, &credentialProcessErr)
+	assert.ErrorContains(t.T(), err, "denied")
+}
+
+func (t *CredentialProcessTest) TestInvalidOutput() {
+	t.outputs = []string{"not json", `{"expires_in": 60}`}
+
+	_, err := t.ts.Token()
+	assert.ErrorContains(t.T(), err, "cannot decode output")
+
+	t.clock = t.clock.Add(time.Hour)
+	_, err = t.ts.Token()
+	assert.ErrorContains(t.T(), err, "no access_token")
+}
+
+func (t *CredentialProcessTest) TestTokenIsCachedUntilRefresh() {
+	t.outputs = []string{tokenOutput("taco", time.Hour), tokenOutput("burrito", time.Hour)}
+
+	token, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+
+	// With a jitter of 0.5, the token is refreshed 15% of its lifetime early.
+	t.clock = t.clock.Add(50 * time.Minute)
+	token, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+
+	// The cached token is served while it is refreshed in the background.
+	t.clock = t.clock.Add(time.Minute)
+	token, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+	t.waitForRefresh()
+	token, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", token.AccessToken)
+	assert.Equal(t.T(), 2, t.runs)
+}
+
+func (t *CredentialProcessTest) TestSlowRefreshDoesNotBlockCallers() {
+	t.outputs = []string{tokenOutput("taco", time.Hour)}
+	_, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	release := make(chan struct{})
+	t.ts.run = func(ctx context.Context, command string) ([]byte, error) {
+		<-release
+		return []byte(tokenOutput("burrito", time.Hour)), nil
+	}
+	t.clock = t.clock.Add(55 * time.Minute)
+
+	for range 3 {
+		token, err := t.ts.Token()
+		require.NoError(t.T(), err)
+		assert.Equal(t.T(), "taco", token.AccessToken)
+	}
+
+	close(release)
+	t.waitForRefresh()
+	token, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", token.AccessToken)
+}
+
+func (t *CredentialProcessTest) TestTokenWithoutExpiryIsNeverRefreshed() {
+	t.outputs = []string{`{"access_token": "taco"}`}
+
+	_, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	t.clock = t.clock.Add(24 * time.Hour)
+	token, err := t.ts.Token()
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+	assert.Equal(t.T(), 1, t.runs)
+}
+
+func (t *CredentialProcessTest) TestFailedRefreshServesCachedTokenAndBacksOff() {
+	t.outputs = []string{tokenOutput("taco", time.Hour), "", "", tokenOutput("burrito", time.Hour)}
+	t.errs = []error{nil, errors.New("broker down"), errors.New("broker down")}
+	_, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	t.clock = t.clock.Add(55 * time.Minute)
+
+	// The refresh fails, so the cached token is served.
+	token, err := t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+	t.waitForRefresh()
+	// The process isn't run again until the backoff of 1s has passed.
+	_, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	t.waitForRefresh()
+	assert.Equal(t.T(), 2, t.runs)
+	// After the second failure, the backoff doubles.
+	t.clock = t.clock.Add(time.Second)
+	_, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	t.waitForRefresh()
+	assert.Equal(t.T(), 3, t.runs)
+	t.clock = t.clock.Add(time.Second)
+	_, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	t.waitForRefresh()
+	assert.Equal(t.T(), 3, t.runs)
+
+	t.clock = t.clock.Add(time.Second)
+	_, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	t.waitForRefresh()
+	token, err = t.ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", token.AccessToken)
+	assert.Equal(t.T(), 4, t.runs)
+}
+
+func (t *CredentialProcessTest) TestFailureWithoutValidTokenReturnsLastError() {
+	t.errs = []error{errors.New("broker down")}
+
+	_, err := t.ts.Token()
+	require.Error(t.T(), err)
+	_, errDuringBackoff := t.ts.Token()
+
+	var credentialProcessErr *CredentialProcessError
+	assert.ErrorAs(t.T(), err, &credentialProcessErr)
+	assert.Equal(t.T(), err, errDuringBackoff)
+	assert.Equal(t.T(), 1, t.runs)
+}
+
+func (t *CredentialProcessTest) TestExpiredTokenIsFailure() {
+	t.outputs = []string{`{"access_token": "taco", "expiry": "2025-01-01T00:00:00Z"}`}
+
+	_, err := t.ts.Token()
+	require.Error(t.T(), err)
+	_, errDuringBackoff := t.ts.Token()
+
+	var credentialProcessErr *CredentialProcessError
+	assert.ErrorAs(t.T(), err, &credentialProcessErr)
+	assert.ErrorContains(t.T(), err, "expired token")
+	assert.Equal(t.T(), err, errDuringBackoff)
+	assert.Equal(t.T(), 1, t.runs)
+}
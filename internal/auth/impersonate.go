@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+// CloudPlatformScope is the scope required by the credentials used to call the
+// IAM Credentials API.
+const CloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// The lifetime requested for impersonated tokens, which is the maximum allowed
+// without extending it via organization policy.
+const impersonatedTokenLifetime = time.Hour
+
+// TokenExchanger mints access tokens for a service account on behalf of the
+// caller, as done by the IAM Credentials API's generateAccessToken method.
+type TokenExchanger interface {
+	// GenerateAccessToken returns a token for targetServiceAccount with the
+	// given scopes. Each of delegates, in order, must have been granted the
+	// token creator role on the next, with the last one having it on
+	// targetServiceAccount.
+	GenerateAccessToken(
+		ctx context.Context,
+		targetServiceAccount string,
+		delegates []string,
+		scopes []string,
+		lifetime time.Duration) (*oauth2.Token, error)
+}
+
+// NewIAMCredentialsTokenExchanger returns a TokenExchanger that calls the IAM
+// Credentials API authenticated by tokenSrc, which must carry the
+// cloud-platform scope.
+func NewIAMCredentialsTokenExchanger(ctx context.Context, tokenSrc oauth2.TokenSource) (TokenExchanger, error) {
+	service, err := iamcredentials.NewService(ctx, option.WithTokenSource(tokenSrc))
+	if err != nil {
+		return nil, fmt.Errorf("iamcredentials.NewService: %w", err)
+	}
+
+	return &iamCredentialsTokenExchanger{service: service}, nil
+}
+
+type iamCredentialsTokenExchanger struct {
+	service *iamcredentials.Service
+}
+
+// The resource name of a service account, with "-" standing for its project.
+func serviceAccountResourceName(email string) string {
+	return "projects/-/serviceAccounts/" + email
+}
+
+func (e *iamCredentialsTokenExchanger) GenerateAccessToken(
+	ctx context.Context,
+	targetServiceAccount string,
+	delegates []string,
+	scopes []string,
+	lifetime time.Duration) (*oauth2.Token, error) {
+	req := &iamcredentials.GenerateAccessTokenRequest{
+		Scope:    scopes,
+		Lifetime: fmt.Sprintf("%ds", int64(lifetime.Seconds())),
+	}
+	for _, d := range delegates {
+		req.Delegates = append(req.Delegates, serviceAccountResourceName(d))
+	}
+
+	resp, err := e.service.Projects.ServiceAccounts.
+		GenerateAccessToken(serviceAccountResourceName(targetServiceAccount), req).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	expiry, err := time.Parse(time.RFC3339, resp.ExpireTime)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse expiry time %q: %w", resp.ExpireTime, err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: resp.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}, nil
+}
+
+// NewImpersonatedTokenSource returns a TokenSource for targetServiceAccount
+// with the given scope, minted by exchanger through the optional chain of
+// delegates. Tokens are reused until they expire.
+func NewImpersonatedTokenSource(
+	ctx context.Context,
+	exchanger TokenExchanger,
+	targetServiceAccount string,
+	delegates []string,
+	scope string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, impersonatedTokenSource{
+		ctx:                  ctx,
+		exchanger:            exchanger,
+		targetServiceAccount: targetServiceAccount,
+		delegates:            delegates,
+		scope:                scope,
+	})
+}
+
+type impersonatedTokenSource struct {
+	ctx                  context.Context
+	exchanger            TokenExchanger
+	targetServiceAccount string
+	delegates            []string
+	scope                string
+}
+
+func (ts impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	token, err := ts.exchanger.GenerateAccessToken(
+		ts.ctx,
+		ts.targetServiceAccount,
+		ts.delegates,
+		[]string{ts.scope},
+		impersonatedTokenLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("cannot impersonate %s: %w", ts.targetServiceAccount, err)
+	}
+
+	return token, nil
+}
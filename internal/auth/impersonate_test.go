@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/oauth2"
+	storagev1 "google.golang.org/api/storage/v1"
+)
+
+const targetServiceAccount = "target@project.iam.gserviceaccount.com"
+
+// A TokenExchanger that records its requests and returns canned results.
+type fakeTokenExchanger struct {
+	target    string
+	delegates []string
+	scopes    []string
+	lifetime  time.Duration
+	calls     int
+
+	token *oauth2.Token
+	err   error
+}
+
+func (e *fakeTokenExchanger) GenerateAccessToken(
+	ctx context.Context,
+	targetServiceAccount string,
+	delegates []string,
+	scopes []string,
+	lifetime time.Duration) (*oauth2.Token, error) {
+	e.calls++
+	e.target = targetServiceAccount
+	e.delegates = delegates
+	e.scopes = scopes
+	e.lifetime = lifetime
+	return e.token, e.err
+}
+
+type ImpersonateTest struct {
+	suite.Suite
+	exchanger *fakeTokenExchanger
+}
+
+func TestImpersonateSuite(t *testing.T) {
+	suite.Run(t, new(ImpersonateTest))
+}
+
+func (t *ImpersonateTest) SetupTest() {
+	t.exchanger = &fakeTokenExchanger{
+		token: &oauth2.Token{AccessToken: "taco", Expiry: time.Now().Add(time.Hour)},
+	}
+}
+
+func (t *ImpersonateTest) TestStorageScope() {
+	assert.Equal(t.T(), storagev1.DevstorageReadOnlyScope, StorageScope(true))
+	assert.Equal(t.T(), storagev1.DevstorageReadWriteScope, StorageScope(false))
+}
+
+func (t *ImpersonateTest) TestTokenIsMintedForTargetWithDelegates() {
+	delegates := []string{"a@project.iam.gserviceaccount.com", "b@project.iam.gserviceaccount.com"}
+	ts := NewImpersonatedTokenSource(context.Background(), t.exchanger, targetServiceAccount, delegates, StorageScope(true))
+
+	token, err := ts.Token()
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", token.AccessToken)
+	assert.Equal(t.T(), targetServiceAccount, t.exchanger.target)
+	assert.Equal(t.T(), delegates, t.exchanger.delegates)
+	assert.Equal(t.T(), []string{storagev1.DevstorageReadOnlyScope}, t.exchanger.scopes)
+	assert.Equal(t.T(), impersonatedTokenLifetime, t.exchanger.lifetime)
+}
+
+func (t *ImpersonateTest) TestTokenIsReusedUntilExpiry() {
+	ts := NewImpersonatedTokenSource(context.Background(), t.exchanger, targetServiceAccount, nil, StorageScope(false))
+
+	_, err := ts.Token()
+	require.NoError(t.T(), err)
+	_, err = ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), 1, t.exchanger.calls)
+
+	// Once the token expires, a new one is minted.
+	t.exchanger.token = &oauth2.Token{AccessToken: "burrito", Expiry: time.Now().Add(-time.Minute)}
+	ts = NewImpersonatedTokenSource(context.Background(), t.exchanger, targetServiceAccount, nil, StorageScope(false))
+	_, err = ts.Token()
+	require.NoError(t.T(), err)
+	_, err = ts.Token()
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), 3, t.exchanger.calls)
+}
+
+func (t *ImpersonateTest) TestExchangeError() {
+	t.exchanger.err = errors.New("permission denied")
+	ts := NewImpersonatedTokenSource(context.Background(), t.exchanger, targetServiceAccount, nil, StorageScope(false))
+
+	_, err := ts.Token()
+
+	assert.ErrorIs(t.T(), err, t.exchanger.err)
+	assert.ErrorContains(t.T(), err, targetServiceAccount)
+}
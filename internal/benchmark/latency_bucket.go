@@ -0,0 +1,366 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/ratelimit"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+)
+
+// LatencyModel describes the simulated cost of bucket requests.
+type LatencyModel struct {
+	// Added to every request before it is passed to the wrapped bucket.
+	RequestLatency time.Duration `json:"request_latency_ns"`
+
+	// Added on top of RequestLatency before the first byte of an object read or
+	// write.
+	FirstByteLatency time.Duration `json:"first_byte_latency_ns"`
+
+	// The fraction by which each latency varies at random, e.g. 0.1 for +/-10%.
+	Jitter float64 `json:"jitter"`
+
+	// The aggregate bandwidth of object reads and of object writes, in bytes
+	// per second. Zero means unlimited.
+	ReadBandwidth  float64 `json:"read_bandwidth"`
+	WriteBandwidth float64 `json:"write_bandwidth"`
+}
+
+// The burst size of the bandwidth throttles, which is also the largest chunk
+// of data a single read or write is allowed to transfer at once.
+const bandwidthBurstBytes = 1 << 20
+
+// NewLatencyBucket returns a bucket that delays requests to the wrapped bucket
+// and limits the bandwidth of object contents according to model.
+func NewLatencyBucket(model LatencyModel, wrapped gcs.Bucket) gcs.Bucket {
+	b := &latencyBucket{
+		model:   model,
+		wrapped: wrapped,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if model.ReadBandwidth > 0 {
+		b.readThrottle = ratelimit.NewThrottle(model.ReadBandwidth, bandwidthBurstBytes)
+	}
+
+	if model.WriteBandwidth > 0 {
+		b.writeThrottle = ratelimit.NewThrottle(model.WriteBandwidth, bandwidthBurstBytes)
+	}
+
+	return b
+}
+
+type latencyBucket struct {
+	model   LatencyModel
+	wrapped gcs.Bucket
+
+	// Nil if the corresponding bandwidth is unlimited.
+	readThrottle  ratelimit.Throttle
+	writeThrottle ratelimit.Throttle
+
+	randMu sync.Mutex
+	rand   *rand.Rand // GUARDED_BY(randMu)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+func (b *latencyBucket) jittered(d time.Duration) time.Duration {
+	if d <= 0 || b.model.Jitter <= 0 {
+		return d
+	}
+
+	b.randMu.Lock()
+	f := 1 + b.model.Jitter*(2*b.rand.Float64()-1)
+	b.randMu.Unlock()
+
+	return time.Duration(float64(d) * f)
+}
+
+// Sleep for the request latency, plus the first byte latency if firstByte is
+// set, returning early with an error if ctx is cancelled.
+func (b *latencyBucket) wait(ctx context.Context, firstByte bool) error {
+	d := b.model.RequestLatency
+	if firstByte {
+		d += b.model.FirstByteLatency
+	}
+
+	d = b.jittered(d)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *latencyBucket) throttledContents(ctx context.Context, r io.Reader) io.Reader {
+	if b.writeThrottle == nil || r == nil {
+		return r
+	}
+
+	return ratelimit.ThrottledReader(ctx, r, b.writeThrottle)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Bucket interface
+////////////////////////////////////////////////////////////////////////
+
+func (b *latencyBucket) Name() string {
+	return b.wrapped.Name()
+}
+
+func (b *latencyBucket) BucketType() gcs.BucketType {
+	return b.wrapped.BucketType()
+}
+
+func (b *latencyBucket) NewReader(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (io.ReadCloser, error) {
+	return b.NewReaderWithReadHandle(ctx, req)
+}
+
+func (b *latencyBucket) NewReaderWithReadHandle(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (rd gcs.StorageReader, err error) {
+	if err = b.wait(ctx, true); err != nil {
+		return
+	}
+
+	rd, err = b.wrapped.NewReaderWithReadHandle(ctx, req)
+	if err != nil || b.readThrottle == nil {
+		return
+	}
+
+	rd = &throttledStorageReader{
+		Reader:        ratelimit.ThrottledReader(ctx, rd, b.readThrottle),
+		StorageReader: rd,
+	}
+
+	return
+}
+
+func (b *latencyBucket) NewMultiRangeDownloader(
+	ctx context.Context,
+	req *gcs.MultiRangeDownloaderRequest) (gcs.MultiRangeDownloader, error) {
+	if err := b.wait(ctx, true); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.NewMultiRangeDownloader(ctx, req)
+}
+
+func (b *latencyBucket) CreateObject(
+	ctx context.Context,
+	req *gcs.CreateObjectRequest) (*gcs.Object, error) {
+	if err := b.wait(ctx, true); err != nil {
+		return nil, err
+	}
+
+	// Don't modify the caller's request.
+	throttled := *req
+	throttled.Contents = b.throttledContents(ctx, req.Contents)
+
+	return b.wrapped.CreateObject(ctx, &throttled)
+}
+
+func (b *latencyBucket) CreateObjectChunkWriter(
+	ctx context.Context,
+	req *gcs.CreateObjectRequest,
+	chunkSize int,
+	callBack func(bytesUploadedSoFar int64)) (gcs.Writer, error) {
+	if err := b.wait(ctx, true); err != nil {
+		return nil, err
+	}
+
+	w, err := b.wrapped.CreateObjectChunkWriter(ctx, req, chunkSize, callBack)
+	if err != nil || b.writeThrottle == nil {
+		return w, err
+	}
+
+	return &throttledWriter{Writer: w, ctx: ctx, throttle: b.writeThrottle}, nil
+}
+
+func (b *latencyBucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (*gcs.MinObject, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	// The wrapped bucket expects the writer it handed out.
+	if tw, ok := w.(*throttledWriter); ok {
+		w = tw.Writer
+	}
+
+	return b.wrapped.FinalizeUpload(ctx, w)
+}
+
+func (b *latencyBucket) CopyObject(
+	ctx context.Context,
+	req *gcs.CopyObjectRequest) (*gcs.Object, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.CopyObject(ctx, req)
+}
+
+func (b *latencyBucket) ComposeObjects(
+	ctx context.Context,
+	req *gcs.ComposeObjectsRequest) (*gcs.Object, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.ComposeObjects(ctx, req)
+}
+
+func (b *latencyBucket) StatObject(
+	ctx context.Context,
+	req *gcs.StatObjectRequest) (*gcs.MinObject, *gcs.ExtendedObjectAttributes, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, nil, err
+	}
+
+	return b.wrapped.StatObject(ctx, req)
+}
+
+func (b *latencyBucket) ListObjects(
+	ctx context.Context,
+	req *gcs.ListObjectsRequest) (*gcs.Listing, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.ListObjects(ctx, req)
+}
+
+func (b *latencyBucket) UpdateObject(
+	ctx context.Context,
+	req *gcs.UpdateObjectRequest) (*gcs.Object, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.UpdateObject(ctx, req)
+}
+
+func (b *latencyBucket) DeleteObject(
+	ctx context.Context,
+	req *gcs.DeleteObjectRequest) error {
+	if err := b.wait(ctx, false); err != nil {
+		return err
+	}
+
+	return b.wrapped.DeleteObject(ctx, req)
+}
+
+func (b *latencyBucket) MoveObject(
+	ctx context.Context,
+	req *gcs.MoveObjectRequest) (*gcs.Object, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.MoveObject(ctx, req)
+}
+
+func (b *latencyBucket) DeleteFolder(ctx context.Context, folderName string) error {
+	if err := b.wait(ctx, false); err != nil {
+		return err
+	}
+
+	return b.wrapped.DeleteFolder(ctx, folderName)
+}
+
+func (b *latencyBucket) GetFolder(ctx context.Context, folderName string) (*gcs.Folder, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.GetFolder(ctx, folderName)
+}
+
+func (b *latencyBucket) RenameFolder(ctx context.Context, folderName string, destinationFolderId string) (*gcs.Folder, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.RenameFolder(ctx, folderName, destinationFolderId)
+}
+
+func (b *latencyBucket) CreateFolder(ctx context.Context, folderName string) (*gcs.Folder, error) {
+	if err := b.wait(ctx, false); err != nil {
+		return nil, err
+	}
+
+	return b.wrapped.CreateFolder(ctx, folderName)
+}
+
+////////////////////////////////////////////////////////////////////////
+// throttledStorageReader, throttledWriter
+////////////////////////////////////////////////////////////////////////
+
+// A gcs.StorageReader whose reads are served by a throttled io.Reader.
+type throttledStorageReader struct {
+	io.Reader
+	gcs.StorageReader
+}
+
+func (r *throttledStorageReader) Read(p []byte) (int, error) {
+	return r.Reader.Read(p)
+}
+
+// A gcs.Writer whose writes wait for the bandwidth throttle.
+type throttledWriter struct {
+	gcs.Writer
+	ctx      context.Context
+	throttle ratelimit.Throttle
+}
+
+func (w *throttledWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		chunk := p
+		if uint64(len(chunk)) > w.throttle.Capacity() {
+			chunk = chunk[:w.throttle.Capacity()]
+		}
+
+		if err = w.throttle.Wait(w.ctx, uint64(len(chunk))); err != nil {
+			return
+		}
+
+		var tmp int
+		tmp, err = w.Writer.Write(chunk)
+		n += tmp
+		p = p[tmp:]
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LatencyBucketTest struct {
+	suite.Suite
+	ctx     context.Context
+	wrapped gcs.Bucket
+}
+
+func TestLatencyBucketSuite(t *testing.T) {
+	suite.Run(t, new(LatencyBucketTest))
+}
+
+func (t *LatencyBucketTest) SetupTest() {
+	t.ctx = context.Background()
+	t.wrapped = fake.NewFakeBucket(timeutil.RealClock(), "some_bucket", gcs.BucketType{})
+}
+
+func (t *LatencyBucketTest) TestRequestLatency() {
+	b := NewLatencyBucket(LatencyModel{RequestLatency: 50 * time.Millisecond}, t.wrapped)
+
+	start := time.Now()
+	_, err := b.ListObjects(t.ctx, &gcs.ListObjectsRequest{})
+
+	require.NoError(t.T(), err)
+	assert.GreaterOrEqual(t.T(), time.Since(start), 50*time.Millisecond)
+}
+
+func (t *LatencyBucketTest) TestFirstByteLatencyOnlyForContents() {
+	b := NewLatencyBucket(LatencyModel{FirstByteLatency: 100 * time.Millisecond}, t.wrapped)
+	_, err := storageutil.CreateObject(t.ctx, t.wrapped, "foo", []byte("taco"))
+	require.NoError(t.T(), err)
+
+	start := time.Now()
+	_, _, err = b.StatObject(t.ctx, &gcs.StatObjectRequest{Name: "foo"})
+	require.NoError(t.T(), err)
+	assert.Less(t.T(), time.Since(start), 100*time.Millisecond)
+
+	start = time.Now()
+	contents, err := storageutil.ReadObject(t.ctx, b, "foo")
+	require.NoError(t.T(), err)
+	assert.GreaterOrEqual(t.T(), time.Since(start), 100*time.Millisecond)
+	assert.Equal(t.T(), "taco", string(contents))
+}
+
+func (t *LatencyBucketTest) TestCancellation() {
+	b := NewLatencyBucket(LatencyModel{RequestLatency: time.Hour}, t.wrapped)
+	ctx, cancel := context.WithTimeout(t.ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err := b.ListObjects(ctx, &gcs.ListObjectsRequest{})
+
+	assert.ErrorIs(t.T(), err, context.DeadlineExceeded)
+}
+
+func (t *LatencyBucketTest) TestReadBandwidth() {
+	// The throttle starts with a full burst, so read more than one burst.
+	contents := bytes.Repeat([]byte("x"), 3*bandwidthBurstBytes)
+	_, err := storageutil.CreateObject(t.ctx, t.wrapped, "foo", contents)
+	require.NoError(t.T(), err)
+	b := NewLatencyBucket(LatencyModel{ReadBandwidth: 8 * bandwidthBurstBytes}, t.wrapped)
+
+	start := time.Now()
+	got, err := storageutil.ReadObject(t.ctx, b, "foo")
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), contents, got)
+	assert.GreaterOrEqual(t.T(), time.Since(start), 200*time.Millisecond)
+}
+
+func (t *LatencyBucketTest) TestWriteBandwidth() {
+	contents := bytes.Repeat([]byte("x"), 3*bandwidthBurstBytes)
+	b := NewLatencyBucket(LatencyModel{WriteBandwidth: 8 * bandwidthBurstBytes}, t.wrapped)
+
+	start := time.Now()
+	_, err := storageutil.CreateObject(t.ctx, b, "foo", contents)
+
+	require.NoError(t.T(), err)
+	assert.GreaterOrEqual(t.T(), time.Since(start), 200*time.Millisecond)
+	got, err := storageutil.ReadObject(t.ctx, t.wrapped, "foo")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), contents, got)
+}
+
+func (t *LatencyBucketTest) TestChunkWriterIsFinalizedByWrappedBucket() {
+	b := NewLatencyBucket(LatencyModel{WriteBandwidth: 1 << 30}, t.wrapped)
+	w, err := b.CreateObjectChunkWriter(t.ctx, &gcs.CreateObjectRequest{Name: "foo"}, 1<<20, nil)
+	require.NoError(t.T(), err)
+	_, err = io.WriteString(w, "burrito")
+	require.NoError(t.T(), err)
+
+	o, err := b.FinalizeUpload(t.ctx, w)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "foo", o.Name)
+	got, err := storageutil.ReadObject(t.ctx, t.wrapped, "foo")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", string(got))
+}
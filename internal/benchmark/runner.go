@@ -0,0 +1,301 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchmark runs synthetic workload profiles against a directory,
+// usually a gcsfuse mount, and reports their throughput and latency.
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options control how each workload profile runs.
+type Options struct {
+	// How long each profile runs for, unless MaxOps is reached first.
+	Duration time.Duration `json:"duration_ns"`
+
+	// If non-zero, each profile stops after this many ops.
+	MaxOps int64 `json:"max_ops,omitempty"`
+
+	// Number of workers issuing ops concurrently.
+	Concurrency int `json:"concurrency"`
+
+	// Size of the large files used by seq-read, random-read and
+	// checkpoint-write.
+	FileSize int64 `json:"file_size"`
+
+	// Size of the small files used by small-file-create and dataloader-shuffle.
+	SmallFileSize int64 `json:"small_file_size"`
+
+	// Size of each read or write call.
+	IOSize int `json:"io_size"`
+
+	// Number of files used by listing and dataloader-shuffle.
+	NumFiles int `json:"num_files"`
+
+	// If set, the files created by each profile are not removed afterwards.
+	KeepFiles bool `json:"keep_files,omitempty"`
+}
+
+// DefaultOptions returns the options used when none are specified.
+func DefaultOptions() Options {
+	return Options{
+		Duration:      10 * time.Second,
+		Concurrency:   4,
+		FileSize:      64 << 20,
+		SmallFileSize: 64 << 10,
+		IOSize:        1 << 20,
+		NumFiles:      256,
+	}
+}
+
+func (o *Options) validate() error {
+	switch {
+	case o.Duration <= 0 && o.MaxOps <= 0:
+		return errors.New("one of duration and max ops must be positive")
+	case o.Concurrency <= 0:
+		return fmt.Errorf("concurrency must be positive, got %d", o.Concurrency)
+	case o.FileSize <= 0 || o.SmallFileSize <= 0:
+		return errors.New("file sizes must be positive")
+	case o.IOSize <= 0:
+		return fmt.Errorf("io size must be positive, got %d", o.IOSize)
+	case o.NumFiles <= 0:
+		return fmt.Errorf("number of files must be positive, got %d", o.NumFiles)
+	}
+
+	return nil
+}
+
+// LatencySummary summarizes the latencies of a profile's ops, in
+// milliseconds.
+type LatencySummary struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Result is the outcome of running a single profile.
+type Result struct {
+	Profile string `json:"profile"`
+
+	// Successful and failed ops.
+	Ops    int64 `json:"ops"`
+	Errors int64 `json:"errors"`
+
+	// The first op error, if any.
+	FirstError string `json:"first_error,omitempty"`
+
+	// Bytes of file contents transferred by successful ops.
+	Bytes int64 `json:"bytes"`
+
+	DurationSecs float64        `json:"duration_secs"`
+	OpsPerSec    float64        `json:"ops_per_sec"`
+	MiBPerSec    float64        `json:"mib_per_sec"`
+	LatencyMs    LatencySummary `json:"latency_ms"`
+}
+
+// Report is the JSON document emitted for a run of several profiles.
+type Report struct {
+	Version string    `json:"version"`
+	Started time.Time `json:"started"`
+
+	// The bucket benchmarked, and the simulated latency model if it was the
+	// fake bucket.
+	Bucket    string        `json:"bucket"`
+	Simulated *LatencyModel `json:"simulated,omitempty"`
+
+	// The gcsfuse command line, to tell runs with different flags apart.
+	Args []string `json:"args"`
+
+	Options Options  `json:"options"`
+	Results []Result `json:"results"`
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Run runs each of the named profiles in turn in its own subdirectory of dir,
+// returning their results. Errors from individual ops are counted in the
+// results; an error is returned only if a profile can't be set up or the
+// arguments are invalid.
+func Run(ctx context.Context, dir string, profiles []string, opts Options) (results []Result, err error) {
+	if err = opts.validate(); err != nil {
+		return
+	}
+
+	var ws []workload
+	for _, name := range profiles {
+		var w workload
+		if w, err = findWorkload(name); err != nil {
+			return
+		}
+		ws = append(ws, w)
+	}
+
+	for _, w := range ws {
+		var r Result
+		if r, err = runWorkload(ctx, dir, w, opts); err != nil {
+			err = fmt.Errorf("profile %s: %w", w.name, err)
+			return
+		}
+		results = append(results, r)
+	}
+
+	return
+}
+
+func runWorkload(ctx context.Context, dir string, w workload, opts Options) (r Result, err error) {
+	run := &workloadRun{
+		dir:     filepath.Join(dir, w.name),
+		opts:    opts,
+		handles: make(map[int]*os.File),
+	}
+
+	if err = os.MkdirAll(run.dir, 0755); err != nil {
+		return
+	}
+
+	defer func() {
+		err = errors.Join(err, run.closeHandles())
+		if !opts.KeepFiles {
+			err = errors.Join(err, os.RemoveAll(run.dir))
+		}
+	}()
+
+	if w.setup != nil {
+		if err = w.setup(run); err != nil {
+			err = fmt.Errorf("setup: %w", err)
+			return
+		}
+	}
+
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var (
+		mu         sync.Mutex
+		latencies  []time.Duration
+		bytes      int64
+		errCount   int64
+		firstError error
+		started    atomic.Int64
+		wg         sync.WaitGroup
+	)
+
+	start := time.Now()
+	for worker := 0; worker < opts.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rand := mathrand.New(mathrand.NewSource(int64(worker)))
+			for ctx.Err() == nil {
+				if opts.MaxOps > 0 && started.Add(1) > opts.MaxOps {
+					return
+				}
+
+				opStart := time.Now()
+				n, opErr := w.op(run, worker, rand)
+				latency := time.Since(opStart)
+
+				mu.Lock()
+				if opErr != nil {
+					errCount++
+					if firstError == nil {
+						firstError = opErr
+					}
+				} else {
+					latencies = append(latencies, latency)
+					bytes += n
+				}
+				mu.Unlock()
+			}
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	r = Result{
+		Profile:      w.name,
+		Ops:          int64(len(latencies)),
+		Errors:       errCount,
+		Bytes:        bytes,
+		DurationSecs: elapsed.Seconds(),
+		LatencyMs:    summarize(latencies),
+	}
+
+	if firstError != nil {
+		r.FirstError = firstError.Error()
+	}
+
+	if secs := elapsed.Seconds(); secs > 0 {
+		r.OpsPerSec = float64(r.Ops) / secs
+		r.MiBPerSec = float64(bytes) / (1 << 20) / secs
+	}
+
+	return
+}
+
+func summarize(latencies []time.Duration) (s LatencySummary) {
+	if len(latencies) == 0 {
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	percentile := func(p int) float64 {
+		i := (len(latencies)*p+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return ms(latencies[i])
+	}
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	s = LatencySummary{
+		Min:  ms(latencies[0]),
+		Mean: ms(total / time.Duration(len(latencies))),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+
+	return
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	mathrand "math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RunnerTest struct {
+	suite.Suite
+	dir  string
+	opts Options
+}
+
+func TestRunnerSuite(t *testing.T) {
+	suite.Run(t, new(RunnerTest))
+}
+
+func (t *RunnerTest) SetupTest() {
+	t.dir = t.T().TempDir()
+	t.opts = Options{
+		MaxOps:        20,
+		Concurrency:   2,
+		FileSize:      64 << 10,
+		SmallFileSize: 4 << 10,
+		IOSize:        16 << 10,
+		NumFiles:      8,
+	}
+}
+
+func (t *RunnerTest) TestAllProfiles() {
+	results, err := Run(context.Background(), t.dir, ProfileNames(), t.opts)
+
+	require.NoError(t.T(), err)
+	require.Len(t.T(), results, len(ProfileNames()))
+	for i, r := range results {
+		assert.Equal(t.T(), ProfileNames()[i], r.Profile)
+		assert.EqualValues(t.T(), 20, r.Ops, r.Profile)
+		assert.Zero(t.T(), r.Errors, "%s: %s", r.Profile, r.FirstError)
+		assert.LessOrEqual(t.T(), r.LatencyMs.Min, r.LatencyMs.P50, r.Profile)
+		assert.LessOrEqual(t.T(), r.LatencyMs.P50, r.LatencyMs.P99, r.Profile)
+		assert.LessOrEqual(t.T(), r.LatencyMs.P99, r.LatencyMs.Max, r.Profile)
+	}
+}
+
+func (t *RunnerTest) TestBytesTransferred() {
+	results, err := Run(context.Background(), t.dir, []string{"seq-read", "small-file-create", "listing"}, t.opts)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), 20*t.opts.FileSize, results[0].Bytes)
+	assert.Equal(t.T(), 20*t.opts.SmallFileSize, results[1].Bytes)
+	assert.Zero(t.T(), results[2].Bytes)
+}
+
+func (t *RunnerTest) TestFilesRemovedUnlessKept() {
+	_, err := Run(context.Background(), t.dir, []string{"small-file-create"}, t.opts)
+	require.NoError(t.T(), err)
+	entries, err := os.ReadDir(t.dir)
+	require.NoError(t.T(), err)
+	assert.Empty(t.T(), entries)
+
+	t.opts.KeepFiles = true
+	_, err = Run(context.Background(), t.dir, []string{"small-file-create"}, t.opts)
+	require.NoError(t.T(), err)
+	entries, err = os.ReadDir(t.dir + "/small-file-create")
+	require.NoError(t.T(), err)
+	assert.Len(t.T(), entries, 20)
+}
+
+func (t *RunnerTest) TestDuration() {
+	t.opts.MaxOps = 0
+	t.opts.Duration = 100 * time.Millisecond
+
+	results, err := Run(context.Background(), t.dir, []string{"listing"}, t.opts)
+
+	require.NoError(t.T(), err)
+	assert.Positive(t.T(), results[0].Ops)
+	assert.InDelta(t.T(), 0.1, results[0].DurationSecs, 0.1)
+}
+
+func (t *RunnerTest) TestOpErrorsAreCounted() {
+	// Listing a missing directory fails.
+	w := workload{
+		name: "failing",
+		op: func(w *workloadRun, _ int, _ *mathrand.Rand) (int64, error) {
+			_, err := os.ReadDir(w.dir + "/missing")
+			return 0, err
+		},
+	}
+
+	r, err := runWorkload(context.Background(), t.dir, w, t.opts)
+
+	require.NoError(t.T(), err)
+	assert.Zero(t.T(), r.Ops)
+	assert.EqualValues(t.T(), 20, r.Errors)
+	assert.Contains(t.T(), r.FirstError, "no such file or directory")
+}
+
+func (t *RunnerTest) TestUnknownProfile() {
+	_, err := Run(context.Background(), t.dir, []string{"seq-read", "bogus"}, t.opts)
+
+	assert.ErrorContains(t.T(), err, `unknown profile "bogus"`)
+}
+
+func (t *RunnerTest) TestInvalidOptions() {
+	t.opts.Concurrency = 0
+
+	_, err := Run(context.Background(), t.dir, ProfileNames(), t.opts)
+
+	assert.ErrorContains(t.T(), err, "concurrency must be positive")
+}
+
+func (t *RunnerTest) TestReportJSON() {
+	report := Report{
+		Version:   "v1",
+		Bucket:    "b",
+		Simulated: &LatencyModel{RequestLatency: time.Millisecond},
+		Options:   t.opts,
+		Results:   []Result{{Profile: "listing", Ops: 3}},
+	}
+	var buf bytes.Buffer
+
+	require.NoError(t.T(), report.WriteJSON(&buf))
+
+	var decoded map[string]any
+	require.NoError(t.T(), json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t.T(), "b", decoded["bucket"])
+	assert.EqualValues(t.T(), time.Millisecond, decoded["simulated"].(map[string]any)["request_latency_ns"])
+	assert.Equal(t.T(), "listing", decoded["results"].([]any)[0].(map[string]any)["profile"])
+}
+
+func TestSummarize(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	s := summarize(latencies)
+
+	assert.Equal(t, LatencySummary{Min: 1, Mean: 50.5, P50: 50, P90: 90, P99: 99, Max: 100}, s)
+}
+
+func TestShuffler(t *testing.T) {
+	s := newShuffler(5)
+
+	for epoch := 0; epoch < 3; epoch++ {
+		seen := make(map[int]bool)
+		for i := 0; i < 5; i++ {
+			seen[s.next()] = true
+		}
+		assert.Len(t, seen, 5)
+	}
+}
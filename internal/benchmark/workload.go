@@ -0,0 +1,303 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// A workload profile. Setup is not measured; each call to op is one measured
+// operation.
+type workload struct {
+	name        string
+	description string
+
+	// Prepare the files the workload needs. May be nil.
+	setup func(w *workloadRun) error
+
+	// Run one operation for the given worker, returning the number of bytes
+	// of file contents it transferred.
+	op func(w *workloadRun, worker int, r *mathrand.Rand) (bytes int64, err error)
+}
+
+// The state of a workload while it runs in a directory.
+type workloadRun struct {
+	dir  string
+	opts Options
+
+	// Used to mint unique file names.
+	nextID atomic.Int64
+
+	// Files kept open across ops, closed when the run finishes.
+	handlesMu sync.Mutex
+	handles   map[int]*os.File // GUARDED_BY(handlesMu)
+
+	shuffle *shuffler
+}
+
+var workloads = []workload{
+	{
+		name:        "seq-read",
+		description: "Each worker reads its own large file from start to end.",
+		setup: func(w *workloadRun) error {
+			for i := 0; i < w.opts.Concurrency; i++ {
+				if err := writeRandomFile(w.path("file", i), w.opts.FileSize, w.opts.IOSize); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		op: func(w *workloadRun, worker int, _ *mathrand.Rand) (int64, error) {
+			return readWholeFile(w.path("file", worker), w.opts.IOSize)
+		},
+	},
+	{
+		name:        "random-read",
+		description: "Workers read IO-sized chunks at random aligned offsets of a shared large file.",
+		setup: func(w *workloadRun) error {
+			return writeRandomFile(w.path("file", 0), w.opts.FileSize, w.opts.IOSize)
+		},
+		op: func(w *workloadRun, worker int, r *mathrand.Rand) (int64, error) {
+			f, err := w.handle(worker, w.path("file", 0))
+			if err != nil {
+				return 0, err
+			}
+
+			chunks := w.opts.FileSize / int64(w.opts.IOSize)
+			if chunks == 0 {
+				chunks = 1
+			}
+			buf := make([]byte, w.opts.IOSize)
+			n, err := f.ReadAt(buf, r.Int63n(chunks)*int64(w.opts.IOSize))
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return int64(n), err
+		},
+	},
+	{
+		name:        "small-file-create",
+		description: "Workers create, write and close new small files.",
+		op: func(w *workloadRun, worker int, _ *mathrand.Rand) (int64, error) {
+			p := w.path("small", int(w.nextID.Add(1)))
+			if err := writeRandomFile(p, w.opts.SmallFileSize, w.opts.IOSize); err != nil {
+				return 0, err
+			}
+			return w.opts.SmallFileSize, nil
+		},
+	},
+	{
+		name:        "listing",
+		description: "Workers list a directory of many files and stat every entry.",
+		setup: func(w *workloadRun) error {
+			for i := 0; i < w.opts.NumFiles; i++ {
+				if err := writeRandomFile(w.path("entry", i), 1, 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		op: func(w *workloadRun, _ int, _ *mathrand.Rand) (int64, error) {
+			entries, err := os.ReadDir(w.dir)
+			if err != nil {
+				return 0, err
+			}
+			for _, e := range entries {
+				if _, err = e.Info(); err != nil {
+					return 0, err
+				}
+			}
+			return 0, nil
+		},
+	},
+	{
+		name:        "checkpoint-write",
+		description: "Workers write a new large file sequentially and close it, like a training checkpoint.",
+		op: func(w *workloadRun, worker int, _ *mathrand.Rand) (int64, error) {
+			p := w.path("checkpoint", int(w.nextID.Add(1)))
+			if err := writeRandomFile(p, w.opts.FileSize, w.opts.IOSize); err != nil {
+				return 0, err
+			}
+			return w.opts.FileSize, nil
+		},
+	},
+	{
+		name:        "dataloader-shuffle",
+		description: "Workers read whole small files in a shuffled order that is redrawn every epoch.",
+		setup: func(w *workloadRun) error {
+			for i := 0; i < w.opts.NumFiles; i++ {
+				if err := writeRandomFile(w.path("sample", i), w.opts.SmallFileSize, w.opts.IOSize); err != nil {
+					return err
+				}
+			}
+			w.shuffle = newShuffler(w.opts.NumFiles)
+			return nil
+		},
+		op: func(w *workloadRun, _ int, _ *mathrand.Rand) (int64, error) {
+			return readWholeFile(w.path("sample", w.shuffle.next()), w.opts.IOSize)
+		},
+	},
+}
+
+// ProfileNames returns the names of the supported workload profiles.
+func ProfileNames() (names []string) {
+	for _, w := range workloads {
+		names = append(names, w.name)
+	}
+
+	return
+}
+
+// ProfileDescription returns a one-line description of the named profile, or
+// "" if there is no such profile.
+func ProfileDescription(name string) string {
+	for _, w := range workloads {
+		if w.name == name {
+			return w.description
+		}
+	}
+
+	return ""
+}
+
+func findWorkload(name string) (workload, error) {
+	for _, w := range workloads {
+		if w.name == name {
+			return w, nil
+		}
+	}
+
+	return workload{}, fmt.Errorf("unknown profile %q", name)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+func (w *workloadRun) path(kind string, i int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%s-%06d", kind, i))
+}
+
+// Return the file kept open for the worker, opening p if there is none.
+func (w *workloadRun) handle(worker int, p string) (*os.File, error) {
+	w.handlesMu.Lock()
+	defer w.handlesMu.Unlock()
+
+	if f, ok := w.handles[worker]; ok {
+		return f, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+
+	w.handles[worker] = f
+	return f, nil
+}
+
+func (w *workloadRun) closeHandles() (err error) {
+	w.handlesMu.Lock()
+	defer w.handlesMu.Unlock()
+
+	for worker, f := range w.handles {
+		err = errors.Join(err, f.Close())
+		delete(w.handles, worker)
+	}
+
+	return
+}
+
+// Write a file of the given size with random contents, ioSize bytes at a time,
+// and close it.
+func writeRandomFile(p string, size int64, ioSize int) (err error) {
+	f, err := os.Create(p)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	buf := make([]byte, ioSize)
+	for size > 0 {
+		chunk := buf
+		if int64(len(chunk)) > size {
+			chunk = chunk[:size]
+		}
+
+		if _, err = rand.Read(chunk); err != nil {
+			return
+		}
+
+		if _, err = f.Write(chunk); err != nil {
+			return fmt.Errorf("write %s: %w", p, err)
+		}
+
+		size -= int64(len(chunk))
+	}
+
+	return
+}
+
+func readWholeFile(p string, ioSize int) (n int64, err error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return io.CopyBuffer(io.Discard, struct{ io.Reader }{f}, make([]byte, ioSize))
+}
+
+// Hands out the integers [0, n) in a random order, redrawn after each pass.
+type shuffler struct {
+	mu   sync.Mutex
+	rand *mathrand.Rand
+	perm []int
+	pos  int
+}
+
+func newShuffler(n int) *shuffler {
+	s := &shuffler{rand: mathrand.New(mathrand.NewSource(int64(n)))}
+	s.perm = s.rand.Perm(n)
+	return s
+}
+
+func (s *shuffler) next() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pos == len(s.perm) {
+		s.rand.Shuffle(len(s.perm), func(i, j int) {
+			s.perm[i], s.perm[j] = s.perm[j], s.perm[i]
+		})
+		s.pos = 0
+	}
+
+	s.pos++
+	return s.perm[s.pos-1]
+}
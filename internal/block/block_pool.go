@@ -15,8 +15,11 @@
 package block
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -37,21 +40,31 @@ type BlockPool struct {
 	// Semaphore used to limit the total number of blocks created across
 	// different files.
 	globalMaxBlocksSem *semaphore.Weighted
+
+	// Records the allocated and free blocks, and the time spent waiting for a
+	// block.
+	metricHandle common.MetricHandle
 }
 
 // NewBlockPool creates the blockPool based on the user configuration.
-func NewBlockPool(blockSize int64, maxBlocks int64, globalMaxBlocksSem *semaphore.Weighted) (bp *BlockPool, err error) {
+// The metric handle may be nil.
+func NewBlockPool(blockSize int64, maxBlocks int64, globalMaxBlocksSem *semaphore.Weighted, metricHandle common.MetricHandle) (bp *BlockPool, err error) {
 	if blockSize <= 0 || maxBlocks <= 0 {
 		err = fmt.Errorf("invalid configuration provided for blockPool, blocksize: %d, maxBlocks: %d", blockSize, maxBlocks)
 		return
 	}
 
+	if metricHandle == nil {
+		metricHandle = common.NewNoopMetrics()
+	}
+
 	bp = &BlockPool{
 		freeBlocksCh:       make(chan Block, maxBlocks),
 		blockSize:          blockSize,
 		maxBlocks:          maxBlocks,
 		totalBlocks:        0,
 		globalMaxBlocksSem: globalMaxBlocksSem,
+		metricHandle:       metricHandle,
 	}
 	return
 }
@@ -59,9 +72,19 @@ func NewBlockPool(blockSize int64, maxBlocks int64, globalMaxBlocksSem *semaphor
 // Get returns a block. It returns an existing block if it's ready for reuse or
 // creates a new one if required.
 func (bp *BlockPool) Get() (Block, error) {
+	// Set once the block can neither be reused nor allocated, i.e. we have to
+	// wait for an upload to free one or for another file to release its blocks.
+	var waitStart time.Time
+	defer func() {
+		if !waitStart.IsZero() {
+			bp.metricHandle.BlockWaitLatency(context.Background(), float64(time.Since(waitStart).Microseconds()), nil)
+		}
+	}()
+
 	for {
 		select {
 		case b := <-bp.freeBlocksCh:
+			bp.metricHandle.FreeBlockCount(context.Background(), -1, nil)
 			// Reset the block for reuse.
 			b.Reuse()
 			return b, nil
@@ -76,8 +99,13 @@ func (bp *BlockPool) Get() (Block, error) {
 				}
 
 				bp.totalBlocks++
+				bp.metricHandle.AllocatedBlockCount(context.Background(), 1, nil)
 				return b, nil
 			}
+
+			if waitStart.IsZero() {
+				waitStart = time.Now()
+			}
 		}
 	}
 }
@@ -99,6 +127,13 @@ func (bp *BlockPool) canAllocateBlock() bool {
 	return semAcquired
 }
 
+// Release puts back a block that is no longer in use on the freeBlocksCh, for
+// Get to reuse.
+func (bp *BlockPool) Release(b Block) {
+	bp.metricHandle.FreeBlockCount(context.Background(), 1, nil)
+	bp.freeBlocksCh <- b
+}
+
 // FreeBlocksChannel returns the freeBlocksCh being used by the block pool.
 func (bp *BlockPool) FreeBlocksChannel() chan Block {
 	return bp.freeBlocksCh
@@ -119,6 +154,8 @@ func (bp *BlockPool) ClearFreeBlockChannel() error {
 				return fmt.Errorf("munmap error: %v", err)
 			}
 			bp.totalBlocks--
+			bp.metricHandle.FreeBlockCount(context.Background(), -1, nil)
+			bp.metricHandle.AllocatedBlockCount(context.Background(), -1, nil)
 			if bp.totalBlocks != 0 {
 				bp.globalMaxBlocksSem.Release(1)
 			}
@@ -15,11 +15,14 @@
 package block
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -37,7 +40,7 @@ func TestBlockPoolTestSuite(t *testing.T) {
 }
 
 func (t *BlockPoolTest) TestInitBlockPool() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10), nil)
 
 	require.Nil(t.T(), err)
 	require.NotNil(t.T(), bp)
@@ -47,28 +50,28 @@ func (t *BlockPoolTest) TestInitBlockPool() {
 }
 
 func (t *BlockPoolTest) TestInitBlockPoolForZeroBlockSize() {
-	_, err := NewBlockPool(0, 10, semaphore.NewWeighted(10))
+	_, err := NewBlockPool(0, 10, semaphore.NewWeighted(10), nil)
 
 	require.NotNil(t.T(), err)
 	assert.Equal(t.T(), fmt.Errorf(invalidConfigError, 0, 10), err)
 }
 
 func (t *BlockPoolTest) TestInitBlockPoolForNegativeBlockSize() {
-	_, err := NewBlockPool(-1, 10, semaphore.NewWeighted(10))
+	_, err := NewBlockPool(-1, 10, semaphore.NewWeighted(10), nil)
 
 	require.NotNil(t.T(), err)
 	assert.Equal(t.T(), fmt.Errorf(invalidConfigError, -1, 10), err)
 }
 
 func (t *BlockPoolTest) TestInitBlockPoolForZeroMaxBlocks() {
-	_, err := NewBlockPool(10, 0, semaphore.NewWeighted(10))
+	_, err := NewBlockPool(10, 0, semaphore.NewWeighted(10), nil)
 
 	require.NotNil(t.T(), err)
 	assert.Equal(t.T(), fmt.Errorf(invalidConfigError, 10, 0), err)
 }
 
 func (t *BlockPoolTest) TestInitBlockPoolForNegativeMaxBlocks() {
-	_, err := NewBlockPool(10, -1, semaphore.NewWeighted(10))
+	_, err := NewBlockPool(10, -1, semaphore.NewWeighted(10), nil)
 
 	require.NotNil(t.T(), err)
 	assert.Equal(t.T(), fmt.Errorf(invalidConfigError, 10, -1), err)
@@ -76,7 +79,7 @@ func (t *BlockPoolTest) TestInitBlockPoolForNegativeMaxBlocks() {
 
 // Represents when block is available on the freeBlocksCh.
 func (t *BlockPoolTest) TestGetWhenBlockIsAvailableForReuse() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10), nil)
 	require.Nil(t.T(), err)
 	// Creating a block with some data and send it to blockCh.
 	b, err := createBlock(2)
@@ -101,7 +104,7 @@ func (t *BlockPoolTest) TestGetWhenBlockIsAvailableForReuse() {
 }
 
 func (t *BlockPoolTest) TestGetWhenTotalBlocksIsLessThanThanMaxBlocks() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10), nil)
 	require.Nil(t.T(), err)
 
 	block, err := bp.Get()
@@ -113,7 +116,7 @@ func (t *BlockPoolTest) TestGetWhenTotalBlocksIsLessThanThanMaxBlocks() {
 
 func (t *BlockPoolTest) TestCreateBlockWithLargeSize() {
 	// Creating block of size 1TB
-	bp, err := NewBlockPool(1024*1024*1024*1024, 10, semaphore.NewWeighted(10))
+	bp, err := NewBlockPool(1024*1024*1024*1024, 10, semaphore.NewWeighted(10), nil)
 	require.Nil(t.T(), err)
 
 	_, err = bp.Get()
@@ -123,14 +126,14 @@ func (t *BlockPoolTest) TestCreateBlockWithLargeSize() {
 }
 
 func (t *BlockPoolTest) TestBlockSize() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(10), nil)
 
 	require.Nil(t.T(), err)
 	require.Equal(t.T(), int64(1024), bp.BlockSize())
 }
 
 func (t *BlockPoolTest) TestClearFreeBlockChannel() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(3))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(3), nil)
 	require.Nil(t.T(), err)
 	blocks := make([]Block, 4)
 	for i := 0; i < 4; i++ {
@@ -155,7 +158,7 @@ func (t *BlockPoolTest) TestClearFreeBlockChannel() {
 }
 
 func (t *BlockPoolTest) TestFirstBlockIsCreatedWithoutAcquiringGlobalSem() {
-	bp, err := NewBlockPool(1024, 3, semaphore.NewWeighted(0))
+	bp, err := NewBlockPool(1024, 3, semaphore.NewWeighted(0), nil)
 	require.Nil(t.T(), err)
 	b1, err := bp.Get()
 	require.Nil(t.T(), err)
@@ -173,9 +176,9 @@ func (t *BlockPoolTest) TestFirstBlockIsCreatedWithoutAcquiringGlobalSem() {
 
 func (t *BlockPoolTest) TestClearFreeBlockChannelWithMultipleBlockPools() {
 	globalMaxBlocksSem := semaphore.NewWeighted(1)
-	bp1, err := NewBlockPool(1024, 3, globalMaxBlocksSem)
+	bp1, err := NewBlockPool(1024, 3, globalMaxBlocksSem, nil)
 	require.Nil(t.T(), err)
-	bp2, err := NewBlockPool(1024, 3, globalMaxBlocksSem)
+	bp2, err := NewBlockPool(1024, 3, globalMaxBlocksSem, nil)
 	require.Nil(t.T(), err)
 	// Create 2 blocks in bp1.
 	b1 := t.validateGetBlockIsNotBlocked(bp1)
@@ -206,7 +209,7 @@ func (t *BlockPoolTest) TestClearFreeBlockChannelWithMultipleBlockPools() {
 }
 
 func (t *BlockPoolTest) TestGetWhenGlobalMaxBlocksIsZero() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(0))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(0), nil)
 	require.Nil(t.T(), err)
 
 	// First block is allowed even with globalMaxBlocks being zero.
@@ -218,7 +221,7 @@ func (t *BlockPoolTest) TestGetWhenGlobalMaxBlocksIsZero() {
 }
 
 func (t *BlockPoolTest) TestGetWhenLimitedByGlobalBlocks() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(2))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(2), nil)
 	require.Nil(t.T(), err)
 
 	// 3 blocks can be created.
@@ -231,7 +234,7 @@ func (t *BlockPoolTest) TestGetWhenLimitedByGlobalBlocks() {
 }
 
 func (t *BlockPoolTest) TestGetWhenTotalBlocksEqualToMaxBlocks() {
-	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(2))
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(2), nil)
 	require.Nil(t.T(), err)
 	bp.totalBlocks = 10
 
@@ -274,6 +277,63 @@ func (t *BlockPoolTest) validateGetBlockIsNotBlocked(bp *BlockPool) Block {
 	}
 }
 
+// fakeBlockMetrics sums up the recorded block pool metrics.
+type fakeBlockMetrics struct {
+	common.MetricHandle
+	mu                    sync.Mutex
+	allocated, free, wait int64
+}
+
+func (m *fakeBlockMetrics) AllocatedBlockCount(_ context.Context, inc int64, _ []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allocated += inc
+}
+
+func (m *fakeBlockMetrics) FreeBlockCount(_ context.Context, inc int64, _ []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.free += inc
+}
+
+func (m *fakeBlockMetrics) BlockWaitLatency(_ context.Context, _ float64, _ []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.wait++
+}
+
+func (t *BlockPoolTest) TestMetrics() {
+	m := &fakeBlockMetrics{MetricHandle: common.NewNoopMetrics()}
+	bp, err := NewBlockPool(1024, 10, semaphore.NewWeighted(1), m)
+	require.Nil(t.T(), err)
+	b1 := t.validateGetBlockIsNotBlocked(bp)
+	b2 := t.validateGetBlockIsNotBlocked(bp)
+	assert.Equal(t.T(), int64(2), m.allocated)
+	assert.Equal(t.T(), int64(0), m.wait)
+
+	// The global limit is reached, so the next Get waits for a block to be
+	// released.
+	done := make(chan Block, 1)
+	go func() {
+		b, err := bp.Get()
+		require.Nil(t.T(), err)
+		done <- b
+	}()
+	time.Sleep(10 * time.Millisecond)
+	bp.Release(b1)
+	<-done
+	assert.Equal(t.T(), int64(2), m.allocated)
+	assert.Equal(t.T(), int64(0), m.free)
+	assert.Equal(t.T(), int64(1), m.wait)
+
+	bp.Release(b2)
+	assert.Equal(t.T(), int64(1), m.free)
+	err = bp.ClearFreeBlockChannel()
+	require.Nil(t.T(), err)
+	assert.Equal(t.T(), int64(1), m.allocated)
+	assert.Equal(t.T(), int64(0), m.free)
+}
+
 func (t *BlockPoolTest) TestFreeBlocksChannel() {
 	freeBlocksCh := make(chan Block)
 	bp := &BlockPool{
@@ -20,9 +20,11 @@ import (
 	"math"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/block"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -67,11 +69,16 @@ type CreateBWHandlerRequest struct {
 	MaxBlocksPerFile         int64
 	GlobalMaxBlocksSem       *semaphore.Weighted
 	ChunkTransferTimeoutSecs int64
+	// Records the blocks and the bytes pending upload. May be nil.
+	MetricHandle common.MetricHandle
+	// Link to the span of the op which created the handler. The spans of the
+	// background upload are linked to it.
+	TraceLink trace.Link
 }
 
 // NewBWHandler creates the bufferedWriteHandler struct.
 func NewBWHandler(req *CreateBWHandlerRequest) (bwh *BufferedWriteHandler, err error) {
-	bp, err := block.NewBlockPool(req.BlockSize, req.MaxBlocksPerFile, req.GlobalMaxBlocksSem)
+	bp, err := block.NewBlockPool(req.BlockSize, req.MaxBlocksPerFile, req.GlobalMaxBlocksSem, req.MetricHandle)
 	if err != nil {
 		return
 	}
@@ -83,10 +90,12 @@ func NewBWHandler(req *CreateBWHandlerRequest) (bwh *BufferedWriteHandler, err e
 			Object:                   req.Object,
 			ObjectName:               req.ObjectName,
 			Bucket:                   req.Bucket,
-			FreeBlocksCh:             bp.FreeBlocksChannel(),
+			BlockPool:                bp,
 			MaxBlocksPerFile:         req.MaxBlocksPerFile,
 			BlockSize:                req.BlockSize,
 			ChunkTransferTimeoutSecs: req.ChunkTransferTimeoutSecs,
+			MetricHandle:             req.MetricHandle,
+			TraceLink:                req.TraceLink,
 		}),
 		totalSize:     0,
 		mtime:         time.Now(),
@@ -107,7 +116,7 @@ func (wh *BufferedWriteHandler) Write(data []byte, offset int64) (err error) {
 	}
 
 	if offset != wh.totalSize && offset != wh.truncatedSize {
-		logger.Errorf("BufferedWriteHandler.OutOfOrderError for object: %s, expectedOffset: %d, actualOffset: %d",
+		logger.Writes.Errorf("BufferedWriteHandler.OutOfOrderError for object: %s, expectedOffset: %d, actualOffset: %d",
 			wh.uploadHandler.objectName, wh.totalSize, offset)
 		return ErrOutOfOrderWrite
 	}
@@ -163,7 +172,7 @@ func (wh *BufferedWriteHandler) Sync() (err error) {
 	err = wh.blockPool.ClearFreeBlockChannel()
 	if err != nil {
 		// Only logging an error in case of resource leak as upload succeeded.
-		logger.Errorf("blockPool.ClearFreeBlockChannel() failed during sync: %v", err)
+		logger.Writes.Errorf("blockPool.ClearFreeBlockChannel() failed during sync: %v", err)
 	}
 
 	select {
@@ -198,7 +207,7 @@ func (wh *BufferedWriteHandler) Flush() (*gcs.MinObject, error) {
 	err = wh.blockPool.ClearFreeBlockChannel()
 	if err != nil {
 		// Only logging an error in case of resource leak as upload succeeded.
-		logger.Errorf("blockPool.ClearFreeBlockChannel() failed: %v", err)
+		logger.Writes.Errorf("blockPool.ClearFreeBlockChannel() failed: %v", err)
 	}
 
 	// Return an error along with object if the uploadHandler failed in between.
@@ -268,6 +277,6 @@ func (wh *BufferedWriteHandler) Unlink() {
 	err := wh.blockPool.ClearFreeBlockChannel()
 	if err != nil {
 		// Only logging an error in case of resource leak.
-		logger.Errorf("blockPool.ClearFreeBlockChannel() failed: %v", err)
+		logger.Writes.Errorf("blockPool.ClearFreeBlockChannel() failed: %v", err)
 	}
 }
@@ -20,9 +20,12 @@ import (
 	"io"
 	"sync"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/block"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // UploadHandler is responsible for synchronized uploads of the filled blocks
@@ -34,8 +37,8 @@ type UploadHandler struct {
 	// Wait group for waiting for the uploader goroutine to finish.
 	wg sync.WaitGroup
 
-	// Channel on which uploaded block will be posted for reuse.
-	freeBlocksCh chan block.Block
+	// Pool to which uploaded blocks are released for reuse.
+	blockPool *block.BlockPool
 
 	// writer to resumable upload the blocks to GCS.
 	writer gcs.Writer
@@ -53,30 +56,49 @@ type UploadHandler struct {
 	obj                  *gcs.Object
 	chunkTransferTimeout int64
 	blockSize            int64
+
+	// Records the bytes pending upload.
+	metricHandle common.MetricHandle
+
+	// traceLink links span, which covers the whole upload, to the op which
+	// created the handler. span is created along with the writer and has a
+	// child span for every block uploaded.
+	traceLink      trace.Link
+	span           trace.Span
+	blocksUploaded int64
 }
 
 type CreateUploadHandlerRequest struct {
 	Object                   *gcs.Object
 	ObjectName               string
 	Bucket                   gcs.Bucket
-	FreeBlocksCh             chan block.Block
+	BlockPool                *block.BlockPool
 	MaxBlocksPerFile         int64
 	BlockSize                int64
 	ChunkTransferTimeoutSecs int64
+	MetricHandle             common.MetricHandle
+	TraceLink                trace.Link
 }
 
 // newUploadHandler creates the UploadHandler struct.
 func newUploadHandler(req *CreateUploadHandlerRequest) *UploadHandler {
+	metricHandle := req.MetricHandle
+	if metricHandle == nil {
+		metricHandle = common.NewNoopMetrics()
+	}
+
 	uh := &UploadHandler{
 		uploadCh:             make(chan block.Block, req.MaxBlocksPerFile),
 		wg:                   sync.WaitGroup{},
-		freeBlocksCh:         req.FreeBlocksCh,
+		blockPool:            req.BlockPool,
 		bucket:               req.Bucket,
 		objectName:           req.ObjectName,
 		obj:                  req.Object,
 		blockSize:            req.BlockSize,
 		signalUploadFailure:  make(chan error, 1),
 		chunkTransferTimeout: req.ChunkTransferTimeoutSecs,
+		metricHandle:         metricHandle,
+		traceLink:            req.TraceLink,
 	}
 	return uh
 }
@@ -97,6 +119,7 @@ func (uh *UploadHandler) Upload(block block.Block) error {
 		go uh.uploader()
 	}
 
+	uh.metricHandle.PendingUploadBytes(context.Background(), block.Size(), nil)
 	uh.uploadCh <- block
 	return nil
 }
@@ -107,7 +130,8 @@ func (uh *UploadHandler) createObjectWriter() (err error) {
 	// We need a new context here, since the first writeFile() call will be complete
 	// (and context will be cancelled) by the time complete upload is done.
 	var ctx context.Context
-	ctx, uh.cancelFunc = context.WithCancel(context.Background())
+	ctx, uh.span = tracing.StartSpanLinkedTo(uh.traceLink, "bufferedwrites.Upload", tracing.ObjectKey.String(uh.objectName))
+	ctx, uh.cancelFunc = context.WithCancel(ctx)
 	uh.writer, err = uh.bucket.CreateObjectChunkWriter(ctx, req, int(uh.blockSize), nil)
 	return
 }
@@ -118,19 +142,38 @@ func (uh *UploadHandler) uploader() {
 		select {
 		case <-uh.signalUploadFailure:
 		default:
-			_, err := io.Copy(uh.writer, currBlock.Reader())
+			err := uh.uploadBlock(currBlock)
 			if err != nil {
-				logger.Errorf("buffered write upload failed for object %s: error in io.Copy: %v", uh.objectName, err)
+				logger.Writes.Errorf("buffered write upload failed for object %s: error in io.Copy: %v", uh.objectName, err)
 				// Close the channel to signal upload failure.
 				close(uh.signalUploadFailure)
 			}
 		}
-		// Put back the uploaded block on the freeBlocksChannel for re-use.
-		uh.freeBlocksCh <- currBlock
+		// Put back the uploaded block in the block pool for re-use.
+		uh.releaseBlock(currBlock)
 		uh.wg.Done()
 	}
 }
 
+// uploadBlock copies a block to the writer under a child span of the upload.
+func (uh *UploadHandler) uploadBlock(b block.Block) (err error) {
+	offset := uh.blocksUploaded * uh.blockSize
+	uh.blocksUploaded++
+	_, span := tracing.StartSpan(trace.ContextWithSpan(context.Background(), uh.span), "bufferedwrites.UploadBlock",
+		append(tracing.RangeAttrs(offset, b.Size()), tracing.BlockIndexKey.Int64(uh.blocksUploaded-1))...)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	_, err = io.Copy(uh.writer, b.Reader())
+	return
+}
+
+// endSpan ends the span of the upload, if it was started.
+func (uh *UploadHandler) endSpan(err error) {
+	if uh.span != nil {
+		tracing.EndSpan(uh.span, err)
+	}
+}
+
 // Finalize finalizes the upload.
 func (uh *UploadHandler) Finalize() (*gcs.MinObject, error) {
 	uh.wg.Wait()
@@ -145,7 +188,8 @@ func (uh *UploadHandler) Finalize() (*gcs.MinObject, error) {
 		}
 	}
 
-	obj, err := uh.bucket.FinalizeUpload(context.Background(), uh.writer)
+	obj, err := uh.bucket.FinalizeUpload(trace.ContextWithSpan(context.Background(), uh.span), uh.writer)
+	uh.endSpan(err)
 	if err != nil {
 		return nil, fmt.Errorf("FinalizeUpload failed for object %s: %w", uh.objectName, err)
 	}
@@ -159,6 +203,7 @@ func (uh *UploadHandler) CancelUpload() {
 	}
 	// Wait for all in progress buffers to be added to the free channel.
 	uh.wg.Wait()
+	uh.endSpan(context.Canceled)
 }
 
 func (uh *UploadHandler) SignalUploadFailure() chan error {
@@ -178,7 +223,7 @@ func (uh *UploadHandler) Destroy() {
 			if !ok {
 				return
 			}
-			uh.freeBlocksCh <- currBlock
+			uh.releaseBlock(currBlock)
 			// Marking as wg.Done to ensure any waiters are unblocked.
 			uh.wg.Done()
 		default:
@@ -188,3 +233,10 @@ func (uh *UploadHandler) Destroy() {
 		}
 	}
 }
+
+// releaseBlock puts back a block that is no longer pending upload in the
+// block pool for re-use.
+func (uh *UploadHandler) releaseBlock(b block.Block) {
+	uh.metricHandle.PendingUploadBytes(context.Background(), -b.Size(), nil)
+	uh.blockPool.Release(b)
+}
@@ -15,12 +15,15 @@
 package bufferedwrites
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/block"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	storagemock "github.com/googlecloudplatform/gcsfuse/v2/internal/storage/mock"
@@ -50,13 +53,13 @@ func TestUploadHandlerTestSuite(t *testing.T) {
 func (t *UploadHandlerTest) SetupTest() {
 	t.mockBucket = new(storagemock.TestifyMockBucket)
 	var err error
-	t.blockPool, err = block.NewBlockPool(blockSize, maxBlocks, semaphore.NewWeighted(maxBlocks))
+	t.blockPool, err = block.NewBlockPool(blockSize, maxBlocks, semaphore.NewWeighted(maxBlocks), nil)
 	require.NoError(t.T(), err)
 	t.uh = newUploadHandler(&CreateUploadHandlerRequest{
 		Object:                   nil,
 		ObjectName:               "testObject",
 		Bucket:                   t.mockBucket,
-		FreeBlocksCh:             t.blockPool.FreeBlocksChannel(),
+		BlockPool:                t.blockPool,
 		MaxBlocksPerFile:         maxBlocks,
 		BlockSize:                blockSize,
 		ChunkTransferTimeoutSecs: chunkTransferTimeoutSecs,
@@ -88,12 +91,63 @@ func (t *UploadHandlerTest) TestMultipleBlockUpload() {
 	assert.Equal(t.T(), mockObj, obj)
 	// The blocks should be available on the free channel for reuse.
 	for _, expect := range blocks {
-		got := <-t.uh.freeBlocksCh
+		got := <-t.blockPool.FreeBlocksChannel()
 		assert.Equal(t.T(), expect, got)
 	}
 	assertAllBlocksProcessed(t.T(), t.uh)
 }
 
+// fakeUploadMetrics sums up the recorded upload metrics.
+type fakeUploadMetrics struct {
+	common.MetricHandle
+	mu                       sync.Mutex
+	pending, uploaded, freed int64
+}
+
+func (m *fakeUploadMetrics) PendingUploadBytes(_ context.Context, inc int64, _ []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending += inc
+	if inc < 0 {
+		m.uploaded -= inc
+	}
+}
+
+func (m *fakeUploadMetrics) FreeBlockCount(_ context.Context, inc int64, _ []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.freed += inc
+}
+
+func (t *UploadHandlerTest) TestUploadMetrics() {
+	m := &fakeUploadMetrics{MetricHandle: common.NewNoopMetrics()}
+	t.uh.metricHandle = m
+	var err error
+	t.blockPool, err = block.NewBlockPool(blockSize, maxBlocks, semaphore.NewWeighted(maxBlocks), m)
+	require.NoError(t.T(), err)
+	t.uh.blockPool = t.blockPool
+	writer := &storagemock.Writer{}
+	writer.On("Write", mock.Anything).Return(4, nil)
+	t.mockBucket.On("CreateObjectChunkWriter", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(writer, nil)
+	t.mockBucket.On("FinalizeUpload", mock.Anything, writer).Return(&gcs.MinObject{}, nil)
+	blocks := t.createBlocks(2)
+	for _, b := range blocks {
+		err := b.Write([]byte("taco"))
+		require.NoError(t.T(), err)
+		err = t.uh.Upload(b)
+		require.NoError(t.T(), err)
+	}
+
+	_, err = t.uh.Finalize()
+
+	require.NoError(t.T(), err)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t.T(), int64(0), m.pending)
+	assert.Equal(t.T(), int64(8), m.uploaded)
+	assert.Equal(t.T(), int64(2), m.freed)
+}
+
 func (t *UploadHandlerTest) TestUploadWhenCreateObjectWriterFails() {
 	// Create a block.
 	b, err := t.blockPool.Get()
@@ -182,7 +236,7 @@ func (t *UploadHandlerTest) TestUploadSingleBlockThrowsErrorInCopy() {
 	// Expect an error on the signalUploadFailure channel due to error while copying content to GCS writer.
 	assertUploadFailureSignal(t.T(), t.uh)
 	assertAllBlocksProcessed(t.T(), t.uh)
-	assert.Equal(t.T(), 1, len(t.uh.freeBlocksCh))
+	assert.Equal(t.T(), 1, len(t.blockPool.FreeBlocksChannel()))
 }
 
 func (t *UploadHandlerTest) TestUploadMultipleBlocksThrowsErrorInCopy() {
@@ -208,7 +262,7 @@ func (t *UploadHandlerTest) TestUploadMultipleBlocksThrowsErrorInCopy() {
 
 	assertUploadFailureSignal(t.T(), t.uh)
 	assertAllBlocksProcessed(t.T(), t.uh)
-	assert.Equal(t.T(), 4, len(t.uh.freeBlocksCh))
+	assert.Equal(t.T(), 4, len(t.blockPool.FreeBlocksChannel()))
 }
 
 func assertUploadFailureSignal(t *testing.T, handler *UploadHandler) {
@@ -262,7 +316,7 @@ func (t *UploadHandlerTest) TestMultipleBlockAwaitBlocksUpload() {
 	// AwaitBlocksUpload.
 	t.uh.AwaitBlocksUpload()
 
-	assert.Equal(t.T(), 5, len(t.uh.freeBlocksCh))
+	assert.Equal(t.T(), 5, len(t.blockPool.FreeBlocksChannel()))
 	assert.Equal(t.T(), 0, len(t.uh.uploadCh))
 	assertAllBlocksProcessed(t.T(), t.uh)
 }
@@ -367,7 +421,7 @@ func (t *UploadHandlerTest) TestDestroy() {
 			t.uh.Destroy()
 
 			assertAllBlocksProcessed(t.T(), t.uh)
-			assert.Equal(t.T(), 5, len(t.uh.freeBlocksCh))
+			assert.Equal(t.T(), 5, len(t.blockPool.FreeBlocksChannel()))
 			assert.Equal(t.T(), 0, len(t.uh.uploadCh))
 			// Check if uploadCh is closed.
 			select {
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"os"
 	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 )
 
 const InvalidKeyAttributes = "key attributes not initialised"
@@ -39,8 +41,14 @@ func GetFileInfoKeyName(objectName string, bucketCreationTime time.Time, bucketN
 	if bucketName == "" || objectName == "" {
 		return "", errors.New(InvalidKeyAttributes)
 	}
+	return GetFileInfoKeyPrefix(objectName, bucketCreationTime, bucketName), nil
+}
+
+// GetFileInfoKeyPrefix returns the prefix of the keys of the objects in the
+// given bucket whose names start with objectPrefix.
+func GetFileInfoKeyPrefix(objectPrefix string, bucketCreationTime time.Time, bucketName string) string {
 	unixTimeString := fmt.Sprintf("%d", bucketCreationTime.Unix())
-	return bucketName + unixTimeString + objectName, nil
+	return bucketName + unixTimeString + objectPrefix
 }
 
 type FileInfo struct {
@@ -58,4 +66,7 @@ type FileSpec struct {
 	Path     string
 	FilePerm os.FileMode
 	DirPerm  os.FileMode
+
+	// If set, the contents of the file are encrypted with it.
+	Cipher *diskcrypt.Cipher
 }
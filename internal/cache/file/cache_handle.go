@@ -19,18 +19,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file/downloader"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 )
 
 type CacheHandle struct {
 	// fileHandle to a local file which contains locally downloaded data.
-	fileHandle *os.File
+	fileHandle diskcrypt.File
 
 	// fileDownloadJob is a reference to async download Job. It can be nil if
 	// job is already completed.
@@ -52,7 +52,7 @@ type CacheHandle struct {
 	prevOffset int64
 }
 
-func NewCacheHandle(localFileHandle *os.File, fileDownloadJob *downloader.Job,
+func NewCacheHandle(localFileHandle diskcrypt.File, fileDownloadJob *downloader.Job,
 	fileInfoCache *lru.Cache, cacheFileForRangeRead bool, initialOffset int64) *CacheHandle {
 	return &CacheHandle{
 		fileHandle:            localFileHandle,
@@ -261,6 +261,39 @@ func (fch *CacheHandle) IsSequential(currentOffset int64) bool {
 	return true
 }
 
+// Prefetch starts downloading the object into the cache file, if it isn't
+// already, and waits until it has been downloaded up to the given offset. It
+// returns an error if the download failed or was invalidated.
+func (fch *CacheHandle) Prefetch(ctx context.Context, offset int64) error {
+	if fch.fileDownloadJob == nil {
+		return nil
+	}
+
+	jobStatus, err := fch.fileDownloadJob.Download(ctx, offset, true)
+	if err != nil {
+		return fmt.Errorf("prefetch: while downloading through job: %w", err)
+	}
+
+	return fch.shouldReadFromCache(&jobStatus, offset)
+}
+
+// WaitForCompletion starts downloading the object into the cache file, if it
+// isn't already, and waits until the whole object has been downloaded and its
+// contents validated. It returns an error, wrapping the job's, if the download
+// or validation failed or was invalidated.
+func (fch *CacheHandle) WaitForCompletion(ctx context.Context) error {
+	if fch.fileDownloadJob == nil {
+		return nil
+	}
+
+	jobStatus, err := fch.fileDownloadJob.WaitForCompletion(ctx)
+	if err != nil {
+		return fmt.Errorf("WaitForCompletion: while downloading through job: %w", err)
+	}
+
+	return fch.shouldReadFromCache(&jobStatus, 0)
+}
+
 // Close closes the underlying fileHandle pointing to locally downloaded cache file.
 func (fch *CacheHandle) Close() (err error) {
 	if fch.fileHandle != nil {
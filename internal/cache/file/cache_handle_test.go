@@ -157,6 +157,7 @@ func (cht *cacheHandleTest) SetupTest() {
 		fileCacheConfig,
 		semaphore.NewWeighted(math.MaxInt64),
 		common.NewNoopMetrics(),
+		nil,
 	)
 
 	cht.cacheHandle = NewCacheHandle(readLocalFileHandle, fileDownloadJob, cht.cache, false, 0)
@@ -217,6 +218,30 @@ func (cht *cacheHandleTest) Test_Close_WithNilFileHandle() {
 	assert.Nil(cht.T(), cht.cacheHandle.fileHandle)
 }
 
+func (cht *cacheHandleTest) Test_Prefetch_DownloadsUpToOffset() {
+	err := cht.cacheHandle.Prefetch(context.Background(), int64(cht.object.Size))
+
+	assert.NoError(cht.T(), err)
+	jobStatus := cht.cacheHandle.fileDownloadJob.GetStatus()
+	assert.Equal(cht.T(), int64(cht.object.Size), jobStatus.Offset)
+}
+
+func (cht *cacheHandleTest) Test_Prefetch_WithNilFileDownloadJob() {
+	cht.cacheHandle.fileDownloadJob = nil
+
+	err := cht.cacheHandle.Prefetch(context.Background(), int64(cht.object.Size))
+
+	assert.NoError(cht.T(), err)
+}
+
+func (cht *cacheHandleTest) Test_Prefetch_WithInvalidatedJob() {
+	cht.cacheHandle.fileDownloadJob.Invalidate()
+
+	err := cht.cacheHandle.Prefetch(context.Background(), int64(cht.object.Size))
+
+	assert.ErrorContains(cht.T(), err, util.InvalidFileDownloadJobErrMsg)
+}
+
 func (cht *cacheHandleTest) Test_IsSequential_WhenReadTypeIsNotSequential() {
 	cht.cacheHandle.isSequential = false
 	currentOffset := int64(3)
@@ -865,6 +890,7 @@ func (cht *cacheHandleTest) Test_SequentialRead_Parallel_Download_True() {
 		fileCacheConfig,
 		semaphore.NewWeighted(math.MaxInt64),
 		common.NewNoopMetrics(),
+		nil,
 	)
 	cht.cacheHandle.fileDownloadJob = fileDownloadJob
 
@@ -900,6 +926,7 @@ func (cht *cacheHandleTest) Test_RandomRead_Parallel_Download_True() {
 		fileCacheConfig,
 		semaphore.NewWeighted(math.MaxInt64),
 		common.NewNoopMetrics(),
+		nil,
 	)
 	cht.cacheHandle.fileDownloadJob = fileDownloadJob
 
@@ -935,6 +962,7 @@ func (cht *cacheHandleTest) Test_RandomRead_CacheForRangeReadFalse_And_ParallelD
 		fileCacheConfig,
 		semaphore.NewWeighted(math.MaxInt64),
 		common.NewNoopMetrics(),
+		nil,
 	)
 
 	// Since, it's a random read, download job will not start.
@@ -15,13 +15,16 @@
 package file
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file/downloader"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
@@ -49,29 +52,46 @@ type CacheHandler struct {
 	// dirPerm parameter specifies the permission of cache directory.
 	dirPerm os.FileMode
 
+	// cipher, if set, encrypts the files in cache. They are removed on Destroy,
+	// since they can't be read without it.
+	cipher *diskcrypt.Cipher
+
 	// mu guards the handling of insertion into and eviction from file cache.
 	mu locker.Locker
 }
 
-func NewCacheHandler(fileInfoCache *lru.Cache, jobManager *downloader.JobManager, cacheDir string, filePerm os.FileMode, dirPerm os.FileMode) *CacheHandler {
+func NewCacheHandler(fileInfoCache *lru.Cache, jobManager *downloader.JobManager, cacheDir string, filePerm os.FileMode, dirPerm os.FileMode, cipher *diskcrypt.Cipher) *CacheHandler {
 	return &CacheHandler{
 		fileInfoCache: fileInfoCache,
 		jobManager:    jobManager,
 		cacheDir:      cacheDir,
 		filePerm:      filePerm,
 		dirPerm:       dirPerm,
+		cipher:        cipher,
 		mu:            locker.New("FileCacheHandler", func() {}),
 	}
 }
 
-func (chr *CacheHandler) createLocalFileReadHandle(objectName string, bucketName string) (*os.File, error) {
+func (chr *CacheHandler) createLocalFileReadHandle(objectName string, bucketName string) (diskcrypt.File, error) {
 	fileSpec := data.FileSpec{
 		Path:     util.GetDownloadPath(chr.cacheDir, util.GetObjectPath(bucketName, objectName)),
 		FilePerm: chr.filePerm,
 		DirPerm:  chr.dirPerm,
+		Cipher:   chr.cipher,
 	}
 
-	return util.CreateFile(fileSpec, os.O_RDONLY)
+	f, err := util.CreateFile(fileSpec, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := chr.cipher.File(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return file, nil
 }
 
 // cleanUpEvictedFile is a utility method called for the evicted/deleted fileInfo.
@@ -87,10 +107,11 @@ func (chr *CacheHandler) cleanUpEvictedFile(fileInfo *data.FileInfo) error {
 	chr.jobManager.InvalidateAndRemoveJob(key.ObjectName, key.BucketName)
 
 	localFilePath := util.GetDownloadPath(chr.cacheDir, util.GetObjectPath(key.BucketName, key.ObjectName))
+	chr.cipher.Remove(localFilePath)
 	err = util.TruncateAndRemoveFile(localFilePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			logger.Warnf("cleanUpEvictedFile: file was not present at the time of clean up: %v", err)
+			logger.FileCache.Warnf("cleanUpEvictedFile: file was not present at the time of clean up: %v", err)
 			return nil
 		}
 		return fmt.Errorf("cleanUpEvictedFile: error while cleaning up file: %s, error: %w", localFilePath, err)
@@ -251,6 +272,53 @@ func (chr *CacheHandler) InvalidateCache(objectName string, bucketName string) e
 	chr.mu.Lock()
 	defer chr.mu.Unlock()
 
+	return chr.eraseEntry(fileInfoKeyName)
+}
+
+// InvalidateCacheWithPrefix is like InvalidateCache, for every object in the
+// bucket whose name starts with the given prefix.
+//
+// Acquires and releases LOCK(CacheHandler.mu)
+func (chr *CacheHandler) InvalidateCacheWithPrefix(prefix string, bucketName string) error {
+	chr.mu.Lock()
+	defer chr.mu.Unlock()
+
+	var err error
+	for _, key := range chr.fileInfoCache.KeysWithPrefix(data.GetFileInfoKeyPrefix(prefix, time.Time{}, bucketName)) {
+		err = errors.Join(err, chr.eraseEntry(key))
+	}
+	return err
+}
+
+// Usage returns the total size of the files in the cache, and the maximum it
+// may reach.
+func (chr *CacheHandler) Usage() (used uint64, capacity uint64) {
+	return chr.fileInfoCache.Size()
+}
+
+// SetCapacity changes the maximum total size of the files in the cache,
+// evicting files until they fit.
+//
+// Acquires and releases LOCK(CacheHandler.mu)
+func (chr *CacheHandler) SetCapacity(capacity uint64) error {
+	chr.mu.Lock()
+	defer chr.mu.Unlock()
+
+	var err error
+	for _, val := range chr.fileInfoCache.SetMaxSize(capacity) {
+		fileInfo := val.(data.FileInfo)
+		if cleanUpErr := chr.cleanUpEvictedFile(&fileInfo); cleanUpErr != nil {
+			err = errors.Join(err, fmt.Errorf("SetCapacity: while performing post eviction of %s object error: %w", fileInfo.Key.ObjectName, cleanUpErr))
+		}
+	}
+	return err
+}
+
+// Erase the entry with the given key from the fileInfoCache, and clean up
+// its file.
+//
+// Requires Lock(chr.mu)
+func (chr *CacheHandler) eraseEntry(fileInfoKeyName string) error {
 	erasedVal := chr.fileInfoCache.Erase(fileInfoKeyName)
 	if erasedVal != nil {
 		fileInfo := erasedVal.(data.FileInfo)
@@ -262,7 +330,9 @@ func (chr *CacheHandler) InvalidateCache(objectName string, bucketName string) e
 	return nil
 }
 
-// Destroy destroys the job manager (i.e. invalidate all the jobs).
+// Destroy destroys the job manager (i.e. invalidate all the jobs). If the files
+// in cache are encrypted, they are also truncated and removed, since the key
+// goes away with the process.
 // Note: This method is expected to be called at the time of unmounting and
 // because file info cache is in-memory, it is not required to destroy it.
 //
@@ -272,5 +342,12 @@ func (chr *CacheHandler) Destroy() (err error) {
 	defer chr.mu.Unlock()
 
 	chr.jobManager.Destroy()
+
+	if chr.cipher != nil {
+		err = util.TruncateAndRemoveFilesUnder(chr.cacheDir)
+		if err != nil {
+			err = fmt.Errorf("Destroy: while removing encrypted cache files: %w", err)
+		}
+	}
 	return
 }
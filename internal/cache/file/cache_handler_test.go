@@ -31,6 +31,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file/downloader"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
@@ -84,10 +85,10 @@ func initializeCacheHandlerTestArgs(t *testing.T, fileCacheConfig *cfg.FileCache
 
 	// Job manager
 	jobManager := downloader.NewJobManager(cache, util.DefaultFilePerm,
-		util.DefaultDirPerm, cacheDir, DefaultSequentialReadSizeMb, fileCacheConfig, common.NewNoopMetrics())
+		util.DefaultDirPerm, cacheDir, DefaultSequentialReadSizeMb, fileCacheConfig, common.NewNoopMetrics(), nil, nil)
 
 	// Mocked cached handler object.
-	cacheHandler := NewCacheHandler(cache, jobManager, cacheDir, util.DefaultFilePerm, util.DefaultDirPerm)
+	cacheHandler := NewCacheHandler(cache, jobManager, cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, nil)
 
 	// Follow consistency, local-cache file, entry in fileInfo cache and job should exist initially.
 	fileInfoKeyName := addTestFileInfoEntryInCache(t, cache, object, storage.TestBucketName)
@@ -712,6 +713,48 @@ func Test_InvalidateCache_WhenEntryNotInCache(t *testing.T) {
 	assert.Nil(t, chTestArgs.jobManager.GetJob(minObject.Name, chTestArgs.bucket.Name()))
 }
 
+func Test_InvalidateCacheWithPrefix(t *testing.T) {
+	cacheDir := path.Join(os.Getenv("HOME"), "CacheHandlerTest/dir")
+	chTestArgs := initializeCacheHandlerTestArgs(t, &cfg.FileCacheConfig{EnableCrc: true}, cacheDir)
+	inDir := createObject(t, chTestArgs.bucket, "dir/object_1", []byte("1"))
+	outsideDir := createObject(t, chTestArgs.bucket, "other/object_2", []byte("2"))
+	addTestFileInfoEntryInCache(t, chTestArgs.cache, inDir, chTestArgs.bucket.Name())
+	addTestFileInfoEntryInCache(t, chTestArgs.cache, outsideDir, chTestArgs.bucket.Name())
+
+	err := chTestArgs.cacheHandler.InvalidateCacheWithPrefix("dir/", chTestArgs.bucket.Name())
+
+	assert.NoError(t, err)
+	assert.False(t, isEntryInFileInfoCache(t, chTestArgs.cache, inDir.Name, chTestArgs.bucket.Name()))
+	assert.True(t, isEntryInFileInfoCache(t, chTestArgs.cache, outsideDir.Name, chTestArgs.bucket.Name()))
+	assert.True(t, isEntryInFileInfoCache(t, chTestArgs.cache, chTestArgs.object.Name, chTestArgs.bucket.Name()))
+}
+
+func Test_Usage(t *testing.T) {
+	cacheDir := path.Join(os.Getenv("HOME"), "CacheHandlerTest/dir")
+	chTestArgs := initializeCacheHandlerTestArgs(t, &cfg.FileCacheConfig{EnableCrc: true}, cacheDir)
+
+	used, capacity := chTestArgs.cacheHandler.Usage()
+
+	assert.Equal(t, chTestArgs.object.Size, used)
+	assert.Equal(t, uint64(HandlerCacheMaxSize), capacity)
+}
+
+func Test_SetCapacity(t *testing.T) {
+	cacheDir := path.Join(os.Getenv("HOME"), "CacheHandlerTest/dir")
+	chTestArgs := initializeCacheHandlerTestArgs(t, &cfg.FileCacheConfig{EnableCrc: true}, cacheDir)
+	newest := createObject(t, chTestArgs.bucket, "object_1", []byte("1"))
+	addTestFileInfoEntryInCache(t, chTestArgs.cache, newest, chTestArgs.bucket.Name())
+
+	err := chTestArgs.cacheHandler.SetCapacity(1)
+
+	assert.NoError(t, err)
+	assert.True(t, isEntryInFileInfoCache(t, chTestArgs.cache, newest.Name, chTestArgs.bucket.Name()))
+	assert.False(t, isEntryInFileInfoCache(t, chTestArgs.cache, chTestArgs.object.Name, chTestArgs.bucket.Name()))
+	used, capacity := chTestArgs.cacheHandler.Usage()
+	assert.Equal(t, uint64(1), used)
+	assert.Equal(t, uint64(1), capacity)
+}
+
 func Test_InvalidateCache_Truncates(t *testing.T) {
 	tbl := []struct {
 		name                         string
@@ -1047,3 +1090,40 @@ func Test_Destroy(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetCacheHandle_Encrypted(t *testing.T) {
+	fileCacheConfig := &cfg.FileCacheConfig{EnableCrc: true}
+	chTestArgs := initializeCacheHandlerTestArgs(t, fileCacheConfig, path.Join(os.Getenv("HOME"), "CacheHandlerTest/dir"))
+	cipher, err := diskcrypt.NewCipher()
+	require.NoError(t, err)
+	jobManager := downloader.NewJobManager(chTestArgs.cache, util.DefaultFilePerm, util.DefaultDirPerm,
+		chTestArgs.cacheDir, DefaultSequentialReadSizeMb, fileCacheConfig, common.NewNoopMetrics(), cipher, nil)
+	cacheHandler := NewCacheHandler(chTestArgs.cache, jobManager, chTestArgs.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, cipher)
+	content := []byte("content of the encrypted object")
+	minObject := createObject(t, chTestArgs.bucket, "encrypted_object", content)
+	cacheHandle, err := cacheHandler.GetCacheHandle(minObject, chTestArgs.bucket, true, 0)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(content))
+	n, cacheHit, err := cacheHandle.Read(context.Background(), chTestArgs.bucket, minObject, 0, buf)
+
+	require.NoError(t, err)
+	assert.False(t, cacheHit)
+	assert.Equal(t, content, buf[:n])
+	// The data in the cache file is encrypted.
+	downloadPath := util.GetDownloadPath(chTestArgs.cacheDir, util.GetObjectPath(chTestArgs.bucket.Name(), minObject.Name))
+	onDisk, err := os.ReadFile(downloadPath)
+	require.NoError(t, err)
+	assert.Len(t, onDisk, len(content))
+	assert.NotEqual(t, content, onDisk)
+	// Reading again is served from the cache.
+	n, cacheHit, err = cacheHandle.Read(context.Background(), chTestArgs.bucket, minObject, 8, buf[:3])
+	require.NoError(t, err)
+	assert.True(t, cacheHit)
+	assert.Equal(t, content[8:11], buf[:n])
+	require.NoError(t, cacheHandle.Close())
+	// Destroy removes the encrypted files.
+	require.NoError(t, cacheHandler.Destroy())
+	_, err = os.Stat(downloadPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
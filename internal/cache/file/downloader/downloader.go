@@ -23,6 +23,8 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"golang.org/x/sync/semaphore"
@@ -49,6 +51,11 @@ type JobManager struct {
 	sequentialReadSizeMb int32
 	fileInfoCache        *lru.Cache
 	fileCacheConfig      *cfg.FileCacheConfig
+	// cipher, if set, encrypts the files in cache created by Job.
+	cipher *diskcrypt.Cipher
+	// verifier, if set, checks the contents downloaded by Job against the
+	// integrity manifest.
+	verifier *integrity.Verifier
 
 	/////////////////////////
 	// Mutable state
@@ -66,7 +73,7 @@ type JobManager struct {
 
 func NewJobManager(fileInfoCache *lru.Cache, filePerm os.FileMode, dirPerm os.FileMode,
 	cacheDir string, sequentialReadSizeMb int32, c *cfg.FileCacheConfig,
-	metricHandle common.MetricHandle) (jm *JobManager) {
+	metricHandle common.MetricHandle, cipher *diskcrypt.Cipher, verifier *integrity.Verifier) (jm *JobManager) {
 	maxParallelDownloads := int64(math.MaxInt64)
 	if c.MaxParallelDownloads > 0 {
 		maxParallelDownloads = c.MaxParallelDownloads
@@ -81,6 +88,8 @@ func NewJobManager(fileInfoCache *lru.Cache, filePerm os.FileMode, dirPerm os.Fi
 		// Shared between jobs - Limits the overall concurrency of downloads.
 		maxParallelismSem: semaphore.NewWeighted(maxParallelDownloads),
 		metricHandle:      metricHandle,
+		cipher:            cipher,
+		verifier:          verifier,
 	}
 	jm.mu = locker.New("JobManager", func() {})
 	jm.jobs = make(map[string]*Job)
@@ -112,13 +121,13 @@ func (jm *JobManager) CreateJobIfNotExists(object *gcs.MinObject, bucket gcs.Buc
 		return job
 	}
 	downloadPath := util.GetDownloadPath(jm.cacheDir, objectPath)
-	fileSpec := data.FileSpec{Path: downloadPath, FilePerm: jm.filePerm, DirPerm: jm.dirPerm}
+	fileSpec := data.FileSpec{Path: downloadPath, FilePerm: jm.filePerm, DirPerm: jm.dirPerm, Cipher: jm.cipher}
 	// Pass call back function to Job. When this callback function is called, it
 	// removes the job reference from jobs map.
 	removeJobCallback := func() {
 		jm.removeJob(object.Name, bucket.Name())
 	}
-	job = NewJob(object, bucket, jm.fileInfoCache, jm.sequentialReadSizeMb, fileSpec, removeJobCallback, jm.fileCacheConfig, jm.maxParallelismSem, jm.metricHandle)
+	job = NewJob(object, bucket, jm.fileInfoCache, jm.sequentialReadSizeMb, fileSpec, removeJobCallback, jm.fileCacheConfig, jm.maxParallelismSem, jm.metricHandle, jm.verifier)
 	jm.jobs[objectPath] = job
 	return job
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"reflect"
 	"strings"
@@ -30,10 +31,15 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	cacheutil "github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/semaphore"
 )
@@ -63,6 +69,7 @@ type Job struct {
 	sequentialReadSizeMb int32
 	fileSpec             data.FileSpec
 	fileCacheConfig      *cfg.FileCacheConfig
+	verifier             *integrity.Verifier
 
 	/////////////////////////
 	// Mutable state
@@ -82,6 +89,14 @@ type Job struct {
 	cancelCtx  context.Context
 	cancelFunc context.CancelFunc
 
+	// span covers the async download. It is linked to the op which started the
+	// download and is non-nil only while the async job is running.
+	span trace.Span
+
+	// timelineSpan covers the async download on the timeline, and is non-nil
+	// only while the async job is running and the timeline is recorded.
+	timelineSpan *timeline.Span
+
 	// doneCh for waiting for cancellation of async download in progress.
 	doneCh chan struct{}
 
@@ -125,6 +140,7 @@ func NewJob(
 	fileCacheConfig *cfg.FileCacheConfig,
 	maxParallelismSem *semaphore.Weighted,
 	metricHandle common.MetricHandle,
+	verifier *integrity.Verifier,
 ) (job *Job) {
 	job = &Job{
 		object:               object,
@@ -136,6 +152,7 @@ func NewJob(
 		fileCacheConfig:      fileCacheConfig,
 		maxParallelismSem:    maxParallelismSem,
 		metricsHandle:        metricHandle,
+		verifier:             verifier,
 	}
 	job.mu = locker.New("Job-"+fileSpec.Path, job.checkInvariants)
 	job.init()
@@ -205,7 +222,7 @@ func (job *Job) Invalidate() {
 	}
 	defer job.mu.Unlock()
 	job.status.Name = Invalid
-	logger.Tracef("Job:%p (%s:/%s) is no longer valid.", job, job.bucket.Name(), job.object.Name)
+	logger.FileCache.Tracef("Job:%p (%s:/%s) is no longer valid.", job, job.bucket.Name(), job.object.Name)
 	if job.removeJobCallback != nil {
 		job.removeJobCallback()
 		job.removeJobCallback = nil
@@ -225,7 +242,8 @@ func (job *Job) subscribe(subscribedOffset int64) (notificationC <-chan JobStatu
 }
 
 // notifySubscribers notifies all the subscribers of download job in case of
-// failure or invalidation or completion till the subscribed offset.
+// failure or invalidation or completion, or once it has downloaded till the
+// subscribed offset.
 //
 // Not concurrency safe and requires LOCK(job.mu)
 func (job *Job) notifySubscribers() {
@@ -233,7 +251,7 @@ func (job *Job) notifySubscribers() {
 	for subItr := job.subscribers.Front(); subItr != nil; subItr = nextSubItr {
 		subItrValue := subItr.Value.(jobSubscriber)
 		nextSubItr = subItr.Next()
-		if job.status.Name == Failed || job.status.Name == Invalid || job.status.Offset >= subItrValue.subscribedOffset {
+		if job.status.Name == Failed || job.status.Name == Invalid || job.status.Name == Completed || job.status.Offset >= subItrValue.subscribedOffset {
 			subItrValue.notificationC <- job.status
 			close(subItrValue.notificationC)
 			job.subscribers.Remove(subItr)
@@ -247,9 +265,9 @@ func (job *Job) notifySubscribers() {
 // Acquires and releases Lock(job.mu)
 func (job *Job) updateStatusAndNotifySubscribers(statusName jobStatusName, statusErr error) {
 	if statusName == Failed {
-		logger.Errorf("Job:%p (%s:/%s) Failed with error: %v", job, job.bucket.Name(), job.object.Name, statusErr)
+		logger.FileCache.Errorf("Job:%p (%s:/%s) Failed with error: %v", job, job.bucket.Name(), job.object.Name, statusErr)
 	} else {
-		logger.Tracef("Job:%p (%s:/%s) status changed to %v with error: %v", job, job.bucket.Name(), job.object.Name, statusName, statusErr)
+		logger.FileCache.Tracef("Job:%p (%s:/%s) status changed to %v with error: %v", job, job.bucket.Name(), job.object.Name, statusName, statusErr)
 	}
 	job.mu.Lock()
 	job.status.Err = statusErr
@@ -283,7 +301,7 @@ func (job *Job) updateStatusOffset(downloadedOffset int64) (err error) {
 	if err == nil {
 		job.status.Offset = downloadedOffset
 		// Notify subscribers if file cache is updated.
-		logger.Tracef("Job:%p (%s:/%s) downloaded till %v offset.", job, job.bucket.Name(), job.object.Name, job.status.Offset)
+		logger.FileCache.Tracef("Job:%p (%s:/%s) downloaded till %v offset.", job, job.bucket.Name(), job.object.Name, job.status.Offset)
 		job.notifySubscribers()
 		return err
 	}
@@ -295,7 +313,7 @@ func (job *Job) updateStatusOffset(downloadedOffset int64) (err error) {
 // downloadObjectToFile downloads the backing object from GCS into the given
 // file and updates the file info cache. It uses gcs.Bucket's NewReader method
 // to download the object.
-func (job *Job) downloadObjectToFile(cacheFile *os.File) (err error) {
+func (job *Job) downloadObjectToFile(cacheFile diskcrypt.File) (err error) {
 	var newReader gcs.StorageReader
 	var readHandle []byte
 	var start, end, sequentialReadSize, newReaderLimit int64
@@ -348,7 +366,7 @@ func (job *Job) downloadObjectToFile(cacheFile *os.File) (err error) {
 			// foreground reads: https://github.com/GoogleCloudPlatform/gcsfuse/blob/master/internal/gcsx/random_reader.go#L298.
 			err = newReader.Close()
 			if err != nil {
-				logger.Warnf("Job:%p (%s:/%s) error while closing reader: %v", job, job.bucket.Name(), job.object.Name, err)
+				logger.FileCache.Warnf("Job:%p (%s:/%s) error while closing reader: %v", job, job.bucket.Name(), job.object.Name, err)
 			}
 			newReader = nil
 		}
@@ -379,29 +397,54 @@ func (job *Job) cleanUpDownloadAsyncJob() {
 		job.removeJobCallback = nil
 	}
 	job.cancelCtx, job.cancelFunc = nil, nil
+	failed := job.status.Name == Failed
+	span, timelineSpan, status := job.span, job.timelineSpan, job.status
+	job.span, job.timelineSpan = nil, nil
 	job.mu.Unlock()
+
+	timelineSpan.End(status.Err)
+
+	if span != nil {
+		span.SetAttributes(tracing.LengthKey.Int64(status.Offset))
+		tracing.EndSpan(span, status.Err)
+	}
+
+	job.metricsHandle.DownloadJobCount(context.Background(), -1, nil)
+	if failed {
+		job.metricsHandle.DownloadJobFailureCount(context.Background(), 1, nil)
+	}
 }
 
 // createCacheFile is a helper function which creates file in cache using
-// appropriate open file flags.
-func (job *Job) createCacheFile() (*os.File, error) {
+// appropriate open file flags, encrypting it if the file spec has a cipher.
+func (job *Job) createCacheFile() (diskcrypt.File, error) {
 	// Create, open and truncate cache file for writing object into it.
 	openFileFlags := os.O_TRUNC | os.O_WRONLY
 	var cacheFile *os.File
 	var err error
 	// Try using O_DIRECT while opening file when parallel downloads are enabled
-	// and O_DIRECT use is not disabled.
-	if job.fileCacheConfig.EnableParallelDownloads && job.fileCacheConfig.EnableODirect {
+	// and O_DIRECT use is not disabled. Not with encryption, which writes from
+	// its own buffers that aren't aligned.
+	if job.fileCacheConfig.EnableParallelDownloads && job.fileCacheConfig.EnableODirect && job.fileSpec.Cipher == nil {
 		cacheFile, err = cacheutil.CreateFile(job.fileSpec, openFileFlags|syscall.O_DIRECT)
 		if errors.Is(err, fs.ErrInvalid) || errors.Is(err, syscall.EINVAL) {
-			logger.Warnf("downloadObjectAsync: failure in opening file with O_DIRECT, falling back to without O_DIRECT")
+			logger.FileCache.Warnf("downloadObjectAsync: failure in opening file with O_DIRECT, falling back to without O_DIRECT")
 			cacheFile, err = cacheutil.CreateFile(job.fileSpec, openFileFlags)
 		}
 	} else {
 		cacheFile, err = cacheutil.CreateFile(job.fileSpec, openFileFlags)
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return cacheFile, err
+	file, err := job.fileSpec.Cipher.Create(cacheFile)
+	if err != nil {
+		cacheFile.Close()
+		return nil, err
+	}
+
+	return file, nil
 }
 
 // downloadObjectAsync downloads the backing GCS object into a file as part of
@@ -464,6 +507,12 @@ func (job *Job) downloadObjectAsync() {
 		return
 	}
 
+	err = job.validateIntegrity()
+	if err != nil {
+		job.handleError(err)
+		return
+	}
+
 	job.updateStatusAndNotifySubscribers(Completed, err)
 }
 
@@ -487,7 +536,11 @@ func (job *Job) Download(ctx context.Context, offset int64, waitForDownload bool
 	} else if job.status.Name == NotStarted {
 		// Start the async download
 		job.status.Name = Downloading
-		job.cancelCtx, job.cancelFunc = context.WithCancel(context.Background())
+		var spanCtx context.Context
+		spanCtx, job.span = tracing.StartLinkedSpan(ctx, "filecache.DownloadJob", tracing.ObjectAttrs(job.object.Name, job.object.Generation)...)
+		job.cancelCtx, job.cancelFunc = context.WithCancel(spanCtx)
+		job.timelineSpan = timeline.Begin(timeline.Downloads, "DownloadJob", "object", job.object.Name, "size", job.object.Size)
+		job.metricsHandle.DownloadJobCount(context.Background(), 1, nil)
 		go job.downloadObjectAsync()
 	} else if job.status.Name == Failed || job.status.Name == Invalid || job.status.Offset >= offset {
 		defer job.mu.Unlock()
@@ -514,6 +567,35 @@ func (job *Job) Download(ctx context.Context, offset int64, waitForDownload bool
 	return
 }
 
+// WaitForCompletion starts downloading the object, if the job hasn't started
+// yet, and waits until the job has completed, i.e. downloaded and validated
+// the whole object, failed or been invalidated. It returns the job's status
+// then.
+//
+// Acquires and releases LOCK(job.mu)
+func (job *Job) WaitForCompletion(ctx context.Context) (jobStatus JobStatus, err error) {
+	if jobStatus, err = job.Download(ctx, 0, false); err != nil {
+		return
+	}
+
+	job.mu.Lock()
+	if job.status.Name != Downloading {
+		defer job.mu.Unlock()
+		return job.status, nil
+	}
+
+	// No offset is reached before completion.
+	notificationC := job.subscribe(math.MaxInt64)
+	job.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		err = fmt.Errorf("WaitForCompletion: %w", ctx.Err())
+	case jobStatus = <-notificationC:
+	}
+	return
+}
+
 // GetStatus returns the status of download job.
 //
 // Acquires and releases LOCK(job.mu)
@@ -530,7 +612,7 @@ func (job *Job) validateCRC() (err error) {
 		return
 	}
 
-	crc32Val, err := cacheutil.CalculateFileCRC32(job.cancelCtx, job.fileSpec.Path)
+	crc32Val, err := job.calculateCRC32()
 	if err != nil {
 		return
 	}
@@ -543,6 +625,36 @@ func (job *Job) validateCRC() (err error) {
 	// If the checksum doesn't match there is an error in downloading the object contents.
 	// Delete the file and corresponding key from fileInfoCache.
 	err = fmt.Errorf("checksum mismatch detected. Actual: %d, expected: %d", crc32Val, *job.object.CRC32C)
+	return job.removeCacheFile(err)
+}
+
+// Checks the contents of the downloaded file against the integrity manifest,
+// if any. In case of mismatch deletes the file and corresponding entry from
+// file cache.
+func (job *Job) validateIntegrity() (err error) {
+	if job.verifier == nil {
+		return
+	}
+
+	file, err := job.openCacheFile()
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	err = job.verifier.VerifyReader(job.cancelCtx, job.object, file)
+	var mismatchErr *integrity.MismatchError
+	if errors.As(err, &mismatchErr) {
+		return job.removeCacheFile(err)
+	}
+
+	return
+}
+
+// Deletes the downloaded file and corresponding entry from fileInfoCache after
+// its contents are found to be wrong, returning err joined with any errors in
+// doing so.
+func (job *Job) removeCacheFile(err error) error {
 	fileInfoKey := data.FileInfoKey{
 		BucketName: job.bucket.Name(),
 		ObjectName: job.object.Name,
@@ -550,17 +662,49 @@ func (job *Job) validateCRC() (err error) {
 
 	fileInfoKeyName, keyErr := fileInfoKey.Key()
 	if keyErr != nil {
-		err = errors.Join(err, keyErr)
-		return
+		return errors.Join(err, keyErr)
 	}
 
 	job.fileInfoCache.Erase(fileInfoKeyName)
+	job.fileSpec.Cipher.Remove(job.fileSpec.Path)
 	removeErr := cacheutil.TruncateAndRemoveFile(job.fileSpec.Path)
 	if removeErr != nil && !os.IsNotExist(removeErr) {
 		err = errors.Join(err, removeErr)
 	}
 
-	return
+	return err
+}
+
+// Calculates the CRC-32 checksum of the downloaded contents of the file in
+// cache, decrypting them if needed.
+func (job *Job) calculateCRC32() (uint32, error) {
+	if job.fileSpec.Cipher == nil {
+		return cacheutil.CalculateFileCRC32(job.cancelCtx, job.fileSpec.Path)
+	}
+
+	file, err := job.openCacheFile()
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return cacheutil.CalculateCRC32(job.cancelCtx, file)
+}
+
+// Opens the downloaded file in cache for reading its plaintext contents.
+func (job *Job) openCacheFile() (diskcrypt.File, error) {
+	f, err := os.Open(job.fileSpec.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	file, err := job.fileSpec.Cipher.File(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return file, nil
 }
 
 // Performs different actions based on the type of error.
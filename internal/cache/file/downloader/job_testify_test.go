@@ -15,6 +15,8 @@
 package downloader
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"math"
 	"os"
@@ -31,11 +33,13 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	testutil "github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -65,7 +69,7 @@ func (t *JobTestifyTest) initReadCacheTestifyTest(objectName string, objectConte
 		DirPerm:  util.DefaultDirPerm,
 	}
 	t.cache = lru.NewCache(lruCacheSize)
-	t.job = NewJob(&t.object, t.mockBucket, t.cache, sequentialReadSize, t.fileSpec, removeCallback, t.defaultFileCacheConfig, semaphore.NewWeighted(math.MaxInt64), common.NewNoopMetrics())
+	t.job = NewJob(&t.object, t.mockBucket, t.cache, sequentialReadSize, t.fileSpec, removeCallback, t.defaultFileCacheConfig, semaphore.NewWeighted(math.MaxInt64), common.NewNoopMetrics(), nil)
 	fileInfoKey := data.FileInfoKey{
 		BucketName: storage.TestBucketName,
 		ObjectName: objectName,
@@ -126,3 +130,55 @@ func (t *JobTestifyTest) Test_downloadObjectToFile_WithReadHandle() {
 	// Verify fileInfoCache update
 	verifyFileInfoEntry(t.T(), t.mockBucket, t.object, t.cache, uint64(objectSize))
 }
+
+func (t *JobTestifyTest) Test_validateIntegrity() {
+	objectName := "path/in/gcs/weights.bin"
+	objectContent := testutil.GenerateRandomBytes(util.MiB)
+	sum := sha256.Sum256(objectContent)
+	tbl := []struct {
+		name          string
+		fileContent   []byte
+		expectedError bool
+	}{
+		{
+			name:        "Matching file",
+			fileContent: objectContent,
+		},
+		{
+			name:          "Tampered file",
+			fileContent:   []byte("test"),
+			expectedError: true,
+		},
+	}
+	for _, tc := range tbl {
+		t.Run(tc.name, func() {
+			t.initReadCacheTestifyTest(objectName, objectContent, DefaultSequentialReadSizeMb, uint64(2*len(objectContent)), func() {})
+			t.job.verifier = integrity.NewVerifier(&integrity.Manifest{Objects: map[string]integrity.Entry{
+				objectName: {SHA256: hex.EncodeToString(sum[:])},
+			}}, nil)
+			file, err := util.CreateFile(t.fileSpec, os.O_TRUNC|os.O_RDWR)
+			require.NoError(t.T(), err)
+			_, err = file.Write(tc.fileContent)
+			require.NoError(t.T(), err)
+			require.NoError(t.T(), file.Close())
+			t.mockBucket.On("Name").Return(storage.TestBucketName)
+			t.job.cancelCtx, t.job.cancelFunc = context.WithCancel(context.Background())
+
+			err = t.job.validateIntegrity()
+
+			if tc.expectedError {
+				var mismatchErr *integrity.MismatchError
+				assert.ErrorAs(t.T(), err, &mismatchErr)
+				// The file and its entry in the file info cache are removed.
+				fileInfoKeyName, err := data.FileInfoKey{BucketName: storage.TestBucketName, ObjectName: objectName}.Key()
+				require.NoError(t.T(), err)
+				assert.Nil(t.T(), t.cache.LookUpWithoutChangingOrder(fileInfoKeyName))
+				_, err = os.Stat(t.fileSpec.Path)
+				assert.ErrorIs(t.T(), err, os.ErrNotExist)
+			} else {
+				assert.NoError(t.T(), err)
+				verifyCompleteFile(t.T(), t.fileSpec, objectContent)
+			}
+		})
+	}
+}
@@ -19,13 +19,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/data"
 	cacheutil "github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 	"golang.org/x/sync/errgroup"
 )
@@ -34,7 +36,12 @@ import (
 // GCS into given destination writer.
 //
 // This function doesn't take locks and can be executed parallely.
-func (job *Job) downloadRange(ctx context.Context, dstWriter io.Writer, start, end int64, readHandle []byte) ([]byte, error) {
+func (job *Job) downloadRange(ctx context.Context, dstWriter io.Writer, start, end int64, readHandle []byte) (_ []byte, err error) {
+	ctx, span := tracing.StartSpan(ctx, "filecache.DownloadRange", append(tracing.ObjectAttrs(job.object.Name, job.object.Generation), tracing.RangeAttrs(start, end-start)...)...)
+	defer func() { tracing.EndSpan(span, err) }()
+	timelineSpan := timeline.Begin(timeline.Downloads, "DownloadRange", "object", job.object.Name, "start", start, "end", end)
+	defer func() { timelineSpan.End(err) }()
+
 	newReader, err := job.bucket.NewReaderWithReadHandle(
 		ctx,
 		&gcs.ReadObjectRequest{
@@ -57,7 +64,7 @@ func (job *Job) downloadRange(ctx context.Context, dstWriter io.Writer, start, e
 		// foreground reads: https://github.com/GoogleCloudPlatform/gcsfuse/blob/master/internal/gcsx/random_reader.go#L298.
 		closeErr := newReader.Close()
 		if closeErr != nil {
-			logger.Warnf("Job:%p (%s:/%s) error while closing reader: %v", job, job.bucket.Name(), job.object.Name, closeErr)
+			logger.FileCache.Warnf("Job:%p (%s:/%s) error while closing reader: %v", job, job.bucket.Name(), job.object.Name, closeErr)
 		}
 	}()
 
@@ -130,7 +137,7 @@ func (job *Job) updateRangeMap(rangeMap map[int64]int64, offsetStart int64, offs
 
 // Reads the range input from the range channel continuously and downloads that
 // range from the GCS. If the range channel is closed, it will exit.
-func (job *Job) downloadOffsets(ctx context.Context, goroutineIndex int64, cacheFile *os.File, rangeMap map[int64]int64) func() error {
+func (job *Job) downloadOffsets(ctx context.Context, goroutineIndex int64, cacheFile diskcrypt.File, rangeMap map[int64]int64) func() error {
 	return func() error {
 		// Since we keep a goroutine for each job irrespective of the maxParallelism,
 		// not releasing the default goroutine to the pool.
@@ -165,7 +172,7 @@ func (job *Job) downloadOffsets(ctx context.Context, goroutineIndex int64, cache
 // parallelDownloadObjectToFile does parallel download of the backing GCS object
 // into given file handle using multiple NewReader method of gcs.Bucket running
 // in parallel. This function is canceled if job.cancelCtx is canceled.
-func (job *Job) parallelDownloadObjectToFile(cacheFile *os.File) (err error) {
+func (job *Job) parallelDownloadObjectToFile(cacheFile diskcrypt.File) (err error) {
 	rangeMap := make(map[int64]int64)
 	// Trying to keep the channel size greater than ParallelDownloadsPerFile to ensure
 	// that there is no goroutine waiting for data(nextRange) to be published to channel.
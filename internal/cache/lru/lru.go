@@ -16,11 +16,13 @@ package lru
 
 import (
 	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 )
 
@@ -36,16 +38,21 @@ const (
 // That means entry's value should be a lru.ValueType.
 type Cache struct {
 	/////////////////////////
-	// Constant data
+	// Dependencies
 	/////////////////////////
 
-	// INVARIANT: maxSize > 0
-	maxSize uint64
+	// Records the number of entries, their size and the evictions, annotated
+	// with metricAttrs. May be nil.
+	metricHandle common.MetricHandle
+	metricAttrs  []common.MetricAttr
 
 	/////////////////////////
 	// Mutable state
 	/////////////////////////
 
+	// INVARIANT: maxSize > 0
+	maxSize uint64
+
 	// Sum of entry.Value.Size() of all the entries in the cache.
 	currentSize uint64
 
@@ -78,9 +85,18 @@ type entry struct {
 // NewCache returns the reference of cache object by initialising the cache with
 // the supplied maxSize, which must be greater than zero.
 func NewCache(maxSize uint64) *Cache {
+	return NewCacheWithMetrics(maxSize, nil, "")
+}
+
+// NewCacheWithMetrics is like NewCache, but also records the cache's entry
+// count, size and evictions to the supplied metric handle, annotated with the
+// given cache type.
+func NewCacheWithMetrics(maxSize uint64, metricHandle common.MetricHandle, cacheType string) *Cache {
 	c := &Cache{
-		maxSize: maxSize,
-		index:   make(map[string]*list.Element),
+		metricHandle: metricHandle,
+		metricAttrs:  []common.MetricAttr{{Key: common.CacheType, Value: cacheType}},
+		maxSize:      maxSize,
+		index:        make(map[string]*list.Element),
 	}
 
 	// Set up invariant checking.
@@ -125,6 +141,44 @@ func (c *Cache) checkInvariants() {
 	}
 }
 
+// recordChange records the change in the number of entries and their size,
+// and the number of evictions, if the cache has a metric handle.
+func (c *Cache) recordChange(entries int64, size int64, evictions int64) {
+	if c.metricHandle == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if entries != 0 {
+		c.metricHandle.CacheEntryCount(ctx, entries, c.metricAttrs)
+	}
+	if size != 0 {
+		c.metricHandle.CacheSizeBytes(ctx, size, c.metricAttrs)
+	}
+	if evictions != 0 {
+		c.metricHandle.CacheEvictionCount(ctx, evictions, c.metricAttrs)
+	}
+}
+
+// evictUntilFits evicts entries until the cache is at or below maxSize and
+// records the evictions.
+//
+// LOCKS_REQUIRED(c.mu)
+func (c *Cache) evictUntilFits() (evictedValues []ValueType) {
+	var evictedSize uint64
+	for c.currentSize > c.maxSize {
+		evictedValue := c.evictOne()
+		evictedSize += evictedValue.Size()
+		evictedValues = append(evictedValues, evictedValue)
+	}
+
+	if len(evictedValues) > 0 {
+		n := int64(len(evictedValues))
+		c.recordChange(-n, -int64(evictedSize), n)
+	}
+	return
+}
+
 func (c *Cache) evictOne() ValueType {
 	e := c.entries.Back()
 	key := e.Value.(entry).Key
@@ -153,34 +207,33 @@ func (c *Cache) Insert(
 	}
 
 	valueSize := value.Size()
-	if valueSize > c.maxSize {
-		return nil, errors.New(InvalidEntrySizeErrorMsg)
-	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if valueSize > c.maxSize {
+		return nil, errors.New(InvalidEntrySizeErrorMsg)
+	}
+
 	e, ok := c.index[key]
 	if ok {
 		// Update an entry if already exist.
-		c.currentSize -= e.Value.(entry).Value.Size()
+		oldSize := e.Value.(entry).Value.Size()
+		c.currentSize -= oldSize
 		c.currentSize += valueSize
 		e.Value = entry{key, value}
 		c.entries.MoveToFront(e)
+		c.recordChange(0, int64(valueSize)-int64(oldSize), 0)
 	} else {
 		// Add the entry if already doesn't exist.
 		e := c.entries.PushFront(entry{key, value})
 		c.index[key] = e
 		c.currentSize += valueSize
+		c.recordChange(1, int64(valueSize), 0)
 	}
 
-	var evictedValues []ValueType
 	// Evict until we're at or below maxSize.
-	for c.currentSize > c.maxSize {
-		evictedValues = append(evictedValues, c.evictOne())
-	}
-
-	return evictedValues, nil
+	return c.evictUntilFits(), nil
 }
 
 // Erase any entry for the supplied key, also returns the value of erased key.
@@ -198,6 +251,7 @@ func (c *Cache) Erase(key string) (value ValueType) {
 
 	delete(c.index, key)
 	c.entries.Remove(e)
+	c.recordChange(-1, -int64(deletedEntry.Size()), 0)
 
 	return deletedEntry
 }
@@ -270,9 +324,41 @@ func (c *Cache) UpdateWithoutChangingOrder(
 }
 
 func (c *Cache) EraseEntriesWithGivenPrefix(prefix string) {
+	for _, key := range c.KeysWithPrefix(prefix) {
+		c.Erase(key)
+	}
+}
+
+// KeysWithPrefix returns the keys of the entries whose keys start with the
+// given prefix, in no particular order.
+func (c *Cache) KeysWithPrefix(prefix string) (keys []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	for key := range c.index {
 		if strings.HasPrefix(key, prefix) {
-			c.Erase(key)
+			keys = append(keys, key)
 		}
 	}
+
+	return
+}
+
+// Size returns the sum of the sizes of the entries in the cache, and the
+// maximum it may reach.
+func (c *Cache) Size() (current uint64, max uint64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.currentSize, c.maxSize
+}
+
+// SetMaxSize changes the maximum size of the cache, evicting entries until the
+// cache fits, and returns the evicted values.
+func (c *Cache) SetMaxSize(maxSize uint64) (evictedValues []ValueType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxSize = maxSize
+	return c.evictUntilFits()
 }
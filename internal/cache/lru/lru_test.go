@@ -15,12 +15,15 @@
 package lru_test
 
 import (
+	"context"
 	"errors"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	. "github.com/jacobsa/ogletest"
@@ -55,6 +58,28 @@ func (td testData) Size() uint64 {
 	return td.DataSize
 }
 
+// fakeCacheMetrics sums up the recorded cache metrics.
+type fakeCacheMetrics struct {
+	common.MetricHandle
+	entries, size, evictions int64
+	attrs                    []common.MetricAttr
+}
+
+func (m *fakeCacheMetrics) CacheEntryCount(_ context.Context, inc int64, attrs []common.MetricAttr) {
+	m.entries += inc
+	m.attrs = attrs
+}
+
+func (m *fakeCacheMetrics) CacheSizeBytes(_ context.Context, inc int64, attrs []common.MetricAttr) {
+	m.size += inc
+	m.attrs = attrs
+}
+
+func (m *fakeCacheMetrics) CacheEvictionCount(_ context.Context, inc int64, attrs []common.MetricAttr) {
+	m.evictions += inc
+	m.attrs = attrs
+}
+
 // insertAndAssert inserts the given key,value in the cache and assert based on
 // the expected eviction and error.
 func (t *CacheTest) insertAndAssert(key string, val lru.ValueType, evictedValues []int64, expectedError error) {
@@ -185,6 +210,46 @@ func (t *CacheTest) TestEraseCacheWithGivenPrefix() {
 	ExpectEq(2, t.cache.LookUp("b").Size())
 }
 
+func (t *CacheTest) TestKeysWithPrefix() {
+	t.insertAndAssert("a", testData{Value: 23, DataSize: 4}, []int64{}, nil)
+	t.insertAndAssert("a/b", testData{Value: 26, DataSize: 5}, []int64{}, nil)
+	t.insertAndAssert("b", testData{Value: 21, DataSize: 2}, []int64{}, nil)
+
+	keys := t.cache.KeysWithPrefix("a")
+	sort.Strings(keys)
+
+	AssertEq(2, len(keys))
+	ExpectEq("a", keys[0])
+	ExpectEq("a/b", keys[1])
+	ExpectEq(0, len(t.cache.KeysWithPrefix("c")))
+}
+
+func (t *CacheTest) TestSize() {
+	t.insertAndAssert("a", testData{Value: 23, DataSize: 4}, []int64{}, nil)
+	t.insertAndAssert("b", testData{Value: 21, DataSize: 2}, []int64{}, nil)
+
+	current, max := t.cache.Size()
+
+	ExpectEq(6, current)
+	ExpectEq(MaxSize, max)
+}
+
+func (t *CacheTest) TestSetMaxSize() {
+	t.insertAndAssert("a", testData{Value: 23, DataSize: 4}, []int64{}, nil)
+	t.insertAndAssert("b", testData{Value: 26, DataSize: 5}, []int64{}, nil)
+	t.insertAndAssert("c", testData{Value: 21, DataSize: 2}, []int64{}, nil)
+
+	evicted := t.cache.SetMaxSize(8)
+
+	AssertEq(1, len(evicted))
+	ExpectEq(23, evicted[0].(testData).Value)
+	current, max := t.cache.Size()
+	ExpectEq(7, current)
+	ExpectEq(8, max)
+	// Entries bigger than the new maximum are rejected.
+	t.insertAndAssert("d", testData{Value: 20, DataSize: 9}, []int64{}, errors.New(lru.InvalidEntrySizeErrorMsg))
+}
+
 func (t *CacheTest) TestEraseCacheWhereNoEntriesExistWithGivenPrefix() {
 	t.insertAndAssert("a", testData{Value: 23, DataSize: 4}, []int64{}, nil)
 	t.insertAndAssert("a/b", testData{Value: 26, DataSize: 5}, []int64{}, nil)
@@ -363,3 +428,43 @@ func (t *CacheTest) TestRaceCondition() {
 
 	wg.Wait()
 }
+
+func (t *CacheTest) TestMetrics() {
+	m := &fakeCacheMetrics{MetricHandle: common.NewNoopMetrics()}
+	t.cache = lru.NewCacheWithMetrics(MaxSize, m, common.StatCache)
+
+	_, err := t.cache.Insert("a", testData{Value: 1, DataSize: 20})
+	AssertEq(nil, err)
+	_, err = t.cache.Insert("b", testData{Value: 2, DataSize: 20})
+	AssertEq(nil, err)
+	ExpectEq(2, m.entries)
+	ExpectEq(40, m.size)
+	ExpectEq(0, m.evictions)
+
+	// Evicts "a".
+	_, err = t.cache.Insert("c", testData{Value: 3, DataSize: 20})
+	AssertEq(nil, err)
+	ExpectEq(2, m.entries)
+	ExpectEq(40, m.size)
+	ExpectEq(1, m.evictions)
+
+	// Replaces "b" with a smaller value.
+	_, err = t.cache.Insert("b", testData{Value: 4, DataSize: 10})
+	AssertEq(nil, err)
+	ExpectEq(2, m.entries)
+	ExpectEq(30, m.size)
+
+	t.cache.Erase("c")
+	ExpectEq(1, m.entries)
+	ExpectEq(10, m.size)
+
+	// Evicts "b".
+	t.cache.SetMaxSize(5)
+	ExpectEq(0, m.entries)
+	ExpectEq(0, m.size)
+	ExpectEq(2, m.evictions)
+
+	AssertEq(1, len(m.attrs))
+	ExpectEq(common.CacheType, m.attrs[0].Key)
+	ExpectEq(common.StatCache, m.attrs[0].Value)
+}
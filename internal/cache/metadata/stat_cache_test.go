@@ -125,7 +125,10 @@ func (t *StatCacheTest) SetupTest() {
 }
 
 func (t *MultiBucketStatCacheTest) SetupTest() {
-	sharedCache := lru.NewCache(uint64((cfg.AverageSizeOfPositiveStatCacheEntry + cfg.AverageSizeOfNegativeStatCacheEntry) * capacity))
+	// Keys are prefixed with the bucket name, which counts twice towards the
+	// estimated RSS of each positive and negative entry.
+	keyPrefixSize := 2 * 2 * uint64(len("fruits/"))
+	sharedCache := lru.NewCache(uint64((cfg.AverageSizeOfPositiveStatCacheEntry + cfg.AverageSizeOfNegativeStatCacheEntry + keyPrefixSize) * capacity))
 	t.multiBucketCache.fruits = testHelperCache{wrapped: metadata.NewStatCacheBucketView(sharedCache, "fruits")}
 	t.multiBucketCache.spices = testHelperCache{wrapped: metadata.NewStatCacheBucketView(sharedCache, "spices")}
 }
@@ -354,18 +357,18 @@ func (t *MultiBucketStatCacheTest) Test_CreateEntriesWithSameNameInDifferentBuck
 }
 
 func (t *MultiBucketStatCacheTest) Test_FillUpToCapacity() {
-	assert.Equal(t.T(), 3, capacity) // maxSize = 3 * 1640 = 4920 bytes
+	assert.Equal(t.T(), 3, capacity) // maxSize = 3 * (1640 + 28) = 5004 bytes
 
 	cache := &t.multiBucketCache
 	fruits := &cache.fruits
 	spices := &cache.spices
 
-	fruits.Insert(apple, expiration)               // size = 1416 bytes
-	fruits.Insert(orange, expiration)              // size = 1420 bytes (cumulative = 2836 bytes)
-	spices.Insert(cardamom, expiration)            // size = 1428 bytes (cumulative = 4264 bytes)
-	fruits.AddNegativeEntry("papaya", expiration)  // size = 186 bytes (cumulative = 4450 bytes)
-	spices.AddNegativeEntry("saffron", expiration) // size = 188 bytes (cumulative = 4638 bytes)
-	spices.AddNegativeEntry("pepper", expiration)  // size = 186 bytes (cumulative = 4824 bytes)
+	fruits.Insert(apple, expiration)               // size = 1448 bytes
+	fruits.Insert(orange, expiration)              // size = 1452 bytes (cumulative = 2900 bytes)
+	spices.Insert(cardamom, expiration)            // size = 1460 bytes (cumulative = 4360 bytes)
+	fruits.AddNegativeEntry("papaya", expiration)  // size = 202 bytes (cumulative = 4562 bytes)
+	spices.AddNegativeEntry("saffron", expiration) // size = 204 bytes (cumulative = 4766 bytes)
+	spices.AddNegativeEntry("pepper", expiration)  // size = 202 bytes (cumulative = 4968 bytes)
 
 	// Before expiration
 	justBefore := expiration.Add(-time.Nanosecond)
@@ -395,20 +398,20 @@ func (t *MultiBucketStatCacheTest) Test_FillUpToCapacity() {
 }
 
 func (t *MultiBucketStatCacheTest) Test_ExpiresLeastRecentlyUsed() {
-	assert.Equal(t.T(), 3, capacity) // maxSize = 3 * 1640 = 4920 bytes
+	assert.Equal(t.T(), 3, capacity) // maxSize = 3 * (1640 + 28) = 5004 bytes
 
 	cache := &t.multiBucketCache
 	fruits := &cache.fruits
 	spices := &cache.spices
 
-	fruits.Insert(apple, expiration)                                  // size = 1416 bytes
-	fruits.Insert(orange, expiration)                                 // Least recent, size = 1420 bytes (cumulative = 2836 bytes)
-	spices.Insert(cardamom, expiration)                               // Second most recent, size = 1428 bytes (cumulative = 4264 bytes)
+	fruits.Insert(apple, expiration)                                  // size = 1448 bytes
+	fruits.Insert(orange, expiration)                                 // Least recent, size = 1452 bytes (cumulative = 2900 bytes)
+	spices.Insert(cardamom, expiration)                               // Second most recent, size = 1460 bytes (cumulative = 4360 bytes)
 	assert.Equal(t.T(), apple, fruits.LookUpOrNil("apple", someTime)) // Most recent
 
 	// Insert another.
 	saffron := &gcs.MinObject{Name: "saffron"}
-	spices.Insert(saffron, expiration) // size = 1424 bytes (cumulative = 5688 bytes)
+	spices.Insert(saffron, expiration) // size = 1456 bytes (cumulative = 5816 bytes)
 	// This will evict the least recent entry, i.e. orange.
 
 	// See what's left.
@@ -19,6 +19,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 )
@@ -53,6 +54,11 @@ type TypeCache interface {
 	Insert(now time.Time, name string, it Type)
 	// Erase removes the entry with the given name.
 	Erase(name string)
+	// EraseAll removes all the entries.
+	EraseAll()
+	// SetTTL changes the TTL of the entries inserted from now on. It does
+	// nothing if caching is disabled.
+	SetTTL(ttl time.Duration)
 	// Get returns the entry with given name, and also
 	// records this entry as latest accessed in the cache.
 	// If now > expiration, then entry is removed from cache, and
@@ -114,15 +120,11 @@ func SizeOfTypeCacheEntry(key string) uint64 {
 // External synchronization is required.
 type typeCache struct {
 	/////////////////////////
-	// Constant data
+	// Mutable state
 	/////////////////////////
 
 	ttl time.Duration
 
-	/////////////////////////
-	// Mutable state
-	/////////////////////////
-
 	// A cache mapping names to the cache entry.
 	// INVARIANT: entries.CheckInvariants() does not panic
 	// INVARIANT: Each value is of type cacheEntry
@@ -134,7 +136,9 @@ type typeCache struct {
 // When insertion of next entry would cause size of cache > maxSizeMB,
 // older entries are evicted according to the LRU-policy.
 // If either of TTL or maxSizeMB is zero, nothing is ever cached.
-func NewTypeCache(maxSizeMB int64, ttl time.Duration) TypeCache {
+// The entries, size and evictions are recorded to metricHandle, which may be
+// nil.
+func NewTypeCache(maxSizeMB int64, ttl time.Duration, metricHandle common.MetricHandle) TypeCache {
 	if ttl > 0 && maxSizeMB != 0 {
 		var lruSizeInBytesToUse uint64 = math.MaxUint64 // default for when maxSizeMB = -1
 		if maxSizeMB > 0 {
@@ -142,7 +146,7 @@ func NewTypeCache(maxSizeMB int64, ttl time.Duration) TypeCache {
 		}
 		return &typeCache{
 			ttl:     ttl,
-			entries: lru.NewCache(lruSizeInBytesToUse),
+			entries: lru.NewCacheWithMetrics(lruSizeInBytesToUse, metricHandle, common.TypeCache),
 		}
 	}
 	return &typeCache{}
@@ -167,6 +171,18 @@ func (tc *typeCache) Erase(name string) {
 	}
 }
 
+func (tc *typeCache) EraseAll() {
+	if tc.entries != nil { // only if caching is enabled
+		tc.entries.EraseEntriesWithGivenPrefix("")
+	}
+}
+
+func (tc *typeCache) SetTTL(ttl time.Duration) {
+	if tc.entries != nil { // only if caching is enabled
+		tc.ttl = ttl
+	}
+}
+
 func (tc *typeCache) Get(now time.Time, name string) Type {
 	if tc.entries == nil { // if caching is not enabled
 		return UnknownType
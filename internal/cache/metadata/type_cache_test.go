@@ -83,7 +83,7 @@ func (t *ZeroTtlTypeCacheTest) SetUp(ti *TestInfo) {
 ////////////////////////////////////////////////////////////////////////
 
 func createNewTypeCache(maxSizeMB int64, ttl time.Duration) *typeCache {
-	tc := NewTypeCache(maxSizeMB, ttl)
+	tc := NewTypeCache(maxSizeMB, ttl, nil)
 
 	AssertNe(nil, tc)
 	AssertNe(nil, tc.(*typeCache))
@@ -185,6 +185,22 @@ func (t *TypeCacheTest) TestGetErasedEntry() {
 	ExpectEq(UnknownType, t.cache.Get(beforeExpiration, "abcd"))
 }
 
+func (t *TypeCacheTest) TestGetAfterEraseAll() {
+	t.cache.Insert(now, "abcd", RegularFileType)
+	t.cache.Insert(now, "efgh", ExplicitDirType)
+	t.cache.EraseAll()
+
+	ExpectEq(UnknownType, t.cache.Get(beforeExpiration, "abcd"))
+	ExpectEq(UnknownType, t.cache.Get(beforeExpiration, "efgh"))
+}
+
+func (t *TypeCacheTest) TestGetAfterSetTTL() {
+	t.cache.SetTTL(2 * TTL)
+	t.cache.Insert(now, "abcd", RegularFileType)
+
+	ExpectEq(RegularFileType, t.cache.Get(afterExpiration, "abcd"))
+}
+
 func (t *TypeCacheTest) TestGetReinsertedEntry() {
 	t.cache.Insert(now, "abcd", RegularFileType)
 	t.cache.Erase("abcd")
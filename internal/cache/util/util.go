@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -66,7 +67,7 @@ func CreateFile(fileSpec data.FileSpec, flag int) (file *os.File, err error) {
 	}
 
 	// Create file if not present.
-	_, err = os.Stat(fileSpec.Path)
+	fileInfo, err := os.Stat(fileSpec.Path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			flag = flag | os.O_CREATE
@@ -74,6 +75,15 @@ func CreateFile(fileSpec data.FileSpec, flag int) (file *os.File, err error) {
 			err = fmt.Errorf("error in stating file %s: %w", fileSpec.Path, err)
 			return
 		}
+	} else if fileInfo.IsDir() {
+		// Cache files of objects under the prefix fileSpec.Path leave behind
+		// directories after they are evicted, e.g. when dir "a/" is replaced by
+		// file "a". Replace them if they hold no files.
+		if err = removeEmptyDirTree(fileSpec.Path); err != nil {
+			err = fmt.Errorf("error in removing directory %s: %w", fileSpec.Path, err)
+			return
+		}
+		flag = flag | os.O_CREATE
 	}
 	file, err = os.OpenFile(fileSpec.Path, flag, fileSpec.FilePerm)
 	if err != nil {
@@ -83,6 +93,29 @@ func CreateFile(fileSpec data.FileSpec, flag int) (file *os.File, err error) {
 	return
 }
 
+// removeEmptyDirTree removes the directory dir along with the directories
+// under it, bottom-up, failing if the tree contains anything other than
+// directories. Each directory is removed with os.Remove, which fails if a file
+// was created in it in the meantime, so no file is ever removed.
+func removeEmptyDirTree(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+		if err = removeEmptyDirTree(path); err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(dir)
+}
+
 // GetObjectPath gives object path which is concatenation of bucket and object
 // name separated by "/".
 func GetObjectPath(bucketName string, objectName string) string {
@@ -155,6 +188,12 @@ func calculateCRC32(ctx context.Context, reader io.Reader) (uint32, error) {
 	}
 }
 
+// CalculateCRC32 calculates and returns the CRC-32 checksum of the contents of
+// the reader.
+func CalculateCRC32(ctx context.Context, reader io.Reader) (uint32, error) {
+	return calculateCRC32(ctx, reader)
+}
+
 // CalculateFileCRC32 calculates and returns the CRC-32 checksum of a file.
 func CalculateFileCRC32(ctx context.Context, filePath string) (uint32, error) {
 	// Open file with simplified flags and permissions
@@ -183,6 +222,35 @@ func TruncateAndRemoveFile(filePath string) error {
 	return nil
 }
 
+// TruncateAndRemoveFilesUnder truncates and removes every file under dir, and
+// then the directories under it, leaving dir itself empty.
+func TruncateAndRemoveFilesUnder(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		return TruncateAndRemoveFile(path)
+	})
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err = os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetMemoryAlignedBuffer creates a buffer([]byte) of size bufferSize aligned to
 // memory address in multiple of alignSize.
 func GetMemoryAlignedBuffer(bufferSize int64, alignSize int64) (buffer []byte, err error) {
@@ -179,6 +179,38 @@ func (ut *utilTest) Test_CreateFile_FilePresent() {
 	ExpectEq(nil, file.Close())
 }
 
+func (ut *utilTest) Test_CreateFile_EmptyDirPresentAtPath() {
+	err := os.MkdirAll(ut.fileSpec.Path, 0755)
+	ExpectEq(nil, err)
+
+	file, err := CreateFile(ut.fileSpec, ut.flag)
+
+	ut.assertFileAndDirCreationWithGivenDirPerm(file, err, 0755)
+	ExpectEq(nil, file.Close())
+}
+
+func (ut *utilTest) Test_CreateFile_EmptyDirTreePresentAtPath() {
+	err := os.MkdirAll(path.Join(ut.fileSpec.Path, "bar", "baz"), 0755)
+	ExpectEq(nil, err)
+
+	file, err := CreateFile(ut.fileSpec, ut.flag)
+
+	ut.assertFileAndDirCreationWithGivenDirPerm(file, err, 0755)
+	ExpectEq(nil, file.Close())
+}
+
+func (ut *utilTest) Test_CreateFile_DirWithFilePresentAtPath() {
+	err := os.MkdirAll(path.Join(ut.fileSpec.Path, "bar"), 0755)
+	ExpectEq(nil, err)
+	err = os.WriteFile(path.Join(ut.fileSpec.Path, "bar", "baz"), []byte("foo"), DefaultFilePerm)
+	ExpectEq(nil, err)
+
+	_, err = CreateFile(ut.fileSpec, ut.flag)
+
+	ExpectNe(nil, err)
+	ExpectTrue(strings.Contains(err.Error(), "error in removing directory "+ut.fileSpec.Path))
+}
+
 func (ut *utilTest) Test_CreateFile_FilePresentWithLessAccess() {
 	err := os.MkdirAll(path.Dir(ut.fileSpec.Path), 0755)
 	ExpectEq(nil, err)
@@ -26,8 +26,10 @@ import (
 	"regexp"
 	"sync"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/jacobsa/fuse/fsutil"
 	"github.com/jacobsa/timeutil"
 )
 
@@ -47,6 +49,9 @@ type ContentCache struct {
 	tempDir    string
 	fileMap    map[CacheObjectKey]*CacheObject
 	mtimeClock timeutil.Clock
+
+	// If set, the contents of temp and cache files are encrypted with it.
+	cipher *diskcrypt.Cipher
 }
 
 // Metadata store struct
@@ -125,6 +130,13 @@ func (c *ContentCache) recoverFileFromCache(metadataFile fs.FileInfo) {
 		ObjectName: metadata.ObjectName,
 	}
 	fileName := metadata.CacheFileNameOnDisk
+	// Files from an earlier mount can't be decrypted with the key of this one.
+	if c.cipher != nil {
+		logger.Infof("content cache: Removing cache file %v, which can't be decrypted", fileName)
+		os.Remove(fileName)
+		os.Remove(metadataAbsolutePath)
+		return
+	}
 	// TODO (#641) linux fs limits single process to open max of 1024 file descriptors
 	// so this is probably not scalable, we should figure out if this is an actual issue or not
 	file, err := os.Open(fileName)
@@ -181,19 +193,36 @@ func matchPattern(fileName string) bool {
 	return match
 }
 
-// New creates a ContentCache.
-func New(tempDir string, mtimeClock timeutil.Clock) *ContentCache {
+// New creates a ContentCache. If cipher is non-nil, the files it creates are
+// encrypted with it.
+func New(tempDir string, mtimeClock timeutil.Clock, cipher *diskcrypt.Cipher) *ContentCache {
 	return &ContentCache{
 		tempDir:    tempDir,
 		fileMap:    make(map[CacheObjectKey]*CacheObject),
 		mtimeClock: mtimeClock,
+		cipher:     cipher,
 	}
 }
 
 // NewTempFile returns a handle for a temporary file on the disk. The caller
 // must call Destroy on the TempFile before releasing it.
 func (c *ContentCache) NewTempFile(rc io.ReadCloser) (gcsx.TempFile, error) {
-	return gcsx.NewTempFile(rc, c.tempDir, c.mtimeClock)
+	if c.cipher == nil {
+		return gcsx.NewTempFile(rc, c.tempDir, c.mtimeClock)
+	}
+
+	f, err := fsutil.AnonymousFile(c.tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("AnonymousFile: %w", err)
+	}
+
+	file, err := c.cipher.NewFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return c.NewCacheFile(rc, file), nil
 }
 
 // AddOrReplace creates a new cache file or updates an existing cache file
@@ -209,7 +238,13 @@ func (c *ContentCache) AddOrReplace(cacheObjectKey *CacheObjectKey, generation i
 	if err != nil {
 		return nil, fmt.Errorf("TempFile: %w", err)
 	}
-	file := c.NewCacheFile(rc, f)
+	cf, err := c.cipher.NewFile(f)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	file := c.NewCacheFile(rc, cf)
 	metadata := &CacheFileObjectMetadata{
 		CacheFileNameOnDisk: file.Name(),
 		BucketName:          cacheObjectKey.BucketName,
@@ -252,7 +287,7 @@ func (c *ContentCache) Remove(cacheObjectKey *CacheObjectKey) {
 }
 
 // NewCacheFile returns a cache tempfile wrapper around the source reader and file
-func (c *ContentCache) NewCacheFile(rc io.ReadCloser, f *os.File) gcsx.TempFile {
+func (c *ContentCache) NewCacheFile(rc io.ReadCloser, f diskcrypt.File) gcsx.TempFile {
 	return gcsx.NewCacheFile(rc, f, c.tempDir, c.mtimeClock)
 }
 
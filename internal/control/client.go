@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+)
+
+// Client calls the admin API served on a control socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client for the admin API served on the Unix socket at
+// the given path.
+func NewClient(path string) *Client {
+	return &Client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+// Config returns the effective config of the mount, as YAML.
+func (c *Client) Config(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.do(ctx, http.MethodGet, "/v1/config", nil, &buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Reload makes the mount load its config file again and apply the changes
+// that don't need a remount.
+func (c *Client) Reload(ctx context.Context) (r fs.Reconfiguration, err error) {
+	err = c.doJSON(ctx, http.MethodPost, "/v1/reload", nil, &r)
+	return
+}
+
+// Stats returns counts of the state held by the file system.
+func (c *Client) Stats(ctx context.Context) (s fs.Stats, err error) {
+	err = c.doJSON(ctx, http.MethodGet, "/v1/stats", nil, &s)
+	return
+}
+
+// Top returns the n callers and path prefixes with the most usage by the given
+// measure, or all of them if n is zero.
+func (c *Client) Top(ctx context.Context, n int, by attribution.Measure) (r attribution.Report, err error) {
+	q := url.Values{"n": {strconv.Itoa(n)}, "by": {string(by)}}
+	err = c.doJSON(ctx, http.MethodGet, "/v1/top?"+q.Encode(), nil, &r)
+	return
+}
+
+// Invalidate drops cache entries as described by req.
+func (c *Client) Invalidate(ctx context.Context, req InvalidateRequest) error {
+	return c.doJSON(ctx, http.MethodPost, "/v1/invalidate", req, nil)
+}
+
+// SetLogSeverity changes the log severity of the mount.
+func (c *Client) SetLogSeverity(ctx context.Context, severity string) error {
+	return c.doJSON(ctx, http.MethodPut, "/v1/log-severity", LogSeverityRequest{Severity: severity}, nil)
+}
+
+// Profile writes a pprof profile of the given kind, "cpu" or "heap", to w.
+// CPU profiles cover the given duration.
+func (c *Client) Profile(ctx context.Context, kind string, duration time.Duration, w io.Writer) error {
+	path := "/v1/pprof/" + url.PathEscape(kind)
+	if kind == "cpu" {
+		path += fmt.Sprintf("?seconds=%d", int(duration.Seconds()))
+	}
+
+	return c.do(ctx, http.MethodGet, path, nil, w)
+}
+
+// Timeline writes the timeline recorded by the mount to w, as a Chrome trace.
+func (c *Client) Timeline(ctx context.Context, w io.Writer) error {
+	return c.do(ctx, http.MethodGet, "/v1/timeline", nil, w)
+}
+
+// Unmount drains the file system and unmounts it.
+func (c *Client) Unmount(ctx context.Context) error {
+	return c.doJSON(ctx, http.MethodPost, "/v1/unmount", nil, nil)
+}
+
+// Send a request with the given value as its JSON body, if any, and decode the
+// JSON response into out, if given.
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	var buf bytes.Buffer
+	if err := c.do(ctx, method, path, body, &buf); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(buf.Bytes(), out)
+}
+
+// Send a request and copy the response to w, or return the error it carries.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, w io.Writer) error {
+	// The host is ignored, since the transport always dials the socket.
+	req, err := http.NewRequestWithContext(ctx, method, "http://gcsfuse"+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("%s", e.Error)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type invalidation struct {
+	cache  fs.Cache
+	path   string
+	prefix bool
+}
+
+type fakeAdmin struct {
+	config        *cfg.Config
+	stats         fs.Stats
+	invalidations []invalidation
+	drained       bool
+	drainErr      error
+	top           *attribution.Report
+}
+
+func (a *fakeAdmin) Stats() fs.Stats {
+	return a.stats
+}
+
+func (a *fakeAdmin) InvalidateCache(cache fs.Cache, path string, prefix bool) error {
+	if cache == fs.FileCache {
+		return fs.ErrCacheDisabled
+	}
+
+	a.invalidations = append(a.invalidations, invalidation{cache, path, prefix})
+	return nil
+}
+
+func (a *fakeAdmin) Drain(context.Context) error {
+	a.drained = true
+	return a.drainErr
+}
+
+func (a *fakeAdmin) Config() *cfg.Config {
+	return a.config
+}
+
+func (a *fakeAdmin) Reconfigure(*cfg.Config) (fs.Reconfiguration, error) {
+	return fs.Reconfiguration{}, errors.New("not implemented")
+}
+
+func (a *fakeAdmin) TopConsumers(n int, by attribution.Measure) (attribution.Report, error) {
+	if a.top == nil {
+		return attribution.Report{}, fs.ErrAttributionDisabled
+	}
+
+	r := *a.top
+	r.By = by
+	r.Top = r.Top[:min(n, len(r.Top))]
+	return r, nil
+}
+
+func serve(t *testing.T, m Mount) (*Client, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	s, err := Serve(path, m)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, s.Close()) })
+
+	return NewClient(path), path
+}
+
+func TestServe_SocketIsPrivate(t *testing.T) {
+	_, path := serve(t, Mount{Admin: &fakeAdmin{}})
+
+	fi, err := os.Stat(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
+
+func TestServe_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	// Leave the socket behind, as a crashed mount would.
+	l.SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	require.FileExists(t, path)
+
+	s, err := Serve(path, Mount{Admin: &fakeAdmin{}})
+
+	require.NoError(t, err)
+	assert.NoError(t, s.Close())
+	assert.NoFileExists(t, path)
+}
+
+func TestConfig(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{config: &cfg.Config{AppName: "my-app"}}})
+
+	out, err := c.Config(context.Background())
+
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "app-name: my-app")
+}
+
+func TestReload(t *testing.T) {
+	want := fs.Reconfiguration{Applied: []string{"metadata-cache.ttl-secs"}, Rejected: []string{"only-dir"}}
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}, Reload: func() (fs.Reconfiguration, error) {
+		return want, nil
+	}})
+
+	r, err := c.Reload(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, want, r)
+}
+
+func TestReload_Failure(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}, Reload: func() (fs.Reconfiguration, error) {
+		return fs.Reconfiguration{}, errors.New("error while parsing config: taco")
+	}})
+
+	_, err := c.Reload(context.Background())
+
+	assert.ErrorContains(t, err, "error while parsing config: taco")
+}
+
+func TestStats(t *testing.T) {
+	admin := &fakeAdmin{stats: fs.Stats{Inodes: 3, Handles: 2, FileCacheBytes: 10}}
+	c, _ := serve(t, Mount{Admin: admin})
+
+	stats, err := c.Stats(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, admin.stats, stats)
+}
+
+func TestTop(t *testing.T) {
+	admin := &fakeAdmin{top: &attribution.Report{Keys: 2, Top: []attribution.Consumer{
+		{Key: attribution.Key{Caller: "/system.slice/trainer.service", PathPrefix: "/data"}, Usage: attribution.Usage{Ops: 5}, PIDs: []uint32{10}},
+		{Key: attribution.Key{Caller: "cp", PathPrefix: "/out"}, Usage: attribution.Usage{Ops: 1, BytesWritten: 10}},
+	}}}
+	c, _ := serve(t, Mount{Admin: admin})
+
+	r, err := c.Top(context.Background(), 1, attribution.ByBytesRead)
+
+	require.NoError(t, err)
+	assert.Equal(t, attribution.ByBytesRead, r.By)
+	assert.Equal(t, 2, r.Keys)
+	assert.Equal(t, admin.top.Top[:1], r.Top)
+}
+
+func TestTop_Errors(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+
+	_, err := c.Top(context.Background(), 10, attribution.ByOps)
+	assert.ErrorContains(t, err, fs.ErrAttributionDisabled.Error())
+	_, err = c.Top(context.Background(), 10, "cost")
+	assert.ErrorContains(t, err, `unknown measure "cost"`)
+}
+
+func TestInvalidate(t *testing.T) {
+	admin := &fakeAdmin{}
+	c, _ := serve(t, Mount{Admin: admin})
+
+	err := c.Invalidate(context.Background(), InvalidateRequest{Cache: fs.StatCache, Path: "a/b", Prefix: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, []invalidation{{fs.StatCache, "a/b", true}}, admin.invalidations)
+}
+
+func TestInvalidate_AllSkipsDisabledCaches(t *testing.T) {
+	admin := &fakeAdmin{}
+	c, _ := serve(t, Mount{Admin: admin})
+
+	err := c.Invalidate(context.Background(), InvalidateRequest{Path: "a"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []invalidation{{fs.StatCache, "a", false}, {fs.TypeCache, "a", false}}, admin.invalidations)
+}
+
+func TestInvalidate_Errors(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+
+	assert.ErrorContains(t, c.Invalidate(context.Background(), InvalidateRequest{Cache: "bogus", Path: "a"}), `unknown cache "bogus"`)
+	assert.ErrorContains(t, c.Invalidate(context.Background(), InvalidateRequest{Cache: fs.FileCache, Path: "a"}), fs.ErrCacheDisabled.Error())
+}
+
+func TestSetLogSeverity(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+
+	assert.NoError(t, c.SetLogSeverity(context.Background(), "info"))
+	assert.Error(t, c.SetLogSeverity(context.Background(), "verbose"))
+}
+
+func TestProfile(t *testing.T) {
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+	var buf bytes.Buffer
+
+	err := c.Profile(context.Background(), "heap", 0, &buf)
+
+	require.NoError(t, err)
+	assert.NotZero(t, buf.Len())
+	assert.Error(t, c.Profile(context.Background(), "goroutine", time.Second, &buf))
+}
+
+func TestTimeline(t *testing.T) {
+	timeline.Enable(10)
+	t.Cleanup(timeline.Disable)
+	timeline.Begin(timeline.GCS, "StatObject").End(nil)
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+	var buf bytes.Buffer
+
+	err := c.Timeline(context.Background(), &buf)
+
+	require.NoError(t, err)
+	assert.True(t, json.Valid(buf.Bytes()))
+	assert.Contains(t, buf.String(), `"name":"StatObject"`)
+}
+
+func TestTimeline_Disabled(t *testing.T) {
+	timeline.Disable()
+	c, _ := serve(t, Mount{Admin: &fakeAdmin{}})
+
+	err := c.Timeline(context.Background(), &bytes.Buffer{})
+
+	assert.ErrorContains(t, err, timeline.ErrDisabled.Error())
+}
+
+func TestUnmount(t *testing.T) {
+	admin := &fakeAdmin{}
+	var unmounted bool
+	c, _ := serve(t, Mount{Admin: admin, Unmount: func() error {
+		unmounted = true
+		return nil
+	}})
+
+	err := c.Unmount(context.Background())
+
+	require.NoError(t, err)
+	assert.True(t, admin.drained)
+	assert.True(t, unmounted)
+}
+
+func TestUnmount_DrainFailure(t *testing.T) {
+	admin := &fakeAdmin{drainErr: errors.New("taco")}
+	var unmounted bool
+	c, _ := serve(t, Mount{Admin: admin, Unmount: func() error {
+		unmounted = true
+		return nil
+	}})
+
+	err := c.Unmount(context.Background())
+
+	assert.ErrorContains(t, err, "drain: taco")
+	assert.False(t, unmounted)
+}
@@ -0,0 +1,39 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package control
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerCheckingListener_RefusesOtherUsers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	l, err := listenPrivate(path)
+	require.NoError(t, err)
+	server := &http.Server{Handler: newHandler(Mount{Admin: &fakeAdmin{}})}
+	go server.Serve(&peerCheckingListener{UnixListener: l, uid: os.Getuid() + 1})
+	t.Cleanup(func() { server.Close() })
+
+	_, err = NewClient(path).Stats(context.Background())
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package control serves the admin API of a mounted file system over a Unix
+// socket, and is the client that "gcsfuse ctl" uses to call it.
+//
+// The API is HTTP with JSON bodies:
+//
+//	GET  /v1/config                   the effective config, as YAML
+//	POST /v1/reload                   reload the config file and apply it
+//	GET  /v1/stats                    counts of inodes, handles and cache usage
+//	GET  /v1/top?n=10&by=ops          the callers and path prefixes with the most usage
+//	POST /v1/invalidate               drop cache entries for a path or prefix
+//	PUT  /v1/log-severity             change the log severity
+//	GET  /v1/pprof/{cpu,heap}         capture a profile
+//	GET  /v1/timeline                 the recorded timeline, as a Chrome trace
+//	POST /v1/unmount                  drain the file system and unmount it
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/perf"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// The CPU profile duration when the request doesn't give one.
+	defaultProfileDuration = 30 * time.Second
+
+	// How long Close waits for requests in progress to finish.
+	shutdownTimeout = 5 * time.Second
+
+	// The number of consumers reported when the request doesn't say.
+	defaultTopN = 10
+)
+
+// Mount is what the admin API administers.
+type Mount struct {
+	// The file system.
+	Admin fs.Admin
+
+	// Loads the config again and applies it to the file system.
+	Reload func() (fs.Reconfiguration, error)
+
+	// Unmounts the file system. Called after draining it.
+	Unmount func() error
+}
+
+// InvalidateRequest is the body of POST /v1/invalidate.
+type InvalidateRequest struct {
+	// The cache to drop entries from, or empty for all the enabled caches.
+	Cache fs.Cache `json:"cache,omitempty"`
+
+	// The path of the entry, relative to the root of the mount.
+	Path string `json:"path"`
+
+	// Whether to drop the entries for every path starting with Path.
+	Prefix bool `json:"prefix,omitempty"`
+}
+
+// LogSeverityRequest is the body of PUT /v1/log-severity.
+type LogSeverityRequest struct {
+	Severity string `json:"severity"`
+}
+
+// The body of error responses.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the admin API of a mount.
+type Server struct {
+	path   string
+	server *http.Server
+	done   chan struct{}
+}
+
+// Serve serves the admin API of m on a Unix socket at the given path, which
+// only the current user can connect to. A socket left behind at the path by an
+// earlier mount is replaced.
+func Serve(path string, m Mount) (*Server, error) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	l, err := listenPrivate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		path: path,
+		server: &http.Server{
+			Handler:           newHandler(m),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(&peerCheckingListener{UnixListener: l, uid: os.Getuid()}); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Control socket %s failed: %v", path, err)
+		}
+	}()
+
+	logger.Infof("Serving the admin API on %s", path)
+	return s, nil
+}
+
+// Listen on a Unix socket at the given path that only the current user can
+// connect to. The socket is created in a new directory that only the current
+// user can enter, and made private before it is moved into place, so that it
+// is never reachable with the permissions that the umask would give it.
+func listenPrivate(path string) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".ctl")
+	if err != nil {
+		return nil, fmt.Errorf("MkdirTemp: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	// Close removes the socket from where it ends up.
+	l.SetUnlinkOnClose(false)
+
+	// Connecting needs write access.
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("chmod: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("rename: %w", err)
+	}
+
+	return l, nil
+}
+
+// A listener that only accepts connections from processes running as the
+// given user, as a check on top of the permissions of the socket.
+type peerCheckingListener struct {
+	*net.UnixListener
+	uid int
+}
+
+func (l *peerCheckingListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkPeer(conn, l.uid); err != nil {
+			logger.Warnf("Refusing a connection to the control socket: %v", err)
+			conn.Close()
+			continue
+		}
+
+		return conn, nil
+	}
+}
+
+// Close stops serving, waiting a while for requests in progress to finish,
+// and removes the socket.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := s.server.Shutdown(ctx)
+	<-s.done
+	if removeErr := os.Remove(s.path); removeErr != nil && !os.IsNotExist(removeErr) {
+		err = errors.Join(err, removeErr)
+	}
+
+	return err
+}
+
+func newHandler(m Mount) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/config", func(w http.ResponseWriter, _ *http.Request) {
+		out, err := yaml.Marshal(m.Admin.Config())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(out)
+	})
+
+	mux.HandleFunc("POST /v1/reload", func(w http.ResponseWriter, _ *http.Request) {
+		logger.Infof("Reloading the config on request")
+		r, err := m.Reload()
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		writeJSON(w, r)
+	})
+
+	mux.HandleFunc("GET /v1/stats", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, m.Admin.Stats())
+	})
+
+	mux.HandleFunc("GET /v1/top", func(w http.ResponseWriter, r *http.Request) {
+		n := defaultTopN
+		if s := r.URL.Query().Get("n"); s != "" {
+			var err error
+			if n, err = strconv.Atoi(s); err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid n %q", s))
+				return
+			}
+		}
+
+		by, err := attribution.ParseMeasure(r.URL.Query().Get("by"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		report, err := m.Admin.TopConsumers(n, by)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		writeJSON(w, report)
+	})
+
+	mux.HandleFunc("POST /v1/invalidate", func(w http.ResponseWriter, r *http.Request) {
+		var req InvalidateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := invalidate(m.Admin, req); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		logger.Infof("Invalidated %s for %q (prefix: %t) on request", cacheNames(req.Cache), req.Path, req.Prefix)
+		writeJSON(w, struct{}{})
+	})
+
+	mux.HandleFunc("PUT /v1/log-severity", func(w http.ResponseWriter, r *http.Request) {
+		var req LogSeverityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := logger.SetLogSeverity(req.Severity); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		logger.Infof("Log severity set to %s on request", req.Severity)
+		writeJSON(w, struct{}{})
+	})
+
+	mux.HandleFunc("GET /v1/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		var write func() error
+		switch r.PathValue("profile") {
+		case "cpu":
+			duration := defaultProfileDuration
+			if s := r.URL.Query().Get("seconds"); s != "" {
+				seconds, err := strconv.Atoi(s)
+				if err != nil || seconds <= 0 {
+					writeError(w, http.StatusBadRequest, fmt.Errorf("invalid seconds %q", s))
+					return
+				}
+				duration = time.Duration(seconds) * time.Second
+			}
+			write = func() error { return perf.WriteCPUProfile(r.Context(), w, duration) }
+		case "heap":
+			write = func() error { return perf.WriteHeapProfile(w) }
+		default:
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown profile %q", r.PathValue("profile")))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := write(); err != nil {
+			// Nothing has been written if starting the profile failed.
+			writeError(w, http.StatusConflict, err)
+		}
+	})
+
+	mux.HandleFunc("GET /v1/timeline", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := timeline.WriteTrace(&buf); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(buf.Bytes())
+	})
+
+	mux.HandleFunc("POST /v1/unmount", func(w http.ResponseWriter, r *http.Request) {
+		logger.Infof("Draining and unmounting on request")
+		if err := m.Admin.Drain(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("drain: %w", err))
+			return
+		}
+
+		if err := m.Unmount(); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("unmount: %w", err))
+			return
+		}
+
+		writeJSON(w, struct{}{})
+	})
+
+	return mux
+}
+
+// The caches that InvalidateRequest.Cache stands for.
+func cacheNames(c fs.Cache) []fs.Cache {
+	if c == "" {
+		return []fs.Cache{fs.StatCache, fs.TypeCache, fs.FileCache}
+	}
+
+	return []fs.Cache{c}
+}
+
+func invalidate(admin fs.Admin, req InvalidateRequest) error {
+	for _, c := range cacheNames(req.Cache) {
+		switch c {
+		case fs.StatCache, fs.TypeCache, fs.FileCache:
+		default:
+			return &badRequestError{fmt.Errorf("unknown cache %q", c)}
+		}
+
+		err := admin.InvalidateCache(c, req.Path, req.Prefix)
+		if errors.Is(err, fs.ErrCacheDisabled) && req.Cache == "" {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type badRequestError struct {
+	error
+}
+
+func (e *badRequestError) Unwrap() error {
+	return e.error
+}
+
+func statusOf(err error) int {
+	var badRequest *badRequestError
+	switch {
+	case errors.As(err, &badRequest), errors.Is(err, fs.ErrCacheDisabled), errors.Is(err, fs.ErrAttributionDisabled),
+		errors.Is(err, timeline.ErrDisabled):
+		return http.StatusBadRequest
+	case errors.Is(err, syscall.ENOENT):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,507 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diskcrypt encrypts the object data that gcsfuse stages on the local
+// disk, i.e. file cache and temp files, so that it can't be read from the disk
+// by anyone but the process that wrote it.
+//
+// Data is encrypted with AES-256 in CTR mode under a key that is generated
+// when the Cipher is created and never leaves memory. CTR mode lets any byte
+// range be read or written on its own, so range reads and parallel downloads
+// keep working, and leaves file sizes unchanged. Each file gets its own random
+// IV, also held in memory, so data from one mount can't be read by the next.
+// Writes over data written before re-encrypt the chunks they touch under a new
+// version of their counters, and the IV is replaced when a file is recreated,
+// so that the same keystream never encrypts two different contents at an
+// offset.
+package diskcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// File is the subset of the methods of *os.File that are used on staged data.
+// Both *os.File and the encrypting files returned by Cipher implement it.
+type File interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Name() string
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+}
+
+// Cipher encrypts files with a key held only in memory. A nil *Cipher leaves
+// files unencrypted.
+//
+// Safe for concurrent access.
+type Cipher struct {
+	block cipher.Block
+
+	mu sync.Mutex
+
+	// The keystream state of the files at paths, for files that are opened
+	// more than once.
+	//
+	// GUARDED_BY(mu)
+	keys map[string]*fileKey
+}
+
+// NewCipher returns a cipher with a freshly generated key.
+func NewCipher() (*Cipher, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher: %w", err)
+	}
+
+	return &Cipher{block: block, keys: make(map[string]*fileKey)}, nil
+}
+
+// NewFile returns f encrypted with a fresh IV that isn't remembered, for files
+// that are only accessed through the returned File, e.g. anonymous temp files.
+// Ranges of f that are skipped over by writes or extended by Truncate read
+// back as zeroes. It returns f if c is nil.
+func (c *Cipher) NewFile(f *os.File) (File, error) {
+	if c == nil {
+		return f, nil
+	}
+
+	key, err := newFileKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedFile{f: f, block: c.block, key: key, fillHoles: true}, nil
+}
+
+// File returns f encrypted with the IV of its path, f.Name(), choosing one if
+// the path has none yet. Files at the same path share the IV until Remove is
+// called for it, so the file can be opened again to read what was written,
+// even concurrently. Unlike with NewFile, ranges that were never written read
+// back as garbage rather than zeroes. It returns f if c is nil.
+func (c *Cipher) File(f *os.File) (File, error) {
+	if c == nil {
+		return f, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[f.Name()]
+	if !ok {
+		var err error
+		if key, err = newFileKey(); err != nil {
+			return nil, err
+		}
+		c.keys[f.Name()] = key
+	}
+
+	return &encryptedFile{f: f, block: c.block, key: key}, nil
+}
+
+// Create is like File, for a file that the caller has just created or
+// truncated to empty, e.g. by opening it with O_TRUNC, to write it from
+// scratch. It gives the path a fresh IV, which is also used from then on by
+// the Files already opened at the path, as whatever they read was written
+// under it. It returns f if c is nil.
+func (c *Cipher) Create(f *os.File) (File, error) {
+	if c == nil {
+		return f, nil
+	}
+
+	iv, err := newIV()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[f.Name()]
+	if !ok {
+		key = &fileKey{}
+		c.keys[f.Name()] = key
+	}
+	key.reset(iv)
+
+	return &encryptedFile{f: f, block: c.block, key: key}, nil
+}
+
+// Remove forgets the IV of the given path. It must be called when the file at
+// the path is deleted, so that a new file there doesn't reuse the IV.
+func (c *Cipher) Remove(path string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.keys, path)
+}
+
+func newIV() ([]byte, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("generating IV: %w", err)
+	}
+
+	return iv, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+// fileKey
+////////////////////////////////////////////////////////////////////////
+
+// The size of the chunks that are re-encrypted as a whole when data in them is
+// overwritten.
+const versionChunkSize = 4 << 10
+
+// A range of bytes [start, end) of a file.
+type extent struct {
+	start, end int64
+}
+
+// A range of bytes of a file whose chunks have the same version.
+type segment struct {
+	off, n  int64
+	version uint64
+}
+
+// fileKey holds what the keystream of a file is derived from, shared by the
+// Files it was opened as.
+//
+// The counter for the block at index i of a chunk at version v is the IV plus
+// v<<64 plus i, as a 128-bit big-endian integer, so each version of a chunk has
+// its own keystream.
+//
+// Safe for concurrent access.
+type fileKey struct {
+	mu sync.Mutex
+
+	// GUARDED_BY(mu)
+	iv []byte
+
+	// The version of each chunk that has been overwritten, by chunk index.
+	// Other chunks are at version 0.
+	//
+	// GUARDED_BY(mu)
+	versions map[int64]uint64
+
+	// The ranges whose keystream has been used under the current IV, sorted
+	// and disjoint. They are kept when the file shrinks, since the keystream
+	// of the range cut off has been used all the same.
+	//
+	// GUARDED_BY(mu)
+	used []extent
+}
+
+// Return a fileKey with a fresh IV.
+func newFileKey() (*fileKey, error) {
+	iv, err := newIV()
+	if err != nil {
+		return nil, err
+	}
+
+	k := &fileKey{}
+	k.reset(iv)
+	return k, nil
+}
+
+// Start over with the given IV, for a file that is empty.
+func (k *fileKey) reset(iv []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.iv = iv
+	k.versions = nil
+	k.used = nil
+}
+
+// Split the n bytes at off into segments of consecutive chunks at the same
+// version.
+//
+// LOCKS_REQUIRED(k.mu)
+func (k *fileKey) segments(off, n int64) []segment {
+	if len(k.versions) == 0 {
+		return []segment{{off: off, n: n}}
+	}
+
+	var segs []segment
+	for end := off + n; off < end; {
+		chunk := off / versionChunkSize
+		next := min((chunk+1)*versionChunkSize, end)
+		v := k.versions[chunk]
+		if last := len(segs) - 1; last >= 0 && segs[last].version == v {
+			segs[last].n += next - off
+		} else {
+			segs = append(segs, segment{off: off, n: next - off, version: v})
+		}
+		off = next
+	}
+	return segs
+}
+
+// Does [start, end) overlap a range whose keystream has been used?
+//
+// LOCKS_REQUIRED(k.mu)
+func (k *fileKey) overlapsUsed(start, end int64) bool {
+	// The first extent that ends after start.
+	i := sort.Search(len(k.used), func(i int) bool { return k.used[i].end > start })
+	return i < len(k.used) && k.used[i].start < end
+}
+
+// Record that the keystream of [start, end) has been used.
+//
+// LOCKS_REQUIRED(k.mu)
+func (k *fileKey) use(start, end int64) {
+	// Merge with the extents that overlap or touch [start, end).
+	i := sort.Search(len(k.used), func(i int) bool { return k.used[i].end >= start })
+	j := i
+	for ; j < len(k.used) && k.used[j].start <= end; j++ {
+		start = min(start, k.used[j].start)
+		end = max(end, k.used[j].end)
+	}
+	k.used = slices.Replace(k.used, i, j, extent{start, end})
+}
+
+// Move the chunks holding [start, end) to their next version.
+//
+// LOCKS_REQUIRED(k.mu)
+func (k *fileKey) bump(start, end int64) {
+	if k.versions == nil {
+		k.versions = make(map[int64]uint64)
+	}
+	for chunk := start / versionChunkSize; chunk*versionChunkSize < end; chunk++ {
+		k.versions[chunk]++
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+// encryptedFile
+////////////////////////////////////////////////////////////////////////
+
+// The size of the chunks of zeroes written to fill holes.
+const zeroChunkSize = 1 << 20
+
+type encryptedFile struct {
+	f     *os.File
+	block cipher.Block
+	key   *fileKey
+
+	// If set, holes created by writes past the end of the file or by extending
+	// it are filled with encrypted zeroes. Not safe with concurrent writes.
+	fillHoles bool
+}
+
+// Encrypt or decrypt p in place, as the data at offset off of the file.
+func (ef *encryptedFile) xorAt(p []byte, off int64) {
+	ef.key.mu.Lock()
+	iv := ef.key.iv
+	segs := ef.key.segments(off, int64(len(p)))
+	ef.key.mu.Unlock()
+
+	ef.xorSegments(iv, segs, p, off)
+}
+
+// Encrypt or decrypt p in place, as the data at offset off of the file, which
+// is split into the given segments.
+func (ef *encryptedFile) xorSegments(iv []byte, segs []segment, p []byte, off int64) {
+	for _, s := range segs {
+		ef.xorVersion(iv, s.version, p[s.off-off:s.off-off+s.n], s.off)
+	}
+}
+
+// Encrypt or decrypt p in place, as the data at offset off of the file, in
+// chunks at the given version.
+func (ef *encryptedFile) xorVersion(iv []byte, version uint64, p []byte, off int64) {
+	hi := binary.BigEndian.Uint64(iv[:8])
+	lo := binary.BigEndian.Uint64(iv[8:])
+	lo, carry := bits.Add64(lo, uint64(off/aes.BlockSize), 0)
+	hi, _ = bits.Add64(hi, version, carry)
+
+	ctr := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(ctr[:8], hi)
+	binary.BigEndian.PutUint64(ctr[8:], lo)
+	stream := cipher.NewCTR(ef.block, ctr)
+
+	// Skip to off within its block.
+	if skip := off % aes.BlockSize; skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+
+	stream.XORKeyStream(p, p)
+}
+
+func (ef *encryptedFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := ef.f.ReadAt(p, off)
+	ef.xorAt(p[:n], off)
+	return n, err
+}
+
+func (ef *encryptedFile) WriteAt(p []byte, off int64) (int, error) {
+	if ef.fillHoles {
+		if err := ef.fillTo(off); err != nil {
+			return 0, err
+		}
+	}
+
+	return ef.writeAt(p, off)
+}
+
+// Encrypt and write p at off, re-encrypting the chunks it touches under their
+// next version if it overwrites data written before.
+func (ef *encryptedFile) writeAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	end := off + int64(len(p))
+	k := ef.key
+	k.mu.Lock()
+	if k.overlapsUsed(off, end) {
+		defer k.mu.Unlock()
+		return ef.overwriteAt(p, off)
+	}
+	k.use(off, end)
+	iv := k.iv
+	segs := k.segments(off, int64(len(p)))
+	k.mu.Unlock()
+
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	ef.xorSegments(iv, segs, buf, off)
+
+	return ef.f.WriteAt(buf, off)
+}
+
+// Write p at off over data written before. The data of the chunks that p
+// touches is decrypted, merged with p and encrypted again under the next
+// version of the chunks, so that no keystream encrypts two contents.
+//
+// LOCKS_REQUIRED(ef.key.mu)
+func (ef *encryptedFile) overwriteAt(p []byte, off int64) (int, error) {
+	k := ef.key
+	fi, err := ef.f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// Cover whole chunks, as far as the file has data.
+	end := off + int64(len(p))
+	start := min(off/versionChunkSize*versionChunkSize, fi.Size())
+	stop := max(end, min((end+versionChunkSize-1)/versionChunkSize*versionChunkSize, fi.Size()))
+	buf := make([]byte, stop-start)
+
+	n, err := ef.f.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	ef.xorSegments(k.iv, k.segments(start, int64(n)), buf[:n], start)
+	copy(buf[off-start:], p)
+
+	k.bump(start, stop)
+	k.use(start, stop)
+	ef.xorSegments(k.iv, k.segments(start, stop-start), buf, start)
+
+	written, err := ef.f.WriteAt(buf, start)
+	return int(min(max(int64(written)-(off-start), 0), int64(len(p)))), err
+}
+
+func (ef *encryptedFile) Read(p []byte) (int, error) {
+	off, err := ef.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := ef.f.Read(p)
+	ef.xorAt(p[:n], off)
+	return n, err
+}
+
+func (ef *encryptedFile) Write(p []byte) (int, error) {
+	off, err := ef.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := ef.WriteAt(p, off)
+	if _, seekErr := ef.f.Seek(off+int64(n), io.SeekStart); err == nil {
+		err = seekErr
+	}
+
+	return n, err
+}
+
+func (ef *encryptedFile) Seek(offset int64, whence int) (int64, error) {
+	return ef.f.Seek(offset, whence)
+}
+
+func (ef *encryptedFile) Truncate(size int64) error {
+	if ef.fillHoles {
+		if err := ef.fillTo(size); err != nil {
+			return err
+		}
+	}
+
+	return ef.f.Truncate(size)
+}
+
+func (ef *encryptedFile) Close() error {
+	return ef.f.Close()
+}
+
+func (ef *encryptedFile) Name() string {
+	return ef.f.Name()
+}
+
+func (ef *encryptedFile) Stat() (os.FileInfo, error) {
+	return ef.f.Stat()
+}
+
+// Extend the file to size with encrypted zeroes, if it is smaller.
+func (ef *encryptedFile) fillTo(size int64) error {
+	fi, err := ef.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	for off := fi.Size(); off < size; {
+		n, err := ef.writeAt(make([]byte, min(zeroChunkSize, size-off)), off)
+		if err != nil {
+			return err
+		}
+		off += int64(n)
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskcrypt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createFile(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(path.Join(t.TempDir(), name), os.O_CREATE|os.O_RDWR, 0600)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func newCipher(t *testing.T) *Cipher {
+	t.Helper()
+	c, err := NewCipher()
+	require.NoError(t, err)
+	return c
+}
+
+func TestNilCipherReturnsFile(t *testing.T) {
+	var c *Cipher
+	f := createFile(t, "foo")
+
+	got, err := c.File(f)
+
+	require.NoError(t, err)
+	assert.Same(t, f, got)
+	got, err = c.NewFile(f)
+	require.NoError(t, err)
+	assert.Same(t, f, got)
+	c.Remove(f.Name())
+}
+
+func TestDataOnDiskIsEncrypted(t *testing.T) {
+	plain := bytes.Repeat([]byte("taco"), 1000)
+	f := createFile(t, "foo")
+	ef, err := newCipher(t).File(f)
+	require.NoError(t, err)
+
+	_, err = ef.WriteAt(plain, 0)
+
+	require.NoError(t, err)
+	onDisk, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Len(t, onDisk, len(plain))
+	assert.NotEqual(t, plain, onDisk)
+	got := make([]byte, len(plain))
+	_, err = ef.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, plain, got)
+}
+
+func TestUnalignedRangesRoundTrip(t *testing.T) {
+	plain := make([]byte, 5000)
+	for i := range plain {
+		plain[i] = byte(i * 7)
+	}
+	ef, err := newCipher(t).File(createFile(t, "foo"))
+	require.NoError(t, err)
+
+	// Write out of order, in ranges that don't line up with AES blocks.
+	for _, r := range [][2]int{{4093, 5000}, {0, 17}, {17, 4093}} {
+		_, err := ef.WriteAt(plain[r[0]:r[1]], int64(r[0]))
+		require.NoError(t, err)
+	}
+
+	for _, r := range [][2]int{{0, 5000}, {1, 2}, {15, 33}, {4000, 5000}} {
+		got := make([]byte, r[1]-r[0])
+		_, err := ef.ReadAt(got, int64(r[0]))
+		require.NoError(t, err)
+		assert.Equal(t, plain[r[0]:r[1]], got, "range %v", r)
+	}
+}
+
+func TestSequentialReadAndWrite(t *testing.T) {
+	ef, err := newCipher(t).NewFile(createFile(t, "foo"))
+	require.NoError(t, err)
+
+	_, err = ef.Write([]byte("burrito "))
+	require.NoError(t, err)
+	_, err = io.Copy(ef, bytes.NewReader([]byte("and taco")))
+	require.NoError(t, err)
+	_, err = ef.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(ef)
+	require.NoError(t, err)
+	assert.Equal(t, "burrito and taco", string(got))
+}
+
+func TestFileSharesIVUntilRemoved(t *testing.T) {
+	c := newCipher(t)
+	f := createFile(t, "foo")
+	w, err := c.File(f)
+	require.NoError(t, err)
+	_, err = w.WriteAt([]byte("taco"), 0)
+	require.NoError(t, err)
+
+	// Opened again at the same path, the file reads back.
+	f2, err := os.Open(f.Name())
+	require.NoError(t, err)
+	defer f2.Close()
+	r, err := c.File(f2)
+	require.NoError(t, err)
+	got := make([]byte, 4)
+	_, err = r.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "taco", string(got))
+
+	// After Remove, the path gets a new IV.
+	c.Remove(f.Name())
+	r, err = c.File(f2)
+	require.NoError(t, err)
+	_, err = r.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, "taco", string(got))
+}
+
+func TestCreateGivesPathFreshIV(t *testing.T) {
+	c := newCipher(t)
+	f := createFile(t, "foo")
+	r, err := c.File(f)
+	require.NoError(t, err)
+	_, err = r.WriteAt([]byte("taco"), 0)
+	require.NoError(t, err)
+	before, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	// Recreate the file and write the same data.
+	f2, err := os.OpenFile(f.Name(), os.O_TRUNC|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f2.Close()
+	w, err := c.Create(f2)
+	require.NoError(t, err)
+	_, err = w.WriteAt([]byte("taco"), 0)
+	require.NoError(t, err)
+
+	// The keystream isn't reused, and the file opened before reads the new
+	// data back.
+	after, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+	got := make([]byte, 4)
+	_, err = r.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "taco", string(got))
+}
+
+func TestWriteAfterTruncateDoesNotReuseKeystream(t *testing.T) {
+	f := createFile(t, "foo")
+	ef, err := newCipher(t).NewFile(f)
+	require.NoError(t, err)
+	_, err = ef.WriteAt([]byte("tacoburrito"), 0)
+	require.NoError(t, err)
+	before, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	// Cut off "burrito" and write it again.
+	require.NoError(t, ef.Truncate(4))
+	_, err = ef.WriteAt([]byte("burrito"), 4)
+	require.NoError(t, err)
+
+	after, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.NotEqual(t, before[4:], after[4:])
+	got := make([]byte, 11)
+	_, err = ef.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "tacoburrito", string(got))
+}
+
+func TestOverwriteDoesNotReuseKeystream(t *testing.T) {
+	f := createFile(t, "foo")
+	ef, err := newCipher(t).File(f)
+	require.NoError(t, err)
+	data := bytes.Repeat([]byte("taco"), 3000)
+	_, err = ef.WriteAt(data, 0)
+	require.NoError(t, err)
+	before, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+
+	// Overwrite a range that straddles two chunks with the same data.
+	_, err = ef.WriteAt(data[4000:5000], 4000)
+	require.NoError(t, err)
+
+	after, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.NotEqual(t, before[4000:5000], after[4000:5000])
+	got := make([]byte, len(data))
+	_, err = ef.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestRepeatedOverwritesRoundTrip(t *testing.T) {
+	c := newCipher(t)
+	f := createFile(t, "foo")
+	w, err := c.File(f)
+	require.NoError(t, err)
+	r, err := c.File(f)
+	require.NoError(t, err)
+	_, err = w.WriteAt(make([]byte, 10000), 0)
+	require.NoError(t, err)
+
+	// The last write also extends the file.
+	want := make([]byte, 10500)
+	for i, off := range []int{0, 4090, 100, 8191, 9000} {
+		p := bytes.Repeat([]byte{byte('a' + i)}, 1500)
+		n, err := w.WriteAt(p, int64(off))
+		require.NoError(t, err)
+		assert.Equal(t, len(p), n)
+		copy(want[off:], p)
+	}
+
+	got := make([]byte, 10500)
+	_, err = r.ReadAt(got, 0)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestNilCipherCreateReturnsFile(t *testing.T) {
+	var c *Cipher
+	f := createFile(t, "foo")
+
+	got, err := c.Create(f)
+
+	require.NoError(t, err)
+	assert.Same(t, f, got)
+}
+
+func TestNewFileHolesReadAsZeroes(t *testing.T) {
+	ef, err := newCipher(t).NewFile(createFile(t, "foo"))
+	require.NoError(t, err)
+
+	_, err = ef.WriteAt([]byte("taco"), 10)
+	require.NoError(t, err)
+	require.NoError(t, ef.Truncate(20))
+
+	got := make([]byte, 20)
+	_, err = ef.ReadAt(got, 0)
+	require.NoError(t, err)
+	want := make([]byte, 20)
+	copy(want[10:], "taco")
+	assert.Equal(t, want, got)
+
+	require.NoError(t, ef.Truncate(12))
+	fi, err := ef.Stat()
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, fi.Size())
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for the access policy rules applying to whole directories and to
+// renames.
+
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	. "github.com/jacobsa/ogletest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccessPolicyTest struct {
+	fsTest
+}
+
+func init() {
+	RegisterTestSuite(&AccessPolicyTest{})
+}
+
+func (t *AccessPolicyTest) SetUpTestSuite() {
+	t.serverCfg.NewConfig = &cfg.Config{
+		FileCache: defaultFileCacheConfig(),
+		MetadataCache: cfg.MetadataCacheConfig{
+			StatCacheMaxSizeMb: 32,
+			TtlSecs:            60,
+			TypeCacheMaxSizeMb: 4,
+		},
+		AccessPolicy: cfg.AccessPolicyConfig{
+			DenyDelete:     []string{"data/protected.txt"},
+			WriteOncePaths: []string{"out/**"},
+		},
+	}
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *AccessPolicyTest) RenameParentOfProtectedFile() {
+	AssertEq(
+		nil,
+		t.createObjects(
+			map[string]string{
+				"data/":              "",
+				"data/protected.txt": "taco",
+			}))
+
+	err := os.Rename(path.Join(mntDir, "data"), path.Join(mntDir, "moved"))
+
+	ExpectTrue(errors.Is(err, syscall.EACCES), "err: %v", err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "data/protected.txt")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *AccessPolicyTest) RemoveEmptyParentOfProtectedFile() {
+	AssertEq(nil, t.createObjects(map[string]string{"data/": ""}))
+
+	err := os.Remove(path.Join(mntDir, "data"))
+
+	AssertEq(nil, err)
+	_, err = storageutil.ReadObject(ctx, bucket, "data/")
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr), "err: %v", err)
+}
+
+func (t *AccessPolicyTest) RenameEmptyParentOfProtectedFile() {
+	AssertEq(nil, t.createObjects(map[string]string{"data/": ""}))
+
+	err := os.Rename(path.Join(mntDir, "data"), path.Join(mntDir, "moved"))
+
+	AssertEq(nil, err)
+}
+
+func (t *AccessPolicyTest) RenameUnprotectedDir() {
+	AssertEq(
+		nil,
+		t.createObjects(
+			map[string]string{
+				"other/":    "",
+				"other/foo": "taco",
+			}))
+
+	err := os.Rename(path.Join(mntDir, "other"), path.Join(mntDir, "moved"))
+
+	AssertEq(nil, err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "moved/foo")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *AccessPolicyTest) RenameIntoWriteOnceDir() {
+	AssertEq(
+		nil,
+		t.createObjects(
+			map[string]string{
+				"out/":    "",
+				"foo.txt": "taco",
+			}))
+
+	err := os.Rename(path.Join(mntDir, "foo.txt"), path.Join(mntDir, "out/foo.txt"))
+
+	AssertEq(nil, err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "out/foo.txt")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *AccessPolicyTest) RenameOverWriteOnceFile() {
+	AssertEq(
+		nil,
+		t.createObjects(
+			map[string]string{
+				"out/":        "",
+				"out/foo.txt": "taco",
+				"foo.txt":     "burrito",
+			}))
+
+	err := os.Rename(path.Join(mntDir, "foo.txt"), path.Join(mntDir, "out/foo.txt"))
+
+	ExpectTrue(errors.Is(err, syscall.EPERM), "err: %v", err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "out/foo.txt")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+////////////////////////////////////////////////////////////////////////
+// Hierarchical bucket
+////////////////////////////////////////////////////////////////////////
+
+type HNSAccessPolicyTests struct {
+	suite.Suite
+	fsTest
+}
+
+func TestHNSAccessPolicyTests(t *testing.T) { suite.Run(t, new(HNSAccessPolicyTests)) }
+
+func (t *HNSAccessPolicyTests) SetupSuite() {
+	t.serverCfg.ImplicitDirectories = false
+	t.serverCfg.NewConfig = &cfg.Config{
+		EnableHns: true,
+		AccessPolicy: cfg.AccessPolicyConfig{
+			// Rules starting with "**" apply below every directory.
+			Hidden:     []string{"**/.secret*"},
+			DenyDelete: []string{"**/protected.txt"},
+		},
+	}
+	t.serverCfg.MetricHandle = common.NewNoopMetrics()
+	bucketType = gcs.BucketType{Hierarchical: true}
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *HNSAccessPolicyTests) TearDownSuite() {
+	t.fsTest.TearDownTestSuite()
+}
+
+func (t *HNSAccessPolicyTests) TearDownTest() {
+	t.fsTest.TearDown()
+}
+
+func (t *HNSAccessPolicyTests) TestRenameNonEmptyFolder() {
+	require.NoError(t.T(), t.createFolders([]string{"foo/", "foo/sub/"}))
+	require.NoError(t.T(), t.createObjects(map[string]string{
+		"foo/a.txt":     "taco",
+		"foo/sub/b.txt": "burrito",
+	}))
+
+	err := os.Rename(path.Join(mntDir, "foo"), path.Join(mntDir, "moved"))
+
+	require.NoError(t.T(), err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "moved/sub/b.txt")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", string(contents))
+}
+
+func (t *HNSAccessPolicyTests) TestRenameFolderHoldingProtectedFile() {
+	require.NoError(t.T(), t.createFolders([]string{"data/", "data/sub/"}))
+	require.NoError(t.T(), t.createObjects(map[string]string{
+		"data/a.txt":             "taco",
+		"data/sub/protected.txt": "burrito",
+	}))
+
+	err := os.Rename(path.Join(mntDir, "data"), path.Join(mntDir, "moved"))
+
+	assert.ErrorIs(t.T(), err, syscall.EACCES)
+	contents, err := storageutil.ReadObject(ctx, bucket, "data/sub/protected.txt")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "burrito", string(contents))
+}
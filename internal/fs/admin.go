@@ -0,0 +1,359 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/inode"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+)
+
+// Cache names one of the caches of a file system.
+type Cache string
+
+const (
+	// StatCache caches the attributes of objects and folders.
+	StatCache Cache = "stat"
+	// TypeCache caches whether names in a directory are files or directories.
+	TypeCache Cache = "type"
+	// FileCache caches the contents of objects on the local disk.
+	FileCache Cache = "file"
+)
+
+// ErrCacheDisabled is returned when invalidating a cache that's disabled.
+var ErrCacheDisabled = errors.New("the cache is disabled")
+
+// ErrAttributionDisabled is returned when asking for the top consumers of a
+// file system that doesn't attribute its usage.
+var ErrAttributionDisabled = errors.New("attribution is disabled")
+
+// Stats are counts of the state held by a file system.
+type Stats struct {
+	// The number of inodes the kernel holds references to.
+	Inodes int `json:"inodes"`
+	// The number of open file and directory handles.
+	Handles int `json:"handles"`
+	// The number of files that haven't been uploaded to GCS yet.
+	LocalFiles int `json:"localFiles"`
+	// The size of the contents in the file cache, and the maximum it may reach.
+	// Both are zero if the file cache is disabled.
+	FileCacheBytes         uint64 `json:"fileCacheBytes"`
+	FileCacheCapacityBytes uint64 `json:"fileCacheCapacityBytes"`
+}
+
+// Reconfiguration reports what Reconfigure made of a new config, by the config
+// paths of the fields that differ, e.g. "metadata-cache.ttl-secs".
+type Reconfiguration struct {
+	// The fields that were changed.
+	Applied []string `json:"applied"`
+	// The fields that can't change without remounting.
+	Rejected []string `json:"rejected"`
+}
+
+// Admin administers a running file system, e.g. for the control socket.
+// NewFileSystem returns a file system that implements it.
+//
+// Paths are relative to the root of the mount, so with a dynamic mount their
+// first component is the bucket's name.
+type Admin interface {
+	// Stats returns counts of the state held by the file system.
+	Stats() Stats
+
+	// InvalidateCache drops the entries for the given path from the given
+	// cache, or with prefix set, those for every path starting with it.
+	InvalidateCache(cache Cache, path string, prefix bool) error
+
+	// Drain uploads the contents of every file with unsynced writes to GCS.
+	Drain(ctx context.Context) error
+
+	// Config returns the effective config, which Reconfigure changes.
+	Config() *cfg.Config
+
+	// Reconfigure changes the TTLs, rate limits, log severity and file cache
+	// size to those of the given config, which must have been validated and
+	// rationalized. The other fields that differ from the effective config are
+	// rejected. On error, the fields applied before it are still reported.
+	Reconfigure(c *cfg.Config) (Reconfiguration, error)
+
+	// TopConsumers returns the n callers and path prefixes with the most usage
+	// by the given measure, or all of them if n isn't positive.
+	TopConsumers(n int, by attribution.Measure) (attribution.Report, error)
+}
+
+var _ Admin = &fileSystem{}
+
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) Stats() (s Stats) {
+	fs.mu.Lock()
+	s.Inodes = len(fs.inodes)
+	s.Handles = len(fs.handles)
+	s.LocalFiles = len(fs.localFileInodes)
+	fs.mu.Unlock()
+
+	if fs.fileCacheHandler != nil {
+		s.FileCacheBytes, s.FileCacheCapacityBytes = fs.fileCacheHandler.Usage()
+	}
+
+	return
+}
+
+func (fs *fileSystem) TopConsumers(n int, by attribution.Measure) (attribution.Report, error) {
+	if fs.attribution == nil {
+		return attribution.Report{}, ErrAttributionDisabled
+	}
+	return fs.attribution.Top(n, by), nil
+}
+
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) InvalidateCache(cache Cache, path string, prefix bool) error {
+	path = strings.TrimPrefix(path, "/")
+
+	switch cache {
+	case TypeCache:
+		fs.invalidateTypeCache(path, prefix)
+		return nil
+
+	case StatCache, FileCache:
+		root := fs.bucketRootFor(path)
+		if root == nil {
+			return fmt.Errorf("no bucket is mounted at %q: %w", path, syscall.ENOENT)
+		}
+		objectName := strings.TrimPrefix(path, root.Name().LocalName())
+		bucket := root.Bucket()
+
+		if cache == StatCache {
+			bucket.StatCache.Invalidate(objectName, prefix)
+			if !prefix {
+				bucket.StatCache.Invalidate(objectName+"/", false)
+			}
+			return nil
+		}
+
+		if fs.fileCacheHandler == nil {
+			return fmt.Errorf("%s: %w", cache, ErrCacheDisabled)
+		}
+		if prefix {
+			return fs.fileCacheHandler.InvalidateCacheWithPrefix(objectName, bucket.Name())
+		}
+		return fs.fileCacheHandler.InvalidateCache(objectName, bucket.Name())
+
+	default:
+		return fmt.Errorf("unknown cache %q", cache)
+	}
+}
+
+// Clear the type caches of the loaded directories that may hold an entry for
+// the given path, or with prefix set, for any path starting with it.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) invalidateTypeCache(path string, prefix bool) {
+	// The entry for a path is held by its parent directory.
+	parent := path[:strings.LastIndex(strings.TrimSuffix(path, "/"), "/")+1]
+
+	for _, d := range fs.loadedDirInodes() {
+		name := d.Name().LocalName()
+		if name != parent && !(prefix && strings.HasPrefix(name, path)) {
+			continue
+		}
+
+		d.Lock()
+		d.ClearTypeCache()
+		d.Unlock()
+	}
+}
+
+// Return the root directory of the bucket that the given path is in, or nil
+// if it's not in a mounted bucket.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) bucketRootFor(path string) inode.BucketOwnedDirInode {
+	for _, d := range fs.loadedDirInodes() {
+		root, ok := d.(inode.BucketOwnedDirInode)
+		if !ok || !root.Name().IsBucketRoot() {
+			continue
+		}
+
+		if name := root.Name().LocalName(); strings.HasPrefix(path+"/", name) {
+			return root
+		}
+	}
+
+	return nil
+}
+
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) loadedDirInodes() (dirs []inode.DirInode) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, in := range fs.inodes {
+		if d, ok := in.(inode.DirInode); ok {
+			dirs = append(dirs, d)
+		}
+	}
+
+	return
+}
+
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) Drain(ctx context.Context) error {
+	var files []*inode.FileInode
+	fs.mu.Lock()
+	for _, in := range fs.inodes {
+		if f, ok := in.(*inode.FileInode); ok {
+			files = append(files, f)
+		}
+	}
+	fs.mu.Unlock()
+
+	var err error
+	for _, f := range files {
+		f.Lock()
+		if syncErr := fs.syncFile(ctx, f); syncErr != nil {
+			err = errors.Join(err, fmt.Errorf("%s: %w", f.Name(), syncErr))
+		}
+		f.Unlock()
+	}
+
+	return err
+}
+
+// LOCKS_EXCLUDED(fs.reconfigureMu)
+func (fs *fileSystem) Config() *cfg.Config {
+	fs.reconfigureMu.Lock()
+	defer fs.reconfigureMu.Unlock()
+
+	return fs.config
+}
+
+// LOCKS_EXCLUDED(fs.reconfigureMu)
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) Reconfigure(c *cfg.Config) (r Reconfiguration, err error) {
+	fs.reconfigureMu.Lock()
+	defer fs.reconfigureMu.Unlock()
+
+	// Build up the new effective config, leaving the old one as is for those
+	// still reading it.
+	effective := *fs.config
+	for _, path := range cfg.Diff(fs.config, c) {
+		applyErr := fs.applyConfigField(path, c, &effective)
+		switch {
+		case errors.Is(applyErr, gcsx.ErrRemountRequired):
+			r.Rejected = append(r.Rejected, path)
+		case applyErr != nil:
+			err = fmt.Errorf("%s: %w", path, applyErr)
+			fs.config = &effective
+			return
+		default:
+			r.Applied = append(r.Applied, path)
+		}
+	}
+
+	fs.config = &effective
+	return
+}
+
+// Apply the field of c with the given config path, and copy it into
+// effective. Returns gcsx.ErrRemountRequired if it can't change while mounted.
+//
+// LOCKS_REQUIRED(fs.reconfigureMu)
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) applyConfigField(path string, c *cfg.Config, effective *cfg.Config) error {
+	switch path {
+	case "metadata-cache.ttl-secs":
+		// Without a TTL at mount, the stat and type caches weren't set up.
+		if fs.newConfig.MetadataCache.TtlSecs == 0 {
+			return gcsx.ErrRemountRequired
+		}
+
+		ttl := time.Duration(c.MetadataCache.TtlSecs) * time.Second
+		negativeTTL := time.Duration(effective.MetadataCache.NegativeTtlSecs) * time.Second
+		// The stat cache may still be off because of its size.
+		if err := fs.bucketManager.SetStatCacheTTLs(ttl, negativeTTL); err != nil && !errors.Is(err, gcsx.ErrRemountRequired) {
+			return err
+		}
+
+		fs.inodeAttributeCacheTTL.Store(int64(ttl))
+		// Directories minted with a zero TTL wouldn't have a type cache, for a
+		// later change to set the TTL of. Entries expire at once with either.
+		fs.dirTypeCacheTTL.Store(int64(max(ttl, time.Nanosecond)))
+		for _, d := range fs.loadedDirInodes() {
+			d.Lock()
+			d.SetTypeCacheTTL(ttl)
+			d.Unlock()
+		}
+		effective.MetadataCache.TtlSecs = c.MetadataCache.TtlSecs
+
+	case "metadata-cache.negative-ttl-secs":
+		ttl := time.Duration(effective.MetadataCache.TtlSecs) * time.Second
+		negativeTTL := time.Duration(c.MetadataCache.NegativeTtlSecs) * time.Second
+		if err := fs.bucketManager.SetStatCacheTTLs(ttl, negativeTTL); err != nil {
+			return err
+		}
+		effective.MetadataCache.NegativeTtlSecs = c.MetadataCache.NegativeTtlSecs
+
+	case "file-system.kernel-list-cache-ttl-secs":
+		fs.kernelListCacheTTL.Store(int64(cfg.ListCacheTTLSecsToDuration(c.FileSystem.KernelListCacheTtlSecs)))
+		effective.FileSystem.KernelListCacheTtlSecs = c.FileSystem.KernelListCacheTtlSecs
+
+	case "gcs-connection.limit-ops-per-sec", "gcs-connection.limit-bytes-per-sec":
+		limits := effective.GcsConnection
+		if path == "gcs-connection.limit-ops-per-sec" {
+			limits.LimitOpsPerSec = c.GcsConnection.LimitOpsPerSec
+		} else {
+			limits.LimitBytesPerSec = c.GcsConnection.LimitBytesPerSec
+		}
+		if err := fs.bucketManager.SetRateLimits(limits.LimitOpsPerSec, limits.LimitBytesPerSec); err != nil {
+			return err
+		}
+		effective.GcsConnection.LimitOpsPerSec = limits.LimitOpsPerSec
+		effective.GcsConnection.LimitBytesPerSec = limits.LimitBytesPerSec
+
+	case "logging.severity":
+		if err := logger.SetLogSeverity(string(c.Logging.Severity)); err != nil {
+			return err
+		}
+		effective.Logging.Severity = c.Logging.Severity
+
+	case "logging.component-severity":
+		if err := logger.SetComponentSeverities(c.Logging.ComponentSeverity); err != nil {
+			return err
+		}
+		effective.Logging.ComponentSeverity = c.Logging.ComponentSeverity
+
+	case "file-cache.max-size-mb":
+		// The file cache can't be turned on or off.
+		if fs.fileCacheHandler == nil || c.FileCache.MaxSizeMb == 0 {
+			return gcsx.ErrRemountRequired
+		}
+		// Failing to clean up evicted files doesn't undo the change.
+		effective.FileCache.MaxSizeMb = c.FileCache.MaxSizeMb
+		return fs.fileCacheHandler.SetCapacity(fileCacheCapacity(c.FileCache.MaxSizeMb))
+
+	default:
+		return gcsx.ErrRemountRequired
+	}
+
+	return nil
+}
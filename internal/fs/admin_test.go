@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Create a file system serving a fake bucket, without mounting it.
+func newAdminTestFileSystem(t *testing.T) (fs.Admin, *fuseDriver, gcs.Bucket) {
+	t.Helper()
+	admin, driver, bucket, _ := newAdminTestFileSystemWithConfig(t, defaultModelConfig())
+	return admin, driver, bucket
+}
+
+// Like newAdminTestFileSystem, with the given config, also returning the
+// bucket manager.
+func newAdminTestFileSystemWithConfig(t *testing.T, c *cfg.Config) (fs.Admin, *fuseDriver, gcs.Bucket, *fakeBucketManager) {
+	t.Helper()
+	clock := &timeutil.SimulatedClock{}
+	clock.SetTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bucket := fake.NewFakeBucket(timeutil.RealClock(), "admin_bucket", gcs.BucketType{})
+	bm := &fakeBucketManager{
+		buckets:                  map[string]gcs.Bucket{bucket.Name(): bucket},
+		chunkTransferTimeoutSecs: 10,
+		tmpObjectPrefix:          ".gcsfuse_tmp/",
+	}
+	serverCfg := &fs.ServerConfig{
+		CacheClock:           clock,
+		BucketManager:        bm,
+		BucketName:           bucket.Name(),
+		TempDir:              t.TempDir(),
+		RenameDirLimit:       1000,
+		SequentialReadSizeMb: SequentialReadSizeMb,
+		FilePerms:            filePerms,
+		DirPerms:             dirPerms,
+		NewConfig:            c,
+		MetricHandle:         common.NewNoopMetrics(),
+	}
+
+	server, err := fs.NewFileSystem(context.Background(), serverCfg)
+	require.NoError(t, err)
+	t.Cleanup(server.Destroy)
+
+	return server.(fs.Admin), newFuseDriver(server), bucket, bm
+}
+
+func TestAdmin_Stats(t *testing.T) {
+	ctx := context.Background()
+	admin, driver, bucket := newAdminTestFileSystem(t)
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	require.NoError(t, err)
+	require.Zero(t, driver.open(ctx, 0, "foo"))
+	require.Zero(t, driver.create(ctx, "bar", nil))
+
+	stats := admin.Stats()
+
+	// The root and the two files, of which only foo is open.
+	assert.Equal(t, 3, stats.Inodes)
+	assert.Equal(t, 1, stats.Handles)
+	assert.Zero(t, stats.FileCacheCapacityBytes)
+}
+
+func TestAdmin_DrainUploadsUnsyncedWrites(t *testing.T) {
+	ctx := context.Background()
+	admin, driver, bucket := newAdminTestFileSystem(t)
+	op := &fuseops.CreateFileOp{Parent: fuseops.RootInodeID, Name: "foo", Mode: filePerms}
+	require.NoError(t, driver.fs.CreateFile(ctx, op))
+	require.NoError(t, driver.fs.WriteFile(ctx, &fuseops.WriteFileOp{Inode: op.Entry.Child, Handle: op.Handle, Data: []byte("taco")}))
+	require.Equal(t, 1, admin.Stats().LocalFiles)
+
+	err := admin.Drain(ctx)
+
+	require.NoError(t, err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "taco", string(contents))
+	assert.Zero(t, admin.Stats().LocalFiles)
+}
+
+func TestAdmin_InvalidateCache(t *testing.T) {
+	admin, _, _ := newAdminTestFileSystem(t)
+
+	assert.NoError(t, admin.InvalidateCache(fs.StatCache, "foo", false))
+	assert.NoError(t, admin.InvalidateCache(fs.TypeCache, "dir/", true))
+	assert.ErrorIs(t, admin.InvalidateCache(fs.FileCache, "foo", false), fs.ErrCacheDisabled)
+	assert.Error(t, admin.InvalidateCache("bogus", "foo", false))
+}
+
+func TestAdmin_TopConsumersDisabled(t *testing.T) {
+	admin, _, _ := newAdminTestFileSystem(t)
+
+	_, err := admin.TopConsumers(10, attribution.ByOps)
+
+	assert.ErrorIs(t, err, fs.ErrAttributionDisabled)
+}
+
+func TestAdmin_Reconfigure(t *testing.T) {
+	mountCfg := defaultModelConfig()
+	mountCfg.CacheDir = cfg.ResolvedPath(t.TempDir())
+	mountCfg.MetadataCache.TtlSecs = 60
+	mountCfg.GcsConnection.LimitOpsPerSec = 10
+	admin, _, _, bm := newAdminTestFileSystemWithConfig(t, mountCfg)
+	c := *mountCfg
+	c.FileCache.MaxSizeMb = 10
+	c.FileSystem.KernelListCacheTtlSecs = 5
+	c.GcsConnection.LimitOpsPerSec = 100
+	c.GcsConnection.LimitBytesPerSec = 1000
+	c.ImplicitDirs = true
+	c.MetadataCache.NegativeTtlSecs = 15
+	c.MetadataCache.TtlSecs = 30
+
+	r, err := admin.Reconfigure(&c)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"file-cache.max-size-mb",
+		"file-system.kernel-list-cache-ttl-secs",
+		"gcs-connection.limit-bytes-per-sec",
+		"gcs-connection.limit-ops-per-sec",
+		"metadata-cache.negative-ttl-secs",
+		"metadata-cache.ttl-secs",
+	}, r.Applied)
+	assert.Equal(t, []string{"implicit-dirs"}, r.Rejected)
+	assert.Equal(t, 100.0, bm.opRateLimitHz)
+	assert.Equal(t, 1000.0, bm.egressBandwidthLimit)
+	assert.Equal(t, 30*time.Second, bm.statCacheTTL)
+	assert.Equal(t, 15*time.Second, bm.negativeStatCacheTTL)
+	assert.Equal(t, uint64(10<<20), admin.Stats().FileCacheCapacityBytes)
+	// The effective config has all but the rejected changes.
+	want := c
+	want.ImplicitDirs = false
+	assert.Equal(t, &want, admin.Config())
+	assert.Equal(t, int64(60), mountCfg.MetadataCache.TtlSecs)
+}
+
+func TestAdmin_ReconfigureComponentSeverity(t *testing.T) {
+	admin, _, _, _ := newAdminTestFileSystemWithConfig(t, defaultModelConfig())
+	t.Cleanup(func() { _ = logger.SetComponentSeverities(nil) })
+	c := *defaultModelConfig()
+	c.Logging.ComponentSeverity = []string{"gcs=trace"}
+
+	r, err := admin.Reconfigure(&c)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logging.component-severity"}, r.Applied)
+	assert.Equal(t, []string{"gcs=trace"}, admin.Config().Logging.ComponentSeverity)
+}
+
+func TestAdmin_ReconfigureRejectsEnablingCaches(t *testing.T) {
+	admin, _, _, _ := newAdminTestFileSystemWithConfig(t, defaultModelConfig())
+	c := *defaultModelConfig()
+	c.FileCache.MaxSizeMb = 10
+	c.MetadataCache.TtlSecs = 30
+
+	r, err := admin.Reconfigure(&c)
+
+	require.NoError(t, err)
+	assert.Empty(t, r.Applied)
+	assert.Equal(t, []string{"file-cache.max-size-mb", "metadata-cache.ttl-secs"}, r.Rejected)
+	assert.Equal(t, int64(0), admin.Config().MetadataCache.TtlSecs)
+}
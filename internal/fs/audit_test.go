@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for the audit log of file system mutations.
+
+package fs_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/audit"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	. "github.com/jacobsa/oglematchers"
+	. "github.com/jacobsa/ogletest"
+)
+
+type AuditTest struct {
+	auditLogPath string
+	fsTest
+}
+
+func init() {
+	RegisterTestSuite(&AuditTest{})
+}
+
+func (t *AuditTest) SetUpTestSuite() {
+	dir, err := os.MkdirTemp("", "audit_test")
+	AssertEq(nil, err)
+	t.auditLogPath = path.Join(dir, "audit.log")
+	t.serverCfg.NewConfig = &cfg.Config{
+		FileCache: defaultFileCacheConfig(),
+		MetadataCache: cfg.MetadataCacheConfig{
+			StatCacheMaxSizeMb: 32,
+			TtlSecs:            60,
+			TypeCacheMaxSizeMb: 4,
+		},
+		Audit: cfg.AuditConfig{
+			FilePath: cfg.ResolvedPath(t.auditLogPath),
+		},
+	}
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *AuditTest) TearDown() {
+	AssertEq(nil, os.Truncate(t.auditLogPath, 0))
+	t.fsTest.TearDown()
+}
+
+func (t *AuditTest) readRecords() (records []audit.Record) {
+	f, err := os.Open(t.auditLogPath)
+	AssertEq(nil, err)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec audit.Record
+		AssertEq(nil, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	AssertEq(nil, scanner.Err())
+	return
+}
+
+func (t *AuditTest) RecordsMutations() {
+	AssertEq(nil, os.Mkdir(path.Join(mntDir, "dir"), 0700))
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "dir/foo"), []byte("taco"), 0600))
+	AssertEq(nil, os.Rename(path.Join(mntDir, "dir/foo"), path.Join(mntDir, "dir/bar")))
+	AssertEq(nil, os.Remove(path.Join(mntDir, "dir/bar")))
+	AssertEq(nil, os.Remove(path.Join(mntDir, "dir")))
+
+	records := t.readRecords()
+
+	var ops, objects []string
+	for _, rec := range records {
+		ops = append(ops, rec.Op)
+		objects = append(objects, rec.Object)
+		ExpectEq("ok", rec.Outcome)
+		ExpectEq(bucket.Name(), rec.Bucket)
+		AssertNe(nil, rec.Uid)
+		ExpectEq(uint32(os.Getuid()), *rec.Uid)
+	}
+	ExpectThat(ops, ElementsAre("mkdir", "create", "write", "rename", "unlink", "rmdir"))
+	ExpectThat(objects, ElementsAre("dir/", "dir/foo", "dir/foo", "dir/foo", "dir/bar", "dir/"))
+	ExpectEq("dir/bar", records[3].NewObject)
+	ExpectNe(0, records[2].Generation)
+}
+
+func (t *AuditTest) RecordsFailures() {
+	_, err := storageutil.CreateObject(ctx, bucket, "dir/foo", []byte("taco"))
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(ctx, bucket, "dir/", nil)
+	AssertEq(nil, err)
+
+	err = os.Remove(path.Join(mntDir, "dir"))
+
+	ExpectNe(nil, err)
+	records := t.readRecords()
+	AssertEq(1, len(records))
+	ExpectEq("rmdir", records[0].Op)
+	ExpectEq("dir/", records[0].Object)
+	ExpectEq("error", records[0].Outcome)
+	ExpectNe("", records[0].Error)
+}
+
+func (t *AuditTest) RecordsCallerOfSetattr() {
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "foo"), []byte("taco"), 0600))
+	AssertEq(nil, os.Truncate(t.auditLogPath, 0))
+
+	AssertEq(nil, os.Truncate(path.Join(mntDir, "foo"), 2))
+
+	records := t.readRecords()
+	AssertEq(1, len(records))
+	ExpectEq("setattr", records[0].Op)
+	ExpectEq("foo", records[0].Object)
+	AssertNe(nil, records[0].Uid)
+	ExpectEq(uint32(os.Getuid()), *records[0].Uid)
+	AssertNe(nil, records[0].Pid)
+	ExpectNe(0, *records[0].Pid)
+}
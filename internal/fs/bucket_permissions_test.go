@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for ops failing early when the mount's credentials lack the
+// permissions they need on the bucket.
+
+package fs_test
+
+import (
+	"os"
+	"path"
+	"syscall"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	. "github.com/jacobsa/oglematchers"
+	. "github.com/jacobsa/ogletest"
+	"github.com/jacobsa/timeutil"
+)
+
+////////////////////////////////////////////////////////////////////////
+// Read-only permissions
+////////////////////////////////////////////////////////////////////////
+
+type ReadOnlyPermissionsTest struct {
+	fsTest
+}
+
+func init() {
+	RegisterTestSuite(&ReadOnlyPermissionsTest{})
+}
+
+func (t *ReadOnlyPermissionsTest) SetUpTestSuite() {
+	bucket = fake.NewFakeBucketWithOptions(timeutil.RealClock(), "some_bucket", bucketType, fake.BucketOptions{
+		GrantedPermissions: []string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList},
+	})
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *ReadOnlyPermissionsTest) ReadFile() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	contents, err := os.ReadFile(path.Join(mntDir, "foo"))
+
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *ReadOnlyPermissionsTest) ModesHaveNoWriteBits() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	fi, err := os.Stat(path.Join(mntDir, "foo"))
+	AssertEq(nil, err)
+	ExpectEq(0, fi.Mode().Perm()&0222)
+
+	fi, err = os.Stat(mntDir)
+	AssertEq(nil, err)
+	ExpectEq(0, fi.Mode().Perm()&0222)
+}
+
+func (t *ReadOnlyPermissionsTest) OpenForWriting() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	_, err = os.OpenFile(path.Join(mntDir, "foo"), os.O_WRONLY, 0)
+
+	ExpectThat(err, Error(HasSubstr("permission denied")))
+}
+
+func (t *ReadOnlyPermissionsTest) CreateFile() {
+	_, err := os.Create(path.Join(mntDir, "foo"))
+
+	ExpectThat(err, Error(HasSubstr("permission denied")))
+}
+
+func (t *ReadOnlyPermissionsTest) MkDir() {
+	err := os.Mkdir(path.Join(mntDir, "dir"), 0700)
+
+	ExpectThat(err, Error(HasSubstr("permission denied")))
+}
+
+func (t *ReadOnlyPermissionsTest) DeleteFile() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	err = os.Remove(path.Join(mntDir, "foo"))
+
+	ExpectThat(err, Error(HasSubstr("permission denied")))
+	contents, err := storageutil.ReadObject(ctx, bucket, "foo")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+////////////////////////////////////////////////////////////////////////
+// Create-only permissions
+////////////////////////////////////////////////////////////////////////
+
+type CreateOnlyPermissionsTest struct {
+	fsTest
+}
+
+func init() {
+	RegisterTestSuite(&CreateOnlyPermissionsTest{})
+}
+
+func (t *CreateOnlyPermissionsTest) SetUpTestSuite() {
+	bucket = fake.NewFakeBucketWithOptions(timeutil.RealClock(), "some_bucket", bucketType, fake.BucketOptions{
+		GrantedPermissions: []string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList, gcs.PermissionObjectsCreate},
+	})
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *CreateOnlyPermissionsTest) CreateNewFile() {
+	err := os.WriteFile(path.Join(mntDir, "foo"), []byte("taco"), 0600)
+
+	AssertEq(nil, err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "foo")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *CreateOnlyPermissionsTest) OverwriteExistingFile() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	_, err = os.OpenFile(path.Join(mntDir, "foo"), os.O_RDWR, 0)
+
+	ExpectTrue(os.IsPermission(err), "err: %v", err)
+}
+
+func (t *CreateOnlyPermissionsTest) DeleteFile() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	err = os.Remove(path.Join(mntDir, "foo"))
+
+	ExpectTrue(os.IsPermission(err), "err: %v", err)
+}
+
+func (t *CreateOnlyPermissionsTest) Rename() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	err = os.Rename(path.Join(mntDir, "foo"), path.Join(mntDir, "bar"))
+
+	ExpectThat(err, Error(HasSubstr(syscall.EACCES.Error())))
+}
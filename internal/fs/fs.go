@@ -25,6 +25,8 @@ import (
 	"path"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -32,16 +34,23 @@ import (
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/audit"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file/downloader"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	cacheutil "github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/contentcache"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/handle"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/inode"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/policy"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/health"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/prefetch"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 	"github.com/jacobsa/fuse"
@@ -129,6 +138,15 @@ type ServerConfig struct {
 	NewConfig *cfg.Config
 
 	MetricHandle common.MetricHandle
+
+	// If set, file system ops and the GCS requests they make are attributed to
+	// their callers and path prefixes by WrapFileSystem, and reported by
+	// Admin.TopConsumers.
+	Attribution *attribution.Tracker
+
+	// If set, the ops are reported to it by WrapFileSystem, and its liveness
+	// probe is an op on the root directory.
+	Health *health.Monitor
 }
 
 // Create a fuse file system server according to the supplied configuration.
@@ -144,7 +162,16 @@ func NewFileSystem(ctx context.Context, serverCfg *ServerConfig) (fuseutil.FileS
 
 	mtimeClock := timeutil.RealClock()
 
-	contentCache := contentcache.New(serverCfg.TempDir, mtimeClock)
+	// Encrypt the object data staged on the local disk, if asked to.
+	var cipher *diskcrypt.Cipher
+	if serverCfg.NewConfig.FileSystem.EncryptLocalData {
+		var err error
+		if cipher, err = diskcrypt.NewCipher(); err != nil {
+			return nil, fmt.Errorf("diskcrypt.NewCipher: %w", err)
+		}
+	}
+
+	contentCache := contentcache.New(serverCfg.TempDir, mtimeClock, cipher)
 
 	if serverCfg.LocalFileCache {
 		err := contentCache.RecoverCache()
@@ -154,12 +181,33 @@ func NewFileSystem(ctx context.Context, serverCfg *ServerConfig) (fuseutil.FileS
 		}
 	}
 
+	accessPolicy, err := policy.New(&serverCfg.NewConfig.AccessPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("policy.New: %w", err)
+	}
+
+	auditLog, err := audit.New(string(serverCfg.NewConfig.Audit.FilePath), serverCfg.NewConfig.Audit.Syslog, serverCfg.MetricHandle)
+	if err != nil {
+		return nil, fmt.Errorf("audit.New: %w", err)
+	}
+
+	// Verify object contents against the signed integrity manifest, if given.
+	var verifier *integrity.Verifier
+	if integrityCfg := serverCfg.NewConfig.Integrity; integrityCfg.Manifest != "" {
+		manifest, err := integrity.LoadManifest(string(integrityCfg.Manifest), string(integrityCfg.Signature), string(integrityCfg.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("integrity.LoadManifest: %w", err)
+		}
+		logger.Infof("Verifying %d objects against integrity manifest %s", len(manifest.Objects), integrityCfg.Manifest)
+		verifier = integrity.NewVerifier(manifest, auditLog)
+	}
+
 	// Create file cache handler if cache is enabled by user. Cache is considered
 	// enabled only if cache-dir is not empty and file-cache:max-size-mb is non 0.
 	var fileCacheHandler *file.CacheHandler
 	if cfg.IsFileCacheEnabled(serverCfg.NewConfig) {
 		var err error
-		fileCacheHandler, err = createFileCacheHandler(serverCfg)
+		fileCacheHandler, err = createFileCacheHandler(serverCfg, cipher, verifier)
 		if err != nil {
 			return nil, err
 		}
@@ -174,15 +222,16 @@ func NewFileSystem(ctx context.Context, serverCfg *ServerConfig) (fuseutil.FileS
 		contentCache:               contentCache,
 		implicitDirs:               serverCfg.ImplicitDirectories,
 		enableNonexistentTypeCache: serverCfg.EnableNonexistentTypeCache,
-		inodeAttributeCacheTTL:     serverCfg.InodeAttributeCacheTTL,
-		dirTypeCacheTTL:            serverCfg.DirTypeCacheTTL,
-		kernelListCacheTTL:         cfg.ListCacheTTLSecsToDuration(serverCfg.NewConfig.FileSystem.KernelListCacheTtlSecs),
 		renameDirLimit:             serverCfg.RenameDirLimit,
 		sequentialReadSizeMb:       serverCfg.SequentialReadSizeMb,
 		uid:                        serverCfg.Uid,
 		gid:                        serverCfg.Gid,
 		fileMode:                   serverCfg.FilePerms,
 		dirMode:                    serverCfg.DirPerms | os.ModeDir,
+		policy:                     accessPolicy,
+		perUserCredentials:         cfg.IsPerUserCredentialsEnabled(serverCfg.NewConfig),
+		auditLog:                   auditLog,
+		trashDirBuckets:            make(map[string]bool),
 		inodes:                     make(map[fuseops.InodeID]inode.Inode),
 		nextInodeID:                fuseops.RootInodeID + 1,
 		generationBackedInodes:     make(map[inode.Name]inode.GenerationBackedInode),
@@ -191,15 +240,22 @@ func NewFileSystem(ctx context.Context, serverCfg *ServerConfig) (fuseutil.FileS
 		localFileInodes:            make(map[inode.Name]inode.Inode),
 		handles:                    make(map[fuseops.HandleID]interface{}),
 		newConfig:                  serverCfg.NewConfig,
+		config:                     serverCfg.NewConfig,
 		fileCacheHandler:           fileCacheHandler,
 		cacheFileForRangeRead:      serverCfg.NewConfig.FileCache.CacheFileForRangeRead,
+		verifier:                   verifier,
 		metricHandle:               serverCfg.MetricHandle,
+		attribution:                serverCfg.Attribution,
 		enableAtomicRenameObject:   serverCfg.NewConfig.EnableAtomicRenameObject,
 		globalMaxWriteBlocksSem:    semaphore.NewWeighted(serverCfg.NewConfig.Write.GlobalMaxBlocks),
 	}
+	fs.inodeAttributeCacheTTL.Store(int64(serverCfg.InodeAttributeCacheTTL))
+	fs.dirTypeCacheTTL.Store(int64(serverCfg.DirTypeCacheTTL))
+	fs.kernelListCacheTTL.Store(int64(cfg.ListCacheTTLSecsToDuration(serverCfg.NewConfig.FileSystem.KernelListCacheTtlSecs)))
 
 	// Set up root bucket
 	var root inode.DirInode
+	var mountedBucket *gcsx.SyncerBucket
 	if serverCfg.BucketName == "" || serverCfg.BucketName == "_" {
 		logger.Info("Set up root directory for all accessible buckets")
 		root = makeRootForAllBuckets(fs)
@@ -209,30 +265,80 @@ func NewFileSystem(ctx context.Context, serverCfg *ServerConfig) (fuseutil.FileS
 		if err != nil {
 			return nil, fmt.Errorf("SetUpBucket: %w", err)
 		}
+		if syncerBucket.Permissions.ReadOnly() {
+			logger.Warnf("Only read permissions are granted on bucket %q; serving it read-only", serverCfg.BucketName)
+			fs.fileMode &^= 0222
+			fs.dirMode &^= 0222
+		}
 		root = makeRootForBucket(ctx, fs, syncerBucket)
+		mountedBucket = &syncerBucket
 	}
 	root.Lock()
 	root.IncrementLookupCount()
 	fs.inodes[fuseops.RootInodeID] = root
+	fs.storeInodeName(root)
+	fs.recordInodeCount(root, 1)
 	fs.implicitDirInodes[root.Name()] = root
 	fs.folderInodes[root.Name()] = root
 	root.Unlock()
 
 	// Set up invariant checking.
 	fs.mu = locker.New("FS", fs.checkInvariants)
+
+	// Record the reads of this mount for the next one, and warm the file cache
+	// from the reads of the previous one.
+	if manifestFile := string(serverCfg.NewConfig.FileCache.AccessManifestFile); manifestFile != "" && fileCacheHandler != nil {
+		manifest, err := prefetch.ReadManifest(manifestFile)
+		if err != nil {
+			logger.Warnf("Not warming the file cache: %v", err)
+		}
+		fs.accessRecorder = prefetch.NewRecorder(manifestFile)
+
+		if concurrency := int(serverCfg.NewConfig.FileCache.PrefetchConcurrency); concurrency > 0 && len(manifest) > 0 {
+			var warmCtx context.Context
+			warmCtx, fs.cancelPrefetch = context.WithCancel(context.Background())
+			go fs.warmFileCache(warmCtx, manifest, mountedBucket, concurrency)
+		}
+	}
+
 	return fs, nil
 }
 
-func createFileCacheHandler(serverCfg *ServerConfig) (fileCacheHandler *file.CacheHandler, err error) {
-	var sizeInBytes uint64
+// warmFileCache downloads the objects of the access manifest into the file
+// cache. In single-bucket mounts, only the objects of the mounted bucket are
+// prefetched; mountedBucket is nil in dynamic mounts, which prefetch from all
+// buckets.
+func (fs *fileSystem) warmFileCache(ctx context.Context, manifest []prefetch.Access, mountedBucket *gcsx.SyncerBucket, concurrency int) {
+	buckets := func(ctx context.Context, name string) (gcs.Bucket, error) {
+		if mountedBucket == nil {
+			return fs.bucketManager.SetUpBucket(ctx, name, true, fs.metricHandle)
+		}
+		if name != mountedBucket.Name() {
+			return nil, fmt.Errorf("bucket %q isn't mounted", name)
+		}
+		return mountedBucket, nil
+	}
+
+	logger.Infof("Warming the file cache with up to %d objects from %s", len(manifest), fs.newConfig.FileCache.AccessManifestFile)
+	result := prefetch.Warm(ctx, manifest, fs.fileCacheHandler, buckets, concurrency)
+	logger.Infof("Warmed the file cache with %d objects (%d MiB); skipped %d deleted, overwritten or unmounted ones and failed %d",
+		result.Warmed, result.Bytes/cacheutil.MiB, result.Skipped, result.Failed)
+}
+
+// The capacity in bytes of the file cache for the given
+// file-cache.max-size-mb.
+func fileCacheCapacity(maxSizeMb int64) uint64 {
 	// -1 means unlimited size for cache, the underlying LRU cache doesn't handle
 	// -1 explicitly, hence we pass MaxUint64 as capacity in that case.
-	if serverCfg.NewConfig.FileCache.MaxSizeMb == -1 {
-		sizeInBytes = math.MaxUint64
-	} else {
-		sizeInBytes = uint64(serverCfg.NewConfig.FileCache.MaxSizeMb) * cacheutil.MiB
+	if maxSizeMb == -1 {
+		return math.MaxUint64
 	}
-	fileInfoCache := lru.NewCache(sizeInBytes)
+
+	return uint64(maxSizeMb) * cacheutil.MiB
+}
+
+func createFileCacheHandler(serverCfg *ServerConfig, cipher *diskcrypt.Cipher, verifier *integrity.Verifier) (fileCacheHandler *file.CacheHandler, err error) {
+	fileInfoCache := lru.NewCacheWithMetrics(fileCacheCapacity(serverCfg.NewConfig.FileCache.MaxSizeMb), serverCfg.MetricHandle, common.FileCache)
 
 	cacheDir := string(serverCfg.NewConfig.CacheDir)
 	// Adding a new directory inside cacheDir to keep file-cache separate from
@@ -248,8 +354,8 @@ func createFileCacheHandler(serverCfg *ServerConfig) (fileCacheHandler *file.Cac
 		return nil, fmt.Errorf("createFileCacheHandler: while creating file cache directory: %w", cacheDirErr)
 	}
 
-	jobManager := downloader.NewJobManager(fileInfoCache, filePerm, dirPerm, cacheDir, serverCfg.SequentialReadSizeMb, &serverCfg.NewConfig.FileCache, serverCfg.MetricHandle)
-	fileCacheHandler = file.NewCacheHandler(fileInfoCache, jobManager, cacheDir, filePerm, dirPerm)
+	jobManager := downloader.NewJobManager(fileInfoCache, filePerm, dirPerm, cacheDir, serverCfg.SequentialReadSizeMb, &serverCfg.NewConfig.FileCache, serverCfg.MetricHandle, cipher, verifier)
+	fileCacheHandler = file.NewCacheHandler(fileInfoCache, jobManager, cacheDir, filePerm, dirPerm, cipher)
 	return
 }
 
@@ -273,12 +379,14 @@ func makeRootForBucket(
 		fs.implicitDirs,
 		fs.newConfig.List.EnableEmptyManagedFolders,
 		fs.enableNonexistentTypeCache,
-		fs.dirTypeCacheTTL,
+		fs.typeCacheTTL(),
 		&syncerBucket,
 		fs.mtimeClock,
 		fs.cacheClock,
 		fs.newConfig.MetadataCache.TypeCacheMaxSizeMb,
 		fs.newConfig.EnableHns,
+		fs.policy,
+		fs.metricHandle,
 	)
 }
 
@@ -346,13 +454,6 @@ type fileSystem struct {
 	contentCache               *contentcache.ContentCache
 	implicitDirs               bool
 	enableNonexistentTypeCache bool
-	inodeAttributeCacheTTL     time.Duration
-	dirTypeCacheTTL            time.Duration
-
-	// kernelListCacheTTL specifies the duration to keep the readdir response cached
-	// in kernel. After ttl, gcsfuse, (filesystem) on next opendir call (just before as part
-	// of next list call) from user, asks the kernel to evict the old cache entries.
-	kernelListCacheTTL time.Duration
 
 	renameDirLimit       int64
 	sequentialReadSizeMb int32
@@ -365,10 +466,39 @@ type fileSystem struct {
 	fileMode os.FileMode
 	dirMode  os.FileMode
 
+	// Which objects are hidden, and which may be modified. Nil if no rules are
+	// configured.
+	policy *policy.Policy
+
+	// Records mutations to the audit log. Nil if audit logging is disabled.
+	auditLog *audit.Logger
+
+	// Are requests made with the credentials of each op's caller? The kernel's
+	// entry, attribute and page caches are shared by all users, so they are
+	// then bypassed, for every op to reach gcsfuse and be made with its
+	// caller's credentials.
+	perUserCredentials bool
+
 	/////////////////////////
 	// Mutable state
 	/////////////////////////
 
+	// The TTLs in nanoseconds from the config, which Reconfigure changes.
+	inodeAttributeCacheTTL atomic.Int64
+	dirTypeCacheTTL        atomic.Int64
+
+	// kernelListCacheTTL specifies the duration to keep the readdir response cached
+	// in kernel. After ttl, gcsfuse, (filesystem) on next opendir call (just before as part
+	// of next list call) from user, asks the kernel to evict the old cache entries.
+	kernelListCacheTTL atomic.Int64
+
+	// The effective config, which starts out as newConfig. Reconfigure replaces
+	// it rather than changing it.
+	//
+	// GUARDED_BY(reconfigureMu)
+	config        *cfg.Config
+	reconfigureMu sync.Mutex
+
 	// A lock protecting the state of the file system struct itself (distinct
 	// from per-inode locks). Make sure to see the notes on lock ordering above.
 	mu locker.Locker
@@ -391,6 +521,11 @@ type fileSystem struct {
 	// GUARDED_BY(mu)
 	inodes map[fuseops.InodeID]inode.Inode
 
+	// The local names of the inodes in the map above, by ID, readable without
+	// mu so that ops stalled while it's held can be reported with their paths.
+	// Only kept for slow op detection and attribution.
+	inodeNames sync.Map
+
 	// A map from object name to an inode for that name backed by a GCS object.
 	// Populated during the name -> inode lookup process, cleared during the
 	// forget inode process.
@@ -485,13 +620,34 @@ type fileSystem struct {
 	// random file access.
 	cacheFileForRangeRead bool
 
+	// verifier checks objects against the integrity manifest before they are
+	// read. It is nil if there is no manifest.
+	verifier *integrity.Verifier
+
 	metricHandle common.MetricHandle
 
+	// attribution tracks the usage of the callers and path prefixes. It is nil
+	// if attribution is disabled.
+	attribution *attribution.Tracker
+
+	// accessRecorder records the reads of objects to the access manifest. It
+	// is nil if there is no manifest.
+	accessRecorder *prefetch.Recorder
+
+	// Cancels the warming of the file cache from the access manifest, if any.
+	cancelPrefetch context.CancelFunc
+
 	enableAtomicRenameObject bool
 
 	// Limits the max number of blocks that can be created across file system when
 	// streaming writes are enabled.
 	globalMaxWriteBlocksSem *semaphore.Weighted
+
+	// The names of the buckets known to have a trash directory object, when
+	// the trash is enabled.
+	//
+	// GUARDED_BY(mu)
+	trashDirBuckets map[string]bool
 }
 
 ////////////////////////////////////////////////////////////////////////
@@ -547,6 +703,112 @@ func (fs *fileSystem) checkInvariantsForLocalFileInodes() {
 	}
 }
 
+// Return EACCES if the mount's credentials are known to lack any of the given
+// permissions on the bucket that owns the inode, so that an op fails before
+// doing any work rather than when it reaches GCS.
+func checkPermissions(in inode.Inode, permissions ...string) error {
+	bucketOwned, ok := in.(inode.BucketOwnedInode)
+	if !ok {
+		return nil
+	}
+
+	perms := bucketOwned.Bucket().Permissions
+	for _, p := range permissions {
+		if !perms.Has(p) {
+			return fmt.Errorf("%s not granted on bucket: %w", p, syscall.EACCES)
+		}
+	}
+
+	return nil
+}
+
+// Start the audit record of an op by the given caller on the named object of
+// the bucket of the given inode. It returns nil if audit logging is disabled.
+func (fs *fileSystem) startAudit(op string, opCtx fuseops.OpContext, in inode.Inode, name inode.Name) *audit.Entry {
+	if fs.auditLog == nil {
+		return nil
+	}
+
+	var bucketName string
+	if bucketOwned, ok := in.(inode.BucketOwnedInode); ok {
+		bucketName = bucketOwned.Bucket().Name()
+	}
+
+	entry := fs.auditLog.Start(op, audit.Caller{Pid: opCtx.Pid, Uid: opCtx.Uid})
+	entry.Object(bucketName, name.GcsObjectName())
+	return entry
+}
+
+// Start the audit record of an op by the given caller on the named child of
+// the directory.
+func (fs *fileSystem) startAuditOfChild(op string, opCtx fuseops.OpContext, parent inode.DirInode, name string, isDir bool) *audit.Entry {
+	if fs.auditLog == nil {
+		return nil
+	}
+
+	if isDir {
+		return fs.startAudit(op, opCtx, parent, inode.NewDirName(parent.Name(), name))
+	}
+	return fs.startAudit(op, opCtx, parent, inode.NewFileName(parent.Name(), name))
+}
+
+// Return an error if the contents of the file may not be replaced: it exists
+// in GCS and is write-once, or is under a hold or retention.
+//
+// LOCKS_REQUIRED(file)
+func (fs *fileSystem) checkOverwrite(file *inode.FileInode) error {
+	if file.IsLocal() {
+		return nil
+	}
+
+	name := file.Name().GcsObjectName()
+	if err := fs.policy.CheckOverwrite(name); err != nil {
+		return err
+	}
+
+	if file.IsRetained() {
+		return fmt.Errorf("%q is under a hold or retention: %w", name, syscall.EPERM)
+	}
+
+	return nil
+}
+
+// Return an error if renaming a file to the given name in the directory would
+// replace an existing write-once file. The destination is only looked up if
+// a write-once rule applies to the name.
+//
+// LOCKS_EXCLUDED(dir)
+func (fs *fileSystem) checkRenameOverwrite(ctx context.Context, dir inode.DirInode, name string) error {
+	policyErr := fs.policy.CheckOverwrite(inode.NewFileName(dir.Name(), name).GcsObjectName())
+	if policyErr == nil {
+		return nil
+	}
+
+	dir.Lock()
+	dst, err := dir.LookUpChild(ctx, name)
+	dir.Unlock()
+	if err != nil {
+		return fmt.Errorf("LookUpChild: %w", err)
+	}
+
+	if dst != nil && dst.FullName.IsFile() {
+		return policyErr
+	}
+	return nil
+}
+
+// The permissions needed to modify the contents of the file: an object that
+// exists in GCS is replaced, which also needs permission to delete it.
+//
+// LOCKS_REQUIRED(f)
+func fileWritePermissions(f *inode.FileInode) []string {
+	if f.IsLocal() {
+		return []string{gcs.PermissionObjectsCreate}
+	}
+
+	return []string{gcs.PermissionObjectsCreate, gcs.PermissionObjectsDelete}
+}
+
 func (fs *fileSystem) checkInvariantsForFolderInodes() {
 	// INVARIANT: For each k/v, v.Name() == k
 	for k, v := range fs.folderInodes {
@@ -732,22 +994,83 @@ func (fs *fileSystem) createExplicitDirInode(inodeID fuseops.InodeID, ic inode.C
 			Ctime: fs.mtimeClock.Now(),
 			Mtime: fs.mtimeClock.Now(),
 		},
-		fs.implicitDirs,
+		fs.implicitDirsFor(ic.FullName),
 		fs.newConfig.List.EnableEmptyManagedFolders,
 		fs.enableNonexistentTypeCache,
-		fs.dirTypeCacheTTL,
+		fs.typeCacheTTL(),
 		ic.Bucket,
 		fs.mtimeClock,
 		fs.cacheClock,
 		fs.newConfig.MetadataCache.TypeCacheMaxSizeMb,
-		fs.newConfig.EnableHns)
+		fs.newConfig.EnableHns,
+		fs.policy,
+		fs.metricHandle)
 
 	return in
 }
 
+// Directories in the trash always resolve implicit directories, since the
+// files moved there don't come with objects for their parents.
+func (fs *fileSystem) implicitDirsFor(name inode.Name) bool {
+	return fs.implicitDirs ||
+		fs.newConfig.FileSystem.EnableTrash && gcsx.IsTrashObjectName(name.GcsObjectName())
+}
+
 // Implementation detail of lookUpOrCreateInodeIfNotStale; do not use outside
 // of that function.
 //
+// The TTL of the type caches of directory inodes minted from now on.
+func (fs *fileSystem) typeCacheTTL() time.Duration {
+	return time.Duration(fs.dirTypeCacheTTL.Load())
+}
+
+// InodePath returns the path of the inode with the given ID relative to the
+// root of the mount, if the inode is live and its name is kept, as for
+// keepsInodeNames. It doesn't take any locks.
+func (fs *fileSystem) InodePath(id fuseops.InodeID) (string, bool) {
+	name, ok := fs.inodeNames.Load(id)
+	if !ok {
+		return "", false
+	}
+	return name.(string), true
+}
+
+// Whether the names of inodes are kept for InodePath, which only the slow op
+// detector and attribution use.
+func (fs *fileSystem) keepsInodeNames() bool {
+	return fs.attribution != nil || fs.newConfig.Debug.SlowOpThreshold > 0
+}
+
+func (fs *fileSystem) storeInodeName(in inode.Inode) {
+	if fs.keepsInodeNames() {
+		fs.inodeNames.Store(in.ID(), in.Name().LocalName())
+	}
+}
+
+func (fs *fileSystem) deleteInodeName(in inode.Inode) {
+	if fs.keepsInodeNames() {
+		fs.inodeNames.Delete(in.ID())
+	}
+}
+
+// recordInodeCount records a change in the number of live inodes of in's type.
+func (fs *fileSystem) recordInodeCount(in inode.Inode, inc int64) {
+	inodeType := common.FileType
+	switch in.(type) {
+	case inode.DirInode:
+		inodeType = common.DirType
+	case *inode.SymlinkInode:
+		inodeType = common.SymlinkType
+	}
+	fs.metricHandle.InodeCount(context.Background(), inc, []common.MetricAttr{{Key: common.InodeType, Value: inodeType}})
+}
+
+// recordHandleCount records a change in the number of open handles of the
+// given type.
+func (fs *fileSystem) recordHandleCount(handleType string, inc int64) {
+	fs.metricHandle.HandleCount(context.Background(), inc, []common.MetricAttr{{Key: common.HandleType, Value: handleType}})
+}
+
 // LOCKS_REQUIRED(fs.mu)
 func (fs *fileSystem) mintInode(ic inode.Core) (in inode.Inode) {
 	// Choose an ID.
@@ -775,15 +1098,17 @@ func (fs *fileSystem) mintInode(ic inode.Core) (in inode.Inode) {
 				Ctime: fs.mtimeClock.Now(),
 				Mtime: fs.mtimeClock.Now(),
 			},
-			fs.implicitDirs,
+			fs.implicitDirsFor(ic.FullName),
 			fs.newConfig.List.EnableEmptyManagedFolders,
 			fs.enableNonexistentTypeCache,
-			fs.dirTypeCacheTTL,
+			fs.typeCacheTTL(),
 			ic.Bucket,
 			fs.mtimeClock,
 			fs.cacheClock,
 			fs.newConfig.MetadataCache.TypeCacheMaxSizeMb,
 			fs.newConfig.EnableHns,
+			fs.policy,
+			fs.metricHandle,
 		)
 
 	case inode.IsSymlink(ic.MinObject):
@@ -813,11 +1138,15 @@ func (fs *fileSystem) mintInode(ic inode.Core) (in inode.Inode) {
 			fs.mtimeClock,
 			ic.Local,
 			fs.newConfig,
-			fs.globalMaxWriteBlocksSem)
+			fs.globalMaxWriteBlocksSem,
+			fs.metricHandle,
+			fs.verifier)
 	}
 
 	// Place it in our map of IDs to inodes.
 	fs.inodes[in.ID()] = in
+	fs.storeInodeName(in)
+	fs.recordInodeCount(in, 1)
 
 	return
 }
@@ -1234,6 +1563,8 @@ func (fs *fileSystem) unlockAndDecrementLookupCount(in inode.Inode, N uint64) {
 	if shouldDestroy {
 		fs.mu.Lock()
 		delete(fs.inodes, in.ID())
+		fs.deleteInodeName(in)
+		fs.recordInodeCount(in, -1)
 
 		// Update indexes if necessary.
 		if fs.generationBackedInodes[name] == in {
@@ -1314,8 +1645,8 @@ func (fs *fileSystem) getAttributes(
 	}
 
 	// Set up the expiration time.
-	if fs.inodeAttributeCacheTTL > 0 {
-		expiration = time.Now().Add(fs.inodeAttributeCacheTTL)
+	if ttl := time.Duration(fs.inodeAttributeCacheTTL.Load()); ttl > 0 && !fs.perUserCredentials {
+		expiration = time.Now().Add(ttl)
 	}
 
 	return
@@ -1404,10 +1735,17 @@ func (fs *fileSystem) invalidateChildFileCacheIfExist(parentInode inode.DirInode
 ////////////////////////////////////////////////////////////////////////
 
 func (fs *fileSystem) Destroy() {
+	if fs.cancelPrefetch != nil {
+		fs.cancelPrefetch()
+	}
+	if err := fs.accessRecorder.Close(); err != nil {
+		logger.Warnf("Writing access manifest: %v", err)
+	}
 	fs.bucketManager.ShutDown()
 	if fs.fileCacheHandler != nil {
 		_ = fs.fileCacheHandler.Destroy()
 	}
+	_ = fs.auditLog.Close()
 }
 
 func (fs *fileSystem) StatFS(
@@ -1514,35 +1852,71 @@ func (fs *fileSystem) SetInodeAttributes(
 
 	in.Lock()
 	defer in.Unlock()
+
+	if err = fs.setAttributes(ctx, op.OpContext, in, op.Size, op.Mtime); err != nil {
+		return err
+	}
+
+	// Fill in the response.
+	op.Attributes, op.AttributesExpiration, err = fs.getAttributes(ctx, in)
+	if err != nil {
+		err = fmt.Errorf("getAttributes: %w", err)
+		return err
+	}
+
+	return
+}
+
+// Set the size and mtime of the inode, where non-nil. We silently ignore
+// updates to other attributes, and to those of inodes other than files.
+//
+// LOCKS_REQUIRED(in)
+func (fs *fileSystem) setAttributes(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	in inode.Inode,
+	size *uint64,
+	mtime *time.Time) (err error) {
+	auditEntry := fs.startAudit("setattr", opCtx, in, in.Name())
+	defer func() { auditEntry.Finish(err) }()
+
 	file, isFile := in.(*inode.FileInode)
+	if !isFile {
+		return
+	}
+
+	// Truncation replaces the object, so fail early if that isn't permitted.
+	if size != nil {
+		if err = fs.policy.CheckModify(file.Name().GcsObjectName(), false); err != nil {
+			return
+		}
+		if err = fs.checkOverwrite(file); err != nil {
+			return
+		}
+		if err = checkPermissions(file, fileWritePermissions(file)...); err != nil {
+			return
+		}
+	}
 
 	// Set file mtimes.
-	if isFile && op.Mtime != nil {
-		err = file.SetMtime(ctx, *op.Mtime)
+	if mtime != nil {
+		err = file.SetMtime(ctx, *mtime)
 		if err != nil {
 			err = fmt.Errorf("SetMtime: %w", err)
-			return err
+			return
 		}
 	}
 
 	// Truncate files.
-	if isFile && op.Size != nil {
-		err = file.Truncate(ctx, int64(*op.Size))
+	if size != nil {
+		err = file.Truncate(ctx, int64(*size))
 		if err != nil {
 			err = fmt.Errorf("truncate: %w", err)
-			return err
+			return
 		}
 	}
 
-	// We silently ignore updates to mode and atime.
-
-	// Fill in the response.
-	op.Attributes, op.AttributesExpiration, err = fs.getAttributes(ctx, in)
-	if err != nil {
-		err = fmt.Errorf("getAttributes: %w", err)
-		return err
-	}
-
+	auditEntry.Generation(file.SourceGeneration().Object)
 	return
 }
 
@@ -1578,10 +1952,51 @@ func (fs *fileSystem) MkDir(
 	parent := fs.dirInodeOrDie(op.Parent)
 	fs.mu.Unlock()
 
+	child, err := fs.mkDir(ctx, op.OpContext, parent, op.Name)
+	if err != nil {
+		return err
+	}
+
+	defer fs.unlockAndMaybeDisposeOfInode(child, &err)
+
+	// Fill out the response.
+	e := &op.Entry
+	e.Child = child.ID()
+	e.Attributes, e.AttributesExpiration, err = fs.getAttributes(ctx, child)
+
+	if err != nil {
+		err = fmt.Errorf("getAttributes: %w", err)
+		return err
+	}
+
+	return
+}
+
+// Create the named child directory of the parent, returning it locked and
+// with its lookup count incremented.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
+// LOCK_FUNCTION(child)
+func (fs *fileSystem) mkDir(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	parent inode.DirInode,
+	name string) (child inode.Inode, err error) {
+	auditEntry := fs.startAuditOfChild("mkdir", opCtx, parent, name, true)
+	defer func() { auditEntry.Finish(err) }()
+
+	if err = fs.policy.CheckCreate(inode.NewDirName(parent.Name(), name).GcsObjectName()); err != nil {
+		return
+	}
+	if err = checkPermissions(parent, gcs.PermissionObjectsCreate); err != nil {
+		return
+	}
+
 	// Create an empty backing object for the child, failing if it already
 	// exists.
 	parent.Lock()
-	result, err := parent.CreateChildDir(ctx, op.Name)
+	result, err := parent.CreateChildDir(ctx, name)
 	parent.Unlock()
 
 	// Special case: *gcs.PreconditionError means the name already exists.
@@ -1594,28 +2009,20 @@ func (fs *fileSystem) MkDir(
 	// Propagate other errors.
 	if err != nil {
 		err = fmt.Errorf("CreateChildDir: %w", err)
-		return err
+		return
+	}
+
+	if result.MinObject != nil {
+		auditEntry.Generation(result.MinObject.Generation)
 	}
 
 	// Attempt to create a child inode using the object we created. If we fail to
 	// do so, it means someone beat us to the punch with a newer generation
 	// (unlikely, so we're probably okay with failing here).
-	child := fs.lookUpOrCreateInodeIfNotStale(*result)
+	child = fs.lookUpOrCreateInodeIfNotStale(*result)
 	if child == nil {
 		err = fmt.Errorf("newly-created record is already stale")
-		return err
-	}
-
-	defer fs.unlockAndMaybeDisposeOfInode(child, &err)
-
-	// Fill out the response.
-	e := &op.Entry
-	e.Child = child.ID()
-	e.Attributes, e.AttributesExpiration, err = fs.getAttributes(ctx, child)
-
-	if err != nil {
-		err = fmt.Errorf("getAttributes: %w", err)
-		return err
+		return
 	}
 
 	return
@@ -1636,8 +2043,20 @@ func (fs *fileSystem) MkNode(
 		return syscall.ENOTSUP
 	}
 
+	// Find the parent.
+	fs.mu.Lock()
+	parent := fs.dirInodeOrDie(op.Parent)
+	fs.mu.Unlock()
+
+	if err = fs.policy.CheckCreate(inode.NewFileName(parent.Name(), op.Name).GcsObjectName()); err != nil {
+		return err
+	}
+	if err = checkPermissions(parent, gcs.PermissionObjectsCreate); err != nil {
+		return err
+	}
+
 	// Create the child.
-	child, err := fs.createFile(ctx, op.Parent, op.Name, op.Mode)
+	child, err := fs.createFile(ctx, parent, op.Name, op.Mode)
 	if err != nil {
 		return err
 	}
@@ -1657,21 +2076,17 @@ func (fs *fileSystem) MkNode(
 	return
 }
 
-// Create a child of the parent with the given ID, returning the child locked
-// and with its lookup count incremented.
+// Create a child of the parent, returning the child locked and with its
+// lookup count incremented.
 //
 // LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
 // LOCK_FUNCTION(child)
 func (fs *fileSystem) createFile(
 	ctx context.Context,
-	parentID fuseops.InodeID,
+	parent inode.DirInode,
 	name string,
 	mode os.FileMode) (child inode.Inode, err error) {
-	// Find the parent.
-	fs.mu.Lock()
-	parent := fs.dirInodeOrDie(parentID)
-	fs.mu.Unlock()
-
 	// Create an empty backing object for the child, failing if it already
 	// exists.
 	parent.Lock()
@@ -1707,10 +2122,8 @@ func (fs *fileSystem) createFile(
 // LOCKS_EXCLUDED(fs.mu)
 // UNLOCK_FUNCTION(fs.mu)
 // LOCK_FUNCTION(child)
-func (fs *fileSystem) createLocalFile(ctx context.Context, parentID fuseops.InodeID, name string) (child inode.Inode, err error) {
-	// Find the parent.
+func (fs *fileSystem) createLocalFile(ctx context.Context, parent inode.DirInode, name string) (child inode.Inode, err error) {
 	fs.mu.Lock()
-	parent := fs.dirInodeOrDie(parentID)
 
 	defer func() {
 		if err != nil {
@@ -1768,14 +2181,12 @@ func (fs *fileSystem) CreateFile(
 		ctx, cancel = util.IsolateContextFromParentContext(ctx)
 		defer cancel()
 	}
-	// Create the child.
-	var child inode.Inode
-	if fs.newConfig.Write.CreateEmptyFile {
-		child, err = fs.createFile(ctx, op.Parent, op.Name, op.Mode)
-	} else {
-		child, err = fs.createLocalFile(ctx, op.Parent, op.Name)
-	}
+	// Find the parent.
+	fs.mu.Lock()
+	parent := fs.dirInodeOrDie(op.Parent)
+	fs.mu.Unlock()
 
+	child, err := fs.create(ctx, op.OpContext, parent, op.Name, op.Mode)
 	if err != nil {
 		return err
 	}
@@ -1789,7 +2200,8 @@ func (fs *fileSystem) CreateFile(
 	fs.nextHandleID++
 
 	// Creating new file is always a write operation, hence passing readOnly as false.
-	fs.handles[handleID] = handle.NewFileHandle(child.(*inode.FileInode), fs.fileCacheHandler, fs.cacheFileForRangeRead, fs.metricHandle, false)
+	fs.handles[handleID] = handle.NewFileHandle(child, fs.fileCacheHandler, fs.cacheFileForRangeRead, fs.metricHandle, fs.verifier, false)
+	fs.recordHandleCount(common.FileType, 1)
 	op.Handle = handleID
 
 	fs.mu.Unlock()
@@ -1807,6 +2219,48 @@ func (fs *fileSystem) CreateFile(
 	return
 }
 
+// Create the named file in the parent for the create op, locally unless the
+// config says to create an empty object, returning it locked and with its
+// lookup count incremented.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
+// LOCK_FUNCTION(child)
+func (fs *fileSystem) create(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	parent inode.DirInode,
+	name string,
+	mode os.FileMode) (child *inode.FileInode, err error) {
+	auditEntry := fs.startAuditOfChild("create", opCtx, parent, name, false)
+	defer func() { auditEntry.Finish(err) }()
+	// Fail before the file is written locally if it can't be uploaded.
+	fileName := inode.NewFileName(parent.Name(), name).GcsObjectName()
+	if err = fs.policy.CheckCreate(fileName); err != nil {
+		return
+	}
+	if err = checkPermissions(parent, gcs.PermissionObjectsCreate); err != nil {
+		return
+	}
+
+	// Create the child. Files in write-once paths are always created locally,
+	// as an empty object couldn't then be written.
+	var in inode.Inode
+	if fs.newConfig.Write.CreateEmptyFile && !fs.policy.IsWriteOnce(fileName) {
+		in, err = fs.createFile(ctx, parent, name, mode)
+	} else {
+		in, err = fs.createLocalFile(ctx, parent, name)
+	}
+
+	if err != nil {
+		return
+	}
+
+	child = in.(*inode.FileInode)
+	auditEntry.Generation(child.SourceGeneration().Object)
+	return
+}
+
 // LOCKS_EXCLUDED(fs.mu)
 func (fs *fileSystem) CreateSymlink(
 	ctx context.Context,
@@ -1823,6 +2277,16 @@ func (fs *fileSystem) CreateSymlink(
 	parent := fs.dirInodeOrDie(op.Parent)
 	fs.mu.Unlock()
 
+	auditEntry := fs.startAuditOfChild("symlink", op.OpContext, parent, op.Name, false)
+	defer func() { auditEntry.Finish(err) }()
+
+	if err = fs.policy.CheckCreate(inode.NewFileName(parent.Name(), op.Name).GcsObjectName()); err != nil {
+		return err
+	}
+	if err = checkPermissions(parent, gcs.PermissionObjectsCreate); err != nil {
+		return err
+	}
+
 	// Create the object in GCS, failing if it already exists.
 	parent.Lock()
 	result, err := parent.CreateChildSymlink(ctx, op.Name, op.Target)
@@ -1841,6 +2305,8 @@ func (fs *fileSystem) CreateSymlink(
 		return err
 	}
 
+	auditEntry.Generation(result.MinObject.Generation)
+
 	// Attempt to create a child inode using the object we created. If we fail to
 	// do so, it means someone beat us to the punch with a newer generation
 	// (unlikely, so we're probably okay with failing here).
@@ -1893,8 +2359,31 @@ func (fs *fileSystem) RmDir(
 	parent := fs.dirInodeOrDie(op.Parent)
 	fs.mu.Unlock()
 
+	return fs.rmDir(ctx, op.OpContext, parent, op.Name)
+}
+
+// Remove the named child directory of the parent, failing if it isn't empty.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
+func (fs *fileSystem) rmDir(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	parent inode.DirInode,
+	name string) (err error) {
+	auditEntry := fs.startAuditOfChild("rmdir", opCtx, parent, name, true)
+	defer func() { auditEntry.Finish(err) }()
+
+	// The directory must be empty to be removed, so only its own name is checked.
+	if err = fs.policy.CheckDelete(inode.NewDirName(parent.Name(), name).GcsObjectName()); err != nil {
+		return err
+	}
+	if err = checkPermissions(parent, gcs.PermissionObjectsDelete); err != nil {
+		return err
+	}
+
 	// Find or create the child inode, locked.
-	child, err := fs.lookUpOrCreateChildInode(ctx, parent, op.Name)
+	child, err := fs.lookUpOrCreateChildInode(ctx, parent, name)
 	if err != nil {
 		return
 	}
@@ -1948,7 +2437,7 @@ func (fs *fileSystem) RmDir(
 			return err
 		}
 
-		if fs.kernelListCacheTTL > 0 {
+		if fs.kernelListCacheTTL.Load() > 0 {
 			// Clear kernel list cache after removing a directory. This ensures remote
 			// GCS files are included in future directory listings for unlinking.
 			childDir.InvalidateKernelListCache()
@@ -1969,12 +2458,14 @@ func (fs *fileSystem) RmDir(
 	// We are done with the child.
 	cleanUpAndUnlockChild()
 
-	// Delete the backing object.
+	// Delete the backing object. It is deleted even when the trash is enabled:
+	// the directory is empty, so its files, if any, are in the trash already,
+	// and the object itself holds nothing to restore.
 	fs.mu.Lock()
 	_, isImplicitDir := fs.implicitDirInodes[child.Name()]
 	fs.mu.Unlock()
 	parent.Lock()
-	err = parent.DeleteChildDir(ctx, op.Name, isImplicitDir, childDir)
+	err = parent.DeleteChildDir(ctx, name, isImplicitDir, childDir)
 	parent.Unlock()
 
 	if err != nil {
@@ -2002,6 +2493,37 @@ func (fs *fileSystem) Rename(
 	newParent := fs.dirInodeOrDie(op.NewParent)
 	fs.mu.Unlock()
 
+	return fs.rename(ctx, op.OpContext, oldParent, op.OldName, newParent, op.NewName)
+}
+
+// Rename the named child of the old parent to the new name in the new parent.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(oldParent)
+// LOCKS_EXCLUDED(newParent)
+func (fs *fileSystem) rename(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	oldParent inode.DirInode,
+	oldName string,
+	newParent inode.DirInode,
+	newName string) (err error) {
+	auditEntry := fs.startAuditOfChild("rename", opCtx, oldParent, oldName, false)
+	defer func() { auditEntry.Finish(err) }()
+
+	if err = fs.policy.CheckDelete(inode.NewFileName(oldParent.Name(), oldName).GcsObjectName()); err != nil {
+		return err
+	}
+	if err = fs.policy.CheckCreate(inode.NewFileName(newParent.Name(), newName).GcsObjectName()); err != nil {
+		return err
+	}
+	if err = checkPermissions(oldParent, gcs.PermissionObjectsDelete); err != nil {
+		return err
+	}
+	if err = checkPermissions(newParent, gcs.PermissionObjectsCreate); err != nil {
+		return err
+	}
+
 	if oldInode, ok := oldParent.(inode.BucketOwnedInode); !ok {
 		// The old parent is not owned by any bucket, which means it's the base
 		// directory that holds all the buckets' root directories. So, this op
@@ -2016,18 +2538,18 @@ func (fs *fileSystem) Rename(
 	}
 
 	// If object to be renamed is a local file inode (un-synced), rename operation is not supported.
-	localChild, err := fs.lookUpLocalFileInode(oldParent, op.OldName)
+	localChild, err := fs.lookUpLocalFileInode(oldParent, oldName)
 	if err != nil {
 		return err
 	}
 	if localChild != nil {
 		fs.unlockAndDecrementLookupCount(localChild, 1)
-		return fmt.Errorf("cannot rename open file %q: %w", op.OldName, syscall.ENOTSUP)
+		return fmt.Errorf("cannot rename open file %q: %w", oldName, syscall.ENOTSUP)
 	}
 
 	// Else find the object in the old location (on GCS).
 	oldParent.Lock()
-	child, err := oldParent.LookUpChild(ctx, op.OldName)
+	child, err := oldParent.LookUpChild(ctx, oldName)
 	oldParent.Unlock()
 
 	if err != nil {
@@ -2040,18 +2562,30 @@ func (fs *fileSystem) Rename(
 		return err
 	}
 
+	if child.FullName.IsDir() {
+		auditEntry.Object(child.Bucket.Name(), child.FullName.GcsObjectName())
+		auditEntry.NewObject(inode.NewDirName(newParent.Name(), newName).GcsObjectName())
+	} else {
+		auditEntry.NewObject(inode.NewFileName(newParent.Name(), newName).GcsObjectName())
+
+		// Only an existing destination is overwritten.
+		if err = fs.checkRenameOverwrite(ctx, newParent, newName); err != nil {
+			return err
+		}
+	}
+
 	if child.FullName.IsDir() {
 		// If 'enable-hns' flag is false, the bucket type is set to 'NonHierarchical' even for HNS buckets because the control client is nil.
 		// Therefore, an additional 'enable hns' check is not required here.
 		if child.Bucket.BucketType().Hierarchical {
-			return fs.renameHierarchicalDir(ctx, oldParent, op.OldName, newParent, op.NewName)
+			return fs.renameHierarchicalDir(ctx, oldParent, oldName, newParent, newName)
 		}
-		return fs.renameNonHierarchicalDir(ctx, oldParent, op.OldName, newParent, op.NewName)
+		return fs.renameNonHierarchicalDir(ctx, oldParent, oldName, newParent, newName)
 	}
 	if (child.Bucket.BucketType().Hierarchical && fs.enableAtomicRenameObject) || child.Bucket.BucketType().Zonal {
-		return fs.renameHierarchicalFile(ctx, oldParent, op.OldName, child.MinObject, newParent, op.NewName)
+		return fs.renameHierarchicalFile(ctx, oldParent, oldName, child.MinObject, newParent, newName)
 	}
-	return fs.renameNonHierarchicalFile(ctx, oldParent, op.OldName, child.MinObject, newParent, op.NewName)
+	return fs.renameNonHierarchicalFile(ctx, oldParent, oldName, child.MinObject, newParent, newName)
 }
 
 // LOCKS_EXCLUDED(oldParent)
@@ -2149,6 +2683,45 @@ func (fs *fileSystem) ensureNoLocalFilesInDirectory(dir inode.BucketOwnedDirInod
 	return nil
 }
 
+// Return an error if the access policy forbids renaming the directory oldDir,
+// holding the given descendants, to newDir.
+func (fs *fileSystem) checkRenameDirPolicy(oldDir, newDir inode.Name, descendants map[inode.Name]*inode.Core) error {
+	names := make([]string, 0, len(descendants))
+	for name := range descendants {
+		names = append(names, name.GcsObjectName())
+	}
+
+	return fs.policy.CheckRenameDir(oldDir.GcsObjectName(), newDir.GcsObjectName(), names)
+}
+
+// checkRenameFolderPolicy is like checkRenameDirPolicy, for a folder of a
+// hierarchical bucket. It pages through all the objects under the folder, as
+// folder renames aren't bounded by renameDirLimit.
+func (fs *fileSystem) checkRenameFolderPolicy(ctx context.Context, bucket *gcsx.SyncerBucket, oldDir, newDir inode.Name) error {
+	var tok string
+	for {
+		listing, err := bucket.ListObjects(ctx, &gcs.ListObjectsRequest{
+			Prefix:            oldDir.GcsObjectName(),
+			ContinuationToken: tok,
+		})
+		if err != nil {
+			return fmt.Errorf("list objects under the old directory %q: %w", oldDir.GcsObjectName(), err)
+		}
+
+		names := make([]string, 0, len(listing.MinObjects))
+		for _, o := range listing.MinObjects {
+			names = append(names, o.Name)
+		}
+		if err = fs.policy.CheckRenameDir(oldDir.GcsObjectName(), newDir.GcsObjectName(), names); err != nil {
+			return err
+		}
+
+		if tok = listing.ContinuationToken; tok == "" {
+			return nil
+		}
+	}
+}
+
 func (fs *fileSystem) checkDirNotEmpty(dir inode.BucketOwnedDirInode, name string) error {
 	unexpected, err := dir.ReadDescendants(context.Background(), 1)
 	if err != nil {
@@ -2188,9 +2761,19 @@ func (fs *fileSystem) renameHierarchicalDir(ctx context.Context, oldParent inode
 	oldDirName := inode.NewDirName(oldParent.Name(), oldName)
 	newDirName := inode.NewDirName(newParent.Name(), newName)
 
+	// Folders are renamed without listing what they hold, unless the access
+	// policy has to check it.
+	if fs.policy.AppliesBelow(oldDirName.GcsObjectName()) || fs.policy.AppliesBelow(newDirName.GcsObjectName()) {
+		if err = fs.checkRenameFolderPolicy(ctx, oldDirInode.Bucket(), oldDirName, newDirName); err != nil {
+			return err
+		}
+	}
+
 	// If the call for getBucketDirInode fails it means directory does not exist.
 	newDirInode, err := fs.getBucketDirInode(ctx, newParent, newName)
 	if err == nil {
+		pendingInodes = append(pendingInodes, newDirInode)
+
 		// If the directory exists, then check if it is empty or not.
 		if err = fs.checkDirNotEmpty(newDirInode, newName); err != nil {
 			return err
@@ -2202,7 +2785,6 @@ func (fs *fileSystem) renameHierarchicalDir(ctx context.Context, oldParent inode
 		newParent.Lock()
 		_ = newParent.DeleteChildDir(ctx, newName, false, newDirInode)
 		newParent.Unlock()
-		pendingInodes = append(pendingInodes, newDirInode)
 	}
 
 	// Note:The renameDirLimit is not utilized in the folder rename operation because there is no user-defined limit on new renames.
@@ -2260,6 +2842,9 @@ func (fs *fileSystem) renameNonHierarchicalDir(
 	if len(descendants) > int(fs.renameDirLimit) {
 		return fmt.Errorf("too many objects to be renamed: %w", syscall.EMFILE)
 	}
+	if err = fs.checkRenameDirPolicy(oldDir.Name(), inode.NewDirName(newParent.Name(), newName), descendants); err != nil {
+		return err
+	}
 
 	// Create the backing object of the new directory.
 	newParent.Lock()
@@ -2332,12 +2917,30 @@ func (fs *fileSystem) Unlink(
 		ctx, cancel = util.IsolateContextFromParentContext(ctx)
 		defer cancel()
 	}
+	// Find the parent.
+	fs.mu.Lock()
+	parent := fs.dirInodeOrDie(op.Parent)
+	fs.mu.Unlock()
+
+	return fs.unlink(ctx, op.OpContext, parent, op.Name)
+}
+
+// Remove the named child file of the parent.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
+func (fs *fileSystem) unlink(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	parent inode.DirInode,
+	name string) (err error) {
+	auditEntry := fs.startAuditOfChild("unlink", opCtx, parent, name, false)
+	defer func() { auditEntry.Finish(err) }()
 
 	fs.mu.Lock()
 
-	// Find the parent and file name.
-	parent := fs.dirInodeOrDie(op.Parent)
-	fileName := inode.NewFileName(parent.Name(), op.Name)
+	// Find the file name.
+	fileName := inode.NewFileName(parent.Name(), name)
 
 	// Get the inode for the given file.
 	// Files must have an associated inode, which can be found in either:
@@ -2352,6 +2955,17 @@ func (fs *fileSystem) Unlink(
 
 	fs.mu.Unlock()
 
+	if err = fs.policy.CheckDelete(fileName.GcsObjectName()); err != nil {
+		return err
+	}
+
+	// Local files have no backing object to delete.
+	if !isLocalFile {
+		if err = checkPermissions(parent, gcs.PermissionObjectsDelete); err != nil {
+			return err
+		}
+	}
+
 	if in != nil {
 		// Perform the unlink operation on the inode.
 		in.Lock()
@@ -2365,19 +2979,60 @@ func (fs *fileSystem) Unlink(
 		return
 	}
 
-	// Delete the backing object present on GCS.
-	parent.Lock()
-	defer parent.Unlock()
+	// Move the backing object present on GCS to the trash, unless it's there
+	// already.
+	if fs.newConfig.FileSystem.EnableTrash && !gcsx.IsTrashObjectName(fileName.GcsObjectName()) {
+		if err = fs.ensureTrashDir(ctx, parent); err != nil {
+			return err
+		}
 
-	err = parent.DeleteChildFile(
-		ctx,
-		op.Name,
-		0,   // Latest generation
-		nil) // No meta-generation precondition
+		parent.Lock()
+		defer parent.Unlock()
+
+		// The trash object is never replaced. If another removal of the same
+		// path in the same second took the name, try again with a timestamp to
+		// the nanosecond.
+		removed := fs.mtimeClock.Now()
+		trashName := gcsx.TrashObjectName(removed, fileName.GcsObjectName())
+		for attempt := 1; ; attempt++ {
+			err = parent.TrashChildFile(
+				ctx,
+				name,
+				0,   // Latest generation
+				nil, // No meta-generation precondition
+				trashName)
+
+			var preconditionErr *gcs.PreconditionError
+			if errors.As(err, &preconditionErr) && attempt < maxTrashAttempts {
+				removed = later(fs.mtimeClock.Now(), removed)
+				trashName = gcsx.UniqueTrashObjectName(removed, fileName.GcsObjectName())
+				continue
+			}
+			if err == nil {
+				auditEntry.NewObject(trashName)
+			}
+			break
+		}
 
-	if err != nil {
-		err = fmt.Errorf("DeleteChildFile: %w", err)
-		return err
+		if err != nil {
+			err = fmt.Errorf("TrashChildFile: %w", err)
+			return err
+		}
+	} else {
+		// Delete the backing object present on GCS.
+		parent.Lock()
+		defer parent.Unlock()
+
+		err = parent.DeleteChildFile(
+			ctx,
+			name,
+			0,   // Latest generation
+			nil) // No meta-generation precondition
+
+		if err != nil {
+			err = fmt.Errorf("DeleteChildFile: %w", err)
+			return err
+		}
 	}
 
 	if err := fs.invalidateChildFileCacheIfExist(parent, fileName.GcsObjectName()); err != nil {
@@ -2387,6 +3042,60 @@ func (fs *fileSystem) Unlink(
 	return
 }
 
+// The number of names tried for a trashed object before giving up.
+const maxTrashAttempts = 5
+
+// Return t, or if it isn't after prev, the time just after prev.
+func later(t, prev time.Time) time.Time {
+	if t.After(prev) {
+		return t
+	}
+	return prev.Add(time.Nanosecond)
+}
+
+// Create the object for the trash directory in the parent's bucket, if it
+// doesn't exist yet, so that the trash shows up in the root listing without
+// implicit directories. Hierarchical buckets create the folder along with the
+// first trashed object.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(parent)
+func (fs *fileSystem) ensureTrashDir(ctx context.Context, parent inode.DirInode) error {
+	owned, ok := parent.(inode.BucketOwnedInode)
+	if !ok {
+		return nil
+	}
+	bucket := owned.Bucket()
+
+	fs.mu.Lock()
+	exists := fs.trashDirBuckets[bucket.Name()]
+	fs.mu.Unlock()
+
+	if exists || fs.newConfig.EnableHns && bucket.BucketType().Hierarchical {
+		return nil
+	}
+
+	_, err := bucket.CreateObject(
+		ctx,
+		&gcs.CreateObjectRequest{
+			Name:                   gcsx.TrashPrefix,
+			Contents:               strings.NewReader(""),
+			GenerationPrecondition: new(int64),
+		})
+
+	// Special case: *gcs.PreconditionError means the object already exists.
+	var preconditionErr *gcs.PreconditionError
+	if err != nil && !errors.As(err, &preconditionErr) {
+		return fmt.Errorf("CreateObject(%q): %w", gcsx.TrashPrefix, err)
+	}
+
+	fs.mu.Lock()
+	fs.trashDirBuckets[bucket.Name()] = true
+	fs.mu.Unlock()
+
+	return nil
+}
+
 // LOCKS_EXCLUDED(fs.mu)
 func (fs *fileSystem) OpenDir(
 	ctx context.Context,
@@ -2403,15 +3112,16 @@ func (fs *fileSystem) OpenDir(
 	fs.nextHandleID++
 
 	fs.handles[handleID] = handle.NewDirHandle(in, fs.implicitDirs)
+	fs.recordHandleCount(common.DirType, 1)
 	op.Handle = handleID
 
 	fs.mu.Unlock()
 
 	// Enables kernel list-cache in case of non-zero kernelListCacheTTL.
-	if fs.kernelListCacheTTL > 0 {
+	if ttl := time.Duration(fs.kernelListCacheTTL.Load()); ttl > 0 && !fs.perUserCredentials {
 		// Invalidates the kernel list-cache once the last cached response is out of
 		// kernelListCacheTTL.
-		op.KeepCache = !in.ShouldInvalidateKernelListCache(fs.kernelListCacheTTL)
+		op.KeepCache = !in.ShouldInvalidateKernelListCache(ttl)
 
 		op.CacheDir = true
 	}
@@ -2460,6 +3170,7 @@ func (fs *fileSystem) ReleaseDirHandle(
 
 	// Clear the entry from the map.
 	delete(fs.handles, op.Handle)
+	fs.recordHandleCount(common.DirType, -1)
 
 	return
 }
@@ -2478,6 +3189,15 @@ func (fs *fileSystem) OpenFile(
 	in.Lock()
 	defer in.Unlock()
 
+	// Refuse to open for writing if the result can't be uploaded, rather than
+	// failing at close.
+	if !op.OpenFlags.IsReadOnly() {
+		err = fs.checkOpenForWriting(in, op.OpenFlags&syscall.O_APPEND != 0, op.OpenFlags&syscall.O_TRUNC != 0)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Get the fs lock again.
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
@@ -2486,18 +3206,40 @@ func (fs *fileSystem) OpenFile(
 	handleID := fs.nextHandleID
 	fs.nextHandleID++
 
-	fs.handles[handleID] = handle.NewFileHandle(in, fs.fileCacheHandler, fs.cacheFileForRangeRead, fs.metricHandle, op.OpenFlags.IsReadOnly())
+	fs.handles[handleID] = handle.NewFileHandle(in, fs.fileCacheHandler, fs.cacheFileForRangeRead, fs.metricHandle, fs.verifier, op.OpenFlags.IsReadOnly())
+	fs.recordHandleCount(common.FileType, 1)
 	op.Handle = handleID
 
 	// When we observe object generations that we didn't create, we assign them
 	// new inode IDs. So for a given inode, all modifications go through the
 	// kernel. Therefore it's safe to tell the kernel to keep the page cache from
 	// open to open for a given inode.
-	op.KeepPageCache = true
+	//
+	// With per-user credentials, the page cache, which other users would read
+	// from, is bypassed altogether.
+	op.KeepPageCache = !fs.perUserCredentials
+	op.UseDirectIO = fs.perUserCredentials
 
 	return
 }
 
+// Return an error if the file may not be opened for writing, appending or
+// truncating as given, because the result couldn't be uploaded.
+//
+// LOCKS_REQUIRED(in)
+func (fs *fileSystem) checkOpenForWriting(in *inode.FileInode, isAppend, isTrunc bool) error {
+	if err := fs.policy.CheckModify(in.Name().GcsObjectName(), isAppend); err != nil {
+		return err
+	}
+	if isTrunc {
+		if err := fs.checkOverwrite(in); err != nil {
+			return err
+		}
+	}
+
+	return checkPermissions(in, fileWritePermissions(in)...)
+}
+
 // LOCKS_EXCLUDED(fs.mu)
 func (fs *fileSystem) ReadFile(
 	ctx context.Context,
@@ -2521,7 +3263,7 @@ func (fs *fileSystem) ReadFile(
 	defer fh.Unlock()
 
 	// Serve the read.
-	op.Dst, op.BytesRead, err = fh.Read(ctx, op.Dst, op.Offset, fs.sequentialReadSizeMb)
+	op.Dst, op.BytesRead, err = fs.readFile(ctx, fh, op.Dst, op.Offset)
 
 	// As required by fuse, we don't treat EOF as an error.
 	if err == io.EOF {
@@ -2531,6 +3273,27 @@ func (fs *fileSystem) ReadFile(
 	return
 }
 
+// Read from the file of the handle at the offset, recording the access for
+// prefetching. It returns the data read, which may be in a buffer other than
+// dst, and io.EOF at the end of the file.
+//
+// LOCKS_REQUIRED(fh)
+func (fs *fileSystem) readFile(
+	ctx context.Context,
+	fh *handle.FileHandle,
+	dst []byte,
+	offset int64) (output []byte, n int, err error) {
+	output, n, err = fh.Read(ctx, dst, offset, fs.sequentialReadSizeMb)
+
+	if fs.accessRecorder != nil && n > 0 {
+		if o := fh.ReaderObject(); o != nil {
+			fs.accessRecorder.Record(fh.Inode().Bucket().Name(), o.Name, o.Generation, offset, n)
+		}
+	}
+
+	return
+}
+
 // LOCKS_EXCLUDED(fs.mu)
 func (fs *fileSystem) ReadSymlink(
 	ctx context.Context,
@@ -2569,13 +3332,26 @@ func (fs *fileSystem) WriteFile(
 	defer in.Unlock()
 
 	// Serve the request.
-	if err := in.Write(ctx, op.Data, op.Offset); err != nil {
+	if err := fs.writeFile(ctx, in, op.Data, op.Offset); err != nil {
 		return err
 	}
 
 	return
 }
 
+// LOCKS_REQUIRED(in)
+func (fs *fileSystem) writeFile(
+	ctx context.Context,
+	in *inode.FileInode,
+	data []byte,
+	offset int64) error {
+	if err := fs.checkOverwrite(in); err != nil {
+		return err
+	}
+
+	return in.Write(ctx, data, offset)
+}
+
 // LOCKS_EXCLUDED(fs.mu)
 func (fs *fileSystem) SyncFile(
 	ctx context.Context,
@@ -2601,10 +3377,8 @@ func (fs *fileSystem) SyncFile(
 	file.Lock()
 	defer file.Unlock()
 
-	// Sync it.
-	if err := fs.syncFile(ctx, file); err != nil {
-		return err
-	}
+	// Sync it, recording a commit of new contents.
+	err = fs.commitFile(ctx, op.OpContext, file, fs.syncFile)
 
 	return
 }
@@ -2628,9 +3402,27 @@ func (fs *fileSystem) FlushFile(
 	in.Lock()
 	defer in.Unlock()
 
-	// Sync it.
-	if err := fs.flushFile(ctx, in); err != nil {
-		return err
+	// Flush it, recording a commit of new contents.
+	err = fs.commitFile(ctx, op.OpContext, in, fs.flushFile)
+
+	return
+}
+
+// Sync or flush the file with the given function, recording an audit of the
+// write if it failed or committed new contents.
+//
+// LOCKS_REQUIRED(f)
+func (fs *fileSystem) commitFile(
+	ctx context.Context,
+	opCtx fuseops.OpContext,
+	f *inode.FileInode,
+	commit func(context.Context, *inode.FileInode) error) (err error) {
+	auditEntry := fs.startAudit("write", opCtx, f, f.Name())
+	generation := f.SourceGeneration().Object
+	err = commit(ctx, f)
+	if newGeneration := f.SourceGeneration().Object; err != nil || newGeneration != generation {
+		auditEntry.Generation(newGeneration)
+		auditEntry.Finish(err)
 	}
 
 	return
@@ -2646,6 +3438,7 @@ func (fs *fileSystem) ReleaseFileHandle(
 	// Update the map. We are okay updating the map before destroy is called
 	// since destroy is doing only internal cleanup.
 	delete(fs.handles, op.Handle)
+	fs.recordHandleCount(common.FileType, -1)
 	fs.mu.Unlock()
 
 	// Destroy the handle.
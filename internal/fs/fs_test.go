@@ -365,6 +365,24 @@ type fakeBucketManager struct {
 	appendThreshold          int64
 	chunkTransferTimeoutSecs int64
 	tmpObjectPrefix          string
+
+	// The arguments of the last calls to SetRateLimits and SetStatCacheTTLs.
+	opRateLimitHz        float64
+	egressBandwidthLimit float64
+	statCacheTTL         time.Duration
+	negativeStatCacheTTL time.Duration
+}
+
+func (bm *fakeBucketManager) SetRateLimits(opRateLimitHz, egressBandwidthLimit float64) error {
+	bm.opRateLimitHz = opRateLimitHz
+	bm.egressBandwidthLimit = egressBandwidthLimit
+	return nil
+}
+
+func (bm *fakeBucketManager) SetStatCacheTTLs(ttl, negativeTTL time.Duration) error {
+	bm.statCacheTTL = ttl
+	bm.negativeStatCacheTTL = negativeTTL
+	return nil
 }
 
 func (bm *fakeBucketManager) ShutDown() {}
@@ -380,6 +398,7 @@ func (bm *fakeBucketManager) SetUpBucket(
 			bm.tmpObjectPrefix,
 			gcsx.NewContentTypeBucket(bucket),
 		)
+		sb.Permissions, err = gcsx.ProbePermissions(ctx, bucket)
 		return
 	}
 	err = fmt.Errorf("Bucket %q does not exist", name)
@@ -264,6 +264,22 @@ func (dh *DirHandle) ensureEntries(ctx context.Context, localFileEntries map[str
 // Public interface
 ////////////////////////////////////////////////////////////////////////
 
+// Entries reads all the entries of the directory afresh, along with the given
+// entries of local files, sorted by name.
+//
+// LOCKS_REQUIRED(dh.Mu)
+// LOCKS_EXCLUDED(dh.in)
+func (dh *DirHandle) Entries(
+	ctx context.Context,
+	localFileEntries map[string]fuseutil.Dirent) (entries []fuseutil.Dirent, err error) {
+	if err = dh.ensureEntries(ctx, localFileEntries); err != nil {
+		return
+	}
+
+	entries = dh.entries
+	return
+}
+
 // ReadDir handles a request to read from the directory, without responding.
 //
 // Special case: we assume that a zero offset indicates that rewinddir has been
@@ -83,7 +83,9 @@ func (t *DirHandleTest) resetDirHandle() {
 		&t.clock,
 		&t.clock,
 		0,
-		false)
+		false,
+		nil,
+		nil)
 
 	t.dh = NewDirHandle(
 		dirInode,
@@ -103,11 +105,13 @@ func (t *DirHandleTest) createLocalFileInode(name string, id fuseops.InodeID) (i
 		},
 		&t.bucket,
 		false, // localFileCache
-		contentcache.New("", &t.clock),
+		contentcache.New("", &t.clock, nil),
 		&t.clock,
 		true, // localFile
 		&cfg.Config{},
-		semaphore.NewWeighted(math.MaxInt64))
+		semaphore.NewWeighted(math.MaxInt64),
+		nil,
+		nil)
 	return
 }
 
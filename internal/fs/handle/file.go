@@ -22,6 +22,8 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/inode"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/jacobsa/syncutil"
 	"golang.org/x/net/context"
 )
@@ -39,6 +41,12 @@ type FileHandle struct {
 	// GUARDED_BY(mu)
 	reader gcsx.RandomReader
 
+	// The object that the reader served the last read from, or nil if it was
+	// served from the inode's local or dirty content, or failed.
+	//
+	// GUARDED_BY(mu)
+	readObject *gcs.MinObject
+
 	// fileCacheHandler is used to get file cache handle and read happens using that.
 	// This will be nil if the file cache is disabled.
 	fileCacheHandler *file.CacheHandler
@@ -47,6 +55,9 @@ type FileHandle struct {
 	// will be downloaded for random reads as well too.
 	cacheFileForRangeRead bool
 	metricHandle          common.MetricHandle
+	// verifier, if set, is passed to the readers to check objects against the
+	// integrity manifest.
+	verifier *integrity.Verifier
 	// For now, we will consider the files which are open in append mode also as write,
 	// as we are not doing anything special for append. When required we will
 	// define an enum instead of boolean to hold the type of open.
@@ -54,12 +65,13 @@ type FileHandle struct {
 }
 
 // LOCKS_REQUIRED(fh.inode.mu)
-func NewFileHandle(inode *inode.FileInode, fileCacheHandler *file.CacheHandler, cacheFileForRangeRead bool, metricHandle common.MetricHandle, readOnly bool) (fh *FileHandle) {
+func NewFileHandle(inode *inode.FileInode, fileCacheHandler *file.CacheHandler, cacheFileForRangeRead bool, metricHandle common.MetricHandle, verifier *integrity.Verifier, readOnly bool) (fh *FileHandle) {
 	fh = &FileHandle{
 		inode:                 inode,
 		fileCacheHandler:      fileCacheHandler,
 		cacheFileForRangeRead: cacheFileForRangeRead,
 		metricHandle:          metricHandle,
+		verifier:              verifier,
 		readOnly:              readOnly,
 	}
 
@@ -103,6 +115,8 @@ func (fh *FileHandle) Unlock() {
 // LOCKS_REQUIRED(fh)
 // LOCKS_EXCLUDED(fh.inode)
 func (fh *FileHandle) Read(ctx context.Context, dst []byte, offset int64, sequentialReadSizeMb int32) (output []byte, n int, err error) {
+	fh.readObject = nil
+
 	// Lock the inode and attempt to ensure that we have a reader for its current
 	// state, or clear fh.reader if it's not possible to create one (probably
 	// because the inode is dirty).
@@ -123,6 +137,9 @@ func (fh *FileHandle) Read(ctx context.Context, dst []byte, offset int64, sequen
 
 		var objectData gcsx.ObjectData
 		objectData, err = fh.reader.ReadAt(ctx, dst, offset)
+		if err == nil || err == io.EOF {
+			fh.readObject = fh.reader.Object()
+		}
 		switch {
 		case err == io.EOF:
 			return
@@ -146,6 +163,14 @@ func (fh *FileHandle) Read(ctx context.Context, dst []byte, offset int64, sequen
 	return
 }
 
+// ReaderObject returns the object that the reader served the last read from,
+// or nil if it was served from the inode's local or dirty content, or failed.
+//
+// LOCKS_REQUIRED(fh)
+func (fh *FileHandle) ReaderObject() *gcs.MinObject {
+	return fh.readObject
+}
+
 ////////////////////////////////////////////////////////////////////////
 // Helpers
 ////////////////////////////////////////////////////////////////////////
@@ -192,7 +217,7 @@ func (fh *FileHandle) tryEnsureReader(ctx context.Context, sequentialReadSizeMb
 	}
 
 	// Attempt to create an appropriate reader.
-	rr := gcsx.NewRandomReader(fh.inode.Source(), fh.inode.Bucket(), sequentialReadSizeMb, fh.fileCacheHandler, fh.cacheFileForRangeRead, fh.metricHandle, &fh.inode.MRDWrapper)
+	rr := gcsx.NewRandomReader(fh.inode.Source(), fh.inode.Bucket(), sequentialReadSizeMb, fh.fileCacheHandler, fh.cacheFileForRangeRead, fh.metricHandle, &fh.inode.MRDWrapper, fh.verifier)
 
 	fh.reader = rr
 	return
@@ -0,0 +1,516 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/handle"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/inode"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/wrappers"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+// InProcess serves the file system to the process it runs in, by path and
+// without a mount, e.g. as an io/fs.FS. NewFileSystem returns a file system
+// that implements it.
+//
+// Paths are slash-separated and relative to the root, which is named ".", as
+// accepted by io/fs.ValidPath. Errors are the syscall.Errno a mount returns,
+// and ops are audited as if by pid and uid 0.
+type InProcess interface {
+	// Stat returns the attributes of the named file or directory.
+	Stat(ctx context.Context, name string) (fuseops.InodeAttributes, error)
+
+	// Open opens the named file or directory with the given os.O_* flags,
+	// creating the file if os.O_CREATE is set and it doesn't exist. Symbolic
+	// links aren't followed.
+	Open(ctx context.Context, name string, flag int, perm os.FileMode) (*InProcessFile, error)
+
+	// Mkdir creates the named directory.
+	Mkdir(ctx context.Context, name string) error
+
+	// Remove removes the named file or empty directory.
+	Remove(ctx context.Context, name string) error
+
+	// Rename renames a file or directory, like the rename op of a mount.
+	Rename(ctx context.Context, oldName, newName string) error
+
+	// SetMtime sets the modification time of the named file.
+	SetMtime(ctx context.Context, name string, mtime time.Time) error
+
+	// Destroy shuts the file system down.
+	Destroy()
+}
+
+// NewInProcess returns the file system, which must have been returned by
+// NewFileSystem, as an InProcess.
+func NewInProcess(fs fuseutil.FileSystem) InProcess {
+	return &inProcess{fs: fs.(*fileSystem)}
+}
+
+type inProcess struct {
+	fs *fileSystem
+}
+
+// InProcessFile is a file or directory opened through InProcess. It keeps its
+// inode alive until Close is called. Its methods are safe for concurrent use,
+// except Close.
+type InProcessFile struct {
+	fs *fileSystem
+	in inode.Inode
+
+	// Exactly one of these is set.
+	fh *handle.FileHandle
+	dh *handle.DirHandle
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) Stat(ctx context.Context, name string) (attrs fuseops.InodeAttributes, err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	in, err := ip.fs.lookUpPath(ctx, name)
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(in)
+
+	in.Lock()
+	defer in.Unlock()
+
+	return in.Attributes(ctx)
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) Open(ctx context.Context, name string, flag int, perm os.FileMode) (f *InProcessFile, err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	in, created, err := ip.fs.lookUpOrCreatePath(ctx, name, flag, perm)
+	if err != nil {
+		return
+	}
+
+	// From here on, the inode must be released if we fail.
+	defer func() {
+		if err != nil {
+			ip.fs.releaseInode(in)
+			f = nil
+		}
+	}()
+
+	f = &InProcessFile{fs: ip.fs, in: in}
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	switch typed := in.(type) {
+	case inode.DirInode:
+		if writable {
+			err = syscall.EISDIR
+			return
+		}
+		f.dh = handle.NewDirHandle(typed, ip.fs.implicitDirs)
+
+	case *inode.FileInode:
+		typed.Lock()
+		defer typed.Unlock()
+
+		// A file that was just created is open for writing already.
+		if writable && !created {
+			truncate := flag&os.O_TRUNC != 0
+			if err = ip.fs.checkOpenForWriting(typed, flag&os.O_APPEND != 0, truncate); err != nil {
+				return
+			}
+
+			if truncate {
+				var attrs fuseops.InodeAttributes
+				if attrs, err = typed.Attributes(ctx); err != nil {
+					return
+				}
+				if attrs.Size != 0 {
+					var size uint64
+					if err = ip.fs.setAttributes(ctx, fuseops.OpContext{}, typed, &size, nil); err != nil {
+						return
+					}
+				}
+			}
+		}
+
+		f.fh = handle.NewFileHandle(typed, ip.fs.fileCacheHandler, ip.fs.cacheFileForRangeRead, ip.fs.metricHandle, ip.fs.verifier, !writable)
+
+	default:
+		err = syscall.ELOOP
+	}
+
+	return
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) Mkdir(ctx context.Context, name string) (err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	if name == "." {
+		return syscall.EEXIST
+	}
+
+	parent, err := ip.fs.lookUpDirPath(ctx, path.Dir(name))
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(parent)
+
+	child, err := ip.fs.mkDir(ctx, fuseops.OpContext{}, parent, path.Base(name))
+	if err != nil {
+		return
+	}
+	ip.fs.unlockAndDecrementLookupCount(child, 1)
+
+	return
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) Remove(ctx context.Context, name string) (err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	if name == "." {
+		return syscall.EBUSY
+	}
+
+	parent, err := ip.fs.lookUpDirPath(ctx, path.Dir(name))
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(parent)
+
+	base := path.Base(name)
+	child, err := ip.fs.lookUpOrCreateChildInode(ctx, parent, base)
+	if err != nil {
+		return
+	}
+	_, isDir := child.(inode.DirInode)
+	ip.fs.unlockAndDecrementLookupCount(child, 1)
+
+	if isDir {
+		return ip.fs.rmDir(ctx, fuseops.OpContext{}, parent, base)
+	}
+	return ip.fs.unlink(ctx, fuseops.OpContext{}, parent, base)
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) Rename(ctx context.Context, oldName, newName string) (err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	if oldName == "." || newName == "." {
+		return syscall.EBUSY
+	}
+
+	oldParent, err := ip.fs.lookUpDirPath(ctx, path.Dir(oldName))
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(oldParent)
+
+	newParent, err := ip.fs.lookUpDirPath(ctx, path.Dir(newName))
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(newParent)
+
+	return ip.fs.rename(ctx, fuseops.OpContext{}, oldParent, path.Base(oldName), newParent, path.Base(newName))
+}
+
+// LOCKS_EXCLUDED(ip.fs.mu)
+func (ip *inProcess) SetMtime(ctx context.Context, name string, mtime time.Time) (err error) {
+	defer ip.fs.mapInProcessError(&err)
+
+	in, err := ip.fs.lookUpPath(ctx, name)
+	if err != nil {
+		return
+	}
+	defer ip.fs.releaseInode(in)
+
+	in.Lock()
+	defer in.Unlock()
+
+	return ip.fs.setAttributes(ctx, fuseops.OpContext{}, in, nil, &mtime)
+}
+
+func (ip *inProcess) Destroy() {
+	ip.fs.Destroy()
+}
+
+// IsDir reports whether the file is a directory.
+func (f *InProcessFile) IsDir() bool {
+	return f.dh != nil
+}
+
+// Stat returns the attributes of the file.
+//
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) Stat(ctx context.Context) (attrs fuseops.InodeAttributes, err error) {
+	defer f.fs.mapInProcessError(&err)
+
+	f.in.Lock()
+	defer f.in.Unlock()
+
+	return f.in.Attributes(ctx)
+}
+
+// ReadAt reads into p from the file at off, returning io.EOF at the end of the
+// file. Like a read op, it may read fewer bytes than fit in p before then.
+//
+// LOCKS_EXCLUDED(f.fh)
+func (f *InProcessFile) ReadAt(ctx context.Context, p []byte, off int64) (n int, err error) {
+	if f.fh == nil {
+		return 0, syscall.EISDIR
+	}
+
+	f.fh.Lock()
+	defer f.fh.Unlock()
+
+	output, n, err := f.fs.readFile(ctx, f.fh, p, off)
+
+	// The file system may return the data in a buffer of its own.
+	copy(p, output[:n])
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		f.fs.mapInProcessError(&err)
+	}
+
+	return
+}
+
+// WriteAt writes p to the file at off.
+//
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) WriteAt(ctx context.Context, p []byte, off int64) (err error) {
+	defer f.fs.mapInProcessError(&err)
+
+	if f.fh == nil {
+		return syscall.EISDIR
+	}
+
+	in := f.fh.Inode()
+	in.Lock()
+	defer in.Unlock()
+
+	return f.fs.writeFile(ctx, in, p, off)
+}
+
+// Truncate changes the size of the file.
+//
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) Truncate(ctx context.Context, size int64) (err error) {
+	defer f.fs.mapInProcessError(&err)
+
+	if f.fh == nil {
+		return syscall.EISDIR
+	}
+
+	in := f.fh.Inode()
+	in.Lock()
+	defer in.Unlock()
+
+	s := uint64(size)
+	return f.fs.setAttributes(ctx, fuseops.OpContext{}, in, &s, nil)
+}
+
+// Sync writes the file's contents to GCS, like fsync on a mount.
+//
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) Sync(ctx context.Context) (err error) {
+	return f.commit(ctx, f.fs.syncFile)
+}
+
+// Flush writes the file's contents to GCS, like closing a file descriptor on a
+// mount.
+//
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) Flush(ctx context.Context) (err error) {
+	return f.commit(ctx, f.fs.flushFile)
+}
+
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) commit(ctx context.Context, commit func(context.Context, *inode.FileInode) error) (err error) {
+	defer f.fs.mapInProcessError(&err)
+
+	if f.fh == nil {
+		return syscall.EISDIR
+	}
+
+	in := f.fh.Inode()
+	in.Lock()
+	defer in.Unlock()
+
+	return f.fs.commitFile(ctx, fuseops.OpContext{}, in, commit)
+}
+
+// ReadDir reads all the entries of the directory afresh, sorted by name.
+//
+// LOCKS_EXCLUDED(f.fs.mu)
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) ReadDir(ctx context.Context) (entries []fuseutil.Dirent, err error) {
+	defer f.fs.mapInProcessError(&err)
+
+	if f.dh == nil {
+		return nil, syscall.ENOTDIR
+	}
+
+	// Fetch local file entries beforehand, as we need the fs lock for them.
+	f.fs.mu.Lock()
+	localFileEntries := f.in.(inode.DirInode).LocalFileEntries(f.fs.localFileInodes)
+	f.fs.mu.Unlock()
+
+	f.dh.Mu.Lock()
+	defer f.dh.Mu.Unlock()
+
+	return f.dh.Entries(ctx, localFileEntries)
+}
+
+// Close closes the file, without flushing it. It must not be used afterwards.
+//
+// LOCKS_EXCLUDED(f.fs.mu)
+// LOCKS_EXCLUDED(f.in)
+func (f *InProcessFile) Close() {
+	if f.fh != nil {
+		f.fh.Lock()
+		f.fh.Destroy()
+		f.fh.Unlock()
+	}
+
+	f.fs.releaseInode(f.in)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+// Map the error of an in-process op to the errno a mount returns for it.
+func (fs *fileSystem) mapInProcessError(err *error) {
+	*err = wrappers.Errno(*err, fs.newConfig.FileSystem.PreconditionErrors)
+}
+
+// Drop the reference to the inode taken by a lookup.
+//
+// LOCKS_EXCLUDED(fs.mu)
+// LOCKS_EXCLUDED(in)
+func (fs *fileSystem) releaseInode(in inode.Inode) {
+	in.Lock()
+	fs.unlockAndDecrementLookupCount(in, 1)
+}
+
+// Look up the inode at the path, one component at a time like the kernel
+// does, returning it unlocked with its lookup count incremented. The caller
+// must release it.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) lookUpPath(ctx context.Context, name string) (in inode.Inode, err error) {
+	fs.mu.Lock()
+	in = fs.inodeOrDie(fuseops.RootInodeID)
+	fs.mu.Unlock()
+
+	in.Lock()
+	in.IncrementLookupCount()
+	in.Unlock()
+
+	if name == "." {
+		return
+	}
+
+	for _, component := range strings.Split(name, "/") {
+		parent, ok := in.(inode.DirInode)
+		if !ok {
+			fs.releaseInode(in)
+			return nil, syscall.ENOTDIR
+		}
+
+		in, err = fs.lookUpOrCreateChildInode(ctx, parent, component)
+		if err == nil {
+			in.Unlock()
+		}
+		fs.releaseInode(parent)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Like lookUpPath, for a directory.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) lookUpDirPath(ctx context.Context, name string) (dir inode.DirInode, err error) {
+	in, err := fs.lookUpPath(ctx, name)
+	if err != nil {
+		return
+	}
+
+	dir, ok := in.(inode.DirInode)
+	if !ok {
+		fs.releaseInode(in)
+		return nil, syscall.ENOTDIR
+	}
+
+	return
+}
+
+// Look up the named file, creating it if the os.O_* flags say so. The inode is
+// returned like by lookUpPath.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *fileSystem) lookUpOrCreatePath(
+	ctx context.Context,
+	name string,
+	flag int,
+	perm os.FileMode) (in inode.Inode, created bool, err error) {
+	if name == "." {
+		in, err = fs.lookUpPath(ctx, name)
+		return
+	}
+
+	parent, err := fs.lookUpDirPath(ctx, path.Dir(name))
+	if err != nil {
+		return
+	}
+	defer fs.releaseInode(parent)
+
+	base := path.Base(name)
+	in, err = fs.lookUpOrCreateChildInode(ctx, parent, base)
+	switch {
+	case err == nil:
+		in.Unlock()
+		if flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0 {
+			fs.releaseInode(in)
+			return nil, false, syscall.EEXIST
+		}
+
+	case errors.Is(err, syscall.ENOENT) && flag&os.O_CREATE != 0:
+		var child *inode.FileInode
+		if child, err = fs.create(ctx, fuseops.OpContext{}, parent, base, perm.Perm()); err != nil {
+			return nil, false, err
+		}
+		child.Unlock()
+		in, created = child, true
+	}
+
+	return
+}
@@ -208,6 +208,10 @@ func (d *baseDirInode) InsertFileIntoTypeCache(_ string) {}
 
 func (d *baseDirInode) EraseFromTypeCache(_ string) {}
 
+func (d *baseDirInode) ClearTypeCache() {}
+
+func (d *baseDirInode) SetTypeCacheTTL(_ time.Duration) {}
+
 func (d *baseDirInode) CreateLocalChildFileCore(_ string) (Core, error) {
 	return Core{}, fuse.ENOSYS
 }
@@ -233,6 +237,16 @@ func (d *baseDirInode) DeleteChildFile(
 	return
 }
 
+func (d *baseDirInode) TrashChildFile(
+	ctx context.Context,
+	name string,
+	generation int64,
+	metaGeneration *int64,
+	trashName string) (err error) {
+	err = fuse.ENOSYS
+	return
+}
+
 func (d *baseDirInode) DeleteChildDir(
 	ctx context.Context,
 	name string,
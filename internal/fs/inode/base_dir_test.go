@@ -104,6 +104,14 @@ func (bm *fakeBucketManager) SetUpBucket(
 	return
 }
 
+func (bm *fakeBucketManager) SetRateLimits(float64, float64) error {
+	return gcsx.ErrRemountRequired
+}
+
+func (bm *fakeBucketManager) SetStatCacheTTLs(time.Duration, time.Duration) error {
+	return gcsx.ErrRemountRequired
+}
+
 func (bm *fakeBucketManager) ShutDown() {}
 
 func (bm *fakeBucketManager) SetUpTimes() int {
@@ -18,10 +18,14 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/metadata"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/policy"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
@@ -105,6 +109,13 @@ type DirInode interface {
 	// EraseFromTypeCache removes the given name from type-cache
 	EraseFromTypeCache(name string)
 
+	// ClearTypeCache removes all the names from type-cache
+	ClearTypeCache()
+
+	// SetTypeCacheTTL changes the TTL of the names inserted into type-cache
+	// from now on.
+	SetTypeCacheTTL(ttl time.Duration)
+
 	// Like CreateChildFile, except clone the supplied source object instead of
 	// creating an empty object.
 	// Return the full name of the child and the GCS object it backs up.
@@ -134,6 +145,17 @@ type DirInode interface {
 		generation int64,
 		metaGeneration *int64) (err error)
 
+	// Like DeleteChildFile, but move the backing object to the given trash
+	// object name rather than deleting it. If the object/generation doesn't
+	// exist, no error is returned. The trash object is only created, never
+	// replaced: if it exists, a *gcs.PreconditionError is returned.
+	TrashChildFile(
+		ctx context.Context,
+		name string,
+		generation int64,
+		metaGeneration *int64,
+		trashName string) (err error)
+
 	// Delete the backing object for the child directory with the given
 	// (relative) name if it is not an Implicit Directory.
 	DeleteChildDir(
@@ -228,6 +250,15 @@ type dirInode struct {
 	// Represents if folder has been unlinked in hierarchical bucket. This is not getting used in
 	// non-hierarchical bucket.
 	unlinked bool
+
+	// Children hidden by the policy are left out of listings and lookups.
+	policy *policy.Policy
+
+	// The UIDs of the callers with a partition of the type cache, as returned
+	// by typeCacheKey.
+	//
+	// GUARDED_BY(mu)
+	typeCacheUIDs map[uint32]struct{}
 }
 
 var _ DirInode = &dirInode{}
@@ -264,6 +295,8 @@ func NewDirInode(
 	cacheClock timeutil.Clock,
 	typeCacheMaxSizeMB int64,
 	isHNSEnabled bool,
+	policy *policy.Policy,
+	metricHandle common.MetricHandle,
 ) (d DirInode) {
 
 	if !name.IsDir() {
@@ -280,8 +313,9 @@ func NewDirInode(
 		enableNonexistentTypeCache: enableNonexistentTypeCache,
 		name:                       name,
 		attrs:                      attrs,
-		cache:                      metadata.NewTypeCache(typeCacheMaxSizeMB, typeCacheTTL),
+		cache:                      metadata.NewTypeCache(typeCacheMaxSizeMB, typeCacheTTL, metricHandle),
 		isHNSEnabled:               isHNSEnabled,
+		policy:                     policy,
 		unlinked:                   false,
 	}
 
@@ -298,6 +332,49 @@ func NewDirInode(
 // Helpers
 ////////////////////////////////////////////////////////////////////////
 
+// The type cache key for the given child name in the given UID's partition.
+// Child names can't contain "/", so keys of different partitions don't clash.
+func partitionedTypeCacheKey(uid uint32, name string) string {
+	return strconv.FormatUint(uint64(uid), 10) + "/" + name
+}
+
+// The type cache key for the given child name. On mounts with per-user
+// credentials, the cache is partitioned by the caller's UID so that users
+// don't learn about children they can't see.
+//
+// LOCKS_REQUIRED(d)
+func (d *dirInode) typeCacheKey(ctx context.Context, name string) string {
+	uid, ok := auth.CallerUID(ctx)
+	if !ok {
+		return name
+	}
+
+	if d.typeCacheUIDs == nil {
+		d.typeCacheUIDs = make(map[uint32]struct{})
+	}
+	d.typeCacheUIDs[uid] = struct{}{}
+	return partitionedTypeCacheKey(uid, name)
+}
+
+// Erase the type of the given child from every partition of the type cache.
+//
+// LOCKS_REQUIRED(d)
+func (d *dirInode) eraseFromTypeCache(name string) {
+	d.cache.Erase(name)
+	for uid := range d.typeCacheUIDs {
+		d.cache.Erase(partitionedTypeCacheKey(uid, name))
+	}
+}
+
+// Record the type of a child the caller just created. Other partitions may
+// hold a stale type, or its absence, for the name, so it is erased from them.
+//
+// LOCKS_REQUIRED(d)
+func (d *dirInode) insertCreatedIntoTypeCache(ctx context.Context, name string, t metadata.Type) {
+	d.eraseFromTypeCache(name)
+	d.cache.Insert(d.cacheClock.Now(), d.typeCacheKey(ctx, name), t)
+}
+
 func (d *dirInode) checkInvariants() {
 	// INVARIANT: d.name.IsDir()
 	if !d.name.IsDir() {
@@ -506,7 +583,9 @@ func (d *dirInode) DecrementLookupCount(n uint64) (destroy bool) {
 
 // LOCKS_REQUIRED(d)
 func (d *dirInode) Destroy() (err error) {
-	// Nothing interesting to do.
+	// Drop the type cache's entries, so that they no longer count towards the
+	// cache metrics.
+	d.cache.EraseAll()
 	return
 }
 
@@ -534,6 +613,11 @@ const ConflictingFileNameSuffix = "\n"
 
 // LOCKS_REQUIRED(d)
 func (d *dirInode) LookUpChild(ctx context.Context, name string) (*Core, error) {
+	// Hidden children don't exist as far as the kernel is concerned.
+	if d.policy.IsHidden(NewFileName(d.Name(), strings.TrimSuffix(name, ConflictingFileNameSuffix)).GcsObjectName()) {
+		return nil, nil
+	}
+
 	// Is this a conflict marker name?
 	if strings.HasSuffix(name, ConflictingFileNameSuffix) {
 		return d.lookUpConflicting(ctx, name)
@@ -560,7 +644,7 @@ func (d *dirInode) LookUpChild(ctx context.Context, name string) (*Core, error)
 		return
 	}
 
-	cachedType := d.cache.Get(d.cacheClock.Now(), name)
+	cachedType := d.cache.Get(d.cacheClock.Now(), d.typeCacheKey(ctx, name))
 	switch cachedType {
 	case metadata.ImplicitDirType:
 		dirResult = &Core{
@@ -604,9 +688,9 @@ func (d *dirInode) LookUpChild(ctx context.Context, name string) (*Core, error)
 	}
 
 	if result != nil {
-		d.cache.Insert(d.cacheClock.Now(), name, result.Type())
+		d.cache.Insert(d.cacheClock.Now(), d.typeCacheKey(ctx, name), result.Type())
 	} else if d.enableNonexistentTypeCache && cachedType == metadata.UnknownType {
-		d.cache.Insert(d.cacheClock.Now(), name, metadata.NonexistentType)
+		d.cache.Insert(d.cacheClock.Now(), d.typeCacheKey(ctx, name), metadata.NonexistentType)
 	}
 
 	return result, nil
@@ -687,9 +771,15 @@ func (d *dirInode) readObjects(
 
 	cores = make(map[Name]*Core)
 	defer func() {
+		for fullName := range cores {
+			if d.policy.IsHidden(fullName.GcsObjectName()) {
+				delete(cores, fullName)
+			}
+		}
+
 		now := d.cacheClock.Now()
 		for fullName, c := range cores {
-			d.cache.Insert(now, path.Base(fullName.LocalName()), c.Type())
+			d.cache.Insert(now, d.typeCacheKey(ctx, path.Base(fullName.LocalName())), c.Type())
 		}
 	}()
 
@@ -814,7 +904,7 @@ func (d *dirInode) CreateChildFile(ctx context.Context, name string) (*Core, err
 	}
 	m := storageutil.ConvertObjToMinObject(o)
 
-	d.cache.Insert(d.cacheClock.Now(), name, metadata.RegularFileType)
+	d.insertCreatedIntoTypeCache(ctx, name, metadata.RegularFileType)
 	return &Core{
 		Bucket:    d.Bucket(),
 		FullName:  fullName,
@@ -833,18 +923,29 @@ func (d *dirInode) CreateLocalChildFileCore(name string) (Core, error) {
 
 // LOCKS_REQUIRED(d)
 func (d *dirInode) InsertFileIntoTypeCache(name string) {
+	d.eraseFromTypeCache(name)
 	d.cache.Insert(d.cacheClock.Now(), name, metadata.RegularFileType)
 }
 
 // LOCKS_REQUIRED(d)
 func (d *dirInode) EraseFromTypeCache(name string) {
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
+}
+
+// LOCKS_REQUIRED(d)
+func (d *dirInode) ClearTypeCache() {
+	d.cache.EraseAll()
+}
+
+// LOCKS_REQUIRED(d)
+func (d *dirInode) SetTypeCacheTTL(ttl time.Duration) {
+	d.cache.SetTTL(ttl)
 }
 
 // LOCKS_REQUIRED(d)
 func (d *dirInode) CloneToChildFile(ctx context.Context, name string, src *gcs.MinObject) (*Core, error) {
 	// Erase any existing type information for this name.
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
 	fullName := NewFileName(d.Name(), name)
 
 	// Clone over anything that might already exist for the name.
@@ -866,7 +967,7 @@ func (d *dirInode) CloneToChildFile(ctx context.Context, name string, src *gcs.M
 		FullName:  fullName,
 		MinObject: m,
 	}
-	d.cache.Insert(d.cacheClock.Now(), name, c.Type())
+	d.insertCreatedIntoTypeCache(ctx, name, c.Type())
 	return c, nil
 }
 
@@ -883,7 +984,7 @@ func (d *dirInode) CreateChildSymlink(ctx context.Context, name string, target s
 	}
 	m := storageutil.ConvertObjToMinObject(o)
 
-	d.cache.Insert(d.cacheClock.Now(), name, metadata.SymlinkType)
+	d.insertCreatedIntoTypeCache(ctx, name, metadata.SymlinkType)
 
 	return &Core{
 		Bucket:    d.Bucket(),
@@ -919,7 +1020,7 @@ func (d *dirInode) CreateChildDir(ctx context.Context, name string) (*Core, erro
 	}
 
 	// Insert the new directory into the type cache.
-	d.cache.Insert(d.cacheClock.Now(), name, metadata.ExplicitDirType)
+	d.insertCreatedIntoTypeCache(ctx, name, metadata.ExplicitDirType)
 
 	return &Core{
 		Bucket:    d.Bucket(),
@@ -935,7 +1036,7 @@ func (d *dirInode) DeleteChildFile(
 	name string,
 	generation int64,
 	metaGeneration *int64) (err error) {
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
 	childName := NewFileName(d.Name(), name)
 
 	err = d.bucket.DeleteObject(
@@ -950,18 +1051,108 @@ func (d *dirInode) DeleteChildFile(
 		err = fmt.Errorf("DeleteObject: %w", err)
 		return
 	}
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
+
+	return
+}
+
+// LOCKS_REQUIRED(d)
+func (d *dirInode) TrashChildFile(
+	ctx context.Context,
+	name string,
+	generation int64,
+	metaGeneration *int64,
+	trashName string) (err error) {
+	d.eraseFromTypeCache(name)
+	childName := NewFileName(d.Name(), name)
+	var doesNotExist int64
+
+	// Hierarchical buckets can move the object atomically.
+	if d.isBucketHierarchical() {
+		_, err = d.bucket.MoveObject(
+			ctx,
+			&gcs.MoveObjectRequest{
+				SrcName:                       childName.GcsObjectName(),
+				DstName:                       trashName,
+				SrcGeneration:                 generation,
+				SrcMetaGenerationPrecondition: metaGeneration,
+				DstGenerationPrecondition:     &doesNotExist,
+			})
+	} else {
+		err = d.copyToTrash(ctx, childName.GcsObjectName(), generation, metaGeneration, trashName)
+	}
+
+	// The object being gone already is fine, unless the latest generation was
+	// asked for and another one replaced it in the meantime.
+	var notFoundErr *gcs.NotFoundError
+	if errors.As(err, &notFoundErr) && (generation != 0 || !d.hasLiveObject(ctx, childName.GcsObjectName())) {
+		err = nil
+	}
+
+	if err != nil {
+		err = fmt.Errorf("trash %q: %w", childName.GcsObjectName(), err)
+		return
+	}
+	d.eraseFromTypeCache(name)
 
 	return
 }
 
+// Copy the object to the trash and delete behind, making sure to delete
+// exactly the generation copied.
+func (d *dirInode) copyToTrash(
+	ctx context.Context,
+	name string,
+	generation int64,
+	metaGeneration *int64,
+	trashName string) error {
+	var doesNotExist int64
+	if generation == 0 {
+		// Bypass the stat cache, whose generation may have been replaced.
+		m, _, err := d.bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: name, ForceFetchFromGcs: true})
+		if err != nil {
+			return err
+		}
+		generation = m.Generation
+	}
+
+	_, err := d.bucket.CopyObject(
+		ctx,
+		&gcs.CopyObjectRequest{
+			SrcName:                       name,
+			DstName:                       trashName,
+			SrcGeneration:                 generation,
+			SrcMetaGenerationPrecondition: metaGeneration,
+			DstGenerationPrecondition:     &doesNotExist,
+		})
+	if err != nil {
+		return err
+	}
+
+	return d.bucket.DeleteObject(
+		ctx,
+		&gcs.DeleteObjectRequest{
+			Name:                       name,
+			Generation:                 generation,
+			MetaGenerationPrecondition: metaGeneration,
+		})
+}
+
+// Return true unless GCS says there's no object with the given name. Errors
+// count as a live object, so that the caller doesn't report it gone.
+func (d *dirInode) hasLiveObject(ctx context.Context, name string) bool {
+	_, _, err := d.bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: name, ForceFetchFromGcs: true})
+	var notFoundErr *gcs.NotFoundError
+	return !errors.As(err, &notFoundErr)
+}
+
 // LOCKS_REQUIRED(d)
 func (d *dirInode) DeleteChildDir(
 	ctx context.Context,
 	name string,
 	isImplicitDir bool,
 	dirInode DirInode) error {
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
 
 	// If the directory is an implicit directory, then no backing object
 	// exists in the gcs bucket, so returning from here.
@@ -985,7 +1176,7 @@ func (d *dirInode) DeleteChildDir(
 		if err != nil {
 			return fmt.Errorf("DeleteObject: %w", err)
 		}
-		d.cache.Erase(name)
+		d.eraseFromTypeCache(name)
 		return nil
 	}
 
@@ -1000,7 +1191,7 @@ func (d *dirInode) DeleteChildDir(
 		dirInode.Unlink()
 	}
 
-	d.cache.Erase(name)
+	d.eraseFromTypeCache(name)
 	return nil
 }
 
@@ -1054,7 +1245,7 @@ func (d *dirInode) RenameFile(ctx context.Context, fileToRename *gcs.MinObject,
 	o, err := d.bucket.MoveObject(ctx, req)
 
 	// Invalidate the cache entry for the old object name.
-	d.cache.Erase(fileToRename.Name)
+	d.eraseFromTypeCache(fileToRename.Name)
 
 	return o, err
 }
@@ -1067,7 +1258,7 @@ func (d *dirInode) RenameFolder(ctx context.Context, folderName string, destinat
 
 	// TODO: Cache updates won't be necessary once type cache usage is removed from HNS.
 	// Remove old entry from type cache.
-	d.cache.Erase(folderName)
+	d.eraseFromTypeCache(folderName)
 
 	return folder, nil
 }
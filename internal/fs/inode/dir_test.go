@@ -24,8 +24,12 @@ import (
 	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/metadata"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/contentcache"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/policy"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/caching"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
@@ -122,6 +126,8 @@ func (t *DirTest) resetInodeWithTypeCacheConfigs(implicitDirs, enableNonexistent
 		&t.clock,
 		typeCacheMaxSizeMB,
 		false,
+		nil,
+		nil,
 	)
 
 	d := t.in.(*dirInode)
@@ -150,6 +156,8 @@ func (t *DirTest) createDirInode(dirInodeName string) DirInode {
 		&t.clock,
 		4,
 		false,
+		nil,
+		nil,
 	)
 }
 
@@ -204,11 +212,13 @@ func (t *DirTest) createLocalFileInode(parent Name, name string, id fuseops.Inod
 		},
 		&t.bucket,
 		false, // localFileCache
-		contentcache.New("", &t.clock),
+		contentcache.New("", &t.clock, nil),
 		&t.clock,
 		true, //localFile
 		&cfg.Config{},
-		semaphore.NewWeighted(math.MaxInt64))
+		semaphore.NewWeighted(math.MaxInt64),
+		nil,
+		nil)
 	return
 }
 
@@ -286,6 +296,24 @@ func (t *DirTest) LookUpChild_FileOnly() {
 	ExpectEq(metadata.UnknownType, t.getTypeFromCache(name+ConflictingFileNameSuffix))
 }
 
+func (t *DirTest) LookUpChild_HiddenByPolicy() {
+	var err error
+	t.in.(*dirInode).policy, err = policy.New(&cfg.AccessPolicyConfig{Hidden: []string{"**/.secret*"}})
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(t.ctx, t.bucket, path.Join(dirInodeName, ".secret"), []byte("taco"))
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(t.ctx, t.bucket, path.Join(dirInodeName, ".secrets")+"/", []byte(""))
+	AssertEq(nil, err)
+
+	fileResult, err := t.in.LookUpChild(t.ctx, ".secret")
+	AssertEq(nil, err)
+	dirResult, err := t.in.LookUpChild(t.ctx, ".secrets")
+	AssertEq(nil, err)
+
+	ExpectEq(nil, fileResult)
+	ExpectEq(nil, dirResult)
+}
+
 func (t *DirTest) LookUpChild_DirOnly() {
 	const name = "qux"
 	objName := path.Join(dirInodeName, name) + "/"
@@ -846,6 +874,26 @@ func (t *DirTest) ReadEntries_NonEmpty_ImplicitDirsDisabled() {
 	AssertFalse(d.prevDirListingTimeStamp.IsZero())
 }
 
+func (t *DirTest) ReadEntries_HiddenByPolicy() {
+	var err error
+	t.in.(*dirInode).policy, err = policy.New(&cfg.AccessPolicyConfig{Hidden: []string{"**/.secret*"}})
+	AssertEq(nil, err)
+	objs := []string{
+		dirInodeName + ".secret",
+		dirInodeName + ".secrets/",
+		dirInodeName + "file",
+	}
+	err = storageutil.CreateEmptyObjects(t.ctx, t.bucket, objs)
+	AssertEq(nil, err)
+
+	entries, err := t.readAllEntries()
+
+	AssertEq(nil, err)
+	AssertEq(1, len(entries))
+	ExpectEq("file", entries[0].Name)
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache(".secret"))
+}
+
 func (t *DirTest) ReadEntries_NonEmpty_ImplicitDirsEnabled() {
 	var err error
 	var entry fuseutil.Dirent
@@ -1364,6 +1412,100 @@ func (t *DirTest) DeleteChildFile_ParticularGenerationAndMetaGeneration() {
 	ExpectTrue(errors.As(err, &notFoundErr))
 }
 
+func (t *DirTest) TrashChildFile_LatestGeneration() {
+	const name = "qux"
+	const trashName = ".trash/20250102T030405Z/qux"
+	objName := path.Join(dirInodeName, name)
+
+	// Create a backing object.
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, objName, []byte("taco"))
+	AssertEq(nil, err)
+
+	// Call the inode.
+	err = t.in.TrashChildFile(t.ctx, name, 0, nil, trashName)
+	AssertEq(nil, err)
+
+	// The object should have moved to the trash.
+	_, err = storageutil.ReadObject(t.ctx, t.bucket, objName)
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+	contents, err := storageutil.ReadObject(t.ctx, t.bucket, trashName)
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache(name))
+}
+
+func (t *DirTest) TrashChildFile_TrashNameTaken() {
+	const name = "qux"
+	const trashName = ".trash/20250102T030405Z/qux"
+	objName := path.Join(dirInodeName, name)
+
+	// Create a backing object, and an earlier trashed object with the same name.
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, objName, []byte("taco"))
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(t.ctx, t.bucket, trashName, []byte("burrito"))
+	AssertEq(nil, err)
+
+	// Call the inode.
+	err = t.in.TrashChildFile(t.ctx, name, 0, nil, trashName)
+
+	// Neither object should have been touched.
+	var preconditionErr *gcs.PreconditionError
+	ExpectTrue(errors.As(err, &preconditionErr))
+	contents, err := storageutil.ReadObject(t.ctx, t.bucket, objName)
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+	contents, err = storageutil.ReadObject(t.ctx, t.bucket, trashName)
+	AssertEq(nil, err)
+	ExpectEq("burrito", string(contents))
+}
+
+func (t *DirTest) TrashChildFile_ReplacedSinceCachedStat() {
+	const name = "qux"
+	const trashName = ".trash/20250102T030405Z/qux"
+	objName := path.Join(dirInodeName, name)
+
+	// Serve stats from a cache, primed before the object is replaced behind it.
+	wrapped := fake.NewFakeBucket(&t.clock, "some_bucket", gcs.BucketType{})
+	statCache := metadata.NewStatCacheBucketView(lru.NewCache(1<<20), "")
+	t.bucket = gcsx.NewSyncerBucket(
+		1, // Append threshold
+		ChunkTransferTimeoutSecs,
+		".gcsfuse_tmp/",
+		caching.NewFastStatBucket(time.Hour, statCache, &t.clock, wrapped, time.Hour))
+	t.resetInode(false, false, true)
+	_, err := storageutil.CreateObject(t.ctx, wrapped, objName, []byte("taco"))
+	AssertEq(nil, err)
+	_, _, err = t.bucket.StatObject(t.ctx, &gcs.StatObjectRequest{Name: objName})
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(t.ctx, wrapped, objName, []byte("burrito"))
+	AssertEq(nil, err)
+
+	// Call the inode.
+	err = t.in.TrashChildFile(t.ctx, name, 0, nil, trashName)
+	AssertEq(nil, err)
+
+	// The live object should have moved to the trash.
+	_, err = storageutil.ReadObject(t.ctx, wrapped, objName)
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+	contents, err := storageutil.ReadObject(t.ctx, wrapped, trashName)
+	AssertEq(nil, err)
+	ExpectEq("burrito", string(contents))
+}
+
+func (t *DirTest) TrashChildFile_DoesntExist() {
+	const name = "qux"
+	const trashName = ".trash/20250102T030405Z/qux"
+
+	err := t.in.TrashChildFile(t.ctx, name, 0, nil, trashName)
+	ExpectEq(nil, err)
+
+	_, err = storageutil.ReadObject(t.ctx, t.bucket, trashName)
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+}
+
 func (t *DirTest) DeleteChildFile_TypeCaching() {
 	const name = "qux"
 	fileObjName := path.Join(dirInodeName, name)
@@ -1454,6 +1596,50 @@ func (t *DirTest) LocalChildFileCore() {
 	ExpectEq(metadata.UnknownType, t.getTypeFromCache("qux"))
 }
 
+func (t *DirTest) LookUpChild_TypeCachePartitionedByCaller() {
+	const name = "qux"
+	alice := auth.WithCallerUID(t.ctx, 1000)
+	bob := auth.WithCallerUID(t.ctx, 1001)
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, path.Join(dirInodeName, name), []byte("taco"))
+	AssertEq(nil, err)
+
+	result, err := t.in.LookUpChild(alice, name)
+
+	AssertEq(nil, err)
+	AssertNe(nil, result)
+	ExpectEq(metadata.RegularFileType, t.getTypeFromCache("1000/"+name))
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache("1001/"+name))
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache(name))
+
+	// Deleting as one caller erases every caller's entry.
+	_, err = t.in.LookUpChild(bob, name)
+	AssertEq(nil, err)
+	err = t.in.DeleteChildFile(alice, name, 0, nil)
+	AssertEq(nil, err)
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache("1000/"+name))
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache("1001/"+name))
+}
+
+func (t *DirTest) CreateChildFile_ErasesOtherCallersTypeCacheEntries() {
+	const name = "qux"
+	alice := auth.WithCallerUID(t.ctx, 1000)
+	bob := auth.WithCallerUID(t.ctx, 1001)
+	t.resetInode(false, true, false)
+	result, err := t.in.LookUpChild(bob, name)
+	AssertEq(nil, err)
+	AssertEq(nil, result)
+	AssertEq(metadata.NonexistentType, t.getTypeFromCache("1001/"+name))
+
+	_, err = t.in.CreateChildFile(alice, name)
+
+	AssertEq(nil, err)
+	ExpectEq(metadata.RegularFileType, t.getTypeFromCache("1000/"+name))
+	ExpectEq(metadata.UnknownType, t.getTypeFromCache("1001/"+name))
+	result, err = t.in.LookUpChild(bob, name)
+	AssertEq(nil, err)
+	ExpectNe(nil, result)
+}
+
 func (t *DirTest) InsertIntoTypeCache() {
 	t.in.InsertFileIntoTypeCache("abc")
 
@@ -1472,6 +1658,27 @@ func (t *DirTest) EraseFromTypeCache() {
 	AssertEq(0, tp)
 }
 
+func (t *DirTest) ClearTypeCache() {
+	t.in.InsertFileIntoTypeCache("abc")
+	t.in.InsertFileIntoTypeCache("def")
+
+	t.in.ClearTypeCache()
+
+	d := t.in.(*dirInode)
+	AssertEq(0, d.cache.Get(d.cacheClock.Now(), "abc"))
+	AssertEq(0, d.cache.Get(d.cacheClock.Now(), "def"))
+}
+
+func (t *DirTest) SetTypeCacheTTL() {
+	t.in.SetTypeCacheTTL(time.Hour)
+	t.in.InsertFileIntoTypeCache("abc")
+
+	t.clock.AdvanceTime(typeCacheTTL + time.Millisecond)
+
+	d := t.in.(*dirInode)
+	AssertEq(metadata.RegularFileType, d.cache.Get(d.cacheClock.Now(), "abc"))
+}
+
 func (t *DirTest) LocalFileEntriesEmpty() {
 	localFileInodes := map[Name]Inode{}
 
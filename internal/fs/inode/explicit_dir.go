@@ -17,6 +17,8 @@ package inode
 import (
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/policy"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/jacobsa/fuse/fuseops"
@@ -45,7 +47,9 @@ func NewExplicitDirInode(
 	mtimeClock timeutil.Clock,
 	cacheClock timeutil.Clock,
 	typeCacheMaxSizeMB int64,
-	enableHNS bool) (d ExplicitDirInode) {
+	enableHNS bool,
+	policy *policy.Policy,
+	metricHandle common.MetricHandle) (d ExplicitDirInode) {
 	wrapped := NewDirInode(
 		id,
 		name,
@@ -58,7 +62,9 @@ func NewExplicitDirInode(
 		mtimeClock,
 		cacheClock,
 		typeCacheMaxSizeMB,
-		enableHNS)
+		enableHNS,
+		policy,
+		metricHandle)
 
 	dirInode := &explicitDirInode{
 		dirInode: wrapped.(*dirInode),
@@ -20,19 +20,24 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/bufferedwrites"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/contentcache"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
 	"github.com/jacobsa/fuse/fuseops"
 	"github.com/jacobsa/syncutil"
 	"github.com/jacobsa/timeutil"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/semaphore"
 )
@@ -83,6 +88,13 @@ type FileInode struct {
 	// authoritative.
 	content gcsx.TempFile
 
+	// With per-user credentials, the UIDs of the callers that content may be
+	// served to: the one that fetched or created it, and those whose
+	// credentials were since checked against the source object.
+	//
+	// GUARDED_BY(mu)
+	contentUIDs map[uint32]struct{}
+
 	// Has Destroy been called?
 	//
 	// GUARDED_BY(mu)
@@ -116,6 +128,13 @@ type FileInode struct {
 	// Limits the max number of blocks that can be created across file system when
 	// streaming writes are enabled.
 	globalMaxWriteBlocksSem *semaphore.Weighted
+
+	// Records the state of the buffered writes and the MRDWrapper.
+	metricHandle common.MetricHandle
+
+	// verifier, if set, checks the contents fetched from GCS against the
+	// integrity manifest before they are first used.
+	verifier *integrity.Verifier
 }
 
 var _ Inode = &FileInode{}
@@ -139,7 +158,9 @@ func NewFileInode(
 	mtimeClock timeutil.Clock,
 	localFile bool,
 	cfg *cfg.Config,
-	globalMaxBlocksSem *semaphore.Weighted) (f *FileInode) {
+	globalMaxBlocksSem *semaphore.Weighted,
+	metricHandle common.MetricHandle,
+	verifier *integrity.Verifier) (f *FileInode) {
 	// Set up the basic struct.
 	var minObj gcs.MinObject
 	if m != nil {
@@ -158,7 +179,9 @@ func NewFileInode(
 		unlinked:                false,
 		config:                  cfg,
 		globalMaxWriteBlocksSem: globalMaxBlocksSem,
-		MRDWrapper:              gcsx.NewMultiRangeDownloaderWrapper(bucket, &minObj),
+		metricHandle:            metricHandle,
+		verifier:                verifier,
+		MRDWrapper:              gcsx.NewMultiRangeDownloaderWrapper(bucket, &minObj, metricHandle),
 	}
 
 	f.lc.Init(id)
@@ -275,6 +298,12 @@ func (f *FileInode) ensureContent(ctx context.Context) (err error) {
 		cacheObjectKey := &contentcache.CacheObjectKey{BucketName: f.bucket.Name(), ObjectName: f.name.objectName}
 		if cacheObject, exists := f.contentCache.Get(cacheObjectKey); exists {
 			if cacheObject.ValidateGeneration(f.src.Generation, f.src.MetaGeneration) {
+				// The cache may have been recovered from disk, so its contents aren't
+				// necessarily verified yet.
+				if err = f.verifyContent(ctx, cacheObject.CacheFile); err != nil {
+					f.contentCache.Remove(cacheObjectKey)
+					return
+				}
 				f.content = cacheObject.CacheFile
 				return
 			}
@@ -293,12 +322,20 @@ func (f *FileInode) ensureContent(ctx context.Context) (err error) {
 			return err
 		}
 
+		if err = f.verifyContent(ctx, tf.CacheFile); err != nil {
+			f.contentCache.Remove(cacheObjectKey)
+			return err
+		}
+
 		// Update state.
 		f.content = tf.CacheFile
 	} else {
 		// Local filecache is not enabled
 		if f.content != nil {
-			return
+			reuse, err := f.mayServeContent(ctx)
+			if err != nil || reuse {
+				return err
+			}
 		}
 
 		rc, err := f.openReader(ctx)
@@ -312,13 +349,94 @@ func (f *FileInode) ensureContent(ctx context.Context) (err error) {
 			err = fmt.Errorf("NewTempFile: %w", err)
 			return err
 		}
+
+		if err = f.verifyContent(ctx, tf); err != nil {
+			tf.Destroy()
+			return err
+		}
+
 		// Update state.
-		f.content = tf
+		f.setContent(ctx, tf)
 	}
 
 	return
 }
 
+// Check the contents of the source object just fetched into content against
+// the integrity manifest, unless its generation has already been verified.
+//
+// LOCKS_REQUIRED(f.mu)
+func (f *FileInode) verifyContent(ctx context.Context, content gcsx.TempFile) error {
+	if !f.verifier.NeedsVerification(&f.src) {
+		return nil
+	}
+
+	sr, err := content.Stat()
+	if err != nil {
+		return fmt.Errorf("Stat: %w", err)
+	}
+
+	return f.verifier.VerifyReader(ctx, &f.src, io.NewSectionReader(content, 0, sr.Size))
+}
+
+// Set the content of this inode, fetched or created by the caller.
+//
+// LOCKS_REQUIRED(f.mu)
+func (f *FileInode) setContent(ctx context.Context, content gcsx.TempFile) {
+	f.content = content
+	f.contentUIDs = nil
+	if uid, ok := auth.CallerUID(ctx); ok {
+		f.contentUIDs = map[uint32]struct{}{uid: {}}
+	}
+}
+
+// Return true if f.content may be served to the caller. With per-user
+// credentials, content fetched by one caller isn't served to another without
+// checking theirs: content that is still clean is destroyed, to be fetched
+// again with the caller's credentials, and local modifications are only served
+// to a caller who can read the source object.
+//
+// LOCKS_REQUIRED(f.mu)
+func (f *FileInode) mayServeContent(ctx context.Context) (bool, error) {
+	if !cfg.IsPerUserCredentialsEnabled(f.config) {
+		return true, nil
+	}
+
+	uid, _ := auth.CallerUID(ctx)
+	if _, ok := f.contentUIDs[uid]; ok {
+		return true, nil
+	}
+
+	sr, err := f.content.Stat()
+	if err != nil {
+		return false, fmt.Errorf("content.Stat: %w", err)
+	}
+
+	if sr.Mtime == nil {
+		f.content.Destroy()
+		f.content = nil
+		f.contentUIDs = nil
+		return false, nil
+	}
+
+	// A local file has no source object to check the caller's credentials
+	// against.
+	if f.local {
+		return false, fmt.Errorf("local file %q of another user: %w", f.name, syscall.EACCES)
+	}
+
+	_, _, err = f.bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: f.src.Name, ForceFetchFromGcs: true})
+	if err != nil {
+		return false, fmt.Errorf("StatObject: %w", err)
+	}
+
+	if f.contentUIDs == nil {
+		f.contentUIDs = make(map[uint32]struct{})
+	}
+	f.contentUIDs[uid] = struct{}{}
+	return true, nil
+}
+
 ////////////////////////////////////////////////////////////////////////
 // Public interface
 ////////////////////////////////////////////////////////////////////////
@@ -505,9 +623,31 @@ func (f *FileInode) Attributes(
 		attrs.Nlink = 0
 	}
 
+	// Objects that can't be replaced are reported as read-only, rather than
+	// failing when synced.
+	if f.IsRetained() {
+		attrs.Mode &^= 0222
+	}
+
 	return
 }
 
+// IsRetained returns true if the source object is under an event-based or
+// temporary hold or a retention policy, and so can't be replaced or deleted.
+// It always returns false for local files and unless
+// config.AccessPolicy.DetectRetention is set. The holds are those in the
+// source object's metadata, as of the lookup that created the inode; setting
+// or releasing a hold changes the metageneration, and so mints a new inode.
+//
+// LOCKS_REQUIRED(f.mu)
+func (f *FileInode) IsRetained() bool {
+	if !f.config.AccessPolicy.DetectRetention || f.IsLocal() {
+		return false
+	}
+
+	return f.src.IsRetained(f.mtimeClock.Now())
+}
+
 func (f *FileInode) Bucket() *gcsx.SyncerBucket {
 	return f.bucket
 }
@@ -843,6 +983,7 @@ func (f *FileInode) updateInodeStateAfterSync(minObj *gcs.MinObject) {
 		if f.content != nil {
 			f.content.Destroy()
 			f.content = nil
+			f.contentUIDs = nil
 		}
 		if f.bwh != nil {
 			f.bwh = nil
@@ -904,7 +1045,11 @@ func (f *FileInode) CreateBufferedOrTempWriter(ctx context.Context) (err error)
 
 	// Creating a file with no contents. The contents will be updated with
 	// writeFile operations.
-	f.content, err = f.contentCache.NewTempFile(io.NopCloser(strings.NewReader("")))
+	content, err := f.contentCache.NewTempFile(io.NopCloser(strings.NewReader("")))
+	if err != nil {
+		return
+	}
+	f.setContent(ctx, content)
 	// Setting the initial mtime to creation time.
 	f.content.SetMtime(f.mtimeClock.Now())
 	return
@@ -934,6 +1079,8 @@ func (f *FileInode) ensureBufferedWriteHandler(ctx context.Context) error {
 			MaxBlocksPerFile:         f.config.Write.MaxBlocksPerFile,
 			GlobalMaxBlocksSem:       f.globalMaxWriteBlocksSem,
 			ChunkTransferTimeoutSecs: f.config.GcsRetries.ChunkTransferTimeoutSecs,
+			MetricHandle:             f.metricHandle,
+			TraceLink:                trace.LinkFromContext(ctx),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create bufferedWriteHandler: %w", err)
@@ -115,11 +115,13 @@ func (t *FileStreamingWritesTest) createInode(fileName string, fileType string)
 		},
 		&syncerBucket,
 		false, // localFileCache
-		contentcache.New("", &t.clock),
+		contentcache.New("", &t.clock, nil),
 		&t.clock,
 		isLocal,
 		&cfg.Config{},
-		semaphore.NewWeighted(math.MaxInt64))
+		semaphore.NewWeighted(math.MaxInt64),
+		nil,
+		nil)
 
 	// Set buffered write config for created inode.
 	t.in.config = &cfg.Config{Write: cfg.WriteConfig{
@@ -15,6 +15,8 @@
 package inode
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -26,9 +28,11 @@ import (
 	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/contentcache"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
@@ -146,11 +150,13 @@ func (t *FileTest) createInodeWithLocalParam(fileName string, local bool) {
 		},
 		&syncerBucket,
 		false, // localFileCache
-		contentcache.New("", &t.clock),
+		contentcache.New("", &t.clock, nil),
 		&t.clock,
 		local,
 		&cfg.Config{},
-		semaphore.NewWeighted(math.MaxInt64))
+		semaphore.NewWeighted(math.MaxInt64),
+		nil,
+		nil)
 
 	t.in.Lock()
 }
@@ -187,6 +193,50 @@ func (t *FileTest) TestInitialAttributes() {
 	assert.Equal(t.T(), attrs.Mtime, t.backingObj.Updated)
 }
 
+func (t *FileTest) TestAttributes_RetainedObjectIsReadOnly() {
+	object, err := t.bucket.CreateObject(t.ctx, &gcs.CreateObjectRequest{
+		Name:           fileName,
+		Contents:       strings.NewReader("taco"),
+		EventBasedHold: true,
+	})
+	require.NoError(t.T(), err)
+	t.backingObj = storageutil.ConvertObjToMinObject(object)
+	t.createInode()
+	t.in.config = &cfg.Config{AccessPolicy: cfg.AccessPolicyConfig{DetectRetention: true}}
+
+	attrs, err := t.in.Attributes(t.ctx)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), fileMode&^0222, attrs.Mode)
+	assert.True(t.T(), t.in.IsRetained())
+}
+
+func (t *FileTest) TestIsRetained_UntilRetentionExpires() {
+	t.backingObj.Retention = &gcs.ObjectRetention{RetentionExpirationTime: t.clock.Now().Add(time.Hour)}
+	t.createInode()
+	t.in.config = &cfg.Config{AccessPolicy: cfg.AccessPolicyConfig{DetectRetention: true}}
+
+	assert.True(t.T(), t.in.IsRetained())
+	t.clock.AdvanceTime(2 * time.Hour)
+	assert.False(t.T(), t.in.IsRetained())
+}
+
+func (t *FileTest) TestAttributes_RetentionNotDetectedByDefault() {
+	object, err := t.bucket.CreateObject(t.ctx, &gcs.CreateObjectRequest{
+		Name:           fileName,
+		Contents:       strings.NewReader("taco"),
+		EventBasedHold: true,
+	})
+	require.NoError(t.T(), err)
+	t.backingObj = storageutil.ConvertObjToMinObject(object)
+	t.createInode()
+
+	attrs, err := t.in.Attributes(t.ctx)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), fileMode, attrs.Mode)
+}
+
 func (t *FileTest) TestInitialAttributes_MtimeFromObjectMetadata_Gcsfuse() {
 	// Set up an explicit mtime on the backing object and re-create the inode.
 	if t.backingObj.Metadata == nil {
@@ -330,6 +380,81 @@ func (t *FileTest) TestWrite() {
 	assert.Equal(t.T(), attrs.Mtime, writeTime)
 }
 
+// useVerifier makes the inode check the backing object against a manifest
+// entry for the given contents.
+func (t *FileTest) useVerifier(released []byte) {
+	sum := sha256.Sum256(released)
+	t.in.verifier = integrity.NewVerifier(&integrity.Manifest{Objects: map[string]integrity.Entry{
+		fileName: {SHA256: hex.EncodeToString(sum[:])},
+	}}, nil)
+}
+
+func (t *FileTest) TestWrite_ContentMatchingManifest() {
+	t.useVerifier([]byte("taco"))
+
+	err := t.in.Write(t.ctx, []byte("p"), 0)
+
+	require.NoError(t.T(), err)
+	var buf [1024]byte
+	n, err := t.in.Read(t.ctx, buf[:], 0)
+	if err == io.EOF {
+		err = nil
+	}
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "paco", string(buf[:n]))
+}
+
+func (t *FileTest) TestWrite_ContentNotMatchingManifest() {
+	t.useVerifier([]byte("burrito"))
+
+	err := t.in.Write(t.ctx, []byte("p"), 0)
+
+	var mismatchErr *integrity.MismatchError
+	assert.ErrorAs(t.T(), err, &mismatchErr)
+	assert.Nil(t.T(), t.in.content)
+	// Nothing fetched is served on a later attempt either.
+	_, err = t.in.Read(t.ctx, make([]byte, 4), 0)
+	assert.ErrorAs(t.T(), err, &mismatchErr)
+}
+
+func (t *FileTest) TestWrite_PerUserCredentials_ContentServedOnlyToCallersWhoCanRead() {
+	t.in.config.GcsAuth.PerUserTokenUrl = "http://localhost/token/{uid}"
+	alice := auth.WithCallerUID(t.ctx, 1000)
+	bob := auth.WithCallerUID(t.ctx, 1001)
+	err := t.in.Write(alice, []byte("p"), 0)
+	require.NoError(t.T(), err)
+	// Bob can read the source object, so is served alice's modifications.
+	var buf [1024]byte
+	n, err := t.in.Read(bob, buf[:], 0)
+	if err == io.EOF {
+		err = nil
+	}
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "paco", string(buf[:n]))
+	// Without the source object, the credentials of other users can't be
+	// checked.
+	err = t.bucket.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: fileName})
+	require.NoError(t.T(), err)
+	carol := auth.WithCallerUID(t.ctx, 1002)
+
+	_, err = t.in.Read(carol, buf[:], 0)
+
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+	n, err = t.in.Read(alice, buf[:], 0)
+	if err == io.EOF {
+		err = nil
+	}
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "paco", string(buf[:n]))
+	n, err = t.in.Read(bob, buf[:], 0)
+	if err == io.EOF {
+		err = nil
+	}
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "paco", string(buf[:n]))
+}
+
 func (t *FileTest) TestTruncate() {
 	var attrs fuseops.InodeAttributes
 	var err error
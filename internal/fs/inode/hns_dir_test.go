@@ -107,6 +107,8 @@ func (t *hnsDirTest) resetDirInodeWithTypeCacheConfigs(implicitDirs, enableNonex
 		&t.fixedTime,
 		typeCacheMaxSizeMB,
 		true,
+		nil,
+		nil,
 	)
 
 	d := t.in.(*dirInode)
@@ -136,6 +138,8 @@ func (t *hnsDirTest) createDirInode(dirInodeName string) DirInode {
 		&t.fixedTime,
 		4,
 		false,
+		nil,
+		nil,
 	)
 }
 
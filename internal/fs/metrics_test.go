@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStateMetrics sums up the recorded inode and handle counts by type.
+type fakeStateMetrics struct {
+	common.MetricHandle
+	mu      sync.Mutex
+	inodes  map[string]int64
+	handles map[string]int64
+}
+
+func (m *fakeStateMetrics) InodeCount(_ context.Context, inc int64, attrs []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inodes[attrs[0].Value] += inc
+}
+
+func (m *fakeStateMetrics) HandleCount(_ context.Context, inc int64, attrs []common.MetricAttr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handles[attrs[0].Value] += inc
+}
+
+func TestMetrics_InodeAndHandleCounts(t *testing.T) {
+	ctx := context.Background()
+	bucket := fake.NewFakeBucket(timeutil.RealClock(), "metrics_bucket", gcs.BucketType{})
+	m := &fakeStateMetrics{
+		MetricHandle: common.NewNoopMetrics(),
+		inodes:       make(map[string]int64),
+		handles:      make(map[string]int64),
+	}
+	server, err := fs.NewFileSystem(ctx, &fs.ServerConfig{
+		CacheClock:           &timeutil.SimulatedClock{},
+		BucketManager:        &fakeBucketManager{buckets: map[string]gcs.Bucket{bucket.Name(): bucket}},
+		BucketName:           bucket.Name(),
+		TempDir:              t.TempDir(),
+		RenameDirLimit:       1000,
+		SequentialReadSizeMb: SequentialReadSizeMb,
+		FilePerms:            filePerms,
+		DirPerms:             dirPerms,
+		NewConfig:            defaultModelConfig(),
+		MetricHandle:         m,
+	})
+	require.NoError(t, err)
+	t.Cleanup(server.Destroy)
+	driver := newFuseDriver(server)
+	_, err = storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	require.NoError(t, err)
+
+	require.Zero(t, driver.open(ctx, 0, "foo"))
+	require.Zero(t, driver.create(ctx, "bar", nil))
+	require.Zero(t, driver.mkdir(ctx, "baz"))
+	openDir := &fuseops.OpenDirOp{Inode: fuseops.RootInodeID}
+	require.NoError(t, server.OpenDir(ctx, openDir))
+
+	assert.Equal(t, map[string]int64{common.DirType: 2, common.FileType: 2}, m.inodes)
+	assert.Equal(t, map[string]int64{common.DirType: 1, common.FileType: 1}, m.handles)
+
+	h := driver.handles[0]
+	require.Zero(t, driver.closeFile(ctx, h.inode, h.handle))
+	require.NoError(t, server.ReleaseDirHandle(ctx, &fuseops.ReleaseDirHandleOp{Handle: openDir.Handle}))
+
+	assert.Equal(t, map[string]int64{common.DirType: 0, common.FileType: 0}, m.handles)
+}
@@ -0,0 +1,1193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// A model-based randomized test harness. Random sequences of file system
+// operations are applied both to a fileSystem backed by the fake bucket, by
+// issuing fuseops directly without a kernel mount, and to a reference
+// in-memory POSIX model. Any divergence in results, or a panic from an
+// invariant check, fails the test after the sequence has been shrunk to a
+// minimal reproduction.
+//
+// Reproduce a failure with:
+//
+//	go test ./internal/fs -run TestModelBasedRandomOps/<mode> -model_seed=<seed>
+
+package fs_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/metadata"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/wrappers"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/caching"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/fuse/fuseutil"
+	"github.com/jacobsa/timeutil"
+)
+
+var (
+	fModelSeed       = flag.Int64("model_seed", 0, "Seed of the first random op sequence; 0 picks one from the clock.")
+	fModelSequences  = flag.Int("model_sequences", 20, "Number of random op sequences to run per mode.")
+	fModelSequenceOp = flag.Int("model_ops", 60, "Number of ops per random sequence.")
+	fModelShrink     = flag.Bool("model_shrink", true, "Shrink failing sequences before reporting them.")
+	fModelOpTimeout  = flag.Duration("model_op_timeout", 30*time.Second, "How long a single op may take before it is reported as a likely deadlock.")
+)
+
+////////////////////////////////////////////////////////////////////////
+// Ops
+////////////////////////////////////////////////////////////////////////
+
+type opKind int
+
+const (
+	opMkdir opKind = iota
+	opCreate
+	opWrite
+	opRead
+	opUnlink
+	opRmdir
+	opRename
+	opReadDir
+	opOpen
+	opHandleWrite
+	opClose
+	opAdvanceClock
+	opParallel
+)
+
+type modelOp struct {
+	kind   opKind
+	path   string
+	path2  string
+	offset int64
+	data   []byte
+	handle int
+	clock  time.Duration
+
+	// For opParallel, independent ops to be run concurrently.
+	batch []modelOp
+}
+
+func (op modelOp) String() string {
+	switch op.kind {
+	case opMkdir:
+		return fmt.Sprintf("mkdir(%q)", op.path)
+	case opCreate:
+		return fmt.Sprintf("create(%q, %d bytes)", op.path, len(op.data))
+	case opWrite:
+		return fmt.Sprintf("write(%q, off=%d, %d bytes)", op.path, op.offset, len(op.data))
+	case opRead:
+		return fmt.Sprintf("read(%q)", op.path)
+	case opUnlink:
+		return fmt.Sprintf("unlink(%q)", op.path)
+	case opRmdir:
+		return fmt.Sprintf("rmdir(%q)", op.path)
+	case opRename:
+		return fmt.Sprintf("rename(%q, %q)", op.path, op.path2)
+	case opReadDir:
+		return fmt.Sprintf("readdir(%q)", op.path)
+	case opOpen:
+		return fmt.Sprintf("open(#%d, %q)", op.handle, op.path)
+	case opHandleWrite:
+		return fmt.Sprintf("pwrite(#%d, off=%d, %d bytes)", op.handle, op.offset, len(op.data))
+	case opClose:
+		return fmt.Sprintf("close(#%d)", op.handle)
+	case opAdvanceClock:
+		return fmt.Sprintf("advanceClock(%v)", op.clock)
+	case opParallel:
+		var parts []string
+		for _, b := range op.batch {
+			parts = append(parts, b.String())
+		}
+		return "parallel{" + strings.Join(parts, "; ") + "}"
+	}
+
+	return fmt.Sprintf("op(%d)", op.kind)
+}
+
+// The paths named by op, used to decide whether ops are independent.
+func (op modelOp) paths() []string {
+	if op.kind == opRename {
+		return []string{op.path, op.path2}
+	}
+
+	return []string{op.path}
+}
+
+func pathsOverlap(a, b string) bool {
+	return a == b || a == "" || b == "" ||
+		strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// Two ops are independent if neither names a path that is, or is an ancestor
+// of, a path named by the other. Independent ops commute.
+func independent(a, b modelOp) bool {
+	for _, pa := range a.paths() {
+		for _, pb := range b.paths() {
+			if pathsOverlap(pa, pb) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+type opResult struct {
+	errno   syscall.Errno
+	data    []byte
+	entries []string
+}
+
+func (r opResult) String() string {
+	switch {
+	case r.errno != 0:
+		return fmt.Sprintf("errno %d (%v)", int(r.errno), r.errno)
+	case r.entries != nil:
+		return fmt.Sprintf("entries %q", r.entries)
+	case r.data != nil:
+		return fmt.Sprintf("data %q", r.data)
+	}
+
+	return "ok"
+}
+
+////////////////////////////////////////////////////////////////////////
+// Reference model
+////////////////////////////////////////////////////////////////////////
+
+// posixModel is an in-memory reference of the POSIX semantics that gcsfuse
+// provides for the ops generated here.
+type posixModel struct {
+	files map[string][]byte
+	dirs  map[string]bool
+
+	// Path of the file open in each handle slot.
+	handles map[int]string
+}
+
+func newPosixModel() *posixModel {
+	return &posixModel{
+		files:   make(map[string][]byte),
+		dirs:    map[string]bool{"": true},
+		handles: make(map[int]string),
+	}
+}
+
+func (m *posixModel) exists(p string) bool {
+	return m.dirs[p] || m.files[p] != nil
+}
+
+// Check that every ancestor of p is a directory, as kernel path resolution
+// does.
+func (m *posixModel) checkParent(p string) syscall.Errno {
+	parent, _ := splitModelPath(p)
+	if parent == "" {
+		return 0
+	}
+
+	if errno := m.checkParent(parent); errno != 0 {
+		return errno
+	}
+
+	switch {
+	case m.dirs[parent]:
+		return 0
+	case m.files[parent] != nil:
+		return syscall.ENOTDIR
+	}
+
+	return syscall.ENOENT
+}
+
+func (m *posixModel) checkPath(p string) syscall.Errno {
+	if errno := m.checkParent(p); errno != 0 {
+		return errno
+	}
+
+	if !m.exists(p) {
+		return syscall.ENOENT
+	}
+
+	return 0
+}
+
+func (m *posixModel) children(p string) (out []string) {
+	prefix := p + "/"
+	if p == "" {
+		prefix = ""
+	}
+
+	collect := func(name string, isDir bool) {
+		if name == p || !strings.HasPrefix(name, prefix) || strings.Contains(name[len(prefix):], "/") {
+			return
+		}
+
+		entry := name[len(prefix):]
+		if isDir {
+			entry += "/"
+		}
+
+		out = append(out, entry)
+	}
+
+	for d := range m.dirs {
+		collect(d, true)
+	}
+
+	for f := range m.files {
+		collect(f, false)
+	}
+
+	sort.Strings(out)
+	return
+}
+
+func writeAt(content []byte, offset int64, data []byte) []byte {
+	if end := offset + int64(len(data)); end > int64(len(content)) {
+		content = append(content, make([]byte, end-int64(len(content)))...)
+	}
+
+	copy(content[offset:], data)
+	return content
+}
+
+func (m *posixModel) apply(op modelOp) (r opResult) {
+	switch op.kind {
+	case opMkdir:
+		if r.errno = m.checkParent(op.path); r.errno == 0 {
+			if m.exists(op.path) {
+				r.errno = syscall.EEXIST
+			} else {
+				m.dirs[op.path] = true
+			}
+		}
+
+	case opCreate:
+		if r.errno = m.checkParent(op.path); r.errno == 0 {
+			if m.exists(op.path) {
+				r.errno = syscall.EEXIST
+			} else {
+				m.files[op.path] = append([]byte{}, op.data...)
+			}
+		}
+
+	case opWrite:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			if m.dirs[op.path] {
+				r.errno = syscall.EISDIR
+			} else {
+				m.files[op.path] = writeAt(m.files[op.path], op.offset, op.data)
+			}
+		}
+
+	case opRead:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			if m.dirs[op.path] {
+				r.errno = syscall.EISDIR
+			} else {
+				r.data = append([]byte{}, m.files[op.path]...)
+			}
+		}
+
+	case opUnlink:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			if m.dirs[op.path] {
+				r.errno = syscall.EISDIR
+			} else {
+				delete(m.files, op.path)
+			}
+		}
+
+	case opRmdir:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			switch {
+			case !m.dirs[op.path]:
+				r.errno = syscall.ENOTDIR
+			case len(m.children(op.path)) > 0:
+				r.errno = syscall.ENOTEMPTY
+			default:
+				delete(m.dirs, op.path)
+			}
+		}
+
+	case opRename:
+		r.errno = m.rename(op.path, op.path2)
+
+	case opReadDir:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			if !m.dirs[op.path] {
+				r.errno = syscall.ENOTDIR
+			} else {
+				r.entries = m.children(op.path)
+				if r.entries == nil {
+					r.entries = []string{}
+				}
+			}
+		}
+
+	case opOpen:
+		if r.errno = m.checkPath(op.path); r.errno == 0 {
+			if m.dirs[op.path] {
+				r.errno = syscall.EISDIR
+			} else {
+				m.handles[op.handle] = op.path
+			}
+		}
+
+	case opHandleWrite:
+		p := m.handles[op.handle]
+		m.files[p] = writeAt(m.files[p], op.offset, op.data)
+
+	case opClose:
+		delete(m.handles, op.handle)
+	}
+
+	return
+}
+
+func (m *posixModel) rename(src, dst string) syscall.Errno {
+	if errno := m.checkPath(src); errno != 0 {
+		return errno
+	}
+
+	if errno := m.checkParent(dst); errno != 0 {
+		return errno
+	}
+
+	srcIsDir := m.dirs[src]
+	if srcIsDir && strings.HasPrefix(dst, src+"/") {
+		return syscall.EINVAL
+	}
+
+	if m.exists(dst) {
+		switch {
+		case !srcIsDir && m.dirs[dst]:
+			return syscall.EISDIR
+		case srcIsDir && !m.dirs[dst]:
+			return syscall.ENOTDIR
+		case srcIsDir && src != dst && len(m.children(dst)) > 0:
+			return syscall.ENOTEMPTY
+		}
+	}
+
+	if src == dst {
+		return 0
+	}
+
+	if !srcIsDir {
+		m.files[dst] = m.files[src]
+		delete(m.files, src)
+		return 0
+	}
+
+	// Move the directory and everything under it.
+	move := func(name string) (string, bool) {
+		if name == src || strings.HasPrefix(name, src+"/") {
+			return dst + strings.TrimPrefix(name, src), true
+		}
+
+		return "", false
+	}
+
+	dirs := make(map[string]bool)
+	for d := range m.dirs {
+		if moved, ok := move(d); ok {
+			dirs[moved] = true
+		} else {
+			dirs[d] = true
+		}
+	}
+
+	files := make(map[string][]byte)
+	for f, content := range m.files {
+		if moved, ok := move(f); ok {
+			files[moved] = content
+		} else {
+			files[f] = content
+		}
+	}
+
+	m.dirs, m.files = dirs, files
+	return 0
+}
+
+func (m *posixModel) hasOpenHandleUnder(p string) bool {
+	for _, hp := range m.handles {
+		if pathsOverlap(hp, p) && (hp == p || strings.HasPrefix(hp, p+"/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////
+// Configuration modes
+////////////////////////////////////////////////////////////////////////
+
+// modelMode is a file system configuration under which sequences are run.
+type modelMode struct {
+	name       string
+	bucketType gcs.BucketType
+
+	// Wraps the fake bucket with a stat cache, with this TTL.
+	statCacheTTL time.Duration
+
+	// Adjusts the server config.
+	configure func(t *testing.T, serverCfg *fs.ServerConfig)
+
+	// Reads of a file with an open write handle are not supported, since
+	// streaming writes can't serve them.
+	streamingWrites bool
+}
+
+func defaultModelConfig() *cfg.Config {
+	return &cfg.Config{
+		FileCache: defaultFileCacheConfig(),
+		MetadataCache: cfg.MetadataCacheConfig{
+			StatCacheMaxSizeMb: 32,
+			TtlSecs:            0,
+			TypeCacheMaxSizeMb: 4,
+		},
+		Write: cfg.WriteConfig{
+			GlobalMaxBlocks: 20,
+		},
+	}
+}
+
+var modelModes = []modelMode{
+	{
+		name:      "default",
+		configure: func(*testing.T, *fs.ServerConfig) {},
+	},
+	{
+		name: "implicit_dirs",
+		configure: func(_ *testing.T, c *fs.ServerConfig) {
+			c.ImplicitDirectories = true
+		},
+	},
+	{
+		name:       "hns",
+		bucketType: gcs.BucketType{Hierarchical: true},
+		configure: func(_ *testing.T, c *fs.ServerConfig) {
+			c.NewConfig.EnableHns = true
+			c.NewConfig.EnableAtomicRenameObject = true
+		},
+	},
+	{
+		name:            "streaming_writes",
+		streamingWrites: true,
+		configure: func(_ *testing.T, c *fs.ServerConfig) {
+			c.NewConfig.Write = cfg.WriteConfig{
+				BlockSizeMb:           1,
+				EnableStreamingWrites: true,
+				GlobalMaxBlocks:       20,
+				MaxBlocksPerFile:      10,
+			}
+		},
+	},
+	{
+		name:         "metadata_caches",
+		statCacheTTL: time.Minute,
+		configure: func(_ *testing.T, c *fs.ServerConfig) {
+			c.NewConfig.MetadataCache.TtlSecs = 60
+			c.DirTypeCacheTTL = time.Minute
+			c.EnableNonexistentTypeCache = true
+		},
+	},
+	{
+		name: "file_cache",
+		configure: func(t *testing.T, c *fs.ServerConfig) {
+			c.NewConfig.CacheDir = cfg.ResolvedPath(t.TempDir())
+			c.NewConfig.FileCache.MaxSizeMb = 10
+			c.NewConfig.FileCache.CacheFileForRangeRead = true
+		},
+	},
+}
+
+////////////////////////////////////////////////////////////////////////
+// Generation
+////////////////////////////////////////////////////////////////////////
+
+var modelNames = []string{"a", "b", "c"}
+
+type opGenerator struct {
+	rand *rand.Rand
+	mode modelMode
+
+	// A model kept up to date with generated ops, used to bias generation
+	// towards existing paths. It is not used to check results.
+	model *posixModel
+
+	nextSlot int
+}
+
+func (g *opGenerator) randomPath() string {
+	depth := 1 + g.rand.Intn(3)
+	var parts []string
+	for i := 0; i < depth; i++ {
+		parts = append(parts, modelNames[g.rand.Intn(len(modelNames))])
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// Pick an existing path satisfying pred most of the time, or a random one.
+func (g *opGenerator) pickPath(pred func(p string) bool) string {
+	var candidates []string
+	for d := range g.model.dirs {
+		if d != "" && pred(d) {
+			candidates = append(candidates, d)
+		}
+	}
+	for f := range g.model.files {
+		if pred(f) {
+			candidates = append(candidates, f)
+		}
+	}
+
+	if len(candidates) == 0 || g.rand.Intn(4) == 0 {
+		return g.randomPath()
+	}
+
+	sort.Strings(candidates)
+	return candidates[g.rand.Intn(len(candidates))]
+}
+
+func (g *opGenerator) anyPath(string) bool { return true }
+
+func (g *opGenerator) isFile(p string) bool { return g.model.files[p] != nil }
+
+func (g *opGenerator) isDir(p string) bool { return g.model.dirs[p] }
+
+func (g *opGenerator) randomData() []byte {
+	data := make([]byte, 1+g.rand.Intn(64))
+	g.rand.Read(data)
+	return data
+}
+
+// Generate a single op that touches only the namespace.
+func (g *opGenerator) pathOp() modelOp {
+	switch g.rand.Intn(10) {
+	case 0, 1:
+		return modelOp{kind: opMkdir, path: g.randomPath()}
+	case 2, 3:
+		return modelOp{kind: opCreate, path: g.randomPath(), data: g.randomData()}
+	case 4:
+		p := g.pickPath(g.isFile)
+		size := int64(len(g.model.files[p]))
+		offset := size
+		if !g.mode.streamingWrites && g.rand.Intn(2) == 0 {
+			offset = g.rand.Int63n(size + 8)
+		}
+		return modelOp{kind: opWrite, path: p, offset: offset, data: g.randomData()}
+	case 5:
+		return modelOp{kind: opRead, path: g.pickPath(g.isFile)}
+	case 6:
+		return modelOp{kind: opUnlink, path: g.pickPath(g.isFile)}
+	case 7:
+		return modelOp{kind: opRmdir, path: g.pickPath(g.isDir)}
+	case 8:
+		return modelOp{kind: opRename, path: g.pickPath(g.anyPath), path2: g.randomPath()}
+	default:
+		if g.rand.Intn(3) == 0 {
+			return modelOp{kind: opReadDir, path: ""}
+		}
+		return modelOp{kind: opReadDir, path: g.pickPath(g.isDir)}
+	}
+}
+
+func (g *opGenerator) next() modelOp {
+	switch n := g.rand.Intn(20); {
+	case n < 2 && len(g.model.handles) < 3:
+		g.nextSlot++
+		return modelOp{kind: opOpen, handle: g.nextSlot, path: g.pickPath(g.isFile)}
+
+	case n < 5 && len(g.model.handles) > 0:
+		slots := make([]int, 0, len(g.model.handles))
+		for s := range g.model.handles {
+			slots = append(slots, s)
+		}
+		sort.Ints(slots)
+		slot := slots[g.rand.Intn(len(slots))]
+
+		if n == 4 {
+			return modelOp{kind: opClose, handle: slot}
+		}
+
+		size := int64(len(g.model.files[g.model.handles[slot]]))
+		return modelOp{kind: opHandleWrite, handle: slot, offset: size, data: g.randomData()}
+
+	case n == 5:
+		return modelOp{kind: opAdvanceClock, clock: time.Duration(g.rand.Intn(90)) * time.Second}
+
+	case n == 6:
+		// A batch of mutually independent ops, to exercise concurrent locking.
+		batch := []modelOp{g.pathOp()}
+		for tries := 0; tries < 6 && len(batch) < 3; tries++ {
+			candidate := g.pathOp()
+			ok := true
+			for _, b := range batch {
+				ok = ok && independent(b, candidate)
+			}
+			if ok {
+				batch = append(batch, candidate)
+			}
+		}
+		return modelOp{kind: opParallel, batch: batch}
+	}
+
+	return g.pathOp()
+}
+
+func generateOps(seed int64, mode modelMode, n int) (ops []modelOp) {
+	g := &opGenerator{
+		rand:  rand.New(rand.NewSource(seed)),
+		mode:  mode,
+		model: newPosixModel(),
+	}
+
+	for len(ops) < n {
+		op := g.next()
+		if !applicable(g.model, mode, op) {
+			continue
+		}
+
+		ops = append(ops, op)
+		applyToModel(g.model, op)
+	}
+
+	return
+}
+
+func applyToModel(m *posixModel, op modelOp) {
+	if op.kind == opParallel {
+		for _, b := range op.batch {
+			m.apply(b)
+		}
+		return
+	}
+
+	m.apply(op)
+}
+
+// Report whether op may be run in the current model state. Sequences are
+// filtered through this both when generated and when replayed, so that
+// shrinking never produces sequences whose outcome gcsfuse leaves
+// unspecified, such as unlinking a file that is open for writing.
+func applicable(m *posixModel, mode modelMode, op modelOp) bool {
+	switch op.kind {
+	case opHandleWrite, opClose:
+		_, ok := m.handles[op.handle]
+		return ok
+
+	case opOpen:
+		_, inUse := m.handles[op.handle]
+		if inUse {
+			return false
+		}
+		// Streaming writes support one writer per file.
+		return !(mode.streamingWrites && m.hasOpenHandleUnder(op.path))
+
+	case opUnlink, opRmdir, opWrite:
+		return !m.hasOpenHandleUnder(op.path)
+
+	case opRename:
+		return !m.hasOpenHandleUnder(op.path) && !m.hasOpenHandleUnder(op.path2)
+
+	case opRead:
+		return !(mode.streamingWrites && m.hasOpenHandleUnder(op.path))
+
+	case opParallel:
+		for i, b := range op.batch {
+			if b.kind == opParallel || !applicable(m, mode, b) {
+				return false
+			}
+			for _, other := range op.batch[:i] {
+				if !independent(b, other) {
+					return false
+				}
+			}
+		}
+		return len(op.batch) > 0
+	}
+
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////
+// Running
+////////////////////////////////////////////////////////////////////////
+
+type modelRun struct {
+	t      *testing.T
+	mode   modelMode
+	ctx    context.Context
+	clock  *timeutil.SimulatedClock
+	bucket gcs.Bucket
+	fs     fuseutil.FileSystem
+	driver *fuseDriver
+	model  *posixModel
+}
+
+func newModelRun(t *testing.T, mode modelMode) *modelRun {
+	r := &modelRun{
+		t:     t,
+		mode:  mode,
+		ctx:   context.Background(),
+		clock: &timeutil.SimulatedClock{},
+		model: newPosixModel(),
+	}
+	r.clock.SetTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	r.bucket = fake.NewFakeBucket(r.clock, "model_bucket", mode.bucketType)
+
+	mounted := r.bucket
+	if mode.statCacheTTL > 0 {
+		statCache := metadata.NewStatCacheBucketView(lru.NewCache(uint64(1<<20)), "")
+		mounted = caching.NewFastStatBucket(mode.statCacheTTL, statCache, r.clock, r.bucket, mode.statCacheTTL)
+	}
+
+	serverCfg := &fs.ServerConfig{
+		CacheClock: r.clock,
+		BucketManager: &fakeBucketManager{
+			buckets:                  map[string]gcs.Bucket{r.bucket.Name(): mounted},
+			chunkTransferTimeoutSecs: 10,
+			tmpObjectPrefix:          ".gcsfuse_tmp/",
+		},
+		BucketName:           r.bucket.Name(),
+		TempDir:              t.TempDir(),
+		RenameDirLimit:       1000,
+		SequentialReadSizeMb: SequentialReadSizeMb,
+		FilePerms:            filePerms,
+		DirPerms:             dirPerms,
+		NewConfig:            defaultModelConfig(),
+		MetricHandle:         common.NewNoopMetrics(),
+	}
+	mode.configure(t, serverCfg)
+
+	server, err := fs.NewFileSystem(r.ctx, serverCfg)
+	if err != nil {
+		t.Fatalf("NewFileSystem: %v", err)
+	}
+
+	r.fs = wrappers.WithErrorMapping(server, false)
+	r.driver = newFuseDriver(r.fs)
+	return r
+}
+
+// Run f, converting a panic into an error and reporting ops that don't finish
+// in time as a likely deadlock.
+func runGuarded(f func()) (err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				buf := make([]byte, 1<<16)
+				done <- fmt.Errorf("panic: %v\n%s", p, buf[:runtime.Stack(buf, false)])
+			}
+		}()
+		f()
+		done <- nil
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(*fModelOpTimeout):
+		buf := make([]byte, 1<<20)
+		err = fmt.Errorf("op did not finish within %v; likely deadlock. Goroutines:\n%s", *fModelOpTimeout, buf[:runtime.Stack(buf, true)])
+	}
+
+	return
+}
+
+func (r *modelRun) step(op modelOp) error {
+	switch op.kind {
+	case opAdvanceClock:
+		r.clock.AdvanceTime(op.clock)
+		return nil
+
+	case opParallel:
+		results := make([]opResult, len(op.batch))
+		err := runGuarded(func() {
+			var wg sync.WaitGroup
+			for i := range op.batch {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					results[i] = r.driver.apply(r.ctx, op.batch[i])
+				}(i)
+			}
+			wg.Wait()
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, b := range op.batch {
+			if err := compareResults(b, r.model.apply(b), results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var got opResult
+	if err := runGuarded(func() { got = r.driver.apply(r.ctx, op) }); err != nil {
+		return fmt.Errorf("%v: %w", op, err)
+	}
+
+	return compareResults(op, r.model.apply(op), got)
+}
+
+func compareResults(op modelOp, want, got opResult) error {
+	if want.errno != got.errno ||
+		(want.data != nil && !bytes.Equal(want.data, got.data)) ||
+		(want.entries != nil && !reflect.DeepEqual(want.entries, got.entries)) {
+		return fmt.Errorf("%v: got %v, want %v", op, got, want)
+	}
+
+	return nil
+}
+
+// Check that the whole namespace seen through the file system, and the
+// objects in the bucket, match the model. Requires all handles to be closed.
+func (r *modelRun) checkFinalState() error {
+	var walk func(p string) error
+	walk = func(p string) error {
+		if err := r.step(modelOp{kind: opReadDir, path: p}); err != nil {
+			return err
+		}
+
+		for _, child := range r.model.children(p) {
+			name := strings.TrimSuffix(child, "/")
+			if p != "" {
+				name = p + "/" + name
+			}
+
+			var err error
+			if strings.HasSuffix(child, "/") {
+				err = walk(name)
+			} else {
+				err = r.step(modelOp{kind: opRead, path: name})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return fmt.Errorf("final state: %w", err)
+	}
+
+	// Every file in the model must be backed by an object with the same
+	// contents, and there must be no other file objects.
+	listing, _, err := storageutil.ListAll(r.ctx, r.bucket, &gcs.ListObjectsRequest{})
+	if err != nil {
+		return fmt.Errorf("ListAll: %w", err)
+	}
+
+	found := make(map[string]bool)
+	for _, o := range listing {
+		if strings.HasSuffix(o.Name, "/") || strings.HasPrefix(o.Name, ".gcsfuse_tmp/") {
+			continue
+		}
+
+		found[o.Name] = true
+		want, ok := r.model.files[o.Name]
+		if !ok {
+			return fmt.Errorf("final state: unexpected object %q", o.Name)
+		}
+
+		got, err := storageutil.ReadObject(r.ctx, r.bucket, o.Name)
+		if err != nil {
+			return fmt.Errorf("ReadObject(%q): %w", o.Name, err)
+		}
+
+		if !bytes.Equal(want, got) {
+			return fmt.Errorf("final state: object %q has contents %q, want %q", o.Name, got, want)
+		}
+	}
+
+	for name := range r.model.files {
+		if !found[name] {
+			return fmt.Errorf("final state: missing object %q", name)
+		}
+	}
+
+	return nil
+}
+
+// Run the sequence on a fresh file system, returning the first divergence
+// from the model.
+func runModelSequence(t *testing.T, mode modelMode, ops []modelOp) (err error) {
+	r := newModelRun(t, mode)
+	defer func() {
+		if destroyErr := runGuarded(r.fs.Destroy); err == nil {
+			err = destroyErr
+		}
+	}()
+
+	for _, op := range ops {
+		if !applicable(r.model, mode, op) {
+			continue
+		}
+
+		if err = r.step(op); err != nil {
+			return
+		}
+	}
+
+	// Close any handles that are still open, in a deterministic order.
+	var slots []int
+	for s := range r.model.handles {
+		slots = append(slots, s)
+	}
+	sort.Ints(slots)
+	for _, s := range slots {
+		if err = r.step(modelOp{kind: opClose, handle: s}); err != nil {
+			return
+		}
+	}
+
+	return r.checkFinalState()
+}
+
+////////////////////////////////////////////////////////////////////////
+// Shrinking
+////////////////////////////////////////////////////////////////////////
+
+// Shrink a failing sequence by repeatedly removing chunks of ops, and by
+// serializing parallel batches, while it keeps failing.
+func shrinkOps(ops []modelOp, fails func([]modelOp) bool) []modelOp {
+	for {
+		ops = removeChunks(ops, fails)
+
+		serialized := false
+		for i := 0; i < len(ops); i++ {
+			if ops[i].kind != opParallel {
+				continue
+			}
+
+			candidate := append(append(append([]modelOp{}, ops[:i]...), ops[i].batch...), ops[i+1:]...)
+			if fails(candidate) {
+				ops = candidate
+				serialized = true
+				i--
+			}
+		}
+
+		if !serialized {
+			return ops
+		}
+	}
+}
+
+func removeChunks(ops []modelOp, fails func([]modelOp) bool) []modelOp {
+	for chunk := len(ops) / 2; chunk >= 1; {
+		removed := false
+		for i := 0; i+chunk <= len(ops); {
+			candidate := append(append([]modelOp{}, ops[:i]...), ops[i+chunk:]...)
+			if fails(candidate) {
+				ops = candidate
+				removed = true
+				continue
+			}
+			i++
+		}
+
+		if !removed {
+			chunk /= 2
+		}
+	}
+
+	return ops
+}
+
+func formatOps(ops []modelOp) string {
+	var sb strings.Builder
+	for i, op := range ops {
+		fmt.Fprintf(&sb, "  %3d: %v\n", i, op)
+	}
+
+	return sb.String()
+}
+
+////////////////////////////////////////////////////////////////////////
+// Tests
+////////////////////////////////////////////////////////////////////////
+
+func TestModelBasedRandomOps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping model-based test in short mode")
+	}
+
+	locker.EnableInvariantsCheck()
+
+	firstSeed := *fModelSeed
+	if firstSeed == 0 {
+		firstSeed = time.Now().UnixNano()
+	}
+
+	for _, mode := range modelModes {
+		t.Run(mode.name, func(t *testing.T) {
+			for i := 0; i < *fModelSequences; i++ {
+				seed := firstSeed + int64(i)
+				ops := generateOps(seed, mode, *fModelSequenceOp)
+
+				err := runModelSequence(t, mode, ops)
+				if err == nil {
+					continue
+				}
+
+				if !*fModelShrink {
+					t.Fatalf("seed %d: %v\nFailing sequence:\n%s", seed, err, formatOps(ops))
+				}
+
+				shrunk := shrinkOps(ops, func(candidate []modelOp) bool {
+					return runModelSequence(t, mode, candidate) != nil
+				})
+				t.Fatalf("seed %d: %v\nMinimal failing sequence:\n%sFailure: %v",
+					seed, err, formatOps(shrunk), runModelSequence(t, mode, shrunk))
+			}
+		})
+	}
+}
+
+// Sequences found by TestModelBasedRandomOps, run in every mode.
+var modelRegressions = map[string][]modelOp{
+	// The destination dir inode was left locked when renaming a dir onto a
+	// non-empty dir in a hierarchical bucket.
+	"rename_dir_onto_non_empty_dir": {
+		{kind: opMkdir, path: "b"},
+		{kind: opMkdir, path: "a"},
+		{kind: opMkdir, path: "a/a"},
+		{kind: opRename, path: "b", path2: "a"},
+	},
+	// Caching file "c" failed because evicting "c/c/c" left the directories
+	// "c/c" in the cache dir.
+	"file_replacing_cached_dir": {
+		{kind: opMkdir, path: "c"},
+		{kind: opMkdir, path: "c/c"},
+		{kind: opCreate, path: "c/c/c", data: []byte("taco")},
+		{kind: opRead, path: "c/c/c"},
+		{kind: opRename, path: "c/c/c", path2: "b"},
+		{kind: opRmdir, path: "c/c"},
+		{kind: opRmdir, path: "c"},
+		{kind: opCreate, path: "c", data: []byte("burrito")},
+		{kind: opRead, path: "c"},
+	},
+}
+
+func TestModelRegressions(t *testing.T) {
+	locker.EnableInvariantsCheck()
+
+	for _, mode := range modelModes {
+		for name, ops := range modelRegressions {
+			t.Run(mode.name+"/"+name, func(t *testing.T) {
+				if err := runModelSequence(t, mode, ops); err != nil {
+					t.Fatalf("%v\nSequence:\n%s", err, formatOps(ops))
+				}
+			})
+		}
+	}
+}
+
+func TestShrinkOps(t *testing.T) {
+	ops := []modelOp{
+		{kind: opMkdir, path: "a"},
+		{kind: opMkdir, path: "b"},
+		{kind: opParallel, batch: []modelOp{{kind: opMkdir, path: "c"}, {kind: opCreate, path: "a/x"}}},
+		{kind: opRead, path: "a"},
+	}
+	// Fails whenever "a/x" is created after "a".
+	fails := func(ops []modelOp) bool {
+		sawDir := false
+		for _, op := range ops {
+			flat := []modelOp{op}
+			if op.kind == opParallel {
+				flat = op.batch
+			}
+			for _, f := range flat {
+				sawDir = sawDir || (f.kind == opMkdir && f.path == "a")
+				if sawDir && f.kind == opCreate && f.path == "a/x" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	shrunk := shrinkOps(ops, fails)
+
+	want := []modelOp{{kind: opMkdir, path: "a"}, {kind: opCreate, path: "a/x"}}
+	if !reflect.DeepEqual(want, shrunk) {
+		t.Errorf("shrinkOps: got\n%swant\n%s", formatOps(shrunk), formatOps(want))
+	}
+}
+
+func TestPosixModelRename(t *testing.T) {
+	m := newPosixModel()
+	m.apply(modelOp{kind: opMkdir, path: "a"})
+	m.apply(modelOp{kind: opMkdir, path: "a/b"})
+	m.apply(modelOp{kind: opCreate, path: "a/b/f", data: []byte("x")})
+	m.apply(modelOp{kind: opMkdir, path: "c"})
+	m.apply(modelOp{kind: opCreate, path: "c/f", data: []byte("y")})
+
+	cases := []struct {
+		src, dst string
+		want     syscall.Errno
+	}{
+		{"a", "a/b/z", syscall.EINVAL},
+		{"a/b/f", "c", syscall.EISDIR},
+		{"a", "c/f", syscall.ENOTDIR},
+		{"a/b", "c", syscall.ENOTEMPTY},
+		{"missing", "z", syscall.ENOENT},
+		{"a", "z", 0},
+	}
+	for _, tc := range cases {
+		if got := m.apply(modelOp{kind: opRename, path: tc.src, path2: tc.dst}).errno; got != tc.want {
+			t.Errorf("rename(%q, %q): got %v, want %v", tc.src, tc.dst, got, tc.want)
+		}
+	}
+
+	if got := string(m.files["z/b/f"]); got != "x" {
+		t.Errorf("z/b/f: got %q, want %q", got, "x")
+	}
+	if m.dirs["a"] || m.dirs["a/b"] {
+		t.Errorf("directory a was not moved: %v", m.dirs)
+	}
+}
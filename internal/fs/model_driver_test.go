@@ -0,0 +1,433 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+// fuseDriver applies modelOps to a fuseutil.FileSystem by issuing fuseops
+// directly, without a kernel mount. It emulates the parts of path resolution
+// and argument checking that the kernel performs before an op reaches the
+// file system, so that its results are comparable with posixModel.
+type fuseDriver struct {
+	fs fuseutil.FileSystem
+
+	// Handles opened by opOpen, by slot.
+	handles map[int]driverHandle
+}
+
+type driverHandle struct {
+	inode  fuseops.InodeID
+	handle fuseops.HandleID
+}
+
+func newFuseDriver(fs fuseutil.FileSystem) *fuseDriver {
+	return &fuseDriver{
+		fs:      fs,
+		handles: make(map[int]driverHandle),
+	}
+}
+
+// errnoOf converts an error returned by the error-mapping wrapper into an
+// errno. Any other error is reported as EIO.
+func errnoOf(err error) syscall.Errno {
+	if err == nil {
+		return 0
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return errno
+	}
+
+	return syscall.EIO
+}
+
+func splitModelPath(p string) (parent, name string) {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return "", p
+	}
+
+	return p[:i], p[i+1:]
+}
+
+// Look up the inode for the given path, as the kernel would by walking it
+// from the root.
+func (d *fuseDriver) resolve(ctx context.Context, p string) (id fuseops.InodeID, attrs fuseops.InodeAttributes, errno syscall.Errno) {
+	id = fuseops.RootInodeID
+	attrs.Mode = os.ModeDir
+	if p == "" {
+		return
+	}
+
+	for _, name := range strings.Split(p, "/") {
+		if !attrs.Mode.IsDir() {
+			errno = syscall.ENOTDIR
+			return
+		}
+
+		op := &fuseops.LookUpInodeOp{Parent: id, Name: name}
+		if errno = errnoOf(d.fs.LookUpInode(ctx, op)); errno != 0 {
+			return
+		}
+
+		id, attrs = op.Entry.Child, op.Entry.Attributes
+	}
+
+	return
+}
+
+// Resolve the parent directory of p, and look up its final component, which
+// may not exist.
+func (d *fuseDriver) resolveChild(ctx context.Context, p string) (parent fuseops.InodeID, name string, child *fuseops.ChildInodeEntry, errno syscall.Errno) {
+	parentPath, name := splitModelPath(p)
+	parent, attrs, errno := d.resolve(ctx, parentPath)
+	if errno != 0 {
+		return
+	}
+
+	if !attrs.Mode.IsDir() {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	op := &fuseops.LookUpInodeOp{Parent: parent, Name: name}
+	switch e := errnoOf(d.fs.LookUpInode(ctx, op)); e {
+	case 0:
+		child = &op.Entry
+	case syscall.ENOENT:
+	default:
+		errno = e
+	}
+
+	return
+}
+
+func (d *fuseDriver) apply(ctx context.Context, op modelOp) (r opResult) {
+	switch op.kind {
+	case opMkdir:
+		r.errno = d.mkdir(ctx, op.path)
+	case opCreate:
+		r.errno = d.create(ctx, op.path, op.data)
+	case opWrite:
+		r.errno = d.write(ctx, op.path, op.offset, op.data)
+	case opRead:
+		r.data, r.errno = d.read(ctx, op.path)
+	case opUnlink:
+		r.errno = d.unlink(ctx, op.path)
+	case opRmdir:
+		r.errno = d.rmdir(ctx, op.path)
+	case opRename:
+		r.errno = d.rename(ctx, op.path, op.path2)
+	case opReadDir:
+		r.entries, r.errno = d.readDir(ctx, op.path)
+	case opOpen:
+		r.errno = d.open(ctx, op.handle, op.path)
+	case opHandleWrite:
+		h := d.handles[op.handle]
+		r.errno = errnoOf(d.fs.WriteFile(ctx, &fuseops.WriteFileOp{Inode: h.inode, Handle: h.handle, Offset: op.offset, Data: op.data}))
+	case opClose:
+		h := d.handles[op.handle]
+		delete(d.handles, op.handle)
+		r.errno = d.closeFile(ctx, h.inode, h.handle)
+	}
+
+	return
+}
+
+func (d *fuseDriver) mkdir(ctx context.Context, p string) syscall.Errno {
+	parent, name, child, errno := d.resolveChild(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if child != nil {
+		return syscall.EEXIST
+	}
+
+	return errnoOf(d.fs.MkDir(ctx, &fuseops.MkDirOp{Parent: parent, Name: name, Mode: dirPerms | os.ModeDir}))
+}
+
+// Equivalent to open(O_CREAT|O_EXCL), write and close.
+func (d *fuseDriver) create(ctx context.Context, p string, data []byte) syscall.Errno {
+	parent, name, child, errno := d.resolveChild(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if child != nil {
+		return syscall.EEXIST
+	}
+
+	op := &fuseops.CreateFileOp{Parent: parent, Name: name, Mode: filePerms}
+	if errno = errnoOf(d.fs.CreateFile(ctx, op)); errno != 0 {
+		return errno
+	}
+
+	if len(data) > 0 {
+		errno = errnoOf(d.fs.WriteFile(ctx, &fuseops.WriteFileOp{Inode: op.Entry.Child, Handle: op.Handle, Data: data}))
+	}
+
+	if closeErrno := d.closeFile(ctx, op.Entry.Child, op.Handle); errno == 0 {
+		errno = closeErrno
+	}
+
+	return errno
+}
+
+// Equivalent to open(O_WRONLY), pwrite and close.
+func (d *fuseDriver) write(ctx context.Context, p string, offset int64, data []byte) syscall.Errno {
+	id, attrs, errno := d.resolve(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if attrs.Mode.IsDir() {
+		return syscall.EISDIR
+	}
+
+	op := &fuseops.OpenFileOp{Inode: id, OpenFlags: syscall.O_WRONLY}
+	if errno = errnoOf(d.fs.OpenFile(ctx, op)); errno != 0 {
+		return errno
+	}
+
+	errno = errnoOf(d.fs.WriteFile(ctx, &fuseops.WriteFileOp{Inode: id, Handle: op.Handle, Offset: offset, Data: data}))
+	if closeErrno := d.closeFile(ctx, id, op.Handle); errno == 0 {
+		errno = closeErrno
+	}
+
+	return errno
+}
+
+// Read the whole of the file at p through a new read-only handle.
+func (d *fuseDriver) read(ctx context.Context, p string) (data []byte, errno syscall.Errno) {
+	id, attrs, errno := d.resolve(ctx, p)
+	if errno != 0 {
+		return
+	}
+
+	if attrs.Mode.IsDir() {
+		errno = syscall.EISDIR
+		return
+	}
+
+	openOp := &fuseops.OpenFileOp{Inode: id, OpenFlags: syscall.O_RDONLY}
+	if errno = errnoOf(d.fs.OpenFile(ctx, openOp)); errno != 0 {
+		return
+	}
+
+	defer d.fs.ReleaseFileHandle(ctx, &fuseops.ReleaseFileHandleOp{Handle: openOp.Handle})
+
+	data = []byte{}
+	for {
+		op := &fuseops.ReadFileOp{Inode: id, Handle: openOp.Handle, Offset: int64(len(data)), Dst: make([]byte, 4096)}
+		if errno = errnoOf(d.fs.ReadFile(ctx, op)); errno != 0 {
+			return
+		}
+
+		if op.BytesRead == 0 {
+			return
+		}
+
+		data = append(data, op.Dst[:op.BytesRead]...)
+	}
+}
+
+func (d *fuseDriver) unlink(ctx context.Context, p string) syscall.Errno {
+	parent, name, child, errno := d.resolveChild(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if child == nil {
+		return syscall.ENOENT
+	}
+
+	if child.Attributes.Mode.IsDir() {
+		return syscall.EISDIR
+	}
+
+	return errnoOf(d.fs.Unlink(ctx, &fuseops.UnlinkOp{Parent: parent, Name: name}))
+}
+
+func (d *fuseDriver) rmdir(ctx context.Context, p string) syscall.Errno {
+	parent, name, child, errno := d.resolveChild(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if child == nil {
+		return syscall.ENOENT
+	}
+
+	if !child.Attributes.Mode.IsDir() {
+		return syscall.ENOTDIR
+	}
+
+	return errnoOf(d.fs.RmDir(ctx, &fuseops.RmDirOp{Parent: parent, Name: name}))
+}
+
+func (d *fuseDriver) rename(ctx context.Context, src, dst string) syscall.Errno {
+	srcParent, srcName, srcChild, errno := d.resolveChild(ctx, src)
+	if errno != 0 {
+		return errno
+	}
+
+	if srcChild == nil {
+		return syscall.ENOENT
+	}
+
+	dstParent, dstName, dstChild, errno := d.resolveChild(ctx, dst)
+	if errno != 0 {
+		return errno
+	}
+
+	srcIsDir := srcChild.Attributes.Mode.IsDir()
+	if srcIsDir && strings.HasPrefix(dst, src+"/") {
+		return syscall.EINVAL
+	}
+
+	if dstChild != nil {
+		dstIsDir := dstChild.Attributes.Mode.IsDir()
+		if !srcIsDir && dstIsDir {
+			return syscall.EISDIR
+		}
+
+		if srcIsDir && !dstIsDir {
+			return syscall.ENOTDIR
+		}
+	}
+
+	// The kernel doesn't bother the file system with renaming a name to itself.
+	if src == dst {
+		return 0
+	}
+
+	return errnoOf(d.fs.Rename(ctx, &fuseops.RenameOp{OldParent: srcParent, OldName: srcName, NewParent: dstParent, NewName: dstName}))
+}
+
+// List the directory at p, returning sorted names with a trailing slash for
+// directories.
+func (d *fuseDriver) readDir(ctx context.Context, p string) (entries []string, errno syscall.Errno) {
+	id, attrs, errno := d.resolve(ctx, p)
+	if errno != 0 {
+		return
+	}
+
+	if !attrs.Mode.IsDir() {
+		errno = syscall.ENOTDIR
+		return
+	}
+
+	openOp := &fuseops.OpenDirOp{Inode: id}
+	if errno = errnoOf(d.fs.OpenDir(ctx, openOp)); errno != 0 {
+		return
+	}
+
+	defer d.fs.ReleaseDirHandle(ctx, &fuseops.ReleaseDirHandleOp{Handle: openOp.Handle})
+
+	entries = []string{}
+	var offset fuseops.DirOffset
+	for {
+		op := &fuseops.ReadDirOp{Inode: id, Handle: openOp.Handle, Offset: offset, Dst: make([]byte, 4096)}
+		if errno = errnoOf(d.fs.ReadDir(ctx, op)); errno != 0 {
+			return
+		}
+
+		if op.BytesRead == 0 {
+			break
+		}
+
+		for _, de := range parseDirents(op.Dst[:op.BytesRead]) {
+			name := de.Name
+			if de.Type == fuseutil.DT_Directory {
+				name += "/"
+			}
+
+			entries = append(entries, name)
+			offset = de.Offset
+		}
+	}
+
+	sort.Strings(entries)
+	return
+}
+
+func (d *fuseDriver) open(ctx context.Context, slot int, p string) syscall.Errno {
+	id, attrs, errno := d.resolve(ctx, p)
+	if errno != 0 {
+		return errno
+	}
+
+	if attrs.Mode.IsDir() {
+		return syscall.EISDIR
+	}
+
+	op := &fuseops.OpenFileOp{Inode: id, OpenFlags: syscall.O_RDWR}
+	if errno = errnoOf(d.fs.OpenFile(ctx, op)); errno != 0 {
+		return errno
+	}
+
+	d.handles[slot] = driverHandle{inode: id, handle: op.Handle}
+	return 0
+}
+
+// Equivalent to close(2): flush, then release the handle.
+func (d *fuseDriver) closeFile(ctx context.Context, id fuseops.InodeID, h fuseops.HandleID) syscall.Errno {
+	errno := errnoOf(d.fs.FlushFile(ctx, &fuseops.FlushFileOp{Inode: id, Handle: h}))
+	if releaseErrno := errnoOf(d.fs.ReleaseFileHandle(ctx, &fuseops.ReleaseFileHandleOp{Handle: h})); errno == 0 {
+		errno = releaseErrno
+	}
+
+	return errno
+}
+
+// Parse the fuse_dirent records written by fuseutil.WriteDirent.
+func parseDirents(buf []byte) (out []fuseutil.Dirent) {
+	const direntSize = 8 + 8 + 4 + 4
+	for len(buf) >= direntSize {
+		nameLen := int(binary.NativeEndian.Uint32(buf[16:20]))
+		out = append(out, fuseutil.Dirent{
+			Inode:  fuseops.InodeID(binary.NativeEndian.Uint64(buf[0:8])),
+			Offset: fuseops.DirOffset(binary.NativeEndian.Uint64(buf[8:16])),
+			Type:   fuseutil.DirentType(binary.NativeEndian.Uint32(buf[20:24])),
+			Name:   string(buf[direntSize : direntSize+nameLen]),
+		})
+
+		recordLen := direntSize + nameLen
+		if recordLen%8 != 0 {
+			recordLen += 8 - recordLen%8
+		}
+
+		if recordLen > len(buf) {
+			break
+		}
+
+		buf = buf[recordLen:]
+	}
+
+	return
+}
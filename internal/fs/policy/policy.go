@@ -0,0 +1,302 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy restricts what a mount exposes and which of its paths can be
+// modified, with glob rules on object names relative to the mount root.
+//
+// A pattern is matched one path component at a time with path.Match, except
+// that a "**" component matches any number of components, including none. A
+// pattern that matches a directory applies to everything under it, so both
+// "datasets" and "datasets/**" protect the whole of datasets/. Renaming a
+// directory moves the objects under it, so it is checked against the rules
+// matching each of them as well.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"syscall"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+)
+
+// A Policy decides which objects are visible and what may be done to them.
+//
+// A nil *Policy hides nothing and allows everything.
+type Policy struct {
+	hidden     []pattern
+	readOnly   []pattern
+	appendOnly []pattern
+	denyDelete []pattern
+	writeOnce  []pattern
+}
+
+// New returns the policy described by c, or nil if c has no rules.
+func New(c *cfg.AccessPolicyConfig) (*Policy, error) {
+	if len(c.Hidden)+len(c.ReadOnly)+len(c.AppendOnly)+len(c.DenyDelete)+len(c.WriteOncePaths) == 0 && !c.WriteOnce {
+		return nil, nil
+	}
+
+	writeOnce := c.WriteOncePaths
+	if c.WriteOnce {
+		writeOnce = []string{"**"}
+	}
+
+	p := &Policy{}
+	for _, r := range []struct {
+		dst  *[]pattern
+		srcs []string
+	}{
+		{&p.hidden, c.Hidden},
+		{&p.readOnly, c.ReadOnly},
+		{&p.appendOnly, c.AppendOnly},
+		{&p.denyDelete, c.DenyDelete},
+		{&p.writeOnce, writeOnce},
+	} {
+		for _, s := range r.srcs {
+			pat, err := compile(s)
+			if err != nil {
+				return nil, err
+			}
+			*r.dst = append(*r.dst, pat)
+		}
+	}
+
+	return p, nil
+}
+
+// IsHidden returns true if the named object, or a directory containing it,
+// is hidden. Names are object names relative to the mount root; directory
+// names may end in "/".
+func (p *Policy) IsHidden(name string) bool {
+	return p != nil && matchAny(p.hidden, name)
+}
+
+// CheckCreate returns an error if an object with the given name may not be
+// created.
+func (p *Policy) CheckCreate(name string) error {
+	if p == nil {
+		return nil
+	}
+
+	if matchAny(p.readOnly, name) {
+		return fmt.Errorf("%q is read-only: %w", name, syscall.EROFS)
+	}
+
+	if matchAny(p.hidden, name) {
+		return fmt.Errorf("%q is hidden: %w", name, syscall.EACCES)
+	}
+
+	return nil
+}
+
+// CheckModify returns an error if the contents of the existing object with
+// the given name may not be changed. Appends are allowed to append-only
+// objects.
+func (p *Policy) CheckModify(name string, appendOnly bool) error {
+	if p == nil {
+		return nil
+	}
+
+	if matchAny(p.readOnly, name) {
+		return fmt.Errorf("%q is read-only: %w", name, syscall.EROFS)
+	}
+
+	if !appendOnly && matchAny(p.appendOnly, name) {
+		return fmt.Errorf("%q is append-only: %w", name, syscall.EACCES)
+	}
+
+	return nil
+}
+
+// IsWriteOnce returns true if the named object is write-once: it may be
+// created, but once it exists in GCS it may not be replaced or deleted.
+func (p *Policy) IsWriteOnce(name string) bool {
+	return p != nil && matchAny(p.writeOnce, name)
+}
+
+// CheckOverwrite returns an error if the named object, which exists in GCS,
+// may not be replaced by new contents or by renaming another object onto it.
+func (p *Policy) CheckOverwrite(name string) error {
+	if p.IsWriteOnce(name) {
+		return fmt.Errorf("%q is write-once: %w", name, syscall.EPERM)
+	}
+
+	return nil
+}
+
+// CheckDelete returns an error if the object with the given name may not be
+// deleted, including by renaming it.
+func (p *Policy) CheckDelete(name string) error {
+	if p == nil {
+		return nil
+	}
+
+	if matchAny(p.readOnly, name) {
+		return fmt.Errorf("%q is read-only: %w", name, syscall.EROFS)
+	}
+
+	if matchAny(p.appendOnly, name) {
+		return fmt.Errorf("%q is append-only: %w", name, syscall.EACCES)
+	}
+
+	if matchAny(p.denyDelete, name) {
+		return fmt.Errorf("%q is protected from deletion: %w", name, syscall.EACCES)
+	}
+
+	if matchAny(p.writeOnce, name) {
+		return fmt.Errorf("%q is write-once: %w", name, syscall.EPERM)
+	}
+
+	return nil
+}
+
+// AppliesBelow returns true if a rule could apply to an object below the
+// directory with the given name, so that renaming it from or to that name
+// requires checking its descendants with CheckRenameDir.
+func (p *Policy) AppliesBelow(name string) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, patterns := range [][]pattern{p.hidden, p.readOnly, p.appendOnly, p.denyDelete, p.writeOnce} {
+		if matchAnyOrBelow(patterns, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckRenameDir returns an error if the directory oldName may not be renamed
+// to newName, taking along the objects below it named in descendants.
+func (p *Policy) CheckRenameDir(oldName, newName string, descendants []string) error {
+	if err := p.CheckDelete(oldName); err != nil {
+		return err
+	}
+
+	if err := p.CheckCreate(newName); err != nil {
+		return err
+	}
+
+	for _, d := range descendants {
+		if err := p.CheckDelete(d); err != nil {
+			return err
+		}
+
+		if err := p.CheckCreate(newName + strings.TrimPrefix(d, oldName)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////
+// Patterns
+////////////////////////////////////////////////////////////////////////
+
+// A pattern split into path components.
+type pattern []string
+
+func compile(s string) (pattern, error) {
+	if _, err := path.Match(s, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+
+	return strings.Split(strings.Trim(s, "/"), "/"), nil
+}
+
+// Return true if some pattern matches the name or one of its parent
+// directories.
+func matchAny(patterns []pattern, name string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	components := strings.Split(strings.Trim(name, "/"), "/")
+	for _, p := range patterns {
+		for n := 1; n <= len(components); n++ {
+			if p.match(components[:n]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Return true if some pattern matches the name, one of its parent
+// directories, or could match an object below it.
+func matchAnyOrBelow(patterns []pattern, name string) bool {
+	if matchAny(patterns, name) {
+		return true
+	}
+
+	components := strings.Split(strings.Trim(name, "/"), "/")
+	for _, p := range patterns {
+		if p.matchBelow(components) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Return true if the pattern could match a path strictly below the directory
+// with the given components.
+func (p pattern) matchBelow(components []string) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	// "**" can take all of the directory's components, and then whatever is
+	// left of the pattern matches something below it.
+	if p[0] == "**" {
+		return true
+	}
+
+	if len(components) == 0 {
+		return true
+	}
+
+	// The pattern was validated when compiled.
+	ok, _ := path.Match(p[0], components[0])
+	return ok && p[1:].matchBelow(components[1:])
+}
+
+func (p pattern) match(components []string) bool {
+	if len(p) == 0 {
+		return len(components) == 0
+	}
+
+	if p[0] == "**" {
+		// Let "**" match as few components as possible first.
+		for i := 0; i <= len(components); i++ {
+			if p[1:].match(components[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(components) == 0 {
+		return false
+	}
+
+	// The pattern was validated when compiled.
+	ok, _ := path.Match(p[0], components[0])
+	return ok && p[1:].match(components[1:])
+}
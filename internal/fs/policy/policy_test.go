@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_NoRules(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{})
+
+	require.NoError(t, err)
+	assert.Nil(t, p)
+	assert.False(t, p.IsHidden("foo"))
+	assert.NoError(t, p.CheckCreate("foo"))
+	assert.NoError(t, p.CheckModify("foo", false))
+	assert.NoError(t, p.CheckDelete("foo"))
+	assert.False(t, p.AppliesBelow("foo/"))
+	assert.NoError(t, p.CheckRenameDir("foo/", "bar/", []string{"foo/a"}))
+	assert.False(t, p.IsWriteOnce("foo"))
+	assert.NoError(t, p.CheckOverwrite("foo"))
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := New(&cfg.AccessPolicyConfig{Hidden: []string{"foo/["}})
+
+	assert.ErrorContains(t, err, `invalid pattern "foo/["`)
+}
+
+func TestIsHidden(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		hidden  bool
+	}{
+		{"**/.secret*", ".secret", true},
+		{"**/.secret*", "a/b/.secrets.json", true},
+		{"**/.secret*", "a/.secret/", true},
+		{"**/.secret*", "a/.secret/key", true},
+		{"**/.secret*", "a/not.secret", false},
+		{"private", "private/", true},
+		{"private", "private/a/b", true},
+		{"private", "a/private", false},
+		{"private/**", "private/a", true},
+		{"*/tmp", "a/tmp/b", true},
+		{"*/tmp", "a/b/tmp", false},
+		{"a/**/z", "a/z", true},
+		{"a/**/z", "a/b/c/z", true},
+		{"a/**/z", "a/b/c", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			p, err := New(&cfg.AccessPolicyConfig{Hidden: []string{tc.pattern}})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.hidden, p.IsHidden(tc.name))
+		})
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{ReadOnly: []string{"datasets/**"}})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.CheckCreate("datasets/new"), syscall.EROFS)
+	assert.ErrorIs(t, p.CheckModify("datasets/a", true), syscall.EROFS)
+	assert.ErrorIs(t, p.CheckDelete("datasets/"), syscall.EROFS)
+	assert.NoError(t, p.CheckCreate("scratch/new"))
+	assert.NoError(t, p.CheckDelete("scratch/old"))
+}
+
+func TestAppendOnly(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{AppendOnly: []string{"logs"}})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.CheckCreate("logs/today"))
+	assert.NoError(t, p.CheckModify("logs/today", true))
+	assert.ErrorIs(t, p.CheckModify("logs/today", false), syscall.EACCES)
+	assert.ErrorIs(t, p.CheckDelete("logs/today"), syscall.EACCES)
+}
+
+func TestDenyDelete(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{DenyDelete: []string{"shared/*"}})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.CheckDelete("shared/data/part-0"), syscall.EACCES)
+	assert.NoError(t, p.CheckDelete("shared"))
+	assert.NoError(t, p.CheckModify("shared/data/part-0", false))
+	assert.NoError(t, p.CheckCreate("shared/data/part-1"))
+}
+
+func TestHiddenCantBeCreated(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{Hidden: []string{"**/.secret*"}})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.CheckCreate("a/.secret"), syscall.EACCES)
+	assert.NoError(t, p.CheckCreate("a/public"))
+}
+
+func TestWriteOnce(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{WriteOncePaths: []string{"archive/**"}})
+	require.NoError(t, err)
+
+	assert.True(t, p.IsWriteOnce("archive/2025/01"))
+	assert.NoError(t, p.CheckCreate("archive/2025/01"))
+	assert.NoError(t, p.CheckModify("archive/2025/01", false))
+	assert.ErrorIs(t, p.CheckOverwrite("archive/2025/01"), syscall.EPERM)
+	assert.ErrorIs(t, p.CheckDelete("archive/2025/"), syscall.EPERM)
+	assert.False(t, p.IsWriteOnce("scratch/a"))
+	assert.NoError(t, p.CheckOverwrite("scratch/a"))
+	assert.NoError(t, p.CheckDelete("scratch/a"))
+}
+
+func TestWriteOnce_WholeMount(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{WriteOnce: true})
+	require.NoError(t, err)
+
+	assert.True(t, p.IsWriteOnce("a"))
+	assert.True(t, p.IsWriteOnce("a/b/c"))
+	assert.ErrorIs(t, p.CheckDelete("a/"), syscall.EPERM)
+}
+
+func TestAppliesBelow(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		applies bool
+	}{
+		{"data/protected.txt", "data/", true},
+		{"data/protected.txt", "data/sub/", false},
+		{"data/protected.txt", "other/", false},
+		{"*/keep", "a/", true},
+		{"a/*/keep", "a/b/", true},
+		{"a/*/keep", "b/", false},
+		{"**/.keep", "any/dir/", true},
+		{"shared", "shared/sub/", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.dir, func(t *testing.T) {
+			p, err := New(&cfg.AccessPolicyConfig{DenyDelete: []string{tc.pattern}})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.applies, p.AppliesBelow(tc.dir))
+		})
+	}
+}
+
+func TestDirWithoutMatchingDescendants(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{Hidden: []string{"**/.secret*"}, DenyDelete: []string{"**/*.ckpt"}})
+	require.NoError(t, err)
+
+	// An empty directory may be removed and renamed, although the rules could
+	// apply below it.
+	assert.NoError(t, p.CheckDelete("empty/"))
+	assert.NoError(t, p.CheckRenameDir("empty/", "a/b/", nil))
+	// So may a directory holding only objects no rule applies to.
+	assert.NoError(t, p.CheckRenameDir("a/", "b/", []string{"a/x", "a/sub/", "a/sub/y"}))
+}
+
+func TestCheckRenameDir(t *testing.T) {
+	p, err := New(&cfg.AccessPolicyConfig{
+		ReadOnly:       []string{"datasets/*/raw"},
+		Hidden:         []string{"private/*"},
+		DenyDelete:     []string{"**/*.ckpt"},
+		WriteOncePaths: []string{"archive/*"},
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.CheckRenameDir("a/", "b/", []string{"a/x", "a/model.ckpt"}), syscall.EACCES)
+	assert.ErrorIs(t, p.CheckRenameDir("datasets/", "b/", []string{"datasets/x/raw"}), syscall.EROFS)
+	assert.ErrorIs(t, p.CheckRenameDir("a/", "datasets/", []string{"a/x/raw"}), syscall.EROFS)
+	assert.ErrorIs(t, p.CheckRenameDir("a/", "private/", []string{"a/x"}), syscall.EACCES)
+	assert.ErrorIs(t, p.CheckRenameDir("archive/", "b/", []string{"archive/x"}), syscall.EPERM)
+	assert.NoError(t, p.CheckRenameDir("datasets/", "b/", []string{"datasets/x/cooked"}))
+	assert.NoError(t, p.CheckRenameDir("a/", "private/", nil))
+}
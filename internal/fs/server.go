@@ -26,15 +26,50 @@ import (
 
 // NewServer creates a fuse file system server according to the supplied configuration.
 func NewServer(ctx context.Context, cfg *ServerConfig) (fuse.Server, error) {
+	fs, err := NewWrappedFileSystem(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return fuseutil.NewFileSystemServer(fs), nil
+}
+
+// NewWrappedFileSystem creates the file system served by NewServer, i.e. the
+// one from NewFileSystem wrapped with attribution, error mapping, tracing,
+// monitoring, timeline recording, health recording and slow op detection.
+func NewWrappedFileSystem(ctx context.Context, cfg *ServerConfig) (fuseutil.FileSystem, error) {
 	fs, err := NewFileSystem(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("create file system: %w", err)
 	}
 
+	return WrapFileSystem(fs, cfg), nil
+}
+
+// WrapFileSystem wraps a file system from NewFileSystem as
+// NewWrappedFileSystem does.
+func WrapFileSystem(fs fuseutil.FileSystem, cfg *ServerConfig) fuseutil.FileSystem {
+	paths, _ := fs.(wrappers.InodePaths)
+	if cfg.Attribution != nil {
+		fs = wrappers.WithAttribution(fs, cfg.Attribution, paths)
+	}
+	perUserCredentials := newcfg.IsPerUserCredentialsEnabled(cfg.NewConfig)
+	if auditHeaders := cfg.NewConfig.Audit.GcsRequestHeaders; perUserCredentials || auditHeaders {
+		fs = wrappers.WithCallerIdentity(fs, perUserCredentials, auditHeaders)
+	}
 	fs = wrappers.WithErrorMapping(fs, cfg.NewConfig.FileSystem.PreconditionErrors)
 	if newcfg.IsTracingEnabled(cfg.NewConfig) {
 		fs = wrappers.WithTracing(fs)
 	}
 	fs = wrappers.WithMonitoring(fs, cfg.MetricHandle)
-	return fuseutil.NewFileSystemServer(fs), nil
+	if cfg.NewConfig.Debug.TimelineMaxEvents > 0 {
+		fs = wrappers.WithTimeline(fs)
+	}
+	if cfg.Health != nil {
+		fs = wrappers.WithHealth(fs, cfg.Health)
+	}
+	if d := cfg.NewConfig.Debug; d.SlowOpThreshold > 0 {
+		fs = wrappers.WithSlowOpDetection(fs, d.SlowOpThreshold, d.SlowOpDumpInterval, paths)
+	}
+	return fs
 }
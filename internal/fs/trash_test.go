@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Tests for removed files being moved to the trash rather than deleted.
+
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"path"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/gcsx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	. "github.com/jacobsa/oglematchers"
+	. "github.com/jacobsa/ogletest"
+)
+
+type TrashTest struct {
+	fsTest
+}
+
+func init() {
+	RegisterTestSuite(&TrashTest{})
+}
+
+func (t *TrashTest) SetUpTestSuite() {
+	t.serverCfg.NewConfig = &cfg.Config{
+		FileCache: defaultFileCacheConfig(),
+		MetadataCache: cfg.MetadataCacheConfig{
+			StatCacheMaxSizeMb: 32,
+			TtlSecs:            60,
+			TypeCacheMaxSizeMb: 4,
+		},
+		FileSystem: cfg.FileSystemConfig{
+			EnableTrash:        true,
+			TrashRetentionSecs: -1,
+		},
+	}
+	t.fsTest.SetUpTestSuite()
+}
+
+func (t *TrashTest) RemoveMovesToTrash() {
+	_, err := storageutil.CreateObject(ctx, bucket, "dir/foo", []byte("taco"))
+	AssertEq(nil, err)
+	AssertEq(nil, os.Mkdir(path.Join(mntDir, "dir"), 0700))
+
+	err = os.Remove(path.Join(mntDir, "dir/foo"))
+
+	AssertEq(nil, err)
+	_, err = storageutil.ReadObject(ctx, bucket, "dir/foo")
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+	stamps, err := os.ReadDir(path.Join(mntDir, ".trash"))
+	AssertEq(nil, err)
+	AssertEq(1, len(stamps))
+	contents, err := os.ReadFile(path.Join(mntDir, ".trash", stamps[0].Name(), "dir/foo"))
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *TrashTest) RestoreByRenaming() {
+	_, err := storageutil.CreateObject(ctx, bucket, "foo", []byte("taco"))
+	AssertEq(nil, err)
+	AssertEq(nil, os.Remove(path.Join(mntDir, "foo")))
+	stamps, err := os.ReadDir(path.Join(mntDir, ".trash"))
+	AssertEq(nil, err)
+	AssertEq(1, len(stamps))
+
+	err = os.Rename(path.Join(mntDir, ".trash", stamps[0].Name(), "foo"), path.Join(mntDir, "foo"))
+
+	AssertEq(nil, err)
+	contents, err := storageutil.ReadObject(ctx, bucket, "foo")
+	AssertEq(nil, err)
+	ExpectEq("taco", string(contents))
+}
+
+func (t *TrashTest) RemoveFromTrashDeletes() {
+	trashName := gcsx.TrashObjectName(mtimeClock.Now(), "foo")
+	_, err := storageutil.CreateObject(ctx, bucket, gcsx.TrashPrefix, nil)
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(ctx, bucket, trashName, []byte("taco"))
+	AssertEq(nil, err)
+
+	err = os.Remove(path.Join(mntDir, trashName))
+
+	AssertEq(nil, err)
+	_, err = storageutil.ReadObject(ctx, bucket, trashName)
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+	entries, err := os.ReadDir(path.Join(mntDir, ".trash"))
+	AssertEq(nil, err)
+	ExpectEq(0, len(entries))
+}
+
+func (t *TrashTest) RemoveSamePathTwiceKeepsBoth() {
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "foo"), []byte("taco"), 0600))
+	AssertEq(nil, os.Remove(path.Join(mntDir, "foo")))
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "foo"), []byte("burrito"), 0600))
+
+	// The clock doesn't move between the removals.
+	err := os.Remove(path.Join(mntDir, "foo"))
+
+	AssertEq(nil, err)
+	stamps, err := os.ReadDir(path.Join(mntDir, ".trash"))
+	AssertEq(nil, err)
+	AssertEq(2, len(stamps))
+	var trashed []string
+	for _, stamp := range stamps {
+		contents, err := os.ReadFile(path.Join(mntDir, ".trash", stamp.Name(), "foo"))
+		AssertEq(nil, err)
+		trashed = append(trashed, string(contents))
+	}
+	// The second removal is trashed under a timestamp to the nanosecond, which
+	// sorts before the first one's.
+	ExpectThat(trashed, ElementsAre("burrito", "taco"))
+}
+
+func (t *TrashTest) RemoveSeveralFilesSharesTimestamp() {
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "foo"), []byte("taco"), 0600))
+	AssertEq(nil, os.WriteFile(path.Join(mntDir, "bar"), []byte("burrito"), 0600))
+
+	AssertEq(nil, os.Remove(path.Join(mntDir, "foo")))
+	AssertEq(nil, os.Remove(path.Join(mntDir, "bar")))
+
+	stamps, err := os.ReadDir(path.Join(mntDir, ".trash"))
+	AssertEq(nil, err)
+	AssertEq(1, len(stamps))
+	entries, err := os.ReadDir(path.Join(mntDir, ".trash", stamps[0].Name()))
+	AssertEq(nil, err)
+	ExpectEq(2, len(entries))
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+type attributing struct {
+	wrapped fuseutil.FileSystem
+	tracker *attribution.Tracker
+	paths   InodePaths
+}
+
+// WithAttribution wraps a FileSystem, attributing each op, the bytes read and
+// written by it and the GCS requests made for it to the process that invoked
+// it and to the prefix of the path of its inode, or for ops on a name in a
+// directory, the directory's.
+//
+// StatFS doesn't carry the caller's identity, so it is attributed to
+// attribution.UnknownCaller. paths may be nil, in which case every op is
+// attributed to the root.
+func WithAttribution(wrapped fuseutil.FileSystem, tracker *attribution.Tracker, paths InodePaths) fuseutil.FileSystem {
+	return &attributing{wrapped: wrapped, tracker: tracker, paths: paths}
+}
+
+func (fs *attributing) begin(ctx context.Context, opCtx fuseops.OpContext, inode fuseops.InodeID) context.Context {
+	var p string
+	if fs.paths != nil && inode != 0 {
+		p, _ = fs.paths.InodePath(inode)
+	}
+	return fs.tracker.Begin(ctx, opCtx.Pid, p)
+}
+
+func (fs *attributing) Destroy() {
+	fs.wrapped.Destroy()
+}
+
+func (fs *attributing) StatFS(ctx context.Context, op *fuseops.StatFSOp) error {
+	return fs.wrapped.StatFS(fs.begin(ctx, fuseops.OpContext{}, 0), op)
+}
+
+func (fs *attributing) LookUpInode(ctx context.Context, op *fuseops.LookUpInodeOp) error {
+	return fs.wrapped.LookUpInode(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) GetInodeAttributes(ctx context.Context, op *fuseops.GetInodeAttributesOp) error {
+	return fs.wrapped.GetInodeAttributes(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) SetInodeAttributes(ctx context.Context, op *fuseops.SetInodeAttributesOp) error {
+	return fs.wrapped.SetInodeAttributes(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ForgetInode(ctx context.Context, op *fuseops.ForgetInodeOp) error {
+	return fs.wrapped.ForgetInode(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) BatchForget(ctx context.Context, op *fuseops.BatchForgetOp) error {
+	return fs.wrapped.BatchForget(fs.begin(ctx, op.OpContext, 0), op)
+}
+
+func (fs *attributing) MkDir(ctx context.Context, op *fuseops.MkDirOp) error {
+	return fs.wrapped.MkDir(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) MkNode(ctx context.Context, op *fuseops.MkNodeOp) error {
+	return fs.wrapped.MkNode(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) CreateFile(ctx context.Context, op *fuseops.CreateFileOp) error {
+	return fs.wrapped.CreateFile(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) CreateLink(ctx context.Context, op *fuseops.CreateLinkOp) error {
+	return fs.wrapped.CreateLink(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) CreateSymlink(ctx context.Context, op *fuseops.CreateSymlinkOp) error {
+	return fs.wrapped.CreateSymlink(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) Rename(ctx context.Context, op *fuseops.RenameOp) error {
+	return fs.wrapped.Rename(fs.begin(ctx, op.OpContext, op.OldParent), op)
+}
+
+func (fs *attributing) RmDir(ctx context.Context, op *fuseops.RmDirOp) error {
+	return fs.wrapped.RmDir(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) Unlink(ctx context.Context, op *fuseops.UnlinkOp) error {
+	return fs.wrapped.Unlink(fs.begin(ctx, op.OpContext, op.Parent), op)
+}
+
+func (fs *attributing) OpenDir(ctx context.Context, op *fuseops.OpenDirOp) error {
+	return fs.wrapped.OpenDir(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ReadDir(ctx context.Context, op *fuseops.ReadDirOp) error {
+	return fs.wrapped.ReadDir(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ReleaseDirHandle(ctx context.Context, op *fuseops.ReleaseDirHandleOp) error {
+	return fs.wrapped.ReleaseDirHandle(fs.begin(ctx, op.OpContext, 0), op)
+}
+
+func (fs *attributing) OpenFile(ctx context.Context, op *fuseops.OpenFileOp) error {
+	return fs.wrapped.OpenFile(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	ctx = fs.begin(ctx, op.OpContext, op.Inode)
+	err := fs.wrapped.ReadFile(ctx, op)
+	attribution.RecordBytes(ctx, common.ReadDirection, int64(op.BytesRead))
+	return err
+}
+
+func (fs *attributing) WriteFile(ctx context.Context, op *fuseops.WriteFileOp) error {
+	ctx = fs.begin(ctx, op.OpContext, op.Inode)
+	err := fs.wrapped.WriteFile(ctx, op)
+	if err == nil {
+		attribution.RecordBytes(ctx, common.WriteDirection, int64(len(op.Data)))
+	}
+	return err
+}
+
+func (fs *attributing) SyncFile(ctx context.Context, op *fuseops.SyncFileOp) error {
+	return fs.wrapped.SyncFile(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) FlushFile(ctx context.Context, op *fuseops.FlushFileOp) error {
+	return fs.wrapped.FlushFile(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ReleaseFileHandle(ctx context.Context, op *fuseops.ReleaseFileHandleOp) error {
+	return fs.wrapped.ReleaseFileHandle(fs.begin(ctx, op.OpContext, 0), op)
+}
+
+func (fs *attributing) ReadSymlink(ctx context.Context, op *fuseops.ReadSymlinkOp) error {
+	return fs.wrapped.ReadSymlink(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) RemoveXattr(ctx context.Context, op *fuseops.RemoveXattrOp) error {
+	return fs.wrapped.RemoveXattr(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) GetXattr(ctx context.Context, op *fuseops.GetXattrOp) error {
+	return fs.wrapped.GetXattr(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) ListXattr(ctx context.Context, op *fuseops.ListXattrOp) error {
+	return fs.wrapped.ListXattr(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) SetXattr(ctx context.Context, op *fuseops.SetXattrOp) error {
+	return fs.wrapped.SetXattr(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
+
+func (fs *attributing) Fallocate(ctx context.Context, op *fuseops.FallocateOp) error {
+	return fs.wrapped.Fallocate(fs.begin(ctx, op.OpContext, op.Inode), op)
+}
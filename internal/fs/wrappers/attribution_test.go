@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A file system whose reads return 100 bytes, making a GCS request for them.
+type readingFS struct {
+	dummyFS
+}
+
+func (fs readingFS) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	attribution.RecordGCSRequest(ctx, "NewReader")
+	op.BytesRead = 100
+	return nil
+}
+
+func TestAttribution(t *testing.T) {
+	tracker := attribution.NewTracker(1, 10, nil)
+	fs := WithAttribution(readingFS{}, tracker, fakeInodePaths{1: "", 2: "data/shard-0/", 3: "data/shard-0/file"})
+
+	require.NoError(t, fs.ReadFile(context.Background(), &fuseops.ReadFileOp{Inode: 3}))
+	require.NoError(t, fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{Parent: 2, Name: "file"}))
+	require.NoError(t, fs.WriteFile(context.Background(), &fuseops.WriteFileOp{Inode: 3, Data: make([]byte, 10)}))
+	require.NoError(t, fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{Parent: 1, Name: "data"}))
+
+	r := tracker.Top(0, attribution.ByOps)
+	require.Len(t, r.Top, 2)
+	assert.Equal(t, attribution.Key{Caller: attribution.UnknownCaller, PathPrefix: "/data"}, r.Top[0].Key)
+	assert.Equal(t, attribution.Usage{Ops: 3, BytesRead: 100, BytesWritten: 10, GCSClassB: 1}, r.Top[0].Usage)
+	assert.Equal(t, attribution.Key{Caller: attribution.UnknownCaller, PathPrefix: "/"}, r.Top[1].Key)
+	assert.Equal(t, int64(1), r.Top[1].Ops)
+}
+
+func TestAttribution_SetattrCaller(t *testing.T) {
+	tracker := attribution.NewTracker(1, 10, nil)
+	fs := WithAttribution(readingFS{}, tracker, fakeInodePaths{3: "data/file"})
+	opCtx := fuseops.OpContext{Pid: uint32(os.Getpid()), Uid: uint32(os.Getuid())}
+
+	require.NoError(t, fs.SetInodeAttributes(context.Background(), &fuseops.SetInodeAttributesOp{Inode: 3, OpContext: opCtx}))
+
+	r := tracker.Top(0, attribution.ByOps)
+	require.Len(t, r.Top, 1)
+	assert.NotEqual(t, attribution.UnknownCaller, r.Top[0].Key.Caller)
+	assert.Equal(t, "/data", r.Top[0].Key.PathPrefix)
+}
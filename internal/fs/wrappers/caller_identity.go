@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/googleapis/gax-go/v2/callctx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+// The custom headers that carry the caller's identity on GCS requests. Headers
+// with the x-goog-custom-audit- prefix are recorded in Cloud Storage data
+// access logs.
+const (
+	AuditPidHeader = "x-goog-custom-audit-gcsfuse-pid"
+	AuditUidHeader = "x-goog-custom-audit-gcsfuse-uid"
+)
+
+type callerIdentity struct {
+	wrapped      fuseutil.FileSystem
+	passUID      bool
+	auditHeaders bool
+}
+
+// WithCallerIdentity wraps a FileSystem, passing the identity of the process
+// that invoked each op in its context. If passUID is set, the UID is passed
+// for use with per-user credentials. If auditHeaders is set, the PID and UID
+// are sent as custom audit headers on the GCS requests made for the op.
+//
+// StatFS doesn't carry the caller's identity, so it is passed through
+// unchanged and uses the mounter's credentials.
+func WithCallerIdentity(wrapped fuseutil.FileSystem, passUID, auditHeaders bool) fuseutil.FileSystem {
+	return &callerIdentity{wrapped: wrapped, passUID: passUID, auditHeaders: auditHeaders}
+}
+
+func (fs *callerIdentity) withCaller(ctx context.Context, opCtx fuseops.OpContext) context.Context {
+	if fs.passUID {
+		ctx = auth.WithCallerUID(ctx, opCtx.Uid)
+	}
+	// Ops not attributed to a process, e.g. writeback, have no PID.
+	if fs.auditHeaders && opCtx.Pid != 0 {
+		ctx = callctx.SetHeaders(ctx,
+			AuditPidHeader, strconv.FormatUint(uint64(opCtx.Pid), 10),
+			AuditUidHeader, strconv.FormatUint(uint64(opCtx.Uid), 10))
+	}
+	return ctx
+}
+
+func (fs *callerIdentity) Destroy() {
+	fs.wrapped.Destroy()
+}
+
+func (fs *callerIdentity) StatFS(ctx context.Context, op *fuseops.StatFSOp) error {
+	return fs.wrapped.StatFS(ctx, op)
+}
+
+func (fs *callerIdentity) LookUpInode(ctx context.Context, op *fuseops.LookUpInodeOp) error {
+	return fs.wrapped.LookUpInode(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) GetInodeAttributes(ctx context.Context, op *fuseops.GetInodeAttributesOp) error {
+	return fs.wrapped.GetInodeAttributes(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) SetInodeAttributes(ctx context.Context, op *fuseops.SetInodeAttributesOp) error {
+	return fs.wrapped.SetInodeAttributes(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ForgetInode(ctx context.Context, op *fuseops.ForgetInodeOp) error {
+	return fs.wrapped.ForgetInode(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) BatchForget(ctx context.Context, op *fuseops.BatchForgetOp) error {
+	return fs.wrapped.BatchForget(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) MkDir(ctx context.Context, op *fuseops.MkDirOp) error {
+	return fs.wrapped.MkDir(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) MkNode(ctx context.Context, op *fuseops.MkNodeOp) error {
+	return fs.wrapped.MkNode(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) CreateFile(ctx context.Context, op *fuseops.CreateFileOp) error {
+	return fs.wrapped.CreateFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) CreateLink(ctx context.Context, op *fuseops.CreateLinkOp) error {
+	return fs.wrapped.CreateLink(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) CreateSymlink(ctx context.Context, op *fuseops.CreateSymlinkOp) error {
+	return fs.wrapped.CreateSymlink(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) Rename(ctx context.Context, op *fuseops.RenameOp) error {
+	return fs.wrapped.Rename(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) RmDir(ctx context.Context, op *fuseops.RmDirOp) error {
+	return fs.wrapped.RmDir(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) Unlink(ctx context.Context, op *fuseops.UnlinkOp) error {
+	return fs.wrapped.Unlink(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) OpenDir(ctx context.Context, op *fuseops.OpenDirOp) error {
+	return fs.wrapped.OpenDir(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ReadDir(ctx context.Context, op *fuseops.ReadDirOp) error {
+	return fs.wrapped.ReadDir(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ReleaseDirHandle(ctx context.Context, op *fuseops.ReleaseDirHandleOp) error {
+	return fs.wrapped.ReleaseDirHandle(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) OpenFile(ctx context.Context, op *fuseops.OpenFileOp) error {
+	return fs.wrapped.OpenFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	return fs.wrapped.ReadFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) WriteFile(ctx context.Context, op *fuseops.WriteFileOp) error {
+	return fs.wrapped.WriteFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) SyncFile(ctx context.Context, op *fuseops.SyncFileOp) error {
+	return fs.wrapped.SyncFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) FlushFile(ctx context.Context, op *fuseops.FlushFileOp) error {
+	return fs.wrapped.FlushFile(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ReleaseFileHandle(ctx context.Context, op *fuseops.ReleaseFileHandleOp) error {
+	return fs.wrapped.ReleaseFileHandle(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ReadSymlink(ctx context.Context, op *fuseops.ReadSymlinkOp) error {
+	return fs.wrapped.ReadSymlink(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) RemoveXattr(ctx context.Context, op *fuseops.RemoveXattrOp) error {
+	return fs.wrapped.RemoveXattr(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) GetXattr(ctx context.Context, op *fuseops.GetXattrOp) error {
+	return fs.wrapped.GetXattr(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) ListXattr(ctx context.Context, op *fuseops.ListXattrOp) error {
+	return fs.wrapped.ListXattr(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) SetXattr(ctx context.Context, op *fuseops.SetXattrOp) error {
+	return fs.wrapped.SetXattr(fs.withCaller(ctx, op.OpContext), op)
+}
+
+func (fs *callerIdentity) Fallocate(ctx context.Context, op *fuseops.FallocateOp) error {
+	return fs.wrapped.Fallocate(fs.withCaller(ctx, op.OpContext), op)
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/googleapis/gax-go/v2/callctx"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A file system recording the caller UID and headers passed to LookUpInode,
+// SetInodeAttributes and StatFS.
+type callerRecordingFS struct {
+	dummyFS
+	uid     uint32
+	ok      bool
+	headers map[string][]string
+}
+
+func (fs *callerRecordingFS) LookUpInode(ctx context.Context, _ *fuseops.LookUpInodeOp) error {
+	fs.uid, fs.ok = auth.CallerUID(ctx)
+	fs.headers = callctx.HeadersFromContext(ctx)
+	return nil
+}
+
+func (fs *callerRecordingFS) SetInodeAttributes(ctx context.Context, _ *fuseops.SetInodeAttributesOp) error {
+	fs.uid, fs.ok = auth.CallerUID(ctx)
+	return nil
+}
+
+func (fs *callerRecordingFS) StatFS(ctx context.Context, _ *fuseops.StatFSOp) error {
+	fs.uid, fs.ok = auth.CallerUID(ctx)
+	return nil
+}
+
+func TestCallerIdentity_PassesOpUID(t *testing.T) {
+	wrapped := &callerRecordingFS{}
+	fs := WithCallerIdentity(wrapped, true, false)
+
+	err := fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{OpContext: fuseops.OpContext{Uid: 1000}})
+
+	require.NoError(t, err)
+	assert.True(t, wrapped.ok)
+	assert.Equal(t, uint32(1000), wrapped.uid)
+}
+
+func TestCallerIdentity_PassesSetattrUID(t *testing.T) {
+	wrapped := &callerRecordingFS{}
+	fs := WithCallerIdentity(wrapped, true, false)
+
+	err := fs.SetInodeAttributes(context.Background(), &fuseops.SetInodeAttributesOp{OpContext: fuseops.OpContext{Uid: 1000}})
+
+	require.NoError(t, err)
+	assert.True(t, wrapped.ok)
+	assert.Equal(t, uint32(1000), wrapped.uid)
+}
+
+func TestCallerIdentity_OpsWithoutUIDHaveNoCaller(t *testing.T) {
+	wrapped := &callerRecordingFS{}
+	fs := WithCallerIdentity(wrapped, true, false)
+
+	err := fs.StatFS(context.Background(), &fuseops.StatFSOp{})
+
+	require.NoError(t, err)
+	assert.False(t, wrapped.ok)
+}
+
+func TestCallerIdentity_AuditHeaders(t *testing.T) {
+	wrapped := &callerRecordingFS{}
+	fs := WithCallerIdentity(wrapped, false, true)
+
+	err := fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{OpContext: fuseops.OpContext{Pid: 42, Uid: 1000}})
+
+	require.NoError(t, err)
+	assert.False(t, wrapped.ok)
+	assert.Equal(t, []string{"42"}, wrapped.headers[AuditPidHeader])
+	assert.Equal(t, []string{"1000"}, wrapped.headers[AuditUidHeader])
+}
+
+func TestCallerIdentity_NoAuditHeadersWithoutPid(t *testing.T) {
+	wrapped := &callerRecordingFS{}
+	fs := WithCallerIdentity(wrapped, true, true)
+
+	err := fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{OpContext: fuseops.OpContext{Uid: 1000}})
+
+	require.NoError(t, err)
+	assert.True(t, wrapped.ok)
+	assert.Empty(t, wrapped.headers)
+}
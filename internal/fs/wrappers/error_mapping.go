@@ -23,6 +23,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/jacobsa/fuse/fuseops"
@@ -40,6 +41,15 @@ func errno(err error, preconditionErrCfg bool) error {
 		return nil
 	}
 
+	// Cannot authenticate because the credential process failed or the caller's
+	// credentials are unavailable. This is checked first since the underlying
+	// error may itself carry an errno, e.g. ENOENT for a missing key file.
+	var credentialProcessErr *auth.CredentialProcessError
+	var userCredentialsErr *auth.UserCredentialsError
+	if errors.As(err, &credentialProcessErr) || errors.As(err, &userCredentialsErr) {
+		return syscall.EACCES
+	}
+
 	// Use existing em errno
 	var errno syscall.Errno
 	if errors.As(err, &errno) {
@@ -104,6 +114,12 @@ func errno(err error, preconditionErrCfg bool) error {
 	return DefaultFSError
 }
 
+// Errno maps err to the syscall.Errno that WithErrorMapping returns for it, for
+// callers of the file system that don't go through FUSE.
+func Errno(err error, preconditionErrCfg bool) error {
+	return errno(err, preconditionErrCfg)
+}
+
 // WithErrorMapping wraps a FileSystem, processing the returned errors, and
 // mapping them into syscall.Errno that can be understood by FUSE.
 func WithErrorMapping(wrapped fuseutil.FileSystem, preconditionErrCfg bool) fuseutil.FileSystem {
@@ -129,7 +145,7 @@ func (em *errorMapping) handlePanic() {
 func (em *errorMapping) mapError(op string, err error) error {
 	fsErr := errno(err, em.preconditionErrCfg)
 	if err != nil && fsErr != nil && err != fsErr {
-		logger.Errorf("%s: %v, %v", op, fsErr, err)
+		logger.FUSE.Errorf("%s: %v, %v", op, fsErr, err)
 	}
 	return fsErr
 }
@@ -17,10 +17,13 @@ package wrappers
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
 	"syscall"
 	"testing"
 
 	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -92,6 +95,28 @@ func (testSuite *ErrorMapping) TestUnAuthenticatedHttpGoogleApiError() {
 	assert.Equal(testSuite.T(), syscall.EACCES, fsErr)
 }
 
+func (testSuite *ErrorMapping) TestCredentialProcessError() {
+	err := &url.Error{Op: "Get", URL: "https://storage.googleapis.com", Err: &auth.CredentialProcessError{
+		Command: "get-token",
+		Err:     &os.PathError{Op: "fork/exec", Path: "/bin/sh", Err: syscall.ENOENT},
+	}}
+
+	fsErr := errno(err, testSuite.preconditionErrCfg)
+
+	assert.Equal(testSuite.T(), syscall.EACCES, fsErr)
+}
+
+func (testSuite *ErrorMapping) TestUserCredentialsError() {
+	err := fmt.Errorf("StatObject: %w", &auth.UserCredentialsError{
+		UID: 1000,
+		Err: &os.PathError{Op: "open", Path: "/keys/1000.json", Err: syscall.ENOENT},
+	})
+
+	fsErr := errno(err, testSuite.preconditionErrCfg)
+
+	assert.Equal(testSuite.T(), syscall.EACCES, fsErr)
+}
+
 func (testSuite *ErrorMapping) TestFileClobberedErrorWithPreconditionErrCfg() {
 	clobberedErr := &gcsfuse_errors.FileClobberedError{
 		Err: fmt.Errorf("some error"),
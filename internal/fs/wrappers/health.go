@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/health"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+type healthRecording struct {
+	wrapped fuseutil.FileSystem
+	monitor *health.Monitor
+}
+
+// WithHealth wraps a FileSystem whose errors have been mapped to errnos,
+// reporting the latency of each op to the monitor and whether it failed with
+// an error that signals a problem with the mount, i.e. an I/O, network or
+// device error, rather than with the op, e.g. a missing file.
+func WithHealth(wrapped fuseutil.FileSystem, monitor *health.Monitor) fuseutil.FileSystem {
+	return &healthRecording{wrapped: wrapped, monitor: monitor}
+}
+
+func (fs *healthRecording) Destroy() {
+	fs.wrapped.Destroy()
+}
+
+func (fs *healthRecording) invokeWrapped(ctx context.Context, w wrappedCall) error {
+	start := time.Now()
+	err := w(ctx)
+	switch categorize(err) {
+	case errIO, errNetwork, errDevice:
+		fs.monitor.RecordOp(time.Since(start), true)
+	default:
+		fs.monitor.RecordOp(time.Since(start), false)
+	}
+	return err
+}
+
+func (fs *healthRecording) StatFS(ctx context.Context, op *fuseops.StatFSOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.StatFS(ctx, op) })
+}
+
+func (fs *healthRecording) LookUpInode(ctx context.Context, op *fuseops.LookUpInodeOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.LookUpInode(ctx, op) })
+}
+
+func (fs *healthRecording) GetInodeAttributes(ctx context.Context, op *fuseops.GetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.GetInodeAttributes(ctx, op) })
+}
+
+func (fs *healthRecording) SetInodeAttributes(ctx context.Context, op *fuseops.SetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.SetInodeAttributes(ctx, op) })
+}
+
+func (fs *healthRecording) ForgetInode(ctx context.Context, op *fuseops.ForgetInodeOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ForgetInode(ctx, op) })
+}
+
+func (fs *healthRecording) BatchForget(ctx context.Context, op *fuseops.BatchForgetOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.BatchForget(ctx, op) })
+}
+
+func (fs *healthRecording) MkDir(ctx context.Context, op *fuseops.MkDirOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.MkDir(ctx, op) })
+}
+
+func (fs *healthRecording) MkNode(ctx context.Context, op *fuseops.MkNodeOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.MkNode(ctx, op) })
+}
+
+func (fs *healthRecording) CreateFile(ctx context.Context, op *fuseops.CreateFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.CreateFile(ctx, op) })
+}
+
+func (fs *healthRecording) CreateLink(ctx context.Context, op *fuseops.CreateLinkOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.CreateLink(ctx, op) })
+}
+
+func (fs *healthRecording) CreateSymlink(ctx context.Context, op *fuseops.CreateSymlinkOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.CreateSymlink(ctx, op) })
+}
+
+func (fs *healthRecording) Rename(ctx context.Context, op *fuseops.RenameOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.Rename(ctx, op) })
+}
+
+func (fs *healthRecording) RmDir(ctx context.Context, op *fuseops.RmDirOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.RmDir(ctx, op) })
+}
+
+func (fs *healthRecording) Unlink(ctx context.Context, op *fuseops.UnlinkOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.Unlink(ctx, op) })
+}
+
+func (fs *healthRecording) OpenDir(ctx context.Context, op *fuseops.OpenDirOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.OpenDir(ctx, op) })
+}
+
+func (fs *healthRecording) ReadDir(ctx context.Context, op *fuseops.ReadDirOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ReadDir(ctx, op) })
+}
+
+func (fs *healthRecording) ReleaseDirHandle(ctx context.Context, op *fuseops.ReleaseDirHandleOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ReleaseDirHandle(ctx, op) })
+}
+
+func (fs *healthRecording) OpenFile(ctx context.Context, op *fuseops.OpenFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.OpenFile(ctx, op) })
+}
+
+func (fs *healthRecording) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ReadFile(ctx, op) })
+}
+
+func (fs *healthRecording) WriteFile(ctx context.Context, op *fuseops.WriteFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.WriteFile(ctx, op) })
+}
+
+func (fs *healthRecording) SyncFile(ctx context.Context, op *fuseops.SyncFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.SyncFile(ctx, op) })
+}
+
+func (fs *healthRecording) FlushFile(ctx context.Context, op *fuseops.FlushFileOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.FlushFile(ctx, op) })
+}
+
+func (fs *healthRecording) ReleaseFileHandle(ctx context.Context, op *fuseops.ReleaseFileHandleOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ReleaseFileHandle(ctx, op) })
+}
+
+func (fs *healthRecording) ReadSymlink(ctx context.Context, op *fuseops.ReadSymlinkOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ReadSymlink(ctx, op) })
+}
+
+func (fs *healthRecording) RemoveXattr(ctx context.Context, op *fuseops.RemoveXattrOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.RemoveXattr(ctx, op) })
+}
+
+func (fs *healthRecording) GetXattr(ctx context.Context, op *fuseops.GetXattrOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.GetXattr(ctx, op) })
+}
+
+func (fs *healthRecording) ListXattr(ctx context.Context, op *fuseops.ListXattrOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.ListXattr(ctx, op) })
+}
+
+func (fs *healthRecording) SetXattr(ctx context.Context, op *fuseops.SetXattrOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.SetXattr(ctx, op) })
+}
+
+func (fs *healthRecording) Fallocate(ctx context.Context, op *fuseops.FallocateOp) error {
+	return fs.invokeWrapped(ctx, func(ctx context.Context) error { return fs.wrapped.Fallocate(ctx, op) })
+}
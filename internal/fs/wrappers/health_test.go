@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/health"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A file system whose reads fail with EIO and lookups with ENOENT.
+type failingFS struct {
+	dummyFS
+}
+
+func (failingFS) ReadFile(context.Context, *fuseops.ReadFileOp) error {
+	return syscall.EIO
+}
+
+func (failingFS) LookUpInode(context.Context, *fuseops.LookUpInodeOp) error {
+	return syscall.ENOENT
+}
+
+func TestHealth_CountsMountErrorsOnly(t *testing.T) {
+	monitor := health.NewMonitor(health.Config{StallThreshold: time.Minute, MaxErrorRate: 0.1, MaxStallRate: 0.1})
+	fs := WithHealth(failingFS{}, monitor)
+
+	assert.Equal(t, syscall.EIO, fs.ReadFile(context.Background(), &fuseops.ReadFileOp{}))
+	assert.Equal(t, syscall.ENOENT, fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{}))
+	assert.NoError(t, fs.StatFS(context.Background(), &fuseops.StatFSOp{}))
+
+	checks := monitor.Readiness(context.Background()).Checks
+	require.Len(t, checks, 3)
+	assert.Equal(t, "error-rate", checks[1].Name)
+	assert.Contains(t, checks[1].Detail, "1 of 3 ops")
+	assert.Contains(t, checks[2].Detail, "0 of 3 ops")
+}
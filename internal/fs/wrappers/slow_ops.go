@@ -0,0 +1,372 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/inflight"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/locker"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+// InodePaths resolves inode IDs to paths relative to the root of the mount.
+// Implementations must not block on the locks of the file system, which a
+// stalled op may hold.
+type InodePaths interface {
+	InodePath(id fuseops.InodeID) (string, bool)
+}
+
+// An op in progress, with the inode and child name it's for.
+type trackedOp struct {
+	op       *inflight.Op
+	inode    fuseops.InodeID
+	name     string
+	reported bool
+}
+
+type slowOps struct {
+	wrapped      fuseutil.FileSystem
+	threshold    time.Duration
+	dumpInterval time.Duration
+	paths        InodePaths
+	stop         chan struct{}
+
+	mu sync.Mutex
+	// GUARDED_BY(mu)
+	ops map[*trackedOp]struct{}
+	// The time of the last dump, and the number of ops that have become slow
+	// and finished since without being reported.
+	//
+	// GUARDED_BY(mu)
+	lastDump   time.Time
+	suppressed int
+}
+
+// WithSlowOpDetection wraps a FileSystem with a watchdog that logs a
+// diagnostic dump when ops take longer than threshold. The dump has a record
+// for every such op with its path, elapsed time and the GCS requests in
+// progress for it, followed by the locks held at the time, as reported by
+// locker.Holders, and the stacks of the goroutines running gcsfuse code, which
+// include those holding the locks.
+//
+// Dumps are logged at most once per dumpInterval and report every slow op
+// once. The number of slow ops that finished between two dumps is reported by
+// the second one. paths may be nil, in which case the ops have no paths.
+func WithSlowOpDetection(wrapped fuseutil.FileSystem, threshold, dumpInterval time.Duration, paths InodePaths) fuseutil.FileSystem {
+	fs := &slowOps{
+		wrapped:      wrapped,
+		threshold:    threshold,
+		dumpInterval: dumpInterval,
+		paths:        paths,
+		stop:         make(chan struct{}),
+		ops:          make(map[*trackedOp]struct{}),
+	}
+	go fs.watch()
+	return fs
+}
+
+// watch checks for slow ops until the file system is destroyed, often enough
+// to report them at most half the threshold late.
+func (fs *slowOps) watch() {
+	period := min(max(fs.threshold/2, 10*time.Millisecond), time.Second)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fs.stop:
+			return
+		case now := <-ticker.C:
+			if d := fs.check(now); d != nil {
+				d.log()
+			}
+		}
+	}
+}
+
+// A record of a slow op in a dump.
+type slowOpRecord struct {
+	Op       string
+	Path     string
+	Elapsed  time.Duration
+	Requests []inflight.Request
+}
+
+type slowOpDump struct {
+	Time       time.Time
+	Ops        []slowOpRecord
+	Suppressed int
+	Locks      []locker.Holder
+	Stacks     []string
+}
+
+// check returns a dump of the ops that have become slow by now and weren't
+// reported yet, or nil if there are none or the last dump was too recent.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *slowOps) check(now time.Time) *slowOpDump {
+	d := fs.collectSlowOps(now)
+	if d == nil {
+		return nil
+	}
+
+	// Collecting the stacks stops the world, so it must not hold up the ops
+	// waiting for fs.mu.
+	d.Locks = locker.Holders()
+	d.Stacks = goroutineStacks()
+	return d
+}
+
+// The prefix of the functions of gcsfuse in stack traces.
+const gcsfuseFuncPrefix = "github.com/googlecloudplatform/gcsfuse/"
+
+// goroutineStacks returns the stacks of the goroutines running gcsfuse code,
+// other than the calling one.
+func goroutineStacks() []string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	// The calling goroutine comes first.
+	var stacks []string
+	for i, s := range strings.Split(string(buf), "\n\n") {
+		if i > 0 && strings.Contains(s, gcsfuseFuncPrefix) {
+			stacks = append(stacks, s)
+		}
+	}
+	return stacks
+}
+
+// collectSlowOps returns a dump without lock holders of the ops that have
+// become slow by now and weren't reported yet, as for check.
+//
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *slowOps) collectSlowOps(now time.Time) *slowOpDump {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if !fs.lastDump.IsZero() && now.Sub(fs.lastDump) < fs.dumpInterval {
+		return nil
+	}
+
+	d := &slowOpDump{Time: now}
+	for t := range fs.ops {
+		elapsed := now.Sub(t.op.Start)
+		if t.reported || elapsed < fs.threshold {
+			continue
+		}
+		t.reported = true
+		d.Ops = append(d.Ops, slowOpRecord{
+			Op:       t.op.Name,
+			Path:     fs.path(t),
+			Elapsed:  elapsed,
+			Requests: t.op.Requests(),
+		})
+	}
+	if len(d.Ops) == 0 {
+		return nil
+	}
+
+	d.Suppressed = fs.suppressed
+	fs.suppressed = 0
+	fs.lastDump = now
+	return d
+}
+
+func (fs *slowOps) path(t *trackedOp) string {
+	if fs.paths == nil || t.inode == 0 {
+		return ""
+	}
+	p, ok := fs.paths.InodePath(t.inode)
+	if !ok {
+		return ""
+	}
+	return "/" + path.Join(p, t.name)
+}
+
+func (d *slowOpDump) log() {
+	logger.Warn("Slow file system ops detected", "count", len(d.Ops), "suppressed", d.Suppressed, "locksHeld", len(d.Locks))
+	for _, r := range d.Ops {
+		requests := make([]string, 0, len(r.Requests))
+		for _, req := range r.Requests {
+			requests = append(requests, req.Desc+" for "+d.Time.Sub(req.Start).String())
+		}
+		logger.Warn("Slow file system op", "op", r.Op, "path", r.Path, "elapsed", r.Elapsed.String(), "gcsRequests", requests)
+	}
+	for _, h := range d.Locks {
+		heldFor := "unknown"
+		if !h.Since.IsZero() {
+			heldFor = d.Time.Sub(h.Since).String()
+		}
+		logger.Warn("Lock held during slow file system ops", "lock", h.Name, "heldFor", heldFor, "readers", h.Readers)
+	}
+	for _, stack := range d.Stacks {
+		logger.Warn("Goroutine during slow file system ops", "stack", stack)
+	}
+}
+
+func (fs *slowOps) Destroy() {
+	close(fs.stop)
+	fs.wrapped.Destroy()
+}
+
+// LOCKS_EXCLUDED(fs.mu)
+func (fs *slowOps) invokeWrapped(ctx context.Context, opName string, inode fuseops.InodeID, name string, w wrappedCall) error {
+	t := &trackedOp{op: inflight.NewOp(opName), inode: inode, name: name}
+	fs.mu.Lock()
+	fs.ops[t] = struct{}{}
+	fs.mu.Unlock()
+
+	defer func() {
+		fs.mu.Lock()
+		delete(fs.ops, t)
+		if !t.reported && time.Since(t.op.Start) >= fs.threshold {
+			fs.suppressed++
+		}
+		fs.mu.Unlock()
+	}()
+
+	return w(inflight.WithOp(ctx, t.op))
+}
+
+func (fs *slowOps) StatFS(ctx context.Context, op *fuseops.StatFSOp) error {
+	return fs.invokeWrapped(ctx, "StatFS", 0, "", func(ctx context.Context) error { return fs.wrapped.StatFS(ctx, op) })
+}
+
+func (fs *slowOps) LookUpInode(ctx context.Context, op *fuseops.LookUpInodeOp) error {
+	return fs.invokeWrapped(ctx, "LookUpInode", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.LookUpInode(ctx, op) })
+}
+
+func (fs *slowOps) GetInodeAttributes(ctx context.Context, op *fuseops.GetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, "GetInodeAttributes", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.GetInodeAttributes(ctx, op) })
+}
+
+func (fs *slowOps) SetInodeAttributes(ctx context.Context, op *fuseops.SetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, "SetInodeAttributes", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SetInodeAttributes(ctx, op) })
+}
+
+func (fs *slowOps) ForgetInode(ctx context.Context, op *fuseops.ForgetInodeOp) error {
+	return fs.invokeWrapped(ctx, "ForgetInode", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ForgetInode(ctx, op) })
+}
+
+func (fs *slowOps) BatchForget(ctx context.Context, op *fuseops.BatchForgetOp) error {
+	return fs.invokeWrapped(ctx, "BatchForget", 0, "", func(ctx context.Context) error { return fs.wrapped.BatchForget(ctx, op) })
+}
+
+func (fs *slowOps) MkDir(ctx context.Context, op *fuseops.MkDirOp) error {
+	return fs.invokeWrapped(ctx, "MkDir", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.MkDir(ctx, op) })
+}
+
+func (fs *slowOps) MkNode(ctx context.Context, op *fuseops.MkNodeOp) error {
+	return fs.invokeWrapped(ctx, "MkNode", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.MkNode(ctx, op) })
+}
+
+func (fs *slowOps) CreateFile(ctx context.Context, op *fuseops.CreateFileOp) error {
+	return fs.invokeWrapped(ctx, "CreateFile", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateFile(ctx, op) })
+}
+
+func (fs *slowOps) CreateLink(ctx context.Context, op *fuseops.CreateLinkOp) error {
+	return fs.invokeWrapped(ctx, "CreateLink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateLink(ctx, op) })
+}
+
+func (fs *slowOps) CreateSymlink(ctx context.Context, op *fuseops.CreateSymlinkOp) error {
+	return fs.invokeWrapped(ctx, "CreateSymlink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateSymlink(ctx, op) })
+}
+
+func (fs *slowOps) Rename(ctx context.Context, op *fuseops.RenameOp) error {
+	return fs.invokeWrapped(ctx, "Rename", op.OldParent, op.OldName, func(ctx context.Context) error { return fs.wrapped.Rename(ctx, op) })
+}
+
+func (fs *slowOps) RmDir(ctx context.Context, op *fuseops.RmDirOp) error {
+	return fs.invokeWrapped(ctx, "RmDir", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.RmDir(ctx, op) })
+}
+
+func (fs *slowOps) Unlink(ctx context.Context, op *fuseops.UnlinkOp) error {
+	return fs.invokeWrapped(ctx, "Unlink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.Unlink(ctx, op) })
+}
+
+func (fs *slowOps) OpenDir(ctx context.Context, op *fuseops.OpenDirOp) error {
+	return fs.invokeWrapped(ctx, "OpenDir", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.OpenDir(ctx, op) })
+}
+
+func (fs *slowOps) ReadDir(ctx context.Context, op *fuseops.ReadDirOp) error {
+	return fs.invokeWrapped(ctx, "ReadDir", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadDir(ctx, op) })
+}
+
+func (fs *slowOps) ReleaseDirHandle(ctx context.Context, op *fuseops.ReleaseDirHandleOp) error {
+	return fs.invokeWrapped(ctx, "ReleaseDirHandle", 0, "", func(ctx context.Context) error { return fs.wrapped.ReleaseDirHandle(ctx, op) })
+}
+
+func (fs *slowOps) OpenFile(ctx context.Context, op *fuseops.OpenFileOp) error {
+	return fs.invokeWrapped(ctx, "OpenFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.OpenFile(ctx, op) })
+}
+
+func (fs *slowOps) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	return fs.invokeWrapped(ctx, "ReadFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadFile(ctx, op) })
+}
+
+func (fs *slowOps) WriteFile(ctx context.Context, op *fuseops.WriteFileOp) error {
+	return fs.invokeWrapped(ctx, "WriteFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.WriteFile(ctx, op) })
+}
+
+func (fs *slowOps) SyncFile(ctx context.Context, op *fuseops.SyncFileOp) error {
+	return fs.invokeWrapped(ctx, "SyncFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SyncFile(ctx, op) })
+}
+
+func (fs *slowOps) FlushFile(ctx context.Context, op *fuseops.FlushFileOp) error {
+	return fs.invokeWrapped(ctx, "FlushFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.FlushFile(ctx, op) })
+}
+
+func (fs *slowOps) ReleaseFileHandle(ctx context.Context, op *fuseops.ReleaseFileHandleOp) error {
+	return fs.invokeWrapped(ctx, "ReleaseFileHandle", 0, "", func(ctx context.Context) error { return fs.wrapped.ReleaseFileHandle(ctx, op) })
+}
+
+func (fs *slowOps) ReadSymlink(ctx context.Context, op *fuseops.ReadSymlinkOp) error {
+	return fs.invokeWrapped(ctx, "ReadSymlink", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadSymlink(ctx, op) })
+}
+
+func (fs *slowOps) RemoveXattr(ctx context.Context, op *fuseops.RemoveXattrOp) error {
+	return fs.invokeWrapped(ctx, "RemoveXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.RemoveXattr(ctx, op) })
+}
+
+func (fs *slowOps) GetXattr(ctx context.Context, op *fuseops.GetXattrOp) error {
+	return fs.invokeWrapped(ctx, "GetXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.GetXattr(ctx, op) })
+}
+
+func (fs *slowOps) ListXattr(ctx context.Context, op *fuseops.ListXattrOp) error {
+	return fs.invokeWrapped(ctx, "ListXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ListXattr(ctx, op) })
+}
+
+func (fs *slowOps) SetXattr(ctx context.Context, op *fuseops.SetXattrOp) error {
+	return fs.invokeWrapped(ctx, "SetXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SetXattr(ctx, op) })
+}
+
+func (fs *slowOps) Fallocate(ctx context.Context, op *fuseops.FallocateOp) error {
+	return fs.invokeWrapped(ctx, "Fallocate", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.Fallocate(ctx, op) })
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/inflight"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingFS blocks ReadFile, with a GCS request in progress, until released.
+type blockingFS struct {
+	fuseutil.NotImplementedFileSystem
+	started chan struct{}
+	release chan struct{}
+}
+
+func (fs *blockingFS) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	inflight.BeginRequest(ctx, 7, `Read("dir/foo", [0, 10))`)
+	defer inflight.EndRequest(ctx, 7)
+	fs.started <- struct{}{}
+	<-fs.release
+	return nil
+}
+
+type fakeInodePaths map[fuseops.InodeID]string
+
+func (p fakeInodePaths) InodePath(id fuseops.InodeID) (string, bool) {
+	path, ok := p[id]
+	return path, ok
+}
+
+func newSlowOps(t *testing.T, dumpInterval time.Duration) (*slowOps, *blockingFS) {
+	t.Helper()
+	wrapped := &blockingFS{started: make(chan struct{}), release: make(chan struct{})}
+	// A threshold high enough for the watchdog not to interfere with the test,
+	// which calls check itself.
+	fs := WithSlowOpDetection(wrapped, time.Hour, dumpInterval, fakeInodePaths{2: "dir/foo"}).(*slowOps)
+	t.Cleanup(fs.Destroy)
+	return fs, wrapped
+}
+
+func startRead(fs *slowOps, wrapped *blockingFS) chan error {
+	done := make(chan error, 1)
+	go func() { done <- fs.ReadFile(context.Background(), &fuseops.ReadFileOp{Inode: 2}) }()
+	<-wrapped.started
+	return done
+}
+
+func TestSlowOps_DumpsSlowOpOnce(t *testing.T) {
+	fs, wrapped := newSlowOps(t, 0)
+	done := startRead(fs, wrapped)
+	now := time.Now()
+
+	assert.Nil(t, fs.check(now))
+	d := fs.check(now.Add(2 * time.Hour))
+	require.NotNil(t, d)
+	require.Len(t, d.Ops, 1)
+	assert.Equal(t, "ReadFile", d.Ops[0].Op)
+	assert.Equal(t, "/dir/foo", d.Ops[0].Path)
+	assert.GreaterOrEqual(t, d.Ops[0].Elapsed, 2*time.Hour)
+	require.Len(t, d.Ops[0].Requests, 1)
+	assert.Equal(t, `Read("dir/foo", [0, 10))`, d.Ops[0].Requests[0].Desc)
+	assert.True(t, slices.ContainsFunc(d.Stacks, func(s string) bool { return strings.Contains(s, "(*blockingFS).ReadFile") }), d.Stacks)
+	assert.Nil(t, fs.check(now.Add(3*time.Hour)))
+
+	wrapped.release <- struct{}{}
+	assert.NoError(t, <-done)
+}
+
+func TestSlowOps_RateLimitsDumps(t *testing.T) {
+	fs, wrapped := newSlowOps(t, 10*time.Hour)
+	done := startRead(fs, wrapped)
+	now := time.Now()
+	require.NotNil(t, fs.check(now.Add(2*time.Hour)))
+	done2 := startRead(fs, wrapped)
+
+	// The second op is slow, but the last dump is too recent.
+	assert.Nil(t, fs.check(now.Add(4*time.Hour)))
+	d := fs.check(now.Add(13 * time.Hour))
+
+	require.NotNil(t, d)
+	require.Len(t, d.Ops, 1)
+	assert.Equal(t, 0, d.Suppressed)
+	wrapped.release <- struct{}{}
+	wrapped.release <- struct{}{}
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done2)
+}
+
+func TestSlowOps_CountsSuppressedOps(t *testing.T) {
+	wrapped := &blockingFS{started: make(chan struct{}), release: make(chan struct{})}
+	fs := WithSlowOpDetection(wrapped, time.Nanosecond, time.Hour, nil).(*slowOps)
+	t.Cleanup(fs.Destroy)
+	fs.mu.Lock()
+	fs.lastDump = time.Now()
+	fs.mu.Unlock()
+	done := startRead(fs, wrapped)
+	wrapped.release <- struct{}{}
+	require.NoError(t, <-done)
+	done = startRead(fs, wrapped)
+
+	d := fs.check(time.Now().Add(2 * time.Hour))
+
+	require.NotNil(t, d)
+	require.Len(t, d.Ops, 1)
+	assert.Equal(t, 1, d.Suppressed)
+	assert.Equal(t, "", d.Ops[0].Path)
+	wrapped.release <- struct{}{}
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/jacobsa/fuse/fuseutil"
+)
+
+type timelineRecording struct {
+	wrapped fuseutil.FileSystem
+}
+
+// WithTimeline wraps a FileSystem and records a span on the timeline.FUSE
+// track for every op, with the inode and child name it's for. The spans are
+// only kept while the timeline is enabled.
+func WithTimeline(wrapped fuseutil.FileSystem) fuseutil.FileSystem {
+	return &timelineRecording{wrapped: wrapped}
+}
+
+func (fs *timelineRecording) Destroy() {
+	fs.wrapped.Destroy()
+}
+
+func (fs *timelineRecording) invokeWrapped(ctx context.Context, opName string, inode fuseops.InodeID, name string, w wrappedCall) (err error) {
+	var span *timeline.Span
+	switch {
+	case name != "":
+		span = timeline.Begin(timeline.FUSE, opName, "inode", inode, "name", name)
+	case inode != 0:
+		span = timeline.Begin(timeline.FUSE, opName, "inode", inode)
+	default:
+		span = timeline.Begin(timeline.FUSE, opName)
+	}
+	defer func() { span.End(err) }()
+
+	return w(ctx)
+}
+
+func (fs *timelineRecording) StatFS(ctx context.Context, op *fuseops.StatFSOp) error {
+	return fs.invokeWrapped(ctx, "StatFS", 0, "", func(ctx context.Context) error { return fs.wrapped.StatFS(ctx, op) })
+}
+
+func (fs *timelineRecording) LookUpInode(ctx context.Context, op *fuseops.LookUpInodeOp) error {
+	return fs.invokeWrapped(ctx, "LookUpInode", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.LookUpInode(ctx, op) })
+}
+
+func (fs *timelineRecording) GetInodeAttributes(ctx context.Context, op *fuseops.GetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, "GetInodeAttributes", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.GetInodeAttributes(ctx, op) })
+}
+
+func (fs *timelineRecording) SetInodeAttributes(ctx context.Context, op *fuseops.SetInodeAttributesOp) error {
+	return fs.invokeWrapped(ctx, "SetInodeAttributes", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SetInodeAttributes(ctx, op) })
+}
+
+func (fs *timelineRecording) ForgetInode(ctx context.Context, op *fuseops.ForgetInodeOp) error {
+	return fs.invokeWrapped(ctx, "ForgetInode", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ForgetInode(ctx, op) })
+}
+
+func (fs *timelineRecording) BatchForget(ctx context.Context, op *fuseops.BatchForgetOp) error {
+	return fs.invokeWrapped(ctx, "BatchForget", 0, "", func(ctx context.Context) error { return fs.wrapped.BatchForget(ctx, op) })
+}
+
+func (fs *timelineRecording) MkDir(ctx context.Context, op *fuseops.MkDirOp) error {
+	return fs.invokeWrapped(ctx, "MkDir", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.MkDir(ctx, op) })
+}
+
+func (fs *timelineRecording) MkNode(ctx context.Context, op *fuseops.MkNodeOp) error {
+	return fs.invokeWrapped(ctx, "MkNode", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.MkNode(ctx, op) })
+}
+
+func (fs *timelineRecording) CreateFile(ctx context.Context, op *fuseops.CreateFileOp) error {
+	return fs.invokeWrapped(ctx, "CreateFile", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateFile(ctx, op) })
+}
+
+func (fs *timelineRecording) CreateLink(ctx context.Context, op *fuseops.CreateLinkOp) error {
+	return fs.invokeWrapped(ctx, "CreateLink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateLink(ctx, op) })
+}
+
+func (fs *timelineRecording) CreateSymlink(ctx context.Context, op *fuseops.CreateSymlinkOp) error {
+	return fs.invokeWrapped(ctx, "CreateSymlink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.CreateSymlink(ctx, op) })
+}
+
+func (fs *timelineRecording) Rename(ctx context.Context, op *fuseops.RenameOp) error {
+	return fs.invokeWrapped(ctx, "Rename", op.OldParent, op.OldName, func(ctx context.Context) error { return fs.wrapped.Rename(ctx, op) })
+}
+
+func (fs *timelineRecording) RmDir(ctx context.Context, op *fuseops.RmDirOp) error {
+	return fs.invokeWrapped(ctx, "RmDir", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.RmDir(ctx, op) })
+}
+
+func (fs *timelineRecording) Unlink(ctx context.Context, op *fuseops.UnlinkOp) error {
+	return fs.invokeWrapped(ctx, "Unlink", op.Parent, op.Name, func(ctx context.Context) error { return fs.wrapped.Unlink(ctx, op) })
+}
+
+func (fs *timelineRecording) OpenDir(ctx context.Context, op *fuseops.OpenDirOp) error {
+	return fs.invokeWrapped(ctx, "OpenDir", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.OpenDir(ctx, op) })
+}
+
+func (fs *timelineRecording) ReadDir(ctx context.Context, op *fuseops.ReadDirOp) error {
+	return fs.invokeWrapped(ctx, "ReadDir", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadDir(ctx, op) })
+}
+
+func (fs *timelineRecording) ReleaseDirHandle(ctx context.Context, op *fuseops.ReleaseDirHandleOp) error {
+	return fs.invokeWrapped(ctx, "ReleaseDirHandle", 0, "", func(ctx context.Context) error { return fs.wrapped.ReleaseDirHandle(ctx, op) })
+}
+
+func (fs *timelineRecording) OpenFile(ctx context.Context, op *fuseops.OpenFileOp) error {
+	return fs.invokeWrapped(ctx, "OpenFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.OpenFile(ctx, op) })
+}
+
+func (fs *timelineRecording) ReadFile(ctx context.Context, op *fuseops.ReadFileOp) error {
+	return fs.invokeWrapped(ctx, "ReadFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadFile(ctx, op) })
+}
+
+func (fs *timelineRecording) WriteFile(ctx context.Context, op *fuseops.WriteFileOp) error {
+	return fs.invokeWrapped(ctx, "WriteFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.WriteFile(ctx, op) })
+}
+
+func (fs *timelineRecording) SyncFile(ctx context.Context, op *fuseops.SyncFileOp) error {
+	return fs.invokeWrapped(ctx, "SyncFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SyncFile(ctx, op) })
+}
+
+func (fs *timelineRecording) FlushFile(ctx context.Context, op *fuseops.FlushFileOp) error {
+	return fs.invokeWrapped(ctx, "FlushFile", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.FlushFile(ctx, op) })
+}
+
+func (fs *timelineRecording) ReleaseFileHandle(ctx context.Context, op *fuseops.ReleaseFileHandleOp) error {
+	return fs.invokeWrapped(ctx, "ReleaseFileHandle", 0, "", func(ctx context.Context) error { return fs.wrapped.ReleaseFileHandle(ctx, op) })
+}
+
+func (fs *timelineRecording) ReadSymlink(ctx context.Context, op *fuseops.ReadSymlinkOp) error {
+	return fs.invokeWrapped(ctx, "ReadSymlink", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ReadSymlink(ctx, op) })
+}
+
+func (fs *timelineRecording) RemoveXattr(ctx context.Context, op *fuseops.RemoveXattrOp) error {
+	return fs.invokeWrapped(ctx, "RemoveXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.RemoveXattr(ctx, op) })
+}
+
+func (fs *timelineRecording) GetXattr(ctx context.Context, op *fuseops.GetXattrOp) error {
+	return fs.invokeWrapped(ctx, "GetXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.GetXattr(ctx, op) })
+}
+
+func (fs *timelineRecording) ListXattr(ctx context.Context, op *fuseops.ListXattrOp) error {
+	return fs.invokeWrapped(ctx, "ListXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.ListXattr(ctx, op) })
+}
+
+func (fs *timelineRecording) SetXattr(ctx context.Context, op *fuseops.SetXattrOp) error {
+	return fs.invokeWrapped(ctx, "SetXattr", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.SetXattr(ctx, op) })
+}
+
+func (fs *timelineRecording) Fallocate(ctx context.Context, op *fuseops.FallocateOp) error {
+	return fs.invokeWrapped(ctx, "Fallocate", op.Inode, "", func(ctx context.Context) error { return fs.wrapped.Fallocate(ctx, op) })
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
+	"github.com/jacobsa/fuse/fuseops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline_RecordsOps(t *testing.T) {
+	timeline.Enable(10)
+	t.Cleanup(timeline.Disable)
+	fs := WithTimeline(failingFS{})
+
+	_ = fs.LookUpInode(context.Background(), &fuseops.LookUpInodeOp{Parent: 1, Name: "a"})
+	_ = fs.ReadFile(context.Background(), &fuseops.ReadFileOp{Inode: 2})
+	_ = fs.StatFS(context.Background(), &fuseops.StatFSOp{})
+
+	var buf bytes.Buffer
+	require.NoError(t, timeline.WriteTrace(&buf))
+	var trace struct {
+		TraceEvents []struct {
+			Name  string
+			Phase string `json:"ph"`
+			PID   int
+			Args  map[string]any
+		}
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+	var ops []string
+	for _, e := range trace.TraceEvents {
+		if e.Phase != "X" {
+			continue
+		}
+		assert.Equal(t, int(timeline.FUSE), e.PID)
+		ops = append(ops, e.Name)
+		switch e.Name {
+		case "LookUpInode":
+			assert.Equal(t, map[string]any{"inode": float64(1), "name": "a", "error": "no such file or directory"}, e.Args)
+		case "ReadFile":
+			assert.Equal(t, map[string]any{"inode": float64(2), "error": "input/output error"}, e.Args)
+		case "StatFS":
+			assert.Nil(t, e.Args)
+		}
+	}
+	assert.Equal(t, []string{"LookUpInode", "ReadFile", "StatFS"}, ops)
+}
@@ -19,12 +19,15 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"sync"
 	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/metadata"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/canned"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/health"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/monitor"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/ratelimit"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
@@ -45,6 +48,9 @@ type BucketConfig struct {
 	// Config for TTL of entries for non-existing file in stat cache
 	NegativeStatCacheTTL time.Duration
 	EnableMonitoring     bool
+	// If set, every request to GCS is recorded as a child span of the op that
+	// made it.
+	EnableTracing bool
 
 	// Files backed by on object of length at least AppendThreshold that have
 	// only been appended to (i.e. none of the object's contents have been
@@ -65,6 +71,35 @@ type BucketConfig struct {
 	AppendThreshold          int64
 	ChunkTransferTimeoutSecs int64
 	TmpObjectPrefix          string
+
+	// If set, called to create the backing bucket in place of the storage
+	// handle, e.g. to mount a simulated bucket for benchmarking.
+	NewBackingBucket func(ctx context.Context, name string) (gcs.Bucket, error)
+
+	// If set, requests are made with the credentials of the calling user, as
+	// identified by auth.CallerUID, through backing buckets created by this
+	// function. Each user gets a separate partition of the stat cache.
+	NewUserBackingBucket func(ctx context.Context, name string, uid uint32) (gcs.Bucket, error)
+
+	// If set, the permissions of the mount's credentials on the bucket are
+	// probed when it is set up, and made available as
+	// SyncerBucket.Permissions. Ignored with NewUserBackingBucket, since
+	// requests are then made with other credentials.
+	CheckPermissions bool
+
+	// If set, trashed objects, i.e. those with TrashPrefix, are garbage
+	// collected once they were removed longer than TrashRetention ago. A
+	// negative TrashRetention keeps them forever.
+	EnableTrash    bool
+	TrashRetention time.Duration
+
+	// If set, the entries, size and evictions of the shared stat cache are
+	// recorded to it.
+	MetricHandle common.MetricHandle
+
+	// If set, the buckets are added to it when set up, with their probe, for
+	// the readiness of the mount.
+	Health *health.Monitor
 }
 
 // BucketManager manages the lifecycle of buckets.
@@ -73,15 +108,53 @@ type BucketManager interface {
 		ctx context.Context,
 		name string, isMultibucketMount bool, metricHandle common.MetricHandle) (b SyncerBucket, err error)
 
+	// Changes the rate limits of the buckets set up so far and from now on,
+	// with the same meaning as the corresponding BucketConfig fields. Returns
+	// ErrRemountRequired if rate limiting was off, in which case the buckets
+	// have no throttles to change.
+	SetRateLimits(opRateLimitHz, egressBandwidthLimit float64) error
+
+	// Changes the stat cache TTLs of the buckets set up so far and from now on,
+	// with the same meaning as the corresponding BucketConfig fields. Returns
+	// ErrRemountRequired if stat caching was off.
+	SetStatCacheTTLs(ttl, negativeTTL time.Duration) error
+
 	// Shuts down the bucket manager and its buckets
 	ShutDown()
 }
 
+// ErrRemountRequired is returned for changes to the config of a bucket manager
+// that only take effect on a new mount.
+var ErrRemountRequired = errors.New("requires a remount")
+
+// A backing bucket wrapped by wrapBackingBucket, with the throttles and stat
+// caching wrapper that the Set* methods adjust.
+type wrappedBucket struct {
+	bucket gcs.Bucket
+	view   metadata.StatCache
+
+	// Nil if the bucket isn't rate limited or doesn't cache stats.
+	throttles *bucketThrottles
+	fastStat  caching.TTLSetter
+
+	// The number of bucket setups using the bucket.
+	refs int
+}
+
 type bucketManager struct {
+	// The fields that the Set* methods change are GUARDED_BY(mu).
 	config          BucketConfig
 	storageHandle   storage.StorageHandle
 	sharedStatCache *lru.Cache
 
+	mu sync.Mutex
+
+	// The wrapped buckets in use, by the name of their stat cache view, which
+	// is unique per bucket and user.
+	//
+	// GUARDED_BY(mu)
+	wrappedBuckets map[string]*wrappedBucket
+
 	// Garbage collector
 	gcCtx                 context.Context
 	stopGarbageCollecting func()
@@ -90,7 +163,7 @@ type bucketManager struct {
 func NewBucketManager(config BucketConfig, storageHandle storage.StorageHandle) BucketManager {
 	var c *lru.Cache
 	if config.StatCacheMaxSizeMB > 0 {
-		c = lru.NewCache(util.MiBsToBytes(config.StatCacheMaxSizeMB))
+		c = lru.NewCacheWithMetrics(util.MiBsToBytes(config.StatCacheMaxSizeMB), config.MetricHandle, common.StatCache)
 	}
 
 	bm := &bucketManager{
@@ -102,83 +175,140 @@ func NewBucketManager(config BucketConfig, storageHandle storage.StorageHandle)
 	return bm
 }
 
-func setUpRateLimiting(
-	in gcs.Bucket,
-	opRateLimitHz float64,
-	egressBandwidthLimit float64) (out gcs.Bucket, err error) {
-	// If no rate limiting has been requested, just return the bucket.
-	if !(opRateLimitHz > 0 || egressBandwidthLimit > 0) {
-		out = in
-		return
-	}
+// Whether the given limits, as in BucketConfig, call for rate limiting.
+func rateLimited(opRateLimitHz, egressBandwidthLimit float64) bool {
+	return opRateLimitHz > 0 || egressBandwidthLimit > 0
+}
 
+// The rate and token bucket capacity of a throttle for the given limit, as in
+// BucketConfig.
+func throttleRate(limit float64) (rateHz float64, capacity uint64, err error) {
 	// Treat a disabled limit as a very large one.
-	if !(opRateLimitHz > 0) {
-		opRateLimitHz = 1e15
-	}
-
-	if !(egressBandwidthLimit > 0) {
-		egressBandwidthLimit = 1e15
+	rateHz = limit
+	if !(rateHz > 0) {
+		rateHz = 1e15
 	}
 
 	// Choose token bucket capacities, targeting only a few percent error in each
 	// window of the given size.
 	const window = 8 * time.Hour
 
-	opCapacity, err := ratelimit.ChooseLimiterCapacity(
-		opRateLimitHz,
+	capacity, err = ratelimit.ChooseLimiterCapacity(
+		rateHz,
 		window)
 
+	return
+}
+
+// The throttles of a bucket. They are shared by the wrapped buckets of all its
+// users, so that the limits apply to all the traffic to the bucket.
+type bucketThrottles struct {
+	op     ratelimit.AdjustableThrottle
+	egress ratelimit.AdjustableThrottle
+}
+
+// Wrap in with the given throttles or, if they are nil, with new ones for the
+// given limits. Returns the throttles used, or nil if no rate limiting has
+// been requested.
+func setUpRateLimiting(
+	in gcs.Bucket,
+	throttles *bucketThrottles,
+	opRateLimitHz float64,
+	egressBandwidthLimit float64) (out gcs.Bucket, _ *bucketThrottles, err error) {
+	if throttles != nil {
+		out = ratelimit.NewThrottledBucket(throttles.op, throttles.egress, in)
+		return out, throttles, nil
+	}
+
+	// If no rate limiting has been requested, just return the bucket.
+	if !rateLimited(opRateLimitHz, egressBandwidthLimit) {
+		out = in
+		return
+	}
+
+	opRateHz, opCapacity, err := throttleRate(opRateLimitHz)
 	if err != nil {
 		err = fmt.Errorf("choosing operation token bucket capacity: %w", err)
 		return
 	}
 
-	egressCapacity, err := ratelimit.ChooseLimiterCapacity(
-		egressBandwidthLimit,
-		window)
-
+	egressRateHz, egressCapacity, err := throttleRate(egressBandwidthLimit)
 	if err != nil {
 		err = fmt.Errorf("choosing egress bandwidth token bucket capacity: %w", err)
 		return
 	}
 
 	// Create the throttles.
-	opThrottle := ratelimit.NewThrottle(opRateLimitHz, opCapacity)
-	egressThrottle := ratelimit.NewThrottle(egressBandwidthLimit, egressCapacity)
+	throttles = &bucketThrottles{
+		op:     ratelimit.NewAdjustableThrottle(opRateHz, opCapacity),
+		egress: ratelimit.NewAdjustableThrottle(egressRateHz, egressCapacity),
+	}
 
 	// And the bucket.
 	out = ratelimit.NewThrottledBucket(
-		opThrottle,
-		egressThrottle,
+		throttles.op,
+		throttles.egress,
 		in)
 
-	return
+	return out, throttles, nil
 }
 
-func (bm *bucketManager) SetUpBucket(
-	ctx context.Context,
-	name string,
-	isMultibucketMount bool,
-	metricHandle common.MetricHandle,
-) (sb SyncerBucket, err error) {
-	var b gcs.Bucket
-	// Set up the appropriate backing bucket.
-	if name == canned.FakeBucketName {
-		b = canned.MakeFakeBucket(ctx)
-	} else {
-		b, err = bm.storageHandle.BucketHandle(ctx, name, bm.config.BillingProject)
-		if err != nil {
-			err = fmt.Errorf("BucketHandle: %w", err)
-			return
+// The name of the stat cache view for the given bucket.
+func statCacheBucketView(name string, isMultibucketMount bool) string {
+	if isMultibucketMount {
+		return name
+	}
+	return ""
+}
+
+// The name of the stat cache view for the given user and bucket. Object names
+// can't contain line feeds, so this can't clash with any other view's keys.
+func userStatCacheBucketView(uid uint32, name string, isMultibucketMount bool) string {
+	return fmt.Sprintf("%d\n%s", uid, statCacheBucketView(name, isMultibucketMount))
+}
+
+// Wrap the backing bucket with monitoring, tracing, logging, the prefix for --only-dir,
+// rate limiting and stat caching through the given view of the shared stat
+// cache, which is added to views. A bucket already wrapped for the view is
+// reused instead, until every setup using it has released the view.
+//
+// The bucket is rate limited with the given throttles, e.g. those of the
+// bucket of the mount's own credentials for a user's bucket, or with new ones
+// if they are nil. Returns the throttles of the wrapped bucket.
+//
+// LOCKS_EXCLUDED(bm.mu)
+func (bm *bucketManager) wrapBackingBucket(
+	b gcs.Bucket,
+	statCacheView string,
+	views *StatCacheViews,
+	throttles *bucketThrottles,
+	metricHandle common.MetricHandle) (gcs.Bucket, *bucketThrottles, error) {
+	var err error
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	if wb, ok := bm.wrappedBuckets[statCacheView]; ok {
+		wb.refs++
+		if wb.view != nil {
+			views.add(wb.view)
 		}
+		return wb.bucket, wb.throttles, nil
 	}
+	if bm.wrappedBuckets == nil {
+		bm.wrappedBuckets = make(map[string]*wrappedBucket)
+	}
+	wb := &wrappedBucket{refs: 1}
 
 	// Enable monitoring.
 	if bm.config.EnableMonitoring {
 		b = monitor.NewMonitoringBucket(b, metricHandle)
 	}
 
+	// Enable tracing.
+	if bm.config.EnableTracing {
+		b = monitor.NewTracingBucket(b)
+	}
+
 	// Enable gcs logs.
 	b = storage.NewDebugBucket(b)
 
@@ -186,38 +316,210 @@ func (bm *bucketManager) SetUpBucket(
 	if bm.config.OnlyDir != "" {
 		b, err = NewPrefixBucket(path.Clean(bm.config.OnlyDir)+"/", b)
 		if err != nil {
-			err = fmt.Errorf("NewPrefixBucket: %w", err)
-			return
+			return nil, nil, fmt.Errorf("NewPrefixBucket: %w", err)
 		}
 	}
 
 	// Enable rate limiting, if requested.
-	b, err = setUpRateLimiting(
+	b, wb.throttles, err = setUpRateLimiting(
 		b,
+		throttles,
 		bm.config.OpRateLimitHz,
 		bm.config.EgressBandwidthLimitBytesPerSecond)
 
 	if err != nil {
-		err = fmt.Errorf("setUpRateLimiting: %w", err)
-		return
+		return nil, nil, fmt.Errorf("setUpRateLimiting: %w", err)
 	}
 
 	// Enable cached StatObject results based on stat cache config.
 	// Disabling stat cache with below config also disables negative stat cache.
 	if bm.config.StatCacheTTL != 0 && bm.sharedStatCache != nil {
-		var statCache metadata.StatCache
-		if isMultibucketMount {
-			statCache = metadata.NewStatCacheBucketView(bm.sharedStatCache, name)
-		} else {
-			statCache = metadata.NewStatCacheBucketView(bm.sharedStatCache, "")
-		}
-
+		wb.view = metadata.NewStatCacheBucketView(bm.sharedStatCache, statCacheView)
+		views.add(wb.view)
 		b = caching.NewFastStatBucket(
 			bm.config.StatCacheTTL,
-			statCache,
+			wb.view,
 			timeutil.RealClock(),
 			b,
 			bm.config.NegativeStatCacheTTL)
+		wb.fastStat = b.(caching.TTLSetter)
+	}
+
+	wb.bucket = b
+	bm.wrappedBuckets[statCacheView] = wb
+	return b, wb.throttles, nil
+}
+
+// Release the wrapped buckets of the given stat cache views taken by a bucket
+// setup, dropping those no other setup uses.
+//
+// LOCKS_EXCLUDED(bm.mu)
+func (bm *bucketManager) releaseWrappedBuckets(statCacheViews []string) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for _, view := range statCacheViews {
+		wb, ok := bm.wrappedBuckets[view]
+		if !ok {
+			continue
+		}
+		wb.refs--
+		if wb.refs == 0 {
+			delete(bm.wrappedBuckets, view)
+		}
+	}
+}
+
+// LOCKS_EXCLUDED(bm.mu)
+func (bm *bucketManager) SetRateLimits(opRateLimitHz, egressBandwidthLimit float64) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	// Buckets set up without rate limiting have no throttles.
+	wasRateLimited := rateLimited(bm.config.OpRateLimitHz, bm.config.EgressBandwidthLimitBytesPerSecond)
+	if !wasRateLimited && rateLimited(opRateLimitHz, egressBandwidthLimit) {
+		return ErrRemountRequired
+	}
+
+	opRateHz, opCapacity, err := throttleRate(opRateLimitHz)
+	if err != nil {
+		return fmt.Errorf("choosing operation token bucket capacity: %w", err)
+	}
+
+	egressRateHz, egressCapacity, err := throttleRate(egressBandwidthLimit)
+	if err != nil {
+		return fmt.Errorf("choosing egress bandwidth token bucket capacity: %w", err)
+	}
+
+	// Throttles shared by several wrapped buckets are set more than once,
+	// which is harmless.
+	for _, wb := range bm.wrappedBuckets {
+		if wb.throttles != nil {
+			wb.throttles.op.SetRate(opRateHz, opCapacity)
+			wb.throttles.egress.SetRate(egressRateHz, egressCapacity)
+		}
+	}
+
+	// Keep setting up throttles for new buckets, even if both limits are now
+	// off, so that they can be turned back on.
+	if wasRateLimited && !rateLimited(opRateLimitHz, egressBandwidthLimit) {
+		opRateLimitHz = 1e15
+	}
+
+	bm.config.OpRateLimitHz = opRateLimitHz
+	bm.config.EgressBandwidthLimitBytesPerSecond = egressBandwidthLimit
+	return nil
+}
+
+// LOCKS_EXCLUDED(bm.mu)
+func (bm *bucketManager) SetStatCacheTTLs(ttl, negativeTTL time.Duration) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	// Buckets set up without stat caching have no stat caching wrappers.
+	if bm.config.StatCacheTTL == 0 || bm.sharedStatCache == nil {
+		return ErrRemountRequired
+	}
+
+	// A zero TTL would stop new buckets being set up with stat caching, so
+	// that they couldn't be changed back later. Entries expire at once with
+	// either.
+	if ttl == 0 {
+		ttl = time.Nanosecond
+	}
+
+	for _, wb := range bm.wrappedBuckets {
+		if wb.fastStat != nil {
+			wb.fastStat.SetTTLs(ttl, negativeTTL)
+		}
+	}
+
+	bm.config.StatCacheTTL = ttl
+	bm.config.NegativeStatCacheTTL = negativeTTL
+	return nil
+}
+
+func (bm *bucketManager) SetUpBucket(
+	ctx context.Context,
+	name string,
+	isMultibucketMount bool,
+	metricHandle common.MetricHandle,
+) (sb SyncerBucket, err error) {
+	var b gcs.Bucket
+	// Set up the appropriate backing bucket.
+	if name == canned.FakeBucketName {
+		b = canned.MakeFakeBucket(ctx)
+	} else if bm.config.NewBackingBucket != nil {
+		b, err = bm.config.NewBackingBucket(ctx, name)
+		if err != nil {
+			err = fmt.Errorf("NewBackingBucket: %w", err)
+			return
+		}
+	} else {
+		b, err = bm.storageHandle.BucketHandle(ctx, name, bm.config.BillingProject)
+		if err != nil {
+			err = fmt.Errorf("BucketHandle: %w", err)
+			return
+		}
+	}
+
+	// Probe the permissions on the backing bucket, since the wrappers don't
+	// forward TestPermissions. A failure to probe is not fatal: the file system
+	// then relies on GCS rejecting ops as before.
+	var perms *BucketPermissions
+	if bm.config.CheckPermissions && bm.config.NewUserBackingBucket == nil {
+		perms, err = ProbePermissions(ctx, b)
+		if err != nil {
+			logger.Warnf("Couldn't check permissions on bucket %q: %v", name, err)
+			err = nil
+		}
+	}
+
+	// The stat cache views of the wrapped buckets taken by this setup. They are
+	// released if it fails, since nothing then uses the bucket. User buckets
+	// may be wrapped concurrently once the bucket is in use.
+	var viewsMu sync.Mutex
+	var takenViews []string
+	take := func(view string) {
+		viewsMu.Lock()
+		defer viewsMu.Unlock()
+		takenViews = append(takenViews, view)
+	}
+	defer func() {
+		if err != nil {
+			viewsMu.Lock()
+			defer viewsMu.Unlock()
+			bm.releaseWrappedBuckets(takenViews)
+		}
+	}()
+
+	views := &StatCacheViews{}
+	view := statCacheBucketView(name, isMultibucketMount)
+	var throttles *bucketThrottles
+	b, throttles, err = bm.wrapBackingBucket(b, view, views, nil, metricHandle)
+	if err != nil {
+		return
+	}
+	take(view)
+
+	// Enable per-user credentials, if requested. User buckets are created
+	// during later ops and outlive them, so they don't use ctx. They share the
+	// throttles of the bucket, so that the rate limits apply to the mount
+	// rather than to each user.
+	if bm.config.NewUserBackingBucket != nil {
+		b = NewPerUserBucket(b, func(uid uint32) (gcs.Bucket, error) {
+			ub, err := bm.config.NewUserBackingBucket(context.Background(), name, uid)
+			if err != nil {
+				return nil, err
+			}
+			view := userStatCacheBucketView(uid, name, isMultibucketMount)
+			ub, _, err = bm.wrapBackingBucket(ub, view, views, throttles, metricHandle)
+			if err != nil {
+				return nil, err
+			}
+			take(view)
+			return ub, nil
+		})
 	}
 
 	// Enable content type awareness
@@ -233,6 +535,8 @@ func (bm *bucketManager) SetUpBucket(
 		bm.config.ChunkTransferTimeoutSecs,
 		bm.config.TmpObjectPrefix,
 		b)
+	sb.Permissions = perms
+	sb.StatCache = views
 
 	// Fetch bucket type from storage layout api and set bucket type.
 	b.BucketType()
@@ -240,14 +544,25 @@ func (bm *bucketManager) SetUpBucket(
 	// Check whether this bucket works, giving the user a warning early if there
 	// is some problem.
 	{
-		_, err = b.ListObjects(ctx, &gcs.ListObjectsRequest{MaxResults: 1})
+		probe := func(ctx context.Context) error {
+			_, err := b.ListObjects(ctx, &gcs.ListObjectsRequest{MaxResults: 1})
+			return err
+		}
+		err = probe(ctx)
+		if bm.config.Health != nil {
+			bm.config.Health.AddBucket(name, probe, err)
+		}
 		if err != nil {
 			return
 		}
 	}
 
 	// Periodically garbage collect temporary objects
-	go garbageCollect(bm.gcCtx, bm.config.TmpObjectPrefix, sb)
+	trashRetention := time.Duration(-1)
+	if bm.config.EnableTrash {
+		trashRetention = bm.config.TrashRetention
+	}
+	go garbageCollect(bm.gcCtx, bm.config.TmpObjectPrefix, trashRetention, sb)
 
 	return
 }
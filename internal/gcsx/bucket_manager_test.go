@@ -16,15 +16,20 @@ package gcsx
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"cloud.google.com/go/storage/control/apiv2/controlpb"
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
 	. "github.com/jacobsa/ogletest"
+	"github.com/jacobsa/timeutil"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -183,3 +188,258 @@ func (t *BucketManagerTest) TestSetUpBucketMethodWhenBucketDoesNotExist_IsMultiB
 	ExpectEq("error in iterating through objects: storage: bucket doesn't exist", err.Error())
 	ExpectNe(nil, bucket.Syncer)
 }
+
+func (t *BucketManagerTest) TestSetUpBucketMethodWithNewBackingBucket() {
+	var bm bucketManager
+	var requestedName string
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix: "TmpObjectPrefix",
+		NewBackingBucket: func(_ context.Context, name string) (gcs.Bucket, error) {
+			requestedName = name
+			return fake.NewFakeBucket(timeutil.RealClock(), name, gcs.BucketType{}), nil
+		},
+	}
+	ctx := context.Background()
+	bm.config = bucketConfig
+	bm.gcCtx = ctx
+
+	bucket, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+
+	AssertEq(nil, err)
+	ExpectEq("simulated", requestedName)
+	ExpectEq("simulated", bucket.Name())
+}
+
+func (t *BucketManagerTest) TestSetUpBucketMethodWhenNewBackingBucketFails() {
+	var bm bucketManager
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix: "TmpObjectPrefix",
+		NewBackingBucket: func(context.Context, string) (gcs.Bucket, error) {
+			return nil, errors.New("taco")
+		},
+	}
+	ctx := context.Background()
+	bm.config = bucketConfig
+	bm.gcCtx = ctx
+
+	_, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+
+	ExpectEq("NewBackingBucket: taco", err.Error())
+}
+
+func (t *BucketManagerTest) TestSetUpBucketMethodChecksPermissions() {
+	var bm bucketManager
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:  "TmpObjectPrefix",
+		CheckPermissions: true,
+		NewBackingBucket: func(_ context.Context, name string) (gcs.Bucket, error) {
+			return fake.NewFakeBucketWithOptions(timeutil.RealClock(), name, gcs.BucketType{}, fake.BucketOptions{
+				GrantedPermissions: []string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList},
+			}), nil
+		},
+	}
+	ctx := context.Background()
+	bm.config = bucketConfig
+	bm.gcCtx = ctx
+
+	bucket, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+
+	AssertEq(nil, err)
+	AssertNe(nil, bucket.Permissions)
+	ExpectTrue(bucket.Permissions.ReadOnly())
+}
+
+func (t *BucketManagerTest) TestSetUpBucketMethodWithoutCheckPermissions() {
+	var bm bucketManager
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix: "TmpObjectPrefix",
+		NewBackingBucket: func(_ context.Context, name string) (gcs.Bucket, error) {
+			return fake.NewFakeBucketWithOptions(timeutil.RealClock(), name, gcs.BucketType{}, fake.BucketOptions{
+				GrantedPermissions: []string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList},
+			}), nil
+		},
+	}
+	ctx := context.Background()
+	bm.config = bucketConfig
+	bm.gcCtx = ctx
+
+	bucket, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+
+	AssertEq(nil, err)
+	ExpectEq(nil, bucket.Permissions)
+	ExpectFalse(bucket.Permissions.ReadOnly())
+}
+
+func (t *BucketManagerTest) TestSetUpBucketMethodInvalidatesStatCache() {
+	backing := fake.NewFakeBucket(timeutil.RealClock(), "simulated", gcs.BucketType{})
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:    "TmpObjectPrefix",
+		StatCacheMaxSizeMB: 1,
+		StatCacheTTL:       time.Hour,
+		NewBackingBucket: func(_ context.Context, _ string) (gcs.Bucket, error) {
+			return backing, nil
+		},
+	}
+	bm := NewBucketManager(bucketConfig, nil)
+	defer bm.ShutDown()
+	ctx := context.Background()
+	bucket, err := bm.SetUpBucket(ctx, "simulated", false, common.NewNoopMetrics())
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(ctx, backing, "foo", []byte("taco"))
+	AssertEq(nil, err)
+	_, _, err = bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: "foo"})
+	AssertEq(nil, err)
+	AssertEq(nil, backing.DeleteObject(ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+
+	// The deleted object is still served from the stat cache, until it's
+	// invalidated.
+	_, _, err = bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: "foo"})
+	ExpectEq(nil, err)
+	bucket.StatCache.Invalidate("f", true)
+	_, _, err = bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: "foo"})
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+}
+
+func (t *BucketManagerTest) TestSetStatCacheTTLs() {
+	backing := fake.NewFakeBucket(timeutil.RealClock(), "simulated", gcs.BucketType{})
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:    "TmpObjectPrefix",
+		StatCacheMaxSizeMB: 1,
+		StatCacheTTL:       time.Hour,
+		NewBackingBucket: func(_ context.Context, _ string) (gcs.Bucket, error) {
+			return backing, nil
+		},
+	}
+	bm := NewBucketManager(bucketConfig, nil)
+	defer bm.ShutDown()
+	ctx := context.Background()
+	bucket, err := bm.SetUpBucket(ctx, "simulated", false, common.NewNoopMetrics())
+	AssertEq(nil, err)
+	_, err = storageutil.CreateObject(ctx, backing, "foo", []byte("taco"))
+	AssertEq(nil, err)
+
+	AssertEq(nil, bm.SetStatCacheTTLs(0, 0))
+
+	// The object is no longer served from the stat cache once deleted.
+	_, _, err = bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: "foo"})
+	AssertEq(nil, err)
+	AssertEq(nil, backing.DeleteObject(ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+	_, _, err = bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: "foo"})
+	var notFoundErr *gcs.NotFoundError
+	ExpectTrue(errors.As(err, &notFoundErr))
+}
+
+func (t *BucketManagerTest) TestSetStatCacheTTLsWithStatCacheOff() {
+	bm := NewBucketManager(BucketConfig{StatCacheMaxSizeMB: 1}, nil)
+	defer bm.ShutDown()
+
+	err := bm.SetStatCacheTTLs(time.Minute, time.Minute)
+
+	ExpectEq(ErrRemountRequired, err)
+}
+
+func (t *BucketManagerTest) TestSetRateLimits() {
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix: "TmpObjectPrefix",
+		OpRateLimitHz:   10,
+		NewBackingBucket: func(_ context.Context, name string) (gcs.Bucket, error) {
+			return fake.NewFakeBucket(timeutil.RealClock(), name, gcs.BucketType{}), nil
+		},
+	}
+	bm := NewBucketManager(bucketConfig, nil)
+	defer bm.ShutDown()
+	_, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+	AssertEq(nil, err)
+
+	err = bm.SetRateLimits(1000, 0)
+
+	AssertEq(nil, err)
+	_, wantCapacity, err := throttleRate(1000)
+	AssertEq(nil, err)
+	impl := bm.(*bucketManager)
+	AssertEq(1, len(impl.wrappedBuckets))
+	ExpectEq(wantCapacity, impl.wrappedBuckets[""].throttles.op.Capacity())
+	ExpectEq(1000, impl.config.OpRateLimitHz)
+}
+
+func (t *BucketManagerTest) TestUserBucketsShareThrottles() {
+	newBucket := func(_ context.Context, name string) (gcs.Bucket, error) {
+		return fake.NewFakeBucket(timeutil.RealClock(), name, gcs.BucketType{}), nil
+	}
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:  "TmpObjectPrefix",
+		OpRateLimitHz:    10,
+		NewBackingBucket: newBucket,
+		NewUserBackingBucket: func(ctx context.Context, name string, _ uint32) (gcs.Bucket, error) {
+			return newBucket(ctx, name)
+		},
+	}
+	bm := NewBucketManager(bucketConfig, nil)
+	defer bm.ShutDown()
+	sb, err := bm.SetUpBucket(context.Background(), "simulated", false, common.NewNoopMetrics())
+	AssertEq(nil, err)
+
+	for _, uid := range []uint32{1000, 1001} {
+		_, err = sb.ListObjects(auth.WithCallerUID(context.Background(), uid), &gcs.ListObjectsRequest{})
+		AssertEq(nil, err)
+	}
+
+	impl := bm.(*bucketManager)
+	AssertEq(3, len(impl.wrappedBuckets))
+	throttles := impl.wrappedBuckets[""].throttles
+	AssertNe(nil, throttles)
+	for _, wb := range impl.wrappedBuckets {
+		ExpectEq(throttles, wb.throttles)
+	}
+}
+
+func (t *BucketManagerTest) TestSetUpBucketReusesWrappedBucket() {
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:    "TmpObjectPrefix",
+		OpRateLimitHz:      10,
+		StatCacheMaxSizeMB: 1,
+		StatCacheTTL:       time.Hour,
+		NewBackingBucket: func(_ context.Context, name string) (gcs.Bucket, error) {
+			return fake.NewFakeBucket(timeutil.RealClock(), name, gcs.BucketType{}), nil
+		},
+	}
+	bm := NewBucketManager(bucketConfig, nil)
+	defer bm.ShutDown()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := bm.SetUpBucket(ctx, "simulated", true, common.NewNoopMetrics())
+		AssertEq(nil, err)
+	}
+	_, err := bm.SetUpBucket(ctx, "other", true, common.NewNoopMetrics())
+	AssertEq(nil, err)
+
+	impl := bm.(*bucketManager)
+	ExpectEq(2, len(impl.wrappedBuckets))
+	ExpectEq(3, impl.wrappedBuckets["simulated"].refs)
+}
+
+func (t *BucketManagerTest) TestSetUpBucketDropsWrappedBucketOnFailure() {
+	bucketConfig := BucketConfig{
+		TmpObjectPrefix:    "TmpObjectPrefix",
+		OpRateLimitHz:      10,
+		StatCacheMaxSizeMB: 1,
+		StatCacheTTL:       time.Hour,
+	}
+	bm := NewBucketManager(bucketConfig, t.storageHandle)
+	defer bm.ShutDown()
+
+	_, err := bm.SetUpBucket(context.Background(), invalidBucketName, true, common.NewNoopMetrics())
+
+	AssertNe(nil, err)
+	ExpectEq(0, len(bm.(*bucketManager).wrappedBuckets))
+}
+
+func (t *BucketManagerTest) TestSetRateLimitsWithRateLimitingOff() {
+	bm := NewBucketManager(BucketConfig{}, nil)
+	defer bm.ShutDown()
+
+	ExpectEq(ErrRemountRequired, bm.SetRateLimits(10, 0))
+	ExpectEq(nil, bm.SetRateLimits(0, 0))
+}
@@ -32,13 +32,41 @@ func garbageCollectOnce(
 	tmpObjectPrefix string,
 	bucket gcs.Bucket) (objectsDeleted uint64, err error) {
 	const stalenessThreshold = 30 * time.Minute
+	now := time.Now()
+
+	return deleteObjectsWithPrefix(ctx, tmpObjectPrefix, bucket, func(o *gcs.MinObject) bool {
+		return now.Sub(o.Updated) >= stalenessThreshold
+	})
+}
+
+// Delete the trashed objects that were removed longer than retention ago. The
+// time of removal is taken from the name, since moving an object may not
+// update it.
+func garbageCollectTrashOnce(
+	ctx context.Context,
+	retention time.Duration,
+	bucket gcs.Bucket) (objectsDeleted uint64, err error) {
+	now := time.Now()
+
+	return deleteObjectsWithPrefix(ctx, TrashPrefix, bucket, func(o *gcs.MinObject) bool {
+		removed, ok := trashTime(o.Name)
+		return ok && now.Sub(removed) >= retention
+	})
+}
+
+// Delete the objects with the given prefix for which isStale returns true.
+func deleteObjectsWithPrefix(
+	ctx context.Context,
+	prefix string,
+	bucket gcs.Bucket,
+	isStale func(*gcs.MinObject) bool) (objectsDeleted uint64, err error) {
 	group, ctx := errgroup.WithContext(ctx)
 
-	// List all objects with the temporary prefix.
+	// List all objects with the prefix.
 	minObjects := make(chan *gcs.MinObject, 100)
 	group.Go(func() (err error) {
 		defer close(minObjects)
-		err = storageutil.ListPrefix(ctx, bucket, tmpObjectPrefix, minObjects)
+		err = storageutil.ListPrefix(ctx, bucket, prefix, minObjects)
 		if err != nil {
 			err = fmt.Errorf("ListPrefix: %w", err)
 			return
@@ -48,12 +76,11 @@ func garbageCollectOnce(
 	})
 
 	// Filter to the names of objects that are stale.
-	now := time.Now()
 	staleNames := make(chan string, 100)
 	group.Go(func() (err error) {
 		defer close(staleNames)
 		for o := range minObjects {
-			if now.Sub(o.Updated) < stalenessThreshold {
+			if !isStale(o) {
 				continue
 			}
 
@@ -95,10 +122,12 @@ func garbageCollectOnce(
 }
 
 // Periodically delete stale temporary objects from the supplied bucket until
-// the context is cancelled.
+// the context is cancelled. If trashRetention is non-negative, trashed objects
+// older than it are deleted too.
 func garbageCollect(
 	ctx context.Context,
 	tmpObjectPrefix string,
+	trashRetention time.Duration,
 	bucket gcs.Bucket) {
 	const period = 10 * time.Minute
 	ticker := time.NewTicker(period)
@@ -130,5 +159,26 @@ func garbageCollect(
 				objectsDeleted,
 				time.Since(startTime))
 		}
+
+		if trashRetention < 0 {
+			continue
+		}
+
+		startTime = time.Now()
+		objectsDeleted, err = garbageCollectTrashOnce(ctx, trashRetention, bucket)
+
+		if err != nil {
+			logger.Infof(
+				"Trash garbage collection failed after deleting %d objects in %v, "+
+					"with error: %v",
+				objectsDeleted,
+				time.Since(startTime),
+				err)
+		} else {
+			logger.Infof(
+				"Trash garbage collection succeeded after deleted %d objects in %v.",
+				objectsDeleted,
+				time.Since(startTime))
+		}
 	}
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/monitor"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"golang.org/x/net/context"
 )
 
@@ -34,8 +35,15 @@ import (
 // it's refcount reaches 0.
 const multiRangeDownloaderTimeout = 60 * time.Second
 
-func NewMultiRangeDownloaderWrapper(bucket gcs.Bucket, object *gcs.MinObject) MultiRangeDownloaderWrapper {
-	return NewMultiRangeDownloaderWrapperWithClock(bucket, object, clock.RealClock{})
+// NewMultiRangeDownloaderWrapper creates a wrapper for the given object, whose
+// refcount changes are recorded to metricHandle.
+func NewMultiRangeDownloaderWrapper(bucket gcs.Bucket, object *gcs.MinObject, metricHandle common.MetricHandle) MultiRangeDownloaderWrapper {
+	return MultiRangeDownloaderWrapper{
+		clock:        clock.RealClock{},
+		bucket:       bucket,
+		object:       object,
+		metricHandle: metricHandle,
+	}
 }
 
 func NewMultiRangeDownloaderWrapperWithClock(bucket gcs.Bucket, object *gcs.MinObject, clock clock.Clock) MultiRangeDownloaderWrapper {
@@ -67,6 +75,8 @@ type MultiRangeDownloaderWrapper struct {
 	cancelCleanup context.CancelFunc
 	// Used for waiting for timeout (helps us in mocking the functionality).
 	clock clock.Clock
+	// Records the refcount changes. May be nil.
+	metricHandle common.MetricHandle
 }
 
 // Returns current refcount.
@@ -84,6 +94,7 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) IncrementRefCount() {
 	defer mrdWrapper.mu.Unlock()
 
 	mrdWrapper.refCount++
+	mrdWrapper.recordRefCountChange(1)
 	if mrdWrapper.cancelCleanup != nil {
 		mrdWrapper.cancelCleanup()
 		mrdWrapper.cancelCleanup = nil
@@ -104,6 +115,7 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) DecrementRefCount() (err error) {
 	}
 
 	mrdWrapper.refCount--
+	mrdWrapper.recordRefCountChange(-1)
 	if mrdWrapper.refCount == 0 {
 		mrdWrapper.Wrapped.Close()
 		mrdWrapper.Wrapped = nil
@@ -113,6 +125,13 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) DecrementRefCount() (err error) {
 	return
 }
 
+// Records a change of the refcount, if the wrapper has a metric handle.
+func (mrdWrapper *MultiRangeDownloaderWrapper) recordRefCountChange(inc int64) {
+	if mrdWrapper.metricHandle != nil {
+		mrdWrapper.metricHandle.MultiRangeDownloaderRefCount(context.Background(), inc, nil)
+	}
+}
+
 // Spawns a cancellable go routine to close the MRD after the timeout.
 // Always call after taking MultiRangeDownloaderWrapper's mutex lock.
 func (mrdWrapper *MultiRangeDownloaderWrapper) cleanupMultiRangeDownloader() {
@@ -158,6 +177,10 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) Read(ctx context.Context, buf []b
 		return 0, nil
 	}
 
+	ctx, span := tracing.StartSpan(ctx, "gcsx.MultiRangeDownloaderRead",
+		append(tracing.ObjectAttrs(mrdWrapper.object.Name, mrdWrapper.object.Generation), tracing.RangeAttrs(startOffset, endOffset-startOffset)...)...)
+	defer func() { tracing.EndSpan(span, err) }()
+
 	err = mrdWrapper.ensureMultiRangeDownloader()
 	if err != nil {
 		err = fmt.Errorf("MultiRangeDownloaderWrapper::Read: Error in creating MultiRangeDownloader:  %v", err)
@@ -182,7 +205,7 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) Read(ctx context.Context, buf []b
 	}()
 
 	requestId := uuid.New()
-	logger.Tracef("%.13v <- MultiRangeDownloader::Add (%s, [%d, %d))", requestId, mrdWrapper.object.Name, startOffset, endOffset)
+	logger.GCS.Tracef("%.13v <- MultiRangeDownloader::Add (%s, [%d, %d))", requestId, mrdWrapper.object.Name, startOffset, endOffset)
 	start := time.Now()
 	mrdWrapper.Wrapped.Add(buffer, startOffset, endOffset-startOffset, func(offsetAddCallback int64, limit int64, e error) {
 		defer func() {
@@ -213,8 +236,8 @@ func (mrdWrapper *MultiRangeDownloaderWrapper) Read(ctx context.Context, buf []b
 	if err != nil {
 		errDesc = err.Error()
 		err = fmt.Errorf("MultiRangeDownloaderWrapper::Read: %w", err)
-		logger.Errorf("%v", err)
+		logger.GCS.Errorf("%v", err)
 	}
-	logger.Tracef("%.13v -> MultiRangeDownloader::Add (%s, [%d, %d)) (%v): %v", requestId, mrdWrapper.object.Name, startOffset, endOffset, duration, errDesc)
+	logger.GCS.Tracef("%.13v -> MultiRangeDownloader::Add (%s, [%d, %d)) (%v): %v", requestId, mrdWrapper.object.Name, startOffset, endOffset, duration, errDesc)
 	return
 }
@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"golang.org/x/net/context"
+	"golang.org/x/sync/singleflight"
+)
+
+// NewPerUserBucket creates a bucket that sends each request to the bucket of
+// the caller identified by auth.CallerUID, created by newUserBucket on first
+// use. Requests without a caller, e.g. garbage collection, and requests from
+// root go to defaultBucket, which uses the mounter's credentials.
+//
+// Errors from newUserBucket are returned as *auth.UserCredentialsError, and are
+// not cached so that e.g. a key file added later is picked up.
+func NewPerUserBucket(
+	defaultBucket gcs.Bucket,
+	newUserBucket func(uid uint32) (gcs.Bucket, error)) gcs.Bucket {
+	return &perUserBucket{
+		defaultBucket: defaultBucket,
+		newUserBucket: newUserBucket,
+		userBuckets:   make(map[uint32]gcs.Bucket),
+	}
+}
+
+type perUserBucket struct {
+	defaultBucket gcs.Bucket
+	newUserBucket func(uid uint32) (gcs.Bucket, error)
+
+	// Creations of user buckets in progress, keyed by UID, so that a slow
+	// token fetch for one user doesn't hold up the others.
+	creating singleflight.Group
+
+	mu sync.Mutex
+
+	// GUARDED_BY(mu)
+	userBuckets map[uint32]gcs.Bucket
+}
+
+func (b *perUserBucket) bucket(ctx context.Context) (gcs.Bucket, error) {
+	uid, ok := auth.CallerUID(ctx)
+	if !ok || uid == 0 {
+		return b.defaultBucket, nil
+	}
+
+	b.mu.Lock()
+	ub, ok := b.userBuckets[uid]
+	b.mu.Unlock()
+	if ok {
+		return ub, nil
+	}
+
+	v, err, _ := b.creating.Do(strconv.FormatUint(uint64(uid), 10), func() (interface{}, error) {
+		ub, err := b.newUserBucket(uid)
+		if err != nil {
+			return nil, err
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.userBuckets[uid] = ub
+		return ub, nil
+	})
+	if err != nil {
+		return nil, &auth.UserCredentialsError{UID: uid, Err: err}
+	}
+	return v.(gcs.Bucket), nil
+}
+
+func (b *perUserBucket) Name() string {
+	return b.defaultBucket.Name()
+}
+
+func (b *perUserBucket) BucketType() gcs.BucketType {
+	return b.defaultBucket.BucketType()
+}
+
+func (b *perUserBucket) NewReader(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (io.ReadCloser, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.NewReader(ctx, req)
+}
+
+func (b *perUserBucket) NewReaderWithReadHandle(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (gcs.StorageReader, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.NewReaderWithReadHandle(ctx, req)
+}
+
+func (b *perUserBucket) NewMultiRangeDownloader(
+	ctx context.Context,
+	req *gcs.MultiRangeDownloaderRequest) (gcs.MultiRangeDownloader, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.NewMultiRangeDownloader(ctx, req)
+}
+
+func (b *perUserBucket) CreateObject(
+	ctx context.Context,
+	req *gcs.CreateObjectRequest) (*gcs.Object, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.CreateObject(ctx, req)
+}
+
+func (b *perUserBucket) CreateObjectChunkWriter(
+	ctx context.Context,
+	req *gcs.CreateObjectRequest,
+	chunkSize int,
+	callBack func(bytesUploadedSoFar int64)) (gcs.Writer, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.CreateObjectChunkWriter(ctx, req, chunkSize, callBack)
+}
+
+func (b *perUserBucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (*gcs.MinObject, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.FinalizeUpload(ctx, w)
+}
+
+func (b *perUserBucket) CopyObject(
+	ctx context.Context,
+	req *gcs.CopyObjectRequest) (*gcs.Object, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.CopyObject(ctx, req)
+}
+
+func (b *perUserBucket) ComposeObjects(
+	ctx context.Context,
+	req *gcs.ComposeObjectsRequest) (*gcs.Object, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.ComposeObjects(ctx, req)
+}
+
+func (b *perUserBucket) StatObject(
+	ctx context.Context,
+	req *gcs.StatObjectRequest) (*gcs.MinObject, *gcs.ExtendedObjectAttributes, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ub.StatObject(ctx, req)
+}
+
+func (b *perUserBucket) ListObjects(
+	ctx context.Context,
+	req *gcs.ListObjectsRequest) (*gcs.Listing, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.ListObjects(ctx, req)
+}
+
+func (b *perUserBucket) UpdateObject(
+	ctx context.Context,
+	req *gcs.UpdateObjectRequest) (*gcs.Object, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.UpdateObject(ctx, req)
+}
+
+func (b *perUserBucket) DeleteObject(
+	ctx context.Context,
+	req *gcs.DeleteObjectRequest) error {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return ub.DeleteObject(ctx, req)
+}
+
+func (b *perUserBucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (*gcs.Object, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.MoveObject(ctx, req)
+}
+
+func (b *perUserBucket) DeleteFolder(ctx context.Context, folderName string) error {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return ub.DeleteFolder(ctx, folderName)
+}
+
+func (b *perUserBucket) GetFolder(ctx context.Context, folderName string) (*gcs.Folder, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.GetFolder(ctx, folderName)
+}
+
+func (b *perUserBucket) RenameFolder(ctx context.Context, folderName string, destinationFolderId string) (*gcs.Folder, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.RenameFolder(ctx, folderName, destinationFolderId)
+}
+
+func (b *perUserBucket) CreateFolder(ctx context.Context, folderName string) (*gcs.Folder, error) {
+	ub, err := b.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ub.CreateFolder(ctx, folderName)
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/auth"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type PerUserBucketTest struct {
+	suite.Suite
+	ctx           context.Context
+	defaultBucket gcs.Bucket
+	userBuckets   map[uint32]gcs.Bucket
+	created       []uint32
+	bucket        gcs.Bucket
+}
+
+func TestPerUserBucketSuite(t *testing.T) {
+	suite.Run(t, new(PerUserBucketTest))
+}
+
+func (t *PerUserBucketTest) SetupTest() {
+	t.ctx = context.Background()
+	t.defaultBucket = fake.NewFakeBucket(timeutil.RealClock(), "default", gcs.BucketType{})
+	t.userBuckets = map[uint32]gcs.Bucket{
+		1000: fake.NewFakeBucket(timeutil.RealClock(), "alice", gcs.BucketType{}),
+	}
+	t.created = nil
+	t.bucket = NewPerUserBucket(t.defaultBucket, func(uid uint32) (gcs.Bucket, error) {
+		t.created = append(t.created, uid)
+		if b, ok := t.userBuckets[uid]; ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("no credentials for %d", uid)
+	})
+}
+
+func (t *PerUserBucketTest) TestRequestsGoToCallersBucket() {
+	alice := auth.WithCallerUID(t.ctx, 1000)
+
+	_, err := storageutil.CreateObject(alice, t.bucket, "foo", []byte("taco"))
+	require.NoError(t.T(), err)
+	_, err = storageutil.CreateObject(alice, t.bucket, "bar", []byte("burrito"))
+	require.NoError(t.T(), err)
+
+	contents, err := storageutil.ReadObject(t.ctx, t.userBuckets[1000], "foo")
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", string(contents))
+	_, err = storageutil.ReadObject(t.ctx, t.defaultBucket, "foo")
+	assert.Error(t.T(), err)
+	// The user's bucket is created once.
+	assert.Equal(t.T(), []uint32{1000}, t.created)
+}
+
+func (t *PerUserBucketTest) TestRequestsWithoutCallerOrFromRootGoToDefaultBucket() {
+	_, err := storageutil.CreateObject(t.ctx, t.bucket, "foo", []byte("taco"))
+	require.NoError(t.T(), err)
+	_, err = storageutil.CreateObject(auth.WithCallerUID(t.ctx, 0), t.bucket, "bar", []byte("burrito"))
+	require.NoError(t.T(), err)
+
+	listing, err := t.defaultBucket.ListObjects(t.ctx, &gcs.ListObjectsRequest{})
+	require.NoError(t.T(), err)
+	assert.Len(t.T(), listing.MinObjects, 2)
+	assert.Empty(t.T(), t.created)
+	assert.Equal(t.T(), "default", t.bucket.Name())
+}
+
+func (t *PerUserBucketTest) TestMissingCredentials() {
+	bob := auth.WithCallerUID(t.ctx, 1001)
+
+	_, _, err := t.bucket.StatObject(bob, &gcs.StatObjectRequest{Name: "foo"})
+	_, _, err2 := t.bucket.StatObject(bob, &gcs.StatObjectRequest{Name: "foo"})
+
+	var credsErr *auth.UserCredentialsError
+	require.True(t.T(), errors.As(err, &credsErr))
+	assert.Equal(t.T(), uint32(1001), credsErr.UID)
+	assert.Error(t.T(), err2)
+	// Failures aren't cached.
+	assert.Equal(t.T(), []uint32{1001, 1001}, t.created)
+}
+
+func (t *PerUserBucketTest) TestSlowCreationDoesNotBlockOtherUsers() {
+	release := make(chan struct{})
+	bucket := NewPerUserBucket(t.defaultBucket, func(uid uint32) (gcs.Bucket, error) {
+		if uid == 1001 {
+			<-release
+		}
+		return t.userBuckets[1000], nil
+	})
+	slowDone := make(chan error)
+	go func() {
+		_, _, err := bucket.StatObject(auth.WithCallerUID(t.ctx, 1001), &gcs.StatObjectRequest{Name: "foo"})
+		slowDone <- err
+	}()
+
+	// Alice's request completes while Bob's bucket is still being created.
+	_, err := storageutil.CreateObject(auth.WithCallerUID(t.ctx, 1000), bucket, "foo", []byte("taco"))
+
+	require.NoError(t.T(), err)
+	close(release)
+	assert.NoError(t.T(), <-slowDone)
+}
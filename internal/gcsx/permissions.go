@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+)
+
+// BucketPermissions are the IAM permissions that the mount's credentials hold
+// on a bucket, as probed when the bucket was set up. They let the file system
+// refuse an op up front rather than fail when it reaches GCS, possibly after
+// data has been written locally.
+//
+// A nil *BucketPermissions means the permissions are unknown, and grants
+// everything.
+type BucketPermissions struct {
+	granted map[string]bool
+}
+
+// NewBucketPermissions returns permissions granting exactly the given ones.
+func NewBucketPermissions(granted []string) *BucketPermissions {
+	p := &BucketPermissions{granted: make(map[string]bool)}
+	for _, g := range granted {
+		p.granted[g] = true
+	}
+
+	return p
+}
+
+// ProbePermissions asks the bucket which of gcs.ObjectPermissions the caller
+// holds. It returns nil permissions and no error if the bucket can't report
+// them.
+func ProbePermissions(ctx context.Context, b gcs.Bucket) (*BucketPermissions, error) {
+	tester, ok := b.(gcs.PermissionTester)
+	if !ok {
+		return nil, nil
+	}
+
+	granted, err := tester.TestPermissions(ctx, gcs.ObjectPermissions)
+	if err != nil {
+		return nil, fmt.Errorf("TestPermissions: %w", err)
+	}
+
+	return NewBucketPermissions(granted), nil
+}
+
+// Has returns true if the permission is held, or the permissions are unknown.
+func (p *BucketPermissions) Has(permission string) bool {
+	return p == nil || p.granted[permission]
+}
+
+// ReadOnly returns true if no permission that modifies objects is held.
+func (p *BucketPermissions) ReadOnly() bool {
+	return !p.Has(gcs.PermissionObjectsCreate) &&
+		!p.Has(gcs.PermissionObjectsDelete) &&
+		!p.Has(gcs.PermissionObjectsUpdate)
+}
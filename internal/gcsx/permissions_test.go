@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPermissionsBucket(granted []string) gcs.Bucket {
+	return fake.NewFakeBucketWithOptions(timeutil.RealClock(), "some_bucket", gcs.BucketType{}, fake.BucketOptions{
+		GrantedPermissions: granted,
+	})
+}
+
+func TestProbePermissions_AllGranted(t *testing.T) {
+	perms, err := ProbePermissions(context.Background(), newPermissionsBucket(nil))
+
+	require.NoError(t, err)
+	for _, p := range gcs.ObjectPermissions {
+		assert.True(t, perms.Has(p), p)
+	}
+	assert.False(t, perms.ReadOnly())
+}
+
+func TestProbePermissions_ReadOnly(t *testing.T) {
+	b := newPermissionsBucket([]string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList})
+
+	perms, err := ProbePermissions(context.Background(), b)
+
+	require.NoError(t, err)
+	assert.True(t, perms.Has(gcs.PermissionObjectsGet))
+	assert.False(t, perms.Has(gcs.PermissionObjectsCreate))
+	assert.True(t, perms.ReadOnly())
+}
+
+func TestProbePermissions_CreateOnlyIsNotReadOnly(t *testing.T) {
+	b := newPermissionsBucket([]string{gcs.PermissionObjectsGet, gcs.PermissionObjectsCreate})
+
+	perms, err := ProbePermissions(context.Background(), b)
+
+	require.NoError(t, err)
+	assert.False(t, perms.Has(gcs.PermissionObjectsDelete))
+	assert.False(t, perms.ReadOnly())
+}
+
+func TestProbePermissions_BucketCantTest(t *testing.T) {
+	// Wrappers don't forward TestPermissions.
+	b := NewContentTypeBucket(newPermissionsBucket(nil))
+
+	perms, err := ProbePermissions(context.Background(), b)
+
+	require.NoError(t, err)
+	assert.Nil(t, perms)
+	assert.True(t, perms.Has(gcs.PermissionObjectsDelete))
+	assert.False(t, perms.ReadOnly())
+}
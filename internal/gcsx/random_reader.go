@@ -29,8 +29,10 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	cacheutil "github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/util"
 	"github.com/jacobsa/fuse/fuseops"
 	"golang.org/x/net/context"
@@ -111,7 +113,7 @@ const (
 
 // NewRandomReader create a random reader for the supplied object record that
 // reads using the given bucket.
-func NewRandomReader(o *gcs.MinObject, bucket gcs.Bucket, sequentialReadSizeMb int32, fileCacheHandler *file.CacheHandler, cacheFileForRangeRead bool, metricHandle common.MetricHandle, mrdWrapper *MultiRangeDownloaderWrapper) RandomReader {
+func NewRandomReader(o *gcs.MinObject, bucket gcs.Bucket, sequentialReadSizeMb int32, fileCacheHandler *file.CacheHandler, cacheFileForRangeRead bool, metricHandle common.MetricHandle, mrdWrapper *MultiRangeDownloaderWrapper, verifier *integrity.Verifier) RandomReader {
 	return &randomReader{
 		object:                o,
 		bucket:                bucket,
@@ -124,6 +126,7 @@ func NewRandomReader(o *gcs.MinObject, bucket gcs.Bucket, sequentialReadSizeMb i
 		cacheFileForRangeRead: cacheFileForRangeRead,
 		mrdWrapper:            mrdWrapper,
 		metricHandle:          metricHandle,
+		verifier:              verifier,
 	}
 }
 
@@ -176,6 +179,10 @@ type randomReader struct {
 	isMRDInUse bool
 
 	metricHandle common.MetricHandle
+
+	// verifier, if set, checks the object against the integrity manifest before
+	// any of its data is served.
+	verifier *integrity.Verifier
 }
 
 func (rr *randomReader) CheckInvariants() {
@@ -224,12 +231,19 @@ func (rr *randomReader) tryReadingFromFileCache(ctx context.Context,
 
 	// Request log and start the execution timer.
 	requestId := uuid.New()
-	readOp := ctx.Value(ReadOp).(*fuseops.ReadFileOp)
-	logger.Tracef("%.13v <- FileCache(%s:/%s, offset: %d, size: %d handle: %d)", requestId, rr.bucket.Name(), rr.object.Name, offset, len(p), readOp.Handle)
+	// Reads that don't come from the kernel, e.g. in-process ones, have no op.
+	var handle fuseops.HandleID
+	if readOp, ok := ctx.Value(ReadOp).(*fuseops.ReadFileOp); ok {
+		handle = readOp.Handle
+	}
+	logger.FileCache.Tracef("%.13v <- FileCache(%s:/%s, offset: %d, size: %d handle: %d)", requestId, rr.bucket.Name(), rr.object.Name, offset, len(p), handle)
 	startTime := time.Now()
+	ctx, span := tracing.StartSpan(ctx, "filecache.Read", append(tracing.ObjectAttrs(rr.object.Name, rr.object.Generation), tracing.RangeAttrs(offset, int64(len(p)))...)...)
 
 	// Response log
 	defer func() {
+		span.SetAttributes(tracing.CacheHitKey.Bool(cacheHit))
+		tracing.EndSpan(span, err)
 		executionTime := time.Since(startTime)
 		var requestOutput string
 		if err != nil {
@@ -242,7 +256,7 @@ func (rr *randomReader) tryReadingFromFileCache(ctx context.Context,
 		}
 
 		// Here rr.fileCacheHandle will not be nil since we return from the above in those cases.
-		logger.Tracef("%.13v -> %s", requestId, requestOutput)
+		logger.FileCache.Tracef("%.13v -> %s", requestId, requestOutput)
 
 		readType := util.Random
 		if isSeq {
@@ -257,7 +271,7 @@ func (rr *randomReader) tryReadingFromFileCache(ctx context.Context,
 		if err != nil {
 			// We fall back to GCS if file size is greater than the cache size
 			if strings.Contains(err.Error(), lru.InvalidEntrySizeErrorMsg) {
-				logger.Warnf("tryReadingFromFileCache: while creating CacheHandle: %v", err)
+				logger.FileCache.Warnf("tryReadingFromFileCache: while creating CacheHandle: %v", err)
 				return 0, false, nil
 			} else if strings.Contains(err.Error(), cacheutil.CacheHandleNotRequiredForRandomReadErrMsg) {
 				// Fall back to GCS if it is a random read, cacheFileForRangeRead is
@@ -279,10 +293,10 @@ func (rr *randomReader) tryReadingFromFileCache(ctx context.Context,
 	n = 0
 
 	if cacheutil.IsCacheHandleInvalid(err) {
-		logger.Tracef("Closing cacheHandle:%p for object: %s:/%s", rr.fileCacheHandle, rr.bucket.Name(), rr.object.Name)
+		logger.FileCache.Tracef("Closing cacheHandle:%p for object: %s:/%s", rr.fileCacheHandle, rr.bucket.Name(), rr.object.Name)
 		err = rr.fileCacheHandle.Close()
 		if err != nil {
-			logger.Warnf("tryReadingFromFileCache: while closing fileCacheHandle: %v", err)
+			logger.FileCache.Warnf("tryReadingFromFileCache: while closing fileCacheHandle: %v", err)
 		}
 		rr.fileCacheHandle = nil
 	} else if !strings.Contains(err.Error(), cacheutil.FallbackToGCSErrMsg) {
@@ -304,6 +318,39 @@ func captureFileCacheMetrics(ctx context.Context, metricHandle common.MetricHand
 	metricHandle.FileCacheReadLatency(ctx, float64(readLatency.Microseconds()), []common.MetricAttr{{Key: common.CacheHit, Value: strconv.FormatBool(cacheHit)}})
 }
 
+// verify checks the object against the integrity manifest before any of its
+// data is served. With the file cache, the object is verified by the download
+// job as it is cached, so that it is read only once. Otherwise, or if it can't
+// be cached, it is read and verified on its own.
+func (rr *randomReader) verify(ctx context.Context) error {
+	if !rr.verifier.NeedsVerification(rr.object) {
+		return nil
+	}
+
+	if rr.fileCacheHandler != nil {
+		cacheHandle, err := rr.fileCacheHandler.GetCacheHandle(rr.object, rr.bucket, true, 0)
+		if err == nil {
+			err = cacheHandle.WaitForCompletion(ctx)
+			if closeErr := cacheHandle.Close(); closeErr != nil {
+				logger.FileCache.Warnf("verify: while closing cacheHandle: %v", closeErr)
+			}
+		}
+
+		var mismatchErr *integrity.MismatchError
+		if errors.As(err, &mismatchErr) {
+			return err
+		}
+		if err != nil {
+			logger.FileCache.Tracef("verify: falling back to reading %s:/%s: %v", rr.bucket.Name(), rr.object.Name, err)
+		}
+		if !rr.verifier.NeedsVerification(rr.object) {
+			return nil
+		}
+	}
+
+	return rr.verifier.Verify(ctx, rr.bucket, rr.object)
+}
+
 func (rr *randomReader) ReadAt(
 	ctx context.Context,
 	p []byte,
@@ -319,6 +366,11 @@ func (rr *randomReader) ReadAt(
 		return
 	}
 
+	if err = rr.verify(ctx); err != nil {
+		err = fmt.Errorf("ReadAt: %w", err)
+		return
+	}
+
 	// Note: If we are reading the file for the first time and read type is sequential
 	// then the file cache behavior is write-through i.e. data is first read from
 	// GCS, cached in file and then served from that file. But the cacheHit is
@@ -415,10 +467,10 @@ func (rr *randomReader) Destroy() {
 	}
 
 	if rr.fileCacheHandle != nil {
-		logger.Tracef("Closing cacheHandle:%p for object: %s:/%s", rr.fileCacheHandle, rr.bucket.Name(), rr.object.Name)
+		logger.FileCache.Tracef("Closing cacheHandle:%p for object: %s:/%s", rr.fileCacheHandle, rr.bucket.Name(), rr.object.Name)
 		err := rr.fileCacheHandle.Close()
 		if err != nil {
-			logger.Warnf("rr.Destroy(): while closing cacheFileHandle: %v", err)
+			logger.FileCache.Warnf("rr.Destroy(): while closing cacheFileHandle: %v", err)
 		}
 		rr.fileCacheHandle = nil
 	}
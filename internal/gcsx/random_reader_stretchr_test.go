@@ -15,7 +15,10 @@
 package gcsx
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -32,6 +35,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/clock"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
@@ -75,11 +79,11 @@ func (t *RandomReaderStretchrTest) SetupTest() {
 	lruCache := lru.NewCache(CacheMaxSize)
 	t.jobManager = downloader.NewJobManager(lruCache, util.DefaultFilePerm, util.DefaultDirPerm, t.cacheDir, sequentialReadSizeInMb, &cfg.FileCacheConfig{
 		EnableCrc: false,
-	}, nil)
-	t.cacheHandler = file.NewCacheHandler(lruCache, t.jobManager, t.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm)
+	}, common.NewNoopMetrics(), nil, nil)
+	t.cacheHandler = file.NewCacheHandler(lruCache, t.jobManager, t.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, nil)
 
 	// Set up the reader.
-	rr := NewRandomReader(t.object, t.mockBucket, sequentialReadSizeInMb, nil, false, common.NewNoopMetrics(), nil)
+	rr := NewRandomReader(t.object, t.mockBucket, sequentialReadSizeInMb, nil, false, common.NewNoopMetrics(), nil, nil)
 	t.rr.wrapped = rr.(*randomReader)
 }
 
@@ -779,3 +783,19 @@ func (t *RandomReaderStretchrTest) Test_ReadFromMultiRangeReader_ValidateTimeout
 		})
 	}
 }
+
+func (t *RandomReaderStretchrTest) Test_ReadAt_IntegrityMismatch() {
+	objectContent := []byte("tampered contents")
+	sum := sha256.Sum256([]byte("released contents"))
+	t.rr.wrapped.verifier = integrity.NewVerifier(&integrity.Manifest{Objects: map[string]integrity.Entry{
+		t.object.Name: {SHA256: hex.EncodeToString(sum[:])},
+	}}, nil)
+	t.mockBucket.On("NewReader", mock.Anything, &gcs.ReadObjectRequest{Name: t.object.Name, Generation: t.object.Generation}).
+		Return(io.NopCloser(bytes.NewReader(objectContent)), nil).Once()
+
+	_, err := t.rr.ReadAt(make([]byte, 5), 0)
+
+	var mismatchErr *integrity.MismatchError
+	assert.ErrorAs(t.T(), err, &mismatchErr)
+	t.mockBucket.AssertExpectations(t.T())
+}
@@ -16,6 +16,8 @@ package gcsx
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -34,6 +36,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/fs/gcsfuse_errors"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/integrity"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
@@ -182,11 +185,11 @@ func (t *RandomReaderTest) SetUp(ti *TestInfo) {
 	lruCache := lru.NewCache(CacheMaxSize)
 	t.jobManager = downloader.NewJobManager(lruCache, util.DefaultFilePerm, util.DefaultDirPerm, t.cacheDir, sequentialReadSizeInMb, &cfg.FileCacheConfig{
 		EnableCrc: false,
-	}, common.NewNoopMetrics())
-	t.cacheHandler = file.NewCacheHandler(lruCache, t.jobManager, t.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm)
+	}, common.NewNoopMetrics(), nil, nil)
+	t.cacheHandler = file.NewCacheHandler(lruCache, t.jobManager, t.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, nil)
 
 	// Set up the reader.
-	rr := NewRandomReader(t.object, t.bucket, sequentialReadSizeInMb, nil, false, common.NewNoopMetrics(), nil)
+	rr := NewRandomReader(t.object, t.bucket, sequentialReadSizeInMb, nil, false, common.NewNoopMetrics(), nil, nil)
 	t.rr.wrapped = rr.(*randomReader)
 }
 
@@ -548,7 +551,7 @@ func (t *RandomReaderTest) UpgradesSequentialReads_NoExistingReader() {
 	t.object.Size = 1 << 40
 	const readSize = 1 * MB
 	// Set up the custom randomReader.
-	rr := NewRandomReader(t.object, t.bucket, readSize/MB, nil, false, common.NewNoopMetrics(), nil)
+	rr := NewRandomReader(t.object, t.bucket, readSize/MB, nil, false, common.NewNoopMetrics(), nil, nil)
 	t.rr.wrapped = rr.(*randomReader)
 
 	// Simulate a previous exhausted reader that ended at the offset from which
@@ -601,6 +604,52 @@ func (t *RandomReaderTest) Test_ReadAt_SequentialFullObject() {
 	ExpectTrue(reflect.DeepEqual(testContent, buf))
 }
 
+// useVerifyingFileCache sets up the reader with a file cache whose download
+// jobs check the object against a manifest entry for the given contents.
+func (t *RandomReaderTest) useVerifyingFileCache(released []byte) {
+	sum := sha256.Sum256(released)
+	verifier := integrity.NewVerifier(&integrity.Manifest{Objects: map[string]integrity.Entry{
+		t.object.Name: {SHA256: hex.EncodeToString(sum[:])},
+	}}, nil)
+	lruCache := lru.NewCache(CacheMaxSize)
+	jobManager := downloader.NewJobManager(lruCache, util.DefaultFilePerm, util.DefaultDirPerm, t.cacheDir, sequentialReadSizeInMb, &cfg.FileCacheConfig{
+		EnableCrc: false,
+	}, common.NewNoopMetrics(), nil, verifier)
+	t.rr.wrapped.fileCacheHandler = file.NewCacheHandler(lruCache, jobManager, t.cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, nil)
+	t.rr.wrapped.verifier = verifier
+}
+
+func (t *RandomReaderTest) Test_ReadAt_VerifiedByFileCacheDownload() {
+	testContent := testutil.GenerateRandomBytes(int(t.object.Size))
+	t.useVerifyingFileCache(testContent)
+	// The object is read once, by the download job, and never by NewReader.
+	rd := &fake.FakeReader{ReadCloser: getReadCloser(testContent)}
+	ExpectCall(t.bucket, "NewReaderWithReadHandle")(
+		Any(), AllOf(rangeStartIs(0), rangeLimitIs(t.object.Size))).
+		WillOnce(Return(rd, nil))
+	ExpectCall(t.bucket, "Name")().WillRepeatedly(Return("test"))
+	buf := make([]byte, t.object.Size)
+
+	objectData, err := t.rr.ReadAt(buf, 0)
+
+	AssertEq(nil, err)
+	ExpectTrue(objectData.CacheHit)
+	ExpectTrue(reflect.DeepEqual(testContent, buf))
+	ExpectFalse(t.rr.wrapped.verifier.NeedsVerification(t.object))
+}
+
+func (t *RandomReaderTest) Test_ReadAt_FileCacheDownloadMismatch() {
+	t.useVerifyingFileCache([]byte("released contents"))
+	rd := &fake.FakeReader{ReadCloser: getReadCloser([]byte("tampered contents"))}
+	t.mockNewReaderWithHandleCallForTestBucket(0, t.object.Size, rd)
+	ExpectCall(t.bucket, "Name")().WillRepeatedly(Return("test"))
+
+	_, err := t.rr.ReadAt(make([]byte, 5), 0)
+
+	var mismatchErr *integrity.MismatchError
+	ExpectTrue(errors.As(err, &mismatchErr), "err: %v", err)
+}
+
 func (t *RandomReaderTest) Test_ReadAt_SequentialRangeRead() {
 	t.rr.wrapped.fileCacheHandler = t.cacheHandler
 	objectSize := t.object.Size
@@ -0,0 +1,39 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import "github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+
+// ServedBucket is a bucket that a file system serves in place of a GCS bucket,
+// as accepted by the public filesystem package. It hides the bucket, whose
+// requests and objects are of internal types, from callers outside this
+// module.
+type ServedBucket struct {
+	b gcs.Bucket
+}
+
+func NewServedBucket(b gcs.Bucket) *ServedBucket {
+	return &ServedBucket{b: b}
+}
+
+// The name of the bucket.
+func (sb *ServedBucket) Name() string {
+	return sb.b.Name()
+}
+
+// BackingBucket returns the bucket that sb serves.
+func BackingBucket(sb *ServedBucket) gcs.Bucket {
+	return sb.b
+}
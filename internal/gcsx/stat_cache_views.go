@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"sync"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/metadata"
+)
+
+// StatCacheViews tracks the views of the shared stat cache that a bucket's
+// lookups go through, one for the mount's credentials and one for each user
+// with per-user credentials, so that entries can be invalidated in all of
+// them. A nil *StatCacheViews has no views.
+//
+// Safe for concurrent access.
+type StatCacheViews struct {
+	mu    sync.Mutex
+	views []metadata.StatCache
+}
+
+func (v *StatCacheViews) add(view metadata.StatCache) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.views = append(v.views, view)
+}
+
+// Invalidate erases the entry for the object or folder with the given name
+// from every view, or with prefix set, the entries for every name starting
+// with it.
+func (v *StatCacheViews) Invalidate(name string, prefix bool) {
+	if v == nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, view := range v.views {
+		if prefix {
+			view.EraseEntriesWithGivenPrefix(name)
+		} else {
+			view.Erase(name)
+		}
+	}
+}
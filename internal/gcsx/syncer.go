@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"golang.org/x/net/context"
 )
 
@@ -154,6 +155,14 @@ func (os *syncer) SyncObject(
 	objectName string,
 	srcObject *gcs.Object,
 	content TempFile) (o *gcs.Object, err error) {
+	ctx, span := tracing.StartSpan(ctx, "gcsx.SyncObject", tracing.ObjectKey.String(objectName))
+	defer func() {
+		if o != nil {
+			span.SetAttributes(tracing.GenerationKey.Int64(o.Generation))
+		}
+		tracing.EndSpan(span, err)
+	}()
+
 	// Stat the content.
 	sr, err := content.Stat()
 	if err != nil {
@@ -171,6 +180,7 @@ func (os *syncer) SyncObject(
 			err = fmt.Errorf("error in seeking: %w", err)
 			return
 		}
+		span.SetAttributes(tracing.SyncStrategyKey.String("full"))
 		return os.fullCreator.Create(ctx, objectName, srcObject, sr.Mtime, os.chunkTransferTimeoutSecs, content)
 	}
 
@@ -189,6 +199,7 @@ func (os *syncer) SyncObject(
 	// object, and no bytes within the source object have been dirtied), we're
 	// done.
 	if sr.Size == srcSize && sr.DirtyThreshold == srcSize {
+		span.SetAttributes(tracing.SyncStrategyKey.String("none"))
 		return
 	}
 
@@ -211,6 +222,7 @@ func (os *syncer) SyncObject(
 			return
 		}
 
+		span.SetAttributes(tracing.SyncStrategyKey.String("compose"))
 		o, err = os.composeCreator.Create(ctx, objectName, srcObject, sr.Mtime, os.chunkTransferTimeoutSecs, content)
 	} else {
 		_, err = content.Seek(0, 0)
@@ -219,6 +231,7 @@ func (os *syncer) SyncObject(
 			return
 		}
 
+		span.SetAttributes(tracing.SyncStrategyKey.String("full"))
 		o, err = os.fullCreator.Create(ctx, objectName, srcObject, sr.Mtime, os.chunkTransferTimeoutSecs, content)
 	}
 
@@ -21,6 +21,14 @@ import (
 type SyncerBucket struct {
 	gcs.Bucket
 	Syncer
+
+	// The permissions the mount's credentials hold on the bucket, or nil if
+	// they weren't probed.
+	Permissions *BucketPermissions
+
+	// The views of the stat cache that lookups in the bucket go through, or nil
+	// if the bucket isn't stat cached.
+	StatCache *StatCacheViews
 }
 
 // NewSyncerBucket creates a SyncerBucket, which can be used either as
@@ -32,5 +40,5 @@ func NewSyncerBucket(
 	bucket gcs.Bucket,
 ) SyncerBucket {
 	syncer := NewSyncer(appendThreshold, chunkTransferTimeoutSecs, tmpObjectPrefix, bucket)
-	return SyncerBucket{bucket, syncer}
+	return SyncerBucket{Bucket: bucket, Syncer: syncer}
 }
@@ -21,6 +21,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/diskcrypt"
 	"github.com/jacobsa/fuse/fsutil"
 	"github.com/jacobsa/timeutil"
 )
@@ -105,7 +106,7 @@ func NewTempFile(
 // or the system default temporary location if empty.
 func NewCacheFile(
 	source io.ReadCloser,
-	f *os.File,
+	f diskcrypt.File,
 	dir string,
 	clock timeutil.Clock) (tf TempFile) {
 
@@ -166,7 +167,7 @@ type tempFile struct {
 	state fileState
 
 	// A file containing our current contents.
-	f *os.File
+	f diskcrypt.File
 
 	// The lowest byte index that has been modified from the initial contents.
 	//
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"strings"
+	"time"
+)
+
+// TrashPrefix is the prefix of the objects that removed files are moved to
+// when the trash is enabled. The object behind a file removed at time t is
+// moved to TrashPrefix + "<t>/" + its original name.
+const TrashPrefix = ".trash/"
+
+// The format of the timestamp component of trashed object names. It has a
+// resolution of one second, so that the files removed together, e.g. by one
+// "rm -r", are grouped under few directories.
+const trashTimeLayout = "20060102T150405Z"
+
+// The format of the timestamp component of the names given by
+// UniqueTrashObjectName. Names in either format parse with trashTimeLayout.
+const uniqueTrashTimeLayout = "20060102T150405.000000000Z"
+
+// TrashObjectName returns the name that the object with the given name is
+// moved to when it is removed at time t.
+func TrashObjectName(t time.Time, name string) string {
+	return TrashPrefix + t.UTC().Format(trashTimeLayout) + "/" + name
+}
+
+// UniqueTrashObjectName is like TrashObjectName, with a timestamp to the
+// nanosecond, for when another removal of the same path in the same second took
+// the name given by TrashObjectName.
+func UniqueTrashObjectName(t time.Time, name string) string {
+	return TrashPrefix + t.UTC().Format(uniqueTrashTimeLayout) + "/" + name
+}
+
+// IsTrashObjectName returns true if the object name is the trash directory
+// itself or anything below it.
+func IsTrashObjectName(name string) bool {
+	return strings.HasPrefix(name, TrashPrefix)
+}
+
+// trashTime returns the time at which the object with the given trashed name
+// was removed, or false if the name isn't a well-formed trashed name.
+func trashTime(name string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(name, TrashPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	stamp, _, ok := strings.Cut(rest, "/")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(trashTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcsx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashObjectName(t *testing.T) {
+	removed := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.FixedZone("X", 3600))
+
+	name := TrashObjectName(removed, "a/b/c.txt")
+
+	assert.Equal(t, ".trash/20250102T020405Z/a/b/c.txt", name)
+	assert.True(t, IsTrashObjectName(name))
+	got, ok := trashTime(name)
+	assert.True(t, ok)
+	assert.True(t, removed.Truncate(time.Second).Equal(got))
+}
+
+func TestTrashObjectName_SameSecond(t *testing.T) {
+	removed := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	first := TrashObjectName(removed, "a")
+	second := TrashObjectName(removed.Add(time.Millisecond), "b")
+
+	assert.Equal(t, ".trash/20250102T030405Z/a", first)
+	assert.Equal(t, ".trash/20250102T030405Z/b", second)
+}
+
+func TestUniqueTrashObjectName(t *testing.T) {
+	removed := time.Date(2025, 1, 2, 3, 4, 5, 6000, time.FixedZone("X", 3600))
+
+	name := UniqueTrashObjectName(removed, "a/b/c.txt")
+
+	assert.Equal(t, ".trash/20250102T020405.000006000Z/a/b/c.txt", name)
+	assert.NotEqual(t, TrashObjectName(removed, "a/b/c.txt"), name)
+	got, ok := trashTime(name)
+	assert.True(t, ok)
+	assert.True(t, removed.Equal(got))
+}
+
+func TestTrashTime_Malformed(t *testing.T) {
+	for _, name := range []string{
+		"a/b",
+		".trash/",
+		".trash/foo",
+		".trash/not-a-time/a",
+	} {
+		_, ok := trashTime(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestGarbageCollectTrashOnce(t *testing.T) {
+	ctx := context.Background()
+	bucket := fake.NewFakeBucket(timeutil.RealClock(), "some_bucket", gcs.BucketType{})
+	now := time.Now()
+	for _, name := range []string{
+		TrashPrefix,
+		TrashObjectName(now.Add(-2*time.Hour), "old"),
+		TrashObjectName(now, "new"),
+		".trash/unknown",
+		"outside",
+	} {
+		_, err := storageutil.CreateObject(ctx, bucket, name, []byte("taco"))
+		require.NoError(t, err)
+	}
+
+	deleted, err := garbageCollectTrashOnce(ctx, time.Hour, bucket)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+	objects, _, err := storageutil.ListAll(ctx, bucket, &gcs.ListObjectsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.Name)
+	}
+	assert.ElementsMatch(t, []string{TrashPrefix, TrashObjectName(now, "new"), ".trash/unknown", "outside"}, names)
+}
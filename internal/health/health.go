@@ -0,0 +1,353 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health reports whether a mount is live, i.e. its file system still
+// serves ops, and ready, i.e. its buckets are reachable and its ops mostly
+// succeed without stalling, for the probes of orchestrators that run gcsfuse
+// as a sidecar.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// The ops of the last window are counted for the error and stall rates.
+	window = time.Minute
+
+	// The number of ops in the window below which the rates aren't checked,
+	// since a few failures of a mostly idle mount don't say much.
+	minOps = 20
+)
+
+// Config is what a Monitor checks against.
+type Config struct {
+	// How long to wait for the liveness op and for each bucket probe.
+	ProbeTimeout time.Duration
+
+	// How long a successful bucket probe counts for.
+	BucketProbeInterval time.Duration
+
+	// Ops taking longer than StallThreshold count as stalled. The mount isn't
+	// ready while more than MaxErrorRate of the ops in the window failed or
+	// more than MaxStallRate stalled.
+	StallThreshold time.Duration
+	MaxErrorRate   float64
+	MaxStallRate   float64
+}
+
+// Check is the outcome of one of the checks of a Status.
+type Check struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Status is the outcome of the liveness or readiness checks.
+type Status struct {
+	OK     bool    `json:"ok"`
+	Checks []Check `json:"checks"`
+}
+
+func newStatus(checks ...Check) Status {
+	s := Status{OK: true, Checks: checks}
+	for _, c := range checks {
+		s.OK = s.OK && c.OK
+	}
+	return s
+}
+
+// A bucket and the outcome of its latest probe.
+type bucketProbe struct {
+	probe func(ctx context.Context) error
+
+	// GUARDED_BY(Monitor.mu)
+	probed  time.Time
+	err     error
+	running bool
+}
+
+// A liveness op in progress, shared by the checks made while it runs.
+type liveOp struct {
+	start time.Time
+	done  chan struct{}
+	err   error
+}
+
+// The ops that finished within a second.
+type opCounts struct {
+	second              int64
+	ops, errors, stalls int
+}
+
+// Monitor checks the liveness and readiness of a mount. The file system
+// reports its ops to RecordOp, the bucket manager the buckets it sets up to
+// AddBucket, and the mount its root op to SetLivenessProbe and its completion
+// to SetMounted.
+type Monitor struct {
+	config Config
+	now    func() time.Time
+
+	mu sync.Mutex
+	// GUARDED_BY(mu)
+	mounted bool
+	live    func(ctx context.Context) error
+	liveOp  *liveOp
+	buckets map[string]*bucketProbe
+	counts  [window / time.Second]opCounts
+}
+
+// NewMonitor returns a monitor that checks against the given config.
+func NewMonitor(config Config) *Monitor {
+	return &Monitor{
+		config:  config,
+		now:     time.Now,
+		buckets: make(map[string]*bucketProbe),
+	}
+}
+
+// SetMounted records that the file system has been mounted, before which the
+// mount isn't ready.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) SetMounted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mounted = true
+}
+
+// SetLivenessProbe sets the op made on the root of the file system to check
+// that it's live. Until it's set, the mount is live.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) SetLivenessProbe(probe func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.live = probe
+}
+
+// MountPointProbe returns a liveness probe that calls statfs on the given
+// mount point. Unlike the attributes of the root, which the kernel caches, the
+// kernel sends every statfs through the fuse connection to the file system, so
+// the probe doesn't finish while the mount is hung.
+func MountPointProbe(mountPoint string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(mountPoint, &st); err != nil {
+			return fmt.Errorf("statfs %s: %w", mountPoint, err)
+		}
+		return nil
+	}
+}
+
+// AddBucket records a bucket that has been set up, with the outcome of the
+// probe made while setting it up. The probe is made again by readiness checks
+// once the outcome is older than the bucket probe interval.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) AddBucket(name string, probe func(ctx context.Context) error, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buckets[name] = &bucketProbe{probe: probe, probed: m.now(), err: err}
+}
+
+// RecordOp records an op that took the given time, and whether it failed with
+// an error that signals a problem with the mount rather than with the op.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) RecordOp(latency time.Duration, failed bool) {
+	second := m.now().Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := &m.counts[second%int64(len(m.counts))]
+	if c.second != second {
+		*c = opCounts{second: second}
+	}
+	c.ops++
+	if failed {
+		c.errors++
+	}
+	if latency >= m.config.StallThreshold {
+		c.stalls++
+	}
+}
+
+// Liveness checks that an op on the root of the file system finishes within
+// the probe timeout. An op that doesn't is shared by later checks until it
+// finishes, so a stuck file system doesn't pile up ops.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) Liveness(ctx context.Context) Status {
+	m.mu.Lock()
+	if m.live == nil {
+		m.mu.Unlock()
+		return newStatus(Check{Name: "root-op", OK: true, Detail: "not mounted yet"})
+	}
+	op := m.liveOp
+	if op == nil {
+		op = &liveOp{start: m.now(), done: make(chan struct{})}
+		m.liveOp = op
+		go m.runLiveOp(op, m.live)
+	}
+	m.mu.Unlock()
+
+	timer := time.NewTimer(op.start.Add(m.config.ProbeTimeout).Sub(m.now()))
+	defer timer.Stop()
+
+	select {
+	case <-op.done:
+		if op.err != nil {
+			return newStatus(Check{Name: "root-op", Detail: op.err.Error()})
+		}
+		return newStatus(Check{Name: "root-op", OK: true})
+	case <-timer.C:
+		return newStatus(Check{Name: "root-op", Detail: fmt.Sprintf("running for %v", m.now().Sub(op.start).Round(time.Millisecond))})
+	case <-ctx.Done():
+		return newStatus(Check{Name: "root-op", Detail: ctx.Err().Error()})
+	}
+}
+
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) runLiveOp(op *liveOp, live func(ctx context.Context) error) {
+	op.err = live(context.Background())
+
+	m.mu.Lock()
+	m.liveOp = nil
+	m.mu.Unlock()
+	close(op.done)
+}
+
+// Readiness checks that the file system has been mounted, that the latest
+// probes of its buckets succeeded, probing again those older than the bucket
+// probe interval, and that the error and stall rates of the ops in the last
+// minute are within their maximums.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) Readiness(ctx context.Context) Status {
+	m.reprobeBuckets(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checks := []Check{{Name: "mounted", OK: m.mounted}}
+
+	names := make([]string, 0, len(m.buckets))
+	for name := range m.buckets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		b := m.buckets[name]
+		c := Check{Name: "bucket:" + name, OK: b.err == nil}
+		if b.err != nil {
+			c.Detail = b.err.Error()
+		} else {
+			c.Detail = fmt.Sprintf("listed %v ago", m.now().Sub(b.probed).Round(time.Second))
+		}
+		checks = append(checks, c)
+	}
+
+	var total opCounts
+	since := m.now().Add(-window).Unix()
+	for _, c := range m.counts {
+		if c.second > since {
+			total.ops += c.ops
+			total.errors += c.errors
+			total.stalls += c.stalls
+		}
+	}
+	checks = append(checks,
+		rateCheck("error-rate", total.errors, total.ops, m.config.MaxErrorRate),
+		rateCheck("stall-rate", total.stalls, total.ops, m.config.MaxStallRate))
+
+	return newStatus(checks...)
+}
+
+func rateCheck(name string, n, ops int, max float64) Check {
+	rate := 0.0
+	if ops > 0 {
+		rate = float64(n) / float64(ops)
+	}
+	return Check{
+		Name:   name,
+		OK:     ops < minOps || rate <= max,
+		Detail: fmt.Sprintf("%d of %d ops in the last %v (%.2f, max %.2f)", n, ops, window, rate, max),
+	}
+}
+
+// Probe the buckets whose latest probe is older than the interval and isn't
+// running, in parallel.
+//
+// LOCKS_EXCLUDED(m.mu)
+func (m *Monitor) reprobeBuckets(ctx context.Context) {
+	m.mu.Lock()
+	var stale []*bucketProbe
+	for _, b := range m.buckets {
+		if !b.running && m.now().Sub(b.probed) >= m.config.BucketProbeInterval {
+			b.running = true
+			stale = append(stale, b)
+		}
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, b := range stale {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// The outcome is kept for later checks, so it mustn't depend on
+			// whether this one was abandoned.
+			probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.config.ProbeTimeout)
+			defer cancel()
+			err := b.probe(probeCtx)
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			b.running = false
+			b.probed = m.now()
+			b.err = err
+		}()
+	}
+	wg.Wait()
+}
+
+// Handler serves the liveness at /livez and the readiness at /readyz as JSON,
+// with the status 200 if they're OK and 503 otherwise.
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /livez", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, m.Liveness(r.Context()))
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, m.Readiness(r.Context()))
+	})
+	return mux
+}
+
+func writeStatus(w http.ResponseWriter, s Status) {
+	w.Header().Set("Content-Type", "application/json")
+	if !s.OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(s)
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMonitor(t *testing.T) (*Monitor, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMonitor(Config{
+		ProbeTimeout:        50 * time.Millisecond,
+		BucketProbeInterval: 30 * time.Second,
+		StallThreshold:      10 * time.Second,
+		MaxErrorRate:        0.1,
+		MaxStallRate:        0.1,
+	})
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestLiveness(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	assert.True(t, m.Liveness(context.Background()).OK)
+
+	m.SetLivenessProbe(func(context.Context) error { return nil })
+	assert.True(t, m.Liveness(context.Background()).OK)
+
+	m.SetLivenessProbe(func(context.Context) error { return errors.New("taco") })
+	s := m.Liveness(context.Background())
+	assert.False(t, s.OK)
+	assert.Equal(t, []Check{{Name: "root-op", Detail: "taco"}}, s.Checks)
+}
+
+func TestLiveness_StuckOpIsShared(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	m.now = time.Now
+	release := make(chan struct{})
+	var calls atomic.Int32
+	m.SetLivenessProbe(func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	})
+
+	assert.False(t, m.Liveness(context.Background()).OK)
+	assert.False(t, m.Liveness(context.Background()).OK)
+	assert.Equal(t, int32(1), calls.Load())
+
+	close(release)
+	assert.Eventually(t, func() bool { return m.Liveness(context.Background()).OK }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestReadiness_MountedAndBuckets(t *testing.T) {
+	m, now := newTestMonitor(t)
+	var probes int
+	probeErr := errors.New("permission denied")
+	m.AddBucket("b", func(context.Context) error {
+		probes++
+		return probeErr
+	}, nil)
+
+	assert.False(t, m.Readiness(context.Background()).OK)
+
+	m.SetMounted()
+	s := m.Readiness(context.Background())
+	assert.True(t, s.OK)
+	assert.Equal(t, Check{Name: "bucket:b", OK: true, Detail: "listed 0s ago"}, s.Checks[1])
+	assert.Zero(t, probes)
+
+	// Once the probe is stale, it's made again.
+	*now = now.Add(time.Minute)
+	s = m.Readiness(context.Background())
+	assert.False(t, s.OK)
+	assert.Equal(t, Check{Name: "bucket:b", Detail: "permission denied"}, s.Checks[1])
+	assert.Equal(t, 1, probes)
+}
+
+func TestReadiness_Rates(t *testing.T) {
+	m, now := newTestMonitor(t)
+	m.SetMounted()
+
+	// Too few ops to judge.
+	for range 5 {
+		m.RecordOp(time.Millisecond, true)
+	}
+	assert.True(t, m.Readiness(context.Background()).OK)
+
+	for range 20 {
+		m.RecordOp(time.Millisecond, false)
+	}
+	m.RecordOp(time.Minute, false)
+	s := m.Readiness(context.Background())
+	assert.False(t, s.OK)
+	assert.Equal(t, Check{Name: "error-rate", Detail: "5 of 26 ops in the last 1m0s (0.19, max 0.10)"}, s.Checks[1])
+	assert.Equal(t, Check{Name: "stall-rate", OK: true, Detail: "1 of 26 ops in the last 1m0s (0.04, max 0.10)"}, s.Checks[2])
+
+	// The ops fall out of the window.
+	*now = now.Add(2 * time.Minute)
+	assert.True(t, m.Readiness(context.Background()).OK)
+}
+
+func TestHandler(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	server := httptest.NewServer(m.Handler())
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/livez")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/readyz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	var s Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&s))
+	assert.False(t, s.OK)
+	assert.Equal(t, Check{Name: "mounted"}, s.Checks[0])
+}
+
+func TestMountPointProbe(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, MountPointProbe(dir)(context.Background()))
+	assert.Error(t, MountPointProbe(filepath.Join(dir, "missing"))(context.Background()))
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inflight tracks the file system ops in progress and the GCS requests
+// made for them, so that stalls can be diagnosed while they happen.
+package inflight
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Request is a GCS request in progress.
+type Request struct {
+	ID    uint64
+	Desc  string
+	Start time.Time
+}
+
+// Op is a file system op in progress.
+type Op struct {
+	Name  string
+	Start time.Time
+
+	mu sync.Mutex
+	// GUARDED_BY(mu)
+	requests map[uint64]Request
+}
+
+// NewOp returns an op started now.
+func NewOp(name string) *Op {
+	return &Op{Name: name, Start: time.Now(), requests: make(map[uint64]Request)}
+}
+
+// Requests returns the GCS requests in progress for the op, oldest first.
+func (op *Op) Requests() []Request {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	reqs := make([]Request, 0, len(op.requests))
+	for _, r := range op.requests {
+		reqs = append(reqs, r)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].Start.Before(reqs[j].Start) })
+	return reqs
+}
+
+type opKey struct{}
+
+// WithOp returns a context in which the GCS requests are tracked for op.
+func WithOp(ctx context.Context, op *Op) context.Context {
+	return context.WithValue(ctx, opKey{}, op)
+}
+
+// BeginRequest records that the request with the given ID, unique among the
+// requests in progress for the op in ctx, was sent. It is a no-op if ctx has
+// no op.
+func BeginRequest(ctx context.Context, id uint64, desc string) {
+	if op, ok := ctx.Value(opKey{}).(*Op); ok {
+		op.mu.Lock()
+		op.requests[id] = Request{ID: id, Desc: desc, Start: time.Now()}
+		op.mu.Unlock()
+	}
+}
+
+// EndRequest records that the request with the given ID finished.
+func EndRequest(ctx context.Context, id uint64) {
+	if op, ok := ctx.Value(opKey{}).(*Op); ok {
+		op.mu.Lock()
+		delete(op.requests, id)
+		op.mu.Unlock()
+	}
+}
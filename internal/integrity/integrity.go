@@ -0,0 +1,333 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integrity verifies the contents of objects against a signed
+// manifest of their checksums, so that the data served through the mount is
+// known to be what a trusted pipeline produced.
+//
+// The manifest is a JSON document mapping object names to the SHA-256 and/or
+// CRC32C of their contents:
+//
+//	{"objects": {"model/weights.bin": {"sha256": "<hex>", "crc32c": 1234}}}
+//
+// It is signed with Ed25519, and the signature is checked against a trusted
+// public key when the manifest is loaded.
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/audit"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"golang.org/x/sync/singleflight"
+)
+
+// Entry holds the expected checksums of an object's contents. A field that is
+// unset isn't checked.
+type Entry struct {
+	SHA256 string  `json:"sha256,omitempty"`
+	CRC32C *uint32 `json:"crc32c,omitempty"`
+}
+
+// Manifest maps object names to the expected checksums of their contents.
+type Manifest struct {
+	Objects map[string]Entry `json:"objects"`
+}
+
+// LoadManifest reads the manifest at manifestPath and checks it against the
+// detached Ed25519 signature at signaturePath, made with the private key of
+// the PEM-encoded public key at publicKeyPath. The signature may be raw or
+// base64-encoded.
+func LoadManifest(manifestPath, signaturePath, publicKeyPath string) (*Manifest, error) {
+	contents, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	sig, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+
+	publicKey, err := readPublicKey(publicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			return nil, fmt.Errorf("signature is neither raw nor base64: %w", err)
+		}
+		sig = decoded
+	}
+
+	if !ed25519.Verify(publicKey, contents, sig) {
+		return nil, errors.New("manifest signature is not valid")
+	}
+
+	m := &Manifest{}
+	if err = json.Unmarshal(contents, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	for name, e := range m.Objects {
+		if e.SHA256 == "" && e.CRC32C == nil {
+			return nil, fmt.Errorf("manifest entry for %q has no checksum", name)
+		}
+		if e.SHA256 != "" {
+			if b, err := hex.DecodeString(e.SHA256); err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("manifest entry for %q has a malformed sha256", name)
+			}
+			e.SHA256 = strings.ToLower(e.SHA256)
+			m.Objects[name] = e
+		}
+	}
+
+	return m, nil
+}
+
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, errors.New("public key is not PEM-encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T, not Ed25519", key)
+	}
+
+	return publicKey, nil
+}
+
+// MismatchError is returned when the contents of an object don't match its
+// manifest entry.
+type MismatchError struct {
+	Name       string
+	Generation int64
+	Checksum   string
+	Expected   string
+	Actual     string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("integrity: %s of object %q (generation %d) is %s, expected %s", e.Checksum, e.Name, e.Generation, e.Actual, e.Expected)
+}
+
+// Verifier checks objects against a manifest, remembering the generations
+// that have been verified so each is only checked once. A nil *Verifier
+// accepts every object.
+//
+// Safe for concurrent access.
+type Verifier struct {
+	manifest *Manifest
+
+	// Records mismatches, if set.
+	auditLog *audit.Logger
+
+	// Deduplicates concurrent verifications of the same object generation.
+	group singleflight.Group
+
+	mu sync.Mutex
+
+	// The generation of each object whose contents have been verified.
+	//
+	// GUARDED_BY(mu)
+	verified map[string]int64
+}
+
+// The time allowed for reading an object in full to verify it, long enough
+// for large model weights.
+const verifyTimeout = 30 * time.Minute
+
+// NewVerifier returns a verifier for the objects in the given manifest, which
+// records mismatches to auditLog, if not nil, as well as logging them.
+func NewVerifier(m *Manifest, auditLog *audit.Logger) *Verifier {
+	return &Verifier{
+		manifest: m,
+		auditLog: auditLog,
+		verified: make(map[string]int64),
+	}
+}
+
+// NeedsVerification returns true if the object is in the manifest and its
+// generation hasn't been verified yet.
+func (v *Verifier) NeedsVerification(object *gcs.MinObject) bool {
+	_, ok := v.lookup(object.Name)
+	return ok && !v.isVerified(object)
+}
+
+// Verify returns nil if the object isn't in the manifest, or if its contents
+// match its entry. Otherwise it returns a *MismatchError. Unless the object's
+// generation has already been verified, its CRC32C from GCS is compared first,
+// and then its contents are read in full from the bucket and checked.
+//
+// Concurrent calls for the same generation share a single read, which isn't
+// cancelled with the ctx of any one caller, but times out on its own.
+func (v *Verifier) Verify(ctx context.Context, bucket gcs.Bucket, object *gcs.MinObject) error {
+	entry, ok := v.lookup(object.Name)
+	if !ok || v.isVerified(object) {
+		return nil
+	}
+
+	if entry.CRC32C != nil && object.CRC32C != nil && *entry.CRC32C != *object.CRC32C {
+		return v.report(&MismatchError{
+			Name:       object.Name,
+			Generation: object.Generation,
+			Checksum:   "crc32c",
+			Expected:   strconv.FormatUint(uint64(*entry.CRC32C), 10),
+			Actual:     strconv.FormatUint(uint64(*object.CRC32C), 10),
+		})
+	}
+
+	key := object.Name + "#" + strconv.FormatInt(object.Generation, 10)
+	resultC := v.group.DoChan(key, func() (any, error) {
+		// Keep the values of ctx, e.g. the caller's credentials.
+		readCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), verifyTimeout)
+		defer cancel()
+
+		rc, err := bucket.NewReader(readCtx, &gcs.ReadObjectRequest{
+			Name:       object.Name,
+			Generation: object.Generation,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("NewReader: %w", err)
+		}
+		defer rc.Close()
+
+		return nil, v.VerifyReader(readCtx, object, rc)
+	})
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("verifying object %q: %w", object.Name, ctx.Err())
+	case result := <-resultC:
+		return result.Err
+	}
+}
+
+// VerifyReader checks the contents read from r against the manifest entry of
+// the object, as Verify does, and remembers the object's generation as
+// verified if they match. It returns nil without reading r if the object isn't
+// in the manifest. Reading stops early if ctx is done.
+func (v *Verifier) VerifyReader(ctx context.Context, object *gcs.MinObject, r io.Reader) error {
+	entry, ok := v.lookup(object.Name)
+	if !ok {
+		return nil
+	}
+
+	sha := sha256.New()
+	crc := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(io.MultiWriter(sha, crc), &ctxReader{ctx: ctx, r: r}); err != nil {
+		return fmt.Errorf("reading object %q: %w", object.Name, err)
+	}
+
+	if actual := hex.EncodeToString(sha.Sum(nil)); entry.SHA256 != "" && actual != entry.SHA256 {
+		return v.report(&MismatchError{
+			Name:       object.Name,
+			Generation: object.Generation,
+			Checksum:   "sha256",
+			Expected:   entry.SHA256,
+			Actual:     actual,
+		})
+	}
+
+	if actual := crc.Sum32(); entry.CRC32C != nil && actual != *entry.CRC32C {
+		return v.report(&MismatchError{
+			Name:       object.Name,
+			Generation: object.Generation,
+			Checksum:   "crc32c",
+			Expected:   strconv.FormatUint(uint64(*entry.CRC32C), 10),
+			Actual:     strconv.FormatUint(uint64(actual), 10),
+		})
+	}
+
+	v.mu.Lock()
+	v.verified[object.Name] = object.Generation
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) lookup(name string) (Entry, bool) {
+	if v == nil {
+		return Entry{}, false
+	}
+
+	entry, ok := v.manifest.Objects[name]
+	return entry, ok
+}
+
+func (v *Verifier) isVerified(object *gcs.MinObject) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	generation, ok := v.verified[object.Name]
+	return ok && generation == object.Generation
+}
+
+// Log and audit the mismatch so that it is recorded even if the caller doesn't
+// surface the error, and return it.
+func (v *Verifier) report(err *MismatchError) error {
+	logger.Errorf("%v", err)
+
+	entry := v.auditLog.Start("verify", audit.Caller{})
+	entry.Object("", err.Name)
+	entry.Generation(err.Generation)
+	entry.Finish(err)
+
+	return err
+}
+
+// ctxReader fails reads once its context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/audit"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Write the manifest, its signature and the public key to a temp dir, and
+// return their paths.
+func writeSigned(t *testing.T, manifest string, sig func(priv ed25519.PrivateKey, manifest []byte) []byte) (string, string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	manifestPath := path.Join(dir, "manifest.json")
+	sigPath := path.Join(dir, "manifest.json.sig")
+	keyPath := path.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifest), 0600))
+	require.NoError(t, os.WriteFile(sigPath, sig(priv, []byte(manifest)), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return manifestPath, sigPath, keyPath
+}
+
+func rawSig(priv ed25519.PrivateKey, manifest []byte) []byte {
+	return ed25519.Sign(priv, manifest)
+}
+
+func sha256Hex(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLoadManifest(t *testing.T) {
+	manifest := `{"objects": {"a/weights.bin": {"sha256": "` + sha256Hex("taco") + `"}, "b": {"crc32c": 17}}}`
+	for _, tc := range []struct {
+		name string
+		sig  func(priv ed25519.PrivateKey, manifest []byte) []byte
+	}{
+		{"Raw signature", rawSig},
+		{"Base64 signature", func(priv ed25519.PrivateKey, manifest []byte) []byte {
+			return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, manifest)) + "\n")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := LoadManifest(writeSigned(t, manifest, tc.sig))
+
+			require.NoError(t, err)
+			assert.Equal(t, sha256Hex("taco"), m.Objects["a/weights.bin"].SHA256)
+			assert.EqualValues(t, 17, *m.Objects["b"].CRC32C)
+		})
+	}
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest string
+		sig      func(priv ed25519.PrivateKey, manifest []byte) []byte
+	}{
+		{
+			name:     "Signature of other contents",
+			manifest: `{"objects": {}}`,
+			sig: func(priv ed25519.PrivateKey, _ []byte) []byte {
+				return ed25519.Sign(priv, []byte(`{"objects": {"a": {"crc32c": 1}}}`))
+			},
+		},
+		{
+			name:     "Malformed signature",
+			manifest: `{"objects": {}}`,
+			sig:      func(ed25519.PrivateKey, []byte) []byte { return []byte("not a signature") },
+		},
+		{
+			name:     "Entry without checksum",
+			manifest: `{"objects": {"a": {}}}`,
+			sig:      rawSig,
+		},
+		{
+			name:     "Malformed sha256",
+			manifest: `{"objects": {"a": {"sha256": "abc"}}}`,
+			sig:      rawSig,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadManifest(writeSigned(t, tc.manifest, tc.sig))
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadManifest_NonEd25519Key(t *testing.T) {
+	manifestPath, sigPath, _ := writeSigned(t, `{"objects": {}}`, rawSig)
+	keyPath := path.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyPath, []byte("not a key"), 0600))
+
+	_, err := LoadManifest(manifestPath, sigPath, keyPath)
+
+	assert.ErrorContains(t, err, "PEM")
+}
+
+type verifierTest struct {
+	ctx      context.Context
+	bucket   gcs.Bucket
+	verifier *Verifier
+}
+
+func newVerifierTest(t *testing.T, objects map[string]string, m *Manifest) *verifierTest {
+	t.Helper()
+	vt := &verifierTest{
+		ctx:      context.Background(),
+		bucket:   fake.NewFakeBucket(timeutil.RealClock(), "some_bucket", gcs.BucketType{}),
+		verifier: NewVerifier(m, nil),
+	}
+	for name, contents := range objects {
+		_, err := storageutil.CreateObject(vt.ctx, vt.bucket, name, []byte(contents))
+		require.NoError(t, err)
+	}
+	return vt
+}
+
+func (vt *verifierTest) stat(t *testing.T, name string) *gcs.MinObject {
+	t.Helper()
+	m, _, err := vt.bucket.StatObject(vt.ctx, &gcs.StatObjectRequest{Name: name})
+	require.NoError(t, err)
+	return m
+}
+
+func TestVerify(t *testing.T) {
+	crc := crc32.Checksum([]byte("taco"), crc32.MakeTable(crc32.Castagnoli))
+	wrongCRC := crc + 1
+	vt := newVerifierTest(t, map[string]string{
+		"sha":      "taco",
+		"crc":      "taco",
+		"bad_sha":  "burrito",
+		"bad_crc":  "taco",
+		"unlisted": "taco",
+	}, &Manifest{Objects: map[string]Entry{
+		"sha":     {SHA256: sha256Hex("taco")},
+		"crc":     {CRC32C: &crc},
+		"bad_sha": {SHA256: sha256Hex("taco")},
+		"bad_crc": {CRC32C: &wrongCRC},
+	}})
+
+	for name, wantErr := range map[string]bool{
+		"sha":      false,
+		"crc":      false,
+		"unlisted": false,
+		"bad_sha":  true,
+		"bad_crc":  true,
+	} {
+		err := vt.verifier.Verify(vt.ctx, vt.bucket, vt.stat(t, name))
+
+		if wantErr {
+			var mismatchErr *MismatchError
+			assert.ErrorAs(t, err, &mismatchErr, name)
+		} else {
+			assert.NoError(t, err, name)
+		}
+	}
+}
+
+func TestVerify_RemembersVerifiedGeneration(t *testing.T) {
+	vt := newVerifierTest(t, map[string]string{"foo": "taco"}, &Manifest{Objects: map[string]Entry{
+		"foo": {SHA256: sha256Hex("taco")},
+	}})
+	o := vt.stat(t, "foo")
+	require.NoError(t, vt.verifier.Verify(vt.ctx, vt.bucket, o))
+
+	// The same generation isn't read again, but a new one is.
+	_, err := storageutil.CreateObject(vt.ctx, vt.bucket, "foo", []byte("burrito"))
+	require.NoError(t, err)
+	assert.NoError(t, vt.verifier.Verify(vt.ctx, vt.bucket, o))
+	assert.Error(t, vt.verifier.Verify(vt.ctx, vt.bucket, vt.stat(t, "foo")))
+}
+
+func TestNilVerifier(t *testing.T) {
+	var v *Verifier
+	o := &gcs.MinObject{Name: "foo"}
+
+	assert.False(t, v.NeedsVerification(o))
+	assert.NoError(t, v.Verify(context.Background(), nil, o))
+	assert.NoError(t, v.VerifyReader(context.Background(), o, nil))
+}
+
+func TestVerify_AuditsMismatch(t *testing.T) {
+	auditPath := path.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.New(auditPath, false, nil)
+	require.NoError(t, err)
+	vt := newVerifierTest(t, map[string]string{"foo": "burrito"}, &Manifest{Objects: map[string]Entry{
+		"foo": {SHA256: sha256Hex("taco")},
+	}})
+	vt.verifier.auditLog = auditLog
+
+	err = vt.verifier.Verify(vt.ctx, vt.bucket, vt.stat(t, "foo"))
+
+	require.Error(t, err)
+	require.NoError(t, auditLog.Close())
+	contents, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), `"op":"verify"`)
+	assert.Contains(t, string(contents), `"object":"foo"`)
+	assert.Contains(t, string(contents), `"outcome":"error"`)
+}
+
+// A bucket whose readers block until release is closed.
+type blockingBucket struct {
+	gcs.Bucket
+	release chan struct{}
+}
+
+func (b *blockingBucket) NewReader(ctx context.Context, req *gcs.ReadObjectRequest) (io.ReadCloser, error) {
+	<-b.release
+	return b.Bucket.NewReader(ctx, req)
+}
+
+func TestVerify_CancelledCallerDoesntFailOthers(t *testing.T) {
+	vt := newVerifierTest(t, map[string]string{"foo": "taco"}, &Manifest{Objects: map[string]Entry{
+		"foo": {SHA256: sha256Hex("taco")},
+	}})
+	bucket := &blockingBucket{Bucket: vt.bucket, release: make(chan struct{})}
+	o := vt.stat(t, "foo")
+	cancelledCtx, cancel := context.WithCancel(vt.ctx)
+	cancelledErr := make(chan error)
+	go func() { cancelledErr <- vt.verifier.Verify(cancelledCtx, bucket, o) }()
+	otherErr := make(chan error)
+	go func() { otherErr <- vt.verifier.Verify(vt.ctx, bucket, o) }()
+
+	cancel()
+
+	assert.ErrorIs(t, <-cancelledErr, context.Canceled)
+	close(bucket.release)
+	assert.NoError(t, <-otherErr)
+	assert.False(t, vt.verifier.NeedsVerification(o))
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locker
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var gEnableHolderTracking bool
+
+// EnableHolderTracking makes the lockers created from then on record when they
+// are acquired, so that Holders can report them. Tracking costs a clock read
+// and an atomic store per acquisition.
+func EnableHolderTracking() {
+	gEnableHolderTracking = true
+}
+
+// Holder describes a locker that is held.
+type Holder struct {
+	// The name the locker was created with.
+	Name string
+	// When it was acquired, or zero if unknown. For a locker held for reading,
+	// when the current readers' hold started.
+	Since time.Time
+	// The number of goroutines holding the locker for reading, or zero if it is
+	// held exclusively.
+	Readers int
+}
+
+// The holder states of the tracking lockers in use. Lockers remove theirs when
+// they are garbage collected.
+var (
+	gTrackersMu sync.Mutex
+
+	// GUARDED_BY(gTrackersMu)
+	gTrackers = make(map[*holderState]struct{})
+)
+
+// Holders returns the lockers held at the moment, longest held first. It
+// returns nothing unless EnableHolderTracking was called.
+func Holders() []Holder {
+	gTrackersMu.Lock()
+	defer gTrackersMu.Unlock()
+
+	var holders []Holder
+	for s := range gTrackers {
+		if since := s.since.Load(); since != 0 {
+			holders = append(holders, Holder{Name: s.name, Since: time.Unix(0, since)})
+		}
+		if readers := s.readers.Load(); readers > 0 {
+			h := Holder{Name: s.name, Readers: int(readers)}
+			if since := s.readSince.Load(); since != 0 {
+				h.Since = time.Unix(0, since)
+			}
+			holders = append(holders, h)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].Since.Before(holders[j].Since) })
+	return holders
+}
+
+// The state of a tracking locker, apart from it so that gTrackers doesn't keep
+// the locker alive.
+type holderState struct {
+	name string
+
+	// When the locker was acquired exclusively, in Unix nanoseconds, or zero if
+	// it isn't.
+	since atomic.Int64
+
+	// The number of readers holding the locker, and when the first of them
+	// acquired it, in Unix nanoseconds. A reader racing with the last one to
+	// release it may leave readSince zero.
+	readers   atomic.Int32
+	readSince atomic.Int64
+}
+
+// Register the state of a new tracking locker, until owner is collected.
+func newHolderState(name string, owner any) *holderState {
+	s := &holderState{name: name}
+
+	gTrackersMu.Lock()
+	gTrackers[s] = struct{}{}
+	gTrackersMu.Unlock()
+
+	runtime.SetFinalizer(owner, func(any) {
+		gTrackersMu.Lock()
+		delete(gTrackers, s)
+		gTrackersMu.Unlock()
+	})
+	return s
+}
+
+func (s *holderState) locked() {
+	s.since.Store(time.Now().UnixNano())
+}
+
+func (s *holderState) unlocked() {
+	s.since.Store(0)
+}
+
+func (s *holderState) rlocked() {
+	if s.readers.Add(1) == 1 {
+		s.readSince.Store(time.Now().UnixNano())
+	}
+}
+
+func (s *holderState) runlocked() {
+	if s.readers.Add(-1) == 0 {
+		s.readSince.Store(0)
+	}
+}
+
+type tracker struct {
+	locker Locker
+	state  *holderState
+}
+
+func newTracker(l Locker, name string) *tracker {
+	t := &tracker{locker: l}
+	t.state = newHolderState(name, t)
+	return t
+}
+
+func (t *tracker) Lock() {
+	t.locker.Lock()
+	t.state.locked()
+}
+
+func (t *tracker) Unlock() {
+	t.state.unlocked()
+	t.locker.Unlock()
+}
+
+type rwTracker struct {
+	locker RWLocker
+	state  *holderState
+}
+
+func newRWTracker(l RWLocker, name string) *rwTracker {
+	t := &rwTracker{locker: l}
+	t.state = newHolderState(name, t)
+	return t
+}
+
+func (t *rwTracker) Lock() {
+	t.locker.Lock()
+	t.state.locked()
+}
+
+func (t *rwTracker) Unlock() {
+	t.state.unlocked()
+	t.locker.Unlock()
+}
+
+func (t *rwTracker) RLock() {
+	t.locker.RLock()
+	t.state.rlocked()
+}
+
+func (t *rwTracker) RUnlock() {
+	t.state.runlocked()
+	t.locker.RUnlock()
+}
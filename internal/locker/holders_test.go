@@ -0,0 +1,60 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolders(t *testing.T) {
+	gEnableHolderTracking = true
+	t.Cleanup(func() { gEnableHolderTracking = false })
+	l := New("FS", func() {})
+	rw := NewRW("dir/", func() {})
+
+	l.Lock()
+	rw.Lock()
+	holders := Holders()
+	rw.Unlock()
+	l.Unlock()
+
+	require.Len(t, holders, 2)
+	assert.Equal(t, "FS", holders[0].Name)
+	assert.Equal(t, "dir/", holders[1].Name)
+	assert.False(t, holders[0].Since.After(holders[1].Since))
+	assert.Zero(t, holders[0].Readers)
+	assert.Empty(t, Holders())
+}
+
+func TestHolders_ReadLocks(t *testing.T) {
+	gEnableHolderTracking = true
+	t.Cleanup(func() { gEnableHolderTracking = false })
+	rw := NewRW("dir/", func() {})
+
+	rw.RLock()
+	rw.RLock()
+	holders := Holders()
+	rw.RUnlock()
+	rw.RUnlock()
+
+	require.Len(t, holders, 1)
+	assert.Equal(t, "dir/", holders[0].Name)
+	assert.Equal(t, 2, holders[0].Readers)
+	assert.False(t, holders[0].Since.IsZero())
+	assert.Empty(t, Holders())
+}
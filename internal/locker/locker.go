@@ -58,6 +58,10 @@ func New(name string, check func()) Locker {
 		}
 	}
 
+	if gEnableHolderTracking {
+		l = newTracker(l, name)
+	}
+
 	return l
 }
 
@@ -50,6 +50,10 @@ func NewRW(name string, check func()) RWLocker {
 		}
 	}
 
+	if gEnableHolderTracking {
+		l = newRWTracker(l, name)
+	}
+
 	return l
 }
 
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync/atomic"
+)
+
+// componentKey is the key of the attribute naming the component that logged a
+// message.
+const componentKey = "component"
+
+// Component is a subsystem whose messages can be logged at a different
+// severity than the rest, with logging.component-severity, and are marked with
+// its name.
+type Component struct {
+	name   string
+	logger atomic.Pointer[slog.Logger]
+}
+
+// The components of cfg.LogComponents.
+var (
+	FUSE          = &Component{name: "fuse"}
+	GCS           = &Component{name: "gcs"}
+	FileCache     = &Component{name: "file-cache"}
+	MetadataCache = &Component{name: "metadata-cache"}
+	Writes        = &Component{name: "writes"}
+	Auth          = &Component{name: "auth"}
+
+	components = []*Component{FUSE, GCS, FileCache, MetadataCache, Writes, Auth}
+)
+
+// Name returns the name of the component, as in logging.component-severity.
+func (c *Component) Name() string {
+	return c.name
+}
+
+// Tracef prints the message with TRACE severity in the specified format.
+func (c *Component) Tracef(format string, v ...interface{}) {
+	c.logf(LevelTrace, format, v...)
+}
+
+// Debugf prints the message with DEBUG severity in the specified format.
+func (c *Component) Debugf(format string, v ...interface{}) {
+	c.logf(LevelDebug, format, v...)
+}
+
+// Infof prints the message with INFO severity in the specified format.
+func (c *Component) Infof(format string, v ...interface{}) {
+	c.logf(LevelInfo, format, v...)
+}
+
+// Warnf prints the message with WARNING severity in the specified format.
+func (c *Component) Warnf(format string, v ...interface{}) {
+	c.logf(LevelWarn, format, v...)
+}
+
+// Errorf prints the message with ERROR severity in the specified format.
+func (c *Component) Errorf(format string, v ...interface{}) {
+	c.logf(LevelError, format, v...)
+}
+
+func (c *Component) logf(level slog.Level, format string, v ...interface{}) {
+	l := c.logger.Load()
+	ctx := context.Background()
+	// Skip formatting the many trace messages of components that aren't traced.
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	l.Log(ctx, level, fmt.Sprintf(format, v...))
+}
+
+// NewLegacyLogger creates a legacy logger for the component, as for
+// NewLegacyLogger.
+func (c *Component) NewLegacyLogger(level slog.Level, prefix string) *log.Logger {
+	return slog.NewLogLogger(defaultLoggerFactory.componentHandler(c.name, prefix), level)
+}
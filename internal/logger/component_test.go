@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestFactory makes the default factory log at the given levels to a file,
+// standing in for stdout, whose contents are returned by the returned
+// function. The previous loggers are restored at the end of the test.
+func useTestFactory(t *testing.T, f *loggerFactory) func() string {
+	t.Helper()
+	oldFactory, oldLogger, oldStdout := defaultLoggerFactory, defaultLogger, os.Stdout
+	oldComponentLoggers := make([]*slog.Logger, len(components))
+	for i, c := range components {
+		oldComponentLoggers[i] = c.logger.Load()
+	}
+	t.Cleanup(func() {
+		defaultLoggerFactory, defaultLogger, os.Stdout = oldFactory, oldLogger, oldStdout
+		for i, c := range components {
+			c.logger.Store(oldComponentLoggers[i])
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "stdout")
+	stdout, err := os.Create(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { stdout.Close() })
+	os.Stdout = stdout
+
+	defaultLoggerFactory = f
+	defaultLogger = f.newLogger()
+	return func() string {
+		out, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, stdout.Truncate(0))
+		_, err = stdout.Seek(0, 0)
+		require.NoError(t, err)
+		return string(out)
+	}
+}
+
+func TestComponent_OwnSeverity(t *testing.T) {
+	output := useTestFactory(t, &loggerFactory{
+		format:          "json",
+		level:           cfg.INFO,
+		componentLevels: map[string]string{"gcs": cfg.TRACE, "fuse": cfg.ERROR},
+	})
+
+	GCS.Tracef("www.traceExample.com")
+	assert.Regexp(t, `"severity":"TRACE","message":"www.traceExample.com","component":"gcs"}`, output())
+	FileCache.Tracef("www.traceExample.com")
+	assert.Empty(t, output())
+	FileCache.Infof("www.infoExample.com")
+	assert.Regexp(t, `"severity":"INFO","message":"www.infoExample.com","component":"file-cache"}`, output())
+	FUSE.Warnf("www.warningExample.com")
+	assert.Empty(t, output())
+	Debugf("www.debugExample.com")
+	assert.Empty(t, output())
+}
+
+func TestComponent_LegacyLogger(t *testing.T) {
+	output := useTestFactory(t, &loggerFactory{
+		format:          "text",
+		level:           cfg.INFO,
+		componentLevels: map[string]string{"fuse": cfg.TRACE},
+	})
+	legacyLogger := FUSE.NewLegacyLogger(LevelTrace, "fuse_debug: ")
+
+	legacyLogger.Print("www.traceExample.com")
+
+	assert.Regexp(t, `severity=TRACE message="fuse_debug: www.traceExample.com" component=fuse`, output())
+}
+
+func TestSetComponentSeverities(t *testing.T) {
+	output := useTestFactory(t, &loggerFactory{
+		format: "json",
+		level:  cfg.INFO,
+	})
+
+	require.NoError(t, SetComponentSeverities([]string{"writes=debug"}))
+	Writes.Debugf("www.debugExample.com")
+	assert.NotEmpty(t, output())
+	GCS.Debugf("www.debugExample.com")
+	assert.Empty(t, output())
+
+	// Components without their own severity follow the rest.
+	require.NoError(t, SetLogSeverity("warning"))
+	Writes.Debugf("www.debugExample.com")
+	assert.NotEmpty(t, output())
+	GCS.Infof("www.infoExample.com")
+	assert.Empty(t, output())
+
+	require.NoError(t, SetComponentSeverities(nil))
+	Writes.Debugf("www.debugExample.com")
+	assert.Empty(t, output())
+}
+
+func TestSetComponentSeverities_Invalid(t *testing.T) {
+	useTestFactory(t, &loggerFactory{level: cfg.INFO})
+
+	err := SetComponentSeverities([]string{"kernel=trace"})
+
+	assert.Error(t, err)
+}
+
+func TestComponent_Sampling(t *testing.T) {
+	s := newSampler(2)
+	s.now = func() time.Time { return time.Unix(1000, 0) }
+	output := useTestFactory(t, &loggerFactory{
+		format:       "json",
+		level:        cfg.TRACE,
+		maxPerSecond: 2,
+		samplers:     map[string]*sampler{"gcs": s},
+	})
+
+	for range 5 {
+		GCS.Tracef("www.traceExample.com")
+	}
+	GCS.Infof("www.infoExample.com")
+
+	out := output()
+	assert.Equal(t, 2, countMatches(t, `"message":"www.traceExample.com"`, out))
+	assert.Regexp(t, `"message":"www.infoExample.com","component":"gcs","dropped":3}`, out)
+}
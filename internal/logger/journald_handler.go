@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// The socket of the native protocol of the systemd journal.
+const journalSocket = "/run/systemd/journal/socket"
+
+// The longest journal field name.
+const maxJournalFieldName = 64
+
+// journaldHandler writes records to the systemd journal over its native
+// protocol, as one datagram per record, with the message, severity and
+// attributes of each record as journal fields.
+type journaldHandler struct {
+	w      io.Writer
+	level  slog.Leveler
+	prefix string
+
+	// The fields of the attributes added with WithAttrs.
+	fields []byte
+
+	// The prefix of the names of the fields of further attributes, from
+	// WithGroup.
+	group string
+}
+
+func newJournaldHandler(w io.Writer, level slog.Leveler, prefix string) *journaldHandler {
+	return &journaldHandler{w: w, level: level, prefix: prefix}
+}
+
+// journalPriority returns the syslog priority of the given level.
+func journalPriority(level slog.Level) int {
+	switch {
+	case level < LevelInfo:
+		return 7
+	case level < LevelWarn:
+		return 6
+	case level < LevelError:
+		return 4
+	default:
+		return 3
+	}
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *journaldHandler) Handle(_ context.Context, r slog.Record) error {
+	severity := slog.Attr{Key: slog.LevelKey, Value: slog.AnyValue(r.Level)}
+	customiseLevels(&severity)
+
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", h.prefix+r.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(journalPriority(r.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", ProgrammeName)
+	appendJournalField(&buf, "SEVERITY", severity.Value.String())
+	buf.Write(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		appendJournalAttr(&buf, h.group, a)
+		return true
+	})
+
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	buf := bytes.NewBuffer(bytes.Clone(h.fields))
+	for _, a := range attrs {
+		appendJournalAttr(buf, h.group, a)
+	}
+	with := *h
+	with.fields = buf.Bytes()
+	return &with
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	with := *h
+	with.group = h.group + name + "_"
+	return &with
+}
+
+// appendJournalAttr appends the fields of an attribute, with the fields of
+// groups named after the group and attribute.
+func appendJournalAttr(buf *bytes.Buffer, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group = group + a.Key + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			appendJournalAttr(buf, group, ga)
+		}
+		return
+	}
+	appendJournalField(buf, journalFieldName(group+a.Key), a.Value.String())
+}
+
+// journalFieldName returns the journal field name for an attribute key: the
+// key in upper case, with characters other than letters, digits and
+// underscores replaced by underscores, and starting with a letter.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		name = "F" + name
+	}
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+	return name
+}
+
+// appendJournalField appends a field in the journal's native format: as
+// NAME=value, or with the length of the value in binary if the value spans
+// lines.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
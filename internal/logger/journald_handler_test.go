@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournaldHandler_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newJournaldHandler(&buf, LevelInfo, "prefix: ")).With("component", "gcs")
+
+	logger.WithGroup("op").Warn("www.warningExample.com", "object-name", "a/b")
+
+	assert.Equal(t, "MESSAGE=prefix: www.warningExample.com\n"+
+		"PRIORITY=4\n"+
+		"SYSLOG_IDENTIFIER="+ProgrammeName+"\n"+
+		"SEVERITY=WARNING\n"+
+		"COMPONENT=gcs\n"+
+		"OP_OBJECT_NAME=a/b\n", buf.String())
+}
+
+func TestJournaldHandler_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newJournaldHandler(&buf, LevelInfo, ""))
+
+	logger.Log(context.Background(), LevelDebug, "www.debugExample.com")
+
+	assert.Empty(t, buf.String())
+}
+
+func TestAppendJournalField_MultiLine(t *testing.T) {
+	var buf bytes.Buffer
+
+	appendJournalField(&buf, "MESSAGE", "a\nb")
+
+	var expected bytes.Buffer
+	expected.WriteString("MESSAGE\n")
+	_ = binary.Write(&expected, binary.LittleEndian, uint64(3))
+	expected.WriteString("a\nb\n")
+	assert.Equal(t, expected.Bytes(), buf.Bytes())
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := []struct {
+		key  string
+		name string
+	}{
+		{"component", "COMPONENT"},
+		{"object-name", "OBJECT_NAME"},
+		{"2xx", "F2XX"},
+		{"", "F"},
+		{string(bytes.Repeat([]byte("a"), 70)), string(bytes.Repeat([]byte("A"), 64))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.key, func(t *testing.T) {
+			assert.Equal(t, tc.name, journalFieldName(tc.key))
+		})
+	}
+}
@@ -28,8 +28,7 @@ import (
 // This method is created to support jacobsa/fuse loggers and will be removed
 // after slog support is added.
 func NewLegacyLogger(level slog.Level, prefix string) *log.Logger {
-	var programLevel = new(slog.LevelVar)
+	programLevel := defaultLoggerFactory.newLevelVar()
 	logger := slog.NewLogLogger(defaultLoggerFactory.handler(programLevel, prefix), level)
-	setLoggingLevel(defaultLoggerFactory.level, programLevel)
 	return logger
 }
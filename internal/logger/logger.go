@@ -20,8 +20,10 @@ import (
 	"io"
 	"log/slog"
 	"log/syslog"
+	"net"
 	"os"
 	"runtime/debug"
+	"sync"
 
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -49,11 +51,57 @@ var (
 // config.
 // Here, background true means, this InitLogFile has been called for the
 // background daemon.
+// With the syslog or journald output, it writes the log there instead.
 func InitLogFile(newLogConfig cfg.LoggingConfig) error {
 	var f *os.File
 	var sysWriter *syslog.Writer
 	var fileWriter *lumberjack.Logger
+	var journal net.Conn
 	var err error
+
+	componentLevels, err := parseComponentLevels(newLogConfig.ComponentSeverity)
+	if err != nil {
+		return err
+	}
+
+	switch newLogConfig.Output {
+	case cfg.LogOutputJournald:
+		journal, err = net.Dial("unixgram", journalSocket)
+		if err != nil {
+			return fmt.Errorf("connecting to the systemd journal: %w", err)
+		}
+	case cfg.LogOutputSyslog:
+		sysWriter, err = syslog.New(syslog.LOG_LOCAL7|syslog.LOG_DEBUG, ProgrammeName)
+		if err != nil {
+			return fmt.Errorf("connecting to syslog: %w", err)
+		}
+	default:
+		f, sysWriter, fileWriter, err = openLogFile(newLogConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	defaultLoggerFactory = &loggerFactory{
+		file:            f,
+		sysWriter:       sysWriter,
+		fileWriter:      fileWriter,
+		journal:         journal,
+		output:          newLogConfig.Output,
+		format:          newLogConfig.Format,
+		level:           string(newLogConfig.Severity),
+		componentLevels: componentLevels,
+		maxPerSecond:    newLogConfig.Sampling.MaxPerSecond,
+		logRotate:       newLogConfig.LogRotate,
+	}
+	defaultLogger = defaultLoggerFactory.newLogger()
+
+	return nil
+}
+
+// openLogFile opens the log file, or syslog in the background if there is
+// none.
+func openLogFile(newLogConfig cfg.LoggingConfig) (f *os.File, sysWriter *syslog.Writer, fileWriter *lumberjack.Logger, err error) {
 	if newLogConfig.FilePath != "" {
 		f, err = os.OpenFile(
 			string(newLogConfig.FilePath),
@@ -61,7 +109,7 @@ func InitLogFile(newLogConfig cfg.LoggingConfig) error {
 			0644,
 		)
 		if err != nil {
-			return err
+			return
 		}
 		fileWriter = &lumberjack.Logger{
 			Filename:   f.Name(),
@@ -83,18 +131,21 @@ func InitLogFile(newLogConfig cfg.LoggingConfig) error {
 			sysWriter, _ = syslog.New(syslog.LOG_LOCAL7|syslog.LOG_DEBUG, ProgrammeName)
 		}
 	}
+	return
+}
 
-	defaultLoggerFactory = &loggerFactory{
-		file:       f,
-		sysWriter:  sysWriter,
-		fileWriter: fileWriter,
-		format:     newLogConfig.Format,
-		level:      string(newLogConfig.Severity),
-		logRotate:  newLogConfig.LogRotate,
+// parseComponentLevels parses logging.component-severity into the logging
+// levels of the components.
+func parseComponentLevels(pairs []string) (map[string]string, error) {
+	severities, err := cfg.ParseComponentSeverities(pairs)
+	if err != nil {
+		return nil, err
 	}
-	defaultLogger = defaultLoggerFactory.newLogger(string(newLogConfig.Severity))
-
-	return nil
+	levels := make(map[string]string, len(severities))
+	for component, severity := range severities {
+		levels[component] = string(severity)
+	}
+	return levels, nil
 }
 
 // init initializes the logger factory to use stdout and stderr.
@@ -106,7 +157,7 @@ func init() {
 		level:     string(logConfig.Severity), // setting log level to INFO by default
 		logRotate: logConfig.LogRotate,
 	}
-	defaultLogger = defaultLoggerFactory.newLogger(cfg.INFO)
+	defaultLogger = defaultLoggerFactory.newLogger()
 }
 
 // SetLogFormat updates the log format of default logger.
@@ -115,7 +166,7 @@ func SetLogFormat(format string) {
 		return
 	}
 	defaultLoggerFactory.format = format
-	defaultLogger = defaultLoggerFactory.newLogger(defaultLoggerFactory.level)
+	defaultLogger = defaultLoggerFactory.newLogger()
 }
 
 // Tracef prints the message with TRACE severity in the specified format.
@@ -143,6 +194,11 @@ func Warnf(format string, v ...interface{}) {
 	defaultLogger.Warn(fmt.Sprintf(format, v...))
 }
 
+// Warn prints the message with WARNING severity.
+func Warn(message string, args ...any) {
+	defaultLogger.Warn(message, args...)
+}
+
 // Errorf prints the message with ERROR severity in the specified format.
 func Errorf(format string, v ...interface{}) {
 	defaultLogger.Error(fmt.Sprintf(format, v...))
@@ -160,6 +216,31 @@ func Fatal(format string, v ...interface{}) {
 	os.Exit(1)
 }
 
+// SetLogSeverity changes the severity at and above which the loggers created
+// so far, and those created later, log.
+func SetLogSeverity(severity string) error {
+	var level cfg.LogSeverity
+	if err := level.UnmarshalText([]byte(severity)); err != nil {
+		return err
+	}
+
+	defaultLoggerFactory.setLevel(string(level))
+	return nil
+}
+
+// SetComponentSeverities changes the severities of the components, given as
+// component=severity pairs as in logging.component-severity. Components that
+// aren't given log at the severity of the rest.
+func SetComponentSeverities(pairs []string) error {
+	levels, err := parseComponentLevels(pairs)
+	if err != nil {
+		return err
+	}
+
+	defaultLoggerFactory.setComponentLevels(levels)
+	return nil
+}
+
 type loggerFactory struct {
 	// If nil, log to stdout or stderr. Otherwise, log to this file.
 	file       *os.File
@@ -168,17 +249,144 @@ type loggerFactory struct {
 	level      string
 	logRotate  cfg.LogRotateLoggingConfig
 	fileWriter *lumberjack.Logger
+
+	// If not nil, log to the systemd journal.
+	journal io.Writer
+
+	// The logging.output, for which the syslog output writes with the
+	// priority of each message.
+	output string
+
+	// The number of trace and debug messages per second that each component,
+	// and the rest, logs. 0 if they aren't sampled.
+	maxPerSecond int64
+
+	// The levels of the loggers created by the factory, so that they can be
+	// changed together.
+	//
+	// GUARDED_BY(mu)
+	levelVars []*slog.LevelVar
+
+	// The levels of the components set apart from the rest, and the levels of
+	// the loggers created by the factory for each component.
+	//
+	// GUARDED_BY(mu)
+	componentLevels    map[string]string
+	componentLevelVars map[string][]*slog.LevelVar
+
+	// The samplers of each component, and of the rest under "".
+	//
+	// GUARDED_BY(mu)
+	samplers map[string]*sampler
+	mu       sync.Mutex
 }
 
-func (f *loggerFactory) newLogger(level string) *slog.Logger {
+func (f *loggerFactory) newLogger() *slog.Logger {
 	// create a new logger
-	var programLevel = new(slog.LevelVar)
-	logger := slog.New(f.handler(programLevel, ""))
+	programLevel := f.newLevelVar()
+	logger := slog.New(f.sampled("", f.handler(programLevel, "")))
 	slog.SetDefault(logger)
-	setLoggingLevel(level, programLevel)
+	for _, c := range components {
+		c.logger.Store(slog.New(f.componentHandler(c.name, "")))
+	}
 	return logger
 }
 
+// componentHandler returns a handler for the messages of the given component,
+// at its level.
+func (f *loggerFactory) componentHandler(component string, prefix string) slog.Handler {
+	programLevel := f.newComponentLevelVar(component)
+	h := f.sampled(component, f.handler(programLevel, prefix))
+	return h.WithAttrs([]slog.Attr{slog.String(componentKey, component)})
+}
+
+// sampled returns the handler, sampled with the sampler of the given
+// component if sampling is on.
+func (f *loggerFactory) sampled(component string, h slog.Handler) slog.Handler {
+	if f.maxPerSecond <= 0 {
+		return h
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.samplers == nil {
+		f.samplers = make(map[string]*sampler)
+	}
+	s, ok := f.samplers[component]
+	if !ok {
+		s = newSampler(f.maxPerSecond)
+		f.samplers[component] = s
+	}
+	return &samplingHandler{Handler: h, sampler: s}
+}
+
+// newLevelVar returns a level set to the factory's level, which follows later
+// calls to setLevel.
+func (f *loggerFactory) newLevelVar() *slog.LevelVar {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var programLevel = new(slog.LevelVar)
+	setLoggingLevel(f.level, programLevel)
+	f.levelVars = append(f.levelVars, programLevel)
+	return programLevel
+}
+
+// newComponentLevelVar returns a level set to the level of the component,
+// which follows later calls to setLevel and setComponentLevels.
+func (f *loggerFactory) newComponentLevelVar(component string) *slog.LevelVar {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var programLevel = new(slog.LevelVar)
+	setLoggingLevel(f.componentLevel(component), programLevel)
+	if f.componentLevelVars == nil {
+		f.componentLevelVars = make(map[string][]*slog.LevelVar)
+	}
+	f.componentLevelVars[component] = append(f.componentLevelVars[component], programLevel)
+	return programLevel
+}
+
+// componentLevel returns the level of the component: its own if set apart,
+// otherwise that of the rest.
+//
+// LOCKS_REQUIRED(f.mu)
+func (f *loggerFactory) componentLevel(component string) string {
+	if level, ok := f.componentLevels[component]; ok {
+		return level
+	}
+	return f.level
+}
+
+// LOCKS_REQUIRED(f.mu)
+func (f *loggerFactory) updateComponentLevelVars() {
+	for component, levelVars := range f.componentLevelVars {
+		for _, programLevel := range levelVars {
+			setLoggingLevel(f.componentLevel(component), programLevel)
+		}
+	}
+}
+
+func (f *loggerFactory) setLevel(level string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.level = level
+	for _, programLevel := range f.levelVars {
+		setLoggingLevel(level, programLevel)
+	}
+	f.updateComponentLevelVars()
+}
+
+func (f *loggerFactory) setComponentLevels(levels map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.componentLevels = levels
+	f.updateComponentLevelVars()
+}
+
 func (f *loggerFactory) createJsonOrTextHandler(writer io.Writer, levelVar *slog.LevelVar, prefix string) slog.Handler {
 	if f.format == textFormat {
 		return slog.NewTextHandler(writer, getHandlerOptions(levelVar, prefix, f.format))
@@ -187,11 +395,18 @@ func (f *loggerFactory) createJsonOrTextHandler(writer io.Writer, levelVar *slog
 }
 
 func (f *loggerFactory) handler(levelVar *slog.LevelVar, prefix string) slog.Handler {
+	if f.journal != nil {
+		return newJournaldHandler(f.journal, levelVar, prefix)
+	}
+
 	if f.fileWriter != nil {
 		return f.createJsonOrTextHandler(f.fileWriter, levelVar, prefix)
 	}
 
 	if f.sysWriter != nil {
+		if f.output == cfg.LogOutputSyslog {
+			return f.newSyslogHandler(f.sysWriter, levelVar, prefix)
+		}
 		return f.createJsonOrTextHandler(f.sysWriter, levelVar, prefix)
 	}
 	return f.createJsonOrTextHandler(os.Stdout, levelVar, prefix)
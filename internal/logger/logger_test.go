@@ -339,3 +339,30 @@ func (t *LoggerTest) TestSetLogFormatToText() {
 		assert.True(t.T(), expectedRegexp.MatchString(output))
 	}
 }
+
+func (t *LoggerTest) TestSetLogSeverity() {
+	defaultLoggerFactory = &loggerFactory{level: cfg.INFO}
+	var buf bytes.Buffer
+	defaultLogger = slog.New(defaultLoggerFactory.createJsonOrTextHandler(&buf, defaultLoggerFactory.newLevelVar(), "TestLogs: "))
+	legacyLogger := NewLegacyLogger(LevelDebug, "")
+
+	err := SetLogSeverity("debug")
+
+	assert.NoError(t.T(), err)
+	assert.Equal(t.T(), cfg.DEBUG, defaultLoggerFactory.level)
+	Debugf("www.debugExample.com")
+	assert.Regexp(t.T(), jsonDebugString, buf.String())
+	assert.NotNil(t.T(), legacyLogger)
+	for _, levelVar := range defaultLoggerFactory.levelVars {
+		assert.Equal(t.T(), LevelDebug, levelVar.Level())
+	}
+}
+
+func (t *LoggerTest) TestSetLogSeverity_Invalid() {
+	defaultLoggerFactory = &loggerFactory{level: cfg.INFO}
+
+	err := SetLogSeverity("verbose")
+
+	assert.Error(t.T(), err)
+	assert.Equal(t.T(), cfg.INFO, defaultLoggerFactory.level)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// droppedKey is the key of the attribute counting the messages dropped by
+// sampling since the last message logged.
+const droppedKey = "dropped"
+
+// sampler limits the trace and debug messages of a component to a number per
+// second.
+//
+// Safe for concurrent access.
+type sampler struct {
+	maxPerSecond int64
+	now          func() time.Time
+
+	mu      sync.Mutex
+	second  int64 // GUARDED_BY(mu)
+	count   int64 // GUARDED_BY(mu)
+	dropped int64 // GUARDED_BY(mu)
+}
+
+func newSampler(maxPerSecond int64) *sampler {
+	return &sampler{
+		maxPerSecond: maxPerSecond,
+		now:          time.Now,
+	}
+}
+
+// allow returns true if another trace or debug message can be logged in the
+// current second, and otherwise counts it as dropped.
+//
+// LOCKS_EXCLUDED(s.mu)
+func (s *sampler) allow() bool {
+	second := s.now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if second != s.second {
+		s.second = second
+		s.count = 0
+	}
+	if s.count >= s.maxPerSecond {
+		s.dropped++
+		return false
+	}
+	s.count++
+	return true
+}
+
+// takeDropped returns the number of messages dropped since it was last
+// called.
+//
+// LOCKS_EXCLUDED(s.mu)
+func (s *sampler) takeDropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := s.dropped
+	s.dropped = 0
+	return dropped
+}
+
+// samplingHandler drops the trace and debug records beyond the rate of its
+// sampler, and counts them in the next record it handles.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < LevelInfo && !h.sampler.allow() {
+		return nil
+	}
+	if dropped := h.sampler.takeDropped(); dropped > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int64(droppedKey, dropped))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
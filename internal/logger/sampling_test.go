@@ -0,0 +1,48 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func countMatches(t *testing.T, expr string, s string) int {
+	t.Helper()
+	return len(regexp.MustCompile(expr).FindAllStringIndex(s, -1))
+}
+
+func TestSampler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newSampler(2)
+	s.now = func() time.Time { return now }
+
+	assert.True(t, s.allow())
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+	assert.False(t, s.allow())
+	assert.Equal(t, int64(2), s.takeDropped())
+	assert.Zero(t, s.takeDropped())
+
+	// The next second allows as many again.
+	now = now.Add(time.Second)
+	assert.True(t, s.allow())
+	assert.True(t, s.allow())
+	assert.False(t, s.allow())
+	assert.Equal(t, int64(1), s.takeDropped())
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// syslogWriter writes messages to syslog at a priority, as *syslog.Writer
+// does.
+type syslogWriter interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+}
+
+// priorityWriter writes to syslog at one priority.
+type priorityWriter func(m string) error
+
+func (w priorityWriter) Write(p []byte) (int, error) {
+	if err := w(string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// syslogHandler writes records to syslog with the priority of their severity,
+// formatted, with their attributes, as in a log file.
+type syslogHandler struct {
+	// The handlers of the records of each priority, by increasing priority:
+	// debug, info, warning and err.
+	handlers [4]slog.Handler
+}
+
+func (f *loggerFactory) newSyslogHandler(w syslogWriter, levelVar *slog.LevelVar, prefix string) slog.Handler {
+	writers := [4]io.Writer{
+		priorityWriter(w.Debug),
+		priorityWriter(w.Info),
+		priorityWriter(w.Warning),
+		priorityWriter(w.Err),
+	}
+	h := &syslogHandler{}
+	for i, writer := range writers {
+		h.handlers[i] = f.createJsonOrTextHandler(writer, levelVar, prefix)
+	}
+	return h
+}
+
+func syslogPriority(level slog.Level) int {
+	switch {
+	case level < LevelInfo:
+		return 0
+	case level < LevelWarn:
+		return 1
+	case level < LevelError:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handlers[0].Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handlers[syslogPriority(r.Level)].Handle(ctx, r)
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+func (h *syslogHandler) with(f func(slog.Handler) slog.Handler) slog.Handler {
+	with := &syslogHandler{}
+	for i, handler := range h.handlers {
+		with.handlers[i] = f(handler)
+	}
+	return with
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/stretchr/testify/assert"
+)
+
+type syslogMessage struct {
+	priority string
+	message  string
+}
+
+type fakeSyslogWriter struct {
+	messages []syslogMessage
+}
+
+func (w *fakeSyslogWriter) write(priority, m string) error {
+	w.messages = append(w.messages, syslogMessage{priority, m})
+	return nil
+}
+
+func (w *fakeSyslogWriter) Debug(m string) error   { return w.write("debug", m) }
+func (w *fakeSyslogWriter) Info(m string) error    { return w.write("info", m) }
+func (w *fakeSyslogWriter) Warning(m string) error { return w.write("warning", m) }
+func (w *fakeSyslogWriter) Err(m string) error     { return w.write("err", m) }
+
+func TestSyslogHandler_Priorities(t *testing.T) {
+	w := &fakeSyslogWriter{}
+	f := &loggerFactory{format: "text"}
+	levelVar := new(slog.LevelVar)
+	setLoggingLevel(cfg.TRACE, levelVar)
+	logger := slog.New(f.newSyslogHandler(w, levelVar, "")).With("component", "gcs")
+
+	logger.Log(context.Background(), LevelTrace, "www.traceExample.com")
+	logger.Log(context.Background(), LevelDebug, "www.debugExample.com")
+	logger.Log(context.Background(), LevelInfo, "www.infoExample.com")
+	logger.Log(context.Background(), LevelWarn, "www.warningExample.com")
+	logger.Log(context.Background(), LevelError, "www.errorExample.com")
+
+	if assert.Len(t, w.messages, 5) {
+		for i, priority := range []string{"debug", "debug", "info", "warning", "err"} {
+			assert.Equal(t, priority, w.messages[i].priority)
+		}
+		assert.Regexp(t, `severity=WARNING message=www.warningExample.com component=gcs`, w.messages[3].message)
+	}
+}
+
+func TestSyslogHandler_Level(t *testing.T) {
+	w := &fakeSyslogWriter{}
+	f := &loggerFactory{format: "json"}
+	levelVar := new(slog.LevelVar)
+	setLoggingLevel(cfg.WARNING, levelVar)
+	logger := slog.New(f.newSyslogHandler(w, levelVar, ""))
+
+	logger.Log(context.Background(), LevelInfo, "www.infoExample.com")
+	logger.Log(context.Background(), LevelError, "www.errorExample.com")
+
+	if assert.Len(t, w.messages, 1) {
+		assert.Equal(t, "err", w.messages[0].priority)
+		assert.Regexp(t, `"severity":"ERROR","message":"www.errorExample.com"`, w.messages[0].message)
+	}
+}
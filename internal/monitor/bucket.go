@@ -22,11 +22,14 @@ import (
 
 	storagev2 "cloud.google.com/go/storage"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/attribution"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 )
 
-// recordRequest records a request and its latency.
+// recordRequest records a request and its latency, and attributes the request
+// to the caller of the op it's for.
 func recordRequest(ctx context.Context, metricHandle common.MetricHandle, method string, start time.Time) {
+	attribution.RecordGCSRequest(ctx, method)
 	metricHandle.GCSRequestCount(ctx, 1, []common.MetricAttr{{Key: common.GCSMethod, Value: method}})
 
 	latencyUs := time.Since(start).Microseconds()
@@ -233,6 +236,7 @@ func (mrc *monitoringReadCloser) Read(p []byte) (n int, err error) {
 	n, err = mrc.wrapped.Read(p)
 	if err == nil || err == io.EOF {
 		mrc.metricHandle.GCSReadBytesCount(mrc.ctx, int64(n), nil)
+		attribution.RecordBytes(mrc.ctx, common.GCSReadDirection, int64(n))
 	}
 	return
 }
@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+)
+
+// ServeHealth serves the liveness and readiness handler of a health monitor
+// at the given address and port, as the Prometheus metrics are served, and
+// returns the function that stops serving them. An empty address serves them
+// on all interfaces.
+func ServeHealth(address string, port int64, handler http.Handler) common.ShutdownFn {
+	addr := net.JoinHostPort(address, strconv.FormatInt(port, 10))
+	logger.Infof("Serving health checks at %s/livez and /readyz", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Failed to start the health check server: %v", err)
+		}
+	}()
+
+	return func(ctx context.Context) error {
+		logger.Info("Shutting down the health check server.")
+		return server.Shutdown(ctx)
+	}
+}
@@ -16,12 +16,15 @@ package monitor
 
 import (
 	"context"
+	"errors"
+	"os"
 
 	cloudtrace "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/googlecloudplatform/gcsfuse/v2/common"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -52,10 +55,65 @@ func newTraceProvider(ctx context.Context, c *cfg.Config) (trace.TracerProvider,
 		return newStdoutTraceProvider()
 	case "gcptrace":
 		return newGCPCloudTraceExporter(ctx, c)
+	case "otlp":
+		return newOTLPTraceProvider(ctx, c)
+	case "file":
+		return newFileTraceProvider(c)
 	default:
 		return nil, nil, nil
 	}
 }
+
+// localSampler samples every trace for the exporters that don't need any
+// cloud service, unless a sampling ratio is set.
+func localSampler(c *cfg.Config) sdktrace.Sampler {
+	if c.Monitoring.ExperimentalTracingSamplingRatio > 0 {
+		return sdktrace.TraceIDRatioBased(c.Monitoring.ExperimentalTracingSamplingRatio)
+	}
+	return sdktrace.AlwaysSample()
+}
+
+func localResource() *resource.Resource {
+	return resource.NewSchemaless(
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(common.GetVersion()),
+	)
+}
+
+// newOTLPTraceProvider exports the spans over OTLP/HTTP, e.g. to a collector
+// or Jaeger running on the same machine.
+func newOTLPTraceProvider(ctx context.Context, c *cfg.Config) (trace.TracerProvider, common.ShutdownFn, error) {
+	var opts []otlptracehttp.Option
+	if c.Monitoring.ExperimentalTracingEndpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(c.Monitoring.ExperimentalTracingEndpoint), otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(localResource()), sdktrace.WithSampler(localSampler(c)))
+	return tp, tp.Shutdown, nil
+}
+
+// newFileTraceProvider appends the spans to a file, one JSON object per span.
+func newFileTraceProvider(c *cfg.Config) (trace.TracerProvider, common.ShutdownFn, error) {
+	f, err := os.OpenFile(string(c.Monitoring.ExperimentalTracingFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(localResource()), sdktrace.WithSampler(localSampler(c)))
+	shutdown := func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), f.Close())
+	}
+	return tp, shutdown, nil
+}
 func newStdoutTraceProvider() (trace.TracerProvider, common.ShutdownFn, error) {
 	exporter, err := stdouttrace.New(
 		stdouttrace.WithPrettyPrint())
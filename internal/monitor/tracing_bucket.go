@@ -0,0 +1,220 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"io"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracingBucket returns a gcs.Bucket that creates a child span of the
+// calling op for every request sent to GCS.
+func NewTracingBucket(b gcs.Bucket) gcs.Bucket {
+	return &tracingBucket{wrapped: b}
+}
+
+type tracingBucket struct {
+	wrapped gcs.Bucket
+}
+
+// startSpan starts the span of a bucket method. The returned context also
+// counts the attempts made by the storage client so that the retry count can
+// be recorded when the span ends.
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx = tracing.WithAttemptCounter(ctx)
+	return tracing.StartSpan(ctx, "gcs."+method, attrs...)
+}
+
+func endSpan(ctx context.Context, span trace.Span, err error) {
+	tracing.SetRetryCount(ctx, span)
+	tracing.EndSpan(span, err)
+}
+
+func setGeneration(span trace.Span, generation int64) {
+	span.SetAttributes(tracing.GenerationKey.Int64(generation))
+}
+
+func (tb *tracingBucket) Name() string {
+	return tb.wrapped.Name()
+}
+
+func (tb *tracingBucket) BucketType() gcs.BucketType {
+	return tb.wrapped.BucketType()
+}
+
+func readRequestAttrs(req *gcs.ReadObjectRequest) []attribute.KeyValue {
+	attrs := tracing.ObjectAttrs(req.Name, req.Generation)
+	if req.Range != nil {
+		attrs = append(attrs, tracing.RangeAttrs(int64(req.Range.Start), int64(req.Range.Limit-req.Range.Start))...)
+	}
+	return attrs
+}
+
+// The spans of the reader methods only cover the creation of the reader, not
+// the reads served by it.
+func (tb *tracingBucket) NewReader(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (rc io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "NewReader", readRequestAttrs(req)...)
+	defer func() { endSpan(ctx, span, err) }()
+	rc, err = tb.wrapped.NewReader(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) NewReaderWithReadHandle(
+	ctx context.Context,
+	req *gcs.ReadObjectRequest) (rd gcs.StorageReader, err error) {
+	ctx, span := startSpan(ctx, "NewReader", readRequestAttrs(req)...)
+	defer func() { endSpan(ctx, span, err) }()
+	rd, err = tb.wrapped.NewReaderWithReadHandle(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) CreateObject(
+	ctx context.Context,
+	req *gcs.CreateObjectRequest) (o *gcs.Object, err error) {
+	ctx, span := startSpan(ctx, "CreateObject", tracing.ObjectKey.String(req.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.CreateObject(ctx, req)
+	if o != nil {
+		setGeneration(span, o.Generation)
+	}
+	return
+}
+
+func (tb *tracingBucket) CreateObjectChunkWriter(ctx context.Context, req *gcs.CreateObjectRequest, chunkSize int, callBack func(bytesUploadedSoFar int64)) (wc gcs.Writer, err error) {
+	ctx, span := startSpan(ctx, "CreateObjectChunkWriter", tracing.ObjectKey.String(req.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	wc, err = tb.wrapped.CreateObjectChunkWriter(ctx, req, chunkSize, callBack)
+	return
+}
+
+func (tb *tracingBucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (o *gcs.MinObject, err error) {
+	ctx, span := startSpan(ctx, "FinalizeUpload", tracing.ObjectKey.String(w.ObjectName()))
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.FinalizeUpload(ctx, w)
+	if o != nil {
+		setGeneration(span, o.Generation)
+	}
+	return
+}
+
+func (tb *tracingBucket) CopyObject(
+	ctx context.Context,
+	req *gcs.CopyObjectRequest) (o *gcs.Object, err error) {
+	ctx, span := startSpan(ctx, "CopyObject", append(tracing.ObjectAttrs(req.SrcName, req.SrcGeneration), tracing.DestinationKey.String(req.DstName))...)
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.CopyObject(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) ComposeObjects(
+	ctx context.Context,
+	req *gcs.ComposeObjectsRequest) (o *gcs.Object, err error) {
+	ctx, span := startSpan(ctx, "ComposeObjects", tracing.ObjectKey.String(req.DstName))
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.ComposeObjects(ctx, req)
+	if o != nil {
+		setGeneration(span, o.Generation)
+	}
+	return
+}
+
+func (tb *tracingBucket) StatObject(
+	ctx context.Context,
+	req *gcs.StatObjectRequest) (m *gcs.MinObject, e *gcs.ExtendedObjectAttributes, err error) {
+	ctx, span := startSpan(ctx, "StatObject", tracing.ObjectKey.String(req.Name))
+	defer func() { endSpan(ctx, span, err) }()
+	m, e, err = tb.wrapped.StatObject(ctx, req)
+	if m != nil {
+		setGeneration(span, m.Generation)
+	}
+	return
+}
+
+func (tb *tracingBucket) ListObjects(
+	ctx context.Context,
+	req *gcs.ListObjectsRequest) (listing *gcs.Listing, err error) {
+	ctx, span := startSpan(ctx, "ListObjects", tracing.PrefixKey.String(req.Prefix))
+	defer func() { endSpan(ctx, span, err) }()
+	listing, err = tb.wrapped.ListObjects(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) UpdateObject(
+	ctx context.Context,
+	req *gcs.UpdateObjectRequest) (o *gcs.Object, err error) {
+	ctx, span := startSpan(ctx, "UpdateObject", tracing.ObjectAttrs(req.Name, req.Generation)...)
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.UpdateObject(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) DeleteObject(
+	ctx context.Context,
+	req *gcs.DeleteObjectRequest) (err error) {
+	ctx, span := startSpan(ctx, "DeleteObject", tracing.ObjectAttrs(req.Name, req.Generation)...)
+	defer func() { endSpan(ctx, span, err) }()
+	err = tb.wrapped.DeleteObject(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (o *gcs.Object, err error) {
+	ctx, span := startSpan(ctx, "MoveObject", append(tracing.ObjectAttrs(req.SrcName, req.SrcGeneration), tracing.DestinationKey.String(req.DstName))...)
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.MoveObject(ctx, req)
+	return
+}
+
+func (tb *tracingBucket) DeleteFolder(ctx context.Context, folderName string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteFolder", tracing.ObjectKey.String(folderName))
+	defer func() { endSpan(ctx, span, err) }()
+	err = tb.wrapped.DeleteFolder(ctx, folderName)
+	return
+}
+
+func (tb *tracingBucket) GetFolder(ctx context.Context, folderName string) (folder *gcs.Folder, err error) {
+	ctx, span := startSpan(ctx, "GetFolder", tracing.ObjectKey.String(folderName))
+	defer func() { endSpan(ctx, span, err) }()
+	folder, err = tb.wrapped.GetFolder(ctx, folderName)
+	return
+}
+
+func (tb *tracingBucket) CreateFolder(ctx context.Context, folderName string) (folder *gcs.Folder, err error) {
+	ctx, span := startSpan(ctx, "CreateFolder", tracing.ObjectKey.String(folderName))
+	defer func() { endSpan(ctx, span, err) }()
+	folder, err = tb.wrapped.CreateFolder(ctx, folderName)
+	return
+}
+
+func (tb *tracingBucket) RenameFolder(ctx context.Context, folderName string, destinationFolderId string) (o *gcs.Folder, err error) {
+	ctx, span := startSpan(ctx, "RenameFolder", tracing.ObjectKey.String(folderName), tracing.DestinationKey.String(destinationFolderId))
+	defer func() { endSpan(ctx, span, err) }()
+	o, err = tb.wrapped.RenameFolder(ctx, folderName, destinationFolderId)
+	return
+}
+
+func (tb *tracingBucket) NewMultiRangeDownloader(
+	ctx context.Context, req *gcs.MultiRangeDownloaderRequest) (mrd gcs.MultiRangeDownloader, err error) {
+	ctx, span := startSpan(ctx, "NewMultiRangeDownloader", tracing.ObjectAttrs(req.Name, req.Generation)...)
+	defer func() { endSpan(ctx, span, err) }()
+	mrd, err = tb.wrapped.NewMultiRangeDownloader(ctx, req)
+	return
+}
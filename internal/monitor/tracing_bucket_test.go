@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setUpTracingBucket(t *testing.T) (gcs.Bucket, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return NewTracingBucket(fake.NewFakeBucket(timeutil.RealClock(), "bucket", gcs.BucketType{})), recorder
+}
+
+func TestTracingBucket_ChildSpansOfOp(t *testing.T) {
+	b, recorder := setUpTracingBucket(t)
+	ctx, op := tracing.StartSpan(context.Background(), "WriteFile")
+
+	o, err := b.CreateObject(ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("hello")})
+	require.NoError(t, err)
+	rd, err := b.NewReaderWithReadHandle(ctx, &gcs.ReadObjectRequest{Name: "foo", Generation: o.Generation, Range: &gcs.ByteRange{Start: 1, Limit: 4}})
+	require.NoError(t, err)
+	require.NoError(t, rd.Close())
+	op.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "gcs.CreateObject", spans[0].Name())
+	assert.Equal(t, op.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), tracing.ObjectKey.String("foo"))
+	assert.Contains(t, spans[0].Attributes(), tracing.GenerationKey.Int64(o.Generation))
+	assert.Contains(t, spans[0].Attributes(), tracing.RetryCountKey.Int64(0))
+	assert.Equal(t, "gcs.NewReader", spans[1].Name())
+	assert.Equal(t, op.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Contains(t, spans[1].Attributes(), tracing.OffsetKey.Int64(1))
+	assert.Contains(t, spans[1].Attributes(), tracing.LengthKey.Int64(3))
+}
+
+func TestTracingBucket_RecordsError(t *testing.T) {
+	b, recorder := setUpTracingBucket(t)
+
+	_, _, err := b.StatObject(context.Background(), &gcs.StatObjectRequest{Name: "missing"})
+
+	require.Error(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "gcs.StatObject", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
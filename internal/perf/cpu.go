@@ -15,7 +15,9 @@
 package perf
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"runtime/pprof"
@@ -25,6 +27,23 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 )
 
+// WriteCPUProfile profiles the CPU for the given duration, or until ctx is
+// done, and writes the profile to w. Only one CPU profile can be taken at a
+// time.
+func WriteCPUProfile(ctx context.Context, w io.Writer, duration time.Duration) error {
+	err := pprof.StartCPUProfile(w)
+	if err != nil {
+		return fmt.Errorf("StartCPUProfile: %w", err)
+	}
+
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
 func HandleCPUProfileSignals() {
 	profileOnce := func(duration time.Duration, path string) (err error) {
 		// Set up the file.
@@ -43,13 +62,10 @@ func HandleCPUProfileSignals() {
 		}()
 
 		// Profile.
-		err = pprof.StartCPUProfile(f)
+		err = WriteCPUProfile(context.Background(), f, duration)
 		if err != nil {
-			logger.Errorf("StartCPUProfile failed: %v", err)
-			return
+			logger.Errorf("WriteCPUProfile failed: %v", err)
 		}
-		time.Sleep(duration)
-		pprof.StopCPUProfile()
 		return
 	}
 
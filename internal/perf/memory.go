@@ -16,6 +16,7 @@ package perf
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"runtime"
@@ -31,12 +32,22 @@ const (
 	MiB = 1024 * KiB
 )
 
+// WriteHeapProfile writes an up to date profile of the heap to w.
+func WriteHeapProfile(w io.Writer) error {
+	// Trigger a garbage collection to get up to date information
+	// (https://tinyurl.com/93d9jh53).
+	runtime.GC()
+
+	err := pprof.Lookup("heap").WriteTo(w, 0)
+	if err != nil {
+		return fmt.Errorf("WriteTo: %w", err)
+	}
+
+	return nil
+}
+
 func HandleMemoryProfileSignals() {
 	profileOnce := func(path string) (err error) {
-		// Trigger a garbage collection to get up to date information
-		// (https://tinyurl.com/93d9jh53).
-		runtime.GC()
-
 		// Open the file.
 		var f *os.File
 		f, err = os.Create(path)
@@ -53,12 +64,7 @@ func HandleMemoryProfileSignals() {
 		}()
 
 		// Dump to the file.
-		err = pprof.Lookup("heap").WriteTo(f, 0)
-		if err != nil {
-			err = fmt.Errorf("WriteTo: %w", err)
-			return
-		}
-
+		err = WriteHeapProfile(f)
 		return
 	}
 
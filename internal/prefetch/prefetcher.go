@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prefetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+)
+
+// BucketFunc returns the mounted bucket with the given name.
+type BucketFunc func(ctx context.Context, name string) (gcs.Bucket, error)
+
+// Result counts the objects of a manifest by the outcome of warming the file
+// cache with them.
+type Result struct {
+	// Downloaded into the cache, and their total size.
+	Warmed int
+	Bytes  uint64
+
+	// Deleted or overwritten since they were read, or in buckets that aren't
+	// mounted.
+	Skipped int
+
+	// Not downloaded because of an error, or because the cache would have
+	// been full.
+	Failed int
+}
+
+// Warm downloads the objects in the manifest into the file cache, in manifest
+// order and with at most concurrency downloads at once. Objects whose
+// generation changed since they were read are skipped. It stops once the
+// objects downloaded would fill the cache, so that they don't evict each
+// other, or when ctx is cancelled.
+func Warm(ctx context.Context, manifest []Access, cache *file.CacheHandler, buckets BucketFunc, concurrency int) Result {
+	_, capacity := cache.Usage()
+	w := &warmer{
+		cache:    cache,
+		sem:      make(chan struct{}, concurrency),
+		buckets:  make(map[string]gcs.Bucket),
+		resolve:  buckets,
+		capacity: capacity,
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range manifest {
+		bucket, err := w.bucket(ctx, a.Bucket)
+		if err != nil {
+			logger.FileCache.Tracef("Not prefetching %s/%s: %v", a.Bucket, a.Object, err)
+			w.count(func(r *Result) { r.Skipped++ })
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case w.sem <- struct{}{}:
+		}
+		if ctx.Err() != nil || w.isFull() {
+			break
+		}
+
+		wg.Add(1)
+		go func(a Access) {
+			defer wg.Done()
+			defer func() { <-w.sem }()
+			w.warm(ctx, bucket, a)
+		}(a)
+	}
+	wg.Wait()
+
+	return w.result
+}
+
+type warmer struct {
+	cache *file.CacheHandler
+
+	// Holds a token for every download in progress.
+	sem chan struct{}
+
+	// Only accessed by the goroutine calling Warm.
+	buckets map[string]gcs.Bucket
+	resolve BucketFunc
+
+	capacity uint64
+
+	mu       sync.Mutex
+	reserved uint64 // GUARDED_BY(mu)
+	full     bool   // GUARDED_BY(mu)
+	result   Result // GUARDED_BY(mu)
+}
+
+func (w *warmer) bucket(ctx context.Context, name string) (gcs.Bucket, error) {
+	if b, ok := w.buckets[name]; ok {
+		if b == nil {
+			return nil, errors.New("bucket not mounted")
+		}
+		return b, nil
+	}
+
+	b, err := w.resolve(ctx, name)
+	if err != nil {
+		logger.FileCache.Warnf("Not prefetching objects of bucket %q: %v", name, err)
+		w.buckets[name] = nil
+		return nil, err
+	}
+	w.buckets[name] = b
+	return b, nil
+}
+
+// LOCKS_EXCLUDED(w.mu)
+func (w *warmer) count(f func(r *Result)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f(&w.result)
+}
+
+// LOCKS_EXCLUDED(w.mu)
+func (w *warmer) isFull() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.full
+}
+
+// reserve reserves room in the cache for an object of the given size, and
+// returns false if there's none left.
+//
+// LOCKS_EXCLUDED(w.mu)
+func (w *warmer) reserve(size uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.full || size > w.capacity-w.reserved {
+		w.full = true
+		return false
+	}
+	w.reserved += size
+	return true
+}
+
+func (w *warmer) warm(ctx context.Context, bucket gcs.Bucket, a Access) {
+	o, _, err := bucket.StatObject(ctx, &gcs.StatObjectRequest{Name: a.Object})
+	var notFoundErr *gcs.NotFoundError
+	switch {
+	case errors.As(err, &notFoundErr):
+		logger.FileCache.Tracef("Not prefetching %s/%s: deleted since it was read", a.Bucket, a.Object)
+		w.count(func(r *Result) { r.Skipped++ })
+		return
+	case err != nil:
+		logger.FileCache.Tracef("Not prefetching %s/%s: %v", a.Bucket, a.Object, err)
+		w.count(func(r *Result) { r.Failed++ })
+		return
+	case o.Generation != a.Generation:
+		logger.FileCache.Tracef("Not prefetching %s/%s: overwritten since it was read", a.Bucket, a.Object)
+		w.count(func(r *Result) { r.Skipped++ })
+		return
+	}
+
+	if !w.reserve(o.Size) {
+		w.count(func(r *Result) { r.Failed++ })
+		return
+	}
+
+	if err := w.download(ctx, bucket, o); err != nil {
+		logger.FileCache.Tracef("Not prefetching %s/%s: %v", a.Bucket, a.Object, err)
+		w.count(func(r *Result) { r.Failed++ })
+		return
+	}
+	w.count(func(r *Result) {
+		r.Warmed++
+		r.Bytes += o.Size
+	})
+}
+
+func (w *warmer) download(ctx context.Context, bucket gcs.Bucket, o *gcs.MinObject) error {
+	handle, err := w.cache.GetCacheHandle(o, bucket, true, 0)
+	if err != nil {
+		return fmt.Errorf("GetCacheHandle: %w", err)
+	}
+	defer handle.Close()
+
+	return handle.Prefetch(ctx, int64(o.Size))
+}
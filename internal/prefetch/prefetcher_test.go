@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prefetch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
+	"github.com/googlecloudplatform/gcsfuse/v2/common"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/file/downloader"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/lru"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/cache/util"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/fake"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const objectSize = 1024
+
+type warmTest struct {
+	bucket gcs.Bucket
+	cache  *file.CacheHandler
+	gens   map[string]int64
+}
+
+func newWarmTest(t *testing.T, capacity uint64, objects ...string) *warmTest {
+	t.Helper()
+	ctx := context.Background()
+	wt := &warmTest{
+		bucket: fake.NewFakeBucket(timeutil.RealClock(), "bucket", gcs.BucketType{}),
+		gens:   make(map[string]int64),
+	}
+	for _, name := range objects {
+		o, err := storageutil.CreateObject(ctx, wt.bucket, name, make([]byte, objectSize))
+		require.NoError(t, err)
+		wt.gens[name] = o.Generation
+	}
+
+	cacheDir := t.TempDir()
+	fileInfoCache := lru.NewCache(capacity)
+	fileCacheConfig := &cfg.FileCacheConfig{DownloadChunkSizeMb: 1}
+	jobManager := downloader.NewJobManager(fileInfoCache, util.DefaultFilePerm, util.DefaultDirPerm,
+		cacheDir, 1, fileCacheConfig, common.NewNoopMetrics(), nil, nil)
+	wt.cache = file.NewCacheHandler(fileInfoCache, jobManager, cacheDir, util.DefaultFilePerm, util.DefaultDirPerm, nil)
+	t.Cleanup(func() { _ = wt.cache.Destroy() })
+	return wt
+}
+
+func (wt *warmTest) buckets(_ context.Context, name string) (gcs.Bucket, error) {
+	if name != wt.bucket.Name() {
+		return nil, fmt.Errorf("bucket %q not mounted", name)
+	}
+	return wt.bucket, nil
+}
+
+func (wt *warmTest) access(name string) Access {
+	return Access{Bucket: "bucket", Object: name, Generation: wt.gens[name], Length: objectSize}
+}
+
+func TestWarm(t *testing.T) {
+	wt := newWarmTest(t, 10*objectSize, "a", "b", "c")
+	manifest := []Access{wt.access("a"), wt.access("b"), wt.access("c")}
+
+	result := Warm(context.Background(), manifest, wt.cache, wt.buckets, 2)
+
+	assert.Equal(t, Result{Warmed: 3, Bytes: 3 * objectSize}, result)
+	used, _ := wt.cache.Usage()
+	assert.Equal(t, uint64(3*objectSize), used)
+}
+
+func TestWarm_SkipsChangedObjects(t *testing.T) {
+	wt := newWarmTest(t, 10*objectSize, "a", "b")
+	overwritten := wt.access("b")
+	overwritten.Generation++
+	manifest := []Access{
+		wt.access("a"),
+		overwritten,
+		{Bucket: "bucket", Object: "deleted", Generation: 1},
+		{Bucket: "unmounted", Object: "a", Generation: 1},
+	}
+
+	result := Warm(context.Background(), manifest, wt.cache, wt.buckets, 1)
+
+	assert.Equal(t, Result{Warmed: 1, Bytes: objectSize, Skipped: 3}, result)
+}
+
+func TestWarm_StopsWhenCacheFull(t *testing.T) {
+	wt := newWarmTest(t, 2*objectSize, "a", "b", "c", "d")
+	manifest := []Access{wt.access("a"), wt.access("b"), wt.access("c"), wt.access("d")}
+
+	result := Warm(context.Background(), manifest, wt.cache, wt.buckets, 1)
+
+	assert.Equal(t, 2, result.Warmed)
+	assert.Equal(t, 1, result.Failed)
+	used, _ := wt.cache.Usage()
+	assert.Equal(t, uint64(2*objectSize), used)
+}
+
+func TestWarm_Cancelled(t *testing.T) {
+	wt := newWarmTest(t, 10*objectSize, "a")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := Warm(ctx, []Access{wt.access("a")}, wt.cache, wt.buckets, 1)
+
+	assert.Zero(t, result.Warmed)
+}
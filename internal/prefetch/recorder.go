@@ -0,0 +1,292 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prefetch records the objects that a mount reads to an access
+// manifest, and warms the file cache from that manifest at the next mount, so
+// that workloads that read the same objects on every run, like training
+// epochs, don't start cold.
+package prefetch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
+	"github.com/jacobsa/timeutil"
+)
+
+// The interval at which a recorder writes its manifest, so that little is lost
+// if the process dies without unmounting.
+const flushInterval = time.Minute
+
+// The number of objects that a recorder keeps track of, which bounds its
+// memory for mounts that read very many objects. Reads of further objects
+// aren't recorded.
+const maxObjects = 1 << 20
+
+// Access is the record of the reads of one object, compacted into the extent
+// of the object that was read.
+type Access struct {
+	Bucket     string `json:"bucket"`
+	Object     string `json:"object"`
+	Generation int64  `json:"generation"`
+
+	// The extent of the object that was read.
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+
+	// The bytes and number of reads, counting re-reads of the same extent.
+	Bytes int64 `json:"bytes"`
+	Reads int64 `json:"reads"`
+
+	// When the object was first and last read. Manifests are written in the
+	// order of the first read.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+type accessKey struct {
+	bucket string
+	object string
+}
+
+// Recorder records the reads of a mount to a manifest file. A nil *Recorder
+// discards them.
+//
+// Safe for concurrent access.
+type Recorder struct {
+	path  string
+	clock timeutil.Clock
+
+	mu       sync.Mutex
+	accesses map[accessKey]*Access // GUARDED_BY(mu)
+	dirty    bool                  // GUARDED_BY(mu)
+	full     bool                  // GUARDED_BY(mu)
+
+	// Serializes writes of the manifest file.
+	flushMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder returns a recorder that writes its manifest to the file at path
+// every minute and when closed. The file is only replaced once something has
+// been read, so that the manifest of the previous run survives a mount that
+// reads nothing.
+func NewRecorder(path string) *Recorder {
+	r := newRecorder(path, timeutil.RealClock())
+	go r.flushPeriodically()
+	return r
+}
+
+func newRecorder(path string, clock timeutil.Clock) *Recorder {
+	return &Recorder{
+		path:     path,
+		clock:    clock,
+		accesses: make(map[accessKey]*Access),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record records a read of n bytes at offset from the given generation of an
+// object. If the object was previously read at another generation, the record
+// of those reads is replaced, keeping only its place in the order.
+func (r *Recorder) Record(bucket, object string, generation, offset int64, n int) {
+	if r == nil || n <= 0 {
+		return
+	}
+	now := r.clock.Now()
+	end := offset + int64(n)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := accessKey{bucket: bucket, object: object}
+	a := r.accesses[k]
+	if a == nil || a.Generation != generation {
+		if a == nil && len(r.accesses) >= maxObjects {
+			if !r.full {
+				logger.FileCache.Warnf("Not recording reads of more than %d objects to access manifest %s", maxObjects, r.path)
+				r.full = true
+			}
+			return
+		}
+		first := now
+		if a != nil {
+			first = a.First
+		}
+		a = &Access{
+			Bucket:     bucket,
+			Object:     object,
+			Generation: generation,
+			Offset:     offset,
+			Length:     int64(n),
+			First:      first,
+		}
+		r.accesses[k] = a
+	}
+
+	a.Offset = min(a.Offset, offset)
+	a.Length = max(a.Offset+a.Length, end) - a.Offset
+	a.Bytes += int64(n)
+	a.Reads++
+	a.Last = now
+	r.dirty = true
+}
+
+// Flush writes the manifest file, if anything was read since it was last
+// written.
+//
+// LOCKS_EXCLUDED(r.mu)
+func (r *Recorder) Flush() error {
+	if r == nil {
+		return nil
+	}
+
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	manifest := make([]Access, 0, len(r.accesses))
+	for _, a := range r.accesses {
+		manifest = append(manifest, *a)
+	}
+	r.dirty = false
+	r.mu.Unlock()
+
+	sort.Slice(manifest, func(i, j int) bool {
+		if !manifest[i].First.Equal(manifest[j].First) {
+			return manifest[i].First.Before(manifest[j].First)
+		}
+		if manifest[i].Bucket != manifest[j].Bucket {
+			return manifest[i].Bucket < manifest[j].Bucket
+		}
+		return manifest[i].Object < manifest[j].Object
+	})
+
+	if err := WriteManifest(r.path, manifest); err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close stops the periodic writes of the manifest file and writes it a last
+// time.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	close(r.stop)
+	<-r.done
+	return r.Flush()
+}
+
+func (r *Recorder) flushPeriodically() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				logger.FileCache.Warnf("Writing access manifest: %v", err)
+			}
+		}
+	}
+}
+
+// WriteManifest replaces the manifest file at path with the given accesses, one
+// JSON object per line. The file is replaced atomically, so that readers never
+// see a partly written manifest.
+func WriteManifest(path string, manifest []Access) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("creating access manifest: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range manifest {
+		if err = enc.Encode(&manifest[i]); err != nil {
+			f.Close()
+			return fmt.Errorf("writing access manifest: %w", err)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing access manifest: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("writing access manifest: %w", err)
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("replacing access manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest file at path, in file order. It returns no
+// accesses and no error if the file doesn't exist, as on the first run.
+func ReadManifest(path string) ([]Access, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening access manifest: %w", err)
+	}
+	defer f.Close()
+
+	var manifest []Access
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var a Access
+		err := dec.Decode(&a)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing access manifest %s: %w", path, err)
+		}
+		manifest = append(manifest, a)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prefetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var startTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestRecorder(t *testing.T) (*Recorder, *timeutil.SimulatedClock) {
+	t.Helper()
+	clock := &timeutil.SimulatedClock{}
+	clock.SetTime(startTime)
+	return newRecorder(filepath.Join(t.TempDir(), "manifest.jsonl"), clock), clock
+}
+
+func TestRecorder_CompactsReadsPerObject(t *testing.T) {
+	r, clock := newTestRecorder(t)
+
+	r.Record("bucket", "a", 1, 100, 50)
+	clock.AdvanceTime(time.Second)
+	r.Record("bucket", "a", 1, 0, 20)
+	r.Record("bucket", "a", 1, 140, 60)
+	r.Record("bucket", "a", 1, 0, 0)
+	require.NoError(t, r.Flush())
+
+	manifest, err := ReadManifest(r.path)
+	require.NoError(t, err)
+	assert.Equal(t, []Access{{
+		Bucket:     "bucket",
+		Object:     "a",
+		Generation: 1,
+		Offset:     0,
+		Length:     200,
+		Bytes:      130,
+		Reads:      3,
+		First:      startTime,
+		Last:       startTime.Add(time.Second),
+	}}, manifest)
+}
+
+func TestRecorder_OrdersByFirstRead(t *testing.T) {
+	r, clock := newTestRecorder(t)
+
+	r.Record("bucket", "b", 1, 0, 10)
+	clock.AdvanceTime(time.Second)
+	r.Record("bucket", "a", 1, 0, 10)
+	clock.AdvanceTime(time.Second)
+	r.Record("other", "c", 1, 0, 10)
+	r.Record("bucket", "b", 1, 10, 10)
+	require.NoError(t, r.Flush())
+
+	manifest, err := ReadManifest(r.path)
+	require.NoError(t, err)
+	require.Len(t, manifest, 3)
+	assert.Equal(t, "b", manifest[0].Object)
+	assert.Equal(t, "a", manifest[1].Object)
+	assert.Equal(t, "c", manifest[2].Object)
+}
+
+func TestRecorder_NewGenerationReplacesReads(t *testing.T) {
+	r, clock := newTestRecorder(t)
+
+	r.Record("bucket", "a", 1, 0, 100)
+	r.Record("bucket", "b", 1, 0, 100)
+	clock.AdvanceTime(time.Second)
+	r.Record("bucket", "a", 2, 50, 10)
+	require.NoError(t, r.Flush())
+
+	manifest, err := ReadManifest(r.path)
+	require.NoError(t, err)
+	require.Len(t, manifest, 2)
+	assert.Equal(t, Access{
+		Bucket:     "bucket",
+		Object:     "a",
+		Generation: 2,
+		Offset:     50,
+		Length:     10,
+		Bytes:      10,
+		Reads:      1,
+		First:      startTime,
+		Last:       startTime.Add(time.Second),
+	}, manifest[0])
+}
+
+func TestRecorder_FlushKeepsManifestIfNothingRead(t *testing.T) {
+	r, _ := newTestRecorder(t)
+	previous := []Access{{Bucket: "bucket", Object: "a", Generation: 1, Length: 10}}
+	require.NoError(t, WriteManifest(r.path, previous))
+
+	require.NoError(t, r.Flush())
+
+	manifest, err := ReadManifest(r.path)
+	require.NoError(t, err)
+	assert.Equal(t, previous, manifest)
+}
+
+func TestRecorder_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.jsonl")
+	r := NewRecorder(path)
+	r.Record("bucket", "a", 1, 0, 10)
+
+	require.NoError(t, r.Close())
+
+	manifest, err := ReadManifest(path)
+	require.NoError(t, err)
+	assert.Len(t, manifest, 1)
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+
+	r.Record("bucket", "a", 1, 0, 10)
+
+	assert.NoError(t, r.Flush())
+	assert.NoError(t, r.Close())
+}
+
+func TestReadManifest_Missing(t *testing.T) {
+	manifest, err := ReadManifest(filepath.Join(t.TempDir(), "manifest.jsonl"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, manifest)
+}
+
+func TestReadManifest_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"bucket\":\"b\"}\nnot json\n"), 0600))
+
+	_, err := ReadManifest(path)
+
+	assert.ErrorContains(t, err, "parsing access manifest")
+}
+
+func TestWriteManifest_LeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.jsonl")
+
+	require.NoError(t, WriteManifest(path, []Access{{Bucket: "bucket", Object: "a"}}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "manifest.jsonl", entries[0].Name())
+}
@@ -36,6 +36,17 @@ type Throttle interface {
 	Wait(ctx context.Context, tokens uint64) (err error)
 }
 
+// A Throttle whose rate can be changed while it's in use.
+//
+// Safe for concurrent access.
+type AdjustableThrottle interface {
+	Throttle
+
+	// Change the rate and the capacity, effective for waits that haven't yet
+	// acquired their tokens.
+	SetRate(rateHz float64, capacity uint64)
+}
+
 type limiter struct {
 	*rate.Limiter
 }
@@ -43,6 +54,13 @@ type limiter struct {
 func NewThrottle(
 	rateHz float64,
 	capacity uint64) (t Throttle) {
+	t = NewAdjustableThrottle(rateHz, capacity)
+	return
+}
+
+func NewAdjustableThrottle(
+	rateHz float64,
+	capacity uint64) (t AdjustableThrottle) {
 	t = &limiter{rate.NewLimiter(rate.Limit(rateHz), int(capacity))}
 	return
 }
@@ -56,3 +74,8 @@ func (l *limiter) Wait(
 	tokens uint64) (err error) {
 	return l.WaitN(ctx, int(tokens))
 }
+
+func (l *limiter) SetRate(rateHz float64, capacity uint64) {
+	l.SetLimit(rate.Limit(rateHz))
+	l.SetBurst(int(capacity))
+}
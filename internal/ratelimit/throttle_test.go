@@ -202,3 +202,16 @@ func (t *ThrottleTest) TestIntegration() {
 			fmt.Sprintf("Test case %d. expected: %f", i, expected))
 	}
 }
+
+func (t *ThrottleTest) TestSetRate() {
+	throttle := ratelimit.NewAdjustableThrottle(1e-3, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Use up the only token, which at this rate won't come back in the test.
+	assert.NoError(t.T(), throttle.Wait(ctx, 1))
+
+	throttle.SetRate(1000, 10)
+
+	assert.Equal(t.T(), uint64(10), throttle.Capacity())
+	assert.NoError(t.T(), throttle.Wait(ctx, 10))
+}
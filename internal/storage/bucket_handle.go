@@ -210,7 +210,7 @@ func (bh *bucketHandle) CreateObject(ctx context.Context, req *gcs.CreateObjectR
 	wc.ChunkTransferTimeout = time.Duration(req.ChunkTransferTimeoutSecs) * time.Second
 	wc = storageutil.SetAttrsInWriter(wc, req)
 	wc.ProgressFunc = func(bytesUploadedSoFar int64) {
-		logger.Tracef("gcs: Req %#16x: -- CreateObject(%q): %20v bytes uploaded so far", ctx.Value(gcs.ReqIdField), req.Name, bytesUploadedSoFar)
+		logger.GCS.Tracef("gcs: Req %#16x: -- CreateObject(%q): %20v bytes uploaded so far", ctx.Value(gcs.ReqIdField), req.Name, bytesUploadedSoFar)
 	}
 	// All objects in zonal buckets must be appendable.
 	wc.Append = bh.BucketType().Zonal
@@ -259,7 +259,7 @@ func (bh *bucketHandle) CreateObjectChunkWriter(ctx context.Context, req *gcs.Cr
 	wc.Writer = storageutil.SetAttrsInWriter(wc.Writer, req)
 	if callBack == nil {
 		callBack = func(bytesUploadedSoFar int64) {
-			logger.Tracef("gcs: Req %#16x: -- UploadBlock(%q): %20v bytes uploaded so far", ctx.Value(gcs.ReqIdField), req.Name, bytesUploadedSoFar)
+			logger.GCS.Tracef("gcs: Req %#16x: -- UploadBlock(%q): %20v bytes uploaded so far", ctx.Value(gcs.ReqIdField), req.Name, bytesUploadedSoFar)
 		}
 	}
 	wc.ProgressFunc = callBack
@@ -572,6 +572,15 @@ func (bh *bucketHandle) MoveObject(ctx context.Context, req *gcs.MoveObjectReque
 		Conditions: nil,
 	}
 
+	// Putting a condition on the current generation of the destination, if any.
+	if req.DstGenerationPrecondition != nil {
+		if *req.DstGenerationPrecondition == 0 {
+			dstMoveObject.Conditions = &storage.Conditions{DoesNotExist: true}
+		} else {
+			dstMoveObject.Conditions = &storage.Conditions{GenerationMatch: *req.DstGenerationPrecondition}
+		}
+	}
+
 	attrs, err := obj.Move(ctx, dstMoveObject)
 	if err == nil {
 		// Converting objAttrs to type *Object
@@ -678,6 +687,15 @@ func (bh *bucketHandle) NewMultiRangeDownloader(
 	return obj.NewMultiRangeDownloader(ctx)
 }
 
+func (bh *bucketHandle) TestPermissions(ctx context.Context, permissions []string) ([]string, error) {
+	granted, err := bh.bucket.IAM().TestPermissions(ctx, permissions)
+	if err != nil {
+		return nil, fmt.Errorf("error testing permissions on bucket %s: %w", bh.bucketName, err)
+	}
+
+	return granted, nil
+}
+
 func isStorageConditionsNotEmpty(conditions storage.Conditions) bool {
 	return conditions != (storage.Conditions{})
 }
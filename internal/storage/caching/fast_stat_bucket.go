@@ -25,6 +25,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"golang.org/x/net/context"
 
 	"github.com/jacobsa/timeutil"
@@ -66,15 +67,35 @@ type fastStatBucket struct {
 	wrapped gcs.Bucket
 
 	/////////////////////////
-	// Constant data
+	// Mutable state
 	/////////////////////////
 
 	// TTL for entries for existing files and folders in the cache.
+	//
+	// GUARDED_BY(mu)
 	primaryCacheTTL time.Duration
 	// TTL for entries for non-existing files and folders in the cache.
+	//
+	// GUARDED_BY(mu)
 	negativeCacheTTL time.Duration
 }
 
+// TTLSetter is implemented by the buckets that NewFastStatBucket returns.
+type TTLSetter interface {
+	// Change the TTLs of the entries cached from now on. Entries already in the
+	// cache keep their expiration.
+	SetTTLs(primaryCacheTTL, negativeCacheTTL time.Duration)
+}
+
+// LOCKS_EXCLUDED(b.mu)
+func (b *fastStatBucket) SetTTLs(primaryCacheTTL, negativeCacheTTL time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.primaryCacheTTL = primaryCacheTTL
+	b.negativeCacheTTL = negativeCacheTTL
+}
+
 ////////////////////////////////////////////////////////////////////////
 // Helpers
 ////////////////////////////////////////////////////////////////////////
@@ -128,7 +149,7 @@ func (b *fastStatBucket) insertHierarchicalListing(listing *gcs.Listing) {
 	for _, p := range listing.CollapsedRuns {
 		if !strings.HasSuffix(p, "/") {
 			// log the error for incorrect prefix but don't fail the operation
-			logger.Errorf("error in prefix name: %s", p)
+			logger.MetadataCache.Errorf("error in prefix name: %s", p)
 		} else {
 			f := &gcs.Folder{
 				Name: p,
@@ -319,7 +340,11 @@ func (b *fastStatBucket) StatObject(
 	}
 
 	// Do we have an entry in the cache?
-	if hit, entry := b.lookUp(req.Name); hit {
+	_, span := tracing.StartSpan(ctx, "statcache.LookUp", tracing.ObjectKey.String(req.Name))
+	hit, entry := b.lookUp(req.Name)
+	span.SetAttributes(tracing.CacheHitKey.Bool(hit))
+	span.End()
+	if hit {
 		// Negative entries result in NotFoundError.
 		if entry == nil {
 			err = &gcs.NotFoundError{
@@ -439,7 +464,11 @@ func (b *fastStatBucket) StatObjectFromGcs(ctx context.Context,
 }
 
 func (b *fastStatBucket) GetFolder(ctx context.Context, prefix string) (*gcs.Folder, error) {
-	if hit, entry := b.lookUpFolder(prefix); hit {
+	_, span := tracing.StartSpan(ctx, "statcache.LookUpFolder", tracing.ObjectKey.String(prefix))
+	hit, entry := b.lookUpFolder(prefix)
+	span.SetAttributes(tracing.CacheHitKey.Bool(hit))
+	span.End()
+	if hit {
 		// Negative entries result in NotFoundError.
 		if entry == nil {
 			err := &gcs.NotFoundError{
@@ -673,6 +673,34 @@ func (t *StatObjectTest) WrappedSucceeds() {
 	ExpectEq(minObj, m)
 }
 
+func (t *StatObjectTest) UsesChangedTTLs() {
+	const name = "taco"
+	t.bucket.(caching.TTLSetter).SetTTLs(time.Minute, time.Hour)
+
+	// LookUp
+	ExpectCall(t.cache, "LookUp")(Any(), Any()).
+		WillRepeatedly(Return(false, nil))
+
+	// Wrapped
+	ExpectCall(t.wrapped, "StatObject")(Any(), Any()).
+		WillOnce(Return(&gcs.MinObject{Name: name}, nil, nil)).
+		WillOnce(Return(nil, nil, &gcs.NotFoundError{Err: errors.New("burrito")}))
+
+	// Insert and AddNegativeEntry
+	ExpectCall(t.cache, "Insert")(Any(), timeutil.TimeEq(t.clock.Now().Add(time.Minute)))
+	ExpectCall(t.cache, "AddNegativeEntry")(name, timeutil.TimeEq(t.clock.Now().Add(time.Hour)))
+
+	// Call
+	req := &gcs.StatObjectRequest{
+		Name: name,
+	}
+
+	_, _, err := t.bucket.StatObject(context.TODO(), req)
+	AssertEq(nil, err)
+	_, _, err = t.bucket.StatObject(context.TODO(), req)
+	ExpectThat(err, HasSameTypeAs(&gcs.NotFoundError{}))
+}
+
 ////////////////////////////////////////////////////////////////////////
 // ListObjects
 ////////////////////////////////////////////////////////////////////////
@@ -21,8 +21,10 @@ import (
 	"time"
 
 	storagev2 "cloud.google.com/go/storage"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/inflight"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/timeline"
 	"golang.org/x/net/context"
 )
 
@@ -55,33 +57,49 @@ func (b *debugBucket) requestLogf(
 	id uint64,
 	format string,
 	v ...interface{}) {
-	logger.Tracef("gcs: Req %#16x: %s", id, fmt.Sprintf(format, v...))
+	logger.GCS.Tracef("gcs: Req %#16x: %s", id, fmt.Sprintf(format, v...))
 }
 
+// A request in progress.
+type debugRequest struct {
+	id    uint64
+	desc  string
+	start time.Time
+	span  *timeline.Span
+}
+
+// startRequest logs the start of a request, records it as in progress for the
+// op in ctx, if any, and begins its span on the timeline.
 func (b *debugBucket) startRequest(
+	ctx context.Context,
 	format string,
-	v ...interface{}) (id uint64, desc string, start time.Time) {
-	start = time.Now()
-	id = b.mintRequestID()
-	desc = fmt.Sprintf(format, v...)
+	v ...interface{}) *debugRequest {
+	r := &debugRequest{
+		id:    b.mintRequestID(),
+		desc:  fmt.Sprintf(format, v...),
+		start: time.Now(),
+	}
+	r.span = timeline.Begin(timeline.GCS, r.desc, "bucket", b.Name())
 
-	b.requestLogf(id, "<- %s", desc)
-	return
+	b.requestLogf(r.id, "<- %s", r.desc)
+	inflight.BeginRequest(ctx, r.id, r.desc)
+	return r
 }
 
 func (b *debugBucket) finishRequest(
-	id uint64,
-	desc string,
-	start time.Time,
+	ctx context.Context,
+	r *debugRequest,
 	err *error) {
-	duration := time.Since(start)
+	duration := time.Since(r.start)
+	inflight.EndRequest(ctx, r.id)
+	r.span.End(*err)
 
 	errDesc := "OK"
 	if *err != nil {
 		errDesc = (*err).Error()
 	}
 
-	b.requestLogf(id, "-> %s (%v): %s", desc, duration, errDesc)
+	b.requestLogf(r.id, "-> %s (%v): %s", r.desc, duration, errDesc)
 }
 
 ////////////////////////////////////////////////////////////////////////
@@ -89,11 +107,12 @@ func (b *debugBucket) finishRequest(
 ////////////////////////////////////////////////////////////////////////
 
 type debugReader struct {
-	bucket    *debugBucket
-	requestID uint64
-	desc      string
-	startTime time.Time
-	wrapped   io.ReadCloser
+	// The context of the op that created the reader, for which the read is
+	// in progress until the reader is closed.
+	ctx     context.Context
+	bucket  *debugBucket
+	request *debugRequest
+	wrapped io.ReadCloser
 }
 
 func (dr *debugReader) Read(p []byte) (n int, err error) {
@@ -101,18 +120,14 @@ func (dr *debugReader) Read(p []byte) (n int, err error) {
 
 	// Don't log EOF errors, which are par for the course.
 	if err != nil && err != io.EOF {
-		dr.bucket.requestLogf(dr.requestID, "-> Read error: %v", err)
+		dr.bucket.requestLogf(dr.request.id, "-> Read error: %v", err)
 	}
 
 	return
 }
 
 func (dr *debugReader) Close() (err error) {
-	defer dr.bucket.finishRequest(
-		dr.requestID,
-		dr.desc,
-		dr.startTime,
-		&err)
+	defer dr.bucket.finishRequest(dr.ctx, dr.request, &err)
 
 	err = dr.wrapped.Close()
 	return
@@ -136,22 +151,21 @@ func (b *debugBucket) BucketType() gcs.BucketType {
 }
 
 func setupReader(ctx context.Context, b *debugBucket, req *gcs.ReadObjectRequest, method string) (gcs.StorageReader, error) {
-	id, desc, start := b.startRequest("%q(%q, %v)", method, req.Name, req.Range)
+	request := b.startRequest(ctx, "%q(%q, %v)", method, req.Name, req.Range)
 
 	// Call through.
 	rc, err := b.wrapped.NewReaderWithReadHandle(ctx, req)
 	if err != nil {
-		b.finishRequest(id, desc, start, &err)
+		b.finishRequest(ctx, request, &err)
 		return rc, err
 	}
 
 	// Return a special reader that prings debug info.
 	rc = &debugReader{
-		bucket:    b,
-		requestID: id,
-		desc:      desc,
-		startTime: start,
-		wrapped:   rc,
+		ctx:     ctx,
+		bucket:  b,
+		request: request,
+		wrapped: rc,
 	}
 	return rc, err
 }
@@ -173,24 +187,24 @@ func (b *debugBucket) NewReaderWithReadHandle(
 func (b *debugBucket) CreateObject(
 	ctx context.Context,
 	req *gcs.CreateObjectRequest) (o *gcs.Object, err error) {
-	id, desc, start := b.startRequest("CreateObject(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "CreateObject(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
-	o, err = b.wrapped.CreateObject(context.WithValue(ctx, gcs.ReqIdField, id), req)
+	o, err = b.wrapped.CreateObject(context.WithValue(ctx, gcs.ReqIdField, request.id), req)
 	return
 }
 
 func (b *debugBucket) CreateObjectChunkWriter(ctx context.Context, req *gcs.CreateObjectRequest, chunkSize int, callBack func(bytesUploadedSoFar int64)) (wc gcs.Writer, err error) {
-	id, desc, start := b.startRequest("CreateObjectChunkWriter(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "CreateObjectChunkWriter(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
-	wc, err = b.wrapped.CreateObjectChunkWriter(context.WithValue(ctx, gcs.ReqIdField, id), req, chunkSize, callBack)
+	wc, err = b.wrapped.CreateObjectChunkWriter(context.WithValue(ctx, gcs.ReqIdField, request.id), req, chunkSize, callBack)
 	return
 }
 
 func (b *debugBucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (o *gcs.MinObject, err error) {
-	id, desc, start := b.startRequest("FinalizeUpload(%q)", w.ObjectName())
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "FinalizeUpload(%q)", w.ObjectName())
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.FinalizeUpload(ctx, w)
 	return
@@ -199,12 +213,12 @@ func (b *debugBucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (o *gcs.
 func (b *debugBucket) CopyObject(
 	ctx context.Context,
 	req *gcs.CopyObjectRequest) (o *gcs.Object, err error) {
-	id, desc, start := b.startRequest(
+	request := b.startRequest(ctx,
 		"CopyObject(%q, %q)",
 		req.SrcName,
 		req.DstName)
 
-	defer b.finishRequest(id, desc, start, &err)
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.CopyObject(ctx, req)
 	return
@@ -213,11 +227,11 @@ func (b *debugBucket) CopyObject(
 func (b *debugBucket) ComposeObjects(
 	ctx context.Context,
 	req *gcs.ComposeObjectsRequest) (o *gcs.Object, err error) {
-	id, desc, start := b.startRequest(
+	request := b.startRequest(ctx,
 		"ComposeObjects(%q)",
 		req.DstName)
 
-	defer b.finishRequest(id, desc, start, &err)
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.ComposeObjects(ctx, req)
 	return
@@ -226,8 +240,8 @@ func (b *debugBucket) ComposeObjects(
 func (b *debugBucket) StatObject(
 	ctx context.Context,
 	req *gcs.StatObjectRequest) (m *gcs.MinObject, e *gcs.ExtendedObjectAttributes, err error) {
-	id, desc, start := b.startRequest("StatObject(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "StatObject(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
 	m, e, err = b.wrapped.StatObject(ctx, req)
 	return
@@ -236,8 +250,8 @@ func (b *debugBucket) StatObject(
 func (b *debugBucket) ListObjects(
 	ctx context.Context,
 	req *gcs.ListObjectsRequest) (listing *gcs.Listing, err error) {
-	id, desc, start := b.startRequest("ListObjects(%q)", req.Prefix)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "ListObjects(%q)", req.Prefix)
+	defer b.finishRequest(ctx, request, &err)
 
 	listing, err = b.wrapped.ListObjects(ctx, req)
 	return
@@ -246,8 +260,8 @@ func (b *debugBucket) ListObjects(
 func (b *debugBucket) UpdateObject(
 	ctx context.Context,
 	req *gcs.UpdateObjectRequest) (o *gcs.Object, err error) {
-	id, desc, start := b.startRequest("UpdateObject(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "UpdateObject(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.UpdateObject(ctx, req)
 	return
@@ -256,8 +270,8 @@ func (b *debugBucket) UpdateObject(
 func (b *debugBucket) DeleteObject(
 	ctx context.Context,
 	req *gcs.DeleteObjectRequest) (err error) {
-	id, desc, start := b.startRequest("DeleteObject(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "DeleteObject(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
 	err = b.wrapped.DeleteObject(ctx, req)
 	return
@@ -266,58 +280,56 @@ func (b *debugBucket) DeleteObject(
 func (b *debugBucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (*gcs.Object, error) {
 	var err error
 	var o *gcs.Object
-	id, desc, start := b.startRequest("MoveObject(%q, %q)", req.SrcName, req.DstName)
+	request := b.startRequest(ctx, "MoveObject(%q, %q)", req.SrcName, req.DstName)
 
-	defer b.finishRequest(id, desc, start, &err)
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.MoveObject(ctx, req)
 	return o, err
 }
 
 func (b *debugBucket) DeleteFolder(ctx context.Context, folderName string) (err error) {
-	id, desc, start := b.startRequest("DeleteFolder(%q)", folderName)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "DeleteFolder(%q)", folderName)
+	defer b.finishRequest(ctx, request, &err)
 
 	err = b.wrapped.DeleteFolder(ctx, folderName)
 	return err
 }
 
 func (b *debugBucket) GetFolder(ctx context.Context, folderName string) (folder *gcs.Folder, err error) {
-	id, desc, start := b.startRequest("GetFolder(%q)", folderName)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "GetFolder(%q)", folderName)
+	defer b.finishRequest(ctx, request, &err)
 
 	folder, err = b.wrapped.GetFolder(ctx, folderName)
 	return
 }
 
 func (b *debugBucket) CreateFolder(ctx context.Context, folderName string) (folder *gcs.Folder, err error) {
-	id, desc, start := b.startRequest("CreateFolder(%q)", folderName)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "CreateFolder(%q)", folderName)
+	defer b.finishRequest(ctx, request, &err)
 
 	folder, err = b.wrapped.CreateFolder(ctx, folderName)
 	return
 }
 
 func (b *debugBucket) RenameFolder(ctx context.Context, folderName string, destinationFolderId string) (o *gcs.Folder, err error) {
-	id, desc, start := b.startRequest("RenameFolder(%q)", folderName)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "RenameFolder(%q)", folderName)
+	defer b.finishRequest(ctx, request, &err)
 
 	o, err = b.wrapped.RenameFolder(ctx, folderName, destinationFolderId)
 	return o, err
 }
 
 type debugMultiRangeDownloader struct {
-	bucket    *debugBucket
-	requestID uint64
-	desc      string
-	startTime time.Time
-	wrapped   gcs.MultiRangeDownloader
+	bucket  *debugBucket
+	request *debugRequest
+	wrapped gcs.MultiRangeDownloader
 }
 
 func (dmrd *debugMultiRangeDownloader) Add(output io.Writer, offset, length int64, callback func(int64, int64, error)) {
-	id, desc, start := dmrd.bucket.startRequest("MultiRangeDownloader.Add(%v,%v)", offset, length)
+	request := dmrd.bucket.startRequest(context.Background(), "MultiRangeDownloader.Add(%v,%v)", offset, length)
 	wrapperCallback := func(offset int64, length int64, err error) {
-		defer dmrd.bucket.finishRequest(id, desc, start, &err)
+		defer dmrd.bucket.finishRequest(context.Background(), request, &err)
 		if callback != nil {
 			callback(offset, length, err)
 		}
@@ -326,38 +338,35 @@ func (dmrd *debugMultiRangeDownloader) Add(output io.Writer, offset, length int6
 }
 
 func (dmrd *debugMultiRangeDownloader) Close() (err error) {
-	id, desc, start := dmrd.bucket.startRequest("MultiRangeDownloader.Close()")
-	defer dmrd.bucket.finishRequest(id, desc, start, &err)
+	request := dmrd.bucket.startRequest(context.Background(), "MultiRangeDownloader.Close()")
+	defer dmrd.bucket.finishRequest(context.Background(), request, &err)
 	err = dmrd.wrapped.Close()
 	return
 }
 
 func (dmrd *debugMultiRangeDownloader) Wait() {
-	id, desc, start := dmrd.bucket.startRequest("MultiRangeDownloader.Wait()")
+	request := dmrd.bucket.startRequest(context.Background(), "MultiRangeDownloader.Wait()")
 	var err error
-	defer dmrd.bucket.finishRequest(id, desc, start, &err)
+	defer dmrd.bucket.finishRequest(context.Background(), request, &err)
 	dmrd.wrapped.Wait()
 }
 
 func (b *debugBucket) NewMultiRangeDownloader(
 	ctx context.Context, req *gcs.MultiRangeDownloaderRequest) (mrd gcs.MultiRangeDownloader, err error) {
-	id, desc, start := b.startRequest("NewMultiRangeDownloader(%q)", req.Name)
-	defer b.finishRequest(id, desc, start, &err)
+	request := b.startRequest(ctx, "NewMultiRangeDownloader(%q)", req.Name)
+	defer b.finishRequest(ctx, request, &err)
 
 	// Call through.
 	mrd, err = b.wrapped.NewMultiRangeDownloader(ctx, req)
 	if err != nil {
-		b.finishRequest(id, desc, start, &err)
 		return
 	}
 
 	// Return a special reader that prints debug info.
 	mrd = &debugMultiRangeDownloader{
-		bucket:    b,
-		requestID: id,
-		desc:      desc,
-		startTime: start,
-		wrapped:   mrd,
+		bucket:  b,
+		request: request,
+		wrapped: mrd,
 	}
 	return
 }
@@ -39,7 +39,22 @@ var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
 
 // Equivalent to NewConn(clock).GetBucket(name).
 func NewFakeBucket(clock timeutil.Clock, name string, bucketType gcs.BucketType) gcs.Bucket {
-	b := &bucket{clock: clock, name: name, bucketType: bucketType}
+	return NewFakeBucketWithOptions(clock, name, bucketType, BucketOptions{})
+}
+
+// NewFakeBucketWithOptions creates a fake bucket emulating the optional GCS
+// features selected by opts. The result also exposes the test controls of
+// Bucket.
+func NewFakeBucketWithOptions(clock timeutil.Clock, name string, bucketType gcs.BucketType, opts BucketOptions) Bucket {
+	b := &bucket{
+		clock:        clock,
+		name:         name,
+		bucketType:   bucketType,
+		opts:         opts,
+		noncurrent:   make(map[string]fakeObjectSlice),
+		softDeleted:  make(map[string]fakeObjectSlice),
+		failureHooks: make(map[Op]FailureHook),
+	}
 	b.mu = syncutil.NewInvariantMutex(b.checkInvariants)
 	return b
 }
@@ -51,6 +66,11 @@ func NewFakeBucket(clock timeutil.Clock, name string, bucketType gcs.BucketType)
 type fakeObject struct {
 	metadata gcs.Object
 	data     []byte
+
+	// The time at which this generation was created. Unlike metadata.Updated,
+	// this is not bumped by metadata updates, and is what retention policies
+	// are measured against.
+	created time.Time
 }
 
 // A slice of objects compared by name.
@@ -178,6 +198,20 @@ type bucket struct {
 	//
 	// INVARIANT: This is an upper bound for generation numbers in objects.
 	prevGeneration int64 // GUARDED_BY(mu)
+
+	// Optional GCS features emulated by this bucket.
+	opts BucketOptions
+
+	// Noncurrent generations of each object name, in increasing order of
+	// generation. Only populated when opts.Versioning is set.
+	noncurrent map[string]fakeObjectSlice // GUARDED_BY(mu)
+
+	// Soft-deleted generations of each object name, in increasing order of
+	// generation. Only populated when opts.SoftDeleteRetention is non-zero.
+	softDeleted map[string]fakeObjectSlice // GUARDED_BY(mu)
+
+	// Failure-injection hooks, keyed by operation.
+	failureHooks map[Op]FailureHook // GUARDED_BY(mu)
 }
 
 func checkName(name string) (err error) {
@@ -226,6 +260,23 @@ func (b *bucket) checkInvariants() {
 					b.prevGeneration))
 		}
 	}
+
+	// Make sure retained generations are strictly increasing and bounded by
+	// prevGeneration as well.
+	for _, retained := range []map[string]fakeObjectSlice{b.noncurrent, b.softDeleted} {
+		for name, gens := range retained {
+			for i, o := range gens {
+				if o.metadata.Name != name {
+					panic(fmt.Sprintf("Retained generation of %q filed under %q", o.metadata.Name, name))
+				}
+
+				if o.metadata.Generation > b.prevGeneration ||
+					(i > 0 && gens[i-1].metadata.Generation >= o.metadata.Generation) {
+					panic(fmt.Sprintf("Bad retained generation %v for %q", o.metadata.Generation, name))
+				}
+			}
+		}
+	}
 }
 
 // Create an object struct for the given attributes and contents.
@@ -256,10 +307,12 @@ func (b *bucket) mintObject(
 		MetaGeneration:  1,
 		StorageClass:    "STANDARD",
 		Updated:         b.clock.Now(),
+		EventBasedHold:  req.EventBasedHold,
 	}
 
 	// Set up data.
 	o.data = contents
+	o.created = o.metadata.Updated
 
 	return
 }
@@ -388,6 +441,11 @@ func preconditionChecks(b *bucket, req *gcs.CreateObjectRequest, contents []byte
 		}
 	}
 
+	// Overwriting an object is not allowed while it is retained.
+	if existingRecord != nil {
+		err = b.checkRetentionLocked(existingRecord)
+	}
+
 	return
 }
 
@@ -399,6 +457,7 @@ func createOrUpdateFakeObject(b *bucket, req *gcs.CreateObjectRequest, contents
 	// Replace an entry in or add an entry to our list of objects.
 	existingIndex := b.objects.find(req.Name)
 	if existingIndex < len(b.objects) {
+		b.retireLocked(b.objects[existingIndex], false)
 		b.objects[existingIndex] = fo
 	} else {
 		b.objects = append(b.objects, fo)
@@ -432,31 +491,15 @@ func (b *bucket) createObjectLocked(
 	return createOrUpdateFakeObject(b, req, contents)
 }
 
-// Create a reader based on the supplied request, also returning the index
-// within b.objects of the entry for the requested generation.
+// Create a reader based on the supplied request, also returning the entry for
+// the requested generation.
 //
 // LOCKS_REQUIRED(b.mu)
 func (b *bucket) newReaderLocked(
-	req *gcs.ReadObjectRequest) (r io.Reader, index int, err error) {
-	// Find the object with the requested name.
-	index = b.objects.find(req.Name)
-	if index == len(b.objects) {
-		err = &gcs.NotFoundError{
-			Err: fmt.Errorf("object %s not found", req.Name),
-		}
-
-		return
-	}
-
-	o := b.objects[index]
-
-	// Does the generation match?
-	if req.Generation != 0 && req.Generation != o.metadata.Generation {
-		err = &gcs.NotFoundError{
-			Err: fmt.Errorf(
-				"object %s generation %v not found", req.Name, req.Generation),
-		}
-
+	req *gcs.ReadObjectRequest) (r io.Reader, o fakeObject, err error) {
+	// Find the requested generation of the object, which may be noncurrent.
+	o, err = b.findGenerationLocked(req.Name, req.Generation)
+	if err != nil {
 		return
 	}
 
@@ -527,6 +570,7 @@ func copyMinObject(o *gcs.Object) *gcs.MinObject {
 	copy.Metadata = copyMetadata(o.Metadata)
 	copy.ContentEncoding = o.ContentEncoding
 	copy.CRC32C = o.CRC32C
+	copy.Retention = gcs.NewObjectRetention(o.EventBasedHold, o.TemporaryHold, o.RetentionExpirationTime)
 	return &copy
 }
 
@@ -549,6 +593,10 @@ func (b *bucket) ListObjects(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpListObjects, req.Prefix); err != nil {
+		return
+	}
+
 	// Set up the result object.
 	listing = new(gcs.Listing)
 
@@ -665,6 +713,10 @@ func (b *bucket) NewReader(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpNewReader, req.Name); err != nil {
+		return
+	}
+
 	r, _, err := b.newReaderLocked(req)
 	if err != nil {
 		return
@@ -679,6 +731,10 @@ func (b *bucket) NewReaderWithReadHandle(ctx context.Context, req *gcs.ReadObjec
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpNewReader, req.Name); err != nil {
+		return
+	}
+
 	r, _, err := b.newReaderLocked(req)
 	if err != nil {
 		return
@@ -699,6 +755,10 @@ func (b *bucket) CreateObject(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpCreateObject, req.Name); err != nil {
+		return
+	}
+
 	o, err = b.createObjectLocked(req)
 	return
 }
@@ -711,6 +771,10 @@ func (b *bucket) FinalizeUpload(ctx context.Context, w gcs.Writer) (*gcs.MinObje
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err := b.injectFailureLocked(OpFinalizeUpload, w.ObjectName()); err != nil {
+		return nil, err
+	}
+
 	err := w.Close()
 	if err != nil {
 		return nil, err
@@ -730,65 +794,56 @@ func (b *bucket) CopyObject(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Check that the destination name is legal.
-	err = checkName(req.DstName)
-	if err != nil {
+	if err = b.injectFailureLocked(OpCopyObject, req.SrcName); err != nil {
 		return
 	}
 
-	// Does the object exist?
-	srcIndex := b.objects.find(req.SrcName)
-	if srcIndex == len(b.objects) {
-		err = &gcs.NotFoundError{
-			Err: fmt.Errorf("object %q not found", req.SrcName),
-		}
-
+	// Check that the destination name is legal.
+	err = checkName(req.DstName)
+	if err != nil {
 		return
 	}
 
-	// Does it have the correct generation?
-	if req.SrcGeneration != 0 &&
-		b.objects[srcIndex].metadata.Generation != req.SrcGeneration {
-		err = &gcs.NotFoundError{
-			Err: fmt.Errorf(
-				"object %s generation %d not found", req.SrcName, req.SrcGeneration),
-		}
-
+	// Does the object exist, with the correct generation? The source may be a
+	// noncurrent generation.
+	src, err := b.findGenerationLocked(req.SrcName, req.SrcGeneration)
+	if err != nil {
 		return
 	}
 
 	// Does it have the correct meta-generation?
 	if req.SrcMetaGenerationPrecondition != nil {
 		p := *req.SrcMetaGenerationPrecondition
-		if b.objects[srcIndex].metadata.MetaGeneration != p {
+		if src.metadata.MetaGeneration != p {
 			err = &gcs.PreconditionError{
 				Err: fmt.Errorf(
 					"object %q has meta-generation %d",
 					req.SrcName,
-					b.objects[srcIndex].metadata.MetaGeneration),
+					src.metadata.MetaGeneration),
 			}
 
 			return
 		}
 	}
 
+	// May the destination be overwritten?
+	err = b.checkDstLocked(req.DstName, req.DstGenerationPrecondition)
+	if err != nil {
+		return
+	}
+
 	// Copy it and assign a new generation number, to ensure that the generation
 	// number for the destination name is strictly increasing.
-	dst := b.objects[srcIndex]
+	dst := src
 	dst.metadata.Name = req.DstName
 	dst.metadata.MediaLink = "http://localhost/download/storage/fake/" + req.DstName
+	dst.metadata.Deleted = time.Time{}
+	dst.created = b.clock.Now()
 
 	b.prevGeneration++
 	dst.metadata.Generation = b.prevGeneration
 
-	// Insert into our array.
-	existingIndex := b.objects.find(req.DstName)
-	if existingIndex < len(b.objects) {
-		b.objects[existingIndex] = dst
-	} else {
-		b.objects = append(b.objects, dst)
-		sort.Sort(b.objects)
-	}
+	b.insertLocked(dst)
 
 	o = copyObject(&dst.metadata)
 	return
@@ -801,6 +856,10 @@ func (b *bucket) ComposeObjects(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpComposeObjects, req.DstName); err != nil {
+		return
+	}
+
 	// GCS doesn't like too few or too many sources.
 	if len(req.Sources) < 1 {
 		err = errors.New("you must provide at least one source component")
@@ -819,9 +878,9 @@ func (b *bucket) ComposeObjects(
 
 	for _, src := range req.Sources {
 		var r io.Reader
-		var srcIndex int
+		var srcObject fakeObject
 
-		r, srcIndex, err = b.newReaderLocked(&gcs.ReadObjectRequest{
+		r, srcObject, err = b.newReaderLocked(&gcs.ReadObjectRequest{
 			Name:       src.Name,
 			Generation: src.Generation,
 		})
@@ -831,7 +890,7 @@ func (b *bucket) ComposeObjects(
 		}
 
 		srcReaders = append(srcReaders, r)
-		dstComponentCount += b.objects[srcIndex].metadata.ComponentCount
+		dstComponentCount += srcObject.metadata.ComponentCount
 	}
 
 	// GCS doesn't like the component count to go too high.
@@ -879,6 +938,10 @@ func (b *bucket) StatObject(ctx context.Context,
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpStatObject, req.Name); err != nil {
+		return
+	}
+
 	// Does the object exist?
 	index := b.objects.find(req.Name)
 	if index == len(b.objects) {
@@ -905,6 +968,10 @@ func (b *bucket) UpdateObject(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpUpdateObject, req.Name); err != nil {
+		return
+	}
+
 	// Does the object exist?
 	index := b.objects.find(req.Name)
 	if index == len(b.objects) {
@@ -992,15 +1059,22 @@ func (b *bucket) DeleteObject(
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpDeleteObject, req.Name); err != nil {
+		return
+	}
+
 	// Do we possess the object with the given name?
 	index := b.objects.find(req.Name)
 	if index == len(b.objects) {
+		b.deleteNoncurrentLocked(req.Name, req.Generation)
 		return
 	}
 
-	// Don't do anything if the generation is wrong.
+	// If the generation is not the live one, it may name a noncurrent
+	// generation instead.
 	if req.Generation != 0 &&
 		b.objects[index].metadata.Generation != req.Generation {
+		b.deleteNoncurrentLocked(req.Name, req.Generation)
 		return
 	}
 
@@ -1019,13 +1093,27 @@ func (b *bucket) DeleteObject(
 		}
 	}
 
-	// Remove the object.
+	// Objects under retention can't be deleted.
+	if err = b.checkRetentionLocked(&b.objects[index]); err != nil {
+		return
+	}
+
+	// Remove the object, retaining it if the bucket is configured to.
+	b.retireLocked(b.objects[index], true)
 	b.objects = append(b.objects[:index], b.objects[index+1:]...)
 
 	return
 }
 
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (*gcs.Object, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.injectFailureLocked(OpMoveObject, req.SrcName); err != nil {
+		return nil, err
+	}
+
 	// Check that the destination name is legal.
 	err := checkName(req.DstName)
 	if err != nil {
@@ -1061,6 +1149,16 @@ func (b *bucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (*g
 		}
 	}
 
+	// Moving removes the source, which is not allowed while it is retained.
+	if err = b.checkRetentionLocked(&b.objects[srcIndex]); err != nil {
+		return nil, err
+	}
+
+	// May the destination be overwritten?
+	if err = b.checkDstLocked(req.DstName, req.DstGenerationPrecondition); err != nil {
+		return nil, err
+	}
+
 	// Move it and assign a new generation number, to ensure that the generation
 	// number for the destination name is strictly increasing.
 	dst := b.objects[srcIndex]
@@ -1073,22 +1171,21 @@ func (b *bucket) MoveObject(ctx context.Context, req *gcs.MoveObjectRequest) (*g
 	// Remove the source object.
 	b.objects = append(b.objects[:srcIndex], b.objects[srcIndex+1:]...)
 	// Insert dest object into our array.
-	existingIndex := b.objects.find(req.DstName)
-	if existingIndex < len(b.objects) {
-		b.objects[existingIndex] = dst
-	} else {
-		b.objects = append(b.objects, dst)
-		sort.Sort(b.objects)
-	}
+	b.insertLocked(dst)
 
 	o := copyObject(&dst.metadata)
 	return o, err
 }
 
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) DeleteFolder(ctx context.Context, folderName string) (err error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err = b.injectFailureLocked(OpDeleteFolder, folderName); err != nil {
+		return
+	}
+
 	// Do we possess the folder with the given name?
 	index := b.folders.find(folderName)
 	if index == len(b.folders) {
@@ -1114,10 +1211,15 @@ func (b *bucket) DeleteFolder(ctx context.Context, folderName string) (err error
 	return
 }
 
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) GetFolder(ctx context.Context, foldername string) (*gcs.Folder, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err := b.injectFailureLocked(OpGetFolder, foldername); err != nil {
+		return nil, err
+	}
+
 	// Does the folder exist?
 	index := b.folders.find(foldername)
 	if index == len(b.folders) {
@@ -1130,10 +1232,15 @@ func (b *bucket) GetFolder(ctx context.Context, foldername string) (*gcs.Folder,
 	return &gcs.Folder{Name: foldername}, nil
 }
 
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) CreateFolder(ctx context.Context, folderName string) (*gcs.Folder, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if err := b.injectFailureLocked(OpCreateFolder, folderName); err != nil {
+		return nil, err
+	}
+
 	// Check that the name is legal.
 	err := checkName(folderName)
 	if err != nil {
@@ -1176,7 +1283,19 @@ func (b *bucket) CreateFolder(ctx context.Context, folderName string) (*gcs.Fold
 	return &fo, nil
 }
 
+// RenameFolder emulates the atomic folder rename of hierarchical buckets:
+// either every folder and object under folderName is moved under
+// destinationFolderId, or nothing is changed and an error is returned.
+//
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) RenameFolder(ctx context.Context, folderName string, destinationFolderId string) (*gcs.Folder, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.injectFailureLocked(OpRenameFolder, folderName); err != nil {
+		return nil, err
+	}
+
 	// Check that the destination name is legal.
 	err := checkName(destinationFolderId)
 	if err != nil {
@@ -1192,11 +1311,35 @@ func (b *bucket) RenameFolder(ctx context.Context, folderName string, destinatio
 		return nil, err
 	}
 
+	// The destination must not exist, and must not lie within the source.
+	if b.folders.find(destinationFolderId) < len(b.folders) {
+		err = &gcs.PreconditionError{
+			Err: fmt.Errorf("folder %q already exists", destinationFolderId),
+		}
+		return nil, err
+	}
+
+	if strings.HasPrefix(destinationFolderId, folderName) {
+		return nil, fmt.Errorf("cannot rename folder %q into its own subtree %q", folderName, destinationFolderId)
+	}
+
+	// Renaming moves every object under the folder, so none of them may be
+	// retained. Check this before changing anything so the rename stays atomic.
+	for i := range b.objects {
+		if strings.HasPrefix(b.objects[i].metadata.Name, folderName) {
+			if err = b.checkRetentionLocked(&b.objects[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := b.clock.Now()
+
 	// Find all folders starting with the given prefix and update their names.
 	for i := range b.folders {
 		if strings.HasPrefix(b.folders[i].Name, folderName) {
-			b.folders[i].Name = strings.Replace(b.folders[i].Name, folderName, destinationFolderId, 1)
-			b.folders[i].UpdateTime = time.Now()
+			b.folders[i].Name = destinationFolderId + strings.TrimPrefix(b.folders[i].Name, folderName)
+			b.folders[i].UpdateTime = now
 		}
 	}
 
@@ -1206,8 +1349,8 @@ func (b *bucket) RenameFolder(ctx context.Context, folderName string, destinatio
 	// Find all objects starting with the given prefix and update their names.
 	for i := range b.objects {
 		if strings.HasPrefix(b.objects[i].metadata.Name, folderName) {
-			b.objects[i].metadata.Name = strings.Replace(b.objects[i].metadata.Name, folderName, destinationFolderId, 1)
-			b.objects[i].metadata.Updated = time.Now()
+			b.objects[i].metadata.Name = destinationFolderId + strings.TrimPrefix(b.objects[i].metadata.Name, folderName)
+			b.objects[i].metadata.Updated = now
 		}
 	}
 
@@ -1217,20 +1360,24 @@ func (b *bucket) RenameFolder(ctx context.Context, folderName string, destinatio
 	// Return the updated folder.
 	folder := &gcs.Folder{
 		Name:       destinationFolderId,
-		UpdateTime: time.Now(),
+		UpdateTime: now,
 	}
 
 	return folder, nil
 }
 
+// LOCKS_EXCLUDED(b.mu)
 func (b *bucket) NewMultiRangeDownloader(
 	ctx context.Context, req *gcs.MultiRangeDownloaderRequest) (gcs.MultiRangeDownloader, error) {
-	index := b.objects.find(req.Name)
-	if index >= len(b.objects) || index < 0 {
-		return nil, &gcs.NotFoundError{Err: fmt.Errorf("not found object %s in fake-bucket", req.Name)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.injectFailureLocked(OpNewMultiRangeDownloader, req.Name); err != nil {
+		return nil, err
 	}
-	obj := b.objects[index]
-	if req.Generation != 0 && obj.metadata.Generation != req.Generation {
+
+	obj, err := b.findGenerationLocked(req.Name, req.Generation)
+	if err != nil {
 		return nil, &gcs.NotFoundError{Err: fmt.Errorf("not found object %s in fake-bucket with generation %v", req.Name, req.Generation)}
 	}
 	if obj.data == nil {
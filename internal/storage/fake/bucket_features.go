@@ -0,0 +1,419 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"google.golang.org/api/googleapi"
+)
+
+// BucketOptions selects optional GCS features emulated by the fake bucket.
+// The zero value emulates a plain bucket with none of them enabled.
+type BucketOptions struct {
+	// If set, objects that are overwritten or deleted are kept as noncurrent
+	// generations, which can still be read, copied and deleted by generation.
+	Versioning bool
+
+	// If non-zero, deleted objects are kept as soft-deleted for this long, and
+	// can be restored with Bucket.RestoreObject until then.
+	SoftDeleteRetention time.Duration
+
+	// If non-zero, a bucket-level retention policy: live objects can't be
+	// overwritten, deleted, moved or renamed until they are at least this old.
+	// Violations fail with a 403 googleapi.Error, as GCS does.
+	RetentionPeriod time.Duration
+
+	// If non-nil, the only IAM permissions that TestPermissions reports as
+	// held on the bucket. Nil grants every permission. Operations are not
+	// restricted by it.
+	GrantedPermissions []string
+}
+
+// Op identifies a fake bucket operation, for failure injection.
+type Op string
+
+const (
+	OpListObjects             Op = "ListObjects"
+	OpNewReader               Op = "NewReader"
+	OpNewMultiRangeDownloader Op = "NewMultiRangeDownloader"
+	OpCreateObject            Op = "CreateObject"
+	OpFinalizeUpload          Op = "FinalizeUpload"
+	OpCopyObject              Op = "CopyObject"
+	OpComposeObjects          Op = "ComposeObjects"
+	OpStatObject              Op = "StatObject"
+	OpUpdateObject            Op = "UpdateObject"
+	OpDeleteObject            Op = "DeleteObject"
+	OpMoveObject              Op = "MoveObject"
+	OpDeleteFolder            Op = "DeleteFolder"
+	OpGetFolder               Op = "GetFolder"
+	OpCreateFolder            Op = "CreateFolder"
+	OpRenameFolder            Op = "RenameFolder"
+)
+
+// FailureHook is consulted before an operation touches any bucket state. It
+// receives the primary object or folder name of the request (the prefix, for
+// ListObjects). A non-nil result is returned from the operation unchanged,
+// and the operation has no effect.
+//
+// Hooks are called with the bucket lock held, and so must not call back into
+// the bucket.
+type FailureHook func(name string) error
+
+// ErrorSequence returns a hook that fails successive calls with errs in
+// order, a nil entry letting that call through, and lets every call after the
+// last entry through.
+func ErrorSequence(errs ...error) FailureHook {
+	return func(string) (err error) {
+		if len(errs) == 0 {
+			return
+		}
+
+		err, errs = errs[0], errs[1:]
+		return
+	}
+}
+
+// Bucket is a fake gcs.Bucket which also exposes controls for tests.
+type Bucket interface {
+	gcs.Bucket
+	gcs.PermissionTester
+
+	// SetFailureHook installs hook for op, replacing any existing one. A nil
+	// hook removes it.
+	SetFailureHook(op Op, hook FailureHook)
+
+	// ObjectGenerations returns every generation of the named object known to
+	// the bucket, noncurrent ones followed by the live one, in increasing order
+	// of generation. Soft-deleted generations are not included.
+	ObjectGenerations(name string) []*gcs.Object
+
+	// SoftDeletedObjects returns the soft-deleted generations of the named
+	// object that have not yet expired, in increasing order of generation.
+	SoftDeletedObjects(name string) []*gcs.Object
+
+	// RestoreObject makes a soft-deleted generation live again, under a new
+	// generation number. It fails if the object currently has a live
+	// generation.
+	RestoreObject(ctx context.Context, name string, generation int64) (*gcs.Object, error)
+}
+
+////////////////////////////////////////////////////////////////////////
+// Helpers
+////////////////////////////////////////////////////////////////////////
+
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) injectFailureLocked(op Op, name string) error {
+	hook := b.failureHooks[op]
+	if hook == nil {
+		return nil
+	}
+
+	return hook(name)
+}
+
+// Find the given generation of the named object, which may be noncurrent. Zero
+// means the live generation.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) findGenerationLocked(name string, generation int64) (o fakeObject, err error) {
+	index := b.objects.find(name)
+	if index < len(b.objects) &&
+		(generation == 0 || b.objects[index].metadata.Generation == generation) {
+		o = b.objects[index]
+		return
+	}
+
+	if generation == 0 {
+		err = &gcs.NotFoundError{
+			Err: fmt.Errorf("object %s not found", name),
+		}
+
+		return
+	}
+
+	for _, nc := range b.noncurrent[name] {
+		if nc.metadata.Generation == generation {
+			o = nc
+			return
+		}
+	}
+
+	err = &gcs.NotFoundError{
+		Err: fmt.Errorf("object %s generation %v not found", name, generation),
+	}
+
+	return
+}
+
+// Return an error if the bucket retention policy forbids replacing or removing
+// the given live object.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) checkRetentionLocked(o *fakeObject) error {
+	if b.opts.RetentionPeriod == 0 {
+		return nil
+	}
+
+	retainUntil := o.created.Add(b.opts.RetentionPeriod)
+	if b.clock.Now().Before(retainUntil) {
+		return &googleapi.Error{
+			Code: http.StatusForbidden,
+			Message: fmt.Sprintf(
+				"object %q is subject to bucket's retention policy and cannot be deleted or overwritten until %v",
+				o.metadata.Name,
+				retainUntil),
+		}
+	}
+
+	return nil
+}
+
+// Return an error if the named object may not be created or overwritten as
+// the destination of a copy or move, given its generation precondition.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) checkDstLocked(name string, generationPrecondition *int64) error {
+	var existing *fakeObject
+	if index := b.objects.find(name); index < len(b.objects) {
+		existing = &b.objects[index]
+	}
+
+	if generationPrecondition != nil {
+		if *generationPrecondition == 0 && existing != nil {
+			return &gcs.PreconditionError{
+				Err: fmt.Errorf("precondition failed: object %q exists", name),
+			}
+		}
+
+		if *generationPrecondition > 0 &&
+			(existing == nil || existing.metadata.Generation != *generationPrecondition) {
+			return &gcs.PreconditionError{
+				Err: fmt.Errorf("precondition failed: object %q doesn't have generation %v", name, *generationPrecondition),
+			}
+		}
+	}
+
+	if existing != nil {
+		return b.checkRetentionLocked(existing)
+	}
+
+	return nil
+}
+
+// Insert o as the live generation of its name, retiring any existing live
+// generation.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) insertLocked(o fakeObject) {
+	existingIndex := b.objects.find(o.metadata.Name)
+	if existingIndex < len(b.objects) {
+		b.retireLocked(b.objects[existingIndex], false)
+		b.objects[existingIndex] = o
+	} else {
+		b.objects = append(b.objects, o)
+		sort.Sort(b.objects)
+	}
+
+	if b.bucketType.Hierarchical {
+		b.addFolderEntry(o.metadata.Name)
+	}
+}
+
+// Called when o stops being the live generation of its name, either because
+// it was overwritten or because it was deleted. Depending on the bucket
+// options, it is kept as a noncurrent or soft-deleted generation.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) retireLocked(o fakeObject, deleted bool) {
+	name := o.metadata.Name
+	switch {
+	case b.opts.Versioning:
+		o.metadata.Deleted = b.clock.Now()
+		b.noncurrent[name] = insertByGeneration(b.noncurrent[name], o)
+
+	case deleted && b.opts.SoftDeleteRetention > 0:
+		o.metadata.Deleted = b.clock.Now()
+		b.softDeleted[name] = insertByGeneration(b.softDeleted[name], o)
+	}
+}
+
+// Delete the given noncurrent generation of the named object, if it exists.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) deleteNoncurrentLocked(name string, generation int64) {
+	if generation == 0 {
+		return
+	}
+
+	gens := b.noncurrent[name]
+	for i, o := range gens {
+		if o.metadata.Generation != generation {
+			continue
+		}
+
+		b.noncurrent[name] = append(gens[:i:i], gens[i+1:]...)
+		if len(b.noncurrent[name]) == 0 {
+			delete(b.noncurrent, name)
+		}
+
+		if b.opts.SoftDeleteRetention > 0 {
+			b.softDeleted[name] = insertByGeneration(b.softDeleted[name], o)
+		}
+
+		return
+	}
+}
+
+// Permanently remove soft-deleted generations whose retention has expired.
+//
+// LOCKS_REQUIRED(b.mu)
+func (b *bucket) purgeExpiredLocked() {
+	now := b.clock.Now()
+	for name, gens := range b.softDeleted {
+		var kept fakeObjectSlice
+		for _, o := range gens {
+			if now.Before(o.metadata.Deleted.Add(b.opts.SoftDeleteRetention)) {
+				kept = append(kept, o)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(b.softDeleted, name)
+		} else {
+			b.softDeleted[name] = kept
+		}
+	}
+}
+
+func insertByGeneration(s fakeObjectSlice, o fakeObject) fakeObjectSlice {
+	i := sort.Search(len(s), func(i int) bool {
+		return s[i].metadata.Generation >= o.metadata.Generation
+	})
+
+	s = append(s, fakeObject{})
+	copy(s[i+1:], s[i:])
+	s[i] = o
+	return s
+}
+
+func copyObjects(s fakeObjectSlice) (out []*gcs.Object) {
+	for i := range s {
+		out = append(out, copyObject(&s[i].metadata))
+	}
+
+	return
+}
+
+////////////////////////////////////////////////////////////////////////
+// Test controls
+////////////////////////////////////////////////////////////////////////
+
+// LOCKS_EXCLUDED(b.mu)
+func (b *bucket) SetFailureHook(op Op, hook FailureHook) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hook == nil {
+		delete(b.failureHooks, op)
+		return
+	}
+
+	b.failureHooks[op] = hook
+}
+
+// LOCKS_EXCLUDED(b.mu)
+func (b *bucket) ObjectGenerations(name string) []*gcs.Object {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := copyObjects(b.noncurrent[name])
+	if index := b.objects.find(name); index < len(b.objects) {
+		out = append(out, copyObject(&b.objects[index].metadata))
+	}
+
+	return out
+}
+
+// LOCKS_EXCLUDED(b.mu)
+func (b *bucket) SoftDeletedObjects(name string) []*gcs.Object {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.purgeExpiredLocked()
+	return copyObjects(b.softDeleted[name])
+}
+
+// LOCKS_EXCLUDED(b.mu)
+func (b *bucket) RestoreObject(ctx context.Context, name string, generation int64) (*gcs.Object, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.purgeExpiredLocked()
+
+	gens := b.softDeleted[name]
+	i := sort.Search(len(gens), func(i int) bool {
+		return gens[i].metadata.Generation >= generation
+	})
+
+	if i == len(gens) || gens[i].metadata.Generation != generation {
+		return nil, &gcs.NotFoundError{
+			Err: fmt.Errorf("soft-deleted object %s generation %v not found", name, generation),
+		}
+	}
+
+	if b.objects.find(name) < len(b.objects) {
+		return nil, &gcs.PreconditionError{
+			Err: fmt.Errorf("precondition failed: object %q has a live generation", name),
+		}
+	}
+
+	o := gens[i]
+	b.softDeleted[name] = append(gens[:i:i], gens[i+1:]...)
+	if len(b.softDeleted[name]) == 0 {
+		delete(b.softDeleted, name)
+	}
+
+	b.prevGeneration++
+	o.metadata.Generation = b.prevGeneration
+	o.metadata.MetaGeneration = 1
+	o.metadata.Deleted = time.Time{}
+	o.metadata.Updated = b.clock.Now()
+	o.created = o.metadata.Updated
+	b.insertLocked(o)
+
+	return copyObject(&o.metadata), nil
+}
+
+func (b *bucket) TestPermissions(ctx context.Context, permissions []string) ([]string, error) {
+	if b.opts.GrantedPermissions == nil {
+		return append([]string(nil), permissions...), nil
+	}
+
+	var granted []string
+	for _, p := range permissions {
+		if slices.Contains(b.opts.GrantedPermissions, p) {
+			granted = append(granted, p)
+		}
+	}
+
+	return granted, nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
+	"github.com/jacobsa/timeutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/api/googleapi"
+)
+
+type BucketFeaturesTest struct {
+	suite.Suite
+	ctx   context.Context
+	clock *timeutil.SimulatedClock
+}
+
+func TestBucketFeaturesSuite(t *testing.T) {
+	suite.Run(t, new(BucketFeaturesTest))
+}
+
+func (t *BucketFeaturesTest) SetupTest() {
+	t.ctx = context.Background()
+	t.clock = &timeutil.SimulatedClock{}
+	t.clock.SetTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func (t *BucketFeaturesTest) newBucket(bucketType gcs.BucketType, opts BucketOptions) Bucket {
+	return NewFakeBucketWithOptions(t.clock, "some_bucket", bucketType, opts)
+}
+
+func (t *BucketFeaturesTest) create(b gcs.Bucket, name, contents string) *gcs.Object {
+	o, err := b.CreateObject(t.ctx, &gcs.CreateObjectRequest{
+		Name:     name,
+		Contents: strings.NewReader(contents),
+	})
+	require.NoError(t.T(), err)
+	return o
+}
+
+func (t *BucketFeaturesTest) read(b gcs.Bucket, name string, generation int64) (string, error) {
+	rc, err := b.NewReader(t.ctx, &gcs.ReadObjectRequest{Name: name, Generation: generation})
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	contents, err := io.ReadAll(rc)
+	return string(contents), err
+}
+
+func (t *BucketFeaturesTest) TestVersioning_OverwriteKeepsNoncurrentGeneration() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{Versioning: true})
+	o1 := t.create(b, "foo", "taco")
+	o2 := t.create(b, "foo", "burrito")
+
+	gens := b.ObjectGenerations("foo")
+
+	require.Len(t.T(), gens, 2)
+	assert.Equal(t.T(), o1.Generation, gens[0].Generation)
+	assert.False(t.T(), gens[0].Deleted.IsZero())
+	assert.Equal(t.T(), o2.Generation, gens[1].Generation)
+	assert.True(t.T(), gens[1].Deleted.IsZero())
+	contents, err := t.read(b, "foo", o1.Generation)
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", contents)
+}
+
+func (t *BucketFeaturesTest) TestVersioning_DeleteNoncurrentGeneration() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{Versioning: true})
+	o1 := t.create(b, "foo", "taco")
+	t.create(b, "foo", "burrito")
+
+	err := b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo", Generation: o1.Generation})
+
+	require.NoError(t.T(), err)
+	assert.Len(t.T(), b.ObjectGenerations("foo"), 1)
+	_, err = t.read(b, "foo", o1.Generation)
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+}
+
+func (t *BucketFeaturesTest) TestWithoutVersioning_OverwriteDropsOldGeneration() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{})
+	o1 := t.create(b, "foo", "taco")
+	t.create(b, "foo", "burrito")
+
+	_, err := t.read(b, "foo", o1.Generation)
+
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+	assert.Len(t.T(), b.ObjectGenerations("foo"), 1)
+}
+
+func (t *BucketFeaturesTest) TestSoftDelete_RestoreWithinRetention() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{SoftDeleteRetention: time.Hour})
+	o := t.create(b, "foo", "taco")
+	require.NoError(t.T(), b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+	_, _, err := b.StatObject(t.ctx, &gcs.StatObjectRequest{Name: "foo"})
+	var notFoundErr *gcs.NotFoundError
+	require.ErrorAs(t.T(), err, &notFoundErr)
+	require.Len(t.T(), b.SoftDeletedObjects("foo"), 1)
+
+	restored, err := b.RestoreObject(t.ctx, "foo", o.Generation)
+
+	require.NoError(t.T(), err)
+	assert.Greater(t.T(), restored.Generation, o.Generation)
+	assert.Empty(t.T(), b.SoftDeletedObjects("foo"))
+	contents, err := t.read(b, "foo", 0)
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", contents)
+}
+
+func (t *BucketFeaturesTest) TestSoftDelete_ExpiresAfterRetention() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{SoftDeleteRetention: time.Hour})
+	o := t.create(b, "foo", "taco")
+	require.NoError(t.T(), b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+	t.clock.AdvanceTime(time.Hour)
+
+	_, err := b.RestoreObject(t.ctx, "foo", o.Generation)
+
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+	assert.Empty(t.T(), b.SoftDeletedObjects("foo"))
+}
+
+func (t *BucketFeaturesTest) TestSoftDelete_RestoreFailsWhenLive() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{SoftDeleteRetention: time.Hour})
+	o := t.create(b, "foo", "taco")
+	require.NoError(t.T(), b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+	t.create(b, "foo", "burrito")
+
+	_, err := b.RestoreObject(t.ctx, "foo", o.Generation)
+
+	var preconditionErr *gcs.PreconditionError
+	assert.ErrorAs(t.T(), err, &preconditionErr)
+}
+
+func (t *BucketFeaturesTest) TestRetentionPolicy() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{RetentionPeriod: time.Hour})
+	t.create(b, "foo", "taco")
+	var apiErr *googleapi.Error
+
+	err := b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo"})
+	require.ErrorAs(t.T(), err, &apiErr)
+	assert.Equal(t.T(), http.StatusForbidden, apiErr.Code)
+	_, err = b.CreateObject(t.ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("")})
+	assert.ErrorAs(t.T(), err, &apiErr)
+	_, err = b.MoveObject(t.ctx, &gcs.MoveObjectRequest{SrcName: "foo", DstName: "bar"})
+	assert.ErrorAs(t.T(), err, &apiErr)
+	// Metadata updates are allowed.
+	_, err = b.UpdateObject(t.ctx, &gcs.UpdateObjectRequest{Name: "foo"})
+	assert.NoError(t.T(), err)
+
+	t.clock.AdvanceTime(time.Hour)
+
+	assert.NoError(t.T(), b.DeleteObject(t.ctx, &gcs.DeleteObjectRequest{Name: "foo"}))
+}
+
+func (t *BucketFeaturesTest) TestMoveObject_DstGenerationPrecondition() {
+	b := t.newBucket(gcs.BucketType{Hierarchical: true}, BucketOptions{})
+	t.create(b, "a/foo", "taco")
+	dst := t.create(b, "b/foo", "burrito")
+	var preconditionErr *gcs.PreconditionError
+	zero := int64(0)
+	wrong := dst.Generation + 1
+
+	_, err := b.MoveObject(t.ctx, &gcs.MoveObjectRequest{SrcName: "a/foo", DstName: "b/foo", DstGenerationPrecondition: &zero})
+	assert.ErrorAs(t.T(), err, &preconditionErr)
+	_, err = b.MoveObject(t.ctx, &gcs.MoveObjectRequest{SrcName: "a/foo", DstName: "b/foo", DstGenerationPrecondition: &wrong})
+	assert.ErrorAs(t.T(), err, &preconditionErr)
+	o, err := b.MoveObject(t.ctx, &gcs.MoveObjectRequest{SrcName: "a/foo", DstName: "c/d/foo", DstGenerationPrecondition: &zero})
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "c/d/foo", o.Name)
+	_, err = b.GetFolder(t.ctx, "c/d/")
+	assert.NoError(t.T(), err)
+}
+
+func (t *BucketFeaturesTest) TestRenameFolder_MovesSubtree() {
+	b := t.newBucket(gcs.BucketType{Hierarchical: true}, BucketOptions{})
+	_, err := b.CreateFolder(t.ctx, "a/")
+	require.NoError(t.T(), err)
+	t.create(b, "a/b/foo", "taco")
+
+	_, err = b.RenameFolder(t.ctx, "a/", "z/")
+
+	require.NoError(t.T(), err)
+	contents, err := t.read(b, "z/b/foo", 0)
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), "taco", contents)
+	_, err = b.GetFolder(t.ctx, "z/b/")
+	assert.NoError(t.T(), err)
+	_, err = b.GetFolder(t.ctx, "a/")
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+}
+
+func (t *BucketFeaturesTest) TestRenameFolder_FailureModesLeaveBucketUnchanged() {
+	b := t.newBucket(gcs.BucketType{Hierarchical: true}, BucketOptions{RetentionPeriod: time.Hour})
+	_, err := b.CreateFolder(t.ctx, "a/")
+	require.NoError(t.T(), err)
+	_, err = b.CreateFolder(t.ctx, "existing/")
+	require.NoError(t.T(), err)
+	t.create(b, "a/foo", "taco")
+
+	_, err = b.RenameFolder(t.ctx, "missing/", "z/")
+	var notFoundErr *gcs.NotFoundError
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+	_, err = b.RenameFolder(t.ctx, "a/", "existing/")
+	var preconditionErr *gcs.PreconditionError
+	assert.ErrorAs(t.T(), err, &preconditionErr)
+	_, err = b.RenameFolder(t.ctx, "a/", "a/b/")
+	assert.Error(t.T(), err)
+	_, err = b.RenameFolder(t.ctx, "a/", "z/")
+	var apiErr *googleapi.Error
+	assert.ErrorAs(t.T(), err, &apiErr)
+
+	_, err = t.read(b, "a/foo", 0)
+	assert.NoError(t.T(), err)
+	_, err = b.GetFolder(t.ctx, "z/")
+	assert.ErrorAs(t.T(), err, &notFoundErr)
+}
+
+func (t *BucketFeaturesTest) TestFailureHook() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{})
+	injected := errors.New("injected")
+	b.SetFailureHook(OpCreateObject, ErrorSequence(injected, nil, injected))
+
+	_, err1 := b.CreateObject(t.ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("")})
+	_, err2 := b.CreateObject(t.ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("")})
+	_, err3 := b.CreateObject(t.ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("")})
+	_, err4 := b.CreateObject(t.ctx, &gcs.CreateObjectRequest{Name: "foo", Contents: strings.NewReader("")})
+
+	assert.ErrorIs(t.T(), err1, injected)
+	assert.NoError(t.T(), err2)
+	assert.ErrorIs(t.T(), err3, injected)
+	assert.NoError(t.T(), err4)
+}
+
+func (t *BucketFeaturesTest) TestFailureHook_ReceivesNameAndCanBeRemoved() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{})
+	t.create(b, "foo", "taco")
+	var names []string
+	b.SetFailureHook(OpStatObject, func(name string) error {
+		names = append(names, name)
+		return errors.New("injected")
+	})
+
+	_, _, err := b.StatObject(t.ctx, &gcs.StatObjectRequest{Name: "foo"})
+	assert.Error(t.T(), err)
+	b.SetFailureHook(OpStatObject, nil)
+	_, _, err = b.StatObject(t.ctx, &gcs.StatObjectRequest{Name: "foo"})
+
+	assert.NoError(t.T(), err)
+	assert.Equal(t.T(), []string{"foo"}, names)
+}
+
+func (t *BucketFeaturesTest) TestPermissions_AllGrantedByDefault() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{})
+
+	granted, err := b.TestPermissions(t.ctx, gcs.ObjectPermissions)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), gcs.ObjectPermissions, granted)
+}
+
+func (t *BucketFeaturesTest) TestPermissions_OnlyGrantedReported() {
+	b := t.newBucket(gcs.BucketType{}, BucketOptions{
+		GrantedPermissions: []string{gcs.PermissionObjectsList, gcs.PermissionObjectsGet},
+	})
+
+	granted, err := b.TestPermissions(t.ctx, gcs.ObjectPermissions)
+
+	require.NoError(t.T(), err)
+	assert.Equal(t.T(), []string{gcs.PermissionObjectsGet, gcs.PermissionObjectsList}, granted)
+}
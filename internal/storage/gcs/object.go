@@ -71,7 +71,14 @@ type Object struct {
 	ContentDisposition string
 	CustomTime         string
 	EventBasedHold     bool
-	Acl                []*storagev1.ObjectAccessControl
+	TemporaryHold      bool
+
+	// The time until which the object can't be deleted or replaced, under the
+	// bucket's retention policy or the object's own retention. Zero if neither
+	// applies.
+	RetentionExpirationTime time.Time
+
+	Acl []*storagev1.ObjectAccessControl
 }
 
 // MinObject is a record representing subset of properties of a particular
@@ -89,6 +96,32 @@ type MinObject struct {
 	Metadata        map[string]string
 	ContentEncoding string
 	CRC32C          *uint32 // Missing for CMEK buckets
+
+	// Nil unless the object has a hold or a retention expiration time. Kept
+	// here so that it is cached along with the rest of the object's metadata.
+	Retention *ObjectRetention
+}
+
+// ObjectRetention holds the holds and retention of an object, as in
+// ExtendedObjectAttributes.
+type ObjectRetention struct {
+	EventBasedHold          bool
+	TemporaryHold           bool
+	RetentionExpirationTime time.Time
+}
+
+// NewObjectRetention returns the retention for the given holds and retention
+// expiration time, or nil if there is none.
+func NewObjectRetention(eventBasedHold, temporaryHold bool, retentionExpirationTime time.Time) *ObjectRetention {
+	if !eventBasedHold && !temporaryHold && retentionExpirationTime.IsZero() {
+		return nil
+	}
+
+	return &ObjectRetention{
+		EventBasedHold:          eventBasedHold,
+		TemporaryHold:           temporaryHold,
+		RetentionExpirationTime: retentionExpirationTime,
+	}
 }
 
 // ExtendedObjectAttributes contains the missing attributes of Object which are not present in MinObject.
@@ -105,7 +138,25 @@ type ExtendedObjectAttributes struct {
 	ContentDisposition string
 	CustomTime         string
 	EventBasedHold     bool
-	Acl                []*storagev1.ObjectAccessControl
+	TemporaryHold      bool
+
+	// As for Object.RetentionExpirationTime.
+	RetentionExpirationTime time.Time
+
+	Acl []*storagev1.ObjectAccessControl
+}
+
+// IsRetained returns true if the object is under a hold, or under retention
+// at the given time, and so can't be deleted or replaced.
+func (e *ExtendedObjectAttributes) IsRetained(now time.Time) bool {
+	return e.EventBasedHold || e.TemporaryHold || now.Before(e.RetentionExpirationTime)
+}
+
+// IsRetained returns true if the object is under a hold, or under retention
+// at the given time, and so can't be deleted or replaced.
+func (mo MinObject) IsRetained(now time.Time) bool {
+	r := mo.Retention
+	return r != nil && (r.EventBasedHold || r.TemporaryHold || now.Before(r.RetentionExpirationTime))
 }
 
 func (mo MinObject) HasContentEncodingGzip() bool {
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcs
+
+import "golang.org/x/net/context"
+
+// The IAM permissions on a bucket that the file system relies on.
+const (
+	PermissionObjectsGet    = "storage.objects.get"
+	PermissionObjectsList   = "storage.objects.list"
+	PermissionObjectsCreate = "storage.objects.create"
+	PermissionObjectsDelete = "storage.objects.delete"
+	PermissionObjectsUpdate = "storage.objects.update"
+)
+
+// ObjectPermissions are all the permissions above, in the order they are
+// usually listed.
+var ObjectPermissions = []string{
+	PermissionObjectsGet,
+	PermissionObjectsList,
+	PermissionObjectsCreate,
+	PermissionObjectsDelete,
+	PermissionObjectsUpdate,
+}
+
+// PermissionTester is implemented by buckets that can report which IAM
+// permissions the caller holds on them.
+type PermissionTester interface {
+	// Return the subset of the given permissions that the caller holds on the
+	// bucket. Holding none of them is not an error.
+	//
+	// Official documentation:
+	//     https://cloud.google.com/storage/docs/json_api/v1/buckets/testIamPermissions
+	TestPermissions(ctx context.Context, permissions []string) ([]string, error)
+}
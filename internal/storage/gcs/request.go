@@ -421,4 +421,8 @@ type MoveObjectRequest struct {
 	// If non-nil, the destination object will be created/overwritten only if the
 	// current meta-generation for the source object is equal to the given value.
 	SrcMetaGenerationPrecondition *int64
+	// If non-nil, the destination object will be created/overwritten only if
+	// its current generation is equal to the given value. Zero means the
+	// destination object must not exist.
+	DstGenerationPrecondition *int64
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/logger"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/gcs"
 	"github.com/googlecloudplatform/gcsfuse/v2/internal/storage/storageutil"
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
 	"golang.org/x/net/context"
 	option "google.golang.org/api/option"
 	"google.golang.org/grpc"
@@ -104,6 +105,11 @@ func createClientOptionForGRPCClient(clientConfig *storageutil.StorageClientConf
 		clientOpts = append(clientOpts, experimental.WithGRPCBidiReads())
 	}
 	clientOpts = append(clientOpts, option.WithGRPCConnectionPool(clientConfig.GrpcConnPoolSize))
+	// Count every RPC attempt, including retries by the storage client, for the
+	// retry count attribute of the calling span.
+	clientOpts = append(clientOpts,
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(countAttemptsUnaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(countAttemptsStreamInterceptor)))
 	clientOpts = append(clientOpts, option.WithUserAgent(clientConfig.UserAgent))
 	// Turning off the go-sdk metrics exporter to prevent any problems.
 	// TODO (kislaykishore) - to revisit here for monitoring support.
@@ -111,6 +117,16 @@ func createClientOptionForGRPCClient(clientConfig *storageutil.StorageClientConf
 	return
 }
 
+func countAttemptsUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	tracing.CountAttempt(ctx)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func countAttemptsStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	tracing.CountAttempt(ctx)
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
 func setRetryConfig(sc *storage.Client, clientConfig *storageutil.StorageClientConfig) {
 	if sc == nil || clientConfig == nil {
 		logger.Fatal("setRetryConfig: Empty storage client or clientConfig")
@@ -201,7 +217,7 @@ func createHTTPClientHandle(ctx context.Context, clientConfig *storageutil.Stora
 		// once we get a good default.
 		err = os.Setenv(dynamicReadReqIncreaseRateEnv, strconv.FormatFloat(clientConfig.ReadStallRetryConfig.ReqIncreaseRate, 'f', -1, 64))
 		if err != nil {
-			logger.Warnf("Error while setting the env %s: %v", dynamicReadReqIncreaseRateEnv, err)
+			logger.GCS.Warnf("Error while setting the env %s: %v", dynamicReadReqIncreaseRateEnv, err)
 		}
 
 		// Hidden way to modify the initial-timeout of the dynamic delay algorithm in go-sdk.
@@ -210,7 +226,7 @@ func createHTTPClientHandle(ctx context.Context, clientConfig *storageutil.Stora
 		// once we get a good default.
 		err = os.Setenv(dynamicReadReqInitialTimeoutEnv, clientConfig.ReadStallRetryConfig.InitialReqTimeout.String())
 		if err != nil {
-			logger.Warnf("Error while setting the env %s: %v", dynamicReadReqInitialTimeoutEnv, err)
+			logger.GCS.Warnf("Error while setting the env %s: %v", dynamicReadReqInitialTimeoutEnv, err)
 		}
 		clientOpts = append(clientOpts, experimental.WithReadStallTimeout(&experimental.ReadStallTimeoutConfig{
 			Min:              clientConfig.ReadStallRetryConfig.MinReqTimeout,
@@ -233,7 +249,7 @@ func (sh *storageClient) lookupBucketType(bucketName string) (*gcs.BucketType, e
 	}
 
 	startTime := time.Now()
-	logger.Infof("GetStorageLayout <- (%s)", bucketName)
+	logger.GCS.Infof("GetStorageLayout <- (%s)", bucketName)
 	storageLayout, err := sh.getStorageLayout(bucketName)
 	duration := time.Since(startTime)
 
@@ -241,7 +257,7 @@ func (sh *storageClient) lookupBucketType(bucketName string) (*gcs.BucketType, e
 		return nil, err
 	}
 
-	logger.Infof("GetStorageLayout -> (%s) %v msec", bucketName, duration.Milliseconds())
+	logger.GCS.Infof("GetStorageLayout -> (%s) %v msec", bucketName, duration.Milliseconds())
 
 	return &gcs.BucketType{
 		Hierarchical: storageLayout.GetHierarchicalNamespace().GetEnabled(),
@@ -330,7 +346,7 @@ func (sh *storageClient) BucketHandle(ctx context.Context, bucketName string, bi
 	if bucketType.Zonal || sh.clientConfig.ClientProtocol == cfg.GRPC {
 		if sh.directPathDetector != nil {
 			if err := sh.directPathDetector.isDirectPathPossible(ctx, bucketName); err != nil {
-				logger.Warnf("Direct path connectivity unavailable for %s, reason: %v", bucketName, err)
+				logger.GCS.Warnf("Direct path connectivity unavailable for %s, reason: %v", bucketName, err)
 			}
 		}
 	}
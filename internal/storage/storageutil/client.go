@@ -18,6 +18,8 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,8 +41,20 @@ type StorageClientConfig struct {
 	KeyFile           string
 	TokenUrl          string
 	ReuseTokenFromUrl bool
-	MaxRetrySleep     time.Duration
-	RetryMultiplier   float64
+	CredentialProcess string
+	// ImpersonateServiceAccount, if set, is the service account whose
+	// short-lived tokens are minted using the above credentials, through the
+	// chain of ImpersonateDelegates.
+	ImpersonateServiceAccount string
+	ImpersonateDelegates      []string
+	// PerUserKeyFileDir or PerUserTokenUrl, if set, supply the credentials of
+	// each user for mounts with per-user credentials. See ForUser.
+	PerUserKeyFileDir string
+	PerUserTokenUrl   string
+	// ReadOnly restricts the token scope to read_only instead of read_write.
+	ReadOnly        bool
+	MaxRetrySleep   time.Duration
+	RetryMultiplier float64
 
 	/** HTTP client parameters. */
 	MaxConnsPerHost            int
@@ -59,6 +73,55 @@ type StorageClientConfig struct {
 	ReadStallRetryConfig cfg.ReadStallGcsRetriesConfig
 }
 
+// ForUser returns a copy of the config that authenticates as the user with the
+// given UID, using the key file <uid>.json in PerUserKeyFileDir or the token
+// url PerUserTokenUrl with "{uid}" replaced by the UID. The mount's own
+// credentials are not used.
+func (c StorageClientConfig) ForUser(uid uint32) StorageClientConfig {
+	id := strconv.FormatUint(uint64(uid), 10)
+	c.KeyFile = ""
+	c.TokenUrl = ""
+	c.CredentialProcess = ""
+	c.ImpersonateServiceAccount = ""
+	c.ImpersonateDelegates = nil
+	if c.PerUserKeyFileDir != "" {
+		c.KeyFile = filepath.Join(c.PerUserKeyFileDir, id+".json")
+	} else {
+		c.TokenUrl = strings.ReplaceAll(c.PerUserTokenUrl, "{uid}", id)
+	}
+	return c
+}
+
+// NewStorageClientConfig returns the client config for the connection and
+// retry settings in c.
+func NewStorageClientConfig(c *cfg.Config, userAgent string) StorageClientConfig {
+	return StorageClientConfig{
+		ClientProtocol:             c.GcsConnection.ClientProtocol,
+		MaxConnsPerHost:            int(c.GcsConnection.MaxConnsPerHost),
+		MaxIdleConnsPerHost:        int(c.GcsConnection.MaxIdleConnsPerHost),
+		HttpClientTimeout:          c.GcsConnection.HttpClientTimeout,
+		MaxRetrySleep:              c.GcsRetries.MaxRetrySleep,
+		MaxRetryAttempts:           int(c.GcsRetries.MaxRetryAttempts),
+		RetryMultiplier:            c.GcsRetries.Multiplier,
+		UserAgent:                  userAgent,
+		CustomEndpoint:             c.GcsConnection.CustomEndpoint,
+		KeyFile:                    string(c.GcsAuth.KeyFile),
+		AnonymousAccess:            c.GcsAuth.AnonymousAccess,
+		TokenUrl:                   c.GcsAuth.TokenUrl,
+		ReuseTokenFromUrl:          c.GcsAuth.ReuseTokenFromUrl,
+		CredentialProcess:          c.GcsAuth.CredentialProcess,
+		ImpersonateServiceAccount:  c.GcsAuth.ImpersonateServiceAccount,
+		ImpersonateDelegates:       c.GcsAuth.ImpersonateDelegates,
+		PerUserKeyFileDir:          string(c.GcsAuth.PerUserKeyFileDir),
+		PerUserTokenUrl:            c.GcsAuth.PerUserTokenUrl,
+		ReadOnly:                   cfg.IsReadOnly(c),
+		ExperimentalEnableJsonRead: c.GcsConnection.ExperimentalEnableJsonRead,
+		GrpcConnPoolSize:           int(c.GcsConnection.GrpcConnPoolSize),
+		EnableHNS:                  c.EnableHns,
+		ReadStallRetryConfig:       c.GcsRetries.ReadStall,
+	}
+}
+
 func CreateHttpClient(storageClientConfig *StorageClientConfig) (httpClient *http.Client, err error) {
 	var transport *http.Transport
 	// Using http1 makes the client more performant.
@@ -120,8 +183,25 @@ func CreateHttpClient(storageClientConfig *StorageClientConfig) (httpClient *htt
 
 // It creates the token-source from the provided
 // key-file or using ADC search order (https://cloud.google.com/docs/authentication/application-default-credentials#order).
+// The token is scoped to read_only for read-only mounts and to read_write
+// otherwise. If a service account to impersonate is configured, these
+// credentials are instead exchanged for that service account's tokens.
 func CreateTokenSource(storageClientConfig *StorageClientConfig) (tokenSrc oauth2.TokenSource, err error) {
-	return auth.GetTokenSource(context.Background(), storageClientConfig.KeyFile, storageClientConfig.TokenUrl, storageClientConfig.ReuseTokenFromUrl)
+	ctx := context.Background()
+	scope := auth.StorageScope(storageClientConfig.ReadOnly)
+	if storageClientConfig.ImpersonateServiceAccount == "" {
+		return auth.GetTokenSource(ctx, storageClientConfig.KeyFile, storageClientConfig.TokenUrl, storageClientConfig.ReuseTokenFromUrl, storageClientConfig.CredentialProcess, scope)
+	}
+
+	baseTokenSrc, err := auth.GetTokenSource(ctx, storageClientConfig.KeyFile, storageClientConfig.TokenUrl, storageClientConfig.ReuseTokenFromUrl, storageClientConfig.CredentialProcess, auth.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	exchanger, err := auth.NewIAMCredentialsTokenExchanger(ctx, baseTokenSrc)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewImpersonatedTokenSource(ctx, exchanger, storageClientConfig.ImpersonateServiceAccount, storageClientConfig.ImpersonateDelegates, scope), nil
 }
 
 // StripScheme strips the scheme part of given url.
@@ -17,7 +17,9 @@ package storageutil
 import (
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/googlecloudplatform/gcsfuse/v2/cfg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"golang.org/x/oauth2"
@@ -47,6 +49,76 @@ func (t *clientTest) validateProxyInTransport(httpClient *http.Client) {
 
 // Tests
 
+func (t *clientTest) TestNewStorageClientConfig() {
+	c := &cfg.Config{
+		EnableHns: true,
+		GcsAuth: cfg.GcsAuthConfig{
+			KeyFile:                   "key.json",
+			AnonymousAccess:           true,
+			CredentialProcess:         "get-token",
+			ImpersonateServiceAccount: "target@project.iam.gserviceaccount.com",
+			ImpersonateDelegates:      []string{"delegate@project.iam.gserviceaccount.com"},
+		},
+		FileSystem: cfg.FileSystemConfig{FuseOptions: []string{"allow_other,ro"}},
+		GcsConnection: cfg.GcsConnectionConfig{
+			ClientProtocol:   cfg.GRPC,
+			MaxConnsPerHost:  10,
+			GrpcConnPoolSize: 4,
+			CustomEndpoint:   "https://localhost:8080",
+		},
+		GcsRetries: cfg.GcsRetriesConfig{
+			MaxRetrySleep:    time.Minute,
+			MaxRetryAttempts: 3,
+			Multiplier:       1.5,
+		},
+	}
+
+	sc := NewStorageClientConfig(c, "AppName")
+
+	assert.Equal(t.T(), cfg.Protocol(cfg.GRPC), sc.ClientProtocol)
+	assert.Equal(t.T(), "AppName", sc.UserAgent)
+	assert.Equal(t.T(), "key.json", sc.KeyFile)
+	assert.True(t.T(), sc.AnonymousAccess)
+	assert.Equal(t.T(), "get-token", sc.CredentialProcess)
+	assert.Equal(t.T(), "target@project.iam.gserviceaccount.com", sc.ImpersonateServiceAccount)
+	assert.Equal(t.T(), []string{"delegate@project.iam.gserviceaccount.com"}, sc.ImpersonateDelegates)
+	assert.True(t.T(), sc.ReadOnly)
+	assert.Equal(t.T(), 10, sc.MaxConnsPerHost)
+	assert.Equal(t.T(), 4, sc.GrpcConnPoolSize)
+	assert.Equal(t.T(), "https://localhost:8080", sc.CustomEndpoint)
+	assert.Equal(t.T(), time.Minute, sc.MaxRetrySleep)
+	assert.Equal(t.T(), 3, sc.MaxRetryAttempts)
+	assert.Equal(t.T(), 1.5, sc.RetryMultiplier)
+	assert.True(t.T(), sc.EnableHNS)
+}
+
+func (t *clientTest) TestForUserWithKeyFileDir() {
+	sc := GetDefaultStorageClientConfig()
+	sc.KeyFile = "mounter.json"
+	sc.ImpersonateServiceAccount = "target@project.iam.gserviceaccount.com"
+	sc.PerUserKeyFileDir = "/etc/gcsfuse/keys"
+
+	userConfig := sc.ForUser(1000)
+
+	assert.Equal(t.T(), "/etc/gcsfuse/keys/1000.json", userConfig.KeyFile)
+	assert.Empty(t.T(), userConfig.TokenUrl)
+	assert.Empty(t.T(), userConfig.ImpersonateServiceAccount)
+	assert.Equal(t.T(), "mounter.json", sc.KeyFile)
+}
+
+func (t *clientTest) TestForUserWithTokenUrl() {
+	sc := GetDefaultStorageClientConfig()
+	sc.TokenUrl = "http://localhost/token"
+	sc.CredentialProcess = "get-token"
+	sc.PerUserTokenUrl = "http://broker/token?uid={uid}"
+
+	userConfig := sc.ForUser(1000)
+
+	assert.Equal(t.T(), "http://broker/token?uid=1000", userConfig.TokenUrl)
+	assert.Empty(t.T(), userConfig.KeyFile)
+	assert.Empty(t.T(), userConfig.CredentialProcess)
+}
+
 func (t *clientTest) TestCreateHttpClientWithHttp1() {
 	sc := GetDefaultStorageClientConfig() // By default http1 enabled
 
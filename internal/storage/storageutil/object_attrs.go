@@ -100,8 +100,21 @@ func ObjectAttrsToBucketObject(attrs *storage.ObjectAttrs) *gcs.Object {
 		ContentDisposition: attrs.ContentDisposition,
 		CustomTime:         string(attrs.CustomTime.Format(time.RFC3339)),
 		EventBasedHold:     attrs.EventBasedHold,
+		TemporaryHold:      attrs.TemporaryHold,
 		Acl:                acl,
+
+		RetentionExpirationTime: retentionExpirationTime(attrs),
+	}
+}
+
+// The later of the expiration of the bucket's retention policy and that of
+// the object's own retention.
+func retentionExpirationTime(attrs *storage.ObjectAttrs) time.Time {
+	t := attrs.RetentionExpirationTime
+	if attrs.Retention != nil && attrs.Retention.RetainUntil.After(t) {
+		t = attrs.Retention.RetainUntil
 	}
+	return t
 }
 
 func ObjectAttrsToMinObject(attrs *storage.ObjectAttrs) *gcs.MinObject {
@@ -122,6 +135,7 @@ func ObjectAttrsToMinObject(attrs *storage.ObjectAttrs) *gcs.MinObject {
 		Generation:      attrs.Generation,
 		MetaGeneration:  attrs.Metageneration,
 		Updated:         attrs.Updated,
+		Retention:       gcs.NewObjectRetention(attrs.EventBasedHold, attrs.TemporaryHold, retentionExpirationTime(attrs)),
 	}
 }
 
@@ -172,6 +186,7 @@ func ConvertObjToMinObject(o *gcs.Object) *gcs.MinObject {
 		Metadata:        o.Metadata,
 		ContentEncoding: o.ContentEncoding,
 		CRC32C:          o.CRC32C,
+		Retention:       gcs.NewObjectRetention(o.EventBasedHold, o.TemporaryHold, o.RetentionExpirationTime),
 	}
 }
 
@@ -193,7 +208,10 @@ func ConvertObjToExtendedObjectAttributes(o *gcs.Object) *gcs.ExtendedObjectAttr
 		ContentDisposition: o.ContentDisposition,
 		CustomTime:         o.CustomTime,
 		EventBasedHold:     o.EventBasedHold,
+		TemporaryHold:      o.TemporaryHold,
 		Acl:                o.Acl,
+
+		RetentionExpirationTime: o.RetentionExpirationTime,
 	}
 }
 
@@ -224,7 +242,10 @@ func ConvertMinObjectAndExtendedObjectAttributesToObject(m *gcs.MinObject,
 		ContentDisposition: e.ContentDisposition,
 		CustomTime:         e.CustomTime,
 		EventBasedHold:     e.EventBasedHold,
+		TemporaryHold:      e.TemporaryHold,
 		Acl:                e.Acl,
+
+		RetentionExpirationTime: e.RetentionExpirationTime,
 	}
 }
 
@@ -233,7 +254,7 @@ func ConvertMinObjectToObject(m *gcs.MinObject) *gcs.Object {
 		return nil
 	}
 
-	return &gcs.Object{
+	o := &gcs.Object{
 		Name:            m.Name,
 		Size:            m.Size,
 		Generation:      m.Generation,
@@ -243,4 +264,10 @@ func ConvertMinObjectToObject(m *gcs.MinObject) *gcs.Object {
 		ContentEncoding: m.ContentEncoding,
 		CRC32C:          m.CRC32C,
 	}
+	if r := m.Retention; r != nil {
+		o.EventBasedHold = r.EventBasedHold
+		o.TemporaryHold = r.TemporaryHold
+		o.RetentionExpirationTime = r.RetentionExpirationTime
+	}
+	return o
 }
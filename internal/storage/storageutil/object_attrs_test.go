@@ -137,10 +137,52 @@ func (t objectAttrsTest) TestObjectAttrsToBucketObjectMethod() {
 	ExpectEq(object.ContentDisposition, attrs.ContentDisposition)
 	ExpectEq(object.CustomTime, customeTimeExpected)
 	ExpectEq(object.EventBasedHold, attrs.EventBasedHold)
+	ExpectEq(object.TemporaryHold, attrs.TemporaryHold)
+	ExpectEq(object.RetentionExpirationTime.String(), attrs.RetentionExpirationTime.String())
 	ExpectEq(object.Acl, acl)
 	ExpectEq(object.ComponentCount, attrs.ComponentCount)
 }
 
+func (t objectAttrsTest) TestObjectAttrsToBucketObjectMethodWithObjectRetention() {
+	policyExpiration := time.Now()
+	retainUntil := policyExpiration.Add(time.Hour)
+	attrs := storage.ObjectAttrs{
+		Name:                    TestObjectName,
+		RetentionExpirationTime: policyExpiration,
+		Retention: &storage.ObjectRetention{
+			Mode:        "Locked",
+			RetainUntil: retainUntil,
+		},
+	}
+
+	object := ObjectAttrsToBucketObject(&attrs)
+
+	ExpectEq(retainUntil.String(), object.RetentionExpirationTime.String())
+}
+
+func (t objectAttrsTest) TestObjectAttrsToMinObjectKeepsRetention() {
+	policyExpiration := time.Now()
+	retainUntil := policyExpiration.Add(time.Hour)
+	attrs := storage.ObjectAttrs{
+		Name:                    TestObjectName,
+		TemporaryHold:           true,
+		RetentionExpirationTime: policyExpiration,
+		Retention: &storage.ObjectRetention{
+			Mode:        "Locked",
+			RetainUntil: retainUntil,
+		},
+	}
+
+	m := ObjectAttrsToMinObject(&attrs)
+
+	AssertNe(nil, m.Retention)
+	ExpectFalse(m.Retention.EventBasedHold)
+	ExpectTrue(m.Retention.TemporaryHold)
+	ExpectEq(retainUntil.String(), m.Retention.RetentionExpirationTime.String())
+	ExpectTrue(ConvertMinObjectToObject(m).TemporaryHold)
+	ExpectTrue(ConvertObjToMinObject(ConvertMinObjectToObject(m)).IsRetained(policyExpiration))
+}
+
 func (t objectAttrsTest) TestConvertObjectAccessControlToACLRuleMethod() {
 	objectAccessControl := &storagev1.ObjectAccessControl{
 		Entity:   "test_entity",
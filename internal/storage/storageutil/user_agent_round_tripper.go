@@ -14,7 +14,11 @@
 
 package storageutil
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/googlecloudplatform/gcsfuse/v2/internal/tracing"
+)
 
 // WithUserAgent returns a ClientOption that sets the User-Agent. This option is incompatible with the WithHTTPClient option.
 // As we are using http-client, we will need to add this header via RoundTripper middleware.
@@ -26,5 +30,8 @@ type userAgentRoundTripper struct {
 
 func (ug *userAgentRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	r.Header.Set("User-Agent", ug.UserAgent)
+	// Every attempt, including retries by the storage client, passes through
+	// here, so count it for the retry count attribute of the calling span.
+	tracing.CountAttempt(r.Context())
 	return ug.wrapped.RoundTrip(r)
 }
@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timeline records when FUSE ops, GCS requests and file cache
+// downloads begin and end, keeping the most recent of them in a ring buffer,
+// and writes them on demand as a Chrome trace. Chrome traces can be opened in
+// Perfetto (https://ui.perfetto.dev) or chrome://tracing to see which of them
+// overlapped, with no service to send them to.
+//
+// Each kind of work is a process of the trace, and the spans of each kind are
+// laid out on as few threads ("lanes") as they can be without overlapping, so
+// the number of lanes in use at any time is the concurrency at that time.
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Track is a kind of work that spans record, shown as a process of the trace.
+type Track int
+
+const (
+	// FUSE is the track of the file system ops served for the kernel.
+	FUSE Track = iota + 1
+	// GCS is the track of the requests sent to GCS, with readers open for as
+	// long as they are.
+	GCS
+	// Downloads is the track of the file cache's download jobs and of the
+	// goroutines downloading ranges for them in parallel.
+	Downloads
+)
+
+var trackNames = map[Track]string{
+	FUSE:      "FUSE ops",
+	GCS:       "GCS requests",
+	Downloads: "File cache downloads",
+}
+
+func (t Track) String() string {
+	if name, ok := trackNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Track(%d)", int(t))
+}
+
+// ErrDisabled is returned when writing the trace of a process that isn't
+// recording one.
+var ErrDisabled = errors.New("the timeline isn't being recorded")
+
+// A finished span.
+type event struct {
+	track Track
+	lane  int
+	name  string
+	start time.Time
+	end   time.Time
+	args  []any
+	err   error
+}
+
+// Recorder keeps the spans that finished most recently, and those in progress.
+type Recorder struct {
+	// The time that the timestamps of the trace are relative to.
+	origin time.Time
+
+	mu sync.Mutex
+
+	// The finished spans, a ring of those that finished last once full.
+	//
+	// GUARDED_BY(mu)
+	events  []event
+	next    int
+	dropped uint64
+
+	// The spans in progress on each lane of each track, or nil for the free
+	// lanes.
+	//
+	// GUARDED_BY(mu)
+	lanes map[Track][]*Span
+}
+
+// NewRecorder returns a recorder that keeps the given number of spans, which
+// must be positive.
+func NewRecorder(maxEvents int) *Recorder {
+	return &Recorder{
+		origin: time.Now(),
+		events: make([]event, 0, maxEvents),
+		lanes:  make(map[Track][]*Span),
+	}
+}
+
+// Span is a piece of work in progress on a track. A nil *Span records
+// nothing.
+type Span struct {
+	r     *Recorder
+	track Track
+	lane  int
+	name  string
+	start time.Time
+	args  []any
+}
+
+// Begin starts a span of the given name on the track, with arguments given as
+// alternating keys and values, as for the logger. The span must be ended.
+//
+// LOCKS_EXCLUDED(r.mu)
+func (r *Recorder) Begin(track Track, name string, args ...any) *Span {
+	s := &Span{r: r, track: track, name: name, start: time.Now(), args: args}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lanes := r.lanes[track]
+	s.lane = len(lanes)
+	for i, other := range lanes {
+		if other == nil {
+			s.lane = i
+			break
+		}
+	}
+	if s.lane == len(lanes) {
+		r.lanes[track] = append(lanes, s)
+	} else {
+		lanes[s.lane] = s
+	}
+
+	return s
+}
+
+// End finishes the span, recording the error it failed with, if any.
+//
+// LOCKS_EXCLUDED(s.r.mu)
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	r := s.r
+	e := event{track: s.track, lane: s.lane, name: s.name, start: s.start, end: time.Now(), args: s.args, err: err}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lanes[s.track][s.lane] = nil
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, e)
+		return
+	}
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	r.dropped++
+}
+
+////////////////////////////////////////////////////////////////////////
+// Chrome trace
+////////////////////////////////////////////////////////////////////////
+
+// An event of the Chrome trace event format, see
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU.
+// Timestamps and durations are in microseconds.
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    float64        `json:"ts"`
+	Dur   float64        `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+type trace struct {
+	TraceEvents     []traceEvent   `json:"traceEvents"`
+	DisplayTimeUnit string         `json:"displayTimeUnit"`
+	OtherData       map[string]any `json:"otherData"`
+}
+
+func (r *Recorder) micros(t time.Time) float64 {
+	return float64(t.Sub(r.origin).Nanoseconds()) / 1e3
+}
+
+func (r *Recorder) traceEvent(e event, inProgress bool) traceEvent {
+	args := make(map[string]any, len(e.args)/2+1)
+	for i := 0; i+1 < len(e.args); i += 2 {
+		args[fmt.Sprint(e.args[i])] = e.args[i+1]
+	}
+	if e.err != nil {
+		args["error"] = e.err.Error()
+	}
+	if inProgress {
+		args["inProgress"] = true
+	}
+
+	return traceEvent{
+		Name:  e.name,
+		Phase: "X",
+		TS:    r.micros(e.start),
+		// Perfetto hides events with no duration.
+		Dur:  max(r.micros(e.end)-r.micros(e.start), 0.001),
+		PID:  int(e.track),
+		TID:  e.lane,
+		Args: args,
+	}
+}
+
+// WriteTrace writes the recorded spans, and those in progress as if they
+// finished now, to w as a Chrome trace in JSON.
+//
+// LOCKS_EXCLUDED(r.mu)
+func (r *Recorder) WriteTrace(w io.Writer) error {
+	now := time.Now()
+	t := trace{
+		DisplayTimeUnit: "ms",
+		OtherData:       map[string]any{"origin": r.origin.Format(time.RFC3339Nano)},
+	}
+	for track := FUSE; track <= Downloads; track++ {
+		t.TraceEvents = append(t.TraceEvents,
+			traceEvent{Name: "process_name", Phase: "M", PID: int(track), Args: map[string]any{"name": track.String()}},
+			traceEvent{Name: "process_sort_index", Phase: "M", PID: int(track), Args: map[string]any{"sort_index": int(track)}})
+	}
+
+	r.mu.Lock()
+	// Oldest first.
+	for i := range r.events {
+		t.TraceEvents = append(t.TraceEvents, r.traceEvent(r.events[(r.next+i)%len(r.events)], false))
+	}
+	for _, lanes := range r.lanes {
+		for _, s := range lanes {
+			if s != nil {
+				t.TraceEvents = append(t.TraceEvents, r.traceEvent(event{track: s.track, lane: s.lane, name: s.name, start: s.start, end: now, args: s.args}, true))
+			}
+		}
+	}
+	t.OtherData["droppedEvents"] = r.dropped
+	r.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(t)
+}
+
+////////////////////////////////////////////////////////////////////////
+// The process's recorder
+////////////////////////////////////////////////////////////////////////
+
+var current atomic.Pointer[Recorder]
+
+// Enable starts recording the spans begun with Begin, keeping the given number
+// of the most recent ones, which must be positive.
+func Enable(maxEvents int) {
+	current.Store(NewRecorder(maxEvents))
+}
+
+// Disable stops recording, discarding the spans recorded so far.
+func Disable() {
+	current.Store(nil)
+}
+
+// Begin starts a span on the track, as Recorder.Begin does, or returns nil if
+// the timeline isn't being recorded.
+func Begin(track Track, name string, args ...any) *Span {
+	r := current.Load()
+	if r == nil {
+		return nil
+	}
+	return r.Begin(track, name, args...)
+}
+
+// WriteTrace writes the spans recorded since Enable to w as a Chrome trace,
+// as Recorder.WriteTrace does, or returns ErrDisabled if there are none.
+func WriteTrace(w io.Writer) error {
+	r := current.Load()
+	if r == nil {
+		return ErrDisabled
+	}
+	return r.WriteTrace(w)
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTrace(t *testing.T, r *Recorder) (events []traceEvent, otherData map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteTrace(&buf))
+
+	var tr trace
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &tr))
+	for _, e := range tr.TraceEvents {
+		if e.Phase != "M" {
+			events = append(events, e)
+		}
+	}
+	return events, tr.OtherData
+}
+
+func TestRecorder_Lanes(t *testing.T) {
+	r := NewRecorder(10)
+
+	a := r.Begin(FUSE, "ReadFile")
+	b := r.Begin(FUSE, "ReadFile")
+	c := r.Begin(GCS, "Read")
+	a.End(nil)
+	d := r.Begin(FUSE, "LookUpInode")
+
+	assert.Equal(t, 0, a.lane)
+	assert.Equal(t, 1, b.lane)
+	// Each track has its own lanes.
+	assert.Equal(t, 0, c.lane)
+	// Freed lanes are reused.
+	assert.Equal(t, 0, d.lane)
+}
+
+func TestRecorder_WriteTrace(t *testing.T) {
+	r := NewRecorder(10)
+	r.Begin(Downloads, "DownloadJob", "object", "a/b").End(errors.New("cancelled"))
+	r.Begin(GCS, "StatObject").End(nil)
+	r.Begin(FUSE, "ReadFile", "inode", 5)
+
+	events, otherData := readTrace(t, r)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, "DownloadJob", events[0].Name)
+	assert.Equal(t, "X", events[0].Phase)
+	assert.Equal(t, int(Downloads), events[0].PID)
+	assert.Equal(t, map[string]any{"object": "a/b", "error": "cancelled"}, events[0].Args)
+	assert.Positive(t, events[0].Dur)
+	assert.Equal(t, "StatObject", events[1].Name)
+	assert.Equal(t, int(GCS), events[1].PID)
+	assert.Nil(t, events[1].Args)
+	assert.GreaterOrEqual(t, events[1].TS, events[0].TS)
+	// Spans in progress are written as if they finished now.
+	assert.Equal(t, "ReadFile", events[2].Name)
+	assert.Equal(t, map[string]any{"inode": float64(5), "inProgress": true}, events[2].Args)
+	assert.Equal(t, float64(0), otherData["droppedEvents"])
+}
+
+func TestRecorder_KeepsMostRecent(t *testing.T) {
+	r := NewRecorder(2)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		r.Begin(FUSE, name).End(nil)
+	}
+
+	events, otherData := readTrace(t, r)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "d", events[0].Name)
+	assert.Equal(t, "e", events[1].Name)
+	assert.Equal(t, float64(3), otherData["droppedEvents"])
+}
+
+func TestBegin_Disabled(t *testing.T) {
+	Disable()
+
+	s := Begin(FUSE, "ReadFile")
+	s.End(nil)
+
+	assert.Nil(t, s)
+	assert.ErrorIs(t, WriteTrace(&bytes.Buffer{}), ErrDisabled)
+}
+
+func TestBegin_Enabled(t *testing.T) {
+	Enable(10)
+	t.Cleanup(Disable)
+
+	Begin(GCS, "ListObjects").End(nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTrace(&buf))
+	assert.Contains(t, buf.String(), `"name":"ListObjects"`)
+	assert.Contains(t, buf.String(), `"args":{"name":"GCS requests"}`)
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides helpers to create the child spans that describe
+// the work done on behalf of a file system op: bucket calls, cache lookups,
+// download jobs and upload chunks.
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer used for all the spans created by
+// gcsfuse.
+const TracerName = "cloud.google.com/gcsfuse"
+
+// Attribute keys set on child spans.
+const (
+	ObjectKey       = attribute.Key("gcs.object")
+	DestinationKey  = attribute.Key("gcs.destination")
+	PrefixKey       = attribute.Key("gcs.prefix")
+	GenerationKey   = attribute.Key("gcs.generation")
+	OffsetKey       = attribute.Key("gcs.range.offset")
+	LengthKey       = attribute.Key("gcs.range.length")
+	CacheHitKey     = attribute.Key("cache.hit")
+	RetryCountKey   = attribute.Key("gcs.retry_count")
+	BlockIndexKey   = attribute.Key("upload.block_index")
+	SyncStrategyKey = attribute.Key("sync.strategy")
+)
+
+// StartSpan starts a span as a child of the span in ctx, if any. The returned
+// context carries the new span.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartLinkedSpan starts a new root span for background work that outlives the
+// op which triggered it. The new span is linked to the span in ctx, if any, so
+// that the two traces can be correlated. The returned context is derived from
+// context.Background() and is not cancelled along with ctx.
+func StartLinkedSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return StartSpanLinkedTo(trace.LinkFromContext(ctx), name, attrs...)
+}
+
+// StartSpanLinkedTo is like StartLinkedSpan, for callers which kept the link
+// to the triggering op rather than its context.
+func StartSpanLinkedTo(link trace.Link, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithNewRoot(), trace.WithAttributes(attrs...)}
+	if link.SpanContext.IsValid() {
+		opts = append(opts, trace.WithLinks(link))
+	}
+	return otel.Tracer(TracerName).Start(context.Background(), name, opts...)
+}
+
+// EndSpan records err, if not nil, on the span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ObjectAttrs returns the attributes identifying an object generation.
+func ObjectAttrs(name string, generation int64) []attribute.KeyValue {
+	return []attribute.KeyValue{ObjectKey.String(name), GenerationKey.Int64(generation)}
+}
+
+// RangeAttrs returns the attributes describing a byte range.
+func RangeAttrs(offset, length int64) []attribute.KeyValue {
+	return []attribute.KeyValue{OffsetKey.Int64(offset), LengthKey.Int64(length)}
+}
+
+type attemptCounterKey struct{}
+
+// WithAttemptCounter returns a context in which every request attempt made by
+// the storage transport is counted. The count is retrieved with Attempts.
+func WithAttemptCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptCounterKey{}, new(atomic.Int64))
+}
+
+// CountAttempt records a request attempt made with ctx. It is a no-op if ctx
+// was not created by WithAttemptCounter.
+func CountAttempt(ctx context.Context) {
+	if c, ok := ctx.Value(attemptCounterKey{}).(*atomic.Int64); ok {
+		c.Add(1)
+	}
+}
+
+// Attempts returns the number of request attempts counted in ctx.
+func Attempts(ctx context.Context) int64 {
+	if c, ok := ctx.Value(attemptCounterKey{}).(*atomic.Int64); ok {
+		return c.Load()
+	}
+	return 0
+}
+
+// SetRetryCount records on span the number of retries made with ctx, i.e. the
+// attempts beyond the first one.
+func SetRetryCount(ctx context.Context, span trace.Span) {
+	if n := Attempts(ctx); n > 1 {
+		span.SetAttributes(RetryCountKey.Int64(n - 1))
+	} else {
+		span.SetAttributes(RetryCountKey.Int64(0))
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setUpRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestStartSpan_ChildOfSpanInContext(t *testing.T) {
+	recorder := setUpRecorder(t)
+	ctx, parent := StartSpan(context.Background(), "parent")
+
+	_, child := StartSpan(ctx, "child", ObjectAttrs("foo", 7)...)
+	EndSpan(child, nil)
+	EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), ObjectKey.String("foo"))
+	assert.Contains(t, spans[0].Attributes(), GenerationKey.Int64(7))
+}
+
+func TestStartLinkedSpan_NewRootLinkedToOp(t *testing.T) {
+	recorder := setUpRecorder(t)
+	opCtx, cancel := context.WithCancel(context.Background())
+	opCtx, op := StartSpan(opCtx, "op")
+
+	bgCtx, bg := StartLinkedSpan(opCtx, "background")
+	EndSpan(op, nil)
+	cancel()
+
+	assert.NoError(t, bgCtx.Err())
+	EndSpan(bg, nil)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.False(t, spans[1].Parent().IsValid())
+	assert.NotEqual(t, op.SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	require.Len(t, spans[1].Links(), 1)
+	assert.Equal(t, op.SpanContext().SpanID(), spans[1].Links()[0].SpanContext.SpanID())
+}
+
+func TestStartLinkedSpan_NoSpanInContext(t *testing.T) {
+	recorder := setUpRecorder(t)
+
+	_, span := StartLinkedSpan(context.Background(), "background")
+	EndSpan(span, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Empty(t, spans[0].Links())
+}
+
+func TestEndSpan_RecordsError(t *testing.T) {
+	recorder := setUpRecorder(t)
+
+	_, span := StartSpan(context.Background(), "failed")
+	EndSpan(span, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+}
+
+func TestSetRetryCount(t *testing.T) {
+	recorder := setUpRecorder(t)
+	ctx := WithAttemptCounter(context.Background())
+	CountAttempt(ctx)
+	CountAttempt(ctx)
+	CountAttempt(ctx)
+
+	_, span := StartSpan(ctx, "retried")
+	SetRetryCount(ctx, span)
+	EndSpan(span, nil)
+
+	assert.Equal(t, int64(3), Attempts(ctx))
+	assert.Contains(t, recorder.Ended()[0].Attributes(), RetryCountKey.Int64(2))
+}
+
+func TestCountAttempt_WithoutCounter(t *testing.T) {
+	ctx := context.Background()
+
+	CountAttempt(ctx)
+
+	assert.Equal(t, int64(0), Attempts(ctx))
+}
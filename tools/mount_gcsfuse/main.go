@@ -140,8 +140,9 @@ func makeGcsfuseArgs(
 		}
 	}
 
-	// Set the bucket and mount point.
-	return append(args, device, mountPoint), nil
+	// Set the bucket and mount point, after "--" so that a bucket named like a
+	// gcsfuse subcommand, e.g. "bench", is mounted rather than run.
+	return append(args, "--", device, mountPoint), nil
 }
 
 // Parse the supplied command-line arguments from a mount(8) invocation on OS X